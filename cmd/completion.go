@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/kuderr/deepwiki/pkg/embedding"
+	"github.com/kuderr/deepwiki/pkg/embeddings"
+	"github.com/kuderr/deepwiki/pkg/generator"
+	"github.com/kuderr/deepwiki/pkg/llm"
+	"github.com/kuderr/deepwiki/pkg/output"
+	"github.com/kuderr/deepwiki/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+// completionCmd represents the completion command
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish|powershell]",
+	Short: "Generate a shell completion script",
+	Long: `Generate a completion script for the given shell.
+
+Bash:
+  source <(deepwiki completion bash)
+  # or install it permanently:
+  deepwiki completion bash > /etc/bash_completion.d/deepwiki
+
+Zsh:
+  deepwiki completion zsh > "${fpath[1]}/_deepwiki"
+
+Fish:
+  deepwiki completion fish > ~/.config/fish/completions/deepwiki.fish
+
+PowerShell:
+  deepwiki completion powershell | Out-String | Invoke-Expression`,
+	DisableFlagsInUseLine: true,
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	RunE:                  runCompletion,
+}
+
+func runCompletion(cmd *cobra.Command, args []string) error {
+	out := cmd.OutOrStdout()
+	root := cmd.Root()
+
+	var err error
+	switch args[0] {
+	case "bash":
+		err = root.GenBashCompletionV2(out, true)
+	case "zsh":
+		err = root.GenZshCompletion(out)
+	case "fish":
+		err = root.GenFishCompletion(out, true)
+	case "powershell":
+		err = root.GenPowerShellCompletionWithDesc(out)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to generate %s completion: %w", args[0], err)
+	}
+
+	return nil
+}
+
+// completeFormats completes --format with the registered output formats
+func completeFormats(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	manager := output.NewOutputManager()
+
+	var completions []string
+	for _, format := range manager.ListFormats() {
+		description, _ := manager.GetFormatDescription(format)
+		completions = append(completions, string(format)+"\t"+description)
+	}
+	sort.Strings(completions)
+
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeGenerationModes completes --mode with the supported generation modes
+func completeGenerationModes(
+	cmd *cobra.Command,
+	args []string,
+	toComplete string,
+) ([]string, cobra.ShellCompDirective) {
+	var completions []string
+	for _, mode := range generator.GenerationModes {
+		completions = append(completions, string(mode))
+	}
+
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeLanguages completes --language with the supported language names and codes
+func completeLanguages(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var completions []string
+	for _, language := range types.LanguageValues() {
+		completions = append(completions, language.String(), language.Code()+"\t"+language.String())
+	}
+
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeSimilarityMetrics completes --similarity-metric with the supported metrics
+func completeSimilarityMetrics(
+	cmd *cobra.Command,
+	args []string,
+	toComplete string,
+) ([]string, cobra.ShellCompDirective) {
+	var completions []string
+	for _, metric := range embeddings.SimilarityMetrics {
+		completions = append(completions, string(metric))
+	}
+
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeLLMProviders completes --llm-provider with the supported LLM providers
+func completeLLMProviders(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var completions []string
+	for _, provider := range llm.GetSupportedProviders() {
+		completions = append(completions, string(provider))
+	}
+
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeEmbeddingProviders completes --embedding-provider with the supported embedding providers
+func completeEmbeddingProviders(
+	cmd *cobra.Command,
+	args []string,
+	toComplete string,
+) ([]string, cobra.ShellCompDirective) {
+	var completions []string
+	for _, provider := range embedding.GetSupportedProviders() {
+		completions = append(completions, string(provider))
+	}
+
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+}
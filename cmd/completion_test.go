@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// executeRoot runs the root command with args and returns its output
+func executeRoot(t *testing.T, args ...string) string {
+	t.Helper()
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&out)
+	rootCmd.SetArgs(args)
+	defer rootCmd.SetArgs(nil)
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("deepwiki %s failed: %v", strings.Join(args, " "), err)
+	}
+
+	return out.String()
+}
+
+func TestCompletionCommand(t *testing.T) {
+	tests := []struct {
+		shell    string
+		expected string
+	}{
+		{"bash", "# bash completion V2 for deepwiki"},
+		{"zsh", "#compdef deepwiki"},
+		{"fish", "complete -c deepwiki"},
+		{"powershell", "Register-ArgumentCompleter"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.shell, func(t *testing.T) {
+			script := executeRoot(t, "completion", test.shell)
+			if !strings.Contains(script, test.expected) {
+				t.Errorf("Expected %s completion to contain %q, got %d bytes", test.shell, test.expected, len(script))
+			}
+		})
+	}
+}
+
+func TestCompletionCommand_InvalidShell(t *testing.T) {
+	rootCmd.SetArgs([]string{"completion", "tcsh"})
+	rootCmd.SetOut(&bytes.Buffer{})
+	rootCmd.SetErr(&bytes.Buffer{})
+	defer rootCmd.SetArgs(nil)
+
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error for unsupported shell")
+	}
+}
+
+func TestGenerateFlagCompletion(t *testing.T) {
+	formats := executeRoot(t, "__complete", "generate", "--format", "")
+	for _, expected := range []string{"markdown", "json", "docusaurus3", "simple-docusaurus2"} {
+		if !strings.Contains(formats, expected) {
+			t.Errorf("Expected format completion %q, got:\n%s", expected, formats)
+		}
+	}
+
+	languages := executeRoot(t, "__complete", "generate", "--language", "")
+	for _, expected := range []string{"English", "en", "Russian", "ru"} {
+		if !strings.Contains(languages, expected) {
+			t.Errorf("Expected language completion %q, got:\n%s", expected, languages)
+		}
+	}
+}
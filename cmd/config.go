@@ -37,6 +37,10 @@ var configValidateCmd = &cobra.Command{
 	Short: "Validate a configuration file",
 	Long: `Validate that a configuration file has correct syntax and values.
 
+Without a filename, the configuration file is discovered by looking for
+deepwiki.yaml, deepwiki.yml, .deepwiki.yaml or .deepwiki.yml in the current
+directory and its parents up to the repository root, then in the home directory.
+
 Examples:
   deepwiki config validate
   deepwiki config validate myconfig.yaml`,
@@ -93,6 +97,12 @@ func runConfigValidate(cmd *cobra.Command, args []string) error {
 	// If we get here, the configuration is valid
 	fmt.Println("✅ Configuration is valid!")
 
+	if cfg.File != "" {
+		fmt.Printf("Configuration file: %s\n", cfg.File)
+	} else {
+		fmt.Println("Configuration file: none found, using defaults")
+	}
+
 	// Show some key settings
 	fmt.Printf("LLM Provider: %s\n", cfg.Providers.LLM.Provider)
 	fmt.Printf("LLM Model: %s\n", cfg.Providers.LLM.Model)
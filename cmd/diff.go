@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/kuderr/deepwiki/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+var diffNameOnly bool
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff <oldDir> <newDir>",
+	Short: "Compare two generated documentation directories",
+	Long: `Compare the output of two generation runs page by page.
+
+Added, removed and modified files are listed together with a unified diff of
+their contents. When both directories contain a manifest.json, its checksums
+are used to skip unchanged files without reading them.
+
+Examples:
+  deepwiki diff ./docs-old ./docs
+  deepwiki diff --name-only ./docs-old ./docs`,
+	Args: cobra.ExactArgs(2),
+	RunE: runDiff,
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	report, err := output.DiffOutputs(args[0], args[1])
+	if err != nil {
+		return fmt.Errorf("failed to compare outputs: %w", err)
+	}
+
+	if !report.HasChanges() {
+		fmt.Printf("✅ No changes (%d files compared)\n", report.Unchanged)
+		return nil
+	}
+
+	markers := map[output.ChangeType]string{
+		output.ChangeAdded:    "A",
+		output.ChangeRemoved:  "D",
+		output.ChangeModified: "M",
+	}
+
+	for _, change := range report.Changes {
+		fmt.Printf("%s %s\n", markers[change.Type], change.Path)
+	}
+
+	if !diffNameOnly {
+		for _, change := range report.Changes {
+			if change.Diff == "" {
+				continue
+			}
+			fmt.Println()
+			fmt.Print(change.Diff)
+		}
+	}
+
+	fmt.Printf("\n📊 %d added, %d removed, %d modified, %d unchanged\n",
+		report.Count(output.ChangeAdded),
+		report.Count(output.ChangeRemoved),
+		report.Count(output.ChangeModified),
+		report.Unchanged)
+
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().BoolVar(&diffNameOnly, "name-only", false, "List changed files without their diffs")
+}
@@ -2,24 +2,41 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"maps"
+	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/kuderr/deepwiki/internal/config"
 	"github.com/kuderr/deepwiki/internal/logging"
+	"github.com/kuderr/deepwiki/pkg/checkpoint"
+	"github.com/kuderr/deepwiki/pkg/deepwiki"
+	"github.com/kuderr/deepwiki/pkg/embedding"
 	"github.com/kuderr/deepwiki/pkg/embeddings"
 	"github.com/kuderr/deepwiki/pkg/generator"
+	"github.com/kuderr/deepwiki/pkg/gitsource"
+	"github.com/kuderr/deepwiki/pkg/llm"
 	"github.com/kuderr/deepwiki/pkg/output"
 	outputgen "github.com/kuderr/deepwiki/pkg/output/generator"
 	"github.com/kuderr/deepwiki/pkg/processor"
 	"github.com/kuderr/deepwiki/pkg/rag"
 	"github.com/kuderr/deepwiki/pkg/scanner"
+	"github.com/kuderr/deepwiki/pkg/tracing"
 	"github.com/kuderr/deepwiki/pkg/types"
+	"github.com/kuderr/deepwiki/pkg/usage"
+	"github.com/kuderr/deepwiki/pkg/watch"
 	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel"
 )
 
 var (
@@ -32,18 +49,40 @@ var (
 	excludeDirs  string
 	excludeFiles string
 	chunkSize    int
+	chunkOverlap int
+	workers      int
 	configFile   string
-	verbose      bool
+	verbose      int
+	noColor      bool
 	dryRun       bool
+	searchIndex  bool
+	dirIndexes   bool
+	siteURL      string
+	gitBranch    string
+	gitDepth     int
+	toStdout     bool
+	archive      string
+	watchMode    bool
+	resumeRun    bool
+	planOnly     bool
+	metric       string
+	debugDump    string
+
+	llmProviderName       string
+	embeddingProviderName string
+	llmBaseURL            string
+	embeddingBaseURL      string
+	generationMode        string
 )
 
 // generateCmd represents the generate command
 var generateCmd = &cobra.Command{
-	Use:   "generate [directory]",
-	Short: "Generate documentation for a local directory",
+	Use:   "generate [directory|git-url]",
+	Short: "Generate documentation for a local directory or git repository",
 	Long: `Generate comprehensive documentation for a local directory using AI.
 
 This command scans the specified directory (or current directory if not specified),
+or a shallow clone of the given git URL,
 analyzes the codebase, and generates structured documentation including:
 - Project overview and architecture
 - Code analysis and explanations
@@ -56,21 +95,86 @@ and supports multiple languages.
 Examples:
   deepwiki generate
   deepwiki generate /path/to/project
+  deepwiki generate https://github.com/org/repo --branch develop
   deepwiki generate --output-dir ./docs
-  deepwiki generate --format json --language Russian`,
+  deepwiki generate --format json --language Russian
+  deepwiki generate --mode file-per-source
+  deepwiki generate --stdout | less
+  deepwiki generate --watch
+  deepwiki generate --resume`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runGenerate,
 }
 
 func runGenerate(cmd *cobra.Command, args []string) error {
-	// Load configuration
-	cfg, err := config.LoadConfig(configFile)
+	// Load configuration, discovering the config file from the target directory
+	discoveryDir := projectPath
+	if len(args) > 0 {
+		discoveryDir = args[0]
+	}
+	if discoveryDir == "" || gitsource.IsRemoteURL(discoveryDir) {
+		discoveryDir = "."
+	}
+	cfg, err := config.LoadConfigForProject(configFile, discoveryDir)
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
 
 	// Override config with CLI flags
-	overrideConfigWithFlags(cfg, cmd)
+	if err := overrideConfigWithFlags(cfg, cmd); err != nil {
+		return err
+	}
+	if _, err := embeddings.ParseSimilarityMetric(cfg.Embeddings.Metric); err != nil {
+		return err
+	}
+
+	// Documentation is written to stdout as a single markdown document with --stdout or
+	// --output-dir -, so progress and status messages go to stderr to keep the pipe clean
+	stdoutMode := toStdout || cfg.Output.Directory == "-"
+	status, statusFile := cmd.OutOrStdout(), os.Stdout
+	outputLocation := cfg.Output.Directory
+	if stdoutMode {
+		if cmd.Flags().Changed("format") && cfg.Output.Format != string(outputgen.FormatMarkdown) {
+			return fmt.Errorf("writing to stdout only supports the markdown format, got %s", cfg.Output.Format)
+		}
+		cfg.Output.Format = string(outputgen.FormatMarkdown)
+		if cfg.Logging.Output == "stdout" {
+			cfg.Logging.Output = "stderr"
+		}
+		status, statusFile = cmd.ErrOrStderr(), os.Stderr
+		outputLocation = "stdout"
+	}
+
+	// Watch mode regenerates the output directory in place. Pages of unchanged files are reused;
+	// the LLM response cache covers the pages derived from all the others, such as the glossary.
+	if watchMode {
+		if stdoutMode {
+			return fmt.Errorf("--watch cannot be combined with writing to stdout")
+		}
+		if dryRun {
+			return fmt.Errorf("--watch cannot be combined with --dry-run")
+		}
+		if resumeRun {
+			return fmt.Errorf("--watch cannot be combined with --resume")
+		}
+		if planOnly {
+			return fmt.Errorf("--watch cannot be combined with --plan")
+		}
+		cfg.Providers.LLM.Cache.Enabled = true
+	}
+	if planOnly && dryRun {
+		return fmt.Errorf("--plan cannot be combined with --dry-run")
+	}
+
+	// File pages are written without a wiki structure to plan or a checkpoint to resume from
+	if generator.GenerationMode(cfg.Output.Mode) == generator.ModeFilePerSource {
+		if planOnly {
+			return fmt.Errorf("--plan cannot be combined with the %s mode", generator.ModeFilePerSource)
+		}
+		if resumeRun {
+			return fmt.Errorf("--resume cannot be combined with the %s mode", generator.ModeFilePerSource)
+		}
+	}
 
 	// Initialize logger
 	logger, err := logging.NewLogger(&cfg.Logging)
@@ -88,6 +192,32 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 	// Create a component-specific logger
 	genLogger := logger.WithComponent("generator")
 
+	// Trace the pipeline when a collector endpoint is configured; spans are no-ops otherwise
+	tracer, err := cfg.NewTracer()
+	if err != nil {
+		return fmt.Errorf("failed to initialize tracing: %w", err)
+	}
+	if tracer != nil {
+		ctx = tracing.ContextWithTracer(ctx, tracer)
+		// Exports in the background report their failures to the OpenTelemetry error handler
+		otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+			genLogger.LogError(ctx, "failed to export traces", err)
+		}))
+		defer func() {
+			if err := tracer.Shutdown(context.Background()); err != nil {
+				genLogger.LogError(ctx, "failed to export traces", err)
+			}
+		}()
+	}
+	ctx, runSpan := tracing.Start(ctx, "deepwiki.generate")
+	defer runSpan.End()
+
+	if cfg.File != "" {
+		genLogger.InfoContext(ctx, "loaded configuration", slog.String("file", cfg.File))
+	} else {
+		genLogger.InfoContext(ctx, "no configuration file found, using defaults")
+	}
+
 	// Determine the project path
 	if len(args) > 0 {
 		projectPath = args[0]
@@ -99,27 +229,72 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Checkpoints belong to the project source: the URL of a cloned repository, else its directory
+	checkpointSource := projectPath
+
+	// Clone remote repositories into a temporary checkout
+	if gitsource.IsRemoteURL(projectPath) {
+		if watchMode {
+			return fmt.Errorf("--watch requires a local directory, got %s", projectPath)
+		}
+		if dryRun {
+			fmt.Fprintf(status, "Dry run mode - would clone %s\n", projectPath)
+			return nil
+		}
+
+		fmt.Fprintf(status, "📥 Cloning %s...\n", projectPath)
+		genLogger.InfoContext(ctx, "cloning repository",
+			slog.String("url", projectPath),
+			slog.String("branch", gitBranch),
+			slog.Int("depth", gitDepth),
+		)
+
+		checkout, err := gitsource.Clone(ctx, projectPath, gitsource.CloneOptions{Branch: gitBranch, Depth: gitDepth})
+		if err != nil {
+			return fmt.Errorf("failed to clone repository: %w", err)
+		}
+		defer func() {
+			if err := checkout.Remove(); err != nil {
+				genLogger.LogError(ctx, "failed to clean up clone", err, slog.String("path", checkout.Path))
+			}
+		}()
+		projectPath = checkout.Path
+	}
+
 	// Convert to absolute path
 	absPath, err := filepath.Abs(projectPath)
 	if err != nil {
 		return fmt.Errorf("failed to get absolute path: %w", err)
 	}
 	projectPath = absPath
+	if !gitsource.IsRemoteURL(checkpointSource) {
+		checkpointSource = projectPath
+	}
 
 	// Validate project path exists
 	if _, err := os.Stat(projectPath); os.IsNotExist(err) {
 		return fmt.Errorf("directory does not exist: %s", projectPath)
 	}
 
+	// Expand placeholders such as {project} and {date} in the output directory
+	if !stdoutMode {
+		resolve := outputDirResolver(ctx, cfg, projectPath)
+		cfg.Output.Directory, err = config.ExpandOutputDirectory(cfg.Output.Directory, resolve)
+		if err != nil {
+			return err
+		}
+		outputLocation = cfg.Output.Directory
+	}
+
 	// Validate output directory
-	if cfg.Output.Directory != "" {
+	if cfg.Output.Directory != "" && !stdoutMode {
 		if err := os.MkdirAll(cfg.Output.Directory, 0o755); err != nil {
 			return fmt.Errorf("failed to create output directory: %w", err)
 		}
 	}
 
-	// Validate LLM provider configuration
-	if cfg.Providers.LLM.APIKey == "" {
+	// Validate LLM provider configuration; Ollama runs locally without a key
+	if cfg.Providers.LLM.APIKey == "" && cfg.Providers.LLM.Provider != string(llm.ProviderOllama) {
 		genLogger.ErrorContext(ctx, "LLM provider API key is required")
 		return fmt.Errorf(
 			"LLM provider API key is required. Set the appropriate environment variable (OPENAI_API_KEY or ANTHROPIC_API_KEY)",
@@ -128,117 +303,246 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 
 	genLogger.InfoContext(ctx, "starting documentation generation",
 		slog.String("project_path", projectPath),
-		slog.String("output_dir", cfg.Output.Directory),
+		slog.String("output_dir", outputLocation),
 		slog.String("format", cfg.Output.Format),
 		slog.String("language", cfg.Output.Language.String()),
 	)
 
-	if verbose {
-		fmt.Printf("Configuration:\n")
-		fmt.Printf("  Project Path: %s\n", projectPath)
-		fmt.Printf("  Output Dir: %s\n", cfg.Output.Directory)
-		fmt.Printf("  Format: %s\n", cfg.Output.Format)
-		fmt.Printf("  Language: %s\n", cfg.Output.Language.String())
-		fmt.Printf("  LLM Provider: %s\n", cfg.Providers.LLM.Provider)
-		fmt.Printf("  LLM Model: %s\n", cfg.Providers.LLM.Model)
-		fmt.Printf("  Embedding Provider: %s\n", cfg.Providers.Embedding.Provider)
-		fmt.Printf("  Embedding Model: %s\n", cfg.Providers.Embedding.Model)
-		fmt.Printf("  Chunk Size: %d\n", cfg.Processing.ChunkSize)
-		fmt.Printf("  Max Tokens: %d\n", cfg.Providers.LLM.MaxTokens)
-		fmt.Printf("  Temperature: %.1f\n", cfg.Providers.LLM.Temperature)
+	if verbose > 0 {
+		fmt.Fprintf(status, "Configuration:\n")
+		fmt.Fprintf(status, "  Project Path: %s\n", projectPath)
+		fmt.Fprintf(status, "  Output Dir: %s\n", outputLocation)
+		fmt.Fprintf(status, "  Format: %s\n", cfg.Output.Format)
+		fmt.Fprintf(status, "  Language: %s\n", cfg.Output.Language.String())
+		fmt.Fprintf(status, "  LLM Provider: %s\n", cfg.Providers.LLM.Provider)
+		fmt.Fprintf(status, "  LLM Model: %s\n", cfg.Providers.LLM.Model)
+		fmt.Fprintf(status, "  Embedding Provider: %s\n", cfg.Providers.Embedding.Provider)
+		fmt.Fprintf(status, "  Embedding Model: %s\n", cfg.Providers.Embedding.Model)
+		fmt.Fprintf(status, "  Chunk Size: %d\n", cfg.Processing.ChunkSize)
+		fmt.Fprintf(status, "  Max Tokens: %d\n", cfg.Providers.LLM.MaxTokens)
+		fmt.Fprintf(status, "  Temperature: %.1f\n", cfg.Providers.LLM.Temperature)
 		if len(cfg.Filters.ExcludeDirs) > 0 {
-			fmt.Printf("  Exclude Dirs: %v\n", cfg.Filters.ExcludeDirs)
+			fmt.Fprintf(status, "  Exclude Dirs: %v\n", cfg.Filters.ExcludeDirs)
 		}
 		if len(cfg.Filters.ExcludeFiles) > 0 {
-			fmt.Printf("  Exclude Files: %v\n", cfg.Filters.ExcludeFiles)
+			fmt.Fprintf(status, "  Exclude Files: %v\n", cfg.Filters.ExcludeFiles)
 		}
-		fmt.Printf("\n")
+		fmt.Fprintf(status, "\n")
 	}
 
 	if dryRun {
-		fmt.Println("Dry run mode - no documentation will be generated")
+		fmt.Fprintln(status, "Dry run mode - no documentation will be generated")
 		return nil
 	}
 
+	checkpointDir, err := checkpoint.DefaultDir(checkpointSource + "\x00" + outputLocation)
+	if err != nil {
+		return err
+	}
+	documentSet, err := processor.DefaultDocumentSetPath(projectPath, cfg.Embeddings.Namespace)
+	if err != nil {
+		return err
+	}
+
+	run := &generateRun{
+		cfg:            cfg,
+		logger:         logger,
+		genLogger:      genLogger,
+		status:         status,
+		statusFile:     statusFile,
+		outputLocation: outputLocation,
+		stdoutMode:     stdoutMode,
+		document:       cmd.OutOrStdout(),
+		checkpointDir:  checkpointDir,
+		documentSet:    documentSet,
+		resume:         resumeRun,
+	}
+	if watchMode {
+		return run.watch(ctx)
+	}
+	return run.generate(ctx)
+}
+
+// generateRun holds the settings shared by every generation of a generate command, so that
+// watch mode can run the pipeline again
+type generateRun struct {
+	cfg            *config.Config
+	logger         *logging.Logger
+	genLogger      *logging.Logger
+	status         io.Writer
+	statusFile     *os.File
+	outputLocation string
+	stdoutMode     bool
+	document       io.Writer // Receives the single markdown document in stdout mode
+	checkpointDir  string    // Where the progress of a run is recorded
+	documentSet    string    // Where the processed documents are saved for later runs
+	resume         bool      // Continue from the checkpoint of a run that did not finish
+
+	// In watch mode the checkpoint outlives each generation, and a regeneration reuses the
+	// documents, embeddings and pages of the files that did not change
+	watching  bool
+	changed   []string                      // Files changed since the last generation, nil for the first
+	documents map[string]processor.Document // Documents of the last generation by file path
+}
+
+// openCheckpoint loads the checkpoint to resume from, or starts a new one. A checkpoint that
+// cannot be started only costs the ability to resume, so the run continues without one.
+func (r *generateRun) openCheckpoint(ctx context.Context) (*checkpoint.Checkpoint, error) {
+	cfg := r.cfg
+	runKey := strings.Join([]string{
+		cfg.Providers.LLM.Provider, cfg.Providers.LLM.Model, cfg.Output.Language.String(), cfg.Output.Format,
+	}, "/")
+
+	if r.changed != nil {
+		return r.reopenCheckpoint(ctx, runKey), nil
+	}
+
+	if !r.resume {
+		cp, err := checkpoint.New(r.checkpointDir, runKey)
+		if err != nil {
+			r.genLogger.WarnContext(ctx, "failed to start checkpoint", slog.String("error", err.Error()))
+			return nil, nil
+		}
+		return cp, nil
+	}
+
+	cp, err := checkpoint.Load(r.checkpointDir, runKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resume: %w", err)
+	}
+	if cp.Phase() == checkpoint.PhaseStarted {
+		fmt.Fprintln(r.status, "♻️  No checkpoint to resume from, starting from the beginning")
+	} else {
+		fmt.Fprintf(r.status, "♻️  Resuming from checkpoint (%s): %d documents embedded, %d pages generated\n",
+			cp.Phase(), cp.Embeddings(), cp.Pages())
+	}
+	return cp, nil
+}
+
+// reopenCheckpoint loads the checkpoint of the last generation of watch mode, dropping the pages
+// of the changed files so that only those are generated again. A checkpoint that cannot be
+// loaded is replaced by a new one, which regenerates every page.
+func (r *generateRun) reopenCheckpoint(ctx context.Context, runKey string) *checkpoint.Checkpoint {
+	cp, err := checkpoint.Load(r.checkpointDir, runKey)
+	if err == nil {
+		var dropped int
+		if dropped, err = cp.DropPages(r.changed); err == nil {
+			fmt.Fprintf(r.status, "♻️  Reusing %d pages of unchanged files, regenerating %d\n", cp.Pages(), dropped)
+			return cp
+		}
+	}
+
+	r.genLogger.WarnContext(ctx, "failed to reuse the last generation", slog.String("error", err.Error()))
+	if cp, err = checkpoint.New(r.checkpointDir, runKey); err != nil {
+		r.genLogger.WarnContext(ctx, "failed to start checkpoint", slog.String("error", err.Error()))
+		return nil
+	}
+	return cp
+}
+
+// reuseDocument returns the document of the last generation for a file that did not change since,
+// for deepwiki.Options.ReuseDocument, or nil before the first generation of watch mode
+func (r *generateRun) reuseDocument() func(file scanner.FileInfo) (*processor.Document, bool) {
+	if r.documents == nil || r.changed == nil {
+		return nil
+	}
+
+	previous := r.documents
+	changed := make(map[string]bool, len(r.changed))
+	for _, path := range r.changed {
+		changed[path] = true
+	}
+	return func(file scanner.FileInfo) (*processor.Document, bool) {
+		if changed[filepath.ToSlash(file.Path)] {
+			return nil, false
+		}
+		doc, ok := previous[file.Path]
+		return &doc, ok
+	}
+}
+
+// generate runs the documentation pipeline for projectPath once
+func (r *generateRun) generate(ctx context.Context) (err error) {
+	cfg, logger, genLogger := r.cfg, r.logger, r.genLogger
+	status, statusFile, outputLocation := r.status, r.statusFile, r.outputLocation
+
 	// Start documentation generation
-	fmt.Printf("🚀 Starting documentation generation for: %s\n", projectPath)
-	fmt.Printf("📝 Output will be saved to: %s\n", cfg.Output.Directory)
-	fmt.Println()
+	fmt.Fprintf(status, "🚀 Starting documentation generation for: %s\n", projectPath)
+	fmt.Fprintf(status, "📝 Output will be saved to: %s\n", outputLocation)
+	fmt.Fprintln(status)
 
 	// Step 1: Scan directory
-	fmt.Println("📁 Scanning directory...")
+	_, scanSpan := tracing.Start(ctx, "scan", tracing.String("path", projectPath))
+	fmt.Fprintln(status, "📁 Scanning directory...")
 	genLogger.InfoContext(ctx, "starting directory scan", slog.String("path", projectPath))
 
-	scanOptions := &scanner.ScanOptions{
-		IncludeExtensions: cfg.Filters.IncludeExtensions,
-		ExcludeDirs:       cfg.Filters.ExcludeDirs,
-		ExcludeFiles:      cfg.Filters.ExcludeFiles,
-		FollowSymlinks:    false,
-		MaxDepth:          0, // unlimited
-		MaxFiles:          cfg.Processing.MaxFiles,
-		AnalyzeContent:    true,
-		MaxFileSize:       1024 * 1024, // 1MB
-		SkipBinaryFiles:   true,
-		Concurrent:        true,
-		MaxWorkers:        4,
-	}
+	indexOptions := deepwiki.Options{
+		ProjectName: filepath.Base(projectPath),
+		Logger:      genLogger.Logger,
+		Scan:        r.scanOptions(),
+		Processing:  newProcessingOptions(cfg),
+		DocumentSet: r.documentSet,
 
-	fileScanner := scanner.NewScanner(scanOptions)
-	scanResult, err := fileScanner.ScanDirectory(projectPath)
+		ReuseDocument: r.reuseDocument(),
+	}
+	// The documents of the last generation are only reused once; a failed one processes all files
+	r.documents = nil
+	scanResult, err := deepwiki.Scan(projectPath, indexOptions)
 	if err != nil {
 		genLogger.LogError(ctx, "directory scan failed", err, slog.String("path", projectPath))
+		scanSpan.RecordError(err)
+		scanSpan.End()
 		return fmt.Errorf("failed to scan directory: %w", err)
 	}
+	scanSpan.SetAttributes(
+		tracing.Int("files.total", scanResult.TotalFiles),
+		tracing.Int("files.filtered", scanResult.FilteredFiles),
+	)
+	scanSpan.End()
 
 	// Log scan results
 	logger.LogScanResult(ctx, scanResult.TotalFiles, scanResult.FilteredFiles, scanResult.ScanTime)
 
 	// Display scan results
-	fmt.Printf("✅ Directory scan completed in %v\n", scanResult.ScanTime.Round(time.Millisecond))
-	fmt.Printf("   • Found %d files in %d directories\n", scanResult.TotalFiles, scanResult.TotalDirs)
-	fmt.Printf("   • Filtered to %d relevant files\n", scanResult.FilteredFiles)
+	fmt.Fprintf(status, "✅ Directory scan completed in %v\n", scanResult.ScanTime.Round(time.Millisecond))
+	fmt.Fprintf(status, "   • Found %d files in %d directories\n", scanResult.TotalFiles, scanResult.TotalDirs)
+	fmt.Fprintf(status, "   • Filtered to %d relevant files\n", scanResult.FilteredFiles)
 
 	if len(scanResult.Errors) > 0 {
-		fmt.Printf("   • %d errors occurred during scanning\n", len(scanResult.Errors))
-		if verbose {
+		fmt.Fprintf(status, "   • %d errors occurred during scanning\n", len(scanResult.Errors))
+		if verbose > 0 {
 			for _, err := range scanResult.Errors {
-				fmt.Printf("     - %s\n", err)
+				fmt.Fprintf(status, "     - %s\n", err)
 			}
 		}
 	}
 
 	// Show file breakdown by category
-	if verbose {
-		categories := make(map[string]int)
-		languages := make(map[string]int)
-
-		for _, file := range scanResult.Files {
-			categories[file.Category]++
-			if file.Language != "" {
-				languages[file.Language]++
+	if verbose > 0 {
+		if len(scanResult.CategoryStats) > 0 {
+			fmt.Fprintf(status, "\n📊 File categories:\n")
+			for _, category := range slices.Sorted(maps.Keys(scanResult.CategoryStats)) {
+				fmt.Fprintf(status, "   • %s: %d files\n", category, scanResult.CategoryStats[category])
 			}
 		}
 
-		if len(categories) > 0 {
-			fmt.Printf("\n📊 File categories:\n")
-			for category, count := range categories {
-				fmt.Printf("   • %s: %d files\n", category, count)
+		if len(scanResult.LanguageStats) > 0 {
+			fmt.Fprintf(status, "\n🔧 Programming languages:\n")
+			for _, language := range slices.Sorted(maps.Keys(scanResult.LanguageStats)) {
+				fmt.Fprintf(status, "   • %s: %d files\n", language, scanResult.LanguageStats[language])
 			}
 		}
 
-		if len(languages) > 0 {
-			fmt.Printf("\n🔧 Programming languages:\n")
-			for language, count := range languages {
-				fmt.Printf("   • %s: %d files\n", language, count)
-			}
-		}
+		fmt.Fprintf(status, "\n📏 Lines: %d total, %d of code\n", scanResult.TotalLines, scanResult.LinesOfCode)
 	}
 
-	fmt.Println()
+	fmt.Fprintln(status)
 
 	// Initialize CLI manager for progress tracking
-	cliManager := output.NewCLIManager(genLogger.Logger, verbose, false, dryRun)
-	cliManager.StartOperation(filepath.Base(projectPath), cfg.Output.Directory)
+	cliManager := output.NewCLIManager(genLogger.Logger, verbose > 0, false, dryRun)
+	cliManager.SetOutput(status)
+	cliManager.SetColor(output.ColorEnabled(statusFile, noColor))
+	cliManager.StartOperation(filepath.Base(projectPath), outputLocation)
 
 	// Initialize LLM provider
 	llmProvider, err := cfg.GetLLMProvider()
@@ -246,6 +550,18 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 		genLogger.LogError(ctx, "failed to initialize LLM provider", err)
 		return fmt.Errorf("failed to initialize LLM provider: %w", err)
 	}
+	if debugDump != "" {
+		llmProvider, err = llm.NewDumpingProvider(llmProvider, debugDump,
+			cfg.Providers.LLM.APIKey, cfg.Providers.Embedding.APIKey)
+		if err != nil {
+			return fmt.Errorf("failed to initialize LLM provider: %w", err)
+		}
+	}
+
+	// File pages are summarized from each file alone, without embeddings or a wiki structure
+	if generator.GenerationMode(cfg.Output.Mode) == generator.ModeFilePerSource {
+		return r.generateFilePages(ctx, cliManager, scanResult.Files, llmProvider)
+	}
 
 	// Initialize embedding provider
 	embeddingProvider, err := cfg.GetEmbeddingProvider()
@@ -253,133 +569,166 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 		genLogger.LogError(ctx, "failed to initialize embedding provider", err)
 		return fmt.Errorf("failed to initialize embedding provider: %w", err)
 	}
+	embeddingUsage, err := embedding.NewUsageTrackingProvider(embeddingProvider)
+	if err != nil {
+		return fmt.Errorf("failed to initialize embedding provider: %w", err)
+	}
 
 	// Phase 2: Text Processing and Chunking
 	cliManager.StartPhase("Phase 2", "Processing and chunking files", len(scanResult.Files))
-	fmt.Println("📝 Phase 2: Processing and chunking files...")
-
-	processingOptions := processor.DefaultProcessingOptions()
-	processingOptions.ChunkSize = cfg.Processing.ChunkSize
-	processingOptions.ChunkOverlap = cfg.Processing.ChunkOverlap
+	fmt.Fprintln(status, "📝 Phase 2: Processing and chunking files...")
+	_, processSpan := tracing.Start(ctx, "process", tracing.Int("files", len(scanResult.Files)))
 
-	textProcessor := processor.NewTextProcessor(processingOptions)
-	processingResult, err := textProcessor.ProcessFiles(scanResult.Files)
+	processingResult, reloaded, err := deepwiki.LoadOrProcess(projectPath, scanResult.Files, indexOptions)
 	if err != nil {
 		cliManager.ReportError("Phase 2", err, "text processing failed")
+		processSpan.RecordError(err)
+		processSpan.End()
 		return fmt.Errorf("failed to process files: %w", err)
 	}
 
+	if reloaded {
+		fmt.Fprintf(status, "   • Files are unchanged: reusing the documents processed earlier (%s)\n",
+			indexOptions.DocumentSet)
+	}
+	if r.watching {
+		r.documents = make(map[string]processor.Document, len(processingResult.Documents))
+		for _, doc := range processingResult.Documents {
+			r.documents[doc.FilePath] = doc
+		}
+	}
+
+	processSpan.SetAttributes(
+		tracing.Bool("reloaded", reloaded),
+		tracing.Int("documents", len(processingResult.Documents)),
+		tracing.Int("chunks", processingResult.TotalChunks),
+		tracing.Int("errors", len(processingResult.Errors)),
+	)
+	processSpan.End()
 	cliManager.CompletePhase("Phase 2", len(processingResult.Documents), len(processingResult.Errors))
-	fmt.Printf("✅ Phase 2 completed: %d documents processed, %d chunks created\n",
+	fmt.Fprintf(status, "✅ Phase 2 completed: %d documents processed, %d chunks created\n",
 		len(processingResult.Documents), processingResult.TotalChunks)
 
+	// Progress is recorded from here on, so that a run that fails can continue with --resume
+	cp, err := r.openCheckpoint(ctx)
+	if err != nil {
+		return err
+	}
+	if cp != nil {
+		defer func() {
+			if err != nil && !r.watching && cp.Phase() != checkpoint.PhaseStarted {
+				fmt.Fprintln(status, "💾 Progress was saved: run the same command with --resume to continue")
+			}
+		}()
+	}
+
 	// Phase 3: Embedding Generation
 	cliManager.StartPhase("Phase 3", "Generating embeddings", processingResult.TotalChunks)
-	fmt.Println("🧠 Phase 3: Generating embeddings...")
+	fmt.Fprintln(status, "🧠 Phase 3: Generating embeddings...")
 
+	// The model and dimensions are those of the configured provider
 	embeddingConfig := embeddings.DefaultEmbeddingConfig()
-	embeddingConfig.Model = cfg.Providers.Embedding.Model
-
-	embeddingGenerator := embeddings.NewEmbeddingProviderGenerator(embeddingProvider, embeddingConfig)
-
-	// Collect all chunk texts for embedding
-	var chunkTexts []string
-	var chunkMetadata []map[string]interface{}
-
-	for _, doc := range processingResult.Documents {
-		for _, chunk := range doc.Chunks {
-			chunkTexts = append(chunkTexts, chunk.Text)
-			metadata := map[string]interface{}{
-				"document_id": doc.ID,
-				"file_path":   doc.FilePath,
-				"chunk_id":    chunk.ID,
-				"language":    doc.Language,
-				"category":    doc.Category,
-			}
-			chunkMetadata = append(chunkMetadata, metadata)
+	embeddingConfig.Model = embeddingUsage.GetModel()
+	embeddingConfig.Dimensions = embeddingUsage.GetDimensions()
+	embeddingConfig.Metric = embeddings.SimilarityMetric(cfg.Embeddings.Metric)
+	embeddingConfig.Namespace = cfg.Embeddings.Namespace
+	if embeddingConfig.OpenTimeout, err = cfg.Embeddings.OpenTimeoutDuration(); err != nil {
+		return err
+	}
+
+	indexOptions.EmbeddingProvider = embeddingUsage
+	indexOptions.Embeddings = embeddingConfig
+
+	// Documents embedded by the run being resumed are not embedded again
+	embeddingModel := fmt.Sprintf("%s:%d", embeddingConfig.Model, embeddingConfig.Dimensions)
+	if cp != nil {
+		indexOptions.Reuse = func(doc processor.Document) (*embeddings.DocumentEmbedding, bool) {
+			return cp.DocumentEmbedding(doc, embeddingModel)
 		}
 	}
 
-	embeddingVectors, err := embeddingGenerator.GenerateBatchEmbeddings(chunkTexts)
+	// Chunks of reused documents count as done
+	indexOptions.EmbedProgress = func(done, total int) {
+		current := processingResult.TotalChunks - total + done
+		cliManager.UpdatePhase(current, fmt.Sprintf("Embedded %d/%d chunks", current, processingResult.TotalChunks))
+	}
+
+	_, embedSpan := tracing.Start(ctx, "embed", tracing.String("model", cfg.Providers.Embedding.Model))
+	embedResult, err := deepwiki.Embed(processingResult.Documents, indexOptions)
 	if err != nil {
 		cliManager.ReportError("Phase 3", err, "embedding generation failed")
+		embedSpan.RecordError(err)
+		embedSpan.End()
 		return fmt.Errorf("failed to generate embeddings: %w", err)
 	}
+	if embedResult.Reused > 0 {
+		fmt.Fprintf(status, "   • %d documents reuse the embeddings of the checkpoint\n", embedResult.Reused)
+	}
 
-	cliManager.CompletePhase("Phase 3", len(embeddingVectors), 0)
-	fmt.Printf("✅ Phase 3 completed: %d embeddings generated\n", len(embeddingVectors))
+	// The skipped chunks are left out of the index; the rest of the run continues
+	if len(embedResult.Skipped) > 0 {
+		for _, skipped := range embedResult.Skipped {
+			genLogger.WarnContext(ctx, "skipped chunk that could not be embedded",
+				slog.String("file_path", skipped.FilePath),
+				slog.String("chunk_id", skipped.ChunkID),
+				slog.String("error", skipped.Err.Error()))
+		}
+		embedSpan.SetAttributes(tracing.Int("skipped", len(embedResult.Skipped)))
+		fmt.Fprintf(status, "⚠️  %d chunks could not be embedded and were skipped\n", len(embedResult.Skipped))
+	}
+
+	docEmbeddings := embedResult.Embeddings
+	if cp != nil {
+		if err := cp.SaveEmbeddings(embedResult.Embedded, embedResult.New, embeddingModel); err != nil {
+			genLogger.WarnContext(ctx, "failed to checkpoint embeddings", slog.String("error", err.Error()))
+		}
+	}
+
+	embeddedChunks := 0
+	for _, doc := range embedResult.Embedded {
+		embeddedChunks += len(doc.Chunks)
+	}
+	embedSpan.SetAttributes(tracing.Int("chunks", embeddedChunks))
+
+	embeddingCount := 0
+	for _, docEmbedding := range docEmbeddings {
+		embeddingCount += len(docEmbedding.Embeddings)
+	}
+	embedSpan.SetAttributes(tracing.Int("embeddings", embeddingCount))
+	embedSpan.End()
+	cliManager.CompletePhase("Phase 3", embeddingCount, 0)
+	fmt.Fprintf(status, "✅ Phase 3 completed: %d embeddings generated\n", embeddingCount)
 
 	// Phase 4: RAG Setup and Document Indexing
-	cliManager.StartPhase("Phase 4", "Setting up RAG and indexing documents", len(embeddingVectors))
-	fmt.Println("🔍 Phase 4: Setting up RAG and indexing documents...")
+	cliManager.StartPhase("Phase 4", "Setting up RAG and indexing documents", embeddingCount)
+	fmt.Fprintln(status, "🔍 Phase 4: Setting up RAG and indexing documents...")
+	_, indexSpan := tracing.Start(ctx, "index", tracing.Int("documents", len(processingResult.Documents)))
 
-	// Create vector database
-	vectorDB, err := embeddings.NewBoltVectorDB(embeddingConfig)
+	// Store the document embeddings in the vector database behind the RAG retriever
+	project, err := deepwiki.Open(processingResult.Documents, docEmbeddings, indexOptions)
 	if err != nil {
 		cliManager.ReportError("Phase 4", err, "failed to create vector database")
-		return fmt.Errorf("failed to create vector database: %w", err)
-	}
-
-	// Create document embeddings and store them
-	for docIndex, doc := range processingResult.Documents {
-		docEmbeddings := make([]embeddings.EmbeddingVector, 0, len(doc.Chunks))
-
-		for chunkIndex, chunk := range doc.Chunks {
-			globalIndex := docIndex*len(doc.Chunks) + chunkIndex
-			if globalIndex < len(embeddingVectors) && embeddingVectors[globalIndex] != nil {
-				embVector := embeddings.EmbeddingVector{
-					ID:        chunk.ID,
-					Vector:    embeddingVectors[globalIndex],
-					Content:   chunk.Text,
-					Dimension: len(embeddingVectors[globalIndex]),
-					Metadata: map[string]string{
-						"document_id": doc.ID,
-						"file_path":   doc.FilePath,
-						"language":    doc.Language,
-						"category":    doc.Category,
-					},
-					CreatedAt: time.Now(),
-				}
-				docEmbeddings = append(docEmbeddings, embVector)
-			}
+		indexSpan.RecordError(err)
+		indexSpan.End()
+		if errors.Is(err, embeddings.ErrMetricMismatch) {
+			return fmt.Errorf("%w (remove %s to rebuild it)", err, embeddingConfig.StoragePath)
 		}
-
-		if len(docEmbeddings) > 0 {
-			docEmbedding := &embeddings.DocumentEmbedding{
-				DocumentID:  doc.ID,
-				FilePath:    doc.FilePath,
-				Language:    doc.Language,
-				Category:    doc.Category,
-				ChunkCount:  len(docEmbeddings),
-				Embeddings:  docEmbeddings,
-				ProcessedAt: time.Now(),
-			}
-
-			err := vectorDB.Store(docEmbedding)
-			if err != nil {
-				genLogger.LogError(ctx, "failed to store document embedding", err,
-					slog.String("document_id", doc.ID))
-				continue
-			}
+		if errors.Is(err, embeddings.ErrDatabaseLocked) {
+			return fmt.Errorf("%w (wait for the other run to finish or raise embeddings.open_timeout)", err)
 		}
+		return err
 	}
+	defer project.Close()
+	ragRetriever := project.Retriever()
 
-	// Initialize RAG retriever
-	embeddingService := embeddings.NewEmbeddingService(embeddingGenerator, vectorDB, embeddingConfig)
-	ragRetriever := rag.NewDocumentRetriever(
-		embeddingService,
-		vectorDB,
-		embeddingGenerator,
-		processingResult.Documents,
-		nil,
-	)
-
-	cliManager.CompletePhase("Phase 4", len(embeddingVectors), 0)
-	fmt.Printf("✅ Phase 4 completed: %d documents indexed in vector database\n", len(embeddingVectors))
+	indexSpan.SetAttributes(tracing.Int("embeddings", embeddingCount))
+	indexSpan.End()
+	cliManager.CompletePhase("Phase 4", embeddingCount, 0)
+	fmt.Fprintf(status, "✅ Phase 4 completed: %d documents indexed in vector database\n", embeddingCount)
 
 	// Phase 5: Wiki Structure Generation
 	cliManager.StartPhase("Phase 5", "Generating wiki structure", 1)
-	fmt.Println("🏗️  Phase 5: Generating wiki structure...")
+	fmt.Fprintln(status, "🏗️  Phase 5: Generating wiki structure...")
 
 	wikiGenerator := generator.NewWikiGenerator(llmProvider, ragRetriever, genLogger.Logger)
 
@@ -391,20 +740,165 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 		Language:        cfg.Output.Language,
 		OutputFormat:    cfg.Output.Format,
 		ProgressTracker: progressTracker,
+		PageURL:         pageURL(cfg.Output.Format),
+		IncludeEnvVars:  cfg.Analysis.EnvVars,
+		IncludeTodos:    cfg.Analysis.Todos,
+		IncludeLicense:  cfg.Analysis.License,
+		IncludeCI:       cfg.Analysis.CI,
+		SummarizeCI:     cfg.Analysis.CISummary,
+		ComposeProjects: processingResult.ComposeProjects,
+
+		IncludeClassDiagram: cfg.Analysis.ClassDiagram,
+		MergeExistingDocs:   cfg.Output.MergeExistingDocs,
+		OutputDirectory:     cfg.Output.Directory,
+		OutputFiles:         previousOutputFiles(cfg.Output.Directory),
+		DedupPages:          cfg.Output.DedupPages,
+		EmbedSnippets:       cfg.Output.EmbedSnippets,
+		IncludeGlossary:     cfg.Output.Glossary,
+		ClassifyImportance:  cfg.Output.ClassifyImportance,
+		BatchPages:          cfg.Output.BatchPages,
+		PlanOnly:            planOnly,
+
+		StructurePhase: phaseSettings(&cfg.Providers.LLM, cfg.Providers.LLM.Phases.Structure),
+		PagePhase:      phaseSettings(&cfg.Providers.LLM, cfg.Providers.LLM.Phases.Page),
+		ContextWindow:  cfg.Providers.LLM.ContextWindow,
+		Retrieval: generator.RetrievalSettings{
+			MaxResults: cfg.Embeddings.TopK,
+			MinScore:   &cfg.Embeddings.MinScore,
+			Strategy:   rag.QueryType(cfg.Embeddings.Strategy),
+
+			MinResults:    cfg.Embeddings.MinResults,
+			MinScoreFloor: cfg.Embeddings.MinScoreFloor,
+		},
+	}
+	if cp != nil {
+		generationOptions.Checkpoint = cp
 	}
 
-	generationResult, err := wikiGenerator.GenerateWiki(ctx, scanResult.Files, generationOptions)
+	// Page importance classifications are kept next to the LLM response cache
+	if cfg.Output.ClassifyImportance {
+		cacheOptions, err := cfg.Providers.LLM.Cache.ToCacheOptions()
+		if err != nil {
+			return err
+		}
+		generationOptions.ImportanceCache, err = generator.LoadImportanceCache(
+			filepath.Join(cacheOptions.Directory, "importance.json"))
+		if err != nil {
+			return err
+		}
+	}
+
+	generateCtx, generateSpan := tracing.Start(ctx, "generate", tracing.String("model", cfg.Providers.LLM.Model))
+	generationResult, err := wikiGenerator.GenerateWiki(generateCtx, scanResult.Files, generationOptions)
+	if generationOptions.ImportanceCache != nil {
+		if err := generationOptions.ImportanceCache.Save(); err != nil {
+			genLogger.WarnContext(ctx, "failed to save page importance cache", slog.String("error", err.Error()))
+		}
+	}
+	llmUsage := llmProvider.GetUsageStats()
+	generateSpan.SetAttributes(
+		tracing.Int("prompt_tokens", llmUsage.PromptTokens),
+		tracing.Int("completion_tokens", llmUsage.CompletionTokens),
+	)
 	if err != nil {
 		cliManager.ReportError("Phase 5", err, "wiki generation failed")
+		generateSpan.RecordError(err)
+		generateSpan.End()
 		return fmt.Errorf("failed to generate wiki: %w", err)
 	}
 
+	generateSpan.SetAttributes(
+		tracing.Int("pages", generationResult.TotalPages),
+		tracing.Int("words", generationResult.TotalWords),
+		tracing.Int("errors", len(generationResult.Errors)),
+	)
+	generateSpan.End()
+
+	// The plan is the output; the checkpoint keeps the structure for a --resume run to write
+	if planOnly {
+		cliManager.CompletePhase("Phase 5", len(generationResult.Structure.Pages), 0)
+		fmt.Fprintf(status, "✅ Phase 5 completed: Wiki structure with %d pages planned\n\n",
+			len(generationResult.Structure.Pages))
+		if err := generator.WritePlan(r.document, generationResult.Structure); err != nil {
+			return err
+		}
+		fmt.Fprintln(status, "\n💾 Run the same command with --resume instead of --plan to write these pages")
+		printUsage(status, usage.Aggregate(llmProvider, embeddingUsage))
+		return nil
+	}
+
 	cliManager.CompletePhase("Phase 5", generationResult.TotalPages, len(generationResult.Errors))
-	fmt.Printf("✅ Phase 5 completed: Wiki structure with %d pages generated\n", generationResult.TotalPages)
+	fmt.Fprintf(status, "✅ Phase 5 completed: Wiki structure with %d pages generated\n", generationResult.TotalPages)
 
 	// Phase 6: Content Generation and Output
-	cliManager.StartPhase("Phase 6", "Generating final output", generationResult.TotalPages+1)
-	fmt.Println("📄 Phase 6: Generating final output...")
+	usageReport := usage.Aggregate(llmProvider, embeddingUsage)
+	return r.writeOutput(ctx, cliManager, "Phase 6", generationResult, usageReport, cp)
+}
+
+// generateFilePages writes one page per scanned file, with an LLM summary above its source
+func (r *generateRun) generateFilePages(
+	ctx context.Context,
+	cliManager *output.CLIManager,
+	files []scanner.FileInfo,
+	llmProvider llm.Provider,
+) error {
+	cfg, genLogger, status := r.cfg, r.genLogger, r.status
+
+	// Phase 2: File Summaries
+	cliManager.StartPhase("Phase 2", "Summarizing files", len(files))
+	fmt.Fprintln(status, "📝 Phase 2: Summarizing files...")
+
+	wikiGenerator := generator.NewWikiGenerator(llmProvider, nil, genLogger.Logger)
+	generationOptions := generator.GenerationOptions{
+		ProjectName:     filepath.Base(projectPath),
+		ProjectPath:     projectPath,
+		Language:        cfg.Output.Language,
+		OutputFormat:    cfg.Output.Format,
+		ProgressTracker: generator.NewConsoleProgressTracker(genLogger.Logger),
+		PageURL:         pageURL(cfg.Output.Format),
+		PagePhase:       phaseSettings(&cfg.Providers.LLM, cfg.Providers.LLM.Phases.Page),
+	}
+
+	generateCtx, generateSpan := tracing.Start(ctx, "generate",
+		tracing.String("model", cfg.Providers.LLM.Model), tracing.String("mode", cfg.Output.Mode))
+	generationResult, err := wikiGenerator.GenerateFilePages(generateCtx, files, generationOptions)
+	if err != nil {
+		cliManager.ReportError("Phase 2", err, "file summaries failed")
+		generateSpan.RecordError(err)
+		generateSpan.End()
+		return fmt.Errorf("failed to generate file pages: %w", err)
+	}
+	generateSpan.SetAttributes(
+		tracing.Int("pages", generationResult.TotalPages),
+		tracing.Int("errors", len(generationResult.Errors)),
+	)
+	generateSpan.End()
+
+	for _, err := range generationResult.Errors {
+		genLogger.WarnContext(ctx, "file page incomplete", slog.String("error", err.Error()))
+	}
+	cliManager.CompletePhase("Phase 2", generationResult.TotalPages, len(generationResult.Errors))
+	fmt.Fprintf(status, "✅ Phase 2 completed: %d file pages generated\n", generationResult.TotalPages)
+
+	// Phase 3: Output
+	return r.writeOutput(ctx, cliManager, "Phase 3", generationResult, usage.Aggregate(llmProvider, nil), nil)
+}
+
+// writeOutput writes the generated pages in the configured format, or as the single markdown
+// document in stdout mode, and prints the summary of the run as phase. The checkpoint, if any,
+// is removed once the output is written.
+func (r *generateRun) writeOutput(
+	ctx context.Context,
+	cliManager *output.CLIManager,
+	phase string,
+	generationResult *generator.GenerationResult,
+	usageReport usage.Report,
+	cp *checkpoint.Checkpoint,
+) error {
+	cfg, genLogger, status, outputLocation := r.cfg, r.genLogger, r.status, r.outputLocation
+
+	cliManager.StartPhase(phase, "Generating final output", generationResult.TotalPages+1)
+	fmt.Fprintf(status, "📄 %s: Generating final output...\n", phase)
 
 	// Create output manager
 	outputManager := output.NewOutputManager()
@@ -413,62 +907,365 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 	outputOptions := outputgen.OutputOptions{
 		Directory:   cfg.Output.Directory,
 		Format:      outputgen.OutputFormat(cfg.Output.Format),
-		ProjectName: generationOptions.ProjectName,
+		ProjectName: filepath.Base(projectPath),
+		ProjectPath: projectPath,
 		Language:    cfg.Output.Language,
+		ToolVersion: Version,
+		SiteURL:     cfg.Output.SiteURL,
+		BaseURL:     cfg.Output.BaseURL,
+
+		GenerateSearchIndex: cfg.Output.SearchIndex,
+		DirectoryIndexes:    cfg.Output.DirectoryIndexes,
+		EmitStructure:       cfg.Output.EmitStructure,
+		LinkPages:           cfg.Output.LinkPages,
+		Frontmatter:         outputgen.FrontmatterOptions{Fields: cfg.Output.Frontmatter},
+		MaxPageWords:        cfg.Output.MaxPageWords,
+		MaxPageBytes:        cfg.Output.MaxPageBytes,
+		TruncateLongPages:   cfg.Output.TruncateLongPages,
+		Archive:             outputgen.ArchiveFormat(cfg.Output.Archive),
+		NotionToken:         cfg.Output.Notion.Token,
+		NotionParentPageID:  cfg.Output.Notion.ParentPageID,
 	}
 
-	// Generate output files
-	outputResult, err := outputManager.GenerateOutput(generationResult.Structure, generationResult.Pages, outputOptions)
+	// Generate output files, or the single markdown document in stdout mode
+	_, outputSpan := tracing.Start(ctx, "output", tracing.String("format", cfg.Output.Format))
+	structure, pages := generationResult.Structure, generationResult.Pages
+	outputResult := &outputgen.OutputResult{OutputDir: outputLocation, GeneratedAt: time.Now()}
+	var err error
+	if r.stdoutMode {
+		err = output.WriteSingleMarkdown(r.document, structure, pages, outputOptions)
+	} else {
+		outputResult, err = outputManager.GenerateOutput(structure, pages, outputOptions)
+	}
 	if err != nil {
-		cliManager.ReportError("Phase 6", err, "output generation failed")
+		cliManager.ReportError(phase, err, "output generation failed")
+		outputSpan.RecordError(err)
+		outputSpan.End()
 		return fmt.Errorf("failed to generate output: %w", err)
 	}
 
-	cliManager.CompletePhase("Phase 6", outputResult.TotalFiles, len(outputResult.Errors))
+	outputSpan.SetAttributes(
+		tracing.Int("files", outputResult.TotalFiles),
+		tracing.Int("bytes", int(outputResult.TotalSize)),
+		tracing.Int("errors", len(outputResult.Errors)),
+	)
+	outputSpan.End()
+	cliManager.CompletePhase(phase, outputResult.TotalFiles, len(outputResult.Errors))
 	cliManager.CompleteOperation(outputResult, outputResult.Errors)
 
+	// The run is complete, so there is nothing left to resume. Watch mode keeps the checkpoint
+	// for the next generation to reuse.
+	if cp != nil && !r.watching {
+		if err := cp.Remove(); err != nil {
+			genLogger.WarnContext(ctx, "failed to remove checkpoint", slog.String("error", err.Error()))
+		}
+	}
+
 	// Show final summary
-	fmt.Printf("\n🎉 Documentation generation completed!\n")
-	fmt.Printf("📁 Output directory: %s\n", cfg.Output.Directory)
-	fmt.Printf("📄 Files generated: %d\n", outputResult.TotalFiles)
-	fmt.Printf("📝 Total pages: %d\n", generationResult.TotalPages)
-	fmt.Printf("🔤 Total words: %d\n", generationResult.TotalWords)
-	fmt.Printf("⏱️  Total processing time: %v\n", time.Since(time.Now().Add(-generationResult.ProcessingTime)))
+	fmt.Fprintf(status, "\n🎉 Documentation generation completed!\n")
+	fmt.Fprintf(status, "📁 Output directory: %s\n", outputLocation)
+	fmt.Fprintf(status, "📄 Files generated: %d\n", outputResult.TotalFiles)
+	if outputResult.ArchivePath != "" {
+		fmt.Fprintf(status, "📦 Archive: %s\n", outputResult.ArchivePath)
+	}
+	fmt.Fprintf(status, "📝 Total pages: %d\n", generationResult.TotalPages)
+	fmt.Fprintf(status, "🔤 Total words: %d\n", generationResult.TotalWords)
+	fmt.Fprintf(status, "⏱️  Total processing time: %v\n",
+		time.Since(time.Now().Add(-generationResult.ProcessingTime)))
+	printUsage(status, usageReport)
 
 	if len(outputResult.Errors) > 0 {
-		fmt.Printf("\n⚠️  %d errors occurred during generation\n", len(outputResult.Errors))
-		if verbose {
+		fmt.Fprintf(status, "\n⚠️  %d errors occurred during generation\n", len(outputResult.Errors))
+		if verbose > 0 {
 			for i, err := range outputResult.Errors {
-				fmt.Printf("  %d. %v\n", i+1, err)
+				fmt.Fprintf(status, "  %d. %v\n", i+1, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// watch generates the documentation, then regenerates it whenever files in the project change
+// until the command is interrupted. Failed generations are reported and watching continues.
+func (r *generateRun) watch(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// The checkpoint is kept between generations only
+	r.watching = true
+	defer func() {
+		if err := os.RemoveAll(r.checkpointDir); err != nil {
+			r.genLogger.WarnContext(ctx, "failed to remove checkpoint", slog.String("error", err.Error()))
+		}
+	}()
+
+	if err := r.generate(ctx); err != nil {
+		fmt.Fprintf(r.status, "\n❌ %v\n", err)
+	}
+
+	ignore, err := r.watchIgnore()
+	if err != nil {
+		return err
+	}
+	watcher, err := watch.New(projectPath, watch.Options{Ignore: ignore})
+	if err != nil {
+		return fmt.Errorf("failed to watch project: %w", err)
+	}
+
+	fmt.Fprintf(r.status, "\n👀 Watching %s for changes (press Ctrl+C to stop)\n", projectPath)
+	err = watcher.Run(ctx, func(changed []string) {
+		fmt.Fprintf(r.status, "\n🔄 %d changed files, regenerating...\n", len(changed))
+		if verbose > 0 {
+			for _, path := range changed {
+				fmt.Fprintf(r.status, "   • %s\n", path)
 			}
 		}
+		r.changed = changed
+		if err := r.generate(ctx); err != nil {
+			fmt.Fprintf(r.status, "\n❌ %v\n", err)
+		}
+		fmt.Fprintf(r.status, "\n👀 Watching %s for changes (press Ctrl+C to stop)\n", projectPath)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to watch project: %w", err)
 	}
 
+	fmt.Fprintln(r.status, "\n👋 Stopped watching")
 	return nil
 }
 
+// watchIgnore returns the paths skipped in watch mode: those the scanner ignores, and the
+// generated output so that writing it does not trigger another generation
+func (r *generateRun) watchIgnore() (func(path string, isDir bool) bool, error) {
+	outputDir, err := filepath.Abs(r.cfg.Output.Directory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve output directory: %w", err)
+	}
+	archivePath := outputDir + "." + r.cfg.Output.Archive
+
+	fileScanner := scanner.NewScanner(r.scanOptions())
+	return func(path string, isDir bool) bool {
+		if path == outputDir || path == archivePath || strings.HasPrefix(path, outputDir+string(filepath.Separator)) {
+			return true
+		}
+		if path == r.documentSet {
+			return true
+		}
+		return fileScanner.IsIgnored(path, projectPath, isDir)
+	}, nil
+}
+
+// printUsage writes the token usage and estimated cost of a run
+func printUsage(w io.Writer, report usage.Report) {
+	fmt.Fprintf(w, "🪙 Tokens: %d (LLM %d prompt + %d completion, embeddings %d)\n",
+		report.TotalTokens(), report.LLM.PromptTokens, report.LLM.CompletionTokens, report.Embedding.Tokens)
+	if report.LLM.CacheReadTokens > 0 || report.LLM.CacheWriteTokens > 0 {
+		fmt.Fprintf(w, "🗄️  Prompt cache: %d tokens read, %d written\n",
+			report.LLM.CacheReadTokens, report.LLM.CacheWriteTokens)
+	}
+	if report.LLM.Throttled > 0 {
+		fmt.Fprintf(w, "🐢 Rate limited: %d LLM requests were rejected by the provider rate limit\n",
+			report.LLM.Throttled)
+	}
+
+	embeddingCost := fmt.Sprintf("$%.4f", report.Embedding.Cost)
+	totalCost := fmt.Sprintf("$%.4f", report.TotalCost())
+	if !report.Embedding.CostKnown {
+		embeddingCost = "unknown price"
+		totalCost = "at least " + totalCost
+	}
+	fmt.Fprintf(w, "💰 Estimated cost: %s (LLM $%.4f, embeddings %s)\n", totalCost, report.LLM.Cost, embeddingCost)
+}
+
+// outputDirResolver returns the values of output directory placeholders for the project
+func outputDirResolver(ctx context.Context, cfg *config.Config, projectPath string) func(string) (string, error) {
+	return func(placeholder string) (string, error) {
+		switch placeholder {
+		case "project":
+			return filepath.Base(projectPath), nil
+		case "date":
+			return time.Now().Format("2006-01-02"), nil
+		case "format":
+			return cfg.Output.Format, nil
+		case "commit":
+			return gitsource.HeadCommit(ctx, projectPath)
+		}
+		return "", fmt.Errorf("unknown placeholder {%s}", placeholder)
+	}
+}
+
+// previousOutputFiles returns the files an earlier run wrote to the output directory, as listed
+// by its manifest, or none
+func previousOutputFiles(dir string) []string {
+	manifest, err := output.ReadManifest(dir)
+	if err != nil {
+		return nil
+	}
+
+	files := make([]string, 0, len(manifest.Files))
+	for _, entry := range manifest.Files {
+		files = append(files, filepath.Join(dir, filepath.FromSlash(entry.Path)))
+	}
+	return files
+}
+
+// scanOptions returns the scanner options of the run, which never include the saved document set
+func (r *generateRun) scanOptions() *scanner.ScanOptions {
+	options := newScanOptions(r.cfg)
+	if r.documentSet != "" {
+		options.ExcludePaths = []string{r.documentSet}
+	}
+	return options
+}
+
+// newScanOptions returns the scanner options for the configured filters
+func newScanOptions(cfg *config.Config) *scanner.ScanOptions {
+	return &scanner.ScanOptions{
+		IncludeExtensions: cfg.Filters.IncludeExtensions,
+		ExcludeDirs:       cfg.Filters.ExcludeDirs,
+		ExcludeFiles:      cfg.Filters.ExcludeFiles,
+		ExcludeSecrets:    cfg.Filters.ExcludeSecrets,
+		AllowFiles:        cfg.Filters.AllowFiles,
+		ExcludeGenerated:  cfg.Filters.ExcludeGenerated,
+		ImportanceRules:   cfg.Filters.ScannerImportanceRules(),
+		FollowSymlinks:    false,
+		MaxDepth:          0, // unlimited
+		MaxFiles:          cfg.Processing.MaxFiles,
+		AnalyzeContent:    true,
+		MaxFileSize:       maxFileSize(cfg),
+		SkipBinaryFiles:   true,
+		Concurrent:        true,
+		MaxWorkers:        workerCount(cfg),
+	}
+}
+
+// newProcessingOptions returns the text processing options for the configured chunking
+func newProcessingOptions(cfg *config.Config) *processor.ProcessingOptions {
+	processingOptions := processor.DefaultProcessingOptions()
+	processingOptions.ChunkSize = cfg.Processing.ChunkSize
+	processingOptions.ChunkOverlap = cfg.Processing.ChunkOverlap
+	processingOptions.ChunkSizes = cfg.Processing.ChunkSizes
+	processingOptions.MaxWorkers = workerCount(cfg)
+	for contentType, limit := range cfg.Processing.FileSizeLimits {
+		processingOptions.MaxFileSizeLimits[processor.ContentType(contentType)] = limit
+	}
+	return processingOptions
+}
+
+// workerCount returns the number of files scanned and processed concurrently: the configured
+// workers, or one per CPU
+func workerCount(cfg *config.Config) int {
+	if cfg.Processing.Workers > 0 {
+		return cfg.Processing.Workers
+	}
+	return runtime.NumCPU()
+}
+
+// maxFileSize returns the largest file size limit of any content type, so that the scanner
+// leaves the per-type limits to the text processor
+func maxFileSize(cfg *config.Config) int64 {
+	processingOptions := newProcessingOptions(cfg)
+
+	var largest int64
+	for _, contentType := range processor.ContentTypes {
+		largest = max(largest, processingOptions.FileSizeLimit(contentType))
+	}
+	return largest
+}
+
+// pageURL returns the location of a page in an output format
+func pageURL(format string) func(page *generator.WikiPage) string {
+	return func(page *generator.WikiPage) string {
+		return outputgen.PageURL(outputgen.OutputFormat(format), page)
+	}
+}
+
+// phaseSettings returns the generator settings of a phase, using the LLM settings it does not override
+func phaseSettings(llmConfig *config.LLMConfig, phase config.LLMPhaseConfig) generator.PhaseSettings {
+	temperature, maxTokens := llmConfig.PhaseSettings(phase)
+	return generator.PhaseSettings{Temperature: &temperature, MaxTokens: maxTokens}
+}
+
 // overrideConfigWithFlags overrides configuration values with CLI flags when provided
-func overrideConfigWithFlags(cfg *config.Config, cmd *cobra.Command) {
+func overrideConfigWithFlags(cfg *config.Config, cmd *cobra.Command) error {
+	if level := logging.LevelForVerbosity(verbose); level != "" {
+		cfg.Logging.Level = level
+	}
 	if outputDir != "" {
 		cfg.Output.Directory = outputDir
 	}
 	if format != "" {
 		cfg.Output.Format = format
 	}
+	if generationMode != "" {
+		if !slices.Contains(generator.GenerationModes, generator.GenerationMode(generationMode)) {
+			return fmt.Errorf("invalid --mode %q: expected one of %s",
+				generationMode, joinProviders(generator.GenerationModes))
+		}
+		cfg.Output.Mode = generationMode
+	}
 	if language != "" {
 		if parsedLang, err := types.ParseLanguageWithCode(language); err == nil {
 			cfg.Output.Language = parsedLang
 		} else {
-			fmt.Printf("Warning: Invalid language flag '%s', using default. %s\n", language, err.Error())
+			fmt.Fprintf(os.Stderr, "Warning: Invalid language flag '%s', using default. %s\n", language, err.Error())
 		}
 	}
 
+	// The provider comes first, so that --model and the base URLs apply to the new provider
+	if llmProviderName != "" {
+		if err := cfg.Providers.LLM.SetProvider(llmProviderName); err != nil {
+			return fmt.Errorf("invalid --llm-provider %q: expected one of %s",
+				llmProviderName, joinProviders(llm.GetSupportedProviders()))
+		}
+	}
+	if embeddingProviderName != "" {
+		if err := cfg.Providers.Embedding.SetProvider(embeddingProviderName); err != nil {
+			return fmt.Errorf("invalid --embedding-provider %q: expected one of %s",
+				embeddingProviderName, joinProviders(embedding.GetSupportedProviders()))
+		}
+	}
 	if model != "" {
 		cfg.Providers.LLM.Model = model
 	}
+	if llmBaseURL != "" {
+		if err := validateBaseURL("--base-url", llmBaseURL); err != nil {
+			return err
+		}
+		cfg.Providers.LLM.BaseURL = llmBaseURL
+	}
+	if embeddingBaseURL != "" {
+		if err := validateBaseURL("--embedding-base-url", embeddingBaseURL); err != nil {
+			return err
+		}
+		cfg.Providers.Embedding.BaseURL = embeddingBaseURL
+	}
 	if chunkSize > 0 {
 		cfg.Processing.ChunkSize = chunkSize
 	}
+	// 0 is a valid overlap, so the flag applies whenever it is given
+	if cmd.Flags().Changed("chunk-overlap") {
+		cfg.Processing.ChunkOverlap = chunkOverlap
+	}
+	if cmd.Flags().Changed("workers") {
+		cfg.Processing.Workers = workers
+	}
+	if searchIndex {
+		cfg.Output.SearchIndex = true
+	}
+	if dirIndexes {
+		cfg.Output.DirectoryIndexes = true
+	}
+	if siteURL != "" {
+		cfg.Output.SiteURL = siteURL
+	}
+	if archive != "" {
+		cfg.Output.Archive = archive
+	}
+	if metric != "" {
+		cfg.Embeddings.Metric = metric
+	}
 
 	// Handle comma-separated exclude options
 	if excludeDirs != "" {
@@ -481,6 +1278,27 @@ func overrideConfigWithFlags(cfg *config.Config, cmd *cobra.Command) {
 			cfg.Filters.ExcludeFiles = append(cfg.Filters.ExcludeFiles, strings.TrimSpace(file))
 		}
 	}
+
+	return nil
+}
+
+// joinProviders lists provider types for error messages
+func joinProviders[T ~string](providers []T) string {
+	names := make([]string, len(providers))
+	for i, provider := range providers {
+		names[i] = string(provider)
+	}
+	return strings.Join(names, ", ")
+}
+
+// validateBaseURL checks that the value of a base URL flag is an absolute http or https URL
+func validateBaseURL(flag, value string) error {
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("invalid %s %q: expected an http or https URL such as http://localhost:4000/v1",
+			flag, value)
+	}
+	return nil
 }
 
 func init() {
@@ -488,19 +1306,68 @@ func init() {
 
 	// Persistent flags
 	generateCmd.Flags().
-		StringVarP(&projectPath, "path", "p", "", "Path to the project directory (default: current directory)")
+		StringVarP(&projectPath, "path", "p", "", "Path or git URL of the project (default: current directory)")
 	generateCmd.Flags().
-		StringVarP(&outputDir, "output-dir", "o", "./docs", "Output directory for generated documentation")
+		StringVarP(&outputDir, "output-dir", "o", "./docs",
+			"Output directory for generated documentation (- writes to stdout like --stdout)")
 	generateCmd.Flags().
-		StringVarP(&format, "format", "f", "", "Output format: markdown, json, docusaurus2, docusaurus3, simple-docusaurus2, simple-docusaurus3")
+		StringVarP(&format, "format", "f", "", "Output format: markdown, json, docusaurus2, docusaurus3, "+
+			"simple-docusaurus2, simple-docusaurus3, rst, gitbook")
+	generateCmd.Flags().StringVar(&generationMode, "mode", "",
+		"Generation mode: wiki, or file-per-source for one page per file with an LLM summary")
 	generateCmd.Flags().
 		StringVarP(&language, "language", "l", "", "Language for generation: English/en, Russian/ru")
 	generateCmd.Flags().StringVarP(&model, "model", "m", "", "LLM model to use for doc generation")
+	generateCmd.Flags().StringVar(&llmProviderName, "llm-provider", "",
+		"LLM provider: openai, anthropic or ollama (overrides providers.llm.provider)")
+	generateCmd.Flags().StringVar(&embeddingProviderName, "embedding-provider", "",
+		"Embedding provider: openai, voyage, ollama or tei (overrides providers.embedding.provider)")
+	generateCmd.Flags().StringVar(&llmBaseURL, "base-url", "",
+		"LLM API base URL, e.g. an OpenAI-compatible gateway (overrides providers.llm.base_url)")
+	generateCmd.Flags().StringVar(&embeddingBaseURL, "embedding-base-url", "",
+		"Embedding API base URL (overrides providers.embedding.base_url)")
 	generateCmd.Flags().StringVar(&excludeDirs, "exclude-dirs", "", "Comma-separated list of directories to exclude")
 	generateCmd.Flags().StringVar(&excludeFiles, "exclude-files", "", "Comma-separated patterns for files to exclude")
 	generateCmd.Flags().IntVar(&chunkSize, "chunk-size", 0, "Text chunk size for embeddings")
+	generateCmd.Flags().
+		IntVar(&chunkOverlap, "chunk-overlap", 0, "Words shared by consecutive chunks (0 = no overlap)")
+	generateCmd.Flags().
+		IntVar(&workers, "workers", 0, "Files scanned and processed concurrently (default: one per CPU)")
 	generateCmd.Flags().StringVar(&configFile, "config", "", "Configuration file path")
-	generateCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output")
+	generateCmd.Flags().
+		CountVarP(&verbose, "verbose", "v", "Verbose output; repeat for more detail (-vv debug, -vvv request tracing)")
+	generateCmd.Flags().BoolVar(&noColor, "no-color", false, "Disable colored output")
 	generateCmd.Flags().
 		BoolVar(&dryRun, "dry-run", false, "Show what would be done without actually generating documentation")
+	generateCmd.Flags().
+		BoolVar(&searchIndex, "search-index", false, "Write a client-side search index (search-index.json) with the output")
+	generateCmd.Flags().
+		StringVar(&archive, "archive", "", "Pack the output directory into an archive next to it: zip, tar.gz or none")
+	generateCmd.Flags().
+		BoolVar(&toStdout, "stdout", false, "Write the documentation to stdout as a single markdown document")
+	generateCmd.Flags().
+		StringVar(&metric, "similarity-metric", "", "Retrieval similarity metric: cosine, euclidean, dot or manhattan")
+	generateCmd.Flags().
+		StringVar(&debugDump, "debug-dump", "", "Write each LLM request and response to a JSON file in this directory")
+	generateCmd.Flags().
+		BoolVar(&watchMode, "watch", false, "Regenerate the documentation whenever source files change")
+	generateCmd.Flags().
+		BoolVar(&resumeRun, "resume", false, "Continue a run that did not finish from its checkpoint")
+	generateCmd.Flags().
+		BoolVar(&planOnly, "plan", false, "Generate only the wiki structure and list the pages that would be written")
+	generateCmd.Flags().
+		BoolVar(&dirIndexes, "directory-indexes", false, "Write an index page for each top-level source directory")
+	generateCmd.Flags().
+		StringVar(&siteURL, "site-url", "", "Public URL of the docs site, used for sitemap.xml and Docusaurus config")
+	generateCmd.Flags().StringVar(&gitBranch, "branch", "", "Branch or tag to clone when generating from a git URL")
+	generateCmd.Flags().
+		IntVar(&gitDepth, "depth", gitsource.DefaultDepth, "Clone depth for git URLs (0 = full history)")
+
+	// Complete enumerated flag values
+	generateCmd.RegisterFlagCompletionFunc("format", completeFormats)
+	generateCmd.RegisterFlagCompletionFunc("mode", completeGenerationModes)
+	generateCmd.RegisterFlagCompletionFunc("language", completeLanguages)
+	generateCmd.RegisterFlagCompletionFunc("similarity-metric", completeSimilarityMetrics)
+	generateCmd.RegisterFlagCompletionFunc("llm-provider", completeLLMProviders)
+	generateCmd.RegisterFlagCompletionFunc("embedding-provider", completeEmbeddingProviders)
 }
@@ -0,0 +1,815 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kuderr/deepwiki/internal/config"
+	"github.com/kuderr/deepwiki/internal/logging"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const tracedStructureResponse = `<wiki_structure>
+  <title>Demo Wiki</title>
+  <description>Documentation for the demo project</description>
+  <pages>
+    <page>
+      <id>overview</id>
+      <title>Overview</title>
+      <description>What the project does</description>
+      <importance>high</importance>
+    </page>
+  </pages>
+</wiki_structure>`
+
+// newFakeOpenAIServer answers chat completions and embeddings like the OpenAI API
+func newFakeOpenAIServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	var mu sync.Mutex
+	chatCalls := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/chat/completions"):
+			body, _ := io.ReadAll(r.Body)
+
+			mu.Lock()
+			chatCalls++
+			content := "# Overview\n\nThe demo project prints a greeting."
+			switch {
+			case strings.Contains(string(body), "Summarize the file"):
+				content = "Prints a greeting."
+			case chatCalls == 1:
+				content = tracedStructureResponse
+			case strings.Contains(string(body), "Rate how important the page below is"):
+				content = "low"
+			}
+			mu.Unlock()
+
+			json.NewEncoder(w).Encode(map[string]any{
+				"id":     "chatcmpl-test",
+				"object": "chat.completion",
+				"model":  "gpt-4o",
+				"choices": []map[string]any{{
+					"index":         0,
+					"message":       map[string]string{"role": "assistant", "content": content},
+					"finish_reason": "stop",
+				}},
+				"usage": map[string]int{"prompt_tokens": 100, "completion_tokens": 20, "total_tokens": 120},
+			})
+		case strings.HasSuffix(r.URL.Path, "/embeddings"):
+			var request struct {
+				Input []string `json:"input"`
+			}
+			json.NewDecoder(r.Body).Decode(&request)
+
+			data := make([]map[string]any, len(request.Input))
+			for i := range request.Input {
+				data[i] = map[string]any{"object": "embedding", "index": i, "embedding": []float64{0.1, 0.2, 0.3}}
+			}
+			json.NewEncoder(w).Encode(map[string]any{
+				"object": "list",
+				"data":   data,
+				"model":  "text-embedding-3-small",
+				"usage":  map[string]int{"prompt_tokens": 10, "total_tokens": 10},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// collectedSpan is a span received by the fake collector
+type collectedSpan struct {
+	Name       string
+	Attributes map[string]string
+}
+
+// newFakeCollector records spans sent with OTLP/HTTP
+func newFakeCollector(t *testing.T) (*httptest.Server, func() map[string]collectedSpan) {
+	t.Helper()
+
+	var mu sync.Mutex
+	spans := make(map[string]collectedSpan)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			http.NotFound(w, r)
+			return
+		}
+		var request collectortrace.ExportTraceServiceRequest
+		body, err := io.ReadAll(r.Body)
+		if err == nil {
+			err = proto.Unmarshal(body, &request)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		for _, resourceSpans := range request.GetResourceSpans() {
+			for _, scopeSpans := range resourceSpans.GetScopeSpans() {
+				for _, span := range scopeSpans.GetSpans() {
+					attributes := make(map[string]string)
+					for _, attr := range span.GetAttributes() {
+						attributes[attr.GetKey()] = otlpValueString(attr.GetValue())
+					}
+					spans[span.GetName()] = collectedSpan{Name: span.GetName(), Attributes: attributes}
+				}
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() map[string]collectedSpan {
+		mu.Lock()
+		defer mu.Unlock()
+		return spans
+	}
+}
+
+// otlpValueString formats an OTLP attribute value
+func otlpValueString(value *commonpb.AnyValue) string {
+	switch v := value.GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		return v.StringValue
+	case *commonpb.AnyValue_IntValue:
+		return strconv.FormatInt(v.IntValue, 10)
+	case *commonpb.AnyValue_BoolValue:
+		return strconv.FormatBool(v.BoolValue)
+	case *commonpb.AnyValue_DoubleValue:
+		return strconv.FormatFloat(v.DoubleValue, 'g', -1, 64)
+	}
+	return ""
+}
+
+func TestRunGenerate_TracesPhases(t *testing.T) {
+	workDir := t.TempDir()
+	t.Chdir(workDir)
+
+	projectDir := filepath.Join(workDir, "project")
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatalf("Failed to create project: %v", err)
+	}
+	// Enough words per file for the processor to produce chunks
+	description := strings.Repeat("The demo project greets its users and explains how it works. ", 20)
+	files := map[string]string{
+		"main.go":   "package main\n\n// " + description + "\nfunc main() {\n\tprintln(\"hello\")\n}\n",
+		"README.md": "# Demo\n\n" + description + "\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(projectDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	openai := newFakeOpenAIServer(t)
+	collector, collectedSpans := newFakeCollector(t)
+
+	t.Setenv("HOME", workDir)
+	t.Setenv("OPENAI_API_KEY", "test-key")
+	t.Setenv("DEEPWIKI_LLM_PROVIDER", "openai")
+	t.Setenv("DEEPWIKI_LLM_BASE_URL", openai.URL)
+	t.Setenv("DEEPWIKI_EMBEDDING_PROVIDER", "openai")
+	t.Setenv("DEEPWIKI_EMBEDDING_BASE_URL", openai.URL)
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", collector.URL)
+
+	executeRoot(t, "generate", projectDir, "--output-dir", filepath.Join(workDir, "docs"), "--format", "markdown")
+
+	spans := collectedSpans()
+	expected := map[string][]string{
+		"deepwiki.generate":   nil,
+		"scan":                {"files.total", "files.filtered"},
+		"process":             {"documents", "chunks"},
+		"embed":               {"chunks", "embeddings"},
+		"index":               {"documents", "embeddings"},
+		"generate":            {"pages", "prompt_tokens", "completion_tokens"},
+		"output":              {"files", "format"},
+		"generator.structure": {"pages"},
+		"generator.page":      {"page.id", "words"},
+		"llm.chat_completion": {"prompt_tokens", "completion_tokens"},
+	}
+	for name, attributes := range expected {
+		span, ok := spans[name]
+		if !ok {
+			t.Errorf("Expected a %q span, got %v", name, spanNames(spans))
+			continue
+		}
+		for _, attribute := range attributes {
+			if _, ok := span.Attributes[attribute]; !ok {
+				t.Errorf("Expected %q span to have attribute %q, got %v", name, attribute, span.Attributes)
+			}
+		}
+	}
+
+	if tokens := spans["generate"].Attributes["prompt_tokens"]; tokens != "200" {
+		t.Errorf("Expected 200 prompt tokens over both LLM calls, got %q", tokens)
+	}
+	if pages := spans["generate"].Attributes["pages"]; pages != "1" {
+		t.Errorf("Expected 1 generated page, got %q", pages)
+	}
+}
+
+func TestRunGenerate_ClassifiesPageImportance(t *testing.T) {
+	t.Cleanup(func() { configFile, format = "", "" })
+
+	workDir := t.TempDir()
+	t.Chdir(workDir)
+
+	projectDir := filepath.Join(workDir, "project")
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatalf("Failed to create project: %v", err)
+	}
+	readme := "# Demo\n\n" + strings.Repeat("The demo project greets its users and explains how it works. ", 20)
+	if err := os.WriteFile(filepath.Join(projectDir, "README.md"), []byte(readme), 0o644); err != nil {
+		t.Fatalf("Failed to write README.md: %v", err)
+	}
+	configPath := filepath.Join(workDir, "deepwiki.yaml")
+	if err := os.WriteFile(configPath, []byte("output:\n  classify_importance: true\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	openai := newFakeOpenAIServer(t)
+	t.Setenv("HOME", workDir)
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(workDir, "cache"))
+	t.Setenv("OPENAI_API_KEY", "test-key")
+	t.Setenv("DEEPWIKI_LLM_PROVIDER", "openai")
+	t.Setenv("DEEPWIKI_LLM_BASE_URL", openai.URL)
+	t.Setenv("DEEPWIKI_EMBEDDING_PROVIDER", "openai")
+	t.Setenv("DEEPWIKI_EMBEDDING_BASE_URL", openai.URL)
+
+	docsDir := filepath.Join(workDir, "docs")
+	executeRoot(t, "generate", projectDir, "--config", configPath, "--output-dir", docsDir, "--format", "docusaurus3")
+
+	// The structure rates the overview high; the classification moves it to the low section
+	sidebar, err := os.ReadFile(filepath.Join(docsDir, "sidebars.ts"))
+	if err != nil {
+		t.Fatalf("Failed to read sidebar: %v", err)
+	}
+	lowSection := "label: '📝 Additional Information',\n      collapsed: true,\n      items: [\n        'overview',"
+	if !strings.Contains(string(sidebar), lowSection) || strings.Contains(string(sidebar), "Essential Documentation") {
+		t.Errorf("Expected the overview in the low importance section, got:\n%s", sidebar)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "cache", "deepwiki", "llm", "importance.json")); err != nil {
+		t.Errorf("Expected the classification to be cached: %v", err)
+	}
+
+	// The processed documents are saved in the cache, where the next scan does not pick them up
+	saved, _ := filepath.Glob(filepath.Join(workDir, "cache", "deepwiki", "documents", "documents-*.json"))
+	if len(saved) != 1 {
+		t.Errorf("Expected the documents to be saved in the cache directory, got %v", saved)
+	}
+	if saved, _ = filepath.Glob(filepath.Join(workDir, "documents-*.json")); len(saved) != 0 {
+		t.Errorf("Expected no documents saved in the working directory, got %v", saved)
+	}
+}
+
+func TestRunGenerate_ResumesAfterEmbeddingPhase(t *testing.T) {
+	t.Cleanup(func() {
+		outputDir = ""
+		resumeRun = false
+	})
+
+	workDir := t.TempDir()
+	t.Chdir(workDir)
+
+	projectDir := filepath.Join(workDir, "project")
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatalf("Failed to create project: %v", err)
+	}
+	readme := "# Demo\n\n" + strings.Repeat("The demo project greets its users and explains how it works. ", 20)
+	if err := os.WriteFile(filepath.Join(projectDir, "README.md"), []byte(readme), 0o644); err != nil {
+		t.Fatalf("Failed to write README.md: %v", err)
+	}
+
+	// The LLM fails until the run is resumed, so the first run dies after the embedding phase
+	openai := newFakeOpenAIServer(t)
+	target, _ := url.Parse(openai.URL)
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	var mu sync.Mutex
+	embeddingRequests, llmDown := 0, true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Retrieval embeds page queries as well; only count requests embedding the README
+		mu.Lock()
+		failing := llmDown && strings.HasSuffix(r.URL.Path, "/chat/completions")
+		if strings.HasSuffix(r.URL.Path, "/embeddings") && strings.Contains(string(body), "greets its users") {
+			embeddingRequests++
+		}
+		mu.Unlock()
+
+		if failing {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"message":"connection reset","type":"invalid_request_error"}}`))
+			return
+		}
+		proxy.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("HOME", workDir)
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(workDir, "cache"))
+	t.Setenv("OPENAI_API_KEY", "test-key")
+	t.Setenv("DEEPWIKI_LLM_PROVIDER", "openai")
+	t.Setenv("DEEPWIKI_LLM_BASE_URL", server.URL)
+	t.Setenv("DEEPWIKI_EMBEDDING_PROVIDER", "openai")
+	t.Setenv("DEEPWIKI_EMBEDDING_BASE_URL", server.URL)
+
+	docsDir := filepath.Join(workDir, "docs")
+	args := []string{"generate", projectDir, "--output-dir", docsDir}
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&out)
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	rootCmd.SetArgs(nil)
+	if err == nil {
+		t.Fatal("Expected the run to fail while the LLM is down")
+	}
+	if !strings.Contains(out.String(), "--resume to continue") {
+		t.Errorf("Expected a hint to resume, got:\n%s", out.String())
+	}
+
+	mu.Lock()
+	embedded := embeddingRequests
+	llmDown = false
+	mu.Unlock()
+	if embedded == 0 {
+		t.Fatal("Expected the first run to embed the documents")
+	}
+
+	output := executeRoot(t, append(args, "--resume")...)
+	if !strings.Contains(output, "Resuming from checkpoint (embeddings)") {
+		t.Errorf("Expected the run to resume from the embedding phase, got:\n%s", output)
+	}
+	mu.Lock()
+	if embeddingRequests != embedded {
+		t.Errorf("Expected no embedding requests when resuming, got %d more", embeddingRequests-embedded)
+	}
+	mu.Unlock()
+	if _, err := os.Stat(filepath.Join(docsDir, "pages", "overview.md")); err != nil {
+		t.Errorf("Expected the resumed run to write the pages: %v", err)
+	}
+
+	// A completed run leaves nothing to resume
+	checkpoints, _ := os.ReadDir(filepath.Join(workDir, "cache", "deepwiki", "checkpoints"))
+	if len(checkpoints) != 0 {
+		t.Errorf("Expected the checkpoint to be removed after a completed run, got %d", len(checkpoints))
+	}
+}
+
+func TestGenerateRun_WatchReusesUnchangedFiles(t *testing.T) {
+	t.Cleanup(func() { projectPath = "" })
+
+	workDir := t.TempDir()
+	t.Chdir(workDir)
+
+	projectDir := filepath.Join(workDir, "project")
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatalf("Failed to create project: %v", err)
+	}
+	description := strings.Repeat("The demo project greets its users and explains how it works. ", 20)
+	writeFile := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(projectDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	writeFile("README.md", "# Demo\n\n"+description+"\n")
+
+	// Count the requests embedding each text and generating page content
+	openai := newFakeOpenAIServer(t)
+	target, _ := url.Parse(openai.URL)
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	var mu sync.Mutex
+	embedded := make(map[string]int)
+	pageRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		mu.Lock()
+		for _, text := range []string{"greets its users", "welcomes its users"} {
+			if strings.HasSuffix(r.URL.Path, "/embeddings") && strings.Contains(string(body), text) {
+				embedded[text]++
+			}
+		}
+		if strings.HasSuffix(r.URL.Path, "/chat/completions") && strings.Contains(string(body), "page_description") {
+			pageRequests++
+		}
+		mu.Unlock()
+		proxy.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("HOME", workDir)
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(workDir, "cache"))
+	t.Setenv("OPENAI_API_KEY", "test-key")
+	t.Setenv("DEEPWIKI_LLM_PROVIDER", "openai")
+	t.Setenv("DEEPWIKI_LLM_BASE_URL", server.URL)
+	t.Setenv("DEEPWIKI_EMBEDDING_PROVIDER", "openai")
+	t.Setenv("DEEPWIKI_EMBEDDING_BASE_URL", server.URL)
+
+	cfg, err := config.LoadConfigForProject("", projectDir)
+	if err != nil {
+		t.Fatalf("LoadConfigForProject failed: %v", err)
+	}
+	cfg.Output.Directory = filepath.Join(workDir, "docs")
+	logger, err := logging.NewLogger(&cfg.Logging)
+	if err != nil {
+		t.Fatalf("NewLogger failed: %v", err)
+	}
+	defer logger.Close()
+
+	projectPath = projectDir
+	var status bytes.Buffer
+	run := &generateRun{
+		cfg:            cfg,
+		logger:         logger,
+		genLogger:      logger.WithComponent("generator"),
+		status:         &status,
+		outputLocation: cfg.Output.Directory,
+		document:       io.Discard,
+		checkpointDir:  filepath.Join(workDir, "checkpoint"),
+		watching:       true,
+	}
+	if err := run.generate(context.Background()); err != nil {
+		t.Fatalf("generate failed: %v\n%s", err, status.String())
+	}
+
+	// A new file leaves the page of the README as it is, and the README is neither processed
+	// nor embedded again, even though it was rewritten without the watcher noticing
+	writeFile("notes.txt", "Notes\n\n"+strings.ReplaceAll(description, "greets", "welcomes")+"\n")
+	writeFile("README.md", "# Demo\n\n"+description+"\nUpdated.\n")
+	run.changed = []string{"notes.txt"}
+	if err := run.generate(context.Background()); err != nil {
+		t.Fatalf("generate failed: %v\n%s", err, status.String())
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if embedded["greets its users"] != 1 || embedded["welcomes its users"] != 1 {
+		t.Errorf("Expected each file to be embedded once, got %v", embedded)
+	}
+	if pageRequests != 1 {
+		t.Errorf("Expected the page to be generated once, got %d requests", pageRequests)
+	}
+	if !strings.Contains(status.String(), "Reusing 1 pages of unchanged files, regenerating 0") {
+		t.Errorf("Expected the page to be reused, got:\n%s", status.String())
+	}
+	if _, err := os.Stat(filepath.Join(cfg.Output.Directory, "pages", "overview.md")); err != nil {
+		t.Errorf("Expected the reused page to be written: %v", err)
+	}
+}
+
+func spanNames(spans map[string]collectedSpan) []string {
+	names := make([]string, 0, len(spans))
+	for name := range spans {
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestRunGenerate_WritesMarkdownToStdout(t *testing.T) {
+	t.Cleanup(func() {
+		outputDir = ""
+		format = ""
+		toStdout = false
+	})
+
+	for _, args := range [][]string{{"--stdout"}, {"--output-dir", "-"}} {
+		t.Run(strings.Join(args, " "), func(t *testing.T) {
+			// Flag values persist between executions, so start from the defaults
+			toStdout, outputDir = false, "./docs"
+
+			workDir := t.TempDir()
+			t.Chdir(workDir)
+
+			projectDir := filepath.Join(workDir, "project")
+			if err := os.MkdirAll(projectDir, 0o755); err != nil {
+				t.Fatalf("Failed to create project: %v", err)
+			}
+			readme := "# Demo\n\n" + strings.Repeat("The demo project greets its users and explains how it works. ", 20)
+			if err := os.WriteFile(filepath.Join(projectDir, "README.md"), []byte(readme), 0o644); err != nil {
+				t.Fatalf("Failed to write README.md: %v", err)
+			}
+
+			openai := newFakeOpenAIServer(t)
+			t.Setenv("HOME", workDir)
+			t.Setenv("OPENAI_API_KEY", "test-key")
+			t.Setenv("DEEPWIKI_LLM_PROVIDER", "openai")
+			t.Setenv("DEEPWIKI_LLM_BASE_URL", openai.URL)
+			t.Setenv("DEEPWIKI_EMBEDDING_PROVIDER", "openai")
+			t.Setenv("DEEPWIKI_EMBEDDING_BASE_URL", openai.URL)
+
+			var stdout, stderr bytes.Buffer
+			rootCmd.SetOut(&stdout)
+			rootCmd.SetErr(&stderr)
+			rootCmd.SetArgs(append([]string{"generate", projectDir}, args...))
+			defer rootCmd.SetArgs(nil)
+			if err := rootCmd.Execute(); err != nil {
+				t.Fatalf("deepwiki generate failed: %v", err)
+			}
+
+			expected := "# Demo Wiki\n\nDocumentation for the demo project\n\n---\n\n" +
+				"# Overview\n\n## What the project does\n\n# Overview\n\nThe demo project prints a greeting.\n\n"
+			if stdout.String() != expected {
+				t.Errorf("Expected only the documentation on stdout, got:\n%s", stdout.String())
+			}
+			if !strings.Contains(stderr.String(), "Phase 6") {
+				t.Errorf("Expected progress messages on stderr, got:\n%s", stderr.String())
+			}
+			for _, dir := range []string{"docs", "-"} {
+				if _, err := os.Stat(filepath.Join(workDir, dir)); err == nil {
+					t.Errorf("Expected no %s directory to be created in stdout mode", dir)
+				}
+			}
+		})
+	}
+}
+
+func TestRunGenerate_FilePerSourceMode(t *testing.T) {
+	workDir := t.TempDir()
+	t.Chdir(workDir)
+	t.Cleanup(func() {
+		outputDir = ""
+		generationMode = ""
+	})
+
+	projectDir := filepath.Join(workDir, "project")
+	sources := map[string]string{
+		"README.md":   "# Demo\n\nThe demo project greets its users.\n",
+		"cmd/main.go": "package main\n\nfunc main() {\n\tprintln(\"hello\")\n}\n",
+	}
+	for name, content := range sources {
+		path := filepath.Join(projectDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	// No embeddings are needed, so the embedding API is unreachable
+	openai := newFakeOpenAIServer(t)
+	t.Setenv("HOME", workDir)
+	t.Setenv("OPENAI_API_KEY", "test-key")
+	t.Setenv("DEEPWIKI_LLM_PROVIDER", "openai")
+	t.Setenv("DEEPWIKI_LLM_BASE_URL", openai.URL)
+	t.Setenv("DEEPWIKI_EMBEDDING_PROVIDER", "openai")
+	t.Setenv("DEEPWIKI_EMBEDDING_BASE_URL", "http://127.0.0.1:1")
+
+	docsDir := filepath.Join(workDir, "docs")
+	rootCmd.SetOut(io.Discard)
+	rootCmd.SetArgs([]string{"generate", projectDir, "--mode", "file-per-source", "--output-dir", docsDir})
+	defer rootCmd.SetArgs(nil)
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("deepwiki generate failed: %v", err)
+	}
+
+	pages, err := filepath.Glob(filepath.Join(docsDir, "pages", "*.md"))
+	if err != nil || len(pages) != len(sources) {
+		t.Fatalf("Expected one page per source file, got %v (%v)", pages, err)
+	}
+	for name, source := range sources {
+		slug := strings.NewReplacer("/", "-", ".", "-").Replace(strings.ToLower(name))
+		content, err := os.ReadFile(filepath.Join(docsDir, "pages", slug+".md"))
+		if err != nil {
+			t.Errorf("Expected a page for %s: %v", name, err)
+			continue
+		}
+		if !strings.Contains(string(content), "## Summary\n\nPrints a greeting.\n") ||
+			!strings.Contains(string(content), strings.TrimSuffix(source, "\n")) {
+			t.Errorf("Expected the summary and source of %s, got:\n%s", name, content)
+		}
+	}
+}
+
+func TestRunGenerate_ExpandsOutputDirPlaceholders(t *testing.T) {
+	workDir := t.TempDir()
+	t.Chdir(workDir)
+	t.Cleanup(func() {
+		dryRun = false
+		outputDir = ""
+		format = ""
+	})
+
+	projectDir := filepath.Join(workDir, "demo")
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatalf("Failed to create project: %v", err)
+	}
+
+	t.Setenv("HOME", workDir)
+	t.Setenv("OPENAI_API_KEY", "test-key")
+
+	template := filepath.Join(workDir, "wikis", "{project}", "{format}-{date}")
+	executeRoot(t, "generate", projectDir, "--output-dir", template, "--format", "json", "--dry-run")
+
+	expected := filepath.Join(workDir, "wikis", "demo", "json-"+time.Now().Format("2006-01-02"))
+	if info, err := os.Stat(expected); err != nil || !info.IsDir() {
+		t.Errorf("Expected output directory %s to be created: %v", expected, err)
+	}
+}
+
+func TestRunGenerate_WatchRejectsIncompatibleModes(t *testing.T) {
+	t.Cleanup(func() {
+		watchMode, toStdout, dryRun, resumeRun, planOnly = false, false, false, false, false
+		outputDir = ""
+	})
+
+	workDir := t.TempDir()
+	t.Chdir(workDir)
+	t.Setenv("HOME", workDir)
+
+	tests := map[string][]string{
+		"resume":     {"generate", workDir, "--watch", "--resume"},
+		"stdout":     {"generate", workDir, "--watch", "--stdout"},
+		"dry run":    {"generate", workDir, "--watch", "--dry-run"},
+		"plan":       {"generate", workDir, "--watch", "--plan"},
+		"remote url": {"generate", "https://github.com/org/repo", "--watch"},
+	}
+	for name, args := range tests {
+		t.Run(name, func(t *testing.T) {
+			watchMode, toStdout, dryRun, resumeRun, planOnly = false, false, false, false, false
+			outputDir = "./docs"
+
+			var out bytes.Buffer
+			rootCmd.SetOut(&out)
+			rootCmd.SetErr(&out)
+			rootCmd.SetArgs(args)
+			defer rootCmd.SetArgs(nil)
+
+			err := rootCmd.Execute()
+			if err == nil || !strings.Contains(err.Error(), "--watch") {
+				t.Errorf("Expected a --watch error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestOverrideConfigWithFlags_BaseURLs(t *testing.T) {
+	t.Cleanup(func() { llmBaseURL, embeddingBaseURL = "", "" })
+
+	llmBaseURL = "http://localhost:4000/v1"
+	embeddingBaseURL = "https://gateway.example.com/openai"
+	cfg := config.DefaultConfig()
+	if err := overrideConfigWithFlags(cfg, generateCmd); err != nil {
+		t.Fatalf("overrideConfigWithFlags failed: %v", err)
+	}
+
+	llmConfig, err := cfg.Providers.LLM.ToLLMConfig()
+	if err != nil {
+		t.Fatalf("ToLLMConfig failed: %v", err)
+	}
+	if llmConfig.BaseURL != llmBaseURL {
+		t.Errorf("Expected LLM base URL %s, got %s", llmBaseURL, llmConfig.BaseURL)
+	}
+	embeddingConfig, err := cfg.Providers.Embedding.ToEmbeddingConfig()
+	if err != nil {
+		t.Fatalf("ToEmbeddingConfig failed: %v", err)
+	}
+	if embeddingConfig.BaseURL != embeddingBaseURL {
+		t.Errorf("Expected embedding base URL %s, got %s", embeddingBaseURL, embeddingConfig.BaseURL)
+	}
+}
+
+func TestOverrideConfigWithFlags_Providers(t *testing.T) {
+	t.Cleanup(func() { llmProviderName, embeddingProviderName = "", "" })
+	t.Setenv("OPENAI_API_KEY", "openai-key")
+	t.Setenv("ANTHROPIC_API_KEY", "anthropic-key")
+	t.Setenv("VOYAGE_API_KEY", "voyage-key")
+
+	llmProviderName, embeddingProviderName = "anthropic", "voyage"
+	cfg := config.DefaultConfig()
+	cfg.Providers.LLM.APIKey = "openai-key"
+	cfg.Providers.Embedding.APIKey = "openai-key"
+	cfg.Providers.Embedding.Dimensions = 512
+	if err := overrideConfigWithFlags(cfg, generateCmd); err != nil {
+		t.Fatalf("overrideConfigWithFlags failed: %v", err)
+	}
+
+	// The OpenAI model and key do not carry over to the new providers
+	if cfg.Providers.LLM.Model != "claude-sonnet-4-0" || cfg.Providers.LLM.APIKey != "anthropic-key" {
+		t.Errorf("Expected the Anthropic defaults, got model %s and key %s",
+			cfg.Providers.LLM.Model, cfg.Providers.LLM.APIKey)
+	}
+	if cfg.Providers.Embedding.Model != "voyage-3-large" || cfg.Providers.Embedding.APIKey != "voyage-key" ||
+		cfg.Providers.Embedding.Dimensions != 0 {
+		t.Errorf("Expected the Voyage defaults, got %+v", cfg.Providers.Embedding)
+	}
+
+	provider, err := cfg.GetLLMProvider()
+	if err != nil {
+		t.Fatalf("GetLLMProvider failed: %v", err)
+	}
+	if provider.GetProviderType() != "anthropic" {
+		t.Errorf("Expected an Anthropic LLM provider, got %s", provider.GetProviderType())
+	}
+	embedder, err := cfg.GetEmbeddingProvider()
+	if err != nil {
+		t.Fatalf("GetEmbeddingProvider failed: %v", err)
+	}
+	if embedder.GetProviderType() != "voyage" {
+		t.Errorf("Expected a Voyage embedding provider, got %s", embedder.GetProviderType())
+	}
+
+	// --model applies to the selected provider
+	model = "claude-opus-4-0"
+	t.Cleanup(func() { model = "" })
+	cfg = config.DefaultConfig()
+	if err := overrideConfigWithFlags(cfg, generateCmd); err != nil {
+		t.Fatalf("overrideConfigWithFlags failed: %v", err)
+	}
+	if cfg.Providers.LLM.Model != model {
+		t.Errorf("Expected model %s, got %s", model, cfg.Providers.LLM.Model)
+	}
+
+	llmProviderName = "gemini"
+	err = overrideConfigWithFlags(config.DefaultConfig(), generateCmd)
+	if err == nil || !strings.Contains(err.Error(), "openai, anthropic, ollama") {
+		t.Errorf("Expected an unsupported provider error listing the providers, got %v", err)
+	}
+}
+
+func TestOverrideConfigWithFlags_Workers(t *testing.T) {
+	cfg := config.DefaultConfig()
+	if got := newScanOptions(cfg).MaxWorkers; got != runtime.NumCPU() {
+		t.Errorf("Expected one scan worker per CPU by default, got %d", got)
+	}
+	if got := newProcessingOptions(cfg).MaxWorkers; got != runtime.NumCPU() {
+		t.Errorf("Expected one processing worker per CPU by default, got %d", got)
+	}
+
+	t.Cleanup(func() {
+		workers = 0
+		generateCmd.Flags().Lookup("workers").Changed = false
+	})
+	if err := generateCmd.Flags().Set("workers", "3"); err != nil {
+		t.Fatalf("Failed to set --workers: %v", err)
+	}
+	if err := overrideConfigWithFlags(cfg, generateCmd); err != nil {
+		t.Fatalf("overrideConfigWithFlags failed: %v", err)
+	}
+	if got := newScanOptions(cfg).MaxWorkers; got != 3 {
+		t.Errorf("Expected 3 scan workers, got %d", got)
+	}
+	if got := newProcessingOptions(cfg).MaxWorkers; got != 3 {
+		t.Errorf("Expected 3 processing workers, got %d", got)
+	}
+}
+
+func TestRunGenerate_RejectsInvalidBaseURL(t *testing.T) {
+	t.Cleanup(func() { llmBaseURL, embeddingBaseURL = "", "" })
+
+	workDir := t.TempDir()
+	t.Chdir(workDir)
+	t.Setenv("HOME", workDir)
+
+	for _, flag := range []string{"--base-url", "--embedding-base-url"} {
+		for _, value := range []string{"localhost:4000", "ftp://example.com", "http://"} {
+			t.Run(flag+" "+value, func(t *testing.T) {
+				llmBaseURL, embeddingBaseURL = "", ""
+
+				var out bytes.Buffer
+				rootCmd.SetOut(&out)
+				rootCmd.SetErr(&out)
+				rootCmd.SetArgs([]string{"generate", workDir, flag, value})
+				defer rootCmd.SetArgs(nil)
+
+				err := rootCmd.Execute()
+				if err == nil || !strings.Contains(err.Error(), "invalid "+flag) {
+					t.Errorf("Expected %s %s to be rejected, got %v", flag, value, err)
+				}
+			})
+		}
+	}
+}
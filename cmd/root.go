@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 
+	"github.com/kuderr/deepwiki/internal/config"
 	"github.com/spf13/cobra"
 )
 
@@ -13,6 +16,9 @@ var (
 	BuildDate = "unknown"
 )
 
+// envFile is an explicit .env file to load instead of the one in the working directory
+var envFile string
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "deepwiki",
@@ -31,6 +37,21 @@ Example usage:
   deepwiki generate
   deepwiki generate --path /path/to/project
   deepwiki generate --output-dir ./docs`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return loadEnvFile()
+	},
+}
+
+// loadEnvFile loads --env-file, or .env from the working directory when it exists, before any
+// configuration is resolved. Variables already set in the environment take precedence.
+func loadEnvFile() error {
+	if envFile != "" {
+		return config.LoadEnvFile(envFile)
+	}
+	if err := config.LoadEnvFile(config.DefaultEnvFile); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -45,6 +66,8 @@ func init() {
 	// will be global for your application.
 
 	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.deepwiki.yaml)")
+	rootCmd.PersistentFlags().StringVar(&envFile, "env-file", "",
+		"file of KEY=value environment variables to load (default is .env in the working directory)")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
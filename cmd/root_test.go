@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRootCommand_LoadsEnvFile(t *testing.T) {
+	t.Setenv("DEEPWIKI_TEST_DOTENV", "")
+	os.Unsetenv("DEEPWIKI_TEST_DOTENV")
+
+	dir := t.TempDir()
+	dotenv := []byte("DEEPWIKI_TEST_DOTENV=from-dotenv\n")
+	if err := os.WriteFile(filepath.Join(dir, ".env"), dotenv, 0o644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	t.Chdir(dir)
+
+	executeRoot(t, "version")
+	if value := os.Getenv("DEEPWIKI_TEST_DOTENV"); value != "from-dotenv" {
+		t.Errorf("Expected .env from the working directory to be loaded, got %q", value)
+	}
+
+	// An explicit --env-file is loaded instead, without overriding what is already set
+	t.Setenv("DEEPWIKI_TEST_DOTENV_EXTRA", "")
+	os.Unsetenv("DEEPWIKI_TEST_DOTENV_EXTRA")
+	custom := filepath.Join(dir, "custom.env")
+	content := "DEEPWIKI_TEST_DOTENV=overridden\nDEEPWIKI_TEST_DOTENV_EXTRA=extra\n"
+	if err := os.WriteFile(custom, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	t.Cleanup(func() { envFile = "" })
+
+	executeRoot(t, "--env-file", custom, "version")
+	if value := os.Getenv("DEEPWIKI_TEST_DOTENV"); value != "from-dotenv" {
+		t.Errorf("Expected the set variable to be kept, got %q", value)
+	}
+	if value := os.Getenv("DEEPWIKI_TEST_DOTENV_EXTRA"); value != "extra" {
+		t.Errorf("Expected the variable from --env-file, got %q", value)
+	}
+}
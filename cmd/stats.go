@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"path/filepath"
+	"slices"
+
+	"github.com/kuderr/deepwiki/internal/config"
+	"github.com/kuderr/deepwiki/internal/logging"
+	"github.com/kuderr/deepwiki/pkg/embedding"
+	"github.com/kuderr/deepwiki/pkg/processor"
+	"github.com/kuderr/deepwiki/pkg/scanner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsJSON       bool
+	statsConfigFile string
+)
+
+// statsCmd represents the stats command
+var statsCmd = &cobra.Command{
+	Use:   "stats [directory]",
+	Short: "Show the size and composition of a project",
+	Long: `Scan and chunk a project the same way generate does, and report its size
+and composition without calling any LLM or embedding API.
+
+The report lists file counts per language and category, lines of code, the
+number of chunks that would be embedded, their estimated tokens, and the
+projected cost of embedding them with the configured embedding model.
+
+Examples:
+  deepwiki stats
+  deepwiki stats /path/to/project
+  deepwiki stats --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runStats,
+}
+
+// projectStats is the report printed by the stats command
+type projectStats struct {
+	Path        string         `json:"path"`
+	TotalFiles  int            `json:"totalFiles"` // Files found before filtering
+	Files       int            `json:"files"`      // Files included in the documentation
+	TotalLines  int            `json:"totalLines"`
+	LinesOfCode int            `json:"linesOfCode"`
+	Languages   map[string]int `json:"languages"`
+	Categories  map[string]int `json:"categories"`
+
+	Chunks          int `json:"chunks"`
+	EstimatedTokens int `json:"estimatedTokens"`
+
+	EmbeddingProvider string   `json:"embeddingProvider"`
+	EmbeddingModel    string   `json:"embeddingModel"`
+	EmbeddingCost     *float64 `json:"embeddingCost,omitempty"` // USD, omitted when the model price is unknown
+}
+
+func runStats(cmd *cobra.Command, args []string) error {
+	projectDir := "."
+	if len(args) > 0 {
+		projectDir = args[0]
+	}
+
+	cfg, err := config.LoadConfigForProject(statsConfigFile, projectDir)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	logger, err := logging.NewLogger(&cfg.Logging)
+	if err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	defer logger.Close()
+	logging.SetGlobalLogger(logger)
+
+	scanResult, err := scanner.NewScanner(newScanOptions(cfg)).ScanDirectory(projectDir)
+	if err != nil {
+		return fmt.Errorf("failed to scan directory: %w", err)
+	}
+
+	processingResult, err := processor.NewTextProcessor(newProcessingOptions(cfg)).ProcessFiles(scanResult.Files)
+	if err != nil {
+		return fmt.Errorf("failed to process files: %w", err)
+	}
+
+	stats := projectStats{
+		Path:              scanResult.RootPath,
+		TotalFiles:        scanResult.TotalFiles,
+		Files:             scanResult.FilteredFiles,
+		TotalLines:        scanResult.TotalLines,
+		LinesOfCode:       scanResult.LinesOfCode,
+		Languages:         scanResult.LanguageStats,
+		Categories:        scanResult.CategoryStats,
+		Chunks:            processingResult.TotalChunks,
+		EstimatedTokens:   processingResult.TotalTokens,
+		EmbeddingProvider: cfg.Providers.Embedding.Provider,
+		EmbeddingModel:    cfg.Providers.Embedding.Model,
+	}
+	providerType := embedding.ProviderType(stats.EmbeddingProvider)
+	if cost, ok := embedding.EstimateCost(providerType, stats.EmbeddingModel, stats.EstimatedTokens); ok {
+		stats.EmbeddingCost = &cost
+	}
+
+	if statsJSON {
+		encoder := json.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(stats)
+	}
+
+	printStats(cmd.OutOrStdout(), stats)
+	return nil
+}
+
+// printStats writes a human-readable report
+func printStats(w io.Writer, stats projectStats) {
+	fmt.Fprintf(w, "📁 Project: %s\n", filepath.Base(stats.Path))
+	fmt.Fprintf(w, "   • Files: %d included of %d found\n", stats.Files, stats.TotalFiles)
+	fmt.Fprintf(w, "   • Lines: %d total, %d of code\n", stats.TotalLines, stats.LinesOfCode)
+
+	if len(stats.Categories) > 0 {
+		fmt.Fprintf(w, "\n📊 File categories:\n")
+		for _, category := range slices.Sorted(maps.Keys(stats.Categories)) {
+			fmt.Fprintf(w, "   • %s: %d files\n", category, stats.Categories[category])
+		}
+	}
+
+	if len(stats.Languages) > 0 {
+		fmt.Fprintf(w, "\n🔧 Programming languages:\n")
+		for _, language := range slices.Sorted(maps.Keys(stats.Languages)) {
+			fmt.Fprintf(w, "   • %s: %d files\n", language, stats.Languages[language])
+		}
+	}
+
+	fmt.Fprintf(w, "\n🧠 Embeddings (%s/%s):\n", stats.EmbeddingProvider, stats.EmbeddingModel)
+	fmt.Fprintf(w, "   • Chunks: %d\n", stats.Chunks)
+	fmt.Fprintf(w, "   • Estimated tokens: %d\n", stats.EstimatedTokens)
+	if stats.EmbeddingCost != nil {
+		fmt.Fprintf(w, "   • Projected cost: $%.4f\n", *stats.EmbeddingCost)
+	} else {
+		fmt.Fprintf(w, "   • Projected cost: unknown for this model\n")
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+
+	statsCmd.Flags().BoolVar(&statsJSON, "json", false, "Print the report as JSON")
+	statsCmd.Flags().StringVar(&statsConfigFile, "config", "", "Configuration file path")
+}
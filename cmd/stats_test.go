@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStatsCommand(t *testing.T) {
+	workDir := t.TempDir()
+	t.Chdir(workDir)
+
+	// Any provider request would be a paid API call
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Unexpected provider request to %s", r.URL.Path)
+		http.Error(w, "unexpected", http.StatusInternalServerError)
+	}))
+	defer api.Close()
+
+	t.Setenv("HOME", workDir)
+	t.Setenv("OPENAI_API_KEY", "test-key")
+	t.Setenv("DEEPWIKI_LLM_BASE_URL", api.URL)
+	t.Setenv("DEEPWIKI_EMBEDDING_BASE_URL", api.URL)
+	t.Setenv("DEEPWIKI_EMBEDDING_PROVIDER", "openai")
+	t.Setenv("DEEPWIKI_EMBEDDING_MODEL", "text-embedding-3-small")
+
+	// Each file has one chunk: the short config file is kept whole
+	paragraph := strings.Repeat("The demo project greets its users and explains how it works. ", 8)
+	projectDir := filepath.Join(workDir, "project")
+	files := map[string]string{
+		"main.go":      "package main\n\n// " + paragraph + "\nfunc main() {\n\tprintln(\"hello\")\n}\n",
+		"util.go":      "package main\n\n// " + paragraph + "\nfunc helper() {}\n",
+		"README.md":    "# Demo\n\n" + paragraph + "\n",
+		"config.yaml":  "name: demo\n",
+		"node_modules": "",
+	}
+	for name, content := range files {
+		path := filepath.Join(projectDir, name)
+		if content == "" {
+			if err := os.MkdirAll(path, 0o755); err != nil {
+				t.Fatalf("Failed to create %s: %v", name, err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	out := executeRoot(t, "stats", projectDir, "--json")
+
+	var stats projectStats
+	if err := json.Unmarshal([]byte(out), &stats); err != nil {
+		t.Fatalf("Failed to decode stats JSON: %v\n%s", err, out)
+	}
+
+	if stats.Files != 4 {
+		t.Errorf("Expected 4 included files, got %d", stats.Files)
+	}
+	expectedLanguages := map[string]int{"Go": 2, "Markdown": 1, "YAML": 1}
+	if !maps.Equal(stats.Languages, expectedLanguages) {
+		t.Errorf("Expected languages %v, got %v", expectedLanguages, stats.Languages)
+	}
+	expectedCategories := map[string]int{"code": 2, "docs": 1, "config": 1}
+	if !maps.Equal(stats.Categories, expectedCategories) {
+		t.Errorf("Expected categories %v, got %v", expectedCategories, stats.Categories)
+	}
+	if stats.LinesOfCode != 10 {
+		t.Errorf("Expected 10 lines of code, got %d", stats.LinesOfCode)
+	}
+	if stats.Chunks != 4 {
+		t.Errorf("Expected 4 chunks, got %d", stats.Chunks)
+	}
+	if stats.EstimatedTokens <= 0 {
+		t.Errorf("Expected estimated tokens, got %d", stats.EstimatedTokens)
+	}
+
+	if stats.EmbeddingCost == nil {
+		t.Fatal("Expected a projected cost for text-embedding-3-small")
+	}
+	if expected := float64(stats.EstimatedTokens) * 0.02 / 1000000; *stats.EmbeddingCost != expected {
+		t.Errorf("Expected projected cost %v, got %v", expected, *stats.EmbeddingCost)
+	}
+
+	text := executeRoot(t, "stats", projectDir, "--json=false")
+	for _, expected := range []string{"Files: 4 included", "Go: 2 files", "Chunks: 4", "Projected cost: $"} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("Expected report to contain %q, got:\n%s", expected, text)
+		}
+	}
+}
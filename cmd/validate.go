@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/kuderr/deepwiki/pkg/output"
+	outputgen "github.com/kuderr/deepwiki/pkg/output/generator"
+	"github.com/spf13/cobra"
+)
+
+var validateFormat string
+
+// validateCmd represents the validate command
+var validateCmd = &cobra.Command{
+	Use:   "validate <outputDir>",
+	Short: "Check generated documentation for broken links and empty pages",
+	Long: `Check a generated documentation directory before publishing it.
+
+Every page written by the output format is parsed. The command reports
+frontmatter that is not valid YAML, internal links to files that do not exist,
+pages without content, and parent, child or related pages in structure.json
+(or the JSON page files) that refer to unknown pages. It exits with an error
+when any problem is found.
+
+The format is read from structure.json when --format is not given.
+
+Examples:
+  deepwiki validate ./docs
+  deepwiki validate --format docusaurus3 ./website`,
+	Args: cobra.ExactArgs(1),
+	RunE: runValidate,
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	format := outputgen.OutputFormat(validateFormat)
+	if format != "" {
+		if _, err := output.NewOutputManager().GetFormatDescription(format); err != nil {
+			return fmt.Errorf("unsupported format: %s", validateFormat)
+		}
+	}
+
+	report, err := output.ValidateOutput(args[0], format)
+	if err != nil {
+		return fmt.Errorf("failed to validate output: %w", err)
+	}
+
+	w := cmd.OutOrStdout()
+	if !report.HasIssues() {
+		fmt.Fprintf(w, "✅ No problems found (%d %s files checked)\n", report.Files, report.Format)
+		return nil
+	}
+
+	for _, issue := range report.Issues {
+		fmt.Fprintf(w, "%s: [%s] %s\n", issue.Path, issue.Kind, issue.Message)
+	}
+	fmt.Fprintf(w, "\n📊 %d problems in %d %s files checked\n", len(report.Issues), report.Files, report.Format)
+
+	cmd.SilenceUsage = true
+	return fmt.Errorf("found %d problems in %s", len(report.Issues), args[0])
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+
+	validateCmd.Flags().StringVarP(&validateFormat, "format", "f", "",
+		"Output format that produced the directory (default: read from structure.json)")
+	validateCmd.RegisterFlagCompletionFunc("format", completeFormats)
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateCommand(t *testing.T) {
+	dir := t.TempDir()
+	pages := filepath.Join(dir, "pages")
+	if err := os.MkdirAll(pages, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"index.md":          "# Wiki\n\n- [Overview](pages/overview.md)\n",
+		"pages/overview.md": "# Overview\n\nThe project overview.\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer func() { validateFormat = "" }()
+
+	out := executeRoot(t, "validate", "--format", "markdown", dir)
+	if !strings.Contains(out, "No problems found (2 markdown files checked)") {
+		t.Errorf("Expected a clean report, got:\n%s", out)
+	}
+
+	if err := os.WriteFile(filepath.Join(pages, "setup.md"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	rootCmd.SetErr(&buf)
+	rootCmd.SetArgs([]string{"validate", dir})
+	defer rootCmd.SetArgs(nil)
+
+	err := rootCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "found 1 problems") {
+		t.Fatalf("Expected validate to fail with 1 problem, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "pages/setup.md: [empty-page] page has no content") {
+		t.Errorf("Expected the empty page to be reported, got:\n%s", buf.String())
+	}
+}
@@ -4,13 +4,24 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/kuderr/deepwiki/internal/httpheaders"
 	"github.com/kuderr/deepwiki/internal/logging"
 	"github.com/kuderr/deepwiki/pkg/embedding"
 	embeddingfactory "github.com/kuderr/deepwiki/pkg/embedding/factory"
+	"github.com/kuderr/deepwiki/pkg/embeddings"
+	"github.com/kuderr/deepwiki/pkg/generator"
 	"github.com/kuderr/deepwiki/pkg/llm"
 	llmfactory "github.com/kuderr/deepwiki/pkg/llm/factory"
+	outputgen "github.com/kuderr/deepwiki/pkg/output/generator"
+	"github.com/kuderr/deepwiki/pkg/processor"
+	"github.com/kuderr/deepwiki/pkg/rag"
+	"github.com/kuderr/deepwiki/pkg/ratelimit"
+	"github.com/kuderr/deepwiki/pkg/scanner"
+	"github.com/kuderr/deepwiki/pkg/tracing"
 	"github.com/kuderr/deepwiki/pkg/types"
 	"gopkg.in/yaml.v3"
 )
@@ -22,7 +33,23 @@ type Config struct {
 	Filters    FiltersConfig     `yaml:"filters"`
 	Output     OutputConfig      `yaml:"output"`
 	Embeddings EmbeddingsConfig  `yaml:"embeddings"`
+	Analysis   AnalysisConfig    `yaml:"analysis"`
 	Logging    logging.LogConfig `yaml:"logging"`
+	Tracing    TracingConfig     `yaml:"tracing"`
+
+	// File is the configuration file that was loaded, empty when only defaults and env are used
+	File string `yaml:"-"`
+
+	// scheduler enforces providers.account_rate_limit_rps across all providers of the config
+	scheduler *ratelimit.Scheduler
+}
+
+// ConfigFileNames are the file names looked up during config auto-discovery, in priority order
+var ConfigFileNames = []string{
+	"deepwiki.yaml",
+	"deepwiki.yml",
+	".deepwiki.yaml",
+	".deepwiki.yml",
 }
 
 // ProcessingConfig contains text processing configuration
@@ -30,6 +57,16 @@ type ProcessingConfig struct {
 	ChunkSize    int `yaml:"chunk_size"`
 	ChunkOverlap int `yaml:"chunk_overlap"`
 	MaxFiles     int `yaml:"max_files"`
+
+	// Workers is the number of files scanned and processed concurrently; 0 uses one per CPU
+	Workers int `yaml:"workers"`
+
+	// ChunkSizes overrides chunk_size for files of a category: code, docs or config
+	ChunkSizes map[string]int `yaml:"chunk_sizes"`
+
+	// FileSizeLimits caps the size in bytes of files read for each content type: code,
+	// documentation, configuration, data, test and unknown. Larger files are skipped.
+	FileSizeLimits map[string]int64 `yaml:"file_size_limits"`
 }
 
 // FiltersConfig contains file filtering configuration
@@ -37,20 +74,137 @@ type FiltersConfig struct {
 	IncludeExtensions []string `yaml:"include_extensions"`
 	ExcludeDirs       []string `yaml:"exclude_dirs"`
 	ExcludeFiles      []string `yaml:"exclude_files"`
+	ExcludeSecrets    bool     `yaml:"exclude_secrets"`
+	AllowFiles        []string `yaml:"allow_files"`
+	ExcludeGenerated  bool     `yaml:"exclude_generated"`
+
+	ImportanceRules []ImportanceRuleConfig `yaml:"importance_rules"` // Checked before the built-in signals
+}
+
+// ImportanceRuleConfig assigns an importance score (1-5) to files matching a glob pattern
+type ImportanceRuleConfig struct {
+	Pattern    string `yaml:"pattern"`
+	Importance int    `yaml:"importance"`
+}
+
+// ScannerImportanceRules converts the configured importance rules for the scanner
+func (f *FiltersConfig) ScannerImportanceRules() []scanner.ImportanceRule {
+	rules := make([]scanner.ImportanceRule, 0, len(f.ImportanceRules))
+	for _, rule := range f.ImportanceRules {
+		rules = append(rules, scanner.ImportanceRule{Pattern: rule.Pattern, Importance: rule.Importance})
+	}
+	return rules
 }
 
 // OutputConfig contains output generation configuration
 type OutputConfig struct {
-	Format    string         `yaml:"format"`
-	Directory string         `yaml:"directory"`
-	Language  types.Language `yaml:"language"`
+	Mode        string         `yaml:"mode"` // Generation mode: wiki or file-per-source
+	Format      string         `yaml:"format"`
+	Directory   string         `yaml:"directory"`
+	Language    types.Language `yaml:"language"`
+	SearchIndex bool           `yaml:"search_index"`
+	SiteURL     string         `yaml:"site_url"`
+	BaseURL     string         `yaml:"base_url"`
+
+	DirectoryIndexes  bool `yaml:"directory_indexes"`   // Index page per top-level source directory
+	MergeExistingDocs bool `yaml:"merge_existing_docs"` // Include handwritten markdown docs as pages
+	EmitStructure     bool `yaml:"emit_structure"`      // Write structure.json for every format
+	DedupPages        bool `yaml:"dedup_pages"`         // Collapse near-duplicate generated pages
+	EmbedSnippets     bool `yaml:"embed_snippets"`      // Quote the source of referenced declarations
+	LinkPages         bool `yaml:"link_pages"`          // Link mentions of other pages in page content
+	Glossary          bool `yaml:"glossary"`            // Glossary page of domain terms written by the LLM
+
+	// ClassifyImportance has the LLM rate the importance of each generated page
+	ClassifyImportance bool `yaml:"classify_importance"`
+
+	// BatchPages generates up to this many low importance pages with one LLM request (0 or 1 = off)
+	BatchPages int `yaml:"batch_pages"`
+
+	// Frontmatter keys written on each page; unset keeps the format defaults, [] writes none
+	Frontmatter []string `yaml:"frontmatter"`
+
+	// Pages over either limit are split into continuation pages, or truncated (0 = no limit)
+	MaxPageWords      int  `yaml:"max_page_words"`
+	MaxPageBytes      int  `yaml:"max_page_bytes"`
+	TruncateLongPages bool `yaml:"truncate_long_pages"`
+
+	// Archive packs the output into <directory>.zip or .tar.gz: zip, tar.gz or none
+	Archive string `yaml:"archive"`
+
+	Notion NotionConfig `yaml:"notion"`
+}
+
+// NotionConfig exports the generated pages to Notion when a parent page is set
+type NotionConfig struct {
+	Token        string `yaml:"token"`          // Integration token (NOTION_TOKEN)
+	ParentPageID string `yaml:"parent_page_id"` // Page the wiki pages are created under
 }
 
 // EmbeddingsConfig contains embedding generation configuration
 type EmbeddingsConfig struct {
-	Enabled    bool `yaml:"enabled"`
-	Dimensions int  `yaml:"dimensions"`
-	TopK       int  `yaml:"top_k"`
+	Enabled    bool   `yaml:"enabled"`
+	Dimensions int    `yaml:"dimensions"`
+	TopK       int    `yaml:"top_k"`  // Chunks retrieved for each page
+	Metric     string `yaml:"metric"` // Similarity metric for retrieval: cosine, euclidean, dot or manhattan
+
+	// MinScore is the lowest score of a chunk retrieved for a page; Strategy is the retrieval
+	// strategy: hybrid, semantic, keyword or structural
+	MinScore float32 `yaml:"min_score"`
+	Strategy string  `yaml:"strategy"`
+
+	// MinResults is the number of chunks below which min_score is lowered step by step, down to
+	// MinScoreFloor, before giving up (0 = never relax)
+	MinResults    int     `yaml:"min_results"`
+	MinScoreFloor float32 `yaml:"min_score_floor"`
+
+	// Namespace prefixes the vector database buckets, so several projects can share one file
+	Namespace string `yaml:"namespace"`
+	// OpenTimeout is how long to wait for a vector database held by another process ("30s")
+	OpenTimeout string `yaml:"open_timeout"`
+}
+
+// OpenTimeoutDuration parses OpenTimeout; it is zero when unset
+func (c *EmbeddingsConfig) OpenTimeoutDuration() (time.Duration, error) {
+	if c.OpenTimeout == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(c.OpenTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid embeddings open_timeout %q: %w", c.OpenTimeout, err)
+	}
+	if timeout < 0 {
+		return 0, fmt.Errorf("embeddings open_timeout must not be negative")
+	}
+	return timeout, nil
+}
+
+// AnalysisConfig controls pages generated by static analysis of the source tree
+type AnalysisConfig struct {
+	EnvVars bool `yaml:"env_vars"`
+	Todos   bool `yaml:"todos"`
+	License bool `yaml:"license"`
+
+	CI        bool `yaml:"ci"`         // CI/CD page from GitHub Actions and GitLab CI files
+	CISummary bool `yaml:"ci_summary"` // LLM-written overview on the CI/CD page
+
+	ClassDiagram bool `yaml:"class_diagram"` // Mermaid class diagram of Go structs and interfaces
+}
+
+// TracingConfig configures OpenTelemetry tracing of the generation pipeline.
+// Tracing is disabled unless an OTLP collector endpoint is set.
+type TracingConfig struct {
+	Endpoint    string            `yaml:"endpoint"`          // OTLP/HTTP collector URL, e.g. http://localhost:4318
+	ServiceName string            `yaml:"service_name"`      // Reported as service.name
+	Headers     map[string]string `yaml:"headers,omitempty"` // Extra headers sent to the collector
+}
+
+// defaultFileSizeLimits returns the processor's default file size limits keyed by content type
+func defaultFileSizeLimits() map[string]int64 {
+	limits := make(map[string]int64)
+	for contentType, limit := range processor.DefaultMaxFileSizeLimits() {
+		limits[string(contentType)] = limit
+	}
+	return limits
 }
 
 // DefaultConfig returns a configuration with sensible defaults
@@ -61,6 +215,8 @@ func DefaultConfig() *Config {
 			ChunkSize:    350,
 			ChunkOverlap: 100,
 			MaxFiles:     1000,
+
+			FileSizeLimits: defaultFileSizeLimits(),
 		},
 		Filters: FiltersConfig{
 			IncludeExtensions: []string{
@@ -123,55 +279,56 @@ func DefaultConfig() *Config {
 				// Logs & Temporary
 				"*.log", "*.tmp", "*.temp", "*.bak", "*.backup", "core", "*.dump",
 			},
+			ExcludeSecrets:   true,
+			ExcludeGenerated: true,
 		},
 		Output: OutputConfig{
+			Mode:      string(generator.ModeWiki),
 			Format:    "markdown",
 			Directory: "./docs",
 			Language:  types.LanguageEnglish,
+
+			EmitStructure: true,
 		},
 		Embeddings: EmbeddingsConfig{
 			Enabled:    true,
 			Dimensions: 256,
 			TopK:       20,
+			Metric:     string(embeddings.CosineSimilarity),
+			MinScore:   0.1,
+			Strategy:   string(rag.QueryTypeHybrid),
+		},
+		Analysis: AnalysisConfig{
+			EnvVars: true,
+			Todos:   false,
+			License: false,
 		},
 		Logging: *logging.DefaultLogConfig(),
+		Tracing: TracingConfig{
+			ServiceName: tracing.DefaultServiceName,
+		},
 	}
 }
 
 // LoadConfig loads configuration from file, environment variables, and CLI flags
 func LoadConfig(configFile string) (*Config, error) {
+	return LoadConfigForProject(configFile, ".")
+}
+
+// LoadConfigForProject loads configuration for the project in projectDir.
+// An explicit configFile always wins; otherwise the file is discovered with DiscoverConfigFile.
+func LoadConfigForProject(configFile, projectDir string) (*Config, error) {
 	config := DefaultConfig()
 
-	// Load from config file if specified
+	if configFile == "" {
+		configFile = DiscoverConfigFile(projectDir)
+	}
+
 	if configFile != "" {
 		if err := loadFromFile(config, configFile); err != nil {
-			return nil, fmt.Errorf("failed to load config file: %w", err)
-		}
-	} else {
-		// Try to find config file in common locations
-		configPaths := []string{
-			"deepwiki.yaml",
-			"deepwiki.yml",
-			".deepwiki.yaml",
-			".deepwiki.yml",
-		}
-
-		// Also check home directory
-		if homeDir, err := os.UserHomeDir(); err == nil {
-			configPaths = append(configPaths,
-				filepath.Join(homeDir, ".deepwiki.yaml"),
-				filepath.Join(homeDir, ".deepwiki.yml"),
-			)
-		}
-
-		for _, path := range configPaths {
-			if _, err := os.Stat(path); err == nil {
-				if err := loadFromFile(config, path); err != nil {
-					return nil, fmt.Errorf("failed to load config file %s: %w", path, err)
-				}
-				break
-			}
+			return nil, fmt.Errorf("failed to load config file %s: %w", configFile, err)
 		}
+		config.File = configFile
 	}
 
 	// Override with environment variables
@@ -185,6 +342,49 @@ func LoadConfig(configFile string) (*Config, error) {
 	return config, nil
 }
 
+// DiscoverConfigFile looks for a config file in startDir and its parents, stopping at the
+// repository root (the first directory containing .git). The home directory is checked last.
+// It returns an empty string when no config file is found.
+func DiscoverConfigFile(startDir string) string {
+	dir, err := filepath.Abs(startDir)
+	if err != nil {
+		return ""
+	}
+
+	for {
+		if path := findConfigFileIn(dir); path != "" {
+			return path
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			break
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		return findConfigFileIn(homeDir)
+	}
+
+	return ""
+}
+
+// findConfigFileIn returns the first config file present in dir, or an empty string
+func findConfigFileIn(dir string) string {
+	for _, name := range ConfigFileNames {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
 // loadFromFile loads configuration from a YAML file
 func loadFromFile(config *Config, filename string) error {
 	data, err := os.ReadFile(filename)
@@ -239,6 +439,20 @@ func loadFromEnv(config *Config) {
 		config.Providers.Embedding.BaseURL = baseURL
 	}
 
+	// Fallback embedding providers without an explicit key use the provider's standard variable
+	for i := range config.Providers.Embedding.Fallbacks {
+		fallback := &config.Providers.Embedding.Fallbacks[i]
+		if fallback.APIKey != "" {
+			continue
+		}
+		switch fallback.Provider {
+		case "openai":
+			fallback.APIKey = os.Getenv("OPENAI_API_KEY")
+		case "voyage":
+			fallback.APIKey = os.Getenv("VOYAGE_API_KEY")
+		}
+	}
+
 	if outputDir := os.Getenv("DEEPWIKI_OUTPUT_DIR"); outputDir != "" {
 		config.Output.Directory = outputDir
 	}
@@ -247,6 +461,10 @@ func loadFromEnv(config *Config) {
 		config.Output.Format = format
 	}
 
+	if token := os.Getenv("NOTION_TOKEN"); token != "" {
+		config.Output.Notion.Token = token
+	}
+
 	if lang := os.Getenv("DEEPWIKI_LANGUAGE"); lang != "" {
 		if parsedLang, err := types.ParseLanguageWithCode(lang); err == nil {
 			config.Output.Language = parsedLang
@@ -262,6 +480,23 @@ func loadFromEnv(config *Config) {
 		config.Filters.ExcludeFiles = append(config.Filters.ExcludeFiles,
 			strings.Split(excludeFiles, ",")...)
 	}
+
+	// Tracing configuration, using the standard OpenTelemetry variables
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); endpoint != "" {
+		config.Tracing.Endpoint = endpoint
+	}
+
+	if serviceName := os.Getenv("OTEL_SERVICE_NAME"); serviceName != "" {
+		config.Tracing.ServiceName = serviceName
+	}
+
+	// Header values may reference environment variables, so secrets stay out of the config file
+	httpheaders.Expand(config.Providers.LLM.Headers)
+	httpheaders.Expand(config.Providers.Embedding.Headers)
+	for i := range config.Providers.Embedding.Fallbacks {
+		httpheaders.Expand(config.Providers.Embedding.Fallbacks[i].Headers)
+	}
+	httpheaders.Expand(config.Tracing.Headers)
 }
 
 // validateConfig validates the configuration values
@@ -283,6 +518,27 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("LLM temperature must be between 0 and 2")
 	}
 
+	phases := map[string]LLMPhaseConfig{
+		"structure": config.Providers.LLM.Phases.Structure,
+		"page":      config.Providers.LLM.Phases.Page,
+	}
+	for name, phase := range phases {
+		if phase.Temperature != nil && (*phase.Temperature < 0 || *phase.Temperature > 2) {
+			return fmt.Errorf("LLM %s phase temperature must be between 0 and 2", name)
+		}
+		if phase.MaxTokens < 0 {
+			return fmt.Errorf("LLM %s phase max tokens cannot be negative", name)
+		}
+	}
+
+	if config.Providers.LLM.ContextWindow < 0 {
+		return fmt.Errorf("LLM context window cannot be negative")
+	}
+
+	if config.Providers.AccountRateLimitRPS < 0 {
+		return fmt.Errorf("account rate limit cannot be negative")
+	}
+
 	// Validate embedding provider configuration
 	if config.Providers.Embedding.Provider == "" {
 		return fmt.Errorf("embedding provider is required")
@@ -305,6 +561,35 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("chunk overlap must be less than chunk size")
 	}
 
+	if config.Processing.Workers < 0 {
+		return fmt.Errorf("processing workers cannot be negative")
+	}
+
+	for category, size := range config.Processing.ChunkSizes {
+		if !slices.Contains(processor.ChunkSizeCategories, category) {
+			return fmt.Errorf("invalid processing.chunk_sizes: unknown category %s", category)
+		}
+		if size <= config.Processing.ChunkOverlap {
+			return fmt.Errorf("invalid processing.chunk_sizes: %s chunk size must be greater than chunk overlap",
+				category)
+		}
+	}
+
+	for contentType, limit := range config.Processing.FileSizeLimits {
+		if !slices.Contains(processor.ContentTypes, processor.ContentType(contentType)) {
+			return fmt.Errorf("invalid processing.file_size_limits: unknown content type %s", contentType)
+		}
+		if limit <= 0 {
+			return fmt.Errorf("invalid processing.file_size_limits: %s limit must be positive", contentType)
+		}
+	}
+
+	for _, rule := range config.Filters.ScannerImportanceRules() {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("invalid filters.importance_rules: %w", err)
+		}
+	}
+
 	// Validate output configuration
 	validFormats := map[string]bool{
 		"markdown":           true,
@@ -313,14 +598,45 @@ func validateConfig(config *Config) error {
 		"docusaurus3":        true,
 		"simple-docusaurus2": true,
 		"simple-docusaurus3": true,
+		"rst":                true,
+		"gitbook":            true,
 	}
 	if !validFormats[config.Output.Format] {
 		return fmt.Errorf(
-			"invalid output format: %s (valid: markdown, json, docusaurus2, docusaurus3, simple-docusaurus2, simple-docusaurus3)",
+			"invalid output format: %s (valid: markdown, json, docusaurus2, docusaurus3, simple-docusaurus2, "+
+				"simple-docusaurus3, rst, gitbook)",
 			config.Output.Format,
 		)
 	}
 
+	if err := outputgen.ValidateFrontmatterFields(config.Output.Frontmatter); err != nil {
+		return fmt.Errorf("invalid output.frontmatter: %w", err)
+	}
+
+	switch generator.GenerationMode(config.Output.Mode) {
+	case "", generator.ModeWiki, generator.ModeFilePerSource:
+	default:
+		return fmt.Errorf("invalid output mode: %s (valid: wiki, file-per-source)", config.Output.Mode)
+	}
+
+	switch outputgen.ArchiveFormat(config.Output.Archive) {
+	case "", outputgen.ArchiveNone, outputgen.ArchiveZip, outputgen.ArchiveTarGz:
+	default:
+		return fmt.Errorf("invalid output archive: %s (valid: zip, tar.gz, none)", config.Output.Archive)
+	}
+
+	if config.Output.MaxPageWords < 0 || config.Output.MaxPageBytes < 0 {
+		return fmt.Errorf("output page limits cannot be negative")
+	}
+
+	if config.Output.BatchPages < 0 {
+		return fmt.Errorf("output batch_pages cannot be negative")
+	}
+
+	if err := ValidateOutputDirectory(config.Output.Directory); err != nil {
+		return err
+	}
+
 	if !config.Output.Language.IsValid() {
 		return fmt.Errorf(
 			"invalid language: %s (valid: %s)",
@@ -338,6 +654,28 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("embeddings dimensions must be positive")
 	}
 
+	if _, err := embeddings.ParseSimilarityMetric(config.Embeddings.Metric); err != nil {
+		return fmt.Errorf("invalid embeddings metric: %s (valid: cosine, euclidean, dot, manhattan)",
+			config.Embeddings.Metric)
+	}
+
+	if _, err := config.Embeddings.OpenTimeoutDuration(); err != nil {
+		return err
+	}
+
+	if _, err := rag.ParseQueryType(config.Embeddings.Strategy); err != nil {
+		return fmt.Errorf("invalid embeddings strategy: %s (valid: hybrid, semantic, keyword, structural)",
+			config.Embeddings.Strategy)
+	}
+
+	if config.Embeddings.MinResults < 0 {
+		return fmt.Errorf("embeddings min_results cannot be negative")
+	}
+
+	if config.Embeddings.MinScoreFloor < 0 || config.Embeddings.MinScoreFloor > config.Embeddings.MinScore {
+		return fmt.Errorf("embeddings min_score_floor must be between 0 and min_score")
+	}
+
 	return nil
 }
 
@@ -371,7 +709,7 @@ func GenerateTemplate() string {
 # DEEPWIKI_LLM_MODEL - LLM model name
 # 
 # Embedding Provider Configuration:
-# DEEPWIKI_EMBEDDING_PROVIDER - Embedding provider (openai, voyage, ollama)
+# DEEPWIKI_EMBEDDING_PROVIDER - Embedding provider (openai, voyage, ollama, tei)
 # DEEPWIKI_EMBEDDING_MODEL - Embedding model name
 # OPENAI_API_KEY - OpenAI API key (also used for embeddings)
 # VOYAGE_API_KEY - Voyage AI API key
@@ -395,7 +733,21 @@ func (c *Config) GetLLMProvider() (llm.Provider, error) {
 		return nil, fmt.Errorf("invalid LLM configuration: %w", err)
 	}
 
-	return llmfactory.NewLLMProvider(llmConfig)
+	if llmConfig.Scheduler, err = c.accountScheduler(); err != nil {
+		return nil, err
+	}
+
+	provider, err := llmfactory.NewLLMProvider(llmConfig)
+	if err != nil || !c.Providers.LLM.Cache.Enabled {
+		return provider, err
+	}
+
+	cacheOptions, err := c.Providers.LLM.Cache.ToCacheOptions()
+	if err != nil {
+		return nil, fmt.Errorf("invalid LLM configuration: %w", err)
+	}
+
+	return llm.NewCachingProvider(provider, cacheOptions)
 }
 
 // GetEmbeddingProvider creates and returns an embedding provider from the configuration
@@ -405,5 +757,55 @@ func (c *Config) GetEmbeddingProvider() (embedding.Provider, error) {
 		return nil, fmt.Errorf("invalid embedding configuration: %w", err)
 	}
 
-	return embeddingfactory.NewEmbeddingProvider(embeddingConfig)
+	fallbackConfigs, err := c.Providers.Embedding.ToFallbackConfigs()
+	if err != nil {
+		return nil, fmt.Errorf("invalid embedding configuration: %w", err)
+	}
+
+	scheduler, err := c.accountScheduler()
+	if err != nil {
+		return nil, err
+	}
+	embeddingConfig.Scheduler = scheduler
+	for _, fallbackConfig := range fallbackConfigs {
+		fallbackConfig.Scheduler = scheduler
+	}
+
+	return embeddingfactory.NewEmbeddingProviderWithFallbacks(embeddingConfig, fallbackConfigs)
+}
+
+// accountScheduler returns the scheduler shared by the LLM and embedding providers, or nil
+// when providers.account_rate_limit_rps is not set. Providers created later, such as those of
+// each watch mode regeneration, share the same scheduler.
+func (c *Config) accountScheduler() (*ratelimit.Scheduler, error) {
+	if c.Providers.AccountRateLimitRPS <= 0 {
+		return nil, nil
+	}
+
+	if c.scheduler == nil {
+		scheduler, err := ratelimit.NewScheduler(c.Providers.AccountRateLimitRPS)
+		if err != nil {
+			return nil, fmt.Errorf("invalid account rate limit: %w", err)
+		}
+		c.scheduler = scheduler
+	}
+	return c.scheduler, nil
+}
+
+// NewTracer returns a tracer exporting to the configured OTLP endpoint,
+// or nil when tracing is disabled
+func (c *Config) NewTracer() (*tracing.Tracer, error) {
+	if c.Tracing.Endpoint == "" {
+		return nil, nil
+	}
+
+	exporter, err := tracing.NewOTLPExporter(tracing.OTLPOptions{
+		Endpoint: c.Tracing.Endpoint,
+		Headers:  c.Tracing.Headers,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tracing.NewTracer(exporter, c.Tracing.ServiceName), nil
 }
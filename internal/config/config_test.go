@@ -1,9 +1,20 @@
 package config
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/kuderr/deepwiki/pkg/embedding"
+	"github.com/kuderr/deepwiki/pkg/llm"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -51,6 +62,10 @@ func TestDefaultConfig(t *testing.T) {
 		t.Errorf("Expected default language 'English', got '%s'", config.Output.Language)
 	}
 
+	if !config.Output.EmitStructure {
+		t.Error("Expected structure.json to be written by default")
+	}
+
 	// Test Filters defaults
 	if len(config.Filters.IncludeExtensions) == 0 {
 		t.Error("Expected some default include extensions")
@@ -161,6 +176,80 @@ logging:
 	}
 }
 
+func TestLoadConfigForProject_DiscoversParentConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	repoDir := t.TempDir()
+	projectDir := filepath.Join(repoDir, "services", "api")
+	if err := os.MkdirAll(filepath.Join(repoDir, ".git"), 0o755); err != nil {
+		t.Fatalf("Failed to create .git: %v", err)
+	}
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatalf("Failed to create project dir: %v", err)
+	}
+
+	configFile := filepath.Join(repoDir, ".deepwiki.yaml")
+	if err := os.WriteFile(configFile, []byte("processing:\n  chunk_size: 123\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	config, err := LoadConfigForProject("", projectDir)
+	if err != nil {
+		t.Fatalf("LoadConfigForProject failed: %v", err)
+	}
+
+	if config.File != configFile {
+		t.Errorf("Expected config file %s, got '%s'", configFile, config.File)
+	}
+	if config.Processing.ChunkSize != 123 {
+		t.Errorf("Expected chunk size 123 from discovered config, got %d", config.Processing.ChunkSize)
+	}
+}
+
+func TestLoadConfigForProject_ExplicitConfigWins(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	projectDir := t.TempDir()
+	discoveredFile := filepath.Join(projectDir, "deepwiki.yaml")
+	if err := os.WriteFile(discoveredFile, []byte("processing:\n  chunk_size: 123\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write discovered config: %v", err)
+	}
+
+	explicitFile := filepath.Join(t.TempDir(), "custom.yaml")
+	if err := os.WriteFile(explicitFile, []byte("processing:\n  chunk_size: 456\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write explicit config: %v", err)
+	}
+
+	config, err := LoadConfigForProject(explicitFile, projectDir)
+	if err != nil {
+		t.Fatalf("LoadConfigForProject failed: %v", err)
+	}
+
+	if config.File != explicitFile {
+		t.Errorf("Expected config file %s, got '%s'", explicitFile, config.File)
+	}
+	if config.Processing.ChunkSize != 456 {
+		t.Errorf("Expected chunk size 456 from explicit config, got %d", config.Processing.ChunkSize)
+	}
+}
+
+func TestDiscoverConfigFile_StopsAtRepoRoot(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	outerDir := t.TempDir()
+	repoDir := filepath.Join(outerDir, "repo")
+	if err := os.MkdirAll(filepath.Join(repoDir, ".git"), 0o755); err != nil {
+		t.Fatalf("Failed to create .git: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(outerDir, ".deepwiki.yaml"), []byte("{}\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	if path := DiscoverConfigFile(repoDir); path != "" {
+		t.Errorf("Expected no config above the repository root, got %s", path)
+	}
+}
+
 func TestLoadConfig_EnvironmentVariables(t *testing.T) {
 	// Set environment variables
 	originalAPIKey := os.Getenv("OPENAI_API_KEY")
@@ -210,6 +299,117 @@ func TestLoadConfig_EnvironmentVariables(t *testing.T) {
 	}
 }
 
+func TestLoadConfig_NotionTokenFromEnvironment(t *testing.T) {
+	t.Setenv("NOTION_TOKEN", "notion-secret")
+
+	config, err := LoadConfig("")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if config.Output.Notion.Token != "notion-secret" {
+		t.Errorf("Expected Notion token from NOTION_TOKEN, got %q", config.Output.Notion.Token)
+	}
+}
+
+func TestLoadConfig_ExpandsHeaderVariables(t *testing.T) {
+	t.Setenv("HELICONE_API_KEY", "helicone-secret")
+	t.Setenv("COLLECTOR_TOKEN", "collector-secret")
+
+	configPath := filepath.Join(t.TempDir(), "deepwiki.yaml")
+	content := `providers:
+  llm:
+    headers:
+      Helicone-Auth: "Bearer ${HELICONE_API_KEY}"
+  embedding:
+    fallbacks:
+      - provider: ollama
+        headers:
+          X-Gateway: "$HELICONE_API_KEY"
+tracing:
+  headers:
+    Authorization: "Bearer ${COLLECTOR_TOKEN}"
+`
+	if err := os.WriteFile(configPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if got := config.Providers.LLM.Headers["Helicone-Auth"]; got != "Bearer helicone-secret" {
+		t.Errorf("Expected the LLM header expanded, got %q", got)
+	}
+	if got := config.Providers.Embedding.Fallbacks[0].Headers["X-Gateway"]; got != "helicone-secret" {
+		t.Errorf("Expected the fallback header expanded, got %q", got)
+	}
+	if got := config.Tracing.Headers["Authorization"]; got != "Bearer collector-secret" {
+		t.Errorf("Expected the tracing header expanded, got %q", got)
+	}
+}
+
+func TestLoadEnvFile_FeedsConfig(t *testing.T) {
+	// Register restores for the variables the env file sets, then start with them unset
+	for _, key := range []string{"OPENAI_API_KEY", "DEEPWIKI_LLM_MODEL", "DEEPWIKI_FORMAT", "DEEPWIKI_OUTPUT_DIR"} {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+	t.Setenv("DEEPWIKI_LLM_MODEL", "exported-model")
+
+	envPath := filepath.Join(t.TempDir(), ".env")
+	content := `# Local secrets
+OPENAI_API_KEY=sk-from-dotenv
+export DEEPWIKI_LLM_MODEL="dotenv-model"
+DEEPWIKI_FORMAT='json' # quoted
+DEEPWIKI_OUTPUT_DIR=./wiki # trailing comment
+`
+	if err := os.WriteFile(envPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+
+	if err := LoadEnvFile(envPath); err != nil {
+		t.Fatalf("LoadEnvFile failed: %v", err)
+	}
+
+	config, err := LoadConfig("")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if config.Providers.LLM.APIKey != "sk-from-dotenv" {
+		t.Errorf("Expected API key from the env file, got %q", config.Providers.LLM.APIKey)
+	}
+	if config.Providers.LLM.Model != "exported-model" {
+		t.Errorf("Expected the exported model to take precedence, got %q", config.Providers.LLM.Model)
+	}
+	if config.Output.Format != "json" {
+		t.Errorf("Expected quoted format 'json', got %q", config.Output.Format)
+	}
+	if config.Output.Directory != "./wiki" {
+		t.Errorf("Expected output directory without the comment, got %q", config.Output.Directory)
+	}
+}
+
+func TestLoadEnvFile_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := LoadEnvFile(filepath.Join(dir, "missing.env")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected a not-exist error for a missing file, got %v", err)
+	}
+
+	for _, line := range []string{"NO_EQUALS_SIGN", "1BAD=value", `UNTERMINATED="value`} {
+		envPath := filepath.Join(dir, ".env")
+		if err := os.WriteFile(envPath, []byte(line+"\n"), 0o644); err != nil {
+			t.Fatalf("Failed to write env file: %v", err)
+		}
+		if err := LoadEnvFile(envPath); err == nil || !strings.Contains(err.Error(), "line 1") {
+			t.Errorf("Expected a line error for %q, got %v", line, err)
+		}
+	}
+}
+
 func TestValidateConfig_Valid(t *testing.T) {
 	config := DefaultConfig()
 
@@ -259,6 +459,178 @@ func TestValidateConfig_InvalidChunkOverlap(t *testing.T) {
 	}
 }
 
+func TestValidateConfig_NegativeWorkers(t *testing.T) {
+	config := DefaultConfig()
+	config.Processing.Workers = -1
+
+	err := validateConfig(config)
+	if err == nil || !strings.Contains(err.Error(), "workers cannot be negative") {
+		t.Errorf("Expected validation error for negative workers, got %v", err)
+	}
+}
+
+func TestValidateConfig_InvalidImportanceRule(t *testing.T) {
+	config := DefaultConfig()
+	config.Filters.ImportanceRules = []ImportanceRuleConfig{{Pattern: "cmd/**", Importance: 6}}
+
+	err := validateConfig(config)
+	if err == nil {
+		t.Error("Expected validation error for importance outside 1-5")
+	}
+}
+
+func TestValidateConfig_UnknownFrontmatterField(t *testing.T) {
+	config := DefaultConfig()
+	config.Output.Frontmatter = []string{"title", "author"}
+
+	err := validateConfig(config)
+	if err == nil || !strings.Contains(err.Error(), "author") {
+		t.Errorf("Expected an error for the unknown frontmatter field, got %v", err)
+	}
+}
+
+func TestValidateConfig_InvalidMode(t *testing.T) {
+	config := DefaultConfig()
+	config.Output.Mode = "single-page"
+
+	err := validateConfig(config)
+	if err == nil || !strings.Contains(err.Error(), "invalid output mode") {
+		t.Errorf("Expected an error for the unknown generation mode, got %v", err)
+	}
+}
+
+func TestValidateConfig_InvalidArchive(t *testing.T) {
+	config := DefaultConfig()
+	config.Output.Archive = "rar"
+
+	err := validateConfig(config)
+	if err == nil || !strings.Contains(err.Error(), "invalid output archive") {
+		t.Errorf("Expected an error for the unknown archive format, got %v", err)
+	}
+}
+
+func TestValidateConfig_InvalidEmbeddingsMetric(t *testing.T) {
+	config := DefaultConfig()
+	config.Embeddings.Metric = "jaccard"
+
+	err := validateConfig(config)
+	if err == nil || !strings.Contains(err.Error(), "invalid embeddings metric") {
+		t.Errorf("Expected an error for the unknown similarity metric, got %v", err)
+	}
+}
+
+func TestValidateConfig_InvalidEmbeddingsStrategy(t *testing.T) {
+	config := DefaultConfig()
+	config.Embeddings.Strategy = "fuzzy"
+
+	err := validateConfig(config)
+	if err == nil || !strings.Contains(err.Error(), "invalid embeddings strategy") {
+		t.Errorf("Expected an error for the unknown retrieval strategy, got %v", err)
+	}
+}
+
+func TestValidateConfig_InvalidEmbeddingsOpenTimeout(t *testing.T) {
+	config := DefaultConfig()
+	config.Embeddings.OpenTimeout = "soon"
+
+	err := validateConfig(config)
+	if err == nil || !strings.Contains(err.Error(), "invalid embeddings open_timeout") {
+		t.Errorf("Expected an error for the open timeout, got %v", err)
+	}
+}
+
+func TestLoadConfig_FileSizeLimits(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	content := "processing:\n  file_size_limits:\n    data: 1048576\n"
+	if err := os.WriteFile(configFile, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	config, err := LoadConfig(configFile)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if limit := config.Processing.FileSizeLimits["data"]; limit != 1048576 {
+		t.Errorf("Expected the configured data limit 1048576, got %d", limit)
+	}
+	if limit := config.Processing.FileSizeLimits["code"]; limit != 2*1024*1024 {
+		t.Errorf("Expected the default code limit to be kept, got %d", limit)
+	}
+
+	config.Processing.FileSizeLimits["binary"] = 10
+	if err := validateConfig(config); err == nil || !strings.Contains(err.Error(), "unknown content type") {
+		t.Errorf("Expected an error for the unknown content type, got %v", err)
+	}
+	delete(config.Processing.FileSizeLimits, "binary")
+	config.Processing.FileSizeLimits["test"] = 0
+	if err := validateConfig(config); err == nil || !strings.Contains(err.Error(), "must be positive") {
+		t.Errorf("Expected an error for a zero limit, got %v", err)
+	}
+}
+
+func TestLoadConfig_ChunkSizes(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	content := "processing:\n  chunk_sizes:\n    code: 200\n    docs: 600\n"
+	if err := os.WriteFile(configFile, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	config, err := LoadConfig(configFile)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if config.Processing.ChunkSizes["code"] != 200 || config.Processing.ChunkSizes["docs"] != 600 {
+		t.Errorf("Expected the configured chunk sizes, got %v", config.Processing.ChunkSizes)
+	}
+
+	config.Processing.ChunkSizes["assets"] = 300
+	if err := validateConfig(config); err == nil || !strings.Contains(err.Error(), "unknown category") {
+		t.Errorf("Expected an error for the unknown category, got %v", err)
+	}
+	delete(config.Processing.ChunkSizes, "assets")
+	config.Processing.ChunkSizes["config"] = config.Processing.ChunkOverlap
+	if err := validateConfig(config); err == nil || !strings.Contains(err.Error(), "greater than chunk overlap") {
+		t.Errorf("Expected an error for a chunk size not greater than the overlap, got %v", err)
+	}
+}
+
+func TestLoadConfig_LLMPhases(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	content := `providers:
+  llm:
+    temperature: 0.4
+    phases:
+      structure:
+        temperature: 0
+      page:
+        max_tokens: 6000
+`
+	if err := os.WriteFile(configFile, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	config, err := LoadConfig(configFile)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	llmConfig := config.Providers.LLM
+	temperature, maxTokens := llmConfig.PhaseSettings(llmConfig.Phases.Structure)
+	if temperature != 0 || maxTokens != 4000 {
+		t.Errorf("Expected structure settings 0 and 4000, got %v and %d", temperature, maxTokens)
+	}
+	temperature, maxTokens = llmConfig.PhaseSettings(llmConfig.Phases.Page)
+	if temperature != 0.4 || maxTokens != 6000 {
+		t.Errorf("Expected page settings 0.4 and 6000, got %v and %d", temperature, maxTokens)
+	}
+
+	tooHot := 2.5
+	config.Providers.LLM.Phases.Page.Temperature = &tooHot
+	if err := validateConfig(config); err == nil || !strings.Contains(err.Error(), "page phase temperature") {
+		t.Errorf("Expected an error for the page temperature, got %v", err)
+	}
+}
+
 func TestValidateConfig_InvalidFormat(t *testing.T) {
 	config := DefaultConfig()
 	config.Output.Format = "invalid"
@@ -395,3 +767,162 @@ func containsInMiddle(s, substr string) bool {
 	}
 	return false
 }
+
+func TestLLMCacheConfig_ToCacheOptions(t *testing.T) {
+	cacheConfig := LLMCacheConfig{Enabled: true, Directory: "/tmp/deepwiki-cache", TTL: "2h"}
+	options, err := cacheConfig.ToCacheOptions()
+	if err != nil {
+		t.Fatalf("ToCacheOptions failed: %v", err)
+	}
+	if options.Directory != "/tmp/deepwiki-cache" || options.TTL != 2*time.Hour {
+		t.Errorf("Unexpected cache options: %+v", options)
+	}
+
+	cacheConfig = LLMCacheConfig{Enabled: true, TTL: "soon"}
+	if _, err := cacheConfig.ToCacheOptions(); err == nil {
+		t.Error("Expected error for an invalid TTL")
+	}
+}
+
+func TestExpandOutputDirectory(t *testing.T) {
+	values := map[string]string{"project": "demo", "date": "2026-01-02", "format": "markdown", "commit": "abc1234"}
+	var resolved []string
+	resolve := func(placeholder string) (string, error) {
+		resolved = append(resolved, placeholder)
+		return values[placeholder], nil
+	}
+
+	dir, err := ExpandOutputDirectory("./wikis/{project}/{date}-{commit}/{format}/{project}", resolve)
+	if err != nil {
+		t.Fatalf("ExpandOutputDirectory failed: %v", err)
+	}
+	if dir != "./wikis/demo/2026-01-02-abc1234/markdown/demo" {
+		t.Errorf("Unexpected expanded directory %q", dir)
+	}
+	if len(resolved) != 4 {
+		t.Errorf("Expected each placeholder to be resolved once, got %v", resolved)
+	}
+
+	resolved = nil
+	if dir, err := ExpandOutputDirectory("./docs", resolve); err != nil || dir != "./docs" || len(resolved) != 0 {
+		t.Errorf("Expected a plain directory to be unchanged, got %q, %v (resolved %v)", dir, err, resolved)
+	}
+}
+
+func TestExpandOutputDirectory_Errors(t *testing.T) {
+	resolve := func(placeholder string) (string, error) {
+		if placeholder == "commit" {
+			return "", fmt.Errorf("not a git repository")
+		}
+		return "a/b", nil
+	}
+
+	for _, dir := range []string{"./wikis/{branch}", "./wikis/{}", "./wikis/{Project}"} {
+		_, err := ExpandOutputDirectory(dir, resolve)
+		if err == nil || !strings.Contains(err.Error(), "unknown placeholder") {
+			t.Errorf("Expected unknown placeholder error for %q, got %v", dir, err)
+		}
+	}
+	if _, err := ExpandOutputDirectory("./wikis/{commit}", resolve); err == nil {
+		t.Error("Expected an error when a placeholder cannot be resolved")
+	}
+	if _, err := ExpandOutputDirectory("./wikis/{project}", resolve); err == nil {
+		t.Error("Expected an error for a value containing a path separator")
+	}
+}
+
+func TestValidateConfig_UnknownOutputPlaceholder(t *testing.T) {
+	config := DefaultConfig()
+	config.Output.Directory = "./wikis/{project}/{branch}"
+
+	if err := validateConfig(config); err == nil {
+		t.Error("Expected validation error for an unknown output directory placeholder")
+	}
+}
+
+func TestGetProviders_ShareAccountRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/chat":
+			fmt.Fprint(w, `{"model":"llama3","message":{"role":"assistant","content":"Hello"},"done":true}`)
+		case "/api/embeddings":
+			fmt.Fprint(w, `{"embedding":[0.1,0.2]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	content := fmt.Sprintf(`providers:
+  account_rate_limit_rps: 50
+  llm:
+    provider: ollama
+    base_url: %[1]s
+  embedding:
+    provider: ollama
+    model: nomic-embed-text
+    base_url: %[1]s
+`, server.URL)
+	if err := os.WriteFile(configFile, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	config, err := LoadConfig(configFile)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	llmProvider, err := config.GetLLMProvider()
+	if err != nil {
+		t.Fatalf("GetLLMProvider failed: %v", err)
+	}
+	embeddingProvider, err := config.GetEmbeddingProvider()
+	if err != nil {
+		t.Fatalf("GetEmbeddingProvider failed: %v", err)
+	}
+	if config.scheduler == nil {
+		t.Fatal("Expected the providers to share the account scheduler")
+	}
+
+	// Every HTTP request is scheduled, including each single-text Ollama embedding request
+	messages := []llm.Message{{Role: "user", Content: "Hello"}}
+	if _, err := llmProvider.ChatCompletion(context.Background(), messages); err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+	if _, err := embeddingProvider.CreateEmbeddings(context.Background(), []string{"one", "two"}); err != nil {
+		t.Fatalf("CreateEmbeddings failed: %v", err)
+	}
+	if granted := config.scheduler.Granted(llm.SchedulerClass); granted != 1 {
+		t.Errorf("Expected 1 scheduled LLM request, got %d", granted)
+	}
+	if granted := config.scheduler.Granted(embedding.SchedulerClass); granted != 2 {
+		t.Errorf("Expected 2 scheduled embedding requests, got %d", granted)
+	}
+
+	config.Providers.AccountRateLimitRPS = -1
+	if err := validateConfig(config); err == nil || !strings.Contains(err.Error(), "account rate limit") {
+		t.Errorf("Expected an error for the negative account rate limit, got %v", err)
+	}
+}
+
+func TestValidateConfig_MinScoreRelaxation(t *testing.T) {
+	config := DefaultConfig()
+	config.Embeddings.MinResults = 3
+	config.Embeddings.MinScoreFloor = 0.05
+	if err := validateConfig(config); err != nil {
+		t.Fatalf("Expected a valid relaxation, got %v", err)
+	}
+
+	config.Embeddings.MinScoreFloor = config.Embeddings.MinScore + 0.1
+	if err := validateConfig(config); err == nil || !strings.Contains(err.Error(), "min_score_floor") {
+		t.Errorf("Expected an error for a floor above min_score, got %v", err)
+	}
+
+	config.Embeddings.MinScoreFloor = 0
+	config.Embeddings.MinResults = -1
+	if err := validateConfig(config); err == nil || !strings.Contains(err.Error(), "min_results") {
+		t.Errorf("Expected an error for negative min_results, got %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// DefaultEnvFile is the env file loaded from the working directory when no path is given
+const DefaultEnvFile = ".env"
+
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// LoadEnvFile sets the variables defined in a .env file. Variables that are already set in the
+// environment keep their value. Lines have the form KEY=value, optionally prefixed with
+// "export"; values may be single-quoted (literal) or double-quoted (with \n, \t, \" and \\
+// escapes), and unquoted values end at a " #" comment.
+func LoadEnvFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open env file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, err := parseEnvLine(line)
+		if err != nil {
+			return fmt.Errorf("invalid env file %s line %d: %w", path, lineNumber, err)
+		}
+		if _, set := os.LookupEnv(key); set {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("failed to set %s: %w", key, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read env file: %w", err)
+	}
+
+	return nil
+}
+
+// parseEnvLine splits a non-empty, non-comment line into its key and unquoted value
+func parseEnvLine(line string) (string, string, error) {
+	line = strings.TrimPrefix(line, "export ")
+	key, value, ok := strings.Cut(line, "=")
+	if !ok {
+		return "", "", fmt.Errorf("expected KEY=value")
+	}
+	key = strings.TrimSpace(key)
+	if !envKeyPattern.MatchString(key) {
+		return "", "", fmt.Errorf("invalid variable name %q", key)
+	}
+	value = strings.TrimSpace(value)
+
+	switch {
+	case strings.HasPrefix(value, "'"):
+		end := strings.Index(value[1:], "'")
+		if end < 0 {
+			return "", "", fmt.Errorf("unterminated single-quoted value for %s", key)
+		}
+		return key, value[1 : end+1], nil
+	case strings.HasPrefix(value, `"`):
+		var unquoted strings.Builder
+		for i := 1; i < len(value); i++ {
+			switch c := value[i]; {
+			case c == '"':
+				return key, unquoted.String(), nil
+			case c == '\\' && i+1 < len(value):
+				i++
+				switch value[i] {
+				case 'n':
+					unquoted.WriteByte('\n')
+				case 't':
+					unquoted.WriteByte('\t')
+				default:
+					unquoted.WriteByte(value[i])
+				}
+			default:
+				unquoted.WriteByte(c)
+			}
+		}
+		return "", "", fmt.Errorf("unterminated double-quoted value for %s", key)
+	}
+
+	if comment := strings.Index(value, " #"); comment >= 0 {
+		value = strings.TrimSpace(value[:comment])
+	}
+	return key, value, nil
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// OutputDirPlaceholders are the placeholders allowed in output.directory, e.g. "./wikis/{project}/{date}"
+var OutputDirPlaceholders = []string{"project", "date", "format", "commit"}
+
+// outputDirPlaceholderPattern matches a {name} placeholder
+var outputDirPlaceholderPattern = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// ValidateOutputDirectory checks that an output directory only uses known placeholders
+func ValidateOutputDirectory(dir string) error {
+	for _, match := range outputDirPlaceholderPattern.FindAllStringSubmatch(dir, -1) {
+		if !isOutputDirPlaceholder(match[1]) {
+			return fmt.Errorf("unknown placeholder %s in output directory %q (valid: {%s})",
+				match[0], dir, strings.Join(OutputDirPlaceholders, "}, {"))
+		}
+	}
+	return nil
+}
+
+// ExpandOutputDirectory replaces the placeholders in an output directory.
+// resolve is only called for placeholders that dir uses.
+func ExpandOutputDirectory(dir string, resolve func(placeholder string) (string, error)) (string, error) {
+	if err := ValidateOutputDirectory(dir); err != nil {
+		return "", err
+	}
+
+	values := make(map[string]string)
+	for _, match := range outputDirPlaceholderPattern.FindAllStringSubmatch(dir, -1) {
+		name := match[1]
+		if _, ok := values[name]; ok {
+			continue
+		}
+
+		value, err := resolve(name)
+		if err != nil {
+			return "", fmt.Errorf("failed to expand {%s} in output directory: %w", name, err)
+		}
+		if value == "" || strings.ContainsAny(value, `/\`) {
+			return "", fmt.Errorf("invalid value %q for {%s} in output directory", value, name)
+		}
+		values[name] = value
+	}
+
+	return outputDirPlaceholderPattern.ReplaceAllStringFunc(dir, func(placeholder string) string {
+		return values[strings.Trim(placeholder, "{}")]
+	}), nil
+}
+
+// isOutputDirPlaceholder reports whether name is a supported placeholder
+func isOutputDirPlaceholder(name string) bool {
+	for _, placeholder := range OutputDirPlaceholders {
+		if name == placeholder {
+			return true
+		}
+	}
+	return false
+}
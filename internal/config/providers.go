@@ -2,6 +2,8 @@ package config
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/kuderr/deepwiki/pkg/embedding"
@@ -12,6 +14,10 @@ import (
 type ProviderConfig struct {
 	LLM       LLMConfig       `yaml:"llm"`
 	Embedding EmbeddingConfig `yaml:"embedding"`
+
+	// Requests per second shared by LLM and embedding requests, for providers billed to the same
+	// account (0 = disabled). Both kinds of requests take turns when they compete for it.
+	AccountRateLimitRPS float64 `yaml:"account_rate_limit_rps"`
 }
 
 // LLMConfig contains LLM provider configuration
@@ -26,11 +32,60 @@ type LLMConfig struct {
 	RetryDelay     string  `yaml:"retry_delay"` // Duration string like "1s"
 	RateLimitRPS   float64 `yaml:"rate_limit_rps"`
 	BaseURL        string  `yaml:"base_url"` // For custom endpoints
+
+	MaxConcurrentRequests int `yaml:"max_concurrent_requests"` // Requests in flight at once (0 = unlimited)
+
+	// Consecutive failed requests that make later requests fail fast (0 = disabled)
+	CircuitBreakerThreshold int    `yaml:"circuit_breaker_threshold"`
+	CircuitBreakerCooldown  string `yaml:"circuit_breaker_cooldown"` // Duration string like "30s"
+
+	Headers         map[string]string `yaml:"headers,omitempty"`          // Extra headers sent with every request
+	OverrideHeaders bool              `yaml:"override_headers,omitempty"` // Let headers replace auth/content type
+
+	Cache LLMCacheConfig `yaml:"cache"`
+
+	// Phases overrides the temperature and max tokens of each generation phase
+	Phases LLMPhasesConfig `yaml:"phases"`
+
+	// Context window of the model in tokens, for models not known by name (0 = look it up)
+	ContextWindow int `yaml:"context_window"`
+}
+
+// LLMPhasesConfig holds the LLM overrides of the generation phases
+type LLMPhasesConfig struct {
+	Structure LLMPhaseConfig `yaml:"structure"` // Planning the wiki structure
+	Page      LLMPhaseConfig `yaml:"page"`      // Writing page content
+}
+
+// LLMPhaseConfig overrides the temperature and max tokens of one generation phase
+type LLMPhaseConfig struct {
+	Temperature *float64 `yaml:"temperature"` // Unset uses the LLM temperature
+	MaxTokens   int      `yaml:"max_tokens"`  // 0 uses the LLM max tokens
+}
+
+// PhaseSettings returns the temperature and max tokens of a generation phase, falling back to
+// the LLM settings for values the phase does not override
+func (c *LLMConfig) PhaseSettings(phase LLMPhaseConfig) (temperature float64, maxTokens int) {
+	temperature, maxTokens = c.Temperature, c.MaxTokens
+	if phase.Temperature != nil {
+		temperature = *phase.Temperature
+	}
+	if phase.MaxTokens > 0 {
+		maxTokens = phase.MaxTokens
+	}
+	return temperature, maxTokens
+}
+
+// LLMCacheConfig controls the on-disk cache of LLM responses
+type LLMCacheConfig struct {
+	Enabled   bool   `yaml:"enabled"`
+	Directory string `yaml:"directory"` // Defaults to deepwiki/llm in the user cache directory
+	TTL       string `yaml:"ttl"`       // Duration string like "24h"
 }
 
 // EmbeddingConfig contains embedding provider configuration
 type EmbeddingConfig struct {
-	Provider       string  `yaml:"provider"` // "openai", "voyage", or "ollama"
+	Provider       string  `yaml:"provider"` // "openai", "voyage", "ollama", or "tei"
 	APIKey         string  `yaml:"api_key"`
 	Model          string  `yaml:"model"`
 	RequestTimeout string  `yaml:"request_timeout"` // Duration string like "30s"
@@ -39,20 +94,38 @@ type EmbeddingConfig struct {
 	RateLimitRPS   float64 `yaml:"rate_limit_rps"`
 	BaseURL        string  `yaml:"base_url"`   // For custom endpoints (Ollama)
 	Dimensions     int     `yaml:"dimensions"` // For some providers
+
+	// Consecutive failed requests that make later requests fail fast (0 = disabled)
+	CircuitBreakerThreshold int    `yaml:"circuit_breaker_threshold"`
+	CircuitBreakerCooldown  string `yaml:"circuit_breaker_cooldown"` // Duration string like "30s"
+
+	Headers         map[string]string `yaml:"headers,omitempty"`          // Extra headers sent with every request
+	OverrideHeaders bool              `yaml:"override_headers,omitempty"` // Let headers replace auth/content type
+
+	// Fallbacks are tried in order when the provider above fails
+	Fallbacks []EmbeddingConfig `yaml:"fallbacks,omitempty"`
 }
 
 // DefaultProviderConfig returns default provider configuration
 func DefaultProviderConfig() *ProviderConfig {
 	return &ProviderConfig{
 		LLM: LLMConfig{
-			Provider:       "openai",
-			Model:          "gpt-4o",
-			MaxTokens:      4000,
-			Temperature:    0.1,
-			RequestTimeout: "3m",
-			MaxRetries:     3,
-			RetryDelay:     "1s",
-			RateLimitRPS:   2.0,
+			Provider:              "openai",
+			Model:                 "gpt-4o",
+			MaxTokens:             4000,
+			Temperature:           0.1,
+			RequestTimeout:        "3m",
+			MaxRetries:            3,
+			RetryDelay:            "1s",
+			RateLimitRPS:          2.0,
+			MaxConcurrentRequests: 4,
+
+			CircuitBreakerThreshold: 5,
+			CircuitBreakerCooldown:  "30s",
+
+			Cache: LLMCacheConfig{
+				TTL: "24h",
+			},
 		},
 		Embedding: EmbeddingConfig{
 			Provider:       "openai",
@@ -61,10 +134,71 @@ func DefaultProviderConfig() *ProviderConfig {
 			MaxRetries:     3,
 			RetryDelay:     "1s",
 			RateLimitRPS:   10.0,
+
+			CircuitBreakerThreshold: 5,
+			CircuitBreakerCooldown:  "30s",
 		},
 	}
 }
 
+// llmAPIKeyEnv and embeddingAPIKeyEnv name the environment variable holding the API key of
+// each provider; Ollama needs none
+var (
+	llmAPIKeyEnv       = map[string]string{"openai": "OPENAI_API_KEY", "anthropic": "ANTHROPIC_API_KEY"}
+	embeddingAPIKeyEnv = map[string]string{"openai": "OPENAI_API_KEY", "voyage": "VOYAGE_API_KEY"}
+)
+
+// SetProvider switches the LLM provider. The model, base URL and API key of the previous
+// provider are dropped: the model becomes the default of the new provider and the API key is
+// read from its environment variable.
+func (c *LLMConfig) SetProvider(provider string) error {
+	if provider == c.Provider {
+		return nil
+	}
+
+	switched := *c
+	switched.Provider = provider
+	switched.Model, switched.BaseURL, switched.APIKey = "", "", ""
+	if env, ok := llmAPIKeyEnv[provider]; ok {
+		switched.APIKey = os.Getenv(env)
+	}
+
+	llmConfig, err := switched.ToLLMConfig()
+	if err != nil {
+		return err
+	}
+	switched.Model = llmConfig.Model
+
+	*c = switched
+	return nil
+}
+
+// SetProvider switches the embedding provider. The model, base URL, dimensions and API key of
+// the previous provider are dropped: the model becomes the default of the new provider and the
+// API key is read from its environment variable.
+func (c *EmbeddingConfig) SetProvider(provider string) error {
+	if provider == c.Provider {
+		return nil
+	}
+
+	switched := *c
+	switched.Provider = provider
+	switched.Model, switched.BaseURL, switched.APIKey = "", "", ""
+	switched.Dimensions = 0
+	if env, ok := embeddingAPIKeyEnv[provider]; ok {
+		switched.APIKey = os.Getenv(env)
+	}
+
+	embeddingConfig, err := switched.ToEmbeddingConfig()
+	if err != nil {
+		return err
+	}
+	switched.Model = embeddingConfig.Model
+
+	*c = switched
+	return nil
+}
+
 // ToLLMConfig converts the application LLM config to llm.Config
 func (c *LLMConfig) ToLLMConfig() (*llm.Config, error) {
 	// Parse durations
@@ -78,6 +212,11 @@ func (c *LLMConfig) ToLLMConfig() (*llm.Config, error) {
 		retryDelay = 1 * time.Second // Default
 	}
 
+	breakerCooldown, err := time.ParseDuration(c.CircuitBreakerCooldown)
+	if err != nil {
+		breakerCooldown = 30 * time.Second // Default
+	}
+
 	// Convert provider type
 	var providerType llm.ProviderType
 	switch c.Provider {
@@ -92,16 +231,22 @@ func (c *LLMConfig) ToLLMConfig() (*llm.Config, error) {
 	}
 
 	config := &llm.Config{
-		Provider:       providerType,
-		APIKey:         c.APIKey,
-		Model:          c.Model,
-		MaxTokens:      c.MaxTokens,
-		Temperature:    c.Temperature,
-		RequestTimeout: requestTimeout,
-		MaxRetries:     c.MaxRetries,
-		RetryDelay:     retryDelay,
-		RateLimitRPS:   c.RateLimitRPS,
-		BaseURL:        c.BaseURL,
+		Provider:              providerType,
+		APIKey:                c.APIKey,
+		Model:                 c.Model,
+		MaxTokens:             c.MaxTokens,
+		Temperature:           c.Temperature,
+		RequestTimeout:        requestTimeout,
+		MaxRetries:            c.MaxRetries,
+		RetryDelay:            retryDelay,
+		RateLimitRPS:          c.RateLimitRPS,
+		BaseURL:               c.BaseURL,
+		Headers:               c.Headers,
+		MaxConcurrentRequests: c.MaxConcurrentRequests,
+		OverrideHeaders:       c.OverrideHeaders,
+
+		CircuitBreakerThreshold: c.CircuitBreakerThreshold,
+		CircuitBreakerCooldown:  breakerCooldown,
 	}
 
 	// Set defaults if not specified
@@ -110,7 +255,7 @@ func (c *LLMConfig) ToLLMConfig() (*llm.Config, error) {
 		case llm.ProviderOpenAI:
 			config.Model = "gpt-4o"
 		case llm.ProviderAnthropic:
-			config.Model = "claude-3-5-sonnet-20241022"
+			config.Model = "claude-sonnet-4-0"
 		case llm.ProviderOllama:
 			config.Model = "llama3.1"
 		}
@@ -130,6 +275,29 @@ func (c *LLMConfig) ToLLMConfig() (*llm.Config, error) {
 	return config, nil
 }
 
+// ToCacheOptions converts the application cache config to llm.CacheOptions
+func (c *LLMCacheConfig) ToCacheOptions() (llm.CacheOptions, error) {
+	options := llm.CacheOptions{Directory: c.Directory}
+
+	if c.TTL != "" {
+		ttl, err := time.ParseDuration(c.TTL)
+		if err != nil {
+			return options, fmt.Errorf("invalid cache TTL %q: %w", c.TTL, err)
+		}
+		options.TTL = ttl
+	}
+
+	if options.Directory == "" {
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return options, fmt.Errorf("failed to locate user cache directory: %w", err)
+		}
+		options.Directory = filepath.Join(cacheDir, "deepwiki", "llm")
+	}
+
+	return options, nil
+}
+
 // ToEmbeddingConfig converts the application embedding config to embedding.Config
 func (c *EmbeddingConfig) ToEmbeddingConfig() (*embedding.Config, error) {
 	// Parse durations
@@ -143,6 +311,11 @@ func (c *EmbeddingConfig) ToEmbeddingConfig() (*embedding.Config, error) {
 		retryDelay = 1 * time.Second // Default
 	}
 
+	breakerCooldown, err := time.ParseDuration(c.CircuitBreakerCooldown)
+	if err != nil {
+		breakerCooldown = 30 * time.Second // Default
+	}
+
 	// Convert provider type
 	var providerType embedding.ProviderType
 	switch c.Provider {
@@ -152,20 +325,27 @@ func (c *EmbeddingConfig) ToEmbeddingConfig() (*embedding.Config, error) {
 		providerType = embedding.ProviderVoyage
 	case "ollama":
 		providerType = embedding.ProviderOllama
+	case "tei":
+		providerType = embedding.ProviderTEI
 	default:
 		return nil, fmt.Errorf("unsupported embedding provider: %s", c.Provider)
 	}
 
 	config := &embedding.Config{
-		Provider:       providerType,
-		APIKey:         c.APIKey,
-		Model:          c.Model,
-		RequestTimeout: requestTimeout,
-		MaxRetries:     c.MaxRetries,
-		RetryDelay:     retryDelay,
-		RateLimitRPS:   c.RateLimitRPS,
-		BaseURL:        c.BaseURL,
-		Dimensions:     c.Dimensions,
+		Provider:        providerType,
+		APIKey:          c.APIKey,
+		Model:           c.Model,
+		RequestTimeout:  requestTimeout,
+		MaxRetries:      c.MaxRetries,
+		RetryDelay:      retryDelay,
+		RateLimitRPS:    c.RateLimitRPS,
+		BaseURL:         c.BaseURL,
+		Dimensions:      c.Dimensions,
+		Headers:         c.Headers,
+		OverrideHeaders: c.OverrideHeaders,
+
+		CircuitBreakerThreshold: c.CircuitBreakerThreshold,
+		CircuitBreakerCooldown:  breakerCooldown,
 	}
 
 	// Set defaults if not specified
@@ -177,6 +357,8 @@ func (c *EmbeddingConfig) ToEmbeddingConfig() (*embedding.Config, error) {
 			config.Model = "voyage-3-large"
 		case embedding.ProviderOllama:
 			config.Model = "nomic-embed-text"
+		case embedding.ProviderTEI:
+			config.Model = "BAAI/bge-base-en-v1.5"
 		}
 	}
 
@@ -188,8 +370,46 @@ func (c *EmbeddingConfig) ToEmbeddingConfig() (*embedding.Config, error) {
 			config.BaseURL = "https://api.voyageai.com/v1"
 		case embedding.ProviderOllama:
 			config.BaseURL = "http://localhost:11434"
+		case embedding.ProviderTEI:
+			config.BaseURL = "http://localhost:8080"
 		}
 	}
 
 	return config, nil
 }
+
+// ToFallbackConfigs converts the configured fallback providers to embedding.Config.
+// Unset connection settings are inherited from the primary provider.
+func (c *EmbeddingConfig) ToFallbackConfigs() ([]*embedding.Config, error) {
+	configs := make([]*embedding.Config, 0, len(c.Fallbacks))
+	for i, fallback := range c.Fallbacks {
+		if fallback.RequestTimeout == "" {
+			fallback.RequestTimeout = c.RequestTimeout
+		}
+		if fallback.RetryDelay == "" {
+			fallback.RetryDelay = c.RetryDelay
+		}
+		if fallback.MaxRetries == 0 {
+			fallback.MaxRetries = c.MaxRetries
+		}
+		if fallback.RateLimitRPS == 0 {
+			fallback.RateLimitRPS = c.RateLimitRPS
+		}
+		if fallback.Dimensions == 0 {
+			fallback.Dimensions = c.Dimensions
+		}
+		if fallback.CircuitBreakerThreshold == 0 {
+			fallback.CircuitBreakerThreshold = c.CircuitBreakerThreshold
+		}
+		if fallback.CircuitBreakerCooldown == "" {
+			fallback.CircuitBreakerCooldown = c.CircuitBreakerCooldown
+		}
+
+		config, err := fallback.ToEmbeddingConfig()
+		if err != nil {
+			return nil, fmt.Errorf("invalid fallback %d: %w", i+1, err)
+		}
+		configs = append(configs, config)
+	}
+	return configs, nil
+}
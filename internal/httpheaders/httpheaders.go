@@ -0,0 +1,40 @@
+// Package httpheaders applies user-configured headers to outbound provider requests.
+package httpheaders
+
+import (
+	"net/http"
+	"os"
+)
+
+// protectedHeaders carry credentials and the body encoding set by each provider. Custom headers
+// only replace them when override is set.
+var protectedHeaders = []string{"Authorization", "Content-Type", "X-Api-Key"}
+
+// Apply adds headers to an outbound request, skipping the protected headers unless override is set.
+// It must be called after the provider has set its own headers.
+func Apply(req *http.Request, headers map[string]string, override bool) {
+	for name, value := range headers {
+		if !override && IsProtected(name) {
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+}
+
+// IsProtected reports whether name is one of the protected headers
+func IsProtected(name string) bool {
+	canonical := http.CanonicalHeaderKey(name)
+	for _, protected := range protectedHeaders {
+		if canonical == protected {
+			return true
+		}
+	}
+	return false
+}
+
+// Expand replaces ${VAR} and $VAR in header values with the environment variables
+func Expand(headers map[string]string) {
+	for name, value := range headers {
+		headers[name] = os.ExpandEnv(value)
+	}
+}
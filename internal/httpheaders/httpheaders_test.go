@@ -0,0 +1,43 @@
+package httpheaders
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestApply_SkipsProtectedHeaders(t *testing.T) {
+	headers := map[string]string{
+		"authorization": "Bearer custom",
+		"X-Request-Tag": "docs",
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost", nil)
+	req.Header.Set("Authorization", "Bearer provider")
+	Apply(req, headers, false)
+
+	if got := req.Header.Get("Authorization"); got != "Bearer provider" {
+		t.Errorf("Expected the provider's Authorization header to be kept, got %q", got)
+	}
+	if got := req.Header.Get("X-Request-Tag"); got != "docs" {
+		t.Errorf("Expected the custom header to be added, got %q", got)
+	}
+
+	Apply(req, headers, true)
+	if got := req.Header.Get("Authorization"); got != "Bearer custom" {
+		t.Errorf("Expected the override to replace Authorization, got %q", got)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	t.Setenv("HTTPHEADERS_TEST_KEY", "secret")
+
+	headers := map[string]string{
+		"Helicone-Auth": "Bearer ${HTTPHEADERS_TEST_KEY}",
+		"X-Plain":       "$HTTPHEADERS_TEST_KEY",
+	}
+	Expand(headers)
+
+	if headers["Helicone-Auth"] != "Bearer secret" || headers["X-Plain"] != "secret" {
+		t.Errorf("Expected environment variables to be expanded, got %v", headers)
+	}
+}
@@ -17,8 +17,44 @@ const (
 	LevelInfo  LogLevel = "info"
 	LevelWarn  LogLevel = "warn"
 	LevelError LogLevel = "error"
+	LevelTrace LogLevel = "trace"
 )
 
+// SlogLevelTrace is the slog level used for request tracing, below debug
+const SlogLevelTrace = slog.LevelDebug - 4
+
+// SlogLevel returns the slog level for the log level, defaulting to info
+func (l LogLevel) SlogLevel() slog.Level {
+	switch l {
+	case LevelTrace:
+		return SlogLevelTrace
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelWarn:
+		return slog.LevelWarn
+	case LevelError:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// LevelForVerbosity maps a repeated -v flag count to a log level:
+// -v logs phase-level info, -vv debug details and -vvv full request tracing.
+// A count of zero returns an empty level, leaving the configured level in place.
+func LevelForVerbosity(count int) LogLevel {
+	switch {
+	case count <= 0:
+		return ""
+	case count == 1:
+		return LevelInfo
+	case count == 2:
+		return LevelDebug
+	default:
+		return LevelTrace
+	}
+}
+
 // LogConfig represents logging configuration
 type LogConfig struct {
 	Level      LogLevel `yaml:"level"       json:"level"`
@@ -73,24 +109,9 @@ func NewLogger(config *LogConfig) (*Logger, error) {
 		output = file
 	}
 
-	// Configure slog level
-	var level slog.Level
-	switch config.Level {
-	case LevelDebug:
-		level = slog.LevelDebug
-	case LevelInfo:
-		level = slog.LevelInfo
-	case LevelWarn:
-		level = slog.LevelWarn
-	case LevelError:
-		level = slog.LevelError
-	default:
-		level = slog.LevelInfo
-	}
-
 	// Create handler options
 	opts := &slog.HandlerOptions{
-		Level:     level,
+		Level:     config.Level.SlogLevel(),
 		AddSource: config.AddSource,
 		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
 			// Customize time format
@@ -99,6 +120,12 @@ func NewLogger(config *LogConfig) (*Logger, error) {
 					a.Value = slog.StringValue(t.Format(config.TimeFormat))
 				}
 			}
+			// Name the trace level instead of printing it as DEBUG-4
+			if a.Key == slog.LevelKey {
+				if level, ok := a.Value.Any().(slog.Level); ok && level == SlogLevelTrace {
+					a.Value = slog.StringValue("TRACE")
+				}
+			}
 			return a
 		},
 	}
@@ -154,6 +181,11 @@ func (l *Logger) WithRequestID(requestID string) *Logger {
 	}
 }
 
+// Trace logs a message at trace level, used for full request and response bodies
+func (l *Logger) Trace(msg string, args ...any) {
+	l.Logger.Log(context.Background(), SlogLevelTrace, msg, args...)
+}
+
 // LogError logs an error with additional context
 func (l *Logger) LogError(ctx context.Context, msg string, err error, attrs ...slog.Attr) {
 	if err != nil {
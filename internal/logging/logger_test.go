@@ -3,6 +3,7 @@ package logging
 import (
 	"context"
 	"errors"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
@@ -315,6 +316,61 @@ func TestLogLevels(t *testing.T) {
 	}
 }
 
+func TestLevelForVerbosity(t *testing.T) {
+	tests := []struct {
+		count    int
+		level    LogLevel
+		expected slog.Level
+	}{
+		{1, LevelInfo, slog.LevelInfo},
+		{2, LevelDebug, slog.LevelDebug},
+		{3, LevelTrace, SlogLevelTrace},
+		{5, LevelTrace, SlogLevelTrace},
+	}
+
+	ctx := context.Background()
+	for _, test := range tests {
+		level := LevelForVerbosity(test.count)
+		if level != test.level {
+			t.Errorf("Verbosity %d: expected level '%s', got '%s'", test.count, test.level, level)
+		}
+
+		logger, err := NewLogger(&LogConfig{Level: level, Output: "stderr"})
+		if err != nil {
+			t.Fatalf("NewLogger failed: %v", err)
+		}
+		if !logger.Enabled(ctx, test.expected) {
+			t.Errorf("Verbosity %d: expected %s to be enabled", test.count, test.expected)
+		}
+		if logger.Enabled(ctx, test.expected-1) {
+			t.Errorf("Verbosity %d: expected levels below %s to be disabled", test.count, test.expected)
+		}
+	}
+
+	if level := LevelForVerbosity(0); level != "" {
+		t.Errorf("Expected no level override without -v, got '%s'", level)
+	}
+}
+
+func TestLogger_Trace(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "trace.log")
+
+	logger, err := NewLogger(&LogConfig{Level: LevelTrace, Format: "text", Output: logFile})
+	if err != nil {
+		t.Fatalf("NewLogger failed: %v", err)
+	}
+	logger.Trace("request body", "body", "{}")
+	logger.Close()
+
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	if !strings.Contains(string(content), "level=TRACE") {
+		t.Errorf("Expected trace level in output, got: %s", content)
+	}
+}
+
 func TestLogger_Close(t *testing.T) {
 	// Test close with stdout/stderr (should not error for standard outputs)
 	config := &LogConfig{
@@ -0,0 +1,61 @@
+// Package apierror classifies the errors of provider API requests, so that callers can tell
+// failures worth retrying, or failing over, from errors caused by the request itself.
+package apierror
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// StatusError is an error response of a provider API
+type StatusError struct {
+	StatusCode int
+	Err        error // Describes the response, e.g. with the message of the API
+}
+
+// New wraps err, describing a response with statusCode, in a StatusError
+func New(statusCode int, err error) error {
+	return &StatusError{StatusCode: statusCode, Err: err}
+}
+
+// Error returns the description of the response
+func (e *StatusError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the description of the response
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode returns the status of the error response in the chain of err, or 0 when there is none
+func StatusCode(err error) int {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode
+	}
+	return 0
+}
+
+// IsTransient reports whether err may not recur when the request is sent again: a server error,
+// rate limit or request timeout response, a timeout, or a failure to reach the API. Other errors,
+// such as an invalid request or input, recur as long as the request is the same.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	if status := StatusCode(err); status != 0 {
+		return status >= http.StatusInternalServerError ||
+			status == http.StatusTooManyRequests || status == http.StatusRequestTimeout
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	// Failures of the HTTP transport, such as refused connections, DNS errors and timeouts
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,52 @@
+package apierror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"testing"
+)
+
+func TestIsTransient(t *testing.T) {
+	connectionRefused := &url.Error{
+		Op:  "Post",
+		URL: "http://localhost:1/v1/embeddings",
+		Err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+	}
+
+	tests := []struct {
+		name      string
+		err       error
+		transient bool
+	}{
+		{"nil", nil, false},
+		{"server error", New(502, errors.New("bad gateway")), true},
+		{"rate limit", fmt.Errorf("batch 0-10 failed: %w", New(429, errors.New("slow down"))), true},
+		{"request timeout", New(408, errors.New("timeout")), true},
+		{"invalid input", New(400, errors.New("input too long")), false},
+		{"unauthorized", New(401, errors.New("invalid API key")), false},
+		{"deadline", fmt.Errorf("request failed: %w", context.DeadlineExceeded), true},
+		{"connection refused", fmt.Errorf("request failed after 3 retries: %w", connectionRefused), true},
+		{"cancelled", &url.Error{Op: "Post", URL: "http://localhost", Err: context.Canceled}, false},
+		{"malformed response", errors.New("expected 2 embeddings, got 1"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTransient(tt.err); got != tt.transient {
+				t.Errorf("IsTransient(%v) = %v, want %v", tt.err, got, tt.transient)
+			}
+		})
+	}
+}
+
+func TestStatusError_KeepsMessage(t *testing.T) {
+	err := fmt.Errorf("batch 0-1 failed: %w", New(400, errors.New("API error: input too long")))
+	if err.Error() != "batch 0-1 failed: API error: input too long" {
+		t.Errorf("Unexpected message %q", err.Error())
+	}
+	if StatusCode(err) != 400 {
+		t.Errorf("Expected status 400, got %d", StatusCode(err))
+	}
+}
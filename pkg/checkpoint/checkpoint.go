@@ -0,0 +1,329 @@
+// Package checkpoint records the progress of a generate run, so that a run that dies partway
+// can resume from the last completed item instead of starting over.
+package checkpoint
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kuderr/deepwiki/pkg/embeddings"
+	"github.com/kuderr/deepwiki/pkg/generator"
+	"github.com/kuderr/deepwiki/pkg/processor"
+)
+
+// Phase is the furthest phase of a run a checkpoint has reached
+type Phase string
+
+// Phases of a run, in order
+const (
+	PhaseStarted    Phase = "started"
+	PhaseEmbeddings Phase = "embeddings" // Document embeddings are complete
+	PhaseStructure  Phase = "structure"  // The wiki structure is generated
+	PhasePages      Phase = "pages"      // Pages are being generated
+)
+
+// version is bumped when the files of a checkpoint change incompatibly; older checkpoints are ignored
+const version = 1
+
+const (
+	stateFileName      = "state.json"
+	embeddingsFileName = "embeddings.json"
+)
+
+// state is the content of state.json
+type state struct {
+	Version   int                            `json:"version"`
+	Phase     Phase                          `json:"phase"`
+	RunKey    string                         `json:"runKey"`
+	Structure *generator.WikiStructure       `json:"structure,omitempty"`
+	Pages     map[string]*generator.WikiPage `json:"pages,omitempty"`
+	UpdatedAt time.Time                      `json:"updatedAt"`
+}
+
+// Checkpoint keeps the embeddings of each document and the wiki structure and pages of a run in
+// a directory, rewriting its files as items complete. Embeddings are keyed by the content of
+// their document and the embedding model, so changed documents are embedded again. The structure
+// and pages belong to a run key describing the generation settings; they are dropped when a
+// resumed run has other settings. Checkpoint implements generator.Checkpoint.
+type Checkpoint struct {
+	dir        string
+	mu         sync.Mutex
+	state      state
+	embeddings map[string]*embeddings.DocumentEmbedding
+}
+
+// DefaultDir returns the checkpoint directory of a project source in the user cache directory
+func DefaultDir(source string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate user cache directory: %w", err)
+	}
+	sum := sha256.Sum256([]byte(source))
+	return filepath.Join(cacheDir, "deepwiki", "checkpoints", hex.EncodeToString(sum[:8])), nil
+}
+
+// New starts an empty checkpoint in dir for a run with runKey, removing any earlier checkpoint
+func New(dir, runKey string) (*Checkpoint, error) {
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, fmt.Errorf("failed to remove old checkpoint: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+	return newCheckpoint(dir, runKey), nil
+}
+
+// Load reads the checkpoint in dir for a run with runKey. A missing or outdated checkpoint gives
+// an empty one; the structure and pages of a run with another key are dropped.
+func Load(dir, runKey string) (*Checkpoint, error) {
+	c := newCheckpoint(dir, runKey)
+
+	var saved state
+	found, err := readJSON(filepath.Join(dir, stateFileName), &saved)
+	if err != nil {
+		return nil, err
+	}
+	if !found || saved.Version != version {
+		return c, nil
+	}
+	if _, err := readJSON(filepath.Join(dir, embeddingsFileName), &c.embeddings); err != nil {
+		return nil, err
+	}
+	if c.embeddings == nil {
+		c.embeddings = make(map[string]*embeddings.DocumentEmbedding)
+	}
+
+	if saved.RunKey == runKey {
+		c.state.Phase = saved.Phase
+		c.state.Structure = saved.Structure
+		if saved.Pages != nil {
+			c.state.Pages = saved.Pages
+		}
+	} else if len(c.embeddings) > 0 {
+		c.state.Phase = PhaseEmbeddings
+	}
+	return c, nil
+}
+
+func newCheckpoint(dir, runKey string) *Checkpoint {
+	return &Checkpoint{
+		dir: dir,
+		state: state{
+			Version: version,
+			Phase:   PhaseStarted,
+			RunKey:  runKey,
+			Pages:   make(map[string]*generator.WikiPage),
+		},
+		embeddings: make(map[string]*embeddings.DocumentEmbedding),
+	}
+}
+
+// Phase returns the furthest phase the checkpoint has reached
+func (c *Checkpoint) Phase() Phase {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state.Phase
+}
+
+// Embeddings returns the number of documents whose embeddings are recorded
+func (c *Checkpoint) Embeddings() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.embeddings)
+}
+
+// Pages returns the number of recorded pages
+func (c *Checkpoint) Pages() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.state.Pages)
+}
+
+// DocumentEmbedding returns the recorded embeddings of a document for an embedding model
+func (c *Checkpoint) DocumentEmbedding(doc processor.Document, model string) (*embeddings.DocumentEmbedding, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	embedding, ok := c.embeddings[embeddingKey(doc, model)]
+	return embedding, ok
+}
+
+// SaveEmbeddings records the embeddings of documents for an embedding model and completes the
+// embedding phase. Documents with chunks that were not embedded are left out, so that a resumed
+// run embeds them again.
+func (c *Checkpoint) SaveEmbeddings(
+	documents []processor.Document,
+	docEmbeddings []*embeddings.DocumentEmbedding,
+	model string,
+) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	byID := make(map[string]*embeddings.DocumentEmbedding, len(docEmbeddings))
+	for _, docEmbedding := range docEmbeddings {
+		byID[docEmbedding.DocumentID] = docEmbedding
+	}
+	for _, doc := range documents {
+		if docEmbedding, ok := byID[doc.ID]; ok && len(docEmbedding.Embeddings) == len(doc.Chunks) {
+			c.embeddings[embeddingKey(doc, model)] = docEmbedding
+		}
+	}
+
+	if err := writeJSON(filepath.Join(c.dir, embeddingsFileName), c.embeddings); err != nil {
+		return err
+	}
+	c.advance(PhaseEmbeddings)
+	return c.saveState()
+}
+
+// Structure returns the recorded wiki structure, or nil
+func (c *Checkpoint) Structure() *generator.WikiStructure {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state.Structure == nil {
+		return nil
+	}
+	structure := *c.state.Structure
+	structure.Pages = slices.Clone(structure.Pages)
+	return &structure
+}
+
+// SaveStructure records the wiki structure
+func (c *Checkpoint) SaveStructure(structure *generator.WikiStructure) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// The pages of the structure are filled in later; keep them as they are now
+	saved := *structure
+	saved.Pages = slices.Clone(structure.Pages)
+	c.state.Structure = &saved
+	c.advance(PhaseStructure)
+	return c.saveState()
+}
+
+// Page returns a recorded page
+func (c *Checkpoint) Page(id string) (*generator.WikiPage, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	page, ok := c.state.Pages[id]
+	return page, ok
+}
+
+// SavePage records a generated page
+func (c *Checkpoint) SavePage(page *generator.WikiPage) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	saved := *page
+	c.state.Pages[page.ID] = &saved
+	c.advance(PhasePages)
+	return c.saveState()
+}
+
+// DropPages forgets the recorded pages generated from any of paths, so that a run regenerates
+// them while reusing the others, and returns how many pages were dropped. Paths are relative to
+// the project root and slash-separated.
+func (c *Checkpoint) DropPages(paths []string) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	dropped := 0
+	for id, page := range c.state.Pages {
+		if slices.ContainsFunc(page.FilePaths, func(path string) bool {
+			return slices.Contains(paths, filepath.ToSlash(path))
+		}) {
+			delete(c.state.Pages, id)
+			dropped++
+		}
+	}
+	if dropped == 0 {
+		return 0, nil
+	}
+	return dropped, c.saveState()
+}
+
+// Remove deletes the checkpoint once the run it records has completed
+func (c *Checkpoint) Remove() error {
+	if err := os.RemoveAll(c.dir); err != nil {
+		return fmt.Errorf("failed to remove checkpoint: %w", err)
+	}
+	return nil
+}
+
+// advance moves the checkpoint to phase unless it has gone further
+func (c *Checkpoint) advance(phase Phase) {
+	phases := []Phase{PhaseStarted, PhaseEmbeddings, PhaseStructure, PhasePages}
+	if slices.Index(phases, phase) > slices.Index(phases, c.state.Phase) {
+		c.state.Phase = phase
+	}
+}
+
+func (c *Checkpoint) saveState() error {
+	c.state.UpdatedAt = time.Now()
+	return writeJSON(filepath.Join(c.dir, stateFileName), c.state)
+}
+
+// embeddingKey identifies the embeddings of a document by its ID, chunks and embedding model
+func embeddingKey(doc processor.Document, model string) string {
+	hash := sha256.New()
+	for _, part := range []string{model, doc.ID, strconv.Itoa(len(doc.Chunks))} {
+		hash.Write([]byte(part + "\x00"))
+	}
+	for _, chunk := range doc.Chunks {
+		hash.Write([]byte(chunk.ID + "\x00" + chunk.Text + "\x00"))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// readJSON decodes the file at path into v and reports whether the file exists
+func readJSON(path string, v any) (bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("failed to parse checkpoint %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// writeJSON replaces the file at path with v, through a temporary file so that a run killed
+// while writing leaves the previous file intact
+func writeJSON(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create checkpoint file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write checkpoint file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write checkpoint file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write checkpoint file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package checkpoint
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/kuderr/deepwiki/pkg/embeddings"
+	"github.com/kuderr/deepwiki/pkg/generator"
+	"github.com/kuderr/deepwiki/pkg/processor"
+	"github.com/kuderr/deepwiki/pkg/types"
+)
+
+func TestCheckpoint_ResumesRecordedItems(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "checkpoint")
+	cp, err := New(dir, "openai/gpt-4o")
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	docs := []processor.Document{
+		{ID: "readme", Chunks: []processor.TextChunk{{ID: "readme-0", Text: "The demo project"}}},
+		{ID: "main", Chunks: []processor.TextChunk{
+			{ID: "main-0", Text: "package main"},
+			{ID: "main-1", Text: "func main()"},
+		}},
+	}
+	docEmbeddings := []*embeddings.DocumentEmbedding{
+		{DocumentID: "readme", Embeddings: []embeddings.EmbeddingVector{{ID: "readme-0", Vector: []float32{0.1}}}},
+		{DocumentID: "main", Embeddings: []embeddings.EmbeddingVector{{ID: "main-0", Vector: []float32{0.2}}}},
+	}
+	if err := cp.SaveEmbeddings(docs, docEmbeddings, "text-embedding-3-small:256"); err != nil {
+		t.Fatalf("SaveEmbeddings failed: %v", err)
+	}
+	structure := &generator.WikiStructure{
+		Title:    "Demo",
+		Language: types.LanguageEnglish,
+		Pages:    []generator.WikiPage{{ID: "overview"}, {ID: "setup"}},
+	}
+	if err := cp.SaveStructure(structure); err != nil {
+		t.Fatalf("SaveStructure failed: %v", err)
+	}
+	structure.Pages[0].Content = "# Overview"
+	if err := cp.SavePage(&structure.Pages[0]); err != nil {
+		t.Fatalf("SavePage failed: %v", err)
+	}
+
+	resumed, err := Load(dir, "openai/gpt-4o")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if resumed.Phase() != PhasePages || resumed.Pages() != 1 {
+		t.Errorf("Expected one page in the pages phase, got %d in %s", resumed.Pages(), resumed.Phase())
+	}
+	if saved := resumed.Structure(); saved == nil || len(saved.Pages) != 2 || saved.Pages[0].Content != "" {
+		t.Errorf("Expected the structure as it was recorded, got %+v", saved)
+	}
+	if page, ok := resumed.Page("overview"); !ok || page.Content != "# Overview" {
+		t.Errorf("Expected the recorded page, got %+v", page)
+	}
+
+	// Only complete embeddings of unchanged documents for the same model are reused
+	if _, ok := resumed.DocumentEmbedding(docs[0], "text-embedding-3-small:256"); !ok {
+		t.Error("Expected the embeddings of the unchanged document")
+	}
+	if _, ok := resumed.DocumentEmbedding(docs[1], "text-embedding-3-small:256"); ok {
+		t.Error("Expected a partly embedded document to be embedded again")
+	}
+	changed := docs[0]
+	changed.Chunks = []processor.TextChunk{{ID: "readme-0", Text: "The renamed project"}}
+	if _, ok := resumed.DocumentEmbedding(changed, "text-embedding-3-small:256"); ok {
+		t.Error("Expected a changed document to be embedded again")
+	}
+	if _, ok := resumed.DocumentEmbedding(docs[0], "nomic-embed-text:768"); ok {
+		t.Error("Expected another embedding model to embed the document again")
+	}
+
+	// Other generation settings keep the embeddings only
+	other, err := Load(dir, "anthropic/claude-sonnet-4")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if other.Phase() != PhaseEmbeddings || other.Structure() != nil || other.Pages() != 0 || other.Embeddings() != 1 {
+		t.Errorf("Expected only the embeddings for other settings, got %d pages in %s",
+			other.Pages(), other.Phase())
+	}
+
+	if err := cp.Remove(); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	empty, err := Load(dir, "openai/gpt-4o")
+	if err != nil || empty.Phase() != PhaseStarted {
+		t.Errorf("Expected an empty checkpoint after Remove, got %v", err)
+	}
+}
+
+func TestCheckpoint_DropPages(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "checkpoint")
+	cp, err := New(dir, "openai/gpt-4o")
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	pages := []generator.WikiPage{
+		{ID: "overview", Content: "# Overview", FilePaths: []string{"README.md"}},
+		{ID: "cli", Content: "# CLI", FilePaths: []string{"cmd/main.go", "cmd/root.go"}},
+	}
+	for i := range pages {
+		if err := cp.SavePage(&pages[i]); err != nil {
+			t.Fatalf("SavePage failed: %v", err)
+		}
+	}
+
+	dropped, err := cp.DropPages([]string{"cmd/root.go", "internal/new.go"})
+	if err != nil || dropped != 1 {
+		t.Fatalf("DropPages() = %d, %v, want 1 page dropped", dropped, err)
+	}
+
+	// The drop is recorded, so that a later run regenerates the page too
+	reloaded, err := Load(dir, "openai/gpt-4o")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if _, ok := reloaded.Page("cli"); ok {
+		t.Error("Expected the page of the changed file to be dropped")
+	}
+	if _, ok := reloaded.Page("overview"); !ok {
+		t.Error("Expected the page of unchanged files to be kept")
+	}
+}
@@ -0,0 +1,171 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/kuderr/deepwiki/internal/logging"
+	"github.com/kuderr/deepwiki/pkg/apierror"
+)
+
+// ErrOpen is returned instead of calling a provider while its breaker is open
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State is the state of a circuit breaker
+type State int
+
+const (
+	// StateClosed lets all requests through
+	StateClosed State = iota
+	// StateOpen fails requests fast until the cooldown has passed
+	StateOpen
+	// StateHalfOpen lets a single probe request through to test the provider
+	StateHalfOpen
+)
+
+// String returns the state name
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+}
+
+// Breaker trips after a number of consecutive failures and fails fast for a cooldown window.
+// After the cooldown a single probe request is let through: success closes the breaker,
+// failure opens it for another cooldown. Only transient errors (server errors, rate limits,
+// timeouts and connection failures) are failures; any other error means the provider answered.
+type Breaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+	logger    *logging.Logger
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewBreaker creates a breaker that opens after threshold consecutive failures
+func NewBreaker(name string, threshold int, cooldown time.Duration) (*Breaker, error) {
+	if threshold <= 0 {
+		return nil, fmt.Errorf("failure threshold must be positive")
+	}
+	if cooldown <= 0 {
+		return nil, fmt.Errorf("cooldown must be positive")
+	}
+
+	return &Breaker{
+		name:      name,
+		threshold: threshold,
+		cooldown:  cooldown,
+		logger:    logging.GetGlobalLogger().WithComponent("circuit-breaker"),
+		now:       time.Now,
+	}, nil
+}
+
+// Execute calls fn unless the breaker is open and records its outcome.
+// Errors after ctx is done are not counted as failures, since the caller gave up, and
+// non-transient errors such as a rejected request count as a success.
+func (b *Breaker) Execute(ctx context.Context, fn func() error) error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+
+	err := fn()
+	switch {
+	case err == nil:
+		b.success()
+	case ctx.Err() != nil:
+		b.release()
+	case !apierror.IsTransient(err):
+		b.success()
+	default:
+		b.failure()
+	}
+	return err
+}
+
+// State returns the current state, moving from open to half-open once the cooldown has passed
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.updateState()
+	return b.state
+}
+
+// allow reports ErrOpen when a request must not be sent
+func (b *Breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.updateState()
+
+	switch b.state {
+	case StateOpen:
+		return fmt.Errorf("%s: %w", b.name, ErrOpen)
+	case StateHalfOpen:
+		if b.probing {
+			return fmt.Errorf("%s: %w", b.name, ErrOpen)
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// updateState half-opens an open breaker whose cooldown has passed. Callers hold b.mu.
+func (b *Breaker) updateState() {
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.cooldown {
+		b.state = StateHalfOpen
+		b.probing = false
+	}
+}
+
+// success closes the breaker
+func (b *Breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != StateClosed {
+		b.logger.Info("circuit breaker closed", slog.String("name", b.name))
+	}
+	b.state = StateClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// failure counts a failure and opens the breaker at the threshold or after a failed probe
+func (b *Breaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == StateHalfOpen || b.failures >= b.threshold {
+		b.state = StateOpen
+		b.openedAt = b.now()
+		b.probing = false
+		b.logger.Warn("circuit breaker opened",
+			slog.String("name", b.name),
+			slog.Int("consecutive_failures", b.failures),
+			slog.Duration("cooldown", b.cooldown))
+	}
+}
+
+// release frees the probe slot without recording an outcome
+func (b *Breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
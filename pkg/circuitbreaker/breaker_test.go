@@ -0,0 +1,183 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kuderr/deepwiki/pkg/apierror"
+)
+
+// transient returns a server error, which counts as a failure
+func transient(message string) error {
+	return apierror.New(http.StatusServiceUnavailable, errors.New(message))
+}
+
+// newTestBreaker returns a breaker driven by a fake clock
+func newTestBreaker(t *testing.T, threshold int, cooldown time.Duration) (*Breaker, *time.Time) {
+	t.Helper()
+
+	breaker, err := NewBreaker("test", threshold, cooldown)
+	if err != nil {
+		t.Fatalf("NewBreaker failed: %v", err)
+	}
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	breaker.now = func() time.Time { return now }
+	return breaker, &now
+}
+
+func TestBreaker_OpensAfterThreshold(t *testing.T) {
+	breaker, _ := newTestBreaker(t, 3, time.Minute)
+	ctx := context.Background()
+	failing := transient("server error")
+
+	calls := 0
+	fail := func() error {
+		calls++
+		return failing
+	}
+
+	for i := range 3 {
+		if state := breaker.State(); state != StateClosed {
+			t.Fatalf("Expected closed breaker before failure %d, got %s", i+1, state)
+		}
+		if err := breaker.Execute(ctx, fail); !errors.Is(err, failing) {
+			t.Fatalf("Expected the call error, got %v", err)
+		}
+	}
+
+	if state := breaker.State(); state != StateOpen {
+		t.Fatalf("Expected open breaker after 3 failures, got %s", state)
+	}
+
+	err := breaker.Execute(ctx, fail)
+	if !errors.Is(err, ErrOpen) {
+		t.Errorf("Expected ErrOpen, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("Expected an open breaker to fail fast without calling, got %d calls", calls)
+	}
+}
+
+func TestBreaker_SuccessResetsFailures(t *testing.T) {
+	breaker, _ := newTestBreaker(t, 2, time.Minute)
+	ctx := context.Background()
+
+	breaker.Execute(ctx, func() error { return transient("fail") })
+	breaker.Execute(ctx, func() error { return nil })
+	breaker.Execute(ctx, func() error { return transient("fail") })
+
+	if state := breaker.State(); state != StateClosed {
+		t.Errorf("Expected failures not to be consecutive, got %s", state)
+	}
+}
+
+func TestBreaker_IgnoresNonTransientErrors(t *testing.T) {
+	breaker, _ := newTestBreaker(t, 2, time.Minute)
+	ctx := context.Background()
+
+	for range 3 {
+		breaker.Execute(ctx, func() error {
+			return apierror.New(http.StatusBadRequest, errors.New("invalid request"))
+		})
+	}
+	if state := breaker.State(); state != StateClosed {
+		t.Fatalf("Expected rejected requests not to open the breaker, got %s", state)
+	}
+
+	// A rejected request also resets the transient failures before it
+	breaker.Execute(ctx, func() error { return transient("fail") })
+	breaker.Execute(ctx, func() error { return errors.New("invalid response") })
+	breaker.Execute(ctx, func() error { return transient("fail") })
+	if state := breaker.State(); state != StateClosed {
+		t.Errorf("Expected failures not to be consecutive, got %s", state)
+	}
+}
+
+func TestBreaker_HalfOpensAfterCooldown(t *testing.T) {
+	breaker, now := newTestBreaker(t, 1, 30*time.Second)
+	ctx := context.Background()
+
+	breaker.Execute(ctx, func() error { return transient("fail") })
+	if state := breaker.State(); state != StateOpen {
+		t.Fatalf("Expected open breaker, got %s", state)
+	}
+
+	*now = now.Add(29 * time.Second)
+	if state := breaker.State(); state != StateOpen {
+		t.Fatalf("Expected breaker to stay open during the cooldown, got %s", state)
+	}
+
+	*now = now.Add(time.Second)
+	if state := breaker.State(); state != StateHalfOpen {
+		t.Fatalf("Expected half-open breaker after the cooldown, got %s", state)
+	}
+
+	// Only one probe is let through while half-open
+	probeStarted := make(chan struct{})
+	finishProbe := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- breaker.Execute(ctx, func() error {
+			close(probeStarted)
+			<-finishProbe
+			return nil
+		})
+	}()
+	<-probeStarted
+
+	if err := breaker.Execute(ctx, func() error { return nil }); !errors.Is(err, ErrOpen) {
+		t.Errorf("Expected concurrent requests to fail fast during the probe, got %v", err)
+	}
+
+	close(finishProbe)
+	if err := <-done; err != nil {
+		t.Fatalf("Probe failed: %v", err)
+	}
+	if state := breaker.State(); state != StateClosed {
+		t.Errorf("Expected a successful probe to close the breaker, got %s", state)
+	}
+}
+
+func TestBreaker_FailedProbeReopens(t *testing.T) {
+	breaker, now := newTestBreaker(t, 2, time.Minute)
+	ctx := context.Background()
+
+	for range 2 {
+		breaker.Execute(ctx, func() error { return transient("fail") })
+	}
+	*now = now.Add(time.Minute)
+
+	breaker.Execute(ctx, func() error { return transient("still failing") })
+	if state := breaker.State(); state != StateOpen {
+		t.Fatalf("Expected a failed probe to reopen the breaker, got %s", state)
+	}
+
+	*now = now.Add(59 * time.Second)
+	if state := breaker.State(); state != StateOpen {
+		t.Errorf("Expected a full cooldown after the failed probe, got %s", state)
+	}
+}
+
+func TestBreaker_IgnoresCancelledCalls(t *testing.T) {
+	breaker, _ := newTestBreaker(t, 1, time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	breaker.Execute(ctx, func() error { return ctx.Err() })
+	if state := breaker.State(); state != StateClosed {
+		t.Errorf("Expected cancelled calls not to count as failures, got %s", state)
+	}
+}
+
+func TestNewBreaker_Validation(t *testing.T) {
+	if _, err := NewBreaker("test", 0, time.Second); err == nil {
+		t.Error("Expected an error for a zero threshold")
+	}
+	if _, err := NewBreaker("test", 1, 0); err == nil {
+		t.Error("Expected an error for a zero cooldown")
+	}
+}
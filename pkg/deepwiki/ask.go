@@ -0,0 +1,73 @@
+package deepwiki
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kuderr/deepwiki/pkg/generator/prompts"
+	"github.com/kuderr/deepwiki/pkg/llm"
+	"github.com/kuderr/deepwiki/pkg/rag"
+)
+
+const (
+	// askSources is the number of chunks retrieved to answer a question
+	askSources = 10
+
+	// askTokens is the token budget of an answer
+	askTokens = 2000
+
+	// askTemperature keeps answers close to the retrieved sources
+	askTemperature = 0.2
+)
+
+// Answer is the answer to a question about a project
+type Answer struct {
+	Text    string
+	Sources []rag.RetrievalResult // Chunks the answer was given from, most relevant first
+}
+
+// Ask answers a question about the project with the LLM provider, from the chunks retrieved for it
+func (p *Project) Ask(ctx context.Context, question string) (*Answer, error) {
+	if p.llm == nil {
+		return nil, errors.New("an LLM provider is required to answer questions")
+	}
+
+	sources, err := p.Retrieve(ctx, question, askSources)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt, err := prompts.ExecuteAskPrompt(prompts.AskData{
+		ProjectName: p.name,
+		Question:    question,
+		Files:       formatSources(sources),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build ask prompt: %w", err)
+	}
+
+	messages := []llm.Message{{Role: "user", Content: prompt}}
+	response, err := p.llm.ChatCompletion(ctx, messages, llm.ChatCompletionOptions{
+		MaxTokens:   askTokens,
+		Temperature: askTemperature,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to answer question: %w", err)
+	}
+	if len(response.Choices) == 0 {
+		return nil, errors.New("failed to answer question: LLM returned no choices")
+	}
+
+	return &Answer{Text: strings.TrimSpace(response.Choices[0].Message.Content), Sources: sources}, nil
+}
+
+// formatSources formats retrieved chunks for the ask prompt, each under its file path
+func formatSources(sources []rag.RetrievalResult) string {
+	var builder strings.Builder
+	for _, source := range sources {
+		fmt.Fprintf(&builder, "\n--- %s ---\n%s\n", source.FilePath, source.Content)
+	}
+	return builder.String()
+}
@@ -0,0 +1,345 @@
+// Package deepwiki is the library entry point to the retrieval of deepwiki: it scans a project
+// directory, chunks and embeds its files, and indexes them so that other programs can retrieve
+// the source excerpts relevant to a query or ask questions about the project.
+//
+// Index runs every step at once. Scan, Process, Embed and Open run them one at a time, for
+// callers that report progress or reuse embeddings between steps, as the generate command does.
+// With Options.DocumentSet, processed documents are saved so that later runs over unchanged
+// files, or LoadDocuments, reload them instead of processing the files again.
+package deepwiki
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/kuderr/deepwiki/pkg/embedding"
+	"github.com/kuderr/deepwiki/pkg/embeddings"
+	"github.com/kuderr/deepwiki/pkg/llm"
+	"github.com/kuderr/deepwiki/pkg/processor"
+	"github.com/kuderr/deepwiki/pkg/rag"
+	"github.com/kuderr/deepwiki/pkg/scanner"
+)
+
+// Options configures the indexing of a project
+type Options struct {
+	EmbeddingProvider embedding.Provider // Embeds chunks and queries; required
+	LLMProvider       llm.Provider       // Answers Ask; retrieval works without it
+	ProjectName       string             // Named in Ask prompts; defaults to the directory name
+	Logger            *slog.Logger       // Nil for slog.Default
+
+	Scan       *scanner.ScanOptions         // Nil for scanner.DefaultScanOptions
+	Processing *processor.ProcessingOptions // Nil for processor.DefaultProcessingOptions
+	RAG        *rag.RAGConfig               // Nil for rag.DefaultRAGConfig
+
+	// Embeddings configures the vector database; its model and dimensions are always those of the
+	// embedding provider. An empty storage path keeps the database in a temporary directory that
+	// Close removes. Nil for embeddings.DefaultEmbeddingConfig with a temporary database.
+	Embeddings *embeddings.EmbeddingConfig
+
+	// ReuseDocument returns a document processed earlier from a file that is unchanged since, which
+	// Process and LoadOrProcess then do not process again
+	ReuseDocument func(file scanner.FileInfo) (*processor.Document, bool)
+
+	// Reuse returns embeddings recorded earlier for a document, which Embed then does not embed again
+	Reuse func(doc processor.Document) (*embeddings.DocumentEmbedding, bool)
+
+	// EmbedProgress is called by Embed after each embedding request with the chunks embedded so
+	// far out of the chunks of the documents not reused
+	EmbedProgress embeddings.ProgressFunc
+
+	// DocumentSet is the file LoadOrProcess saves processed documents to, and reloads them from
+	// while the scanned files and processing options are unchanged. Empty processes every time.
+	DocumentSet string
+}
+
+// logger returns the logger of the options
+func (o Options) logger() *slog.Logger {
+	if o.Logger == nil {
+		return slog.Default()
+	}
+	return o.Logger
+}
+
+// embeddingConfig returns the embedding configuration with the model of the embedding provider
+func (o Options) embeddingConfig() *embeddings.EmbeddingConfig {
+	config := embeddings.DefaultEmbeddingConfig()
+	config.StoragePath = ""
+	if o.Embeddings != nil {
+		copied := *o.Embeddings
+		config = &copied
+	}
+	config.Model = o.EmbeddingProvider.GetModel()
+	config.Dimensions = o.EmbeddingProvider.GetDimensions()
+	return config
+}
+
+// Project is an indexed project, ready for retrieval
+type Project struct {
+	name      string
+	documents []processor.Document
+	retriever *rag.DefaultDocumentRetriever
+	vectorDB  embeddings.VectorDatabase
+	llm       llm.Provider
+	tempDir   string // Holds a temporary vector database, removed by Close
+}
+
+// Index scans, processes, embeds and indexes the files of dir. Chunks that could not be embedded
+// are left out of the index. Close the project to release its vector database.
+func Index(ctx context.Context, dir string, opts Options) (*Project, error) {
+	if opts.ProjectName == "" {
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get absolute path: %w", err)
+		}
+		opts.ProjectName = filepath.Base(absDir)
+	}
+
+	scanResult, err := Scan(dir, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan directory: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	processingResult, _, err := LoadOrProcess(dir, scanResult.Files, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to process files: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	embedResult, err := Embed(processingResult.Documents, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate embeddings: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return Open(processingResult.Documents, embedResult.Embeddings, opts)
+}
+
+// Scan lists the files of dir selected by the scan options
+func Scan(dir string, opts Options) (*scanner.ScanResult, error) {
+	return scanner.NewScanner(opts.Scan).ScanDirectory(dir)
+}
+
+// Process reads scanned files and splits them into chunks, reusing the documents
+// Options.ReuseDocument returns
+func Process(files []scanner.FileInfo, opts Options) (*processor.ProcessingResult, error) {
+	return processor.NewTextProcessor(opts.Processing).ProcessFilesReusing(files, opts.ReuseDocument)
+}
+
+// LoadOrProcess reloads the documents saved at Options.DocumentSet when they were processed from
+// the same files of the project in dir with the same options, and otherwise processes files and
+// saves the result. It reports whether the documents were reloaded. A document set that cannot be
+// read or written, or files that cannot be hashed, are logged and the files are processed.
+func LoadOrProcess(dir string, files []scanner.FileInfo, opts Options) (*processor.ProcessingResult, bool, error) {
+	if opts.DocumentSet == "" {
+		result, err := Process(files, opts)
+		return result, false, err
+	}
+
+	project, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	sourceHash, err := processor.SourceHash(files, opts.Processing)
+	if err != nil {
+		opts.logger().Warn("failed to hash the files, processing them without saved documents",
+			slog.String("error", err.Error()))
+		result, err := Process(files, opts)
+		return result, false, err
+	}
+
+	saved, found, err := processor.LoadDocumentSet(opts.DocumentSet, project, sourceHash)
+	if err != nil {
+		opts.logger().Warn("failed to load processed documents, processing the files again",
+			slog.String("path", opts.DocumentSet), slog.String("error", err.Error()))
+	}
+	if found {
+		return saved, true, nil
+	}
+
+	result, err := Process(files, opts)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := processor.SaveDocumentSet(opts.DocumentSet, project, sourceHash, result); err != nil {
+		opts.logger().Warn("failed to save processed documents",
+			slog.String("path", opts.DocumentSet), slog.String("error", err.Error()))
+	}
+	return result, false, nil
+}
+
+// LoadDocuments returns the documents LoadOrProcess saved at path for the project in dir, for
+// querying a project indexed earlier: pass them to Open with the vector database of that run and
+// no embeddings. The files are not scanned, so changes since that run are not detected.
+func LoadDocuments(path, dir string) ([]processor.Document, error) {
+	project, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	result, found, err := processor.LoadDocumentSet(path, project, "")
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("no processed documents of %s saved at %s", project, path)
+	}
+	return result.Documents, nil
+}
+
+// SkippedChunk is a chunk that could not be embedded
+type SkippedChunk struct {
+	FilePath string
+	ChunkID  string
+	Err      error
+}
+
+// EmbedResult holds the embeddings of processed documents
+type EmbedResult struct {
+	Embeddings []*embeddings.DocumentEmbedding // Of all documents, reused ones first
+	Embedded   []processor.Document            // Documents embedded by Embed rather than reused
+	New        []*embeddings.DocumentEmbedding // Embeddings of the embedded documents
+	Reused     int                             // Documents whose embeddings Options.Reuse returned
+	Skipped    []SkippedChunk                  // Chunks left out because they could not be embedded
+}
+
+// Embed embeds the chunks of documents, reusing the embeddings Options.Reuse returns. Chunks
+// that could not be embedded are reported in the result rather than failing the others.
+func Embed(documents []processor.Document, opts Options) (*EmbedResult, error) {
+	if opts.EmbeddingProvider == nil {
+		return nil, errors.New("an embedding provider is required")
+	}
+
+	result := &EmbedResult{}
+	for _, doc := range documents {
+		if opts.Reuse != nil {
+			if docEmbedding, ok := opts.Reuse(doc); ok {
+				result.Embeddings = append(result.Embeddings, docEmbedding)
+				result.Reused++
+				continue
+			}
+		}
+		result.Embedded = append(result.Embedded, doc)
+	}
+	if len(result.Embedded) == 0 {
+		return result, nil
+	}
+
+	generator := embeddings.NewEmbeddingProviderGenerator(opts.EmbeddingProvider, opts.embeddingConfig())
+	generator.SetProgress(opts.EmbedProgress)
+	newEmbeddings, err := embeddings.EmbedDocuments(generator, result.Embedded)
+	var partial *embeddings.PartialEmbeddingError
+	if errors.As(err, &partial) {
+		// Skipped indices count the chunks of all embedded documents in order
+		var chunks []SkippedChunk
+		for _, doc := range result.Embedded {
+			for _, chunk := range doc.Chunks {
+				chunks = append(chunks, SkippedChunk{FilePath: doc.FilePath, ChunkID: chunk.ID})
+			}
+		}
+		for _, skipped := range partial.Skipped {
+			chunk := chunks[skipped.Index]
+			chunk.Err = skipped.Err
+			result.Skipped = append(result.Skipped, chunk)
+		}
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result.New = newEmbeddings
+	result.Embeddings = append(result.Embeddings, newEmbeddings...)
+	return result, nil
+}
+
+// Open stores the embeddings of documents in a vector database and returns the project to query
+// them. Embeddings that cannot be stored are left out of the index.
+func Open(
+	documents []processor.Document,
+	docEmbeddings []*embeddings.DocumentEmbedding,
+	opts Options,
+) (*Project, error) {
+	if opts.EmbeddingProvider == nil {
+		return nil, errors.New("an embedding provider is required")
+	}
+
+	config := opts.embeddingConfig()
+	project := &Project{name: opts.ProjectName, documents: documents, llm: opts.LLMProvider}
+	if config.StoragePath == "" {
+		tempDir, err := os.MkdirTemp("", "deepwiki-index-*")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create vector database directory: %w", err)
+		}
+		project.tempDir = tempDir
+		config.StoragePath = filepath.Join(tempDir, "embeddings.db")
+	}
+
+	vectorDB, err := embeddings.NewBoltVectorDB(config)
+	if err != nil {
+		project.Close()
+		return nil, fmt.Errorf("failed to create vector database: %w", err)
+	}
+	project.vectorDB = vectorDB
+
+	for _, docEmbedding := range docEmbeddings {
+		if err := vectorDB.Store(docEmbedding); err != nil {
+			opts.logger().Error("failed to store document embedding",
+				slog.String("document_id", docEmbedding.DocumentID), slog.String("error", err.Error()))
+		}
+	}
+
+	generator := embeddings.NewEmbeddingProviderGenerator(opts.EmbeddingProvider, config)
+	service := embeddings.NewEmbeddingService(generator, vectorDB, config)
+	project.retriever = rag.NewDocumentRetriever(service, vectorDB, generator, documents, opts.RAG)
+	return project, nil
+}
+
+// Documents returns the processed documents of the project
+func (p *Project) Documents() []processor.Document {
+	return p.documents
+}
+
+// Retriever returns the retriever of the project, for retrieval beyond Retrieve
+func (p *Project) Retriever() *rag.DefaultDocumentRetriever {
+	return p.retriever
+}
+
+// Retrieve returns the chunks most relevant to a query, best first
+func (p *Project) Retrieve(ctx context.Context, query string, maxResults int) ([]rag.RetrievalResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	results, err := p.retriever.RetrieveByQuery(query, maxResults)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve documents: %w", err)
+	}
+	return results, nil
+}
+
+// Close releases the vector database of the project and removes it if it is temporary
+func (p *Project) Close() error {
+	var errs []error
+	if p.vectorDB != nil {
+		if err := p.vectorDB.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close vector database: %w", err))
+		}
+		p.vectorDB = nil
+	}
+	if p.tempDir != "" {
+		if err := os.RemoveAll(p.tempDir); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove vector database: %w", err))
+		}
+		p.tempDir = ""
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,289 @@
+package deepwiki
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kuderr/deepwiki/pkg/embedding"
+	"github.com/kuderr/deepwiki/pkg/embeddings"
+	"github.com/kuderr/deepwiki/pkg/llm"
+	"github.com/kuderr/deepwiki/pkg/processor"
+)
+
+const fakeDimensions = 64
+
+// fakeEmbedder embeds texts as normalized bags of words, so that texts sharing words are similar.
+// Texts containing "poison" cannot be embedded.
+type fakeEmbedder struct {
+	texts []string
+}
+
+func (f *fakeEmbedder) CreateEmbeddings(
+	ctx context.Context,
+	texts []string,
+	opts ...embedding.EmbeddingOptions,
+) (*embedding.EmbeddingResponse, error) {
+	response := &embedding.EmbeddingResponse{}
+	for i, text := range texts {
+		if strings.Contains(text, "poison") {
+			return nil, errors.New("text rejected")
+		}
+		f.texts = append(f.texts, text)
+
+		vector := make([]float64, fakeDimensions)
+		for _, word := range strings.Fields(strings.ToLower(text)) {
+			hash := fnv.New32a()
+			hash.Write([]byte(strings.Trim(word, ".,()\"")))
+			vector[hash.Sum32()%fakeDimensions]++
+		}
+		var norm float64
+		for _, value := range vector {
+			norm += value * value
+		}
+		for j := range vector {
+			vector[j] /= math.Sqrt(norm)
+		}
+		response.Data = append(response.Data, embedding.Embedding{Index: i, Embedding: vector})
+	}
+	return response, nil
+}
+
+func (f *fakeEmbedder) GetProviderType() embedding.ProviderType { return embedding.ProviderOpenAI }
+func (f *fakeEmbedder) GetModel() string                        { return "fake-embedding" }
+func (f *fakeEmbedder) GetDimensions() int                      { return fakeDimensions }
+func (f *fakeEmbedder) GetMaxTokens() int                       { return 8000 }
+func (f *fakeEmbedder) EstimateTokens(text string) int          { return len(strings.Fields(text)) }
+
+func (f *fakeEmbedder) SplitTextForEmbedding(text string, maxTokens int) []string {
+	return []string{text}
+}
+
+// fakeLLM answers every chat completion with a fixed answer and records the prompts
+type fakeLLM struct {
+	prompts []string
+}
+
+func (f *fakeLLM) ChatCompletion(
+	ctx context.Context,
+	messages []llm.Message,
+	opts ...llm.ChatCompletionOptions,
+) (*llm.ChatCompletionResponse, error) {
+	f.prompts = append(f.prompts, messages[len(messages)-1].Content)
+	return &llm.ChatCompletionResponse{
+		Choices: []llm.Choice{{Message: llm.Message{Role: "assistant", Content: " Queries go through db.go. "}}},
+	}, nil
+}
+
+func (f *fakeLLM) ChatCompletionStream(
+	ctx context.Context,
+	messages []llm.Message,
+	handler llm.StreamHandler,
+	opts ...llm.ChatCompletionOptions,
+) error {
+	return nil
+}
+
+func (f *fakeLLM) CountTokens(text string) (int, error)                    { return len(strings.Fields(text)), nil }
+func (f *fakeLLM) EstimateCost(promptTokens, completionTokens int) float64 { return 0 }
+func (f *fakeLLM) GetUsageStats() llm.TokenCount                           { return llm.TokenCount{} }
+func (f *fakeLLM) ResetUsageStats()                                        {}
+func (f *fakeLLM) GetProviderType() llm.ProviderType                       { return llm.ProviderOpenAI }
+func (f *fakeLLM) GetModel() string                                        { return "fake-llm" }
+
+// writeProject writes a small Go project with an authentication and a database file
+func writeProject(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"auth.go": "package app\n\n// Login checks the password of a user and returns a session token. " +
+			strings.Repeat("Sessions expire and users log in again with their password. ", 8) +
+			"\nfunc Login(user, password string) (string, error) {\n\treturn issueToken(user), nil\n}\n",
+		"db.go": "package app\n\n// Query runs an SQL query against the database connection pool. " +
+			strings.Repeat("Each database query borrows a connection from the pool. ", 8) +
+			"\nfunc Query(sql string) ([]Row, error) {\n\treturn pool.Query(sql)\n}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestIndex_RetrieveAndAsk(t *testing.T) {
+	dir := writeProject(t)
+	chat := &fakeLLM{}
+
+	project, err := Index(context.Background(), dir, Options{EmbeddingProvider: &fakeEmbedder{}, LLMProvider: chat})
+	if err != nil {
+		t.Fatalf("Index failed: %v", err)
+	}
+	if len(project.Documents()) != 2 {
+		t.Fatalf("Expected 2 documents, got %d", len(project.Documents()))
+	}
+
+	results, err := project.Retrieve(context.Background(), "SQL query against the database", 2)
+	if err != nil {
+		t.Fatalf("Retrieve failed: %v", err)
+	}
+	if len(results) == 0 || filepath.Base(results[0].FilePath) != "db.go" {
+		t.Fatalf("Expected db.go to be retrieved first, got %+v", results)
+	}
+
+	answer, err := project.Ask(context.Background(), "How are database queries run?")
+	if err != nil {
+		t.Fatalf("Ask failed: %v", err)
+	}
+	if answer.Text != "Queries go through db.go." || len(answer.Sources) == 0 {
+		t.Errorf("Unexpected answer: %+v", answer)
+	}
+	if len(chat.prompts) != 1 {
+		t.Fatalf("Expected 1 chat completion, got %d", len(chat.prompts))
+	}
+	for _, expected := range []string{filepath.Base(dir), "How are database queries run?", "pool.Query(sql)"} {
+		if !strings.Contains(chat.prompts[0], expected) {
+			t.Errorf("Expected the ask prompt to contain %q, got:\n%s", expected, chat.prompts[0])
+		}
+	}
+
+	tempDir := project.tempDir
+	if err := project.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := os.Stat(tempDir); !os.IsNotExist(err) {
+		t.Errorf("Expected Close to remove the temporary vector database %s", tempDir)
+	}
+}
+
+func TestIndex_StoragePath(t *testing.T) {
+	config := embeddings.DefaultEmbeddingConfig()
+	config.StoragePath = filepath.Join(t.TempDir(), "index.db")
+
+	project, err := Index(context.Background(), writeProject(t), Options{
+		EmbeddingProvider: &fakeEmbedder{},
+		Embeddings:        config,
+	})
+	if err != nil {
+		t.Fatalf("Index failed: %v", err)
+	}
+	defer project.Close()
+
+	if _, err := os.Stat(config.StoragePath); err != nil {
+		t.Errorf("Expected the vector database at %s: %v", config.StoragePath, err)
+	}
+	if _, err := project.Ask(context.Background(), "What does Login do?"); err == nil {
+		t.Error("Expected Ask to fail without an LLM provider")
+	}
+}
+
+func TestEmbed_ReusesAndSkips(t *testing.T) {
+	documents := []processor.Document{
+		{ID: "reused", FilePath: "reused.go", Chunks: []processor.TextChunk{{ID: "reused-0", Text: "reused text"}}},
+		{ID: "fresh", FilePath: "fresh.go", Chunks: []processor.TextChunk{
+			{ID: "fresh-0", Text: "fresh text"},
+			{ID: "fresh-1", Text: "poison text"},
+		}},
+	}
+	recorded := &embeddings.DocumentEmbedding{DocumentID: "reused"}
+	embedder := &fakeEmbedder{}
+	config := embeddings.DefaultEmbeddingConfig()
+	config.MaxRetries = 0
+
+	result, err := Embed(documents, Options{
+		EmbeddingProvider: embedder,
+		Embeddings:        config,
+		Reuse: func(doc processor.Document) (*embeddings.DocumentEmbedding, bool) {
+			return recorded, doc.ID == "reused"
+		},
+	})
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+
+	if result.Reused != 1 || len(result.Embedded) != 1 || result.Embedded[0].ID != "fresh" {
+		t.Errorf("Expected the reused document to be skipped, got %d reused and %+v", result.Reused, result.Embedded)
+	}
+	if len(result.Embeddings) != 2 || result.Embeddings[0] != recorded {
+		t.Errorf("Expected the recorded and the new embeddings, got %+v", result.Embeddings)
+	}
+	if len(result.New) != 1 || len(result.New[0].Embeddings) != 1 {
+		t.Errorf("Expected one new embedding of the fresh document, got %+v", result.New)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0].ChunkID != "fresh-1" ||
+		result.Skipped[0].FilePath != "fresh.go" || result.Skipped[0].Err == nil {
+		t.Errorf("Expected the poisoned chunk to be skipped, got %+v", result.Skipped)
+	}
+	for _, text := range embedder.texts {
+		if text == "reused text" {
+			t.Error("Expected the reused document not to be embedded again")
+		}
+	}
+}
+
+func TestIndex_ReloadsDocumentSet(t *testing.T) {
+	dir := writeProject(t)
+	cacheDir := t.TempDir()
+	config := embeddings.DefaultEmbeddingConfig()
+	config.StoragePath = filepath.Join(cacheDir, "embeddings.db")
+	opts := Options{
+		EmbeddingProvider: &fakeEmbedder{},
+		Embeddings:        config,
+		DocumentSet:       filepath.Join(cacheDir, "documents.json"),
+	}
+
+	project, err := Index(context.Background(), dir, opts)
+	if err != nil {
+		t.Fatalf("Index failed: %v", err)
+	}
+	indexed := project.Documents()
+	if err := project.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	scanResult, err := Scan(dir, opts)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if _, reloaded, err := LoadOrProcess(dir, scanResult.Files, opts); err != nil || !reloaded {
+		t.Errorf("Expected the documents of unchanged files to be reloaded, got %v, %v", reloaded, err)
+	}
+
+	// The documents and vector database of the earlier run answer queries without a scan
+	documents, err := LoadDocuments(opts.DocumentSet, dir)
+	if err != nil {
+		t.Fatalf("LoadDocuments failed: %v", err)
+	}
+	if len(documents) != len(indexed) || documents[0].ID != indexed[0].ID {
+		t.Fatalf("Expected the indexed documents, got %+v", documents)
+	}
+	if _, err := LoadDocuments(opts.DocumentSet, t.TempDir()); err == nil {
+		t.Error("Expected no documents for another project")
+	}
+	reopened, err := Open(documents, nil, opts)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	results, err := reopened.Retrieve(context.Background(), "SQL query against the database", 2)
+	reopened.Close()
+	if err != nil || len(results) == 0 || filepath.Base(results[0].FilePath) != "db.go" {
+		t.Errorf("Expected db.go to be retrieved from the reloaded project, got %+v, %v", results, err)
+	}
+
+	// Changing a file invalidates the saved documents
+	if err := os.WriteFile(filepath.Join(dir, "db.go"), []byte("package app\n"), 0o644); err != nil {
+		t.Fatalf("Failed to modify db.go: %v", err)
+	}
+	scanResult, err = Scan(dir, opts)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if _, reloaded, err := LoadOrProcess(dir, scanResult.Files, opts); err != nil || reloaded {
+		t.Errorf("Expected the documents to be processed again after a change, got %v, %v", reloaded, err)
+	}
+}
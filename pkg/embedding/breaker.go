@@ -0,0 +1,59 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kuderr/deepwiki/pkg/circuitbreaker"
+)
+
+// CircuitBreakerProvider wraps a provider so that requests fail fast while the provider is
+// consistently failing. Behind a FallbackProvider this makes failover to the next provider immediate.
+type CircuitBreakerProvider struct {
+	Provider
+	breaker *circuitbreaker.Breaker
+}
+
+// NewCircuitBreakerProvider creates a provider that stops calling provider for cooldown
+// after threshold consecutive failed requests
+func NewCircuitBreakerProvider(
+	provider Provider,
+	threshold int,
+	cooldown time.Duration,
+) (*CircuitBreakerProvider, error) {
+	if provider == nil {
+		return nil, fmt.Errorf("provider cannot be nil")
+	}
+
+	name := fmt.Sprintf("embedding/%s", provider.GetProviderType())
+	breaker, err := circuitbreaker.NewBreaker(name, threshold, cooldown)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CircuitBreakerProvider{
+		Provider: provider,
+		breaker:  breaker,
+	}, nil
+}
+
+// CreateEmbeddings calls the wrapped provider unless the breaker is open
+func (p *CircuitBreakerProvider) CreateEmbeddings(
+	ctx context.Context,
+	texts []string,
+	opts ...EmbeddingOptions,
+) (*EmbeddingResponse, error) {
+	var response *EmbeddingResponse
+	err := p.breaker.Execute(ctx, func() error {
+		var err error
+		response, err = p.Provider.CreateEmbeddings(ctx, texts, opts...)
+		return err
+	})
+	return response, err
+}
+
+// BreakerState returns the state of the circuit breaker
+func (p *CircuitBreakerProvider) BreakerState() circuitbreaker.State {
+	return p.breaker.State()
+}
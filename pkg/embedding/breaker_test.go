@@ -0,0 +1,48 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kuderr/deepwiki/pkg/apierror"
+	"github.com/kuderr/deepwiki/pkg/circuitbreaker"
+)
+
+func TestCircuitBreakerProvider_FailsOverImmediatelyOnceOpen(t *testing.T) {
+	unavailable := apierror.New(http.StatusServiceUnavailable, errors.New("service unavailable"))
+	primary := &stubProvider{providerType: ProviderOpenAI, dimensions: 4, err: unavailable}
+	secondary := &stubProvider{providerType: ProviderVoyage, dimensions: 4}
+
+	guarded, err := NewCircuitBreakerProvider(primary, 2, time.Minute)
+	if err != nil {
+		t.Fatalf("NewCircuitBreakerProvider failed: %v", err)
+	}
+	provider, err := NewFallbackProvider(guarded, secondary)
+	if err != nil {
+		t.Fatalf("NewFallbackProvider failed: %v", err)
+	}
+
+	for range 5 {
+		if _, err := provider.CreateEmbeddings(context.Background(), []string{"a"}); err != nil {
+			t.Fatalf("Expected the secondary provider to serve the batch, got %v", err)
+		}
+	}
+
+	if primary.calls != 2 {
+		t.Errorf("Expected the primary to be skipped after 2 failures, got %d calls", primary.calls)
+	}
+	if secondary.calls != 5 {
+		t.Errorf("Expected the secondary to serve all 5 batches, got %d", secondary.calls)
+	}
+	if state := guarded.BreakerState(); state != circuitbreaker.StateOpen {
+		t.Errorf("Expected open breaker, got %s", state)
+	}
+
+	_, err = guarded.CreateEmbeddings(context.Background(), []string{"a"})
+	if !errors.Is(err, circuitbreaker.ErrOpen) {
+		t.Errorf("Expected ErrOpen from the open breaker, got %v", err)
+	}
+}
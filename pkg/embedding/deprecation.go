@@ -0,0 +1,23 @@
+package embedding
+
+import "strings"
+
+// deprecatedModels maps models their provider has deprecated or retired to a suggested replacement
+var deprecatedModels = map[string]string{
+	"text-similarity-ada-001": "text-embedding-3-small",
+	"text-search-ada-doc-001": "text-embedding-3-small",
+	"text-embedding-ada-001":  "text-embedding-3-small",
+	"voyage-01":               "voyage-3",
+	"voyage-lite-01":          "voyage-3-lite",
+	"voyage-lite-01-instruct": "voyage-3-lite",
+	"voyage-lite-02-instruct": "voyage-3-lite",
+	"voyage-2":                "voyage-3",
+	"voyage-code-2":           "voyage-code-3",
+}
+
+// DeprecatedModel reports whether a model has been deprecated by its provider and returns the
+// suggested replacement
+func DeprecatedModel(model string) (replacement string, deprecated bool) {
+	replacement, deprecated = deprecatedModels[strings.ToLower(model)]
+	return replacement, deprecated
+}
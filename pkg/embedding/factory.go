@@ -10,6 +10,7 @@ func GetSupportedProviders() []ProviderType {
 		ProviderOpenAI,
 		ProviderVoyage,
 		ProviderOllama,
+		ProviderTEI,
 	}
 }
 
@@ -33,6 +34,11 @@ func ValidateConfig(config *Config) error {
 		if config.BaseURL == "" {
 			return fmt.Errorf("base_url is required for Ollama provider")
 		}
+	case ProviderTEI:
+		// TEI is self-hosted; the API key is optional
+		if config.BaseURL == "" {
+			return fmt.Errorf("base_url is required for TEI provider")
+		}
 	case "":
 		return fmt.Errorf("provider type is required")
 	default:
@@ -2,10 +2,13 @@ package factory
 
 import (
 	"fmt"
+	"log/slog"
 
+	"github.com/kuderr/deepwiki/internal/logging"
 	"github.com/kuderr/deepwiki/pkg/embedding"
 	"github.com/kuderr/deepwiki/pkg/embedding/ollama"
 	"github.com/kuderr/deepwiki/pkg/embedding/openai"
+	"github.com/kuderr/deepwiki/pkg/embedding/tei"
 	"github.com/kuderr/deepwiki/pkg/embedding/voyage"
 )
 
@@ -22,15 +25,73 @@ func NewEmbeddingProvider(config *embedding.Config) (embedding.Provider, error)
 	if config.MaxRetries < 0 {
 		return nil, fmt.Errorf("max retries cannot be negative")
 	}
+	if config.CircuitBreakerThreshold < 0 {
+		return nil, fmt.Errorf("circuit breaker threshold cannot be negative")
+	}
 
+	var provider embedding.Provider
+	var err error
 	switch config.Provider {
 	case embedding.ProviderOpenAI:
-		return openai.NewProvider(config)
+		provider, err = openai.NewProvider(config)
 	case embedding.ProviderVoyage:
-		return voyage.NewProvider(config)
+		provider, err = voyage.NewProvider(config)
 	case embedding.ProviderOllama:
-		return ollama.NewProvider(config)
+		provider, err = ollama.NewProvider(config)
+	case embedding.ProviderTEI:
+		provider, err = tei.NewProvider(config)
 	default:
 		return nil, fmt.Errorf("unsupported embedding provider: %s", config.Provider)
 	}
+	if err != nil {
+		return nil, err
+	}
+	warnDeprecatedModel(config)
+
+	if config.CircuitBreakerThreshold == 0 {
+		return provider, nil
+	}
+
+	return embedding.NewCircuitBreakerProvider(provider, config.CircuitBreakerThreshold, config.CircuitBreakerCooldown)
+}
+
+// NewEmbeddingProviderWithFallbacks creates the primary provider and, when fallbacks are given,
+// wraps it so embedding fails over to each fallback in order
+func NewEmbeddingProviderWithFallbacks(
+	config *embedding.Config,
+	fallbacks []*embedding.Config,
+) (embedding.Provider, error) {
+	primary, err := NewEmbeddingProvider(config)
+	if err != nil {
+		return nil, err
+	}
+	if len(fallbacks) == 0 {
+		return primary, nil
+	}
+
+	providers := make([]embedding.Provider, 0, len(fallbacks))
+	for i, fallbackConfig := range fallbacks {
+		provider, err := NewEmbeddingProvider(fallbackConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create fallback embedding provider %d: %w", i+1, err)
+		}
+		providers = append(providers, provider)
+	}
+
+	return embedding.NewFallbackProvider(primary, providers...)
+}
+
+// warnDeprecatedModel logs a warning when the configured model has been deprecated by its
+// provider. The model is still used, so that it can be overridden deliberately.
+func warnDeprecatedModel(config *embedding.Config) {
+	replacement, deprecated := embedding.DeprecatedModel(config.Model)
+	if !deprecated {
+		return
+	}
+	logging.GetGlobalLogger().WithComponent("embedding").Warn(
+		"embedding model is deprecated by its provider; consider switching to the replacement",
+		slog.String("provider", string(config.Provider)),
+		slog.String("model", config.Model),
+		slog.String("replacement", replacement),
+	)
 }
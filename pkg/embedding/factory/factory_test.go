@@ -1,10 +1,13 @@
 package factory
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/kuderr/deepwiki/internal/logging"
 	"github.com/kuderr/deepwiki/pkg/embedding"
 )
 
@@ -67,6 +70,21 @@ func TestNewEmbeddingProvider(t *testing.T) {
 			wantErr:  false,
 			wantType: embedding.ProviderOllama,
 		},
+		{
+			name: "TEI provider",
+			config: &embedding.Config{
+				Provider:       embedding.ProviderTEI,
+				Model:          "BAAI/bge-base-en-v1.5",
+				BaseURL:        "http://localhost:8080",
+				RequestTimeout: 30 * time.Second,
+				MaxRetries:     3,
+				RetryDelay:     1 * time.Second,
+				RateLimitRPS:   10.0,
+				Dimensions:     768,
+			},
+			wantErr:  false,
+			wantType: embedding.ProviderTEI,
+		},
 		{
 			name: "unsupported provider",
 			config: &embedding.Config{
@@ -420,3 +438,98 @@ func TestEmbeddingProviderConfigValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestNewEmbeddingProviderWithFallbacks(t *testing.T) {
+	primary := &embedding.Config{
+		Provider: embedding.ProviderOllama,
+		Model:    "nomic-embed-text",
+		BaseURL:  "http://localhost:11434",
+	}
+	fallback := &embedding.Config{
+		Provider: embedding.ProviderOllama,
+		Model:    "nomic-embed-text",
+		BaseURL:  "http://ollama-backup:11434",
+	}
+
+	provider, err := NewEmbeddingProviderWithFallbacks(primary, []*embedding.Config{fallback})
+	if err != nil {
+		t.Fatalf("NewEmbeddingProviderWithFallbacks failed: %v", err)
+	}
+	if _, ok := provider.(*embedding.FallbackProvider); !ok {
+		t.Errorf("Expected a fallback provider, got %T", provider)
+	}
+
+	provider, err = NewEmbeddingProviderWithFallbacks(primary, nil)
+	if err != nil {
+		t.Fatalf("NewEmbeddingProviderWithFallbacks failed: %v", err)
+	}
+	if _, ok := provider.(*embedding.FallbackProvider); ok {
+		t.Error("Expected the primary provider without fallbacks")
+	}
+
+	invalid := &embedding.Config{Provider: "unknown"}
+	if _, err := NewEmbeddingProviderWithFallbacks(primary, []*embedding.Config{invalid}); err == nil {
+		t.Error("Expected error for an invalid fallback")
+	}
+}
+
+// captureLogs sends the global logger to a file for the rest of the test and returns a function
+// reading what was logged
+func captureLogs(t *testing.T) func() string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "deepwiki.log")
+	logger, err := logging.NewLogger(&logging.LogConfig{Level: logging.LevelInfo, Output: path})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	previous := logging.GetGlobalLogger()
+	logging.SetGlobalLogger(logger)
+	t.Cleanup(func() {
+		logging.SetGlobalLogger(previous)
+		logger.Close()
+	})
+
+	return func() string {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read log: %v", err)
+		}
+		return string(data)
+	}
+}
+
+func TestNewEmbeddingProvider_WarnsOnDeprecatedModel(t *testing.T) {
+	tests := []struct {
+		model    string
+		wantWarn bool
+	}{
+		{model: "voyage-2", wantWarn: true},
+		{model: "voyage-3", wantWarn: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			logs := captureLogs(t)
+			config := &embedding.Config{
+				Provider:     embedding.ProviderVoyage,
+				APIKey:       "test-voyage-key",
+				Model:        tt.model,
+				RateLimitRPS: 1,
+			}
+
+			// A deprecated model is still used
+			if _, err := NewEmbeddingProvider(config); err != nil {
+				t.Fatalf("NewEmbeddingProvider failed: %v", err)
+			}
+
+			logged := logs()
+			warned := strings.Contains(logged, "embedding model is deprecated")
+			if warned != tt.wantWarn {
+				t.Errorf("Expected warning %v, got log:\n%s", tt.wantWarn, logged)
+			}
+			if tt.wantWarn && !strings.Contains(logged, "replacement=voyage-3") {
+				t.Errorf("Expected the replacement to be suggested, got log:\n%s", logged)
+			}
+		})
+	}
+}
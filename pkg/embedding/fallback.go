@@ -0,0 +1,156 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/kuderr/deepwiki/internal/logging"
+	"github.com/kuderr/deepwiki/pkg/apierror"
+	"github.com/kuderr/deepwiki/pkg/circuitbreaker"
+)
+
+// FallbackProvider tries an ordered list of providers and fails over to the next one when a
+// provider is unavailable: its breaker is open, or it failed with a server error, a rate limit,
+// a timeout or a connection failure. Other errors, such as a rejected input, are returned as they
+// are. All providers must produce vectors of the same dimension, so embeddings from different
+// providers can be stored side by side.
+type FallbackProvider struct {
+	providers []Provider
+	logger    *logging.Logger
+
+	mu         sync.Mutex
+	dimensions int // Dimensions of the vectors served so far (0 = none served yet)
+}
+
+// NewFallbackProvider creates a provider that uses primary and fails over to fallbacks in order
+func NewFallbackProvider(primary Provider, fallbacks ...Provider) (*FallbackProvider, error) {
+	if primary == nil {
+		return nil, fmt.Errorf("primary provider cannot be nil")
+	}
+
+	providers := []Provider{primary}
+	for _, fallback := range fallbacks {
+		if fallback == nil {
+			return nil, fmt.Errorf("fallback provider cannot be nil")
+		}
+		// Dimensions of 0 mean the model default, which is only known after the first call
+		if fallback.GetDimensions() > 0 && primary.GetDimensions() > 0 &&
+			fallback.GetDimensions() != primary.GetDimensions() {
+			return nil, fmt.Errorf("fallback provider %s/%s has %d dimensions, primary has %d",
+				fallback.GetProviderType(), fallback.GetModel(), fallback.GetDimensions(), primary.GetDimensions())
+		}
+		providers = append(providers, fallback)
+	}
+
+	return &FallbackProvider{
+		providers:  providers,
+		logger:     logging.GetGlobalLogger().WithComponent("embedding-fallback"),
+		dimensions: primary.GetDimensions(),
+	}, nil
+}
+
+// CreateEmbeddings creates embeddings with the first available provider. The response names the
+// provider and model that served it, so usage and pricing are attributed to them.
+func (p *FallbackProvider) CreateEmbeddings(
+	ctx context.Context,
+	texts []string,
+	opts ...EmbeddingOptions,
+) (*EmbeddingResponse, error) {
+	var errs []error
+	for i, provider := range p.providers {
+		response, err := provider.CreateEmbeddings(ctx, texts, opts...)
+		if err == nil {
+			if err := p.checkDimensions(provider, response); err != nil {
+				return nil, err
+			}
+			response.Provider = provider.GetProviderType()
+			if response.Model == "" {
+				response.Model = provider.GetModel()
+			}
+			if i > 0 {
+				p.logger.Info("embedding batch served by fallback provider",
+					slog.String("provider", string(provider.GetProviderType())),
+					slog.String("model", provider.GetModel()),
+					slog.Int("texts", len(texts)))
+			}
+			return response, nil
+		}
+
+		err = fmt.Errorf("%s/%s: %w", provider.GetProviderType(), provider.GetModel(), err)
+		errs = append(errs, err)
+
+		// A cancelled run is not a provider failure, and a rejected input fails on every provider
+		if ctx.Err() != nil || !(apierror.IsTransient(err) || errors.Is(err, circuitbreaker.ErrOpen)) {
+			break
+		}
+		if i+1 < len(p.providers) {
+			next := p.providers[i+1]
+			p.logger.Warn("embedding provider failed, failing over",
+				slog.String("provider", string(provider.GetProviderType())),
+				slog.String("next_provider", string(next.GetProviderType())),
+				slog.String("error", err.Error()))
+		}
+	}
+
+	if len(errs) == 1 {
+		return nil, errs[0]
+	}
+	return nil, fmt.Errorf("all embedding providers failed: %w", errors.Join(errs...))
+}
+
+// checkDimensions fails when provider served vectors of another dimension than the vectors before.
+// Providers with the model default dimensions are only known after they serve a batch.
+func (p *FallbackProvider) checkDimensions(provider Provider, response *EmbeddingResponse) error {
+	if len(response.Data) == 0 {
+		return nil
+	}
+	dimensions := len(response.Data[0].Embedding)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.dimensions == 0 {
+		p.dimensions = dimensions
+	}
+	if dimensions != p.dimensions {
+		return fmt.Errorf("embedding provider %s/%s returned %d dimensions, expected %d",
+			provider.GetProviderType(), provider.GetModel(), dimensions, p.dimensions)
+	}
+	return nil
+}
+
+// GetProviderType returns the primary provider type
+func (p *FallbackProvider) GetProviderType() ProviderType {
+	return p.providers[0].GetProviderType()
+}
+
+// GetModel returns the primary model
+func (p *FallbackProvider) GetModel() string {
+	return p.providers[0].GetModel()
+}
+
+// GetDimensions returns the primary embedding dimensions
+func (p *FallbackProvider) GetDimensions() int {
+	return p.providers[0].GetDimensions()
+}
+
+// GetMaxTokens returns the smallest token limit of all providers, so any of them can take a batch
+func (p *FallbackProvider) GetMaxTokens() int {
+	maxTokens := p.providers[0].GetMaxTokens()
+	for _, provider := range p.providers[1:] {
+		maxTokens = min(maxTokens, provider.GetMaxTokens())
+	}
+	return maxTokens
+}
+
+// EstimateTokens estimates tokens using the primary provider
+func (p *FallbackProvider) EstimateTokens(text string) int {
+	return p.providers[0].EstimateTokens(text)
+}
+
+// SplitTextForEmbedding splits text using the primary provider
+func (p *FallbackProvider) SplitTextForEmbedding(text string, maxTokens int) []string {
+	return p.providers[0].SplitTextForEmbedding(text, maxTokens)
+}
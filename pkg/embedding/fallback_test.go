@@ -0,0 +1,235 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/kuderr/deepwiki/pkg/apierror"
+)
+
+// stubProvider returns fixed vectors or a fixed error and counts calls. Vectors have vectorSize
+// elements when it is set, dimensions otherwise.
+type stubProvider struct {
+	providerType ProviderType
+	dimensions   int
+	vectorSize   int
+	err          error
+	calls        int
+}
+
+func (p *stubProvider) CreateEmbeddings(
+	ctx context.Context,
+	texts []string,
+	opts ...EmbeddingOptions,
+) (*EmbeddingResponse, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	size := p.dimensions
+	if p.vectorSize > 0 {
+		size = p.vectorSize
+	}
+	response := &EmbeddingResponse{Object: "list", Model: p.GetModel()}
+	for i := range texts {
+		response.Data = append(response.Data, Embedding{Index: i, Embedding: make([]float64, size)})
+	}
+	return response, nil
+}
+
+func (p *stubProvider) GetProviderType() ProviderType { return p.providerType }
+func (p *stubProvider) GetModel() string              { return string(p.providerType) + "-model" }
+func (p *stubProvider) GetDimensions() int            { return p.dimensions }
+func (p *stubProvider) GetMaxTokens() int             { return 8192 }
+func (p *stubProvider) EstimateTokens(text string) int {
+	return len(text) / 4
+}
+func (p *stubProvider) SplitTextForEmbedding(text string, maxTokens int) []string {
+	return []string{text}
+}
+
+// unavailable returns a server error, on which providers fail over
+func unavailable(message string) error {
+	return apierror.New(http.StatusServiceUnavailable, errors.New(message))
+}
+
+func TestFallbackProvider_FailsOverWhenUnavailable(t *testing.T) {
+	primary := &stubProvider{providerType: ProviderOpenAI, dimensions: 4, err: unavailable("service unavailable")}
+	secondary := &stubProvider{providerType: ProviderVoyage, dimensions: 4}
+
+	provider, err := NewFallbackProvider(primary, secondary)
+	if err != nil {
+		t.Fatalf("NewFallbackProvider failed: %v", err)
+	}
+
+	response, err := provider.CreateEmbeddings(context.Background(), []string{"a", "b", "c"})
+	if err != nil {
+		t.Fatalf("Expected the secondary provider to complete the batch, got %v", err)
+	}
+	if len(response.Data) != 3 {
+		t.Errorf("Expected 3 embeddings, got %d", len(response.Data))
+	}
+	if primary.calls != 1 || secondary.calls != 1 {
+		t.Errorf("Expected one call per provider, got %d and %d", primary.calls, secondary.calls)
+	}
+	if response.Provider != ProviderVoyage || response.Model != secondary.GetModel() {
+		t.Errorf("Expected the batch attributed to %s/%s, got %s/%s",
+			ProviderVoyage, secondary.GetModel(), response.Provider, response.Model)
+	}
+
+	// The wrapper still reports the primary provider
+	if provider.GetProviderType() != ProviderOpenAI {
+		t.Errorf("Expected primary provider type, got %s", provider.GetProviderType())
+	}
+}
+
+func TestFallbackProvider_PrimaryServesWhenHealthy(t *testing.T) {
+	primary := &stubProvider{providerType: ProviderOpenAI, dimensions: 4}
+	secondary := &stubProvider{providerType: ProviderVoyage, dimensions: 4}
+
+	provider, err := NewFallbackProvider(primary, secondary)
+	if err != nil {
+		t.Fatalf("NewFallbackProvider failed: %v", err)
+	}
+
+	if _, err := provider.CreateEmbeddings(context.Background(), []string{"a"}); err != nil {
+		t.Fatalf("CreateEmbeddings failed: %v", err)
+	}
+	if secondary.calls != 0 {
+		t.Errorf("Expected the secondary provider to be unused, got %d calls", secondary.calls)
+	}
+	if primary.calls != 1 {
+		t.Errorf("Expected the primary provider to serve the batch, got %d calls", primary.calls)
+	}
+}
+
+func TestFallbackProvider_UsageAttributedToServingProvider(t *testing.T) {
+	primary := &stubProvider{providerType: ProviderOpenAI, dimensions: 4}
+	secondary := &stubProvider{providerType: ProviderOllama, dimensions: 4}
+
+	fallback, err := NewFallbackProvider(primary, secondary)
+	if err != nil {
+		t.Fatalf("NewFallbackProvider failed: %v", err)
+	}
+	provider, err := NewUsageTrackingProvider(fallback)
+	if err != nil {
+		t.Fatalf("NewUsageTrackingProvider failed: %v", err)
+	}
+
+	if _, err := provider.CreateEmbeddings(context.Background(), []string{"first batch"}); err != nil {
+		t.Fatalf("CreateEmbeddings failed: %v", err)
+	}
+	primary.err = unavailable("service unavailable")
+	for range 2 {
+		if _, err := provider.CreateEmbeddings(context.Background(), []string{"next batch"}); err != nil {
+			t.Fatalf("CreateEmbeddings failed: %v", err)
+		}
+	}
+
+	stats := provider.GetUsageStats()
+	served := stats.ProviderRequests
+	if stats.Requests != 3 || served[ProviderOpenAI] != 1 || served[ProviderOllama] != 2 {
+		t.Errorf("Expected 1 request served by openai and 2 by ollama, got %+v", stats)
+	}
+
+	// The unpriced primary model makes the cost unknown; the self-hosted fallback is free
+	if stats.CostKnown {
+		t.Errorf("Expected the cost of the primary model to be unknown, got %+v", stats)
+	}
+	provider.ResetUsageStats()
+	if _, err := provider.CreateEmbeddings(context.Background(), []string{"batch"}); err != nil {
+		t.Fatalf("CreateEmbeddings failed: %v", err)
+	}
+	if stats := provider.GetUsageStats(); !stats.CostKnown || stats.EstimatedCost != 0 {
+		t.Errorf("Expected batches served by ollama to be priced as free, got %+v", stats)
+	}
+}
+
+func TestFallbackProvider_AllFail(t *testing.T) {
+	primary := &stubProvider{providerType: ProviderOpenAI, err: unavailable("primary down")}
+	secondary := &stubProvider{providerType: ProviderOllama, err: unavailable("secondary down")}
+
+	provider, err := NewFallbackProvider(primary, secondary)
+	if err != nil {
+		t.Fatalf("NewFallbackProvider failed: %v", err)
+	}
+
+	_, err = provider.CreateEmbeddings(context.Background(), []string{"a"})
+	if err == nil {
+		t.Fatal("Expected an error when every provider fails")
+	}
+	if !strings.Contains(err.Error(), "primary down") || !strings.Contains(err.Error(), "secondary down") {
+		t.Errorf("Expected both provider errors to be reported, got %v", err)
+	}
+}
+
+func TestFallbackProvider_ReturnsInputErrors(t *testing.T) {
+	rejected := apierror.New(http.StatusBadRequest, errors.New("input too long"))
+	primary := &stubProvider{providerType: ProviderOpenAI, dimensions: 4, err: rejected}
+	secondary := &stubProvider{providerType: ProviderVoyage, dimensions: 4}
+
+	provider, err := NewFallbackProvider(primary, secondary)
+	if err != nil {
+		t.Fatalf("NewFallbackProvider failed: %v", err)
+	}
+
+	_, err = provider.CreateEmbeddings(context.Background(), []string{"a"})
+	if apierror.StatusCode(err) != http.StatusBadRequest {
+		t.Errorf("Expected the rejection of the primary provider, got %v", err)
+	}
+	if secondary.calls != 0 {
+		t.Errorf("Expected no failover on a rejected input, got %d calls", secondary.calls)
+	}
+}
+
+func TestFallbackProvider_RejectsOtherDimensions(t *testing.T) {
+	// The fallback uses its model default, which only shows once it serves a batch
+	primary := &stubProvider{providerType: ProviderOpenAI, dimensions: 4}
+	secondary := &stubProvider{providerType: ProviderOllama, vectorSize: 8}
+
+	provider, err := NewFallbackProvider(primary, secondary)
+	if err != nil {
+		t.Fatalf("NewFallbackProvider failed: %v", err)
+	}
+	if _, err := provider.CreateEmbeddings(context.Background(), []string{"a"}); err != nil {
+		t.Fatalf("CreateEmbeddings failed: %v", err)
+	}
+
+	primary.err = unavailable("service unavailable")
+	if _, err := provider.CreateEmbeddings(context.Background(), []string{"b"}); err == nil {
+		t.Error("Expected an error for vectors of another dimension")
+	}
+}
+
+func TestFallbackProvider_StopsOnCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	primary := &stubProvider{providerType: ProviderOpenAI, err: context.Canceled}
+	secondary := &stubProvider{providerType: ProviderVoyage}
+
+	provider, err := NewFallbackProvider(primary, secondary)
+	if err != nil {
+		t.Fatalf("NewFallbackProvider failed: %v", err)
+	}
+
+	if _, err := provider.CreateEmbeddings(ctx, []string{"a"}); err == nil {
+		t.Fatal("Expected an error for a cancelled context")
+	}
+	if secondary.calls != 0 {
+		t.Errorf("Expected no failover after cancellation, got %d calls", secondary.calls)
+	}
+}
+
+func TestNewFallbackProvider_DimensionMismatch(t *testing.T) {
+	primary := &stubProvider{providerType: ProviderOpenAI, dimensions: 1536}
+	secondary := &stubProvider{providerType: ProviderVoyage, dimensions: 1024}
+
+	if _, err := NewFallbackProvider(primary, secondary); err == nil {
+		t.Error("Expected error for mismatched dimensions")
+	}
+}
@@ -0,0 +1,14 @@
+package embedding
+
+import (
+	"net/http"
+
+	"github.com/kuderr/deepwiki/internal/httpheaders"
+)
+
+// ApplyHeaders adds the configured custom headers to an outbound request. Protected headers are
+// only replaced when Config.OverrideHeaders is set. It must be called after the provider has set
+// its own headers.
+func (c *Config) ApplyHeaders(req *http.Request) {
+	httpheaders.Apply(req, c.Headers, c.OverrideHeaders)
+}
@@ -3,6 +3,8 @@ package embedding
 import (
 	"context"
 	"time"
+
+	"github.com/kuderr/deepwiki/pkg/ratelimit"
 )
 
 // ProviderType represents the type of embedding provider
@@ -12,6 +14,7 @@ const (
 	ProviderOpenAI ProviderType = "openai"
 	ProviderVoyage ProviderType = "voyage"
 	ProviderOllama ProviderType = "ollama"
+	ProviderTEI    ProviderType = "tei"
 )
 
 // EmbeddingResponse represents a response from embeddings API
@@ -20,6 +23,9 @@ type EmbeddingResponse struct {
 	Data   []Embedding `json:"data"`
 	Model  string      `json:"model"`
 	Usage  Usage       `json:"usage"`
+
+	// Provider that served the response, set by FallbackProvider; empty for a single provider
+	Provider ProviderType `json:"-"`
 }
 
 // Embedding represents a single embedding
@@ -69,11 +75,28 @@ type Config struct {
 	RetryDelay     time.Duration `yaml:"retry_delay"`
 	RateLimitRPS   float64       `yaml:"rate_limit_rps"`
 
+	// Consecutive failed requests that open the circuit breaker (0 = disabled),
+	// and how long requests fail fast before the provider is probed again
+	CircuitBreakerThreshold int           `yaml:"circuit_breaker_threshold,omitempty"`
+	CircuitBreakerCooldown  time.Duration `yaml:"circuit_breaker_cooldown,omitempty"`
+
 	// Provider-specific configurations
-	BaseURL    string `yaml:"base_url,omitempty"`   // For custom endpoints (Ollama)
+	BaseURL    string `yaml:"base_url,omitempty"`   // For custom endpoints (Ollama, TEI)
 	Dimensions int    `yaml:"dimensions,omitempty"` // For some providers
+
+	// Extra headers sent with every request, e.g. for API gateways.
+	// OverrideHeaders lets them replace the provider's auth and content type headers.
+	Headers         map[string]string `yaml:"headers,omitempty"`
+	OverrideHeaders bool              `yaml:"override_headers,omitempty"`
+
+	// Scheduler shares a request budget with the other providers of the same account, such as
+	// the LLM provider. Every HTTP request waits for its turn as a SchedulerClass request.
+	Scheduler *ratelimit.Scheduler `yaml:"-"`
 }
 
+// SchedulerClass is the class of embedding requests in a ratelimit.Scheduler
+const SchedulerClass = "embedding"
+
 // DefaultConfig returns default configuration for the specified provider
 func DefaultConfig(provider ProviderType, apiKey string) *Config {
 	base := &Config{
@@ -99,6 +122,11 @@ func DefaultConfig(provider ProviderType, apiKey string) *Config {
 		base.BaseURL = "http://localhost:11434"
 		base.Dimensions = 768
 		base.APIKey = "" // Ollama doesn't need API key
+	case ProviderTEI:
+		base.Model = "BAAI/bge-base-en-v1.5"
+		base.BaseURL = "http://localhost:8080"
+		base.Dimensions = 768
+		base.APIKey = "" // TEI only needs a key when started with --api-key
 	}
 
 	return base
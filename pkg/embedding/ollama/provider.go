@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/kuderr/deepwiki/internal/logging"
+	"github.com/kuderr/deepwiki/pkg/apierror"
 	"github.com/kuderr/deepwiki/pkg/embedding"
 	"golang.org/x/time/rate"
 )
@@ -54,7 +55,8 @@ func NewProvider(config *embedding.Config) (embedding.Provider, error) {
 
 	// Set up HTTP client with timeout
 	httpClient := &http.Client{
-		Timeout: config.RequestTimeout,
+		Timeout:   config.RequestTimeout,
+		Transport: config.Scheduler.Transport(nil, embedding.SchedulerClass),
 	}
 
 	provider := &OllamaProvider{
@@ -150,6 +152,7 @@ func (p *OllamaProvider) createSingleEmbedding(ctx context.Context, text string)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	p.config.ApplyHeaders(req)
 
 	// Perform request with retries
 	var response *http.Response
@@ -169,7 +172,8 @@ func (p *OllamaProvider) createSingleEmbedding(ctx context.Context, text string)
 			break // Success or client error (don't retry client errors)
 		}
 
-		if response != nil {
+		// The last response is read below
+		if response != nil && attempt < p.config.MaxRetries {
 			response.Body.Close()
 		}
 
@@ -194,7 +198,8 @@ func (p *OllamaProvider) createSingleEmbedding(ctx context.Context, text string)
 	}
 
 	if response.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", response.StatusCode, string(body))
+		return nil, apierror.New(response.StatusCode,
+			fmt.Errorf("API request failed with status %d: %s", response.StatusCode, string(body)))
 	}
 
 	var ollamaResponse OllamaEmbedResponse
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/kuderr/deepwiki/internal/logging"
+	"github.com/kuderr/deepwiki/pkg/apierror"
 	"github.com/kuderr/deepwiki/pkg/embedding"
 	"golang.org/x/time/rate"
 )
@@ -94,7 +95,8 @@ func NewProvider(config *embedding.Config) (embedding.Provider, error) {
 
 	// Set up HTTP client with timeout
 	httpClient := &http.Client{
-		Timeout: config.RequestTimeout,
+		Timeout:   config.RequestTimeout,
+		Transport: config.Scheduler.Transport(nil, embedding.SchedulerClass),
 	}
 
 	provider := &OpenAIProvider{
@@ -297,6 +299,7 @@ func (p *OpenAIProvider) sendRequest(ctx context.Context, request EmbeddingReque
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+	p.config.ApplyHeaders(req)
 
 	// Perform request with retries
 	var response *http.Response
@@ -316,7 +319,8 @@ func (p *OpenAIProvider) sendRequest(ctx context.Context, request EmbeddingReque
 			break // Success or client error (don't retry client errors)
 		}
 
-		if response != nil {
+		// The last response is read below
+		if response != nil && attempt < p.config.MaxRetries {
 			response.Body.Close()
 		}
 	}
@@ -335,9 +339,10 @@ func (p *OpenAIProvider) sendRequest(ctx context.Context, request EmbeddingReque
 	if response.StatusCode != http.StatusOK {
 		var apiError APIError
 		if err := json.Unmarshal(body, &apiError); err != nil {
-			return nil, fmt.Errorf("API request failed with status %d: %s", response.StatusCode, string(body))
+			return nil, apierror.New(response.StatusCode,
+				fmt.Errorf("API request failed with status %d: %s", response.StatusCode, string(body)))
 		}
-		return nil, fmt.Errorf("API error: %w", apiError)
+		return nil, apierror.New(response.StatusCode, fmt.Errorf("API error: %w", apiError))
 	}
 
 	var embeddingResponse EmbeddingResponse
@@ -660,3 +660,59 @@ func TestOpenAIProvider_EmptyTextList(t *testing.T) {
 		t.Errorf("Expected error to contain 'no texts provided', got: %v", err)
 	}
 }
+
+func TestOpenAIProvider_CustomHeaders(t *testing.T) {
+	tests := []struct {
+		name         string
+		override     bool
+		expectedAuth string
+	}{
+		{name: "protected headers kept", override: false, expectedAuth: "Bearer test-key"},
+		{name: "explicit override", override: true, expectedAuth: "Bearer gateway-key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get("OpenAI-Organization"); got != "org-123" {
+					t.Errorf("Expected OpenAI-Organization header, got %q", got)
+				}
+				if got := r.Header.Get("Authorization"); got != tt.expectedAuth {
+					t.Errorf("Expected Authorization %q, got %q", tt.expectedAuth, got)
+				}
+
+				response := EmbeddingResponse{
+					Object: "list",
+					Model:  "text-embedding-3-small",
+					Data:   []EmbeddingData{{Object: "embedding", Embedding: make([]float64, 4)}},
+				}
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(response)
+			}))
+			defer server.Close()
+
+			config := &embedding.Config{
+				Provider:       embedding.ProviderOpenAI,
+				APIKey:         "test-key",
+				Model:          "text-embedding-3-small",
+				BaseURL:        server.URL,
+				RequestTimeout: 30 * time.Second,
+				RateLimitRPS:   10.0,
+				Headers: map[string]string{
+					"OpenAI-Organization": "org-123",
+					"Authorization":       "Bearer gateway-key",
+				},
+				OverrideHeaders: tt.override,
+			}
+
+			provider, err := NewProvider(config)
+			if err != nil {
+				t.Fatalf("NewProvider() error = %v", err)
+			}
+
+			if _, err := provider.CreateEmbeddings(context.Background(), []string{"test"}); err != nil {
+				t.Fatalf("CreateEmbeddings() error = %v", err)
+			}
+		})
+	}
+}
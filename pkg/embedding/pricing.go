@@ -0,0 +1,27 @@
+package embedding
+
+// embeddingCostPer1M lists published prices in USD per million input tokens
+// (these would need to be updated based on current pricing)
+var embeddingCostPer1M = map[string]float64{
+	"text-embedding-3-small": 0.02,
+	"text-embedding-3-large": 0.13,
+	"text-embedding-ada-002": 0.10,
+	"voyage-3-large":         0.18,
+	"voyage-3":               0.06,
+	"voyage-3-lite":          0.02,
+	"voyage-code-3":          0.18,
+}
+
+// EstimateCost returns the cost in USD of embedding tokens with the given model and whether
+// its price is known. Models served by Ollama or TEI are self-hosted and free.
+func EstimateCost(provider ProviderType, model string, tokens int) (float64, bool) {
+	if provider == ProviderOllama || provider == ProviderTEI {
+		return 0, true
+	}
+
+	costPer1M, ok := embeddingCostPer1M[model]
+	if !ok {
+		return 0, false
+	}
+	return float64(tokens) * costPer1M / 1000000, true
+}
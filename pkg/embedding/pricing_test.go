@@ -0,0 +1,18 @@
+package embedding
+
+import "testing"
+
+func TestEstimateCost(t *testing.T) {
+	cost, ok := EstimateCost(ProviderOpenAI, "text-embedding-3-small", 2000000)
+	if !ok || cost != 0.04 {
+		t.Errorf("Expected $0.04 for 2M tokens, got %v (known: %v)", cost, ok)
+	}
+
+	if cost, ok := EstimateCost(ProviderOllama, "nomic-embed-text", 2000000); !ok || cost != 0 {
+		t.Errorf("Expected local Ollama embeddings to be free, got %v (known: %v)", cost, ok)
+	}
+
+	if _, ok := EstimateCost(ProviderOpenAI, "unknown-model", 1000); ok {
+		t.Error("Expected an unknown model to have no price")
+	}
+}
@@ -0,0 +1,321 @@
+package tei
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kuderr/deepwiki/internal/logging"
+	"github.com/kuderr/deepwiki/pkg/apierror"
+	"github.com/kuderr/deepwiki/pkg/embedding"
+	"golang.org/x/time/rate"
+)
+
+// defaultBatchSize is the default max_client_batch_size of a TEI server
+const defaultBatchSize = 32
+
+// TEIProvider implements embedding.Provider for HuggingFace Text Embeddings Inference
+type TEIProvider struct {
+	config      *embedding.Config
+	httpClient  *http.Client
+	rateLimiter *rate.Limiter
+	logger      *logging.Logger
+}
+
+// EmbedRequest represents a request to the native TEI /embed API
+type EmbedRequest struct {
+	Inputs   []string `json:"inputs"`
+	Truncate bool     `json:"truncate"`
+}
+
+// EmbedResponse is the response of the TEI /embed API: one vector per input, in input order
+type EmbedResponse [][]float64
+
+// ErrorResponse represents an error returned by TEI
+type ErrorResponse struct {
+	Error     string `json:"error"`
+	ErrorType string `json:"error_type"`
+}
+
+// NewProvider creates a new TEI embedding provider
+func NewProvider(config *embedding.Config) (embedding.Provider, error) {
+	if config == nil {
+		return nil, fmt.Errorf("config cannot be nil")
+	}
+
+	if config.BaseURL == "" {
+		return nil, fmt.Errorf("base URL is required for TEI provider")
+	}
+
+	if config.Model == "" {
+		return nil, fmt.Errorf("model is required")
+	}
+
+	// Set up rate limiter
+	rateLimiter := rate.NewLimiter(rate.Limit(config.RateLimitRPS), 1)
+
+	// Set up HTTP client with timeout
+	httpClient := &http.Client{
+		Timeout:   config.RequestTimeout,
+		Transport: config.Scheduler.Transport(nil, embedding.SchedulerClass),
+	}
+
+	provider := &TEIProvider{
+		config:      config,
+		httpClient:  httpClient,
+		rateLimiter: rateLimiter,
+		logger:      logging.GetGlobalLogger().WithComponent("tei-embed"),
+	}
+
+	return provider, nil
+}
+
+// CreateEmbeddings creates embeddings for the given texts
+func (p *TEIProvider) CreateEmbeddings(
+	ctx context.Context,
+	texts []string,
+	opts ...embedding.EmbeddingOptions,
+) (*embedding.EmbeddingResponse, error) {
+	if len(texts) == 0 {
+		return nil, fmt.Errorf("no texts provided")
+	}
+
+	// Apply options
+	options := embedding.EmbeddingOptions{
+		BatchSize: defaultBatchSize,
+	}
+	if len(opts) > 0 && opts[0].BatchSize > 0 {
+		options.BatchSize = opts[0].BatchSize
+	}
+
+	p.logger.Debug("creating embeddings",
+		slog.String("model", p.config.Model),
+		slog.Int("text_count", len(texts)),
+		slog.Int("batch_size", options.BatchSize))
+
+	allEmbeddings := make([]embedding.Embedding, 0, len(texts))
+	totalTokens := 0
+
+	// TEI rejects requests with more inputs than its max_client_batch_size
+	for i := 0; i < len(texts); i += options.BatchSize {
+		end := min(i+options.BatchSize, len(texts))
+		batch := texts[i:end]
+
+		if err := p.rateLimiter.Wait(ctx); err != nil {
+			return nil, fmt.Errorf("rate limiting failed: %w", err)
+		}
+
+		vectors, err := p.sendRequest(ctx, EmbedRequest{Inputs: batch, Truncate: true})
+		if err != nil {
+			return nil, fmt.Errorf("batch %d-%d failed: %w", i, end, err)
+		}
+		if len(vectors) != len(batch) {
+			return nil, fmt.Errorf("batch %d-%d failed: expected %d embeddings, got %d",
+				i, end, len(batch), len(vectors))
+		}
+
+		for j, vector := range vectors {
+			allEmbeddings = append(allEmbeddings, embedding.Embedding{
+				Object:    "embedding",
+				Index:     i + j,
+				Embedding: vector,
+			})
+		}
+
+		// TEI does not report usage; estimate tokens (roughly 4 characters per token)
+		for _, text := range batch {
+			totalTokens += p.EstimateTokens(text)
+		}
+	}
+
+	response := &embedding.EmbeddingResponse{
+		Object: "list",
+		Data:   allEmbeddings,
+		Model:  p.config.Model,
+		Usage: embedding.Usage{
+			PromptTokens: totalTokens,
+			TotalTokens:  totalTokens,
+		},
+	}
+
+	p.logger.Debug("embeddings created successfully",
+		slog.Int("embedding_count", len(allEmbeddings)),
+		slog.Int("total_tokens", totalTokens))
+
+	return response, nil
+}
+
+// sendRequest posts a request to the /embed API, retrying server errors and overload (429)
+func (p *TEIProvider) sendRequest(ctx context.Context, request EmbedRequest) (EmbedResponse, error) {
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := strings.TrimSuffix(p.config.BaseURL, "/") + "/embed"
+
+	var response *http.Response
+	var lastErr error
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if p.config.APIKey != "" {
+			req.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+		}
+		p.config.ApplyHeaders(req)
+
+		response, lastErr = p.httpClient.Do(req)
+		retryable := lastErr != nil || response.StatusCode >= 500 ||
+			response.StatusCode == http.StatusTooManyRequests
+		if !retryable || attempt == p.config.MaxRetries {
+			break // Success, client error, or the last attempt whose error is reported
+		}
+
+		if lastErr != nil {
+			p.logger.Warn("request attempt failed, retrying",
+				slog.Int("attempt", attempt+1),
+				slog.Int("max_retries", p.config.MaxRetries),
+				slog.String("error", lastErr.Error()))
+		} else {
+			p.logger.Warn("request attempt failed, retrying",
+				slog.Int("attempt", attempt+1),
+				slog.Int("max_retries", p.config.MaxRetries),
+				slog.Int("status", response.StatusCode))
+			response.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(p.config.RetryDelay * time.Duration(attempt+1)):
+		}
+	}
+
+	if lastErr != nil {
+		return nil, fmt.Errorf("request failed after %d retries: %w", p.config.MaxRetries, lastErr)
+	}
+
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		var errorResp ErrorResponse
+		if err := json.Unmarshal(body, &errorResp); err != nil || errorResp.Error == "" {
+			return nil, apierror.New(response.StatusCode,
+				fmt.Errorf("API request failed with status %d: %s", response.StatusCode, string(body)))
+		}
+		return nil, apierror.New(response.StatusCode, fmt.Errorf("API error (%s, status %d): %s",
+			errorResp.ErrorType, response.StatusCode, errorResp.Error))
+	}
+
+	var embedResponse EmbedResponse
+	if err := json.Unmarshal(body, &embedResponse); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return embedResponse, nil
+}
+
+// GetProviderType returns the provider type
+func (p *TEIProvider) GetProviderType() embedding.ProviderType {
+	return embedding.ProviderTEI
+}
+
+// GetModel returns the current model
+func (p *TEIProvider) GetModel() string {
+	return p.config.Model
+}
+
+// GetDimensions returns the embedding dimensions
+func (p *TEIProvider) GetDimensions() int {
+	if p.config.Dimensions > 0 {
+		return p.config.Dimensions
+	}
+	// Default dimensions for common models served by TEI
+	switch p.config.Model {
+	case "BAAI/bge-small-en-v1.5", "sentence-transformers/all-MiniLM-L6-v2":
+		return 384
+	case "BAAI/bge-base-en-v1.5", "nomic-ai/nomic-embed-text-v1.5":
+		return 768
+	case "BAAI/bge-large-en-v1.5", "BAAI/bge-m3":
+		return 1024
+	default:
+		return 768 // Default fallback
+	}
+}
+
+// GetMaxTokens returns the maximum tokens supported
+func (p *TEIProvider) GetMaxTokens() int {
+	// Longer inputs are truncated by the server
+	switch p.config.Model {
+	case "nomic-ai/nomic-embed-text-v1.5", "BAAI/bge-m3":
+		return 8192
+	case "sentence-transformers/all-MiniLM-L6-v2":
+		return 256
+	default:
+		return 512 // Most BERT based models
+	}
+}
+
+// EstimateTokens estimates the number of tokens in the given text
+func (p *TEIProvider) EstimateTokens(text string) int {
+	// Simple approximation: ~4 characters per token
+	return len(text) / 4
+}
+
+// SplitTextForEmbedding splits text into chunks that fit within token limits
+func (p *TEIProvider) SplitTextForEmbedding(text string, maxTokens int) []string {
+	if maxTokens <= 0 {
+		maxTokens = p.GetMaxTokens()
+	}
+
+	// Handle empty text
+	if text == "" {
+		return []string{}
+	}
+
+	// Simple character-based splitting (approximating tokens)
+	maxChars := maxTokens * 4 // ~4 chars per token
+
+	if len(text) <= maxChars {
+		return []string{text}
+	}
+
+	var chunks []string
+	words := strings.Fields(text)
+	var currentChunk strings.Builder
+
+	for _, word := range words {
+		// Check if adding this word would exceed the token limit
+		estimatedTokens := p.EstimateTokens(currentChunk.String() + " " + word)
+		if currentChunk.Len() > 0 && estimatedTokens > maxTokens {
+			chunks = append(chunks, strings.TrimSpace(currentChunk.String()))
+			currentChunk.Reset()
+		}
+
+		if currentChunk.Len() > 0 {
+			currentChunk.WriteString(" ")
+		}
+		currentChunk.WriteString(word)
+	}
+
+	if currentChunk.Len() > 0 {
+		chunks = append(chunks, strings.TrimSpace(currentChunk.String()))
+	}
+
+	return chunks
+}
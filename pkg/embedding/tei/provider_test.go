@@ -0,0 +1,191 @@
+package tei
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kuderr/deepwiki/pkg/embedding"
+)
+
+// newTestConfig returns a TEI config for a server at baseURL
+func newTestConfig(baseURL string) *embedding.Config {
+	return &embedding.Config{
+		Provider:       embedding.ProviderTEI,
+		Model:          "BAAI/bge-small-en-v1.5",
+		BaseURL:        baseURL,
+		RequestTimeout: 30 * time.Second,
+		MaxRetries:     2,
+		RetryDelay:     time.Millisecond,
+		RateLimitRPS:   100.0,
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *embedding.Config
+		wantErr string
+	}{
+		{"nil config", nil, "config cannot be nil"},
+		{"missing base URL", &embedding.Config{Provider: embedding.ProviderTEI, Model: "BAAI/bge-small-en-v1.5"},
+			"base URL is required for TEI provider"},
+		{"missing model", &embedding.Config{Provider: embedding.ProviderTEI, BaseURL: "http://localhost:8080"},
+			"model is required"},
+		{"valid config", newTestConfig("http://localhost:8080"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := NewProvider(tt.config)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("NewProvider() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewProvider() unexpected error = %v", err)
+			}
+
+			if provider.GetProviderType() != embedding.ProviderTEI {
+				t.Errorf("GetProviderType() = %v, want %v", provider.GetProviderType(), embedding.ProviderTEI)
+			}
+			if provider.GetDimensions() != 384 {
+				t.Errorf("GetDimensions() = %d, want 384", provider.GetDimensions())
+			}
+			if provider.GetMaxTokens() != 512 {
+				t.Errorf("GetMaxTokens() = %d, want 512", provider.GetMaxTokens())
+			}
+		})
+	}
+}
+
+func TestTEIProvider_CreateEmbeddings(t *testing.T) {
+	var batches [][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/embed" {
+			t.Errorf("Expected POST /embed, got %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer tei-key" {
+			t.Errorf("Expected the API key as a bearer token, got %q", got)
+		}
+
+		var request EmbedRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		if !request.Truncate {
+			t.Error("Expected inputs to be truncated by the server")
+		}
+		batches = append(batches, request.Inputs)
+
+		// TEI answers with an array of vectors, one per input
+		response := make(EmbedResponse, len(request.Inputs))
+		for i, input := range request.Inputs {
+			response[i] = []float64{float64(len(input)), 1}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	config := newTestConfig(server.URL + "/")
+	config.APIKey = "tei-key"
+	provider, err := NewProvider(config)
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+
+	texts := []string{"a", "bb", "ccc"}
+	response, err := provider.CreateEmbeddings(context.Background(), texts, embedding.EmbeddingOptions{BatchSize: 2})
+	if err != nil {
+		t.Fatalf("CreateEmbeddings() error = %v", err)
+	}
+
+	if len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 1 {
+		t.Errorf("Expected batches of 2 and 1 texts, got %v", batches)
+	}
+	if response.Model != "BAAI/bge-small-en-v1.5" {
+		t.Errorf("Expected the configured model, got %s", response.Model)
+	}
+	if len(response.Data) != len(texts) {
+		t.Fatalf("Expected %d embeddings, got %d", len(texts), len(response.Data))
+	}
+	for i, data := range response.Data {
+		if data.Index != i {
+			t.Errorf("Embedding %d has index %d", i, data.Index)
+		}
+		if data.Embedding[0] != float64(len(texts[i])) {
+			t.Errorf("Embedding %d = %v, want the vector of %q", i, data.Embedding, texts[i])
+		}
+	}
+}
+
+func TestTEIProvider_CreateEmbeddingsErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		body         string
+		wantErr      string
+		wantRequests int
+	}{
+		{
+			name:         "validation error",
+			status:       http.StatusRequestEntityTooLarge,
+			body:         `{"error":"batch size 64 > maximum allowed batch size 32","error_type":"Validation"}`,
+			wantErr:      "API error (Validation, status 413): batch size 64 > maximum allowed batch size 32",
+			wantRequests: 1,
+		},
+		{
+			name:         "overloaded",
+			status:       http.StatusTooManyRequests,
+			body:         `{"error":"Model is overloaded","error_type":"Overloaded"}`,
+			wantErr:      "Model is overloaded",
+			wantRequests: 3,
+		},
+		{
+			name:         "server error",
+			status:       http.StatusInternalServerError,
+			body:         "internal error",
+			wantErr:      "API request failed with status 500: internal error",
+			wantRequests: 3,
+		},
+		{
+			name:         "wrong number of embeddings",
+			status:       http.StatusOK,
+			body:         `[[0.1, 0.2]]`,
+			wantErr:      "expected 2 embeddings, got 1",
+			wantRequests: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			provider, err := NewProvider(newTestConfig(server.URL))
+			if err != nil {
+				t.Fatalf("NewProvider() error = %v", err)
+			}
+
+			_, err = provider.CreateEmbeddings(context.Background(), []string{"first", "second"})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("CreateEmbeddings() error = %v, want error containing %q", err, tt.wantErr)
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("Expected %d requests, got %d", tt.wantRequests, requests)
+			}
+		})
+	}
+}
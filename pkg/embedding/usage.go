@@ -0,0 +1,112 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// UsageStats is the embedding usage recorded by a UsageTrackingProvider
+type UsageStats struct {
+	Requests      int
+	TotalTokens   int
+	EstimatedCost float64 // USD, covering only the tokens of models with a known price
+	CostKnown     bool    // Whether every model used has a known price
+
+	// Requests served by each provider, which differ from the wrapped one after a failover
+	ProviderRequests map[ProviderType]int
+}
+
+// usageKey identifies the provider and model that served a request
+type usageKey struct {
+	provider ProviderType
+	model    string
+}
+
+// UsageTrackingProvider wraps a provider and sums the token usage reported in its responses.
+// Responses without usage, as returned by Ollama, are counted with estimated tokens.
+type UsageTrackingProvider struct {
+	Provider
+
+	mu       sync.Mutex
+	requests map[ProviderType]int
+	tokens   map[usageKey]int
+}
+
+// NewUsageTrackingProvider creates a provider that records the usage of provider
+func NewUsageTrackingProvider(provider Provider) (*UsageTrackingProvider, error) {
+	if provider == nil {
+		return nil, fmt.Errorf("provider cannot be nil")
+	}
+
+	return &UsageTrackingProvider{
+		Provider: provider,
+		requests: make(map[ProviderType]int),
+		tokens:   make(map[usageKey]int),
+	}, nil
+}
+
+// CreateEmbeddings calls the wrapped provider and records the usage of successful requests
+func (p *UsageTrackingProvider) CreateEmbeddings(
+	ctx context.Context,
+	texts []string,
+	opts ...EmbeddingOptions,
+) (*EmbeddingResponse, error) {
+	response, err := p.Provider.CreateEmbeddings(ctx, texts, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := response.Usage.TotalTokens
+	if tokens == 0 {
+		tokens = response.Usage.PromptTokens
+	}
+	if tokens == 0 {
+		for _, text := range texts {
+			tokens += p.EstimateTokens(text)
+		}
+	}
+	// A fallback provider names the provider that served the response
+	key := usageKey{provider: response.Provider, model: response.Model}
+	if key.provider == "" {
+		key.provider = p.GetProviderType()
+	}
+	if key.model == "" {
+		key.model = p.GetModel()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests[key.provider]++
+	p.tokens[key] += tokens
+
+	return response, nil
+}
+
+// GetUsageStats returns the usage recorded so far
+func (p *UsageTrackingProvider) GetUsageStats() UsageStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := UsageStats{CostKnown: true, ProviderRequests: make(map[ProviderType]int, len(p.requests))}
+	for provider, requests := range p.requests {
+		stats.Requests += requests
+		stats.ProviderRequests[provider] = requests
+	}
+	for key, tokens := range p.tokens {
+		stats.TotalTokens += tokens
+		cost, ok := EstimateCost(key.provider, key.model, tokens)
+		stats.EstimatedCost += cost
+		stats.CostKnown = stats.CostKnown && ok
+	}
+	return stats
+}
+
+// ResetUsageStats clears the recorded usage
+func (p *UsageTrackingProvider) ResetUsageStats() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	clear(p.requests)
+	clear(p.tokens)
+}
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/kuderr/deepwiki/internal/logging"
+	"github.com/kuderr/deepwiki/pkg/apierror"
 	"github.com/kuderr/deepwiki/pkg/embedding"
 	"golang.org/x/time/rate"
 )
@@ -96,7 +97,8 @@ func NewProvider(config *embedding.Config) (embedding.Provider, error) {
 
 	// Set up HTTP client with timeout
 	httpClient := &http.Client{
-		Timeout: config.RequestTimeout,
+		Timeout:   config.RequestTimeout,
+		Transport: config.Scheduler.Transport(nil, embedding.SchedulerClass),
 	}
 
 	provider := &VoyageProvider{
@@ -313,6 +315,7 @@ func (p *VoyageProvider) sendRequest(
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+	p.config.ApplyHeaders(req)
 
 	// Perform request with retries
 	var response *http.Response
@@ -332,7 +335,8 @@ func (p *VoyageProvider) sendRequest(
 			break // Success or client error (don't retry client errors)
 		}
 
-		if response != nil {
+		// The last response is read below
+		if response != nil && attempt < p.config.MaxRetries {
 			response.Body.Close()
 		}
 	}
@@ -354,11 +358,12 @@ func (p *VoyageProvider) sendRequest(
 			// Try fallback to simple APIError
 			var apiError APIError
 			if err := json.Unmarshal(body, &apiError); err != nil {
-				return nil, fmt.Errorf("API request failed with status %d: %s", response.StatusCode, string(body))
+				return nil, apierror.New(response.StatusCode,
+					fmt.Errorf("API request failed with status %d: %s", response.StatusCode, string(body)))
 			}
-			return nil, fmt.Errorf("API error: %w", apiError)
+			return nil, apierror.New(response.StatusCode, fmt.Errorf("API error: %w", apiError))
 		}
-		return nil, fmt.Errorf("API error: %s", errorResp.Error.Detail)
+		return nil, apierror.New(response.StatusCode, fmt.Errorf("API error: %s", errorResp.Error.Detail))
 	}
 
 	var embeddingResponse VoyageEmbeddingResponse
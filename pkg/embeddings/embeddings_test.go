@@ -1,7 +1,15 @@
 package embeddings
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -94,6 +102,28 @@ func TestSimilarityMetrics(t *testing.T) {
 	}
 }
 
+func TestSimilarityMetrics_HigherIsMoreSimilar(t *testing.T) {
+	// Unit vectors, so that the dot product is bounded like the other metrics
+	query := []float32{0.6, 0.8, 0}
+	near := []float32{0.8, 0.6, 0}
+	far := []float32{0, 0.6, 0.8}
+
+	for _, metric := range SimilarityMetrics {
+		calc := NewSimilarityCalculator(metric)
+
+		identical := calc.Calculate(query, query)
+		if math.Abs(float64(identical)-1) > 1e-6 {
+			t.Errorf("Metric %s: expected the maximum similarity 1 for identical vectors, got %f", metric, identical)
+		}
+
+		nearScore, farScore := calc.Calculate(query, near), calc.Calculate(query, far)
+		if !(identical > nearScore && nearScore > farScore) {
+			t.Errorf("Metric %s: expected identical > near > far, got %f, %f, %f",
+				metric, identical, nearScore, farScore)
+		}
+	}
+}
+
 func TestBoltVectorDB(t *testing.T) {
 	// Create temporary database
 	tempDir := t.TempDir()
@@ -317,6 +347,453 @@ func TestBoltVectorDBBatch(t *testing.T) {
 	}
 }
 
+// storeVectors stores one document with a chunk per named vector
+func storeVectors(t testing.TB, db *BoltVectorDB, vectors map[string][]float32) {
+	t.Helper()
+
+	embedding := &DocumentEmbedding{DocumentID: "doc", FilePath: "doc.go", ProcessedAt: time.Now()}
+	for id, vector := range vectors {
+		embedding.Embeddings = append(embedding.Embeddings, EmbeddingVector{
+			ID:        id,
+			Vector:    vector,
+			Content:   id,
+			Dimension: len(vector),
+		})
+	}
+	if err := db.Store(embedding); err != nil {
+		t.Fatalf("Failed to store embedding: %v", err)
+	}
+}
+
+func TestBoltVectorDB_SearchHonorsMetric(t *testing.T) {
+	// Each vector is the closest match to the query under exactly one metric
+	vectors := map[string][]float32{
+		"same-direction": {3, 0},     // cosine 1
+		"largest":        {5, 4},     // dot product 5
+		"nearest":        {1.3, 0.3}, // euclidean 0.42
+		"fewest-steps":   {1, 0.5},   // manhattan 0.5
+	}
+	expected := map[SimilarityMetric]string{
+		CosineSimilarity:  "same-direction",
+		DotProduct:        "largest",
+		EuclideanDistance: "nearest",
+		ManhattanDistance: "fewest-steps",
+	}
+
+	for metric, want := range expected {
+		t.Run(string(metric), func(t *testing.T) {
+			config := &EmbeddingConfig{
+				StoragePath: filepath.Join(t.TempDir(), "test.db"),
+				Dimensions:  2,
+				Timeout:     30,
+				Metric:      metric,
+			}
+			db, err := NewBoltVectorDB(config)
+			if err != nil {
+				t.Fatalf("Failed to create vector database: %v", err)
+			}
+			defer db.Close()
+
+			if db.Metric() != metric {
+				t.Errorf("Expected metric %s, got %s", metric, db.Metric())
+			}
+			storeVectors(t, db, vectors)
+
+			results, err := db.Search([]float32{1, 0}, &VectorSearchOptions{TopK: 1})
+			if err != nil {
+				t.Fatalf("Failed to search: %v", err)
+			}
+			if len(results) != 1 || results[0].ChunkID != want {
+				t.Errorf("Expected top result %s, got %+v", want, results)
+			}
+		})
+	}
+}
+
+func TestBoltVectorDB_MetricMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	open := func(metric SimilarityMetric) (*BoltVectorDB, error) {
+		return NewBoltVectorDB(&EmbeddingConfig{StoragePath: path, Dimensions: 2, Timeout: 30, Metric: metric})
+	}
+
+	// An empty database takes the metric it is opened with
+	db, err := open(EuclideanDistance)
+	if err != nil {
+		t.Fatalf("Failed to create vector database: %v", err)
+	}
+	db.Close()
+
+	db, err = open(DotProduct)
+	if err != nil {
+		t.Fatalf("Failed to reopen empty vector database: %v", err)
+	}
+	storeVectors(t, db, map[string][]float32{"chunk": {1, 0}})
+	db.Close()
+
+	if _, err := open(CosineSimilarity); !errors.Is(err, ErrMetricMismatch) {
+		t.Fatalf("Expected ErrMetricMismatch, got %v", err)
+	}
+
+	db, err = open(DotProduct)
+	if err != nil {
+		t.Fatalf("Failed to reopen vector database with its metric: %v", err)
+	}
+	db.Close()
+
+	if _, err := open("jaccard"); err == nil {
+		t.Error("Expected an error for an unknown metric")
+	}
+}
+
+func TestBoltVectorDB_ConcurrentWrites(t *testing.T) {
+	config := &EmbeddingConfig{
+		StoragePath: filepath.Join(t.TempDir(), "test.db"),
+		Dimensions:  2,
+		Timeout:     30,
+	}
+	db, err := NewBoltVectorDB(config)
+	if err != nil {
+		t.Fatalf("Failed to create vector database: %v", err)
+	}
+	defer db.Close()
+
+	const writers, chunks = 20, 5
+	document := func(writer, version int) *DocumentEmbedding {
+		embedding := &DocumentEmbedding{
+			DocumentID:  fmt.Sprintf("doc-%d", writer),
+			FilePath:    fmt.Sprintf("file-%d.go", writer),
+			ProcessedAt: time.Now(),
+		}
+		for chunk := 0; chunk < chunks; chunk++ {
+			embedding.Embeddings = append(embedding.Embeddings, EmbeddingVector{
+				ID:      fmt.Sprintf("doc-%d-v%d-chunk-%d", writer, version, chunk),
+				Vector:  []float32{1, float32(chunk)},
+				Content: fmt.Sprintf("writer %d version %d chunk %d", writer, version, chunk),
+			})
+		}
+		return embedding
+	}
+
+	// Every writer stores its document twice, once through StoreBatch, and a throwaway document
+	// that it deletes again, while readers search
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*4)
+	for writer := 0; writer < writers; writer++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			errs <- db.Store(document(writer, 1))
+			errs <- db.StoreBatch([]*DocumentEmbedding{document(writer, 2)})
+
+			scratch := document(writers+writer, 1)
+			errs <- db.Store(scratch)
+			errs <- db.Delete(scratch.DocumentID)
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := db.Search([]float32{1, 0}, &VectorSearchOptions{TopK: 10}); err != nil {
+				t.Errorf("Failed to search: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Concurrent write failed: %v", err)
+		}
+	}
+
+	docIDs, err := db.List()
+	if err != nil {
+		t.Fatalf("Failed to list documents: %v", err)
+	}
+	if len(docIDs) != writers {
+		t.Errorf("Expected %d documents, got %d", writers, len(docIDs))
+	}
+	for writer := 0; writer < writers; writer++ {
+		stored, err := db.Get(fmt.Sprintf("doc-%d", writer))
+		if err != nil {
+			t.Fatalf("Failed to get document %d: %v", writer, err)
+		}
+		if len(stored.Embeddings) != chunks || stored.Embeddings[0].ID != fmt.Sprintf("doc-%d-v2-chunk-0", writer) {
+			t.Errorf("Document %d does not hold the last stored version: %+v", writer, stored.Embeddings)
+		}
+	}
+
+	// Replaced versions leave no stale chunks behind
+	results, err := db.Search([]float32{1, 0}, &VectorSearchOptions{TopK: writers * chunks * 3})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(results) != writers*chunks {
+		t.Errorf("Expected %d searchable chunks, got %d", writers*chunks, len(results))
+	}
+
+	stats, err := db.GetStats()
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	if stats.TotalDocuments != writers || stats.TotalEmbeddings != writers*chunks {
+		t.Errorf("Expected stats for %d documents and %d embeddings, got %d and %d",
+			writers, writers*chunks, stats.TotalDocuments, stats.TotalEmbeddings)
+	}
+}
+
+// newSearchBenchmarkDB returns a database holding n vectors of the given dimensions, where every
+// value repeats for several chunks so that rankings contain ties
+func newSearchBenchmarkDB(tb testing.TB, n, dimensions int) *BoltVectorDB {
+	tb.Helper()
+
+	db, err := NewBoltVectorDB(&EmbeddingConfig{
+		StoragePath: filepath.Join(tb.TempDir(), "search.db"),
+		Dimensions:  dimensions,
+		Timeout:     30,
+	})
+	if err != nil {
+		tb.Fatalf("Failed to create vector database: %v", err)
+	}
+	tb.Cleanup(func() { db.Close() })
+
+	vectors := make(map[string][]float32, n)
+	for i := 0; i < n; i++ {
+		vector := make([]float32, dimensions)
+		for j := range vector {
+			vector[j] = float32((i/3*7+j*13)%97) / 97
+		}
+		vectors[fmt.Sprintf("chunk-%05d", i)] = vector
+	}
+	storeVectors(tb, db, vectors)
+
+	return db
+}
+
+func TestBoltVectorDB_ParallelSearchMatchesSequential(t *testing.T) {
+	db := newSearchBenchmarkDB(t, 4*minParallelSearch+37, 8)
+	query := []float32{0.9, 0.1, 0.5, 0.3, 0.7, 0.2, 0.8, 0.4}
+
+	search := func(workers int, options *VectorSearchOptions) []VectorSearchResult {
+		db.searchWorkers = workers
+		results, err := db.Search(query, options)
+		if err != nil {
+			t.Fatalf("Failed to search with %d workers: %v", workers, err)
+		}
+		return results
+	}
+
+	for _, options := range []*VectorSearchOptions{
+		{TopK: 25},
+		{TopK: 10, Offset: 40, MinScore: 0.5},
+		{TopK: 3 * minParallelSearch},
+	} {
+		sequential := search(1, options)
+		if len(sequential) == 0 {
+			t.Fatalf("Expected results for %+v", *options)
+		}
+		for _, workers := range []int{2, 4, 16} {
+			if parallel := search(workers, options); !reflect.DeepEqual(parallel, sequential) {
+				t.Errorf("Expected %d workers to return the sequential results for %+v", workers, *options)
+			}
+		}
+	}
+}
+
+func TestCandidateHeap_MatchesSort(t *testing.T) {
+	// Scores repeat so that ties are broken by chunk ID
+	var candidates []candidateResult
+	for i := 0; i < 200; i++ {
+		candidates = append(candidates, candidateResult{
+			ChunkID: fmt.Sprintf("chunk-%03d", (i*37)%200),
+			Score:   float32((i*53)%23) / 23,
+		})
+	}
+
+	sorted := slices.Clone(candidates)
+	slices.SortFunc(sorted, func(a, b candidateResult) int {
+		if rankedBefore(a, b) {
+			return -1
+		}
+		if rankedBefore(b, a) {
+			return 1
+		}
+		return 0
+	})
+
+	for _, n := range []int{0, 1, 10, 199, 200, 500} {
+		best := candidateHeap{}
+		for _, candidate := range candidates {
+			best.offer(candidate, n)
+		}
+		if best.Len() > n {
+			t.Errorf("Expected the heap to hold at most %d candidates, got %d", n, best.Len())
+		}
+
+		want := sorted[:min(n, len(sorted))]
+		if got := best.ranked(); !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected the heap top %d to match the sorted top %d, got %v", n, n, got)
+		}
+	}
+}
+
+func TestBoltVectorDB_LockTimeout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := NewBoltVectorDB(&EmbeddingConfig{StoragePath: path, Timeout: 30})
+	if err != nil {
+		t.Fatalf("Failed to create vector database: %v", err)
+	}
+	defer db.Close()
+
+	start := time.Now()
+	_, err = NewBoltVectorDB(&EmbeddingConfig{StoragePath: path, Timeout: 30, OpenTimeout: 50 * time.Millisecond})
+	if !errors.Is(err, ErrDatabaseLocked) {
+		t.Fatalf("Expected ErrDatabaseLocked, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Expected the open timeout to replace Timeout, waited %s", elapsed)
+	}
+	if !strings.Contains(err.Error(), path) {
+		t.Errorf("Expected the error to name the database file, got %v", err)
+	}
+}
+
+func TestBoltVectorDB_Namespaces(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shared.db")
+	open := func(namespace string, metric SimilarityMetric) *BoltVectorDB {
+		t.Helper()
+		db, err := NewBoltVectorDB(&EmbeddingConfig{
+			StoragePath: path,
+			Dimensions:  2,
+			Timeout:     30,
+			Namespace:   namespace,
+			Metric:      metric,
+		})
+		if err != nil {
+			t.Fatalf("Failed to open namespace %q: %v", namespace, err)
+		}
+		return db
+	}
+
+	// Each project stores a document under the same ID
+	for _, namespace := range []string{"alpha", "beta"} {
+		db := open(namespace, CosineSimilarity)
+		err := db.Store(&DocumentEmbedding{
+			DocumentID: "doc",
+			FilePath:   namespace + ".go",
+			Embeddings: []EmbeddingVector{{ID: namespace + "-chunk", Vector: []float32{1, 0}, Content: namespace}},
+		})
+		if err != nil {
+			t.Fatalf("Failed to store in %s: %v", namespace, err)
+		}
+		db.Close()
+	}
+
+	for _, namespace := range []string{"alpha", "beta"} {
+		db := open(namespace, CosineSimilarity)
+		results, err := db.Search([]float32{1, 0}, &VectorSearchOptions{TopK: 10, IncludeContent: true})
+		if err != nil {
+			t.Fatalf("Failed to search %s: %v", namespace, err)
+		}
+		if len(results) != 1 || results[0].ChunkID != namespace+"-chunk" || results[0].Content != namespace {
+			t.Errorf("Expected only the %s chunk, got %+v", namespace, results)
+		}
+		if doc, err := db.Get("doc"); err != nil || doc.FilePath != namespace+".go" {
+			t.Errorf("Expected the %s document, got %+v (%v)", namespace, doc, err)
+		}
+		db.Close()
+	}
+
+	// The metric is recorded per namespace, and the unprefixed buckets are a namespace of their own
+	db := open("", DotProduct)
+	if ids, err := db.List(); err != nil || len(ids) != 0 {
+		t.Errorf("Expected the default namespace to be empty, got %v (%v)", ids, err)
+	}
+	db.Close()
+}
+
+func TestBoltVectorDB_SearchPaging(t *testing.T) {
+	db, err := NewBoltVectorDB(&EmbeddingConfig{
+		StoragePath: filepath.Join(t.TempDir(), "test.db"),
+		Dimensions:  2,
+		Timeout:     30,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create vector database: %v", err)
+	}
+	defer db.Close()
+
+	// Chunks come in pairs with the same vector, so ties must be ordered consistently
+	vectors := make(map[string][]float32)
+	for i := 0; i < 23; i++ {
+		vectors[fmt.Sprintf("chunk-%02d", i)] = []float32{1, float32(i / 2)}
+	}
+	storeVectors(t, db, vectors)
+
+	query := []float32{1, 0}
+	all, err := db.Search(query, &VectorSearchOptions{TopK: 100})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(all) != len(vectors) {
+		t.Fatalf("Expected %d results, got %d", len(vectors), len(all))
+	}
+	expected := make([]string, len(all))
+	for i, result := range all {
+		expected[i] = result.ChunkID
+	}
+
+	chunkIDs := func(results []VectorSearchResult) []string {
+		ids := make([]string, len(results))
+		for i, result := range results {
+			ids[i] = result.ChunkID
+		}
+		return ids
+	}
+
+	// Paging by cursor
+	var paged []string
+	cursor, pages := "", 0
+	for {
+		page, err := db.SearchPage(query, &VectorSearchOptions{TopK: 5, IncludeContent: true}, cursor)
+		if err != nil {
+			t.Fatalf("Failed to search page %d: %v", pages, err)
+		}
+		pages++
+		for _, result := range page.Results {
+			if result.Content != result.ChunkID {
+				t.Errorf("Expected content %s, got %q", result.ChunkID, result.Content)
+			}
+		}
+		paged = append(paged, chunkIDs(page.Results)...)
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	if !slices.Equal(paged, expected) {
+		t.Errorf("Cursor paging returned %v, want %v", paged, expected)
+	}
+	if pages != 5 {
+		t.Errorf("Expected 5 pages of up to 5 results, got %d", pages)
+	}
+
+	// Paging by offset
+	paged = nil
+	for offset := 0; offset < len(vectors); offset += 5 {
+		results, err := db.Search(query, &VectorSearchOptions{TopK: 5, Offset: offset})
+		if err != nil {
+			t.Fatalf("Failed to search at offset %d: %v", offset, err)
+		}
+		paged = append(paged, chunkIDs(results)...)
+	}
+	if !slices.Equal(paged, expected) {
+		t.Errorf("Offset paging returned %v, want %v", paged, expected)
+	}
+
+	if _, err := db.SearchPage(query, nil, "not a cursor"); err == nil {
+		t.Error("Expected an error for an invalid cursor")
+	}
+}
+
 func TestEmbeddingService(t *testing.T) {
 	// Create mock embedding generator
 	mockGen := &MockEmbeddingGenerator{
@@ -581,3 +1058,29 @@ func BenchmarkVectorSearch(b *testing.B) {
 		}
 	}
 }
+
+func BenchmarkBoltVectorDB_Search(b *testing.B) {
+	db := newSearchBenchmarkDB(b, 5000, 128)
+	query := make([]float32, 128)
+	for i := range query {
+		query[i] = float32(i%17) / 17
+	}
+	options := &VectorSearchOptions{TopK: 10}
+
+	for _, bench := range []struct {
+		name    string
+		workers int
+	}{
+		{"sequential", 1},
+		{"parallel", runtime.GOMAXPROCS(0)},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			db.searchWorkers = bench.workers
+			for i := 0; i < b.N; i++ {
+				if _, err := db.Search(query, options); err != nil {
+					b.Fatalf("Search failed: %v", err)
+				}
+			}
+		})
+	}
+}
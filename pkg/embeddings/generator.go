@@ -2,10 +2,13 @@ package embeddings
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/kuderr/deepwiki/pkg/apierror"
 	"github.com/kuderr/deepwiki/pkg/embedding"
 )
 
@@ -13,8 +16,12 @@ import (
 type EmbeddingProviderGenerator struct {
 	provider embedding.Provider
 	config   *EmbeddingConfig
+	progress ProgressFunc
 }
 
+// ProgressFunc is called as batch embedding progresses, with the number of texts done out of total
+type ProgressFunc func(done, total int)
+
 // NewEmbeddingProviderGenerator creates a new embedding generator with any provider
 func NewEmbeddingProviderGenerator(provider embedding.Provider, config *EmbeddingConfig) *EmbeddingProviderGenerator {
 	if config == nil {
@@ -27,6 +34,11 @@ func NewEmbeddingProviderGenerator(provider embedding.Provider, config *Embeddin
 	}
 }
 
+// SetProgress sets the function GenerateBatchEmbeddings reports its progress to after each batch
+func (g *EmbeddingProviderGenerator) SetProgress(progress ProgressFunc) {
+	g.progress = progress
+}
+
 // GenerateEmbedding generates an embedding for a single text
 func (g *EmbeddingProviderGenerator) GenerateEmbedding(text string) ([]float32, error) {
 	if len(strings.TrimSpace(text)) == 0 {
@@ -70,30 +82,61 @@ func (g *EmbeddingProviderGenerator) GenerateEmbedding(text string) ([]float32,
 	return embedding32, nil
 }
 
-// GenerateBatchEmbeddings generates embeddings for multiple texts
+// SkippedText is a text that could not be embedded
+type SkippedText struct {
+	Index int   // Index of the text in the request
+	Err   error // Provider error for the text on its own
+}
+
+// PartialEmbeddingError is returned with the embeddings of a batch request when some texts could
+// not be embedded. Their vectors are nil; the other vectors are valid.
+type PartialEmbeddingError struct {
+	Skipped []SkippedText
+	Total   int
+}
+
+func (e *PartialEmbeddingError) Error() string {
+	return fmt.Sprintf("skipped %d of %d texts that could not be embedded: %v",
+		len(e.Skipped), e.Total, e.Skipped[0].Err)
+}
+
+// GenerateBatchEmbeddings generates embeddings for multiple texts. When a batch is rejected for
+// its input, it is split in halves until the bad texts are found, so that a single bad text does not
+// fail the others; a transient failure (5xx, rate limit, timeout) fails the run instead. Texts that
+// still fail are skipped: their vectors are nil and a *PartialEmbeddingError is returned with the vectors.
+// The progress function, when set, is called after each batch with the texts done so far.
 func (g *EmbeddingProviderGenerator) GenerateBatchEmbeddings(texts []string) ([][]float32, error) {
 	if len(texts) == 0 {
 		return nil, fmt.Errorf("no texts provided")
 	}
 
-	// Filter out empty texts
+	// Filter out empty texts and embed each distinct text once
 	validTexts := make([]string, 0, len(texts))
-	textIndexMap := make(map[int]int) // Maps result index to original index
+	textIndexMap := make(map[int][]int)   // Maps result index to every original index with that text
+	uniqueIndex := make(map[[32]byte]int) // Maps content hash to result index
 
 	for i, text := range texts {
-		if len(strings.TrimSpace(text)) > 0 {
-			// Check and split if necessary
-			if g.EstimateTokens(text) > g.config.ChunkSize {
-				chunks := g.SplitTextForEmbedding(text, g.config.ChunkSize)
-				if len(chunks) > 0 {
-					validTexts = append(validTexts, chunks[0])
-					textIndexMap[len(validTexts)-1] = i
-				}
-			} else {
-				validTexts = append(validTexts, text)
-				textIndexMap[len(validTexts)-1] = i
+		if len(strings.TrimSpace(text)) == 0 {
+			continue
+		}
+
+		// Check and split if necessary
+		if g.EstimateTokens(text) > g.config.ChunkSize {
+			chunks := g.SplitTextForEmbedding(text, g.config.ChunkSize)
+			if len(chunks) == 0 {
+				continue
 			}
+			text = chunks[0]
 		}
+
+		hash := sha256.Sum256([]byte(text))
+		index, seen := uniqueIndex[hash]
+		if !seen {
+			validTexts = append(validTexts, text)
+			index = len(validTexts) - 1
+			uniqueIndex[hash] = index
+		}
+		textIndexMap[index] = append(textIndexMap[index], i)
 	}
 
 	if len(validTexts) == 0 {
@@ -107,6 +150,9 @@ func (g *EmbeddingProviderGenerator) GenerateBatchEmbeddings(texts []string) ([]
 		batchSize = 100
 	}
 
+	var skipped []SkippedText
+	embedded := 0
+	done := 0
 	for i := 0; i < len(validTexts); i += batchSize {
 		end := i + batchSize
 		if end > len(validTexts) {
@@ -116,17 +162,112 @@ func (g *EmbeddingProviderGenerator) GenerateBatchEmbeddings(texts []string) ([]
 		batch := validTexts[i:end]
 		embeddings, err := g.processBatch(batch)
 		if err != nil {
-			return nil, fmt.Errorf("failed to process batch %d-%d: %v", i, end, err)
+			if apierror.IsTransient(err) {
+				return nil, fmt.Errorf("failed to process batch %d-%d: %w", i, end, err)
+			}
+
+			var errs []error
+			var splitErr error
+			embeddings, errs, splitErr = g.processIsolated(batch, err)
+			if splitErr != nil {
+				return nil, fmt.Errorf("failed to process batch %d-%d: %w", i, end, splitErr)
+			}
+
+			// A batch whose texts all fail on their own points at the provider, not the texts
+			if len(batch) > 1 && !slices.ContainsFunc(embeddings, func(e []float32) bool { return e != nil }) {
+				return nil, fmt.Errorf("failed to process batch %d-%d: %w", i, end, err)
+			}
+			for j, itemErr := range errs {
+				if itemErr == nil {
+					continue
+				}
+				for _, originalIndex := range textIndexMap[i+j] {
+					skipped = append(skipped, SkippedText{Index: originalIndex, Err: itemErr})
+				}
+			}
 		}
 
-		// Map embeddings back to original indices
+		// Fan each embedding back out to all original indices; duplicates get their own copy
 		for j, embedding := range embeddings {
-			originalIndex := textIndexMap[i+j]
-			allEmbeddings[originalIndex] = embedding
+			if embedding == nil {
+				continue
+			}
+			embedded++
+			for k, originalIndex := range textIndexMap[i+j] {
+				if k > 0 {
+					embedding = slices.Clone(embedding)
+				}
+				allEmbeddings[originalIndex] = embedding
+			}
+		}
+
+		if g.progress != nil {
+			for j := range batch {
+				done += len(textIndexMap[i+j])
+			}
+			// Empty texts are done without a request
+			if end == len(validTexts) {
+				done = len(texts)
+			}
+			g.progress(done, len(texts))
 		}
 	}
 
-	return allEmbeddings, nil
+	if len(skipped) == 0 {
+		return allEmbeddings, nil
+	}
+	if embedded == 0 {
+		return nil, fmt.Errorf("failed to generate embeddings: %w", skipped[0].Err)
+	}
+
+	slices.SortFunc(skipped, func(a, b SkippedText) int { return a.Index - b.Index })
+	return allEmbeddings, &PartialEmbeddingError{Skipped: skipped, Total: len(texts)}
+}
+
+// processIsolated finds the texts that made a batch fail with err by splitting it in halves,
+// without retries. The returned slices are parallel to texts; a text that fails has a nil embedding
+// and its error. A transient failure while splitting is returned as the error.
+func (g *EmbeddingProviderGenerator) processIsolated(texts []string, err error) ([][]float32, []error, error) {
+	embeddings := make([][]float32, len(texts))
+	errs := make([]error, len(texts))
+	if len(texts) == 1 {
+		errs[0] = err
+		return embeddings, errs, nil
+	}
+
+	mid := len(texts) / 2
+	if err := g.bisect(texts[:mid], embeddings[:mid], errs[:mid]); err != nil {
+		return nil, nil, err
+	}
+	if err := g.bisect(texts[mid:], embeddings[mid:], errs[mid:]); err != nil {
+		return nil, nil, err
+	}
+	return embeddings, errs, nil
+}
+
+// bisect embeds texts in one request, splitting them in halves while the request is rejected.
+// Results are written to embeddings and errs, which are parallel to texts.
+func (g *EmbeddingProviderGenerator) bisect(texts []string, embeddings [][]float32, errs []error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(g.config.Timeout)*time.Second)
+	result, err := g.requestEmbeddings(ctx, texts)
+	cancel()
+	if err == nil {
+		copy(embeddings, result)
+		return nil
+	}
+	if apierror.IsTransient(err) {
+		return err
+	}
+	if len(texts) == 1 {
+		errs[0] = err
+		return nil
+	}
+
+	mid := len(texts) / 2
+	if err := g.bisect(texts[:mid], embeddings[:mid], errs[:mid]); err != nil {
+		return err
+	}
+	return g.bisect(texts[mid:], embeddings[mid:], errs[mid:])
 }
 
 // processBatch processes a single batch of texts
@@ -134,12 +275,12 @@ func (g *EmbeddingProviderGenerator) processBatch(texts []string) ([][]float32,
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(g.config.Timeout)*time.Second)
 	defer cancel()
 
-	var response *embedding.EmbeddingResponse
+	var embeddings [][]float32
 	var err error
 
 	// Retry logic
 	for attempt := 0; attempt <= g.config.MaxRetries; attempt++ {
-		response, err = g.provider.CreateEmbeddings(ctx, texts)
+		embeddings, err = g.requestEmbeddings(ctx, texts)
 		if err == nil {
 			break
 		}
@@ -152,7 +293,17 @@ func (g *EmbeddingProviderGenerator) processBatch(texts []string) ([][]float32,
 	}
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate embeddings after %d attempts: %v", g.config.MaxRetries+1, err)
+		return nil, fmt.Errorf("failed to generate embeddings after %d attempts: %w", g.config.MaxRetries+1, err)
+	}
+
+	return embeddings, nil
+}
+
+// requestEmbeddings makes a single embeddings request for texts
+func (g *EmbeddingProviderGenerator) requestEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	response, err := g.provider.CreateEmbeddings(ctx, texts)
+	if err != nil {
+		return nil, err
 	}
 
 	if len(response.Data) != len(texts) {
@@ -335,72 +486,3 @@ func (g *EmbeddingProviderGenerator) splitByWords(text string, maxTokens int) []
 
 	return chunks
 }
-
-// ValidateConfig validates the embedding configuration
-func (g *EmbeddingProviderGenerator) ValidateConfig() error {
-	if g.config.Model == "" {
-		return fmt.Errorf("embedding model not specified")
-	}
-
-	if g.config.Dimensions <= 0 {
-		return fmt.Errorf("invalid dimensions: %d", g.config.Dimensions)
-	}
-
-	if g.config.ChunkSize <= 0 {
-		return fmt.Errorf("invalid chunk size: %d", g.config.ChunkSize)
-	}
-
-	if g.config.BatchSize <= 0 {
-		return fmt.Errorf("invalid batch size: %d", g.config.BatchSize)
-	}
-
-	// Validate model-specific constraints
-	switch g.config.Model {
-	case "text-embedding-3-small":
-		if g.config.Dimensions > 1536 {
-			return fmt.Errorf("text-embedding-3-small supports max 1536 dimensions, got %d", g.config.Dimensions)
-		}
-	case "text-embedding-3-large":
-		if g.config.Dimensions > 3072 {
-			return fmt.Errorf("text-embedding-3-large supports max 3072 dimensions, got %d", g.config.Dimensions)
-		}
-	case "text-embedding-ada-002":
-		if g.config.Dimensions != 1536 {
-			return fmt.Errorf("text-embedding-ada-002 only supports 1536 dimensions, got %d", g.config.Dimensions)
-		}
-	default:
-		// Unknown model, use defaults
-	}
-
-	return nil
-}
-
-// GetModelInfo returns information about the current model
-func (g *EmbeddingProviderGenerator) GetModelInfo() map[string]interface{} {
-	info := map[string]interface{}{
-		"model":      g.config.Model,
-		"dimensions": g.config.Dimensions,
-		"maxTokens":  g.config.ChunkSize,
-		"batchSize":  g.config.BatchSize,
-		"maxRetries": g.config.MaxRetries,
-		"timeout":    g.config.Timeout,
-	}
-
-	// Add model-specific information
-	switch g.config.Model {
-	case "text-embedding-3-small":
-		info["cost_per_1k_tokens"] = 0.00002
-		info["max_dimensions"] = 1536
-		info["performance"] = "high"
-	case "text-embedding-3-large":
-		info["cost_per_1k_tokens"] = 0.00013
-		info["max_dimensions"] = 3072
-		info["performance"] = "highest"
-	case "text-embedding-ada-002":
-		info["cost_per_1k_tokens"] = 0.0001
-		info["max_dimensions"] = 1536
-		info["performance"] = "standard"
-	}
-
-	return info
-}
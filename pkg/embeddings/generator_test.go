@@ -0,0 +1,362 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kuderr/deepwiki/pkg/apierror"
+	"github.com/kuderr/deepwiki/pkg/embedding"
+	"github.com/kuderr/deepwiki/pkg/embedding/factory"
+	"github.com/kuderr/deepwiki/pkg/processor"
+)
+
+// countingProvider embeds each text as its length and records every text it receives.
+// Requests containing a text in reject fail, with status when it is set.
+type countingProvider struct {
+	received []string
+	requests int
+	reject   map[string]bool
+	status   int
+}
+
+func (p *countingProvider) CreateEmbeddings(
+	ctx context.Context,
+	texts []string,
+	opts ...embedding.EmbeddingOptions,
+) (*embedding.EmbeddingResponse, error) {
+	p.requests++
+	for _, text := range texts {
+		if p.reject[text] {
+			err := fmt.Errorf("invalid input: %q", text)
+			if p.status != 0 {
+				err = apierror.New(p.status, err)
+			}
+			return nil, err
+		}
+	}
+	p.received = append(p.received, texts...)
+
+	response := &embedding.EmbeddingResponse{Object: "list"}
+	for i, text := range texts {
+		response.Data = append(response.Data, embedding.Embedding{
+			Index:     i,
+			Embedding: []float64{float64(len(text)), 1},
+		})
+	}
+	return response, nil
+}
+
+func (p *countingProvider) GetProviderType() embedding.ProviderType { return embedding.ProviderOpenAI }
+func (p *countingProvider) GetModel() string                        { return "test-model" }
+func (p *countingProvider) GetDimensions() int                      { return 2 }
+func (p *countingProvider) GetMaxTokens() int                       { return 8192 }
+func (p *countingProvider) EstimateTokens(text string) int          { return len(text) / 4 }
+func (p *countingProvider) SplitTextForEmbedding(text string, maxTokens int) []string {
+	return []string{text}
+}
+
+func TestGenerateBatchEmbeddings_DeduplicatesTexts(t *testing.T) {
+	provider := &countingProvider{}
+	config := DefaultEmbeddingConfig()
+	config.BatchSize = 2
+	generator := NewEmbeddingProviderGenerator(provider, config)
+
+	header := "// Copyright 2024 Example Corp. Licensed under the Apache License 2.0"
+	texts := []string{header, "func main() {}", header, "", "package stub", header, "func main() {}"}
+
+	vectors, err := generator.GenerateBatchEmbeddings(texts)
+	if err != nil {
+		t.Fatalf("GenerateBatchEmbeddings failed: %v", err)
+	}
+
+	if len(provider.received) != 3 {
+		t.Errorf("Expected 3 unique texts sent to the provider, got %d: %v", len(provider.received), provider.received)
+	}
+	if len(vectors) != len(texts) {
+		t.Fatalf("Expected %d vectors, got %d", len(texts), len(vectors))
+	}
+
+	for i, text := range texts {
+		if text == "" {
+			if vectors[i] != nil {
+				t.Errorf("Expected no vector for empty text at %d", i)
+			}
+			continue
+		}
+		if vectors[i] == nil || vectors[i][0] != float32(len(text)) {
+			t.Errorf("Expected vector for text %q at %d, got %v", text, i, vectors[i])
+		}
+	}
+
+	// Duplicates share values but not storage
+	vectors[0][1] = 42
+	if vectors[2][1] != 1 {
+		t.Error("Expected duplicate texts to receive independent vectors")
+	}
+}
+
+func TestGenerateBatchEmbeddings_SkipsFailingTexts(t *testing.T) {
+	provider := &countingProvider{reject: map[string]bool{"bad chunk": true}}
+	config := DefaultEmbeddingConfig()
+	config.BatchSize = 2
+	config.MaxRetries = 0
+	generator := NewEmbeddingProviderGenerator(provider, config)
+
+	texts := []string{"first chunk", "second chunk", "bad chunk", "fourth chunk", "fifth chunk", "bad chunk"}
+
+	vectors, err := generator.GenerateBatchEmbeddings(texts)
+	var partial *PartialEmbeddingError
+	if !errors.As(err, &partial) {
+		t.Fatalf("Expected a PartialEmbeddingError, got %v", err)
+	}
+	if partial.Total != len(texts) {
+		t.Errorf("Expected %d texts in total, got %d", len(texts), partial.Total)
+	}
+
+	var skipped []int
+	for _, s := range partial.Skipped {
+		skipped = append(skipped, s.Index)
+		if s.Err == nil {
+			t.Errorf("Expected an error recorded for skipped text %d", s.Index)
+		}
+	}
+	if !slices.Equal(skipped, []int{2, 5}) {
+		t.Errorf("Expected texts 2 and 5 to be skipped, got %v", skipped)
+	}
+
+	for i, text := range texts {
+		if text == "bad chunk" {
+			if vectors[i] != nil {
+				t.Errorf("Expected no vector for the failing text at %d", i)
+			}
+			continue
+		}
+		if vectors[i] == nil || vectors[i][0] != float32(len(text)) {
+			t.Errorf("Expected vector for text %q at %d, got %v", text, i, vectors[i])
+		}
+	}
+
+	// The failing batch is split in halves; the other batches are sent once
+	if provider.requests != 5 {
+		t.Errorf("Expected 5 requests (3 batches and 2 halves), got %d", provider.requests)
+	}
+}
+
+func TestGenerateBatchEmbeddings_BisectsFailingBatch(t *testing.T) {
+	provider := &countingProvider{reject: map[string]bool{"bad": true}, status: http.StatusBadRequest}
+	config := DefaultEmbeddingConfig()
+	config.BatchSize = 8
+	config.MaxRetries = 0
+	generator := NewEmbeddingProviderGenerator(provider, config)
+
+	texts := []string{"t1", "t2", "t3", "t4", "t5", "bad", "t7", "t8"}
+	vectors, err := generator.GenerateBatchEmbeddings(texts)
+	var partial *PartialEmbeddingError
+	if !errors.As(err, &partial) {
+		t.Fatalf("Expected a PartialEmbeddingError, got %v", err)
+	}
+	if len(partial.Skipped) != 1 || partial.Skipped[0].Index != 5 {
+		t.Errorf("Expected only text 5 to be skipped, got %v", partial.Skipped)
+	}
+	for i, vector := range vectors {
+		if (vector == nil) != (i == 5) {
+			t.Errorf("Unexpected vector %v for text %d", vector, i)
+		}
+	}
+
+	// The batch, then two halves of 4, 2 and 1 texts instead of 8 single texts
+	if provider.requests != 7 {
+		t.Errorf("Expected 7 requests, got %d", provider.requests)
+	}
+}
+
+func TestGenerateBatchEmbeddings_FailsOnTransientError(t *testing.T) {
+	provider := &countingProvider{reject: map[string]bool{"t2": true}, status: http.StatusServiceUnavailable}
+	config := DefaultEmbeddingConfig()
+	config.BatchSize = 4
+	config.MaxRetries = 0
+	generator := NewEmbeddingProviderGenerator(provider, config)
+
+	_, err := generator.GenerateBatchEmbeddings([]string{"t1", "t2", "t3", "t4"})
+	if err == nil {
+		t.Fatal("Expected an error for a transient failure")
+	}
+	var partial *PartialEmbeddingError
+	if errors.As(err, &partial) {
+		t.Errorf("Expected a fatal error, got a partial one: %v", err)
+	}
+	if provider.requests != 1 {
+		t.Errorf("Expected the batch not to be split, got %d requests", provider.requests)
+	}
+}
+
+func TestGenerateBatchEmbeddings_FailsWhenProviderFails(t *testing.T) {
+	texts := []string{"first chunk", "second chunk", "third chunk"}
+	reject := make(map[string]bool)
+	for _, text := range texts {
+		reject[text] = true
+	}
+
+	config := DefaultEmbeddingConfig()
+	config.BatchSize = 2
+	config.MaxRetries = 0
+	generator := NewEmbeddingProviderGenerator(&countingProvider{reject: reject}, config)
+
+	vectors, err := generator.GenerateBatchEmbeddings(texts)
+	if err == nil {
+		t.Fatal("Expected an error when no text can be embedded")
+	}
+	var partial *PartialEmbeddingError
+	if errors.As(err, &partial) {
+		t.Errorf("Expected a fatal error, got a partial one: %v", err)
+	}
+	if vectors != nil {
+		t.Errorf("Expected no vectors, got %v", vectors)
+	}
+}
+
+func TestGenerateBatchEmbeddings_ReportsProgress(t *testing.T) {
+	provider := &countingProvider{}
+	config := DefaultEmbeddingConfig()
+	config.BatchSize = 2
+	generator := NewEmbeddingProviderGenerator(provider, config)
+
+	var calls [][2]int
+	generator.SetProgress(func(done, total int) {
+		calls = append(calls, [2]int{done, total})
+	})
+
+	// 5 distinct texts make 3 batches; the duplicate counts with its batch and the empty text at the end
+	texts := []string{"one", "two", "three", "one", "four", "", "five"}
+	if _, err := generator.GenerateBatchEmbeddings(texts); err != nil {
+		t.Fatalf("GenerateBatchEmbeddings failed: %v", err)
+	}
+
+	want := [][2]int{{3, 7}, {5, 7}, {7, 7}}
+	if !slices.Equal(calls, want) {
+		t.Errorf("Expected progress calls %v, got %v", want, calls)
+	}
+	if len(calls) != provider.requests {
+		t.Errorf("Expected one progress call per batch request (%d), got %d", provider.requests, len(calls))
+	}
+}
+
+// newFakeEmbeddingServer serves the embedding APIs of OpenAI and Voyage (/embeddings) and Ollama
+// (/api/embeddings), embedding each text as its length, and counts the requests per path
+func newFakeEmbeddingServer(t *testing.T) (*httptest.Server, map[string]int) {
+	t.Helper()
+	var mu sync.Mutex
+	requests := make(map[string]int)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		mu.Unlock()
+
+		var request struct {
+			Input  []string `json:"input"`
+			Inputs []string `json:"inputs"`
+			Prompt string   `json:"prompt"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/embeddings":
+			data := make([]map[string]any, len(request.Input))
+			for i, text := range request.Input {
+				data[i] = map[string]any{
+					"object": "embedding", "index": i, "embedding": []float64{float64(len(text)), 1},
+				}
+			}
+			json.NewEncoder(w).Encode(map[string]any{
+				"object": "list", "data": data, "model": "test-model",
+				"usage": map[string]int{"prompt_tokens": len(request.Input), "total_tokens": len(request.Input)},
+			})
+		case "/api/embeddings":
+			json.NewEncoder(w).Encode(map[string]any{"embedding": []float64{float64(len(request.Prompt)), 1}})
+		case "/embed":
+			vectors := make([][]float64, len(request.Inputs))
+			for i, text := range request.Inputs {
+				vectors[i] = []float64{float64(len(text)), 1}
+			}
+			json.NewEncoder(w).Encode(vectors)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func TestEmbedDocuments_SupportedProviders(t *testing.T) {
+	documents := []processor.Document{
+		{ID: "main", FilePath: "main.go", Language: "Go", Category: "code", Chunks: []processor.TextChunk{
+			{ID: "main-0", Text: "package main"},
+			{ID: "main-1", Text: "func main() {}"},
+			{ID: "main-2", Text: "func run() error { return nil }"},
+		}},
+		{ID: "empty", FilePath: "empty.go", Chunks: []processor.TextChunk{{ID: "empty-0", Text: " "}}},
+		{ID: "readme", FilePath: "README.md", Category: "documentation", Chunks: []processor.TextChunk{
+			{ID: "readme-0", Text: "# Demo"},
+		}},
+	}
+
+	for _, providerType := range embedding.GetSupportedProviders() {
+		t.Run(string(providerType), func(t *testing.T) {
+			server, requests := newFakeEmbeddingServer(t)
+			provider, err := factory.NewEmbeddingProvider(&embedding.Config{
+				Provider:       providerType,
+				APIKey:         "test-key",
+				Model:          "test-model",
+				BaseURL:        server.URL,
+				RequestTimeout: 5 * time.Second,
+				RateLimitRPS:   1000,
+			})
+			if err != nil {
+				t.Fatalf("NewEmbeddingProvider failed: %v", err)
+			}
+
+			embedded, err := EmbedDocuments(NewEmbeddingProviderGenerator(provider, nil), documents)
+			if err != nil {
+				t.Fatalf("EmbedDocuments failed: %v", err)
+			}
+			if len(requests) == 0 {
+				t.Fatal("Expected the provider API to be called")
+			}
+
+			// The document without text is omitted; the others keep their chunks in order
+			if len(embedded) != 2 || embedded[0].DocumentID != "main" || embedded[1].DocumentID != "readme" {
+				t.Fatalf("Expected embeddings for main and readme, got %+v", embedded)
+			}
+			for _, docEmbedding := range embedded {
+				doc := documents[slices.IndexFunc(documents, func(d processor.Document) bool {
+					return d.ID == docEmbedding.DocumentID
+				})]
+				if docEmbedding.ChunkCount != len(doc.Chunks) {
+					t.Errorf("Expected %d chunks for %s, got %d", len(doc.Chunks), doc.ID, docEmbedding.ChunkCount)
+				}
+				for i, vector := range docEmbedding.Embeddings {
+					chunk := doc.Chunks[i]
+					if vector.ID != chunk.ID || vector.Vector[0] != float32(len(chunk.Text)) {
+						t.Errorf("Expected the vector of %s, got %s with %v", chunk.ID, vector.ID, vector.Vector)
+					}
+					if vector.Metadata["file_path"] != doc.FilePath {
+						t.Errorf("Expected file path %s in the metadata, got %v", doc.FilePath, vector.Metadata)
+					}
+				}
+			}
+		})
+	}
+}
@@ -2,6 +2,8 @@ package embeddings
 
 import (
 	"errors"
+	"fmt"
+	"math"
 	"time"
 
 	"github.com/kuderr/deepwiki/pkg/processor"
@@ -9,9 +11,11 @@ import (
 
 // Common errors
 var (
-	ErrNotFound      = errors.New("document not found")
-	ErrInvalidVector = errors.New("invalid vector dimensions")
-	ErrInvalidConfig = errors.New("invalid configuration")
+	ErrNotFound       = errors.New("document not found")
+	ErrInvalidVector  = errors.New("invalid vector dimensions")
+	ErrInvalidConfig  = errors.New("invalid configuration")
+	ErrMetricMismatch = errors.New("similarity metric mismatch")
+	ErrDatabaseLocked = errors.New("vector database is locked")
 )
 
 // EmbeddingVector represents an embedding vector with metadata
@@ -30,7 +34,7 @@ type DocumentEmbedding struct {
 	FilePath    string            `json:"filePath"`    // Original file path
 	Language    string            `json:"language"`    // Programming language
 	Category    string            `json:"category"`    // File category
-	ChunkCount  int               `json:"chunkCount"`  // Number of chunks
+	ChunkCount  int               `json:"chunkCount"`  // Number of embedded chunks
 	Embeddings  []EmbeddingVector `json:"embeddings"`  // Chunk embeddings
 	Summary     *EmbeddingVector  `json:"summary"`     // Optional document-level embedding
 	ProcessedAt time.Time         `json:"processedAt"` // When embeddings were created
@@ -46,9 +50,16 @@ type VectorSearchResult struct {
 	Metadata   map[string]string `json:"metadata"`   // Additional metadata
 }
 
+// VectorSearchPage is one page of search results
+type VectorSearchPage struct {
+	Results    []VectorSearchResult `json:"results"`    // Results on this page, best first
+	NextCursor string               `json:"nextCursor"` // Cursor for the next page; empty on the last page
+}
+
 // VectorSearchOptions represents options for vector search
 type VectorSearchOptions struct {
 	TopK           int                `json:"topK"`           // Number of results to return
+	Offset         int                `json:"offset"`         // Number of top results to skip, for paging
 	MinScore       float32            `json:"minScore"`       // Minimum similarity score
 	MaxResults     int                `json:"maxResults"`     // Maximum results (different from TopK for reranking)
 	FilterBy       map[string]string  `json:"filterBy"`       // Metadata filters
@@ -70,7 +81,7 @@ func DefaultVectorSearchOptions() *VectorSearchOptions {
 
 // EmbeddingConfig represents configuration for embeddings
 type EmbeddingConfig struct {
-	// OpenAI settings
+	// Model settings, normally those of the embedding provider
 	Model      string `json:"model"`      // Embedding model (e.g., "text-embedding-3-small")
 	Dimensions int    `json:"dimensions"` // Vector dimensions
 	BatchSize  int    `json:"batchSize"`  // Batch size for API calls
@@ -81,8 +92,13 @@ type EmbeddingConfig struct {
 	Timeout    int `json:"timeout"`    // Timeout in seconds
 
 	// Storage settings
-	StoragePath string `json:"storagePath"` // Path to vector database file
-	Compress    bool   `json:"compress"`    // Whether to compress vectors
+	StoragePath string        `json:"storagePath"` // Path to vector database file
+	Compress    bool          `json:"compress"`    // Whether to compress vectors
+	Namespace   string        `json:"namespace"`   // Bucket prefix, so several projects can share a file
+	OpenTimeout time.Duration `json:"openTimeout"` // Wait for a file locked by another process (0 = Timeout)
+
+	// Search settings
+	Metric SimilarityMetric `json:"metric"` // Similarity metric used by search (default: cosine)
 }
 
 // DefaultEmbeddingConfig returns default embedding configuration
@@ -96,9 +112,18 @@ func DefaultEmbeddingConfig() *EmbeddingConfig {
 		Timeout:     30,
 		StoragePath: "./embeddings.db",
 		Compress:    false,
+		Metric:      CosineSimilarity,
 	}
 }
 
+// openTimeout returns how long opening the database waits for another process to release it
+func (c *EmbeddingConfig) openTimeout() time.Duration {
+	if c.OpenTimeout > 0 {
+		return c.OpenTimeout
+	}
+	return time.Duration(c.Timeout) * time.Second
+}
+
 // VectorDatabase interface defines operations for vector storage
 type VectorDatabase interface {
 	// Storage operations
@@ -155,6 +180,22 @@ const (
 	ManhattanDistance SimilarityMetric = "manhattan" // Manhattan distance
 )
 
+// SimilarityMetrics lists the supported similarity metrics
+var SimilarityMetrics = []SimilarityMetric{CosineSimilarity, EuclideanDistance, DotProduct, ManhattanDistance}
+
+// ParseSimilarityMetric returns the metric with the given name; empty selects cosine
+func ParseSimilarityMetric(name string) (SimilarityMetric, error) {
+	if name == "" {
+		return CosineSimilarity, nil
+	}
+	for _, metric := range SimilarityMetrics {
+		if string(metric) == name {
+			return metric, nil
+		}
+	}
+	return "", fmt.Errorf("unknown similarity metric: %s", name)
+}
+
 // SimilarityCalculator provides methods for calculating vector similarities
 type SimilarityCalculator struct {
 	metric SimilarityMetric
@@ -165,7 +206,14 @@ func NewSimilarityCalculator(metric SimilarityMetric) *SimilarityCalculator {
 	return &SimilarityCalculator{metric: metric}
 }
 
-// Calculate computes similarity between two vectors
+// Metric returns the metric the calculator uses
+func (sc *SimilarityCalculator) Metric() SimilarityMetric {
+	return sc.metric
+}
+
+// Calculate computes similarity between two vectors. Higher scores mean more similar vectors
+// under every metric, so that rankings and MinScore thresholds work alike: the Euclidean and
+// Manhattan distances are converted to similarities in (0, 1], with 1 for identical vectors.
 func (sc *SimilarityCalculator) Calculate(v1, v2 []float32) float32 {
 	if len(v1) != len(v2) {
 		return 0.0
@@ -175,16 +223,22 @@ func (sc *SimilarityCalculator) Calculate(v1, v2 []float32) float32 {
 	case CosineSimilarity:
 		return sc.cosineSimilarity(v1, v2)
 	case EuclideanDistance:
-		return 1.0 / (1.0 + sc.euclideanDistance(v1, v2)) // Convert distance to similarity
+		return distanceSimilarity(sc.euclideanDistance(v1, v2))
 	case DotProduct:
 		return sc.dotProduct(v1, v2)
 	case ManhattanDistance:
-		return 1.0 / (1.0 + sc.manhattanDistance(v1, v2)) // Convert distance to similarity
+		return distanceSimilarity(sc.manhattanDistance(v1, v2))
 	default:
 		return sc.cosineSimilarity(v1, v2)
 	}
 }
 
+// distanceSimilarity converts a distance to a similarity that is 1 at distance 0 and falls
+// towards 0 as the distance grows
+func distanceSimilarity(distance float32) float32 {
+	return 1.0 / (1.0 + distance)
+}
+
 // cosineSimilarity calculates cosine similarity between vectors
 func (sc *SimilarityCalculator) cosineSimilarity(v1, v2 []float32) float32 {
 	var dotProduct, normA, normB float32
@@ -199,7 +253,7 @@ func (sc *SimilarityCalculator) cosineSimilarity(v1, v2 []float32) float32 {
 		return 0.0
 	}
 
-	return dotProduct / (float32(len(v1)) * float32(len(v2)))
+	return dotProduct / float32(math.Sqrt(float64(normA)*float64(normB)))
 }
 
 // euclideanDistance calculates Euclidean distance between vectors
@@ -209,7 +263,7 @@ func (sc *SimilarityCalculator) euclideanDistance(v1, v2 []float32) float32 {
 		diff := v1[i] - v2[i]
 		sum += diff * diff
 	}
-	return float32(len(v1)) // Simplified for now
+	return float32(math.Sqrt(float64(sum)))
 }
 
 // dotProduct calculates dot product of two vectors
@@ -255,7 +309,7 @@ func NewEmbeddingService(
 	return &EmbeddingService{
 		generator:  generator,
 		database:   database,
-		calculator: NewSimilarityCalculator(CosineSimilarity),
+		calculator: NewSimilarityCalculator(config.Metric),
 		config:     config,
 	}
 }
@@ -283,28 +337,75 @@ func (es *EmbeddingService) ProcessDocument(doc processor.Document) (*DocumentEm
 		texts[i] = chunk.Text
 	}
 
-	// Generate embeddings
+	// Generate embeddings; chunks that could not be embedded are left out
 	vectors, err := es.generator.GenerateBatchEmbeddings(texts)
-	if err != nil {
+	var partial *PartialEmbeddingError
+	if err != nil && !errors.As(err, &partial) {
+		return nil, err
+	}
+
+	return newDocumentEmbedding(doc, vectors), nil
+}
+
+// EmbedDocuments embeds the chunks of all documents in one batch run, so that batches span
+// documents and texts repeated across files are embedded once, and groups the vectors by
+// document. Chunks that could not be embedded are left out and reported by a
+// *PartialEmbeddingError, whose indices count the chunks of all documents in order. Documents
+// without any embedded chunk are omitted.
+func EmbedDocuments(generator EmbeddingGenerator, documents []processor.Document) ([]*DocumentEmbedding, error) {
+	var texts []string
+	for _, doc := range documents {
+		for _, chunk := range doc.Chunks {
+			texts = append(texts, chunk.Text)
+		}
+	}
+
+	vectors, err := generator.GenerateBatchEmbeddings(texts)
+	var partial *PartialEmbeddingError
+	if err != nil && !errors.As(err, &partial) {
 		return nil, err
 	}
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(vectors))
+	}
+
+	var embedded []*DocumentEmbedding
+	offset := 0
+	for _, doc := range documents {
+		docEmbedding := newDocumentEmbedding(doc, vectors[offset:offset+len(doc.Chunks)])
+		offset += len(doc.Chunks)
+		if len(docEmbedding.Embeddings) > 0 {
+			embedded = append(embedded, docEmbedding)
+		}
+	}
 
-	// Create embedding vectors
-	embeddingVectors := make([]EmbeddingVector, len(vectors))
+	if partial != nil {
+		return embedded, partial
+	}
+	return embedded, nil
+}
+
+// newDocumentEmbedding pairs the chunks of doc with their vectors; chunks without a vector are
+// left out
+func newDocumentEmbedding(doc processor.Document, vectors [][]float32) *DocumentEmbedding {
+	embeddingVectors := make([]EmbeddingVector, 0, len(vectors))
 	for i, vector := range vectors {
-		embeddingVectors[i] = EmbeddingVector{
+		if vector == nil {
+			continue
+		}
+		embeddingVectors = append(embeddingVectors, EmbeddingVector{
 			ID:        doc.Chunks[i].ID,
 			Vector:    vector,
 			Content:   doc.Chunks[i].Text, // Store the original chunk content
 			Dimension: len(vector),
 			Metadata: map[string]string{
-				"chunkId":   doc.Chunks[i].ID,
-				"wordCount": string(rune(doc.Chunks[i].WordCount)),
-				"startPos":  string(rune(doc.Chunks[i].StartPos)),
-				"endPos":    string(rune(doc.Chunks[i].EndPos)),
+				"document_id": doc.ID,
+				"file_path":   doc.FilePath,
+				"language":    doc.Language,
+				"category":    doc.Category,
 			},
 			CreatedAt: time.Now(),
-		}
+		})
 	}
 
 	return &DocumentEmbedding{
@@ -312,8 +413,8 @@ func (es *EmbeddingService) ProcessDocument(doc processor.Document) (*DocumentEm
 		FilePath:    doc.FilePath,
 		Language:    doc.Language,
 		Category:    doc.Category,
-		ChunkCount:  len(doc.Chunks),
+		ChunkCount:  len(embeddingVectors),
 		Embeddings:  embeddingVectors,
 		ProcessedAt: time.Now(),
-	}, nil
+	}
 }
@@ -1,19 +1,36 @@
 package embeddings
 
 import (
+	"container/heap"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"sort"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"go.etcd.io/bbolt"
+	bbolterrors "go.etcd.io/bbolt/errors"
 )
 
-// BoltVectorDB implements VectorDatabase using BoltDB for persistence
+// BoltVectorDB implements VectorDatabase using BoltDB for persistence. It is safe for concurrent
+// use: each Store, StoreBatch and Delete call is applied atomically in its own update transaction,
+// writes are serialized, and searches read a consistent snapshot that sees a write completely or
+// not at all.
 type BoltVectorDB struct {
 	db         *bbolt.DB
 	config     *EmbeddingConfig
 	calculator *SimilarityCalculator
+
+	// searchWorkers is the number of goroutines a search scores stored embeddings on
+	searchWorkers int
+
+	// writeMu serializes write transactions. Bolt itself allows a single writer at a time; the lock
+	// keeps that guarantee explicit rather than relying on the storage engine.
+	writeMu sync.Mutex
 }
 
 // Bucket names for different data types
@@ -24,41 +41,55 @@ const (
 	statsBucket      = "stats"
 )
 
-// NewBoltVectorDB creates a new BoltDB-based vector database
+// metricKey is the metadata key holding the similarity metric the stored vectors are searched with
+const metricKey = "metric"
+
+// NewBoltVectorDB creates a new BoltDB-based vector database. The database records the
+// similarity metric of config; opening a database that already holds embeddings for another
+// metric fails with ErrMetricMismatch. Opening a file that another process holds open fails
+// with ErrDatabaseLocked once the open timeout has passed.
 func NewBoltVectorDB(config *EmbeddingConfig) (*BoltVectorDB, error) {
 	if config == nil {
 		config = DefaultEmbeddingConfig()
 	}
+	metric, err := ParseSimilarityMetric(string(config.Metric))
+	if err != nil {
+		return nil, err
+	}
 
-	db, err := bbolt.Open(config.StoragePath, 0o600, &bbolt.Options{
-		Timeout: time.Duration(config.Timeout) * time.Second,
-	})
+	timeout := config.openTimeout()
+	db, err := bbolt.Open(config.StoragePath, 0o600, &bbolt.Options{Timeout: timeout})
+	if errors.Is(err, bbolterrors.ErrTimeout) {
+		return nil, fmt.Errorf("%w: %s is in use by another process (waited %s)",
+			ErrDatabaseLocked, config.StoragePath, timeout)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to open bolt database: %v", err)
 	}
 
+	vdb := &BoltVectorDB{
+		db:            db,
+		config:        config,
+		calculator:    NewSimilarityCalculator(metric),
+		searchWorkers: runtime.GOMAXPROCS(0),
+	}
+
 	// Create buckets
 	err = db.Update(func(tx *bbolt.Tx) error {
 		buckets := []string{documentsBucket, embeddingsBucket, metadataBucket, statsBucket}
 		for _, bucket := range buckets {
-			_, err := tx.CreateBucketIfNotExists([]byte(bucket))
+			_, err := tx.CreateBucketIfNotExists([]byte(vdb.bucketName(bucket)))
 			if err != nil {
-				return fmt.Errorf("failed to create bucket %s: %v", bucket, err)
+				return fmt.Errorf("failed to create bucket %s: %v", vdb.bucketName(bucket), err)
 			}
 		}
-		return nil
+		return vdb.recordMetric(tx, metric)
 	})
 	if err != nil {
 		db.Close()
 		return nil, err
 	}
 
-	vdb := &BoltVectorDB{
-		db:         db,
-		config:     config,
-		calculator: NewSimilarityCalculator(CosineSimilarity),
-	}
-
 	// Initialize stats if not exist
 	if err := vdb.initializeStats(); err != nil {
 		db.Close()
@@ -68,98 +99,132 @@ func NewBoltVectorDB(config *EmbeddingConfig) (*BoltVectorDB, error) {
 	return vdb, nil
 }
 
-// Store stores a single document embedding
-func (vdb *BoltVectorDB) Store(embedding *DocumentEmbedding) error {
-	return vdb.db.Update(func(tx *bbolt.Tx) error {
-		docBucket := tx.Bucket([]byte(documentsBucket))
-		embBucket := tx.Bucket([]byte(embeddingsBucket))
+// bucketName returns the name of a bucket in the namespace of the database. Without a namespace
+// the bucket names are unprefixed, as in databases written before namespaces existed.
+func (vdb *BoltVectorDB) bucketName(name string) string {
+	if vdb.config.Namespace == "" {
+		return name
+	}
+	return vdb.config.Namespace + "/" + name
+}
 
-		// Store document metadata
-		docData, err := json.Marshal(embedding)
-		if err != nil {
-			return fmt.Errorf("failed to marshal document: %v", err)
+// bucket returns the bucket called name in the namespace of the database
+func (vdb *BoltVectorDB) bucket(tx *bbolt.Tx, name string) *bbolt.Bucket {
+	return tx.Bucket([]byte(vdb.bucketName(name)))
+}
+
+// recordMetric stores metric as the metric of the database. Embeddings stored before the metric
+// was recorded were searched with cosine similarity.
+func (vdb *BoltVectorDB) recordMetric(tx *bbolt.Tx, metric SimilarityMetric) error {
+	metaBucket := vdb.bucket(tx, metadataBucket)
+	stored := SimilarityMetric(metaBucket.Get([]byte(metricKey)))
+
+	if key, _ := vdb.bucket(tx, embeddingsBucket).Cursor().First(); key != nil {
+		if stored == "" {
+			stored = CosineSimilarity
+		}
+		if stored != metric {
+			return fmt.Errorf("%w: database holds embeddings for %s, not %s", ErrMetricMismatch, stored, metric)
 		}
+	}
 
-		err = docBucket.Put([]byte(embedding.DocumentID), docData)
-		if err != nil {
-			return fmt.Errorf("failed to store document: %v", err)
-		}
-
-		// Store individual embeddings for fast vector search
-		for _, emb := range embedding.Embeddings {
-			embData := &EmbeddingData{
-				DocumentID: embedding.DocumentID,
-				ChunkID:    emb.ID,
-				FilePath:   embedding.FilePath,
-				Content:    emb.Content, // Store content for easy retrieval
-				Vector:     emb.Vector,
-				Metadata:   emb.Metadata,
-			}
+	return metaBucket.Put([]byte(metricKey), []byte(metric))
+}
 
-			embBytes, err := json.Marshal(embData)
-			if err != nil {
-				return fmt.Errorf("failed to marshal embedding: %v", err)
-			}
+// Metric returns the similarity metric used by Search
+func (vdb *BoltVectorDB) Metric() SimilarityMetric {
+	return vdb.calculator.Metric()
+}
 
-			err = embBucket.Put([]byte(emb.ID), embBytes)
-			if err != nil {
-				return fmt.Errorf("failed to store embedding: %v", err)
-			}
+// Store stores a single document embedding, replacing any stored document with the same ID
+func (vdb *BoltVectorDB) Store(embedding *DocumentEmbedding) error {
+	return vdb.update(func(tx *bbolt.Tx) error {
+		docDelta, embDelta, err := vdb.storeInTx(tx, embedding)
+		if err != nil {
+			return err
 		}
 
 		// Update stats
-		return vdb.updateStatsInTx(tx, 1, len(embedding.Embeddings))
+		return vdb.updateStatsInTx(tx, docDelta, embDelta)
 	})
 }
 
-// StoreBatch stores multiple document embeddings in a single transaction
+// StoreBatch stores multiple document embeddings in a single transaction. Either all of them are
+// stored or, on error, none.
 func (vdb *BoltVectorDB) StoreBatch(embeddings []*DocumentEmbedding) error {
-	return vdb.db.Update(func(tx *bbolt.Tx) error {
-		docBucket := tx.Bucket([]byte(documentsBucket))
-		embBucket := tx.Bucket([]byte(embeddingsBucket))
-
-		totalEmbeddings := 0
+	return vdb.update(func(tx *bbolt.Tx) error {
+		totalDocuments, totalEmbeddings := 0, 0
 
 		for _, embedding := range embeddings {
-			// Store document metadata
-			docData, err := json.Marshal(embedding)
+			docDelta, embDelta, err := vdb.storeInTx(tx, embedding)
 			if err != nil {
-				return fmt.Errorf("failed to marshal document %s: %v", embedding.DocumentID, err)
+				return err
 			}
+			totalDocuments += docDelta
+			totalEmbeddings += embDelta
+		}
 
-			err = docBucket.Put([]byte(embedding.DocumentID), docData)
-			if err != nil {
-				return fmt.Errorf("failed to store document %s: %v", embedding.DocumentID, err)
-			}
+		// Update stats
+		return vdb.updateStatsInTx(tx, totalDocuments, totalEmbeddings)
+	})
+}
 
-			// Store individual embeddings
-			for _, emb := range embedding.Embeddings {
-				embData := &EmbeddingData{
-					DocumentID: embedding.DocumentID,
-					ChunkID:    emb.ID,
-					FilePath:   embedding.FilePath,
-					Content:    emb.Content, // Store content for easy retrieval
-					Vector:     emb.Vector,
-					Metadata:   emb.Metadata,
-				}
-
-				embBytes, err := json.Marshal(embData)
-				if err != nil {
-					return fmt.Errorf("failed to marshal embedding %s: %v", emb.ID, err)
-				}
-
-				err = embBucket.Put([]byte(emb.ID), embBytes)
-				if err != nil {
-					return fmt.Errorf("failed to store embedding %s: %v", emb.ID, err)
-				}
-			}
+// storeInTx writes a document and its chunk embeddings, first removing the embeddings of a
+// previously stored version, and returns the resulting change in document and embedding counts
+func (vdb *BoltVectorDB) storeInTx(tx *bbolt.Tx, embedding *DocumentEmbedding) (int, int, error) {
+	docBucket := vdb.bucket(tx, documentsBucket)
+	embBucket := vdb.bucket(tx, embeddingsBucket)
+
+	docDelta, embDelta := 1, len(embedding.Embeddings)
+	previous, err := vdb.deleteInTx(tx, embedding.DocumentID)
+	if err == nil {
+		docDelta, embDelta = 0, embDelta-previous
+	} else if !errors.Is(err, ErrNotFound) {
+		return 0, 0, err
+	}
 
-			totalEmbeddings += len(embedding.Embeddings)
+	// Store document metadata
+	docData, err := json.Marshal(embedding)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to marshal document %s: %v", embedding.DocumentID, err)
+	}
+
+	err = docBucket.Put([]byte(embedding.DocumentID), docData)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to store document %s: %v", embedding.DocumentID, err)
+	}
+
+	// Store individual embeddings for fast vector search
+	for _, emb := range embedding.Embeddings {
+		embData := &EmbeddingData{
+			DocumentID: embedding.DocumentID,
+			ChunkID:    emb.ID,
+			FilePath:   embedding.FilePath,
+			Content:    emb.Content, // Store content for easy retrieval
+			Vector:     emb.Vector,
+			Metadata:   emb.Metadata,
 		}
 
-		// Update stats
-		return vdb.updateStatsInTx(tx, len(embeddings), totalEmbeddings)
-	})
+		embBytes, err := json.Marshal(embData)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to marshal embedding %s: %v", emb.ID, err)
+		}
+
+		err = embBucket.Put([]byte(emb.ID), embBytes)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to store embedding %s: %v", emb.ID, err)
+		}
+	}
+
+	return docDelta, embDelta, nil
+}
+
+// update runs fn in a write transaction while holding the write lock
+func (vdb *BoltVectorDB) update(fn func(tx *bbolt.Tx) error) error {
+	vdb.writeMu.Lock()
+	defer vdb.writeMu.Unlock()
+
+	return vdb.db.Update(fn)
 }
 
 // Get retrieves a document embedding by ID
@@ -167,7 +232,7 @@ func (vdb *BoltVectorDB) Get(documentID string) (*DocumentEmbedding, error) {
 	var embedding *DocumentEmbedding
 
 	err := vdb.db.View(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket([]byte(documentsBucket))
+		bucket := vdb.bucket(tx, documentsBucket)
 		data := bucket.Get([]byte(documentID))
 		if data == nil {
 			return fmt.Errorf("document not found: %s", documentID)
@@ -181,39 +246,49 @@ func (vdb *BoltVectorDB) Get(documentID string) (*DocumentEmbedding, error) {
 
 // Delete removes a document and all its embeddings
 func (vdb *BoltVectorDB) Delete(documentID string) error {
-	return vdb.db.Update(func(tx *bbolt.Tx) error {
-		// Get document first to find all embedding IDs
-		docBucket := tx.Bucket([]byte(documentsBucket))
-		embBucket := tx.Bucket([]byte(embeddingsBucket))
-
-		data := docBucket.Get([]byte(documentID))
-		if data == nil {
-			return fmt.Errorf("document not found: %s", documentID)
-		}
-
-		var embedding DocumentEmbedding
-		err := json.Unmarshal(data, &embedding)
+	return vdb.update(func(tx *bbolt.Tx) error {
+		removed, err := vdb.deleteInTx(tx, documentID)
 		if err != nil {
-			return fmt.Errorf("failed to unmarshal document: %v", err)
+			return err
 		}
 
-		// Delete individual embeddings
-		for _, emb := range embedding.Embeddings {
-			err = embBucket.Delete([]byte(emb.ID))
-			if err != nil {
-				return fmt.Errorf("failed to delete embedding %s: %v", emb.ID, err)
-			}
-		}
+		// Update stats
+		return vdb.updateStatsInTx(tx, -1, -removed)
+	})
+}
+
+// deleteInTx removes a document and its embeddings and returns how many embeddings it had
+func (vdb *BoltVectorDB) deleteInTx(tx *bbolt.Tx, documentID string) (int, error) {
+	// Get document first to find all embedding IDs
+	docBucket := vdb.bucket(tx, documentsBucket)
+	embBucket := vdb.bucket(tx, embeddingsBucket)
+
+	data := docBucket.Get([]byte(documentID))
+	if data == nil {
+		return 0, fmt.Errorf("%w: %s", ErrNotFound, documentID)
+	}
 
-		// Delete document
-		err = docBucket.Delete([]byte(documentID))
+	var embedding DocumentEmbedding
+	err := json.Unmarshal(data, &embedding)
+	if err != nil {
+		return 0, fmt.Errorf("failed to unmarshal document: %v", err)
+	}
+
+	// Delete individual embeddings
+	for _, emb := range embedding.Embeddings {
+		err = embBucket.Delete([]byte(emb.ID))
 		if err != nil {
-			return fmt.Errorf("failed to delete document: %v", err)
+			return 0, fmt.Errorf("failed to delete embedding %s: %v", emb.ID, err)
 		}
+	}
 
-		// Update stats
-		return vdb.updateStatsInTx(tx, -1, -len(embedding.Embeddings))
-	})
+	// Delete document
+	err = docBucket.Delete([]byte(documentID))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete document: %v", err)
+	}
+
+	return len(embedding.Embeddings), nil
 }
 
 // List returns all document IDs
@@ -221,7 +296,7 @@ func (vdb *BoltVectorDB) List() ([]string, error) {
 	var documentIDs []string
 
 	err := vdb.db.View(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket([]byte(documentsBucket))
+		bucket := vdb.bucket(tx, documentsBucket)
 		return bucket.ForEach(func(k, v []byte) error {
 			documentIDs = append(documentIDs, string(k))
 			return nil
@@ -231,67 +306,176 @@ func (vdb *BoltVectorDB) List() ([]string, error) {
 	return documentIDs, err
 }
 
-// Search performs vector similarity search
+// Search performs vector similarity search. Results are ordered by descending score, ties by
+// chunk ID; the first options.Offset of them are skipped.
 func (vdb *BoltVectorDB) Search(vector []float32, options *VectorSearchOptions) ([]VectorSearchResult, error) {
 	if options == nil {
 		options = DefaultVectorSearchOptions()
 	}
 
-	var results []VectorSearchResult
-	candidates := make([]candidateResult, 0)
+	// Apply limits
+	offset := max(options.Offset, 0)
+	candidates, err := vdb.rankCandidates(vector, options, nil, offset+options.TopK)
+	if err != nil {
+		return nil, err
+	}
+	candidates = candidates[min(offset, len(candidates)):]
 
-	err := vdb.db.View(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket([]byte(embeddingsBucket))
+	return vdb.toResults(candidates, options.IncludeContent), nil
+}
 
-		// Iterate through all embeddings and calculate similarity
-		return bucket.ForEach(func(k, v []byte) error {
-			var embData EmbeddingData
-			err := json.Unmarshal(v, &embData)
-			if err != nil {
-				return nil // Skip invalid entries
-			}
+// SearchPage returns the page of search results that follows cursor, with up to options.TopK
+// results. An empty cursor starts at the best match. The page's NextCursor continues the search
+// after its last result; it is empty once no results remain. options.Offset is ignored.
+//
+// Every page scores the stored embeddings again but keeps only the requested page in memory and
+// looks up chunk content for it alone, so later pages cost no more than the first. Results stored
+// after the first page was returned are included in later pages where they rank after the cursor.
+func (vdb *BoltVectorDB) SearchPage(
+	vector []float32,
+	options *VectorSearchOptions,
+	cursor string,
+) (*VectorSearchPage, error) {
+	if options == nil {
+		options = DefaultVectorSearchOptions()
+	}
 
-			// Apply filters
-			if !vdb.matchesFilters(&embData, options.FilterBy) {
-				return nil
-			}
+	var after *candidateResult
+	if cursor != "" {
+		position, err := decodeSearchCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		after = position
+	}
 
-			// Calculate similarity
-			score := vdb.calculator.Calculate(vector, embData.Vector)
-			if score < options.MinScore {
-				return nil
-			}
+	// One extra candidate tells whether another page follows
+	candidates, err := vdb.rankCandidates(vector, options, after, options.TopK+1)
+	if err != nil {
+		return nil, err
+	}
 
-			candidates = append(candidates, candidateResult{
-				DocumentID: embData.DocumentID,
-				ChunkID:    embData.ChunkID,
-				FilePath:   embData.FilePath,
-				Score:      score,
-				Metadata:   embData.Metadata,
-			})
+	page := &VectorSearchPage{}
+	if len(candidates) > options.TopK {
+		candidates = candidates[:options.TopK]
+		if len(candidates) > 0 {
+			page.NextCursor = encodeSearchCursor(candidates[len(candidates)-1])
+		}
+	}
+	page.Results = vdb.toResults(candidates, options.IncludeContent)
+
+	return page, nil
+}
+
+// minParallelSearch is the number of stored embeddings each search worker is given at least;
+// smaller databases are scored on the calling goroutine
+const minParallelSearch = 512
+
+// rankCandidates scores every stored embedding matching the options and returns the best limit
+// of them in rank order. When after is set, only candidates ranked after it are considered.
+//
+// The embeddings are split into contiguous ranges scored by up to searchWorkers goroutines, each
+// keeping its own best limit candidates; the ranges are then merged. Ties are ordered by chunk ID,
+// so the result does not depend on the number of workers.
+func (vdb *BoltVectorDB) rankCandidates(
+	vector []float32,
+	options *VectorSearchOptions,
+	after *candidateResult,
+	limit int,
+) ([]candidateResult, error) {
+	limit = max(limit, 0)
+	var candidates []candidateResult
+
+	err := vdb.db.View(func(tx *bbolt.Tx) error {
+		bucket := vdb.bucket(tx, embeddingsBucket)
 
+		// Values stay valid until the transaction ends, so the workers decode them in place
+		var values [][]byte
+		err := bucket.ForEach(func(_, v []byte) error {
+			values = append(values, v)
 			return nil
 		})
+		if err != nil {
+			return err
+		}
+
+		workers := max(min(vdb.searchWorkers, len(values)/minParallelSearch), 1)
+		if workers == 1 {
+			candidates = vdb.scoreCandidates(values, vector, options, after, limit)
+			return nil
+		}
+
+		ranked := make([][]candidateResult, workers)
+		size := (len(values) + workers - 1) / workers
+		var wg sync.WaitGroup
+		for i := range workers {
+			part := values[min(i*size, len(values)):min((i+1)*size, len(values))]
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ranked[i] = vdb.scoreCandidates(part, vector, options, after, limit)
+			}()
+		}
+		wg.Wait()
+
+		candidates = mergeCandidates(ranked, limit)
+		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Sort by score (descending)
-	sort.Slice(candidates, func(i, j int) bool {
-		return candidates[i].Score > candidates[j].Score
-	})
+	return candidates, nil
+}
 
-	// Apply limits
-	limit := options.TopK
-	if limit > len(candidates) {
-		limit = len(candidates)
+// scoreCandidates decodes and scores the stored embedding values and returns the best limit
+// candidates among them in rank order
+func (vdb *BoltVectorDB) scoreCandidates(
+	values [][]byte,
+	vector []float32,
+	options *VectorSearchOptions,
+	after *candidateResult,
+	limit int,
+) []candidateResult {
+	best := make(candidateHeap, 0, min(limit, len(values)))
+
+	for _, v := range values {
+		var embData EmbeddingData
+		if err := json.Unmarshal(v, &embData); err != nil {
+			continue // Skip invalid entries
+		}
+
+		// Apply filters
+		if !vdb.matchesFilters(&embData, options.FilterBy) {
+			continue
+		}
+
+		// Calculate similarity
+		score := vdb.calculator.Calculate(vector, embData.Vector)
+		if score < options.MinScore {
+			continue
+		}
+
+		candidate := candidateResult{
+			DocumentID: embData.DocumentID,
+			ChunkID:    embData.ChunkID,
+			FilePath:   embData.FilePath,
+			Score:      score,
+			Metadata:   embData.Metadata,
+		}
+		if after != nil && !rankedBefore(*after, candidate) {
+			continue
+		}
+		best.offer(candidate, limit)
 	}
 
-	// Convert to results
-	results = make([]VectorSearchResult, limit)
-	for i := 0; i < limit; i++ {
-		candidate := candidates[i]
+	return best.ranked()
+}
+
+// toResults converts ranked candidates to search results, looking up their content if requested
+func (vdb *BoltVectorDB) toResults(candidates []candidateResult, includeContent bool) []VectorSearchResult {
+	results := make([]VectorSearchResult, len(candidates))
+	for i, candidate := range candidates {
 		results[i] = VectorSearchResult{
 			DocumentID: candidate.DocumentID,
 			ChunkID:    candidate.ChunkID,
@@ -301,7 +485,7 @@ func (vdb *BoltVectorDB) Search(vector []float32, options *VectorSearchOptions)
 		}
 
 		// Add content if requested
-		if options.IncludeContent {
+		if includeContent {
 			// TODO: Optimize this by storing content in embedding data or using a single query
 			content, err := vdb.getChunkContent(candidate.DocumentID, candidate.ChunkID)
 			if err == nil {
@@ -313,14 +497,120 @@ func (vdb *BoltVectorDB) Search(vector []float32, options *VectorSearchOptions)
 		}
 	}
 
-	return results, nil
+	return results
+}
+
+// rankedBefore reports whether a ranks ahead of b: higher scores first, ties by chunk ID
+func rankedBefore(a, b candidateResult) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	return a.ChunkID < b.ChunkID
+}
+
+// rankedHeap is a min-heap of ranked candidate lists, ordered by the head of each list. It holds
+// one entry per list being merged.
+type rankedHeap [][]candidateResult
+
+func (h rankedHeap) Len() int           { return len(h) }
+func (h rankedHeap) Less(i, j int) bool { return rankedBefore(h[i][0], h[j][0]) }
+func (h rankedHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *rankedHeap) Push(x any)        { *h = append(*h, x.([]candidateResult)) }
+func (h *rankedHeap) Pop() any {
+	old := *h
+	list := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return list
+}
+
+// mergeCandidates merges lists that are each in rank order into the best n candidates overall
+func mergeCandidates(lists [][]candidateResult, n int) []candidateResult {
+	h := make(rankedHeap, 0, len(lists))
+	total := 0
+	for _, list := range lists {
+		if len(list) > 0 {
+			h = append(h, list)
+			total += len(list)
+		}
+	}
+	heap.Init(&h)
+
+	merged := make([]candidateResult, 0, min(n, total))
+	for len(merged) < n && h.Len() > 0 {
+		merged = append(merged, h[0][0])
+		if h[0] = h[0][1:]; len(h[0]) == 0 {
+			heap.Pop(&h)
+		} else {
+			heap.Fix(&h, 0)
+		}
+	}
+	return merged
+}
+
+// candidateHeap holds the best candidates seen so far, up to a fixed number. It is a min-heap
+// with the lowest ranked candidate at the root, which is the one a better candidate replaces.
+type candidateHeap []candidateResult
+
+func (h candidateHeap) Len() int           { return len(h) }
+func (h candidateHeap) Less(i, j int) bool { return rankedBefore(h[j], h[i]) }
+func (h candidateHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *candidateHeap) Push(x any)        { *h = append(*h, x.(candidateResult)) }
+func (h *candidateHeap) Pop() any {
+	old := *h
+	candidate := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return candidate
+}
+
+// offer adds candidate if the heap holds fewer than n candidates or candidate ranks ahead of the
+// lowest ranked one, which it then replaces
+func (h *candidateHeap) offer(candidate candidateResult, n int) {
+	switch {
+	case h.Len() < n:
+		heap.Push(h, candidate)
+	case n > 0 && rankedBefore(candidate, (*h)[0]):
+		(*h)[0] = candidate
+		heap.Fix(h, 0)
+	}
+}
+
+// ranked empties the heap and returns its candidates in rank order
+func (h *candidateHeap) ranked() []candidateResult {
+	ranked := make([]candidateResult, h.Len())
+	for i := len(ranked) - 1; i >= 0; i-- {
+		ranked[i] = heap.Pop(h).(candidateResult)
+	}
+	return ranked
+}
+
+// encodeSearchCursor returns an opaque cursor for the position after candidate
+func encodeSearchCursor(candidate candidateResult) string {
+	position := strconv.FormatFloat(float64(candidate.Score), 'g', -1, 32) + "|" + candidate.ChunkID
+	return base64.RawURLEncoding.EncodeToString([]byte(position))
+}
+
+// decodeSearchCursor returns the position a cursor from encodeSearchCursor points after
+func decodeSearchCursor(cursor string) (*candidateResult, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid search cursor: %w", err)
+	}
+	scoreText, chunkID, ok := strings.Cut(string(data), "|")
+	if !ok {
+		return nil, fmt.Errorf("invalid search cursor")
+	}
+	score, err := strconv.ParseFloat(scoreText, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid search cursor: %w", err)
+	}
+	return &candidateResult{ChunkID: chunkID, Score: float32(score)}, nil
 }
 
 // Optimize optimizes the database (compact, rebuild indexes, etc.)
 func (vdb *BoltVectorDB) Optimize() error {
 	// BoltDB doesn't require explicit optimization, but we can update stats
-	return vdb.db.Update(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket([]byte(statsBucket))
+	return vdb.update(func(tx *bbolt.Tx) error {
+		bucket := vdb.bucket(tx, statsBucket)
 		stats := &DatabaseStats{}
 
 		data := bucket.Get([]byte("stats"))
@@ -344,7 +634,7 @@ func (vdb *BoltVectorDB) GetStats() (*DatabaseStats, error) {
 	var stats *DatabaseStats
 
 	err := vdb.db.View(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket([]byte(statsBucket))
+		bucket := vdb.bucket(tx, statsBucket)
 		data := bucket.Get([]byte("stats"))
 		if data == nil {
 			stats = &DatabaseStats{}
@@ -367,7 +657,7 @@ func (vdb *BoltVectorDB) getChunkContent(documentID, chunkID string) (string, er
 	var content string
 
 	err := vdb.db.View(func(tx *bbolt.Tx) error {
-		embBucket := tx.Bucket([]byte(embeddingsBucket))
+		embBucket := vdb.bucket(tx, embeddingsBucket)
 		embData := embBucket.Get([]byte(chunkID))
 		if embData == nil {
 			return fmt.Errorf("chunk not found: %s", chunkID)
@@ -415,7 +705,7 @@ type candidateResult struct {
 // initializeStats initializes database statistics
 func (vdb *BoltVectorDB) initializeStats() error {
 	return vdb.db.Update(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket([]byte(statsBucket))
+		bucket := vdb.bucket(tx, statsBucket)
 		data := bucket.Get([]byte("stats"))
 		if data != nil {
 			return nil // Stats already exist
@@ -443,7 +733,7 @@ func (vdb *BoltVectorDB) initializeStats() error {
 
 // updateStatsInTx updates statistics within a transaction
 func (vdb *BoltVectorDB) updateStatsInTx(tx *bbolt.Tx, docDelta, embDelta int) error {
-	bucket := tx.Bucket([]byte(statsBucket))
+	bucket := vdb.bucket(tx, statsBucket)
 
 	var stats DatabaseStats
 	data := bucket.Get([]byte("stats"))
@@ -0,0 +1,108 @@
+package generator
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kuderr/deepwiki/pkg/scanner"
+)
+
+// SourceLocation points at a line in a project file
+type SourceLocation struct {
+	FilePath string `json:"filePath"`
+	Line     int    `json:"line"`
+}
+
+// addAnalysisPages runs the enabled static analysis passes and adds their pages to the wiki.
+// These pages are built directly from the source tree; only the optional CI/CD summary uses the LLM.
+func (g *WikiGenerator) addAnalysisPages(
+	ctx context.Context,
+	files []scanner.FileInfo,
+	structure *WikiStructure,
+	result *GenerationResult,
+	options GenerationOptions,
+) {
+	var pages []*WikiPage
+
+	if options.IncludeEnvVars {
+		if page := BuildEnvVarsPage(ExtractEnvVars(files), options.Language); page != nil {
+			pages = append(pages, page)
+		}
+	}
+
+	if page := BuildMakefileTasksPage(ExtractMakefileTargets(files), options.Language); page != nil {
+		pages = append(pages, page)
+	}
+
+	if page := BuildServicesPage(options.ComposeProjects, options.Language); page != nil {
+		pages = append(pages, page)
+	}
+
+	if options.IncludeTodos {
+		if page := BuildTodosPage(ExtractTodos(files), options.Language); page != nil {
+			pages = append(pages, page)
+		}
+	}
+
+	if options.IncludeCI {
+		if page := BuildCIPage(ExtractCIWorkflows(files), options.Language); page != nil {
+			if options.SummarizeCI {
+				g.summarizeCIPage(ctx, page, options)
+			}
+			pages = append(pages, page)
+		}
+	}
+
+	if options.IncludeLicense {
+		if page := BuildLicensePage(ExtractLicenseReport(options.ProjectPath, files), options.Language); page != nil {
+			pages = append(pages, page)
+		}
+	}
+
+	for _, page := range pages {
+		addGeneratedPage(structure, result, page)
+		g.logger.Info("Added analysis page", "page", page.ID)
+	}
+
+	if options.IncludeClassDiagram {
+		g.addClassDiagram(files, structure, result)
+	}
+}
+
+// addGeneratedPage appends page to the structure and registers it in the result
+func addGeneratedPage(structure *WikiStructure, result *GenerationResult, page *WikiPage) {
+	if page.CreatedAt.IsZero() {
+		page.CreatedAt = time.Now()
+	}
+	page.WordCount = len(strings.Fields(page.Content))
+	page.SourceFiles = len(page.FilePaths)
+
+	structure.Pages = append(structure.Pages, *page)
+
+	// Appending may move the backing array, so refresh every page pointer held by the result
+	for i := range structure.Pages {
+		id := structure.Pages[i].ID
+		if _, ok := result.Pages[id]; ok || id == page.ID {
+			result.Pages[id] = &structure.Pages[i]
+		}
+	}
+
+	result.TotalPages = len(result.Pages)
+	result.TotalWords += page.WordCount
+}
+
+// readSourceFile reads a scanned file, preferring its absolute path, without its byte order mark
+func readSourceFile(file scanner.FileInfo) (string, error) {
+	path := file.AbsolutePath
+	if path == "" {
+		path = file.Path
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(string(content), "\ufeff"), nil
+}
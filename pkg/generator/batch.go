@@ -0,0 +1,181 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/kuderr/deepwiki/pkg/generator/prompts"
+	"github.com/kuderr/deepwiki/pkg/llm"
+	"github.com/kuderr/deepwiki/pkg/rag"
+	"github.com/kuderr/deepwiki/pkg/tracing"
+)
+
+// batchImportance is the importance of the pages generated in batches, the short ones
+const batchImportance = "low"
+
+// pageBatchStart matches the start of the JSON array of a page batch answer
+var pageBatchStart = regexp.MustCompile(`\[\s*\{`)
+
+// generatePageBatches writes the low importance pages of structure that are still to be
+// generated, options.BatchPages per request, and returns the IDs of the pages written. Pages of
+// a batch that fails are left to be generated one at a time.
+func (g *WikiGenerator) generatePageBatches(
+	ctx context.Context,
+	fileTree string,
+	structure *WikiStructure,
+	options GenerationOptions,
+) map[string]bool {
+	if options.BatchPages < 2 {
+		return nil
+	}
+
+	var pending []*WikiPage
+	for i := range structure.Pages {
+		page := &structure.Pages[i]
+		if page.SourceDoc != "" || page.Importance != batchImportance {
+			continue
+		}
+		if options.Checkpoint != nil {
+			if saved, ok := options.Checkpoint.Page(page.ID); ok && strings.TrimSpace(saved.Content) != "" {
+				continue
+			}
+		}
+		pending = append(pending, page)
+	}
+
+	written := make(map[string]bool)
+	for batch := range slices.Chunk(pending, options.BatchPages) {
+		if len(batch) < 2 {
+			continue
+		}
+		for _, page := range g.generatePageBatch(ctx, fileTree, batch, structure, options) {
+			written[page.ID] = true
+			g.checkpointPage(page, options)
+		}
+	}
+	return written
+}
+
+// generatePageBatch writes pages with one request asking for a JSON array of pages, and returns
+// the pages it wrote. Pages missing from the answer or with unusable content are left unwritten,
+// as are all pages when the prompt does not fit the context window or the answer is not JSON.
+func (g *WikiGenerator) generatePageBatch(
+	ctx context.Context,
+	fileTree string,
+	pages []*WikiPage,
+	structure *WikiStructure,
+	options GenerationOptions,
+) (written []*WikiPage) {
+	ctx, span := tracing.Start(ctx, "generator.page_batch", tracing.Int("pages", len(pages)))
+	defer func() {
+		span.SetAttributes(tracing.Int("written", len(written)))
+		span.End()
+	}()
+
+	ids := make([]string, len(pages))
+	batchPages := make([]prompts.BatchPage, len(pages))
+	relevantDocs := make(map[string][]rag.RetrievalResult, len(pages))
+	for i, page := range pages {
+		docs, err := g.retrievePageDocuments(page, options)
+		if err != nil {
+			g.logger.Warn("Failed to generate page batch", "error", err)
+			return nil
+		}
+		ids[i] = page.ID
+		relevantDocs[page.ID] = docs
+		batchPages[i] = prompts.BatchPage{
+			ID:            page.ID,
+			Title:         page.Title,
+			Description:   page.Description,
+			RelevantFiles: g.formatRelevantFiles(docs),
+		}
+	}
+
+	prompt, err := prompts.ExecutePageBatchPrompt(prompts.PageBatchData{
+		ProjectName: options.ProjectName,
+		Language:    options.Language,
+		FileTree:    fileTree,
+		Pages:       batchPages,
+		OtherPages:  otherPageSummaries(structure, ids...),
+	})
+	if err != nil {
+		g.logger.Warn("Failed to build page batch prompt", "error", err)
+		return nil
+	}
+
+	// The answer holds every page of the batch
+	completionOptions := options.PagePhase.completionOptions()
+	completionOptions.MaxTokens = min(completionOptions.MaxTokens*len(pages), maxCompletionTokens)
+	messages := []llm.Message{{Role: "user", Content: prompt}}
+	promptTokens, err := g.checkRequestFits(messages, completionOptions,
+		g.promptBudget(options, completionOptions.MaxTokens))
+	if err != nil {
+		g.logger.Info("Page batch does not fit the context window, generating its pages one at a time",
+			"pages", ids, "error", err)
+		return nil
+	}
+
+	g.logger.Info("Generating pages in one request", "pages", ids)
+	answer, err := g.completeCheckedChat(ctx, messages, completionOptions,
+		g.responseLimit(options, promptTokens), nil)
+	if err != nil {
+		g.logger.Warn("Failed to generate page batch, generating its pages one at a time", "error", err)
+		return nil
+	}
+
+	contents, err := parsePageBatch(g.contentPostProcessor.cleanThinkBlocks(answer))
+	if err != nil {
+		g.logger.Warn("Failed to parse page batch, generating its pages one at a time", "error", err)
+		return nil
+	}
+
+	for _, page := range pages {
+		content := g.contentPostProcessor.CleanMarkdown(contents[page.ID])
+		if problem := pageContentProblem(content); problem != "" {
+			g.logger.Warn("Page batch has no usable content for page, generating it alone",
+				"page", page.ID, "problem", problem)
+			continue
+		}
+
+		g.setPageContent(ctx, page, content, relevantDocs[page.ID], options)
+		written = append(written, page)
+		g.logger.Info("Page content generated successfully",
+			"page", page.ID,
+			"words", page.WordCount,
+			"sources", page.SourceFiles,
+			"batch", len(pages),
+		)
+	}
+	return written
+}
+
+// parsePageBatch returns the content of each page of a page batch answer by page ID. The JSON
+// array may be wrapped in a code block or surrounded by text.
+func parsePageBatch(answer string) (map[string]string, error) {
+	start := pageBatchStart.FindStringIndex(answer)
+	end := strings.LastIndex(answer, "]")
+	if start == nil || end < start[0] {
+		return nil, errors.New("answer has no JSON array of pages")
+	}
+
+	var pages []struct {
+		ID      string `json:"id"`
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal([]byte(answer[start[0]:end+1]), &pages); err != nil {
+		return nil, fmt.Errorf("failed to decode pages: %w", err)
+	}
+
+	contents := make(map[string]string, len(pages))
+	for _, page := range pages {
+		if _, seen := contents[page.ID]; !seen {
+			contents[page.ID] = page.Content
+		}
+	}
+	return contents, nil
+}
@@ -0,0 +1,105 @@
+package generator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kuderr/deepwiki/pkg/types"
+)
+
+const batchStructureResponse = `<wiki_structure><title>demo</title><pages>
+  <page><id>overview</id><title>Overview</title><importance>high</importance></page>
+  <page><id>license</id><title>License</title><importance>low</importance></page>
+  <page><id>changelog</id><title>Changelog</title><importance>low</importance></page>
+</pages></wiki_structure>`
+
+func TestGenerateWiki_BatchesLowImportancePages(t *testing.T) {
+	generator, provider := newScriptedGenerator(
+		choice(batchStructureResponse, "stop"),
+		choice("Here are the pages:\n```json\n"+`[
+  {"id": "license", "content": "# License\n\nThe project is MIT licensed."},
+  {"id": "changelog", "content": "# Changelog\n\nReleases are listed in [CHANGELOG.md]."}
+]`+"\n```", "stop"),
+		choice("# Overview\n\nThe project overview.", "stop"),
+	)
+
+	options := GenerationOptions{ProjectName: "demo", Language: types.LanguageEnglish, BatchPages: 4}
+	result, err := generator.GenerateWiki(context.Background(), nil, options)
+	if err != nil {
+		t.Fatalf("GenerateWiki failed: %v", err)
+	}
+
+	want := map[string]string{
+		"overview":  "# Overview\n\nThe project overview.",
+		"license":   "# License\n\nThe project is MIT licensed.",
+		"changelog": "# Changelog\n\nReleases are listed in [CHANGELOG.md].",
+	}
+	for id, content := range want {
+		page, ok := result.Pages[id]
+		if !ok {
+			t.Errorf("Expected page %s", id)
+			continue
+		}
+		if page.Content != content {
+			t.Errorf("Expected page %s content %q, got %q", id, content, page.Content)
+		}
+		if page.WordCount == 0 {
+			t.Errorf("Expected a word count for page %s", id)
+		}
+	}
+	if result.TotalPages != 3 {
+		t.Errorf("Expected 3 pages, got %d", result.TotalPages)
+	}
+
+	// The structure, the batch of low importance pages, and the overview
+	if len(provider.maxTokens) != 3 {
+		t.Fatalf("Expected 3 LLM calls, got %d", len(provider.maxTokens))
+	}
+	if provider.maxTokens[1] != 2*defaultCompletionTokens {
+		t.Errorf("Expected the batch to get the token budget of both pages, got %d", provider.maxTokens[1])
+	}
+}
+
+func TestGenerateWiki_BatchFallsBackToSinglePages(t *testing.T) {
+	generator, provider := newScriptedGenerator(
+		choice(batchStructureResponse, "stop"),
+		choice("# License\n\nThe project is MIT licensed.", "stop"), // Not a JSON array
+		choice("# Overview\n\nThe project overview.", "stop"),
+		choice("# License\n\nThe project is MIT licensed.", "stop"),
+		choice("# Changelog\n\nReleases are listed in CHANGELOG.md.", "stop"),
+	)
+
+	options := GenerationOptions{ProjectName: "demo", Language: types.LanguageEnglish, BatchPages: 4}
+	result, err := generator.GenerateWiki(context.Background(), nil, options)
+	if err != nil {
+		t.Fatalf("GenerateWiki failed: %v", err)
+	}
+
+	if len(provider.maxTokens) != 5 {
+		t.Fatalf("Expected the batch pages to be generated one at a time, got %d LLM calls", len(provider.maxTokens))
+	}
+	changelog := "# Changelog\n\nReleases are listed in CHANGELOG.md."
+	if page := result.Pages["changelog"]; page == nil || page.Content != changelog {
+		t.Errorf("Expected the changelog from its own request, got %+v", page)
+	}
+	if result.TotalPages != 3 || len(result.Errors) != 0 {
+		t.Errorf("Expected 3 pages without errors, got %d pages and errors %v", result.TotalPages, result.Errors)
+	}
+}
+
+func TestParsePageBatch(t *testing.T) {
+	contents, err := parsePageBatch(`[{"id": "a", "content": "First"}, {"id": "a", "content": "Repeated"},` +
+		` {"id": "b", "content": "Links [x](./x.md)"}]`)
+	if err != nil {
+		t.Fatalf("parsePageBatch failed: %v", err)
+	}
+	if len(contents) != 2 || contents["a"] != "First" || contents["b"] != "Links [x](./x.md)" {
+		t.Errorf("Expected the first content of each page, got %v", contents)
+	}
+
+	for _, answer := range []string{"", "No pages [here]", `[{"id": "a", "content": }]`} {
+		if _, err := parsePageBatch(answer); err == nil {
+			t.Errorf("Expected an error for %q", answer)
+		}
+	}
+}
@@ -0,0 +1,395 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kuderr/deepwiki/pkg/generator/prompts"
+	"github.com/kuderr/deepwiki/pkg/llm"
+	"github.com/kuderr/deepwiki/pkg/scanner"
+	"github.com/kuderr/deepwiki/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// CIPageID is the ID of the generated CI/CD page
+const CIPageID = "ci-cd"
+
+// ciSummaryTokens is the token budget of the optional CI/CD summary
+const ciSummaryTokens = 1024
+
+// CI providers
+const (
+	CIProviderGitHubActions = "GitHub Actions"
+	CIProviderGitLab        = "GitLab CI"
+)
+
+// CIWorkflow is a CI pipeline definition such as a GitHub Actions workflow or .gitlab-ci.yml
+type CIWorkflow struct {
+	FilePath string   `json:"filePath"`
+	Provider string   `json:"provider"`
+	Name     string   `json:"name"`
+	Triggers []string `json:"triggers"`
+	Jobs     []CIJob  `json:"jobs"`
+}
+
+// CIJob is a job of a CI workflow
+type CIJob struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name,omitempty"`
+	RunsOn string   `json:"runsOn,omitempty"` // GitHub runner or GitLab image
+	Stage  string   `json:"stage,omitempty"`  // GitLab stage
+	Needs  []string `json:"needs,omitempty"`
+	Steps  []string `json:"steps"`
+}
+
+// gitlabReservedKeys are top-level .gitlab-ci.yml keys that are not jobs
+var gitlabReservedKeys = map[string]bool{
+	"stages": true, "variables": true, "default": true, "include": true, "workflow": true,
+	"image": true, "services": true, "before_script": true, "after_script": true, "cache": true,
+}
+
+// ciProvider returns the CI provider of a config file, or "" if it is not a CI definition
+func ciProvider(file scanner.FileInfo) string {
+	if file.Category != string(scanner.CategoryConfig) {
+		return ""
+	}
+
+	filePath := filepath.ToSlash(file.Path)
+	ext := path.Ext(filePath)
+	switch {
+	case path.Dir(filePath) == ".github/workflows" && (ext == ".yml" || ext == ".yaml"):
+		return CIProviderGitHubActions
+	case file.Name == ".gitlab-ci.yml" || file.Name == ".gitlab-ci.yaml":
+		return CIProviderGitLab
+	}
+	return ""
+}
+
+// ExtractCIWorkflows parses the CI definitions among the scanned config files, sorted by path
+func ExtractCIWorkflows(files []scanner.FileInfo) []CIWorkflow {
+	var workflows []CIWorkflow
+
+	for _, file := range files {
+		provider := ciProvider(file)
+		if provider == "" {
+			continue
+		}
+
+		content, err := readSourceFile(file)
+		if err != nil {
+			continue
+		}
+
+		var workflow *CIWorkflow
+		if provider == CIProviderGitHubActions {
+			workflow, err = ParseGitHubWorkflow(content)
+		} else {
+			workflow, err = ParseGitLabCI(content)
+		}
+		if err != nil {
+			continue
+		}
+
+		workflow.FilePath = filepath.ToSlash(file.Path)
+		if workflow.Name == "" {
+			workflow.Name = strings.TrimSuffix(filepath.Base(file.Path), filepath.Ext(file.Path))
+		}
+		workflows = append(workflows, *workflow)
+	}
+
+	sort.Slice(workflows, func(i, j int) bool { return workflows[i].FilePath < workflows[j].FilePath })
+	return workflows
+}
+
+// ParseGitHubWorkflow parses a GitHub Actions workflow file
+func ParseGitHubWorkflow(content string) (*CIWorkflow, error) {
+	root, err := parseYAMLMapping(content)
+	if err != nil {
+		return nil, err
+	}
+
+	workflow := &CIWorkflow{
+		Provider: CIProviderGitHubActions,
+		Name:     scalarValue(mappingValue(root, "name")),
+		Triggers: githubTriggers(mappingValue(root, "on")),
+	}
+
+	jobs := mappingValue(root, "jobs")
+	for id, node := range mappingPairs(jobs) {
+		job := CIJob{
+			ID:     id,
+			Name:   scalarValue(mappingValue(node, "name")),
+			RunsOn: strings.Join(scalarValues(mappingValue(node, "runs-on")), ", "),
+			Needs:  scalarValues(mappingValue(node, "needs")),
+		}
+		if reusable := scalarValue(mappingValue(node, "uses")); reusable != "" {
+			job.Steps = append(job.Steps, fmt.Sprintf("Calls `%s`", reusable))
+		}
+		for _, step := range sequenceItems(mappingValue(node, "steps")) {
+			job.Steps = append(job.Steps, githubStepSummary(step))
+		}
+		workflow.Jobs = append(workflow.Jobs, job)
+	}
+
+	return workflow, nil
+}
+
+// githubTriggers lists the events of an "on" block, with branch, path and schedule filters
+func githubTriggers(on *yaml.Node) []string {
+	if on == nil {
+		return nil
+	}
+	if on.Kind != yaml.MappingNode {
+		return scalarValues(on)
+	}
+
+	var triggers []string
+	for event, config := range mappingPairs(on) {
+		var filters []string
+		for _, key := range []string{"branches", "tags", "paths", "types"} {
+			if values := scalarValues(mappingValue(config, key)); len(values) > 0 {
+				filters = append(filters, fmt.Sprintf("%s: %s", key, strings.Join(values, ", ")))
+			}
+		}
+		for _, schedule := range sequenceItems(config) {
+			if cron := scalarValue(mappingValue(schedule, "cron")); cron != "" {
+				filters = append(filters, fmt.Sprintf("cron: %s", cron))
+			}
+		}
+
+		if len(filters) > 0 {
+			event += " (" + strings.Join(filters, "; ") + ")"
+		}
+		triggers = append(triggers, event)
+	}
+	return triggers
+}
+
+// githubStepSummary describes a workflow step by its name, action or command
+func githubStepSummary(step *yaml.Node) string {
+	name := scalarValue(mappingValue(step, "name"))
+	action := scalarValue(mappingValue(step, "uses"))
+	command := firstLine(scalarValue(mappingValue(step, "run")))
+
+	detail := ""
+	switch {
+	case action != "":
+		detail = fmt.Sprintf("`%s`", action)
+	case command != "":
+		detail = fmt.Sprintf("`%s`", command)
+	}
+
+	switch {
+	case name != "" && detail != "":
+		return name + ": " + detail
+	case name != "":
+		return name
+	default:
+		return detail
+	}
+}
+
+// ParseGitLabCI parses a .gitlab-ci.yml file. Hidden jobs (starting with ".") are templates and skipped.
+func ParseGitLabCI(content string) (*CIWorkflow, error) {
+	root, err := parseYAMLMapping(content)
+	if err != nil {
+		return nil, err
+	}
+
+	workflow := &CIWorkflow{Provider: CIProviderGitLab}
+	for _, rule := range sequenceItems(mappingValue(mappingValue(root, "workflow"), "rules")) {
+		if condition := scalarValue(mappingValue(rule, "if")); condition != "" {
+			workflow.Triggers = append(workflow.Triggers, condition)
+		}
+	}
+
+	for id, node := range mappingPairs(root) {
+		if gitlabReservedKeys[id] || strings.HasPrefix(id, ".") || node.Kind != yaml.MappingNode {
+			continue
+		}
+
+		job := CIJob{
+			ID:     id,
+			RunsOn: scalarValue(mappingValue(node, "image")),
+			Stage:  scalarValue(mappingValue(node, "stage")),
+			Needs:  scalarValues(mappingValue(node, "needs")),
+		}
+		if job.Stage == "" {
+			job.Stage = "test" // GitLab's default stage
+		}
+		for _, command := range scalarValues(mappingValue(node, "script")) {
+			job.Steps = append(job.Steps, fmt.Sprintf("`%s`", firstLine(command)))
+		}
+		workflow.Jobs = append(workflow.Jobs, job)
+	}
+
+	return workflow, nil
+}
+
+// BuildCIPage renders the CI/CD page in language, or returns nil if there are no workflows
+func BuildCIPage(workflows []CIWorkflow, language types.Language) *WikiPage {
+	if len(workflows) == 0 {
+		return nil
+	}
+
+	labels := labelsFor(language)
+	var content strings.Builder
+	fmt.Fprintf(&content, "# %s\n\n%s\n", labels.CI, labels.CIIntro)
+
+	filePaths := make([]string, 0, len(workflows))
+	for _, workflow := range workflows {
+		filePaths = append(filePaths, workflow.FilePath)
+
+		content.WriteString(fmt.Sprintf("\n## %s (`%s`)\n\n", workflow.Name, workflow.FilePath))
+		content.WriteString(fmt.Sprintf("- **%s:** %s\n", labels.Provider, workflow.Provider))
+		if len(workflow.Triggers) > 0 {
+			content.WriteString(fmt.Sprintf("- **%s:** %s\n", labels.Triggers, strings.Join(workflow.Triggers, ", ")))
+		}
+
+		for _, job := range workflow.Jobs {
+			title := fmt.Sprintf("`%s`", job.ID)
+			if job.Name != "" && job.Name != job.ID {
+				title += " - " + job.Name
+			}
+			content.WriteString("\n### " + fmt.Sprintf(labels.Job, title) + "\n\n")
+
+			if job.Stage != "" {
+				content.WriteString(fmt.Sprintf("- **%s:** %s\n", labels.Stage, job.Stage))
+			}
+			if job.RunsOn != "" {
+				content.WriteString(fmt.Sprintf("- **%s:** %s\n", labels.RunsOn, job.RunsOn))
+			}
+			if len(job.Needs) > 0 {
+				content.WriteString(fmt.Sprintf("- **%s:** %s\n", labels.Needs, strings.Join(job.Needs, ", ")))
+			}
+			if len(job.Steps) > 0 {
+				content.WriteString(fmt.Sprintf("- **%s:**\n", labels.Steps))
+				for i, step := range job.Steps {
+					content.WriteString(fmt.Sprintf("  %d. %s\n", i+1, step))
+				}
+			}
+		}
+	}
+
+	return &WikiPage{
+		ID:          CIPageID,
+		Title:       labels.CI,
+		Description: labels.CIDescription,
+		Content:     content.String(),
+		FilePaths:   filePaths,
+		Importance:  "medium",
+	}
+}
+
+// summarizeCIPage asks the LLM for a short overview and places it above the pipeline details.
+// The page is kept as-is if the request fails.
+func (g *WikiGenerator) summarizeCIPage(ctx context.Context, page *WikiPage, options GenerationOptions) {
+	prompt, err := prompts.ExecuteCISummaryPrompt(prompts.CISummaryData{
+		ProjectName: options.ProjectName,
+		Language:    options.Language,
+		Pipelines:   page.Content,
+	})
+	if err != nil {
+		g.logger.Warn("Failed to build CI summary prompt", "error", err)
+		return
+	}
+
+	summary, err := g.completeChat(ctx, []llm.Message{{Role: "user", Content: prompt}}, llm.ChatCompletionOptions{
+		MaxTokens:   ciSummaryTokens,
+		Temperature: 0.1,
+	})
+	if err != nil {
+		g.logger.Warn("Failed to summarize CI/CD pipelines", "error", err)
+		return
+	}
+
+	title, details, _ := strings.Cut(page.Content, "\n\n")
+	page.Content = title + "\n\n" + strings.TrimSpace(summary) + "\n\n" + details
+}
+
+// parseYAMLMapping parses a YAML document whose root is a mapping
+func parseYAMLMapping(content string) (*yaml.Node, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &document); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("expected a YAML mapping")
+	}
+	return document.Content[0], nil
+}
+
+// mappingValue returns the value of key in a mapping node, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// mappingPairs iterates over the keys and values of a mapping node in document order
+func mappingPairs(node *yaml.Node) func(yield func(string, *yaml.Node) bool) {
+	return func(yield func(string, *yaml.Node) bool) {
+		if node == nil || node.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if !yield(node.Content[i].Value, node.Content[i+1]) {
+				return
+			}
+		}
+	}
+}
+
+// sequenceItems returns the items of a sequence node
+func sequenceItems(node *yaml.Node) []*yaml.Node {
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return nil
+	}
+	return node.Content
+}
+
+// scalarValue returns the value of a scalar node, or ""
+func scalarValue(node *yaml.Node) string {
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return node.Value
+}
+
+// scalarValues returns a scalar as a single value, or the scalar items of a sequence
+func scalarValues(node *yaml.Node) []string {
+	if node == nil {
+		return nil
+	}
+	if node.Kind == yaml.ScalarNode {
+		return []string{node.Value}
+	}
+
+	var values []string
+	for _, item := range sequenceItems(node) {
+		if value := scalarValue(item); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// firstLine returns the first non-blank line of a multi-line command
+func firstLine(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
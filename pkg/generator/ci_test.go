@@ -0,0 +1,184 @@
+package generator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kuderr/deepwiki/pkg/scanner"
+	"github.com/kuderr/deepwiki/pkg/types"
+)
+
+const githubWorkflow = `name: CI
+on:
+  push:
+    branches: [main]
+  pull_request:
+  schedule:
+    - cron: "0 3 * * 1"
+
+jobs:
+  lint:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - run: make lint
+  test:
+    name: Unit tests
+    runs-on: ubuntu-latest
+    needs: lint
+    steps:
+      - uses: actions/checkout@v4
+      - name: Run tests
+        run: |
+          go test ./...
+          go vet ./...
+`
+
+const gitlabPipeline = `stages: [build, test]
+
+workflow:
+  rules:
+    - if: $CI_COMMIT_BRANCH == "main"
+
+.cache-template:
+  cache:
+    paths: [.cache]
+
+build:
+  stage: build
+  image: golang:1.24
+  script:
+    - go build ./...
+
+test:
+  needs: [build]
+  script:
+    - go test ./...
+`
+
+// scanCIProject scans a project holding the given files so CI detection goes through the scanner
+func scanCIProject(t *testing.T, files map[string]string) []scanner.FileInfo {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	result, err := scanner.NewScanner(scanner.DefaultScanOptions()).ScanDirectory(dir)
+	if err != nil {
+		t.Fatalf("ScanDirectory failed: %v", err)
+	}
+	return result.Files
+}
+
+func TestExtractCIWorkflows_GitHubActions(t *testing.T) {
+	files := scanCIProject(t, map[string]string{
+		".github/workflows/ci.yml": githubWorkflow,
+		"config.yaml":              "jobs:\n  build:\n    steps: []\n",
+	})
+
+	workflows := ExtractCIWorkflows(files)
+	if len(workflows) != 1 {
+		t.Fatalf("Expected 1 workflow, got %+v", workflows)
+	}
+
+	workflow := workflows[0]
+	if workflow.Name != "CI" || workflow.Provider != CIProviderGitHubActions {
+		t.Errorf("Expected the GitHub Actions CI workflow, got %q from %q", workflow.Name, workflow.Provider)
+	}
+	expectedTriggers := []string{"push (branches: main)", "pull_request", "schedule (cron: 0 3 * * 1)"}
+	if strings.Join(workflow.Triggers, "|") != strings.Join(expectedTriggers, "|") {
+		t.Errorf("Expected triggers %v, got %v", expectedTriggers, workflow.Triggers)
+	}
+
+	if len(workflow.Jobs) != 2 {
+		t.Fatalf("Expected lint and test jobs, got %+v", workflow.Jobs)
+	}
+	test := workflow.Jobs[1]
+	if test.ID != "test" || test.Name != "Unit tests" || test.RunsOn != "ubuntu-latest" {
+		t.Errorf("Unexpected test job %+v", test)
+	}
+	if len(test.Needs) != 1 || test.Needs[0] != "lint" {
+		t.Errorf("Expected the test job to need lint, got %v", test.Needs)
+	}
+	if len(test.Steps) != 2 || test.Steps[1] != "Run tests: `go test ./...`" {
+		t.Errorf("Unexpected test steps %v", test.Steps)
+	}
+
+	page := BuildCIPage(workflows, types.LanguageEnglish)
+	for _, expected := range []string{
+		"## CI (`.github/workflows/ci.yml`)",
+		"- **Triggers:** push (branches: main), pull_request, schedule (cron: 0 3 * * 1)",
+		"### Job `lint`",
+		"### Job `test` - Unit tests",
+		"  2. `make lint`",
+	} {
+		if !strings.Contains(page.Content, expected) {
+			t.Errorf("Expected CI page to contain %q, got:\n%s", expected, page.Content)
+		}
+	}
+}
+
+func TestParseGitLabCI(t *testing.T) {
+	workflow, err := ParseGitLabCI(gitlabPipeline)
+	if err != nil {
+		t.Fatalf("ParseGitLabCI failed: %v", err)
+	}
+
+	if len(workflow.Triggers) != 1 || workflow.Triggers[0] != `$CI_COMMIT_BRANCH == "main"` {
+		t.Errorf("Expected the workflow rule as trigger, got %v", workflow.Triggers)
+	}
+	if len(workflow.Jobs) != 2 {
+		t.Fatalf("Expected build and test jobs without templates, got %+v", workflow.Jobs)
+	}
+	build, test := workflow.Jobs[0], workflow.Jobs[1]
+	if build.Stage != "build" || build.RunsOn != "golang:1.24" || build.Steps[0] != "`go build ./...`" {
+		t.Errorf("Unexpected build job %+v", build)
+	}
+	if test.Stage != "test" || len(test.Needs) != 1 || test.Needs[0] != "build" {
+		t.Errorf("Unexpected test job %+v", test)
+	}
+}
+
+func TestGenerateWiki_SummarizesCIPage(t *testing.T) {
+	files := scanCIProject(t, map[string]string{".github/workflows/ci.yml": githubWorkflow})
+	generator, provider := newScriptedGenerator(
+		choice(`<wiki_structure><title>demo</title><pages>
+  <page><id>overview</id><title>Overview</title><importance>high</importance></page>
+</pages></wiki_structure>`, "stop"),
+		choice("# Overview\n\nThe project overview.", "stop"),
+		choice("Every push to main is linted and tested.", "stop"),
+	)
+
+	options := GenerationOptions{
+		ProjectName: "demo",
+		Language:    types.LanguageEnglish,
+		IncludeCI:   true,
+		SummarizeCI: true,
+	}
+	result, err := generator.GenerateWiki(context.Background(), files, options)
+	if err != nil {
+		t.Fatalf("GenerateWiki failed: %v", err)
+	}
+
+	page, ok := result.Pages[CIPageID]
+	if !ok {
+		t.Fatal("Expected a CI/CD page")
+	}
+	if !strings.HasPrefix(page.Content, "# CI/CD\n\nEvery push to main is linted and tested.\n\n") {
+		t.Errorf("Expected the summary below the title, got:\n%s", page.Content)
+	}
+	if len(provider.maxTokens) != 3 || provider.maxTokens[2] != ciSummaryTokens {
+		t.Errorf("Expected one summary request, got token budgets %v", provider.maxTokens)
+	}
+}
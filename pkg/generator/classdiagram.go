@@ -0,0 +1,487 @@
+package generator
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/kuderr/deepwiki/pkg/scanner"
+)
+
+// ClassDiagramPageID is the ID of the generated type diagram page, used when the wiki
+// has no architecture or API page to hold the diagram
+const ClassDiagramPageID = "type-diagram"
+
+// maxClassDiagramNodes keeps the diagram readable on large projects
+const maxClassDiagramNodes = 30
+
+// ClassNode is an exported Go struct or interface
+type ClassNode struct {
+	Name     string   `json:"name"`
+	Package  string   `json:"package"`
+	Dir      string   `json:"dir"`  // Package directory, slash-separated
+	Kind     string   `json:"kind"` // struct or interface
+	FilePath string   `json:"filePath"`
+	Fields   []string `json:"fields,omitempty"`
+	Methods  []string `json:"methods,omitempty"`
+}
+
+// key identifies the node across packages, which may share a name but not a directory
+func (n *ClassNode) key() string {
+	return classKey(n.Dir, n.Name)
+}
+
+// classKey returns the key of the type name declared in the package at dir
+func classKey(dir, name string) string {
+	return dir + "." + name
+}
+
+// ClassRelation links two nodes of a class diagram
+type ClassRelation struct {
+	From  string `json:"from"` // Node key ("package/dir.Type")
+	To    string `json:"to"`
+	Kind  string `json:"kind"`            // embeds or references
+	Label string `json:"label,omitempty"` // Referencing field name
+}
+
+// ClassDiagram describes the main Go types of a project and how they relate
+type ClassDiagram struct {
+	Nodes     []ClassNode     `json:"nodes"`
+	Relations []ClassRelation `json:"relations"`
+}
+
+// ExtractClassDiagram parses the scanned Go files and collects their exported structs and
+// interfaces, the types they embed and the project types their fields reference
+func ExtractClassDiagram(files []scanner.FileInfo) *ClassDiagram {
+	fset := token.NewFileSet()
+	nodes := make(map[string]*ClassNode)
+	packages := make(map[string]string) // Package name by directory
+	var parsed []parsedGoFile
+
+	for _, file := range files {
+		if file.Extension != ".go" || file.Category == string(scanner.CategoryTest) {
+			continue
+		}
+
+		content, err := readSourceFile(file)
+		if err != nil {
+			continue
+		}
+		astFile, err := parser.ParseFile(fset, file.Path, content, parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		dir := filepath.ToSlash(filepath.Dir(file.Path))
+		packages[dir] = astFile.Name.Name
+		parsed = append(parsed, parsedGoFile{file: astFile, dir: dir})
+
+		for _, decl := range astFile.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				if !typeSpec.Name.IsExported() {
+					continue
+				}
+				node := &ClassNode{Name: typeSpec.Name.Name, Package: astFile.Name.Name, Dir: dir, FilePath: file.Path}
+				switch typeSpec.Type.(type) {
+				case *ast.StructType:
+					node.Kind = "struct"
+				case *ast.InterfaceType:
+					node.Kind = "interface"
+				default:
+					continue
+				}
+				nodes[node.key()] = node
+			}
+		}
+	}
+
+	diagram := &ClassDiagram{}
+	seenRelations := make(map[string]bool)
+	addRelation := func(relation ClassRelation) {
+		id := relation.From + "|" + relation.To + "|" + relation.Kind
+		if relation.From == relation.To || seenRelations[id] {
+			return
+		}
+		seenRelations[id] = true
+		diagram.Relations = append(diagram.Relations, relation)
+	}
+
+	for _, parsedFile := range parsed {
+		scope := typeScope{dir: parsedFile.dir, imports: importedDirs(parsedFile.file, packages)}
+		for _, decl := range parsedFile.file.Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				if decl.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range decl.Specs {
+					typeSpec := spec.(*ast.TypeSpec)
+					node, ok := nodes[classKey(scope.dir, typeSpec.Name.Name)]
+					if !ok {
+						continue
+					}
+					collectMembers(node, typeSpec, scope, nodes, addRelation)
+				}
+			case *ast.FuncDecl:
+				if decl.Recv == nil || len(decl.Recv.List) == 0 || !decl.Name.IsExported() {
+					continue
+				}
+				if node, ok := nodes[classKey(scope.dir, receiverTypeName(decl.Recv.List[0].Type))]; ok {
+					node.Methods = append(node.Methods, methodSignature(decl.Name.Name, decl.Type))
+				}
+			}
+		}
+	}
+
+	diagram.Nodes = selectClassNodes(nodes, diagram.Relations)
+	diagram.Relations = relationsBetween(diagram.Nodes, diagram.Relations)
+	return diagram
+}
+
+// parsedGoFile is a parsed Go file and the directory of its package
+type parsedGoFile struct {
+	file *ast.File
+	dir  string
+}
+
+// typeScope resolves type names in a file: unqualified names to its own package directory,
+// qualified names to the directory of the project package imported under the qualifier
+type typeScope struct {
+	dir     string
+	imports map[string]string // Package directory by local import name
+}
+
+// importedDirs maps the local names of a file's imports of project packages to their
+// directories. An import path names the package at the longest directory it ends with.
+func importedDirs(file *ast.File, packages map[string]string) map[string]string {
+	imports := make(map[string]string)
+	for _, spec := range file.Imports {
+		path := strings.Trim(spec.Path.Value, "`\"")
+		dir := ""
+		for candidate := range packages {
+			if candidate != "." && (path == candidate || strings.HasSuffix(path, "/"+candidate)) &&
+				len(candidate) > len(dir) {
+				dir = candidate
+			}
+		}
+		if dir == "" {
+			continue
+		}
+
+		name := packages[dir]
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		if name != "_" && name != "." {
+			imports[name] = dir
+		}
+	}
+	return imports
+}
+
+// collectMembers records a type's exported fields or interface methods and its relations
+func collectMembers(
+	node *ClassNode,
+	typeSpec *ast.TypeSpec,
+	scope typeScope,
+	nodes map[string]*ClassNode,
+	addRelation func(ClassRelation),
+) {
+	var fields []*ast.Field
+	switch t := typeSpec.Type.(type) {
+	case *ast.StructType:
+		fields = t.Fields.List
+	case *ast.InterfaceType:
+		fields = t.Methods.List
+	}
+
+	for _, field := range fields {
+		if len(field.Names) == 0 {
+			// Embedded type
+			for _, target := range referencedTypes(field.Type, scope, nodes) {
+				addRelation(ClassRelation{From: node.key(), To: target, Kind: "embeds"})
+			}
+			continue
+		}
+
+		for _, name := range field.Names {
+			if !name.IsExported() {
+				continue
+			}
+			if funcType, ok := field.Type.(*ast.FuncType); ok && node.Kind == "interface" {
+				node.Methods = append(node.Methods, methodSignature(name.Name, funcType))
+				continue
+			}
+			node.Fields = append(node.Fields, fmt.Sprintf("+%s %s", mermaidTypeName(field.Type), name.Name))
+			for _, target := range referencedTypes(field.Type, scope, nodes) {
+				addRelation(ClassRelation{From: node.key(), To: target, Kind: "references", Label: name.Name})
+			}
+		}
+	}
+}
+
+// referencedTypes returns the keys of the known types named in a type expression
+func referencedTypes(expr ast.Expr, scope typeScope, nodes map[string]*ClassNode) []string {
+	var keys []string
+	ast.Inspect(expr, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			if ident, ok := n.X.(*ast.Ident); ok {
+				if dir, imported := scope.imports[ident.Name]; imported {
+					if key := classKey(dir, n.Sel.Name); nodes[key] != nil {
+						keys = append(keys, key)
+					}
+				}
+			}
+			return false
+		case *ast.Ident:
+			if key := classKey(scope.dir, n.Name); nodes[key] != nil {
+				keys = append(keys, key)
+			}
+		}
+		return true
+	})
+	return keys
+}
+
+// receiverTypeName returns the type name of a method receiver such as *T or T[K]
+func receiverTypeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverTypeName(t.X)
+	case *ast.IndexExpr:
+		return receiverTypeName(t.X)
+	case *ast.IndexListExpr:
+		return receiverTypeName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+// methodSignature renders a method as a Mermaid class member, e.g. "+Add(int, int) int"
+func methodSignature(name string, funcType *ast.FuncType) string {
+	var params []string
+	if funcType.Params != nil {
+		for _, field := range funcType.Params.List {
+			count := max(len(field.Names), 1)
+			for range count {
+				params = append(params, mermaidTypeName(field.Type))
+			}
+		}
+	}
+
+	signature := fmt.Sprintf("+%s(%s)", name, strings.Join(params, ", "))
+	// Mermaid has no syntax for multiple results, so only a single result is shown
+	if funcType.Results != nil && len(funcType.Results.List) == 1 && len(funcType.Results.List[0].Names) <= 1 {
+		signature += " " + mermaidTypeName(funcType.Results.List[0].Type)
+	}
+	return signature
+}
+
+// mermaidTypeName renders a Go type with the characters Mermaid accepts in class members
+func mermaidTypeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return mermaidTypeName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.ArrayType:
+		return mermaidTypeName(t.Elt) + "[]"
+	case *ast.Ellipsis:
+		return mermaidTypeName(t.Elt) + "[]"
+	case *ast.IndexExpr:
+		return mermaidTypeName(t.X) + "~" + mermaidTypeName(t.Index) + "~"
+	case *ast.IndexListExpr:
+		return mermaidTypeName(t.X)
+	case *ast.MapType:
+		return "map"
+	case *ast.ChanType:
+		return "chan"
+	case *ast.FuncType:
+		return "func"
+	case *ast.InterfaceType:
+		return "any"
+	case *ast.StructType:
+		return "struct"
+	}
+	return "any"
+}
+
+// selectClassNodes keeps the most connected types, up to maxClassDiagramNodes
+func selectClassNodes(nodes map[string]*ClassNode, relations []ClassRelation) []ClassNode {
+	degree := make(map[string]int)
+	for _, relation := range relations {
+		degree[relation.From]++
+		degree[relation.To]++
+	}
+
+	selected := make([]ClassNode, 0, len(nodes))
+	for _, node := range nodes {
+		selected = append(selected, *node)
+	}
+	sort.Slice(selected, func(i, j int) bool {
+		a, b := selected[i], selected[j]
+		if degree[a.key()] != degree[b.key()] {
+			return degree[a.key()] > degree[b.key()]
+		}
+		return a.key() < b.key()
+	})
+	if len(selected) > maxClassDiagramNodes {
+		selected = selected[:maxClassDiagramNodes]
+	}
+
+	sort.Slice(selected, func(i, j int) bool { return selected[i].key() < selected[j].key() })
+	return selected
+}
+
+// relationsBetween drops relations to types that are not part of the diagram
+func relationsBetween(nodes []ClassNode, relations []ClassRelation) []ClassRelation {
+	included := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		included[node.key()] = true
+	}
+
+	var kept []ClassRelation
+	for _, relation := range relations {
+		if included[relation.From] && included[relation.To] {
+			kept = append(kept, relation)
+		}
+	}
+	return kept
+}
+
+// Mermaid renders the diagram as a Mermaid classDiagram block
+func (d *ClassDiagram) Mermaid() string {
+	// Type names are only qualified by package when they would otherwise collide, and by
+	// package directory when packages share a name
+	nameCount := make(map[string]int)
+	packageCount := make(map[string]int)
+	for _, node := range d.Nodes {
+		nameCount[node.Name]++
+		packageCount[node.Package+"."+node.Name]++
+	}
+	ids := make(map[string]string, len(d.Nodes))
+	for _, node := range d.Nodes {
+		switch {
+		case packageCount[node.Package+"."+node.Name] > 1:
+			ids[node.key()] = mermaidIdentifier(node.Dir) + "_" + node.Name
+		case nameCount[node.Name] > 1:
+			ids[node.key()] = node.Package + "_" + node.Name
+		default:
+			ids[node.key()] = node.Name
+		}
+	}
+
+	var builder strings.Builder
+	builder.WriteString("```mermaid\nclassDiagram\n")
+	for _, node := range d.Nodes {
+		id := ids[node.key()]
+		if node.Kind != "interface" && len(node.Fields) == 0 && len(node.Methods) == 0 {
+			builder.WriteString(fmt.Sprintf("    class %s\n", id))
+			continue
+		}
+
+		builder.WriteString(fmt.Sprintf("    class %s {\n", id))
+		if node.Kind == "interface" {
+			builder.WriteString("        <<interface>>\n")
+		}
+		for _, member := range append(append([]string{}, node.Fields...), node.Methods...) {
+			builder.WriteString(fmt.Sprintf("        %s\n", member))
+		}
+		builder.WriteString("    }\n")
+	}
+
+	for _, relation := range d.Relations {
+		from, to := ids[relation.From], ids[relation.To]
+		switch relation.Kind {
+		case "embeds":
+			builder.WriteString(fmt.Sprintf("    %s <|-- %s\n", to, from))
+		default:
+			builder.WriteString(fmt.Sprintf("    %s --> %s : %s\n", from, to, relation.Label))
+		}
+	}
+	builder.WriteString("```\n")
+
+	return builder.String()
+}
+
+// mermaidIdentifier replaces the characters of s that Mermaid identifiers do not allow
+func mermaidIdentifier(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// classDiagramSection renders the diagram as a page section
+func classDiagramSection(diagram *ClassDiagram) string {
+	return "## Type Diagram\n\n" +
+		"Exported structs and interfaces, the types they embed and the project types their fields reference.\n\n" +
+		diagram.Mermaid()
+}
+
+// addClassDiagram renders the type diagram into the architecture or API page,
+// or adds it as its own page when the wiki has neither
+func (g *WikiGenerator) addClassDiagram(
+	files []scanner.FileInfo,
+	structure *WikiStructure,
+	result *GenerationResult,
+) {
+	diagram := ExtractClassDiagram(files)
+	if len(diagram.Nodes) == 0 {
+		return
+	}
+	section := classDiagramSection(diagram)
+
+	for _, keyword := range []string{"architecture", "api"} {
+		for i := range structure.Pages {
+			page := &structure.Pages[i]
+			if _, generated := result.Pages[page.ID]; !generated || page.SourceDoc != "" {
+				continue
+			}
+			if !strings.Contains(strings.ToLower(page.ID+" "+page.Title), keyword) {
+				continue
+			}
+
+			wordCount := page.WordCount
+			page.Content = strings.TrimRight(page.Content, "\n") + "\n\n" + section
+			page.WordCount = len(strings.Fields(page.Content))
+			result.TotalWords += page.WordCount - wordCount
+			g.logger.Info("Added type diagram", "page", page.ID, "types", len(diagram.Nodes))
+			return
+		}
+	}
+
+	filePaths := make([]string, 0, len(diagram.Nodes))
+	seen := make(map[string]bool)
+	for _, node := range diagram.Nodes {
+		if !seen[node.FilePath] {
+			seen[node.FilePath] = true
+			filePaths = append(filePaths, node.FilePath)
+		}
+	}
+
+	addGeneratedPage(structure, result, &WikiPage{
+		ID:          ClassDiagramPageID,
+		Title:       "Type Diagram",
+		Description: "Class diagram of the main Go structs and interfaces",
+		Content:     "# Type Diagram\n\n" + strings.TrimPrefix(section, "## Type Diagram\n\n"),
+		FilePaths:   filePaths,
+		Importance:  "medium",
+	})
+	g.logger.Info("Added analysis page", "page", ClassDiagramPageID)
+}
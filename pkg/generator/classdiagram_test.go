@@ -0,0 +1,246 @@
+package generator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/kuderr/deepwiki/pkg/scanner"
+	"github.com/kuderr/deepwiki/pkg/types"
+)
+
+// calculatorSource is the Calculator fixture used by the integration tests
+const calculatorSource = `package utils
+
+// Calculator provides basic math operations
+type Calculator struct{}
+
+// Add adds two numbers
+func (c *Calculator) Add(a, b int) int {
+	return a + b
+}
+
+// Subtract subtracts two numbers
+func (c *Calculator) Subtract(a, b int) int {
+	return a - b
+}
+`
+
+const shapesSource = `package utils
+
+import "io"
+
+// Shape is anything with an area
+type Shape interface {
+	Area() float64
+}
+
+// Base holds shared fields
+type Base struct {
+	Name string
+}
+
+// Scene arranges shapes
+type Scene struct {
+	Base
+	Shapes     []Shape
+	Calculator *Calculator
+	Output     io.Writer
+	count      int
+}
+
+type hidden struct{}
+
+// Render draws the scene
+func (s *Scene) Render(w io.Writer) error {
+	return nil
+}
+`
+
+func writeGoFiles(t *testing.T, sources map[string]string) []scanner.FileInfo {
+	t.Helper()
+
+	dir := t.TempDir()
+	var files []scanner.FileInfo
+	for name, content := range sources {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		files = append(files, scanner.FileInfo{
+			Path:         filepath.FromSlash(name),
+			AbsolutePath: path,
+			Name:         filepath.Base(name),
+			Extension:    ".go",
+			Category:     string(scanner.CategoryCode),
+		})
+	}
+	return files
+}
+
+// mermaidClassLine matches the statements used in generated class diagrams
+var mermaidClassLine = regexp.MustCompile(
+	`^(classDiagram|    class \w+( \{)?|        <<interface>>|        \+[\w\[\]~]+(\(.*\))?( [\w\[\]~]+)?|    \}|` +
+		`    \w+ <\|-- \w+|    \w+ --> \w+ : \w+)$`,
+)
+
+// assertValidMermaid checks that a diagram is a fenced classDiagram with balanced class blocks
+func assertValidMermaid(t *testing.T, diagram string) {
+	t.Helper()
+
+	body, ok := strings.CutPrefix(diagram, "```mermaid\n")
+	if !ok || !strings.HasSuffix(body, "```\n") {
+		t.Fatalf("Expected a fenced mermaid block, got:\n%s", diagram)
+	}
+	lines := strings.Split(strings.TrimSuffix(body, "```\n"), "\n")
+	lines = lines[:len(lines)-1]
+
+	if lines[0] != "classDiagram" {
+		t.Errorf("Expected a classDiagram, got %q", lines[0])
+	}
+	open := false
+	for _, line := range lines {
+		if !mermaidClassLine.MatchString(line) {
+			t.Errorf("Unexpected Mermaid line %q", line)
+		}
+		switch {
+		case strings.HasSuffix(line, "{"):
+			if open {
+				t.Errorf("Nested class block at %q", line)
+			}
+			open = true
+		case line == "    }":
+			open = false
+		}
+	}
+	if open {
+		t.Error("Unclosed class block")
+	}
+}
+
+func TestExtractClassDiagram_Calculator(t *testing.T) {
+	files := writeGoFiles(t, map[string]string{"calculator.go": calculatorSource})
+
+	diagram := ExtractClassDiagram(files)
+	if len(diagram.Nodes) != 1 || diagram.Nodes[0].Name != "Calculator" {
+		t.Fatalf("Expected a Calculator node, got %+v", diagram.Nodes)
+	}
+	if methods := diagram.Nodes[0].Methods; len(methods) != 2 || methods[0] != "+Add(int, int) int" {
+		t.Errorf("Expected Add and Subtract methods, got %v", methods)
+	}
+
+	mermaid := diagram.Mermaid()
+	assertValidMermaid(t, mermaid)
+	if !strings.Contains(mermaid, "    class Calculator {\n        +Add(int, int) int\n") {
+		t.Errorf("Expected Calculator as a class node, got:\n%s", mermaid)
+	}
+}
+
+func TestExtractClassDiagram_Relations(t *testing.T) {
+	files := writeGoFiles(t, map[string]string{
+		"calculator.go": calculatorSource,
+		"shapes.go":     shapesSource,
+	})
+
+	mermaid := ExtractClassDiagram(files).Mermaid()
+	assertValidMermaid(t, mermaid)
+
+	for _, expected := range []string{
+		"    class Shape {\n        <<interface>>\n        +Area() float64\n    }",
+		"    Base <|-- Scene",
+		"    Scene --> Shape : Shapes",
+		"    Scene --> Calculator : Calculator",
+		"        +Shape[] Shapes",
+		"        +Render(Writer) error",
+	} {
+		if !strings.Contains(mermaid, expected) {
+			t.Errorf("Expected diagram to contain %q, got:\n%s", expected, mermaid)
+		}
+	}
+	for _, unexpected := range []string{"hidden", "count"} {
+		if strings.Contains(mermaid, unexpected) {
+			t.Errorf("Expected unexported %s to be left out, got:\n%s", unexpected, mermaid)
+		}
+	}
+}
+
+func TestExtractClassDiagram_SamePackageNameInTwoDirectories(t *testing.T) {
+	files := writeGoFiles(t, map[string]string{
+		"internal/config/config.go": "package config\n\n// Config is the internal configuration\n" +
+			"type Config struct {\n\tDebug bool\n}\n",
+		"pkg/config/config.go": "package config\n\n// Config is the public configuration\n" +
+			"type Config struct {\n\tName string\n}\n",
+		"pkg/app/app.go": "package app\n\nimport (\n\tinternal \"example.com/demo/internal/config\"\n" +
+			"\t\"example.com/demo/pkg/config\"\n)\n\n// App runs the demo\n" +
+			"type App struct {\n\tSettings *config.Config\n\tDefaults internal.Config\n}\n",
+	})
+
+	diagram := ExtractClassDiagram(files)
+	if len(diagram.Nodes) != 3 {
+		t.Fatalf("Expected App and both Config types as nodes, got %+v", diagram.Nodes)
+	}
+	wantRelations := map[string]string{
+		"Settings": "pkg/config.Config",
+		"Defaults": "internal/config.Config",
+	}
+	for _, relation := range diagram.Relations {
+		if want := wantRelations[relation.Label]; relation.From != "pkg/app.App" || relation.To != want {
+			t.Errorf("Unexpected relation %+v", relation)
+		}
+		delete(wantRelations, relation.Label)
+	}
+	if len(wantRelations) != 0 {
+		t.Errorf("Expected relations for fields %v", wantRelations)
+	}
+
+	mermaid := diagram.Mermaid()
+	assertValidMermaid(t, mermaid)
+	for _, expected := range []string{
+		"    class internal_config_Config {\n        +bool Debug\n",
+		"    class pkg_config_Config {\n        +string Name\n",
+		"    App --> pkg_config_Config : Settings",
+		"    App --> internal_config_Config : Defaults",
+	} {
+		if !strings.Contains(mermaid, expected) {
+			t.Errorf("Expected diagram to contain %q, got:\n%s", expected, mermaid)
+		}
+	}
+}
+
+func TestGenerateWiki_ClassDiagramInArchitecturePage(t *testing.T) {
+	files := writeGoFiles(t, map[string]string{"calculator.go": calculatorSource})
+	structure := `<wiki_structure>
+  <title>demo Documentation</title>
+  <description>The demo project</description>
+  <pages>
+    <page><id>overview</id><title>Overview</title><importance>high</importance></page>
+    <page><id>architecture</id><title>Architecture</title><importance>high</importance></page>
+  </pages>
+</wiki_structure>`
+	generator, _ := newScriptedGenerator(
+		choice(structure, "stop"),
+		choice("# Page\n\nGenerated content.", "stop"),
+	)
+
+	options := GenerationOptions{ProjectName: "demo", Language: types.LanguageEnglish, IncludeClassDiagram: true}
+	result, err := generator.GenerateWiki(context.Background(), files, options)
+	if err != nil {
+		t.Fatalf("GenerateWiki failed: %v", err)
+	}
+
+	if !strings.Contains(result.Pages["architecture"].Content, "## Type Diagram\n\n") {
+		t.Errorf("Expected the diagram in the architecture page, got:\n%s", result.Pages["architecture"].Content)
+	}
+	if strings.Contains(result.Pages["overview"].Content, "classDiagram") {
+		t.Error("Expected the overview page to be left unchanged")
+	}
+	if _, ok := result.Pages[ClassDiagramPageID]; ok {
+		t.Error("Expected no separate diagram page when an architecture page exists")
+	}
+}
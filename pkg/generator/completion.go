@@ -0,0 +1,191 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kuderr/deepwiki/pkg/llm"
+	"github.com/kuderr/deepwiki/pkg/tracing"
+)
+
+const (
+	// defaultCompletionTokens is the token budget of the first attempt
+	defaultCompletionTokens = 4000
+	// defaultTemperature is the temperature of phases that do not set one
+	defaultTemperature = 0.1
+	// maxCompletionTokens caps the budget when retrying truncated responses
+	maxCompletionTokens = 16000
+	// maxCompletionAttempts is how often an empty or truncated response is requested again
+	maxCompletionAttempts = 3
+	// maxRefusalWords is the length up to which a page starting with a refusal is taken for one
+	maxRefusalWords = 60
+)
+
+// refusalPrefixes are the openings of a model declining to write a page, in lowercase
+var refusalPrefixes = []string{
+	"i'm sorry", "i am sorry", "sorry,", "i apologize",
+	"i cannot", "i can't", "i can not", "i'm unable", "i am unable", "i'm not able", "i am not able",
+	"as an ai",
+}
+
+// completionOptions returns the chat completion options of a phase
+func (s PhaseSettings) completionOptions() llm.ChatCompletionOptions {
+	options := llm.ChatCompletionOptions{
+		MaxTokens:   defaultCompletionTokens,
+		Temperature: defaultTemperature,
+	}
+	if s.MaxTokens > 0 {
+		options.MaxTokens = s.MaxTokens
+	}
+	if s.Temperature != nil {
+		options.Temperature = *s.Temperature
+	}
+	return options
+}
+
+// completeChat calls the LLM and validates the response. Empty responses are requested again
+// and responses truncated by the token limit are retried with twice the token budget.
+func (g *WikiGenerator) completeChat(
+	ctx context.Context,
+	messages []llm.Message,
+	options llm.ChatCompletionOptions,
+) (string, error) {
+	return g.completeCheckedChat(ctx, messages, options, 0, nil)
+}
+
+// contentCheck cleans a response and describes why it is unusable, or returns an empty problem
+type contentCheck func(answer string) (content, problem string)
+
+// completeCheckedChat is completeChat with check applied to each response. A response that check
+// rejects is answered with a corrective user turn, within the same attempt budget. A positive
+// limit is the room left for the response in the context window, which retries never exceed.
+func (g *WikiGenerator) completeCheckedChat(
+	ctx context.Context,
+	messages []llm.Message,
+	options llm.ChatCompletionOptions,
+	limit int,
+	check contentCheck,
+) (_ string, err error) {
+	if options.MaxTokens <= 0 {
+		options.MaxTokens = defaultCompletionTokens
+	}
+	maxTokens := maxCompletionTokens
+	if limit > 0 {
+		maxTokens = min(maxTokens, limit)
+		options.MaxTokens = min(options.MaxTokens, limit)
+	}
+
+	// Token usage is summed over all attempts
+	ctx, span := tracing.Start(ctx, "llm.chat_completion", tracing.String("model", g.llmProvider.GetModel()))
+	var usage llm.Usage
+	attempts := 0
+	defer func() {
+		span.SetAttributes(
+			tracing.Int("attempts", attempts),
+			tracing.Int("prompt_tokens", usage.PromptTokens),
+			tracing.Int("completion_tokens", usage.CompletionTokens),
+		)
+		span.RecordError(err)
+		span.End()
+	}()
+
+	var problem string
+	for attempt := 1; attempt <= maxCompletionAttempts; attempt++ {
+		attempts = attempt
+		// A retry must not be served the cached response it is retrying
+		options.BypassCache = attempt > 1
+		response, err := g.llmProvider.ChatCompletion(ctx, messages, options)
+		if err != nil {
+			return "", err
+		}
+		usage.PromptTokens += response.Usage.PromptTokens
+		usage.CompletionTokens += response.Usage.CompletionTokens
+
+		if len(response.Choices) == 0 || strings.TrimSpace(response.Choices[0].Message.Content) == "" {
+			problem = "empty response"
+			g.logger.Warn("LLM returned an empty response, retrying", "attempt", attempt)
+			continue
+		}
+
+		choice := response.Choices[0]
+		if choice.Truncated() {
+			problem = fmt.Sprintf("response truncated at %d tokens", options.MaxTokens)
+			if options.MaxTokens >= maxTokens {
+				break
+			}
+			options.MaxTokens = min(options.MaxTokens*2, maxTokens)
+			g.logger.Warn("LLM response was truncated, retrying with more tokens",
+				"attempt", attempt,
+				"max_tokens", options.MaxTokens,
+			)
+			continue
+		}
+
+		if check == nil {
+			return choice.Message.Content, nil
+		}
+		var content string
+		content, problem = check(choice.Message.Content)
+		if problem == "" {
+			return content, nil
+		}
+		g.logger.Warn("LLM returned unusable content, retrying", "problem", problem, "attempt", attempt)
+
+		// Asking the same question again tends to get the same answer, so point out the problem
+		correction := []llm.Message{
+			{Role: "assistant", Content: choice.Message.Content},
+			{Role: "user", Content: fmt.Sprintf(
+				"That answer is unusable (%s). Answer the request above in full.", problem)},
+		}
+		messages = append(messages[:len(messages):len(messages)], correction...)
+
+		// The corrective turns take room from the response
+		if limit > 0 {
+			maxTokens = max(maxTokens-g.promptTokens(requestText(correction, llm.ChatCompletionOptions{})), 1)
+			options.MaxTokens = min(options.MaxTokens, maxTokens)
+		}
+	}
+
+	return "", fmt.Errorf("invalid LLM response: %s", problem)
+}
+
+// completePageContent generates the content of a page and cleans its markdown. Content that is
+// blank once cleaned, or is a short refusal rather than a page, is requested again. limit is
+// passed to completeCheckedChat.
+func (g *WikiGenerator) completePageContent(
+	ctx context.Context,
+	page *WikiPage,
+	messages []llm.Message,
+	options llm.ChatCompletionOptions,
+	limit int,
+) (string, error) {
+	content, err := g.completeCheckedChat(ctx, messages, options, limit, func(answer string) (string, string) {
+		content := g.contentPostProcessor.CleanMarkdown(answer)
+		return content, pageContentProblem(content)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to generate content of page %s: %w", page.ID, err)
+	}
+	return content, nil
+}
+
+// pageContentProblem describes why generated page content is unusable, or returns ""
+func pageContentProblem(content string) string {
+	trimmed := strings.TrimSpace(content)
+	if trimmed == "" {
+		return "blank content"
+	}
+
+	if len(strings.Fields(trimmed)) > maxRefusalWords {
+		return ""
+	}
+	opening := strings.ToLower(strings.TrimLeft(trimmed, "#*_> \t"))
+	opening = strings.ReplaceAll(opening, "’", "'")
+	for _, prefix := range refusalPrefixes {
+		if strings.HasPrefix(opening, prefix) {
+			return "refusal"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,362 @@
+package generator
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/kuderr/deepwiki/pkg/llm"
+	"github.com/kuderr/deepwiki/pkg/tracing"
+	"github.com/kuderr/deepwiki/pkg/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// scriptedLLMProvider returns the given choices in order and records the token budget,
+// temperature, messages and caching options of each call, and how often tokens are counted
+type scriptedLLMProvider struct {
+	MockLLMProvider
+	responses     []llm.Choice
+	maxTokens     []int
+	temperatures  []float64
+	messages      [][]llm.Message
+	bypassCache   []bool
+	cacheMessages []int
+	tokenCounts   int
+}
+
+func (m *scriptedLLMProvider) CountTokens(text string) (int, error) {
+	m.tokenCounts++
+	return m.MockLLMProvider.CountTokens(text)
+}
+
+func (m *scriptedLLMProvider) ChatCompletion(
+	ctx context.Context,
+	messages []llm.Message,
+	opts ...llm.ChatCompletionOptions,
+) (*llm.ChatCompletionResponse, error) {
+	m.maxTokens = append(m.maxTokens, opts[0].MaxTokens)
+	m.temperatures = append(m.temperatures, opts[0].Temperature)
+	m.messages = append(m.messages, messages)
+	m.bypassCache = append(m.bypassCache, opts[0].BypassCache)
+	m.cacheMessages = append(m.cacheMessages, opts[0].CacheMessages)
+
+	call := len(m.maxTokens) - 1
+	if call >= len(m.responses) {
+		call = len(m.responses) - 1
+	}
+	return &llm.ChatCompletionResponse{
+		Choices: []llm.Choice{m.responses[call]},
+		Usage:   llm.Usage{PromptTokens: 100, CompletionTokens: 20},
+	}, nil
+}
+
+func newScriptedGenerator(responses ...llm.Choice) (*WikiGenerator, *scriptedLLMProvider) {
+	provider := &scriptedLLMProvider{responses: responses}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewWikiGenerator(provider, &MockRAGRetriever{}, logger), provider
+}
+
+func choice(content, finishReason string) llm.Choice {
+	return llm.Choice{Message: llm.Message{Role: "assistant", Content: content}, FinishReason: finishReason}
+}
+
+func TestGeneratePageContent_RetriesEmptyResponse(t *testing.T) {
+	generator, provider := newScriptedGenerator(
+		choice("   ", "stop"),
+		choice("# Overview\n\nThe project overview.", "stop"),
+	)
+
+	page := &WikiPage{ID: "overview", Title: "Overview"}
+	structure := &WikiStructure{Pages: []WikiPage{*page}}
+	options := GenerationOptions{ProjectName: "demo", Language: types.LanguageEnglish}
+
+	if err := generator.GeneratePageContent(context.Background(), "", page, structure, options); err != nil {
+		t.Fatalf("GeneratePageContent failed: %v", err)
+	}
+
+	if len(provider.maxTokens) != 2 {
+		t.Fatalf("Expected 2 LLM calls, got %d", len(provider.maxTokens))
+	}
+	if provider.maxTokens[1] != provider.maxTokens[0] {
+		t.Errorf("Expected the same token budget for an empty response retry, got %v", provider.maxTokens)
+	}
+	if page.Content == "" {
+		t.Error("Expected page content from the second response")
+	}
+}
+
+func TestGeneratePageContent_CachesSharedContext(t *testing.T) {
+	generator, provider := newScriptedGenerator(choice("# Page\n\nThe page content.", "stop"))
+
+	structure := &WikiStructure{Pages: []WikiPage{
+		{ID: "overview", Title: "Overview", Description: "What the project does"},
+		{ID: "config", Title: "Configuration", Description: "How to configure it"},
+	}}
+	options := GenerationOptions{ProjectName: "demo", Language: types.LanguageEnglish}
+	for i := range structure.Pages {
+		err := generator.GeneratePageContent(context.Background(), "main.go\n", &structure.Pages[i], structure, options)
+		if err != nil {
+			t.Fatalf("GeneratePageContent failed: %v", err)
+		}
+	}
+
+	if len(provider.messages) != 2 {
+		t.Fatalf("Expected 2 LLM calls, got %d", len(provider.messages))
+	}
+	first, second := provider.messages[0], provider.messages[1]
+	if len(first) != 2 || len(second) != 2 || first[0] != second[0] {
+		t.Errorf("Expected the same context message ahead of each page prompt, got %+v and %+v", first, second)
+	}
+	if !strings.Contains(second[1].Content, "Configuration") || strings.Contains(first[1].Content, "Configuration") {
+		t.Errorf("Expected the page prompts to differ by page, got %q and %q", first[1].Content, second[1].Content)
+	}
+	if provider.cacheMessages[0] != 1 || provider.cacheMessages[1] != 1 {
+		t.Errorf("Expected the context message to be cached, got %v", provider.cacheMessages)
+	}
+}
+
+func TestCompleteChat_RetriesTruncatedResponseWithMoreTokens(t *testing.T) {
+	generator, provider := newScriptedGenerator(
+		choice("# Overview\n\nThe project", "length"),
+		choice("# Overview\n\nThe project overview.", "stop"),
+	)
+
+	messages := []llm.Message{{Role: "user", Content: "Describe the project"}}
+	content, err := generator.completeChat(context.Background(), messages, llm.ChatCompletionOptions{MaxTokens: 4000})
+	if err != nil {
+		t.Fatalf("completeChat failed: %v", err)
+	}
+
+	if content != "# Overview\n\nThe project overview." {
+		t.Errorf("Expected the complete response, got %q", content)
+	}
+	if len(provider.maxTokens) != 2 || provider.maxTokens[1] <= provider.maxTokens[0] {
+		t.Errorf("Expected a retry with a higher token budget, got %v", provider.maxTokens)
+	}
+}
+
+func TestCompleteChat_GivesUp(t *testing.T) {
+	tests := []struct {
+		name      string
+		response  llm.Choice
+		wantCalls int
+	}{
+		{name: "always empty", response: choice("", "stop"), wantCalls: maxCompletionAttempts},
+		{name: "always truncated", response: choice("partial", "max_tokens"), wantCalls: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generator, provider := newScriptedGenerator(tt.response)
+
+			messages := []llm.Message{{Role: "user", Content: "Describe the project"}}
+			_, err := generator.completeChat(context.Background(), messages, llm.ChatCompletionOptions{})
+			if err == nil {
+				t.Error("Expected an error for an invalid response")
+			}
+			if len(provider.maxTokens) != tt.wantCalls {
+				t.Errorf("Expected %d calls, got %d", tt.wantCalls, len(provider.maxTokens))
+			}
+			for _, tokens := range provider.maxTokens {
+				if tokens > maxCompletionTokens {
+					t.Errorf("Expected token budget capped at %d, got %d", maxCompletionTokens, tokens)
+				}
+			}
+		})
+	}
+}
+
+func TestGeneratePageContent_TracesCompletion(t *testing.T) {
+	generator, _ := newScriptedGenerator(
+		choice("", "stop"),
+		choice("# Overview\n\nThe project overview.", "stop"),
+	)
+
+	exporter := tracetest.NewInMemoryExporter()
+	tracer := tracing.NewTracer(exporter, "")
+	ctx := tracing.ContextWithTracer(context.Background(), tracer)
+
+	page := &WikiPage{ID: "overview", Title: "Overview"}
+	structure := &WikiStructure{Pages: []WikiPage{*page}}
+	options := GenerationOptions{ProjectName: "demo", Language: types.LanguageEnglish}
+
+	if err := generator.GeneratePageContent(ctx, "", page, structure, options); err != nil {
+		t.Fatalf("GeneratePageContent failed: %v", err)
+	}
+	if err := tracer.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	spans := make(map[string]tracetest.SpanStub)
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = span
+	}
+	pageSpan, ok := spans["generator.page"]
+	if !ok {
+		t.Fatal("Expected a generator.page span")
+	}
+	if value := spanAttribute(pageSpan, "page.id"); value.AsString() != "overview" {
+		t.Errorf("Expected page.id 'overview', got %v", value.Emit())
+	}
+
+	completionSpan, ok := spans["llm.chat_completion"]
+	if !ok {
+		t.Fatal("Expected an llm.chat_completion span")
+	}
+	if completionSpan.Parent.SpanID() != pageSpan.SpanContext.SpanID() {
+		t.Errorf("Expected completion span to be a child of the page span")
+	}
+	if value := spanAttribute(completionSpan, "attempts"); value.AsInt64() != 2 {
+		t.Errorf("Expected 2 attempts, got %v", value.Emit())
+	}
+	if value := spanAttribute(completionSpan, "prompt_tokens"); value.AsInt64() != 200 {
+		t.Errorf("Expected prompt tokens summed over attempts, got %v", value.Emit())
+	}
+	if value := spanAttribute(completionSpan, "completion_tokens"); value.AsInt64() != 40 {
+		t.Errorf("Expected completion tokens summed over attempts, got %v", value.Emit())
+	}
+}
+
+// spanAttribute returns the value of the last attribute of span with the given key
+func spanAttribute(span tracetest.SpanStub, key string) attribute.Value {
+	for i := len(span.Attributes) - 1; i >= 0; i-- {
+		if string(span.Attributes[i].Key) == key {
+			return span.Attributes[i].Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestGenerator_UsesPhaseSettings(t *testing.T) {
+	generator, provider := newScriptedGenerator(
+		choice(mergedStructureResponse, "stop"),
+		choice("# Overview\n\nThe project overview.", "stop"),
+	)
+
+	structureTemperature, pageTemperature := 0.0, 0.7
+	options := GenerationOptions{
+		ProjectName:    "demo",
+		Language:       types.LanguageEnglish,
+		StructurePhase: PhaseSettings{Temperature: &structureTemperature, MaxTokens: 8000},
+		PagePhase:      PhaseSettings{Temperature: &pageTemperature},
+	}
+
+	structure, err := generator.GenerateWikiStructure(context.Background(), "", "", options)
+	if err != nil {
+		t.Fatalf("GenerateWikiStructure failed: %v", err)
+	}
+	page := &structure.Pages[0]
+	if err := generator.GeneratePageContent(context.Background(), "", page, structure, options); err != nil {
+		t.Fatalf("GeneratePageContent failed: %v", err)
+	}
+
+	if len(provider.maxTokens) != 2 {
+		t.Fatalf("Expected 2 LLM calls, got %d", len(provider.maxTokens))
+	}
+	if provider.temperatures[0] != 0 || provider.maxTokens[0] != 8000 {
+		t.Errorf("Expected the structure request to use temperature 0 and 8000 tokens, got %v and %d",
+			provider.temperatures[0], provider.maxTokens[0])
+	}
+	if provider.temperatures[1] != 0.7 || provider.maxTokens[1] != defaultCompletionTokens {
+		t.Errorf("Expected the page request to use temperature 0.7 and %d tokens, got %v and %d",
+			defaultCompletionTokens, provider.temperatures[1], provider.maxTokens[1])
+	}
+}
+
+func TestGenerateWiki_RetriesBlankAndRefusedPages(t *testing.T) {
+	generator, _ := newScriptedGenerator(
+		choice(`<wiki_structure><title>demo</title><pages>
+  <page><id>overview</id><title>Overview</title><importance>high</importance></page>
+  <page><id>secrets</id><title>Secrets</title><importance>low</importance></page>
+</pages></wiki_structure>`, "stop"),
+		choice("  \n\t", "stop"),
+		choice("<think>Nothing to say.</think>\n", "stop"),
+		choice("# Overview\n\nThe project overview.", "stop"),
+		choice("I'm sorry, but I can't help with that request.", "stop"),
+	)
+
+	options := GenerationOptions{ProjectName: "demo", Language: types.LanguageEnglish}
+	result, err := generator.GenerateWiki(context.Background(), nil, options)
+	if err != nil {
+		t.Fatalf("GenerateWiki failed: %v", err)
+	}
+
+	page, ok := result.Pages["overview"]
+	if !ok || page.Content != "# Overview\n\nThe project overview." {
+		t.Fatalf("Expected the overview from the valid response, got %+v", page)
+	}
+	if _, ok := result.Pages["secrets"]; ok {
+		t.Error("Expected the refused page to be left out")
+	}
+	if result.TotalPages != 1 || len(result.Errors) != 1 {
+		t.Errorf("Expected 1 page and 1 error, got %d pages and errors %v", result.TotalPages, result.Errors)
+	}
+}
+
+func TestCompletePageContent_CorrectsRefusal(t *testing.T) {
+	refusal := "I'm sorry, but I can't help with that request."
+	generator, provider := newScriptedGenerator(
+		choice(refusal, "stop"),
+		choice("# Overview\n\nThe project overview.", "stop"),
+	)
+
+	page := &WikiPage{ID: "overview", Title: "Overview"}
+	messages := []llm.Message{{Role: "user", Content: "Write the overview page"}}
+	content, err := generator.completePageContent(context.Background(), page, messages, llm.ChatCompletionOptions{}, 0)
+	if err != nil {
+		t.Fatalf("completePageContent failed: %v", err)
+	}
+
+	if content != "# Overview\n\nThe project overview." {
+		t.Errorf("Expected the page from the second response, got %q", content)
+	}
+	if len(provider.messages) != 2 || provider.bypassCache[0] || !provider.bypassCache[1] {
+		t.Fatalf("Expected a cached request and an uncached retry, got %d calls bypassing %v",
+			len(provider.messages), provider.bypassCache)
+	}
+	retry := provider.messages[1]
+	if len(retry) != 3 || retry[1].Role != "assistant" || retry[1].Content != refusal ||
+		retry[2].Role != "user" || !strings.Contains(retry[2].Content, "refusal") {
+		t.Errorf("Expected the retry to answer the refusal with a corrective turn, got %+v", retry)
+	}
+}
+
+func TestCompletePageContent_SharesAttemptBudget(t *testing.T) {
+	generator, provider := newScriptedGenerator(
+		choice("", "stop"),
+		choice("I cannot write this page.", "stop"),
+	)
+
+	page := &WikiPage{ID: "overview", Title: "Overview"}
+	messages := []llm.Message{{Role: "user", Content: "Write the overview page"}}
+	_, err := generator.completePageContent(context.Background(), page, messages, llm.ChatCompletionOptions{}, 0)
+	if err == nil || !strings.Contains(err.Error(), "refusal") {
+		t.Errorf("Expected a refusal error, got %v", err)
+	}
+	if len(provider.messages) != maxCompletionAttempts {
+		t.Errorf("Expected %d LLM calls in all, got %d", maxCompletionAttempts, len(provider.messages))
+	}
+}
+
+func TestPageContentProblem(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"", "blank content"},
+		{" \n\t", "blank content"},
+		{"# Overview\n\nThe project overview.", ""},
+		{"I’m sorry, but I cannot generate this page.", "refusal"},
+		{"**As an AI** I am unable to see the repository.", "refusal"},
+		{"# I cannot stress enough\n\n" + strings.Repeat("word ", maxRefusalWords), ""},
+	}
+
+	for _, tt := range tests {
+		if got := pageContentProblem(tt.content); got != tt.want {
+			t.Errorf("pageContentProblem(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
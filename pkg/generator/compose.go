@@ -0,0 +1,81 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kuderr/deepwiki/pkg/processor"
+	"github.com/kuderr/deepwiki/pkg/types"
+)
+
+// ServicesPageID is the ID of the generated docker-compose services page
+const ServicesPageID = "services"
+
+// BuildServicesPage renders the docker-compose services page in language, or returns nil if there
+// are no services
+func BuildServicesPage(projects []processor.ComposeProject, language types.Language) *WikiPage {
+	if len(projects) == 0 {
+		return nil
+	}
+
+	labels := labelsFor(language)
+	var content strings.Builder
+	fmt.Fprintf(&content, "# %s\n\n%s\n", labels.Services, labels.ServicesIntro)
+
+	filePaths := make([]string, 0, len(projects))
+	for _, project := range projects {
+		filePaths = append(filePaths, project.FilePath)
+		content.WriteString(fmt.Sprintf("\n## `%s`\n", project.FilePath))
+
+		for _, svc := range project.Services {
+			content.WriteString(fmt.Sprintf("\n### %s\n\n", svc.Name))
+			if svc.Image != "" {
+				content.WriteString(fmt.Sprintf("- **%s:** `%s`\n", labels.Image, svc.Image))
+			}
+			if svc.Build != "" {
+				content.WriteString(fmt.Sprintf("- **%s:** `%s`\n", labels.Build, svc.Build))
+			}
+			if len(svc.Ports) > 0 {
+				content.WriteString(fmt.Sprintf("- **%s:** %s\n", labels.Ports, codeList(svc.Ports)))
+			}
+			if len(svc.DependsOn) > 0 {
+				content.WriteString(fmt.Sprintf("- **%s:** %s\n", labels.DependsOn, codeList(svc.DependsOn)))
+			}
+			if len(svc.Environment) > 0 {
+				keys := make([]string, 0, len(svc.Environment))
+				for key := range svc.Environment {
+					keys = append(keys, key)
+				}
+				sort.Strings(keys)
+
+				content.WriteString(fmt.Sprintf("- **%s:**\n", labels.Environment))
+				for _, key := range keys {
+					if value := svc.Environment[key]; value != "" {
+						content.WriteString(fmt.Sprintf("  - `%s=%s`\n", key, value))
+					} else {
+						content.WriteString(fmt.Sprintf("  - `%s`\n", key))
+					}
+				}
+			}
+		}
+	}
+
+	return &WikiPage{
+		ID:          ServicesPageID,
+		Title:       labels.Services,
+		Description: labels.ServicesDescription,
+		Content:     content.String(),
+		FilePaths:   filePaths,
+		Importance:  "medium",
+	}
+}
+
+// codeList formats values as a comma-separated list of inline code spans
+func codeList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = "`" + value + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
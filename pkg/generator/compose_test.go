@@ -0,0 +1,52 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kuderr/deepwiki/pkg/processor"
+	"github.com/kuderr/deepwiki/pkg/types"
+)
+
+func TestBuildServicesPage(t *testing.T) {
+	if page := BuildServicesPage(nil, types.LanguageEnglish); page != nil {
+		t.Error("Expected no page without compose projects")
+	}
+
+	page := BuildServicesPage([]processor.ComposeProject{
+		{
+			FilePath: "docker-compose.yml",
+			Services: []processor.ComposeService{
+				{
+					Name:      "api",
+					Build:     "./api",
+					Ports:     []string{"8080:80"},
+					DependsOn: []string{"db"},
+					Environment: map[string]string{
+						"LOG_LEVEL":   "debug",
+						"DB_PASSWORD": processor.RedactedValue,
+					},
+				},
+				{Name: "db", Image: "postgres:16", Ports: []string{"5432:5432"}},
+			},
+		},
+	}, types.LanguageEnglish)
+	if page == nil {
+		t.Fatal("Expected services page to be built")
+	}
+
+	for _, want := range []string{
+		"### api",
+		"### db",
+		"- **Ports:** `8080:80`",
+		"- **Ports:** `5432:5432`",
+		"- **Image:** `postgres:16`",
+		"- **Depends on:** `db`",
+		"`LOG_LEVEL=debug`",
+		"`DB_PASSWORD=" + processor.RedactedValue + "`",
+	} {
+		if !strings.Contains(page.Content, want) {
+			t.Errorf("Expected services page to contain %q", want)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/kuderr/deepwiki/pkg/generator/prompts"
+	"github.com/kuderr/deepwiki/pkg/llm"
+	"github.com/kuderr/deepwiki/pkg/rag"
+)
+
+// ErrContextOverflow is returned when a prompt does not fit the context window of the model
+var ErrContextOverflow = errors.New("prompt exceeds the model context window")
+
+// contextWindow returns the context window of the model in tokens, or 0 when it is unknown
+func (g *WikiGenerator) contextWindow(options GenerationOptions) int {
+	if options.ContextWindow > 0 {
+		return options.ContextWindow
+	}
+	return max(llm.ContextWindow(g.llmProvider.GetModel()), 0)
+}
+
+// promptBudget returns how many prompt tokens fit the context window next to a response of
+// maxTokens, or 0 when the context window is unknown and prompts are not checked
+func (g *WikiGenerator) promptBudget(options GenerationOptions, maxTokens int) int {
+	window := g.contextWindow(options)
+	if window <= 0 {
+		return 0
+	}
+	return max(window-maxTokens, 1)
+}
+
+// responseLimit returns the largest response that fits the context window next to a prompt of
+// promptTokens, or 0 when the context window is unknown
+func (g *WikiGenerator) responseLimit(options GenerationOptions, promptTokens int) int {
+	window := g.contextWindow(options)
+	if window <= 0 {
+		return 0
+	}
+	return max(window-promptTokens, 1)
+}
+
+// promptTokens counts the tokens of a prompt with the provider's counter
+func (g *WikiGenerator) promptTokens(prompt string) int {
+	tokens, err := g.llmProvider.CountTokens(prompt)
+	if err != nil {
+		return len(prompt) / 4
+	}
+	return tokens
+}
+
+// requestText joins the text of a request that takes room in the context window: the system
+// prompt and the messages
+func requestText(messages []llm.Message, options llm.ChatCompletionOptions) string {
+	texts := make([]string, 0, len(messages)+1)
+	if options.SystemPrompt != "" {
+		texts = append(texts, options.SystemPrompt)
+	}
+	for _, message := range messages {
+		texts = append(texts, message.Content)
+	}
+	return strings.Join(texts, "\n\n")
+}
+
+// checkRequestFits counts the prompt tokens of a request, system prompt included, and returns
+// ErrContextOverflow if they exceed budget. It returns the tokens counted, or 0 when budget is
+// 0 and nothing is counted.
+func (g *WikiGenerator) checkRequestFits(messages []llm.Message, options llm.ChatCompletionOptions, budget int) (
+	int, error,
+) {
+	if budget <= 0 {
+		return 0, nil
+	}
+	tokens := g.promptTokens(requestText(messages, options))
+	if tokens > budget {
+		return tokens, fmt.Errorf("%w: %d prompt tokens, %d available", ErrContextOverflow, tokens, budget)
+	}
+	return tokens, nil
+}
+
+// fitParts renders a prompt from the first parts, dropping the last ones until it fits budget
+// next to the rest of the request. The request is counted once; the parts to drop are chosen by
+// their length, at the tokens per byte of the counted request, so that trimming takes no more
+// requests to a remote token counter. It returns the prompt, the number of parts it holds and
+// its estimated tokens with the rest of the request, or 0 when budget is 0.
+func (g *WikiGenerator) fitParts(
+	parts []string,
+	minParts int,
+	rest string,
+	budget int,
+	render func(n int) (string, error),
+) (string, int, int, error) {
+	n := len(parts)
+	prompt, err := render(n)
+	if err != nil || budget <= 0 {
+		return prompt, n, 0, err
+	}
+
+	text := rest + prompt
+	tokens := g.promptTokens(text)
+	if tokens <= budget {
+		return prompt, n, tokens, nil
+	}
+
+	tokensPerByte := float64(tokens) / float64(max(len(text), 1))
+	for n > minParts && tokens > budget {
+		n--
+		tokens -= int(math.Ceil(float64(len(parts[n])) * tokensPerByte))
+	}
+	if tokens > budget {
+		return "", 0, tokens, fmt.Errorf("%w: %d prompt tokens, %d available", ErrContextOverflow, tokens, budget)
+	}
+
+	prompt, err = render(n)
+	return prompt, n, tokens, err
+}
+
+// fitPagePrompt renders the page prompt with the retrieved documents, dropping the lowest ranked
+// ones until it fits budget next to the rest of the request (the system prompt and the page
+// context prompt). It returns the prompt, the documents it includes and its estimated tokens
+// with the rest of the request.
+func (g *WikiGenerator) fitPagePrompt(
+	rest string,
+	data prompts.PageContentData,
+	docs []rag.RetrievalResult,
+	budget int,
+) (string, []rag.RetrievalResult, int, error) {
+	parts := make([]string, len(docs))
+	for i := range docs {
+		parts[i] = g.formatRelevantFiles(docs[i : i+1])
+	}
+
+	prompt, n, tokens, err := g.fitParts(parts, 0, rest, budget, func(n int) (string, error) {
+		data.RelevantFiles = g.formatRelevantFiles(docs[:n])
+		return prompts.ExecutePageContentPrompt(data)
+	})
+	if err != nil {
+		return "", nil, 0, err
+	}
+	if n < len(docs) {
+		g.logger.Warn("Trimmed retrieved documents to fit the context window",
+			"page", data.Title,
+			"kept", n,
+			"retrieved", len(docs),
+		)
+	}
+	return prompt, docs[:n], tokens, nil
+}
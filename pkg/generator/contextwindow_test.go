@@ -0,0 +1,146 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kuderr/deepwiki/pkg/llm"
+	"github.com/kuderr/deepwiki/pkg/rag"
+	"github.com/kuderr/deepwiki/pkg/types"
+)
+
+// rankedDocsRetriever returns the same ranked documents for every query
+type rankedDocsRetriever struct {
+	MockRAGRetriever
+	docs []rag.RetrievalResult
+}
+
+func (r *rankedDocsRetriever) RetrieveRelevantDocuments(ctx *rag.RetrievalContext) ([]rag.RetrievalResult, error) {
+	return slices.Clone(r.docs), nil
+}
+
+// newContextWindowGenerator returns a generator retrieving count documents of words words each;
+// the mock provider counts one token per word
+func newContextWindowGenerator(count, words int) (*WikiGenerator, *scriptedLLMProvider) {
+	retriever := &rankedDocsRetriever{}
+	for i := range count {
+		retriever.docs = append(retriever.docs, rag.RetrievalResult{
+			FilePath: fmt.Sprintf("file%d.go", i),
+			Content:  strings.Repeat("word ", words),
+		})
+	}
+	provider := &scriptedLLMProvider{responses: []llm.Choice{choice("# Overview\n\nThe overview.", "stop")}}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewWikiGenerator(provider, retriever, logger), provider
+}
+
+func TestGeneratePageContent_TrimsRetrievedDocsToFitContextWindow(t *testing.T) {
+	generator, provider := newContextWindowGenerator(5, 1000)
+
+	page := &WikiPage{ID: "overview", Title: "Overview"}
+	structure := &WikiStructure{Pages: []WikiPage{*page}}
+	options := GenerationOptions{
+		ProjectName:   "demo",
+		Language:      types.LanguageEnglish,
+		ContextWindow: defaultCompletionTokens + 3000, // Room for the prompt and two documents
+	}
+
+	if err := generator.GeneratePageContent(context.Background(), "", page, structure, options); err != nil {
+		t.Fatalf("GeneratePageContent failed: %v", err)
+	}
+
+	if len(provider.maxTokens) != 1 {
+		t.Fatalf("Expected 1 LLM call, got %d", len(provider.maxTokens))
+	}
+	if !slices.Equal(page.FilePaths, []string{"file0.go", "file1.go"}) || page.SourceFiles != 2 {
+		t.Errorf("Expected the two best ranked documents to be kept, got %v", page.FilePaths)
+	}
+}
+
+func TestGeneratePageContent_CountsTokensOnce(t *testing.T) {
+	generator, provider := newContextWindowGenerator(20, 200)
+
+	page := &WikiPage{ID: "overview", Title: "Overview"}
+	structure := &WikiStructure{Pages: []WikiPage{*page}}
+	options := GenerationOptions{
+		ProjectName:   "demo",
+		Language:      types.LanguageEnglish,
+		ContextWindow: defaultCompletionTokens + 1500,
+	}
+
+	if err := generator.GeneratePageContent(context.Background(), "", page, structure, options); err != nil {
+		t.Fatalf("GeneratePageContent failed: %v", err)
+	}
+
+	if provider.tokenCounts != 1 {
+		t.Errorf("Expected tokens to be counted once, got %d", provider.tokenCounts)
+	}
+	if len(page.FilePaths) == 0 || len(page.FilePaths) >= 20 {
+		t.Errorf("Expected the retrieved documents to be trimmed, got %v", page.FilePaths)
+	}
+}
+
+func TestCheckRequestFits_CountsSystemPrompt(t *testing.T) {
+	generator, _ := newContextWindowGenerator(0, 0)
+
+	messages := []llm.Message{{Role: "user", Content: strings.Repeat("word ", 50)}}
+	options := llm.ChatCompletionOptions{SystemPrompt: strings.Repeat("rule ", 100)}
+
+	tokens, err := generator.checkRequestFits(messages, options, 120)
+	if !errors.Is(err, ErrContextOverflow) {
+		t.Fatalf("Expected ErrContextOverflow, got %v", err)
+	}
+	if tokens != 150 {
+		t.Errorf("Expected 150 tokens, got %d", tokens)
+	}
+}
+
+func TestCompleteCheckedChat_RetriesWithinContextWindow(t *testing.T) {
+	generator, provider := newScriptedGenerator(
+		choice("# Overview\n\nThe project", "length"),
+		choice("# Overview\n\nThe project overview", "length"),
+		choice("# Overview\n\nThe project overview.", "stop"),
+	)
+
+	messages := []llm.Message{{Role: "user", Content: "Write the overview page"}}
+	_, err := generator.completeCheckedChat(context.Background(), messages,
+		llm.ChatCompletionOptions{MaxTokens: 4000}, 5000, nil)
+	if err == nil {
+		t.Fatal("Expected an error for a response truncated at the context window")
+	}
+
+	if !slices.Equal(provider.maxTokens, []int{4000, 5000}) {
+		t.Errorf("Expected token budgets [4000 5000], got %v", provider.maxTokens)
+	}
+}
+
+func TestGeneratePageContent_ContextOverflow(t *testing.T) {
+	generator, provider := newContextWindowGenerator(2, 100)
+
+	page := &WikiPage{ID: "overview", Title: "Overview"}
+	structure := &WikiStructure{Pages: []WikiPage{*page}}
+	options := GenerationOptions{
+		ProjectName:   "demo",
+		Language:      types.LanguageEnglish,
+		ContextWindow: defaultCompletionTokens + 50, // Too small even without documents
+	}
+
+	err := generator.GeneratePageContent(context.Background(), "", page, structure, options)
+	if !errors.Is(err, ErrContextOverflow) {
+		t.Fatalf("Expected ErrContextOverflow, got %v", err)
+	}
+	if len(provider.maxTokens) != 0 {
+		t.Errorf("Expected no LLM call, got %d", len(provider.maxTokens))
+	}
+
+	_, err = generator.GenerateWikiStructure(context.Background(), strings.Repeat("file.go\n", 100), "", options)
+	if !errors.Is(err, ErrContextOverflow) {
+		t.Errorf("Expected ErrContextOverflow for the structure prompt, got %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package generator
+
+import (
+	"slices"
+	"strings"
+)
+
+const (
+	// duplicatePageThreshold is the shingle similarity at which two pages count as duplicates
+	duplicatePageThreshold = 0.8
+
+	// shingleSize is the number of consecutive words in a shingle
+	shingleSize = 3
+)
+
+// collapseDuplicatePages drops generated pages that nearly duplicate another page and rebuilds
+// the pages of result from the remaining structure
+func (g *WikiGenerator) collapseDuplicatePages(structure *WikiStructure, result *GenerationResult) {
+	dropped := dedupPages(structure, duplicatePageThreshold)
+	if len(dropped) == 0 {
+		return
+	}
+
+	for id, kept := range dropped {
+		g.logger.Info("Collapsed duplicate page", "page", id, "into", kept)
+	}
+
+	pages := make(map[string]*WikiPage, len(result.Pages))
+	result.TotalWords = 0
+	for i := range structure.Pages {
+		page := &structure.Pages[i]
+		if _, ok := result.Pages[page.ID]; ok {
+			pages[page.ID] = page
+			result.TotalWords += page.WordCount
+		}
+	}
+	result.Pages = pages
+}
+
+// dedupPages removes generated pages whose content is at least threshold similar to another
+// page. Of each duplicate pair the more important page is kept, or the longer one on a tie; it
+// takes over the file paths and related pages of the dropped page, and references to the
+// dropped page are redirected to it. Pages without content or copied from existing docs are
+// left alone. It returns the IDs of the dropped pages mapped to the pages that replaced them.
+func dedupPages(structure *WikiStructure, threshold float64) map[string]string {
+	pages := structure.Pages
+	shingles := make([]map[string]bool, len(pages))
+	for i, page := range pages {
+		if page.Content != "" && page.SourceDoc == "" {
+			shingles[i] = shingleSet(page.Content)
+		}
+	}
+
+	dropped := make(map[string]string)
+	removed := make([]bool, len(pages))
+	for i := range pages {
+		for j := i + 1; j < len(pages) && !removed[i]; j++ {
+			if removed[j] || shingles[i] == nil || shingles[j] == nil {
+				continue
+			}
+			if jaccard(shingles[i], shingles[j]) < threshold {
+				continue
+			}
+
+			keep, drop := i, j
+			if strongerPage(pages[j], pages[i]) {
+				keep, drop = j, i
+			}
+			mergePage(&pages[keep], pages[drop])
+			removed[drop] = true
+			dropped[pages[drop].ID] = pages[keep].ID
+		}
+	}
+	if len(dropped) == 0 {
+		return nil
+	}
+
+	// A page may have been dropped in favor of a page that was dropped later
+	resolve := func(id string) string {
+		for {
+			next, ok := dropped[id]
+			if !ok {
+				return id
+			}
+			id = next
+		}
+	}
+	for id := range dropped {
+		dropped[id] = resolve(id)
+	}
+
+	remaining := make([]WikiPage, 0, len(pages)-len(dropped))
+	for i, page := range pages {
+		if removed[i] {
+			continue
+		}
+		if page.ParentID != "" {
+			if page.ParentID = resolve(page.ParentID); page.ParentID == page.ID {
+				page.ParentID = ""
+			}
+		}
+		page.RelatedPages = redirectRelatedPages(page.ID, page.RelatedPages, resolve)
+		remaining = append(remaining, page)
+	}
+	structure.Pages = remaining
+
+	return dropped
+}
+
+// strongerPage reports whether a should be kept over b
+func strongerPage(a, b WikiPage) bool {
+	if rank := importanceRank(a.Importance) - importanceRank(b.Importance); rank != 0 {
+		return rank > 0
+	}
+	return a.WordCount > b.WordCount
+}
+
+// importanceRank orders importance levels from low to high
+func importanceRank(importance string) int {
+	switch importance {
+	case "high":
+		return 2
+	case "medium":
+		return 1
+	default:
+		return 0
+	}
+}
+
+// mergePage adds the file paths and related pages of duplicate to page
+func mergePage(page *WikiPage, duplicate WikiPage) {
+	for _, path := range duplicate.FilePaths {
+		if !slices.Contains(page.FilePaths, path) {
+			page.FilePaths = append(page.FilePaths, path)
+		}
+	}
+	page.SourceFiles = max(page.SourceFiles, len(page.FilePaths))
+
+	page.RelatedPages = append(page.RelatedPages, duplicate.RelatedPages...)
+}
+
+// redirectRelatedPages resolves the related pages of page id, dropping repeats and self references
+func redirectRelatedPages(id string, related []string, resolve func(string) string) []string {
+	if len(related) == 0 {
+		return related
+	}
+
+	redirected := make([]string, 0, len(related))
+	for _, relatedID := range related {
+		relatedID = resolve(relatedID)
+		if relatedID != id && !slices.Contains(redirected, relatedID) {
+			redirected = append(redirected, relatedID)
+		}
+	}
+	return redirected
+}
+
+// shingleSet returns the set of lowercase word shingles in content
+func shingleSet(content string) map[string]bool {
+	words := strings.Fields(strings.ToLower(content))
+	set := make(map[string]bool)
+	if len(words) < shingleSize {
+		set[strings.Join(words, " ")] = true
+		return set
+	}
+	for i := 0; i+shingleSize <= len(words); i++ {
+		set[strings.Join(words[i:i+shingleSize], " ")] = true
+	}
+	return set
+}
+
+// jaccard returns the Jaccard similarity of two sets
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+
+	shared := 0
+	for shingle := range a {
+		if b[shingle] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
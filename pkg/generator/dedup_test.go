@@ -0,0 +1,108 @@
+package generator
+
+import (
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const storageContent = `# Storage
+
+The storage layer persists documents in a BoltDB file. Each document is split into chunks,
+and every chunk is stored with its embedding so that retrieval can rank chunks by similarity.
+Writes are serialized through a single transaction per batch, and reads run concurrently.`
+
+func TestDedupPages_CollapsesNearDuplicates(t *testing.T) {
+	structure := &WikiStructure{
+		Pages: []WikiPage{
+			{ID: "overview", Title: "Overview", Content: "# Overview\n\nA tool that writes wikis for code.",
+				Importance: "high", RelatedPages: []string{"storage-layer"}},
+			{ID: "storage", Title: "Storage", Content: storageContent, Importance: "medium",
+				ParentID: "overview", FilePaths: []string{"pkg/vectordb/vectordb.go"}, SourceFiles: 1,
+				RelatedPages: []string{"overview", "storage-layer"}},
+			{ID: "storage-layer", Title: "Storage Layer", Content: storageContent + "\nIt is fast.",
+				Importance: "high", ParentID: "overview",
+				FilePaths: []string{"pkg/vectordb/vectordb.go", "pkg/vectordb/types.go"}, SourceFiles: 2,
+				RelatedPages: []string{"storage", "api"}},
+			{ID: "api", Title: "API", Content: "# API\n\nThe HTTP API serves generated pages.",
+				Importance: "medium", RelatedPages: []string{"storage"}},
+		},
+	}
+
+	dropped := dedupPages(structure, duplicatePageThreshold)
+
+	if len(dropped) != 1 || dropped["storage"] != "storage-layer" {
+		t.Fatalf("Expected storage to collapse into storage-layer, got %v", dropped)
+	}
+	if len(structure.Pages) != 3 {
+		t.Fatalf("Expected 3 pages, got %d", len(structure.Pages))
+	}
+
+	var kept WikiPage
+	for _, page := range structure.Pages {
+		if page.ID == "storage-layer" {
+			kept = page
+		}
+	}
+	wantPaths := []string{"pkg/vectordb/vectordb.go", "pkg/vectordb/types.go"}
+	if !slices.Equal(kept.FilePaths, wantPaths) || kept.SourceFiles != 2 {
+		t.Errorf("Expected merged file paths %v, got %v (%d source files)", wantPaths, kept.FilePaths, kept.SourceFiles)
+	}
+	if !slices.Equal(kept.RelatedPages, []string{"api", "overview"}) {
+		t.Errorf("Expected related pages [api overview], got %v", kept.RelatedPages)
+	}
+
+	api := structure.Pages[2]
+	if !slices.Equal(api.RelatedPages, []string{"storage-layer"}) {
+		t.Errorf("Expected links to the dropped page to be redirected, got %v", api.RelatedPages)
+	}
+}
+
+func TestDedupPages_KeepsDistinctPages(t *testing.T) {
+	structure := &WikiStructure{
+		Pages: []WikiPage{
+			{ID: "storage", Content: storageContent},
+			{ID: "guide", Content: "# Guide\n\nRun the generate command in a project to write its wiki."},
+			{ID: "failed"}, // Pages without content are never compared
+			{ID: "failed-too"},
+		},
+	}
+
+	if dropped := dedupPages(structure, duplicatePageThreshold); dropped != nil {
+		t.Errorf("Expected no pages to be dropped, got %v", dropped)
+	}
+	if len(structure.Pages) != 4 {
+		t.Errorf("Expected all 4 pages to remain, got %d", len(structure.Pages))
+	}
+}
+
+func TestCollapseDuplicatePages_RebuildsResult(t *testing.T) {
+	g := NewWikiGenerator(nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	structure := &WikiStructure{
+		Pages: []WikiPage{
+			{ID: "storage", Content: storageContent, Importance: "high"},
+			{ID: "failed", Importance: "low"},
+			{ID: "storage-copy", Content: strings.ToUpper(storageContent), Importance: "low"},
+		},
+	}
+	result := &GenerationResult{Pages: make(map[string]*WikiPage)}
+	for i := range structure.Pages {
+		page := &structure.Pages[i]
+		page.WordCount = len(strings.Fields(page.Content))
+		if page.Content != "" {
+			result.Pages[page.ID] = page
+			result.TotalWords += page.WordCount
+		}
+	}
+
+	g.collapseDuplicatePages(structure, result)
+
+	if len(result.Pages) != 1 || result.Pages["storage"] != &structure.Pages[0] {
+		t.Fatalf("Expected only the storage page in the result, got %v", result.Pages)
+	}
+	if result.TotalWords != structure.Pages[0].WordCount {
+		t.Errorf("Expected %d total words, got %d", structure.Pages[0].WordCount, result.TotalWords)
+	}
+}
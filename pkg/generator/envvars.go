@@ -0,0 +1,137 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/kuderr/deepwiki/pkg/scanner"
+	"github.com/kuderr/deepwiki/pkg/types"
+)
+
+// EnvVarsPageID is the ID of the generated environment variables page
+const EnvVarsPageID = "environment-variables"
+
+// EnvVar is an environment variable read by the project
+type EnvVar struct {
+	Name       string           `json:"name"`
+	References []SourceLocation `json:"references"`
+}
+
+// envVarPatterns match environment variable reads across supported languages.
+// The first capture group is the variable name.
+var envVarPatterns = []*regexp.Regexp{
+	// Go
+	regexp.MustCompile(`os\.(?:Getenv|LookupEnv)\(\s*"([A-Za-z_][A-Za-z0-9_]*)"`),
+	// JavaScript / TypeScript
+	regexp.MustCompile(`process\.env\.([A-Za-z_][A-Za-z0-9_]*)`),
+	regexp.MustCompile(`process\.env\[\s*['"]([A-Za-z_][A-Za-z0-9_]*)['"]\s*\]`),
+	// Python
+	regexp.MustCompile(`os\.environ\[\s*['"]([A-Za-z_][A-Za-z0-9_]*)['"]\s*\]`),
+	regexp.MustCompile(`os\.(?:environ\.get|getenv)\(\s*['"]([A-Za-z_][A-Za-z0-9_]*)['"]`),
+	// Ruby
+	regexp.MustCompile(`ENV(?:\.fetch\(|\[)\s*['"]([A-Za-z_][A-Za-z0-9_]*)['"]`),
+	// Rust
+	regexp.MustCompile(`env::var(?:_os)?\(\s*"([A-Za-z_][A-Za-z0-9_]*)"`),
+	// Java / Kotlin
+	regexp.MustCompile(`System\.getenv\(\s*"([A-Za-z_][A-Za-z0-9_]*)"`),
+}
+
+// envVarCategories are the file categories scanned for environment variable reads
+var envVarCategories = map[string]bool{
+	string(scanner.CategoryCode):   true,
+	string(scanner.CategoryConfig): true,
+	string(scanner.CategoryBuild):  true,
+}
+
+// ExtractEnvVars finds environment variables read by the scanned files, sorted by name
+func ExtractEnvVars(files []scanner.FileInfo) []EnvVar {
+	byName := make(map[string]*EnvVar)
+
+	for _, file := range files {
+		if file.IsDir || file.IsBinary || !envVarCategories[file.Category] {
+			continue
+		}
+
+		content, err := readSourceFile(file)
+		if err != nil {
+			continue
+		}
+
+		for lineNum, line := range strings.Split(content, "\n") {
+			seen := make(map[string]bool)
+			for _, pattern := range envVarPatterns {
+				for _, match := range pattern.FindAllStringSubmatch(line, -1) {
+					name := match[1]
+					if seen[name] {
+						continue
+					}
+					seen[name] = true
+
+					envVar, ok := byName[name]
+					if !ok {
+						envVar = &EnvVar{Name: name}
+						byName[name] = envVar
+					}
+					envVar.References = append(envVar.References, SourceLocation{
+						FilePath: file.Path,
+						Line:     lineNum + 1,
+					})
+				}
+			}
+		}
+	}
+
+	envVars := make([]EnvVar, 0, len(byName))
+	for _, envVar := range byName {
+		envVars = append(envVars, *envVar)
+	}
+	sort.Slice(envVars, func(i, j int) bool {
+		return envVars[i].Name < envVars[j].Name
+	})
+
+	return envVars
+}
+
+// BuildEnvVarsPage renders the environment variables page in language, or returns nil if there
+// are none
+func BuildEnvVarsPage(envVars []EnvVar, language types.Language) *WikiPage {
+	if len(envVars) == 0 {
+		return nil
+	}
+
+	labels := labelsFor(language)
+	var content strings.Builder
+	fmt.Fprintf(&content, "# %s\n\n%s\n\n", labels.EnvVars, labels.EnvVarsIntro)
+	content.WriteString(tableHeader(labels.Variable, labels.ReferencedIn))
+
+	filePaths := make(map[string]bool)
+	for _, envVar := range envVars {
+		refs := make([]string, 0, len(envVar.References))
+		for _, ref := range envVar.References {
+			refs = append(refs, fmt.Sprintf("`%s:%d`", ref.FilePath, ref.Line))
+			filePaths[ref.FilePath] = true
+		}
+		content.WriteString(fmt.Sprintf("| `%s` | %s |\n", envVar.Name, strings.Join(refs, ", ")))
+	}
+
+	return &WikiPage{
+		ID:          EnvVarsPageID,
+		Title:       labels.EnvVars,
+		Description: labels.EnvVarsDescription,
+		Content:     content.String(),
+		FilePaths:   sortedKeys(filePaths),
+		Importance:  "medium",
+	}
+}
+
+// sortedKeys returns the keys of a set in sorted order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
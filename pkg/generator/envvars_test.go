@@ -0,0 +1,146 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kuderr/deepwiki/pkg/scanner"
+	"github.com/kuderr/deepwiki/pkg/types"
+)
+
+func writeFixture(t *testing.T, dir, name, content, category string) scanner.FileInfo {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("Failed to create fixture dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write fixture %s: %v", name, err)
+	}
+
+	return scanner.FileInfo{
+		Path:         name,
+		AbsolutePath: path,
+		Name:         filepath.Base(name),
+		IsText:       true,
+		Category:     category,
+	}
+}
+
+func TestExtractEnvVars(t *testing.T) {
+	dir := t.TempDir()
+
+	files := []scanner.FileInfo{
+		writeFixture(t, dir, "main.go", `package main
+
+import "os"
+
+func main() {
+	addr := os.Getenv("FOO")
+	if v, ok := os.LookupEnv("BAR_URL"); ok {
+		_ = v
+	}
+	_ = os.Getenv("FOO")
+}
+`, "code"),
+		writeFixture(t, dir, "web/app.js", `const port = process.env.PORT || 3000;
+const key = process.env["API_KEY"];
+`, "code"),
+		writeFixture(t, dir, "tools/run.py", `import os
+debug = os.environ.get("DEBUG")
+home = os.environ["APP_HOME"]
+`, "code"),
+		writeFixture(t, dir, "docs/usage.md", "Set os.Getenv(\"DOCS_ONLY\") in docs\n", "docs"),
+	}
+
+	envVars := ExtractEnvVars(files)
+
+	byName := make(map[string]EnvVar)
+	for _, envVar := range envVars {
+		byName[envVar.Name] = envVar
+	}
+
+	for _, name := range []string{"FOO", "BAR_URL", "PORT", "API_KEY", "DEBUG", "APP_HOME"} {
+		if _, ok := byName[name]; !ok {
+			t.Errorf("Expected env var %s to be detected", name)
+		}
+	}
+
+	if _, ok := byName["DOCS_ONLY"]; ok {
+		t.Error("Documentation files should not be scanned for env vars")
+	}
+
+	foo := byName["FOO"]
+	if len(foo.References) != 2 {
+		t.Fatalf("Expected 2 references to FOO, got %d", len(foo.References))
+	}
+	if foo.References[0].FilePath != "main.go" || foo.References[0].Line != 6 {
+		t.Errorf("Unexpected first FOO reference: %+v", foo.References[0])
+	}
+
+	for i := 1; i < len(envVars); i++ {
+		if envVars[i-1].Name > envVars[i].Name {
+			t.Errorf("Env vars should be sorted by name, got %s before %s", envVars[i-1].Name, envVars[i].Name)
+		}
+	}
+}
+
+func TestBuildEnvVarsPage(t *testing.T) {
+	if page := BuildEnvVarsPage(nil, types.LanguageEnglish); page != nil {
+		t.Error("Expected no page when no env vars were found")
+	}
+
+	page := BuildEnvVarsPage([]EnvVar{
+		{Name: "FOO", References: []SourceLocation{{FilePath: "main.go", Line: 6}}},
+	}, types.LanguageEnglish)
+	if page == nil {
+		t.Fatal("Expected page to be built")
+	}
+
+	if page.ID != EnvVarsPageID {
+		t.Errorf("Expected page ID %s, got %s", EnvVarsPageID, page.ID)
+	}
+	if !strings.Contains(page.Content, "`FOO`") {
+		t.Error("Expected page to list FOO")
+	}
+	if !strings.Contains(page.Content, "`main.go:6`") {
+		t.Error("Expected page to reference main.go:6")
+	}
+	if len(page.FilePaths) != 1 || page.FilePaths[0] != "main.go" {
+		t.Errorf("Expected FilePaths [main.go], got %v", page.FilePaths)
+	}
+}
+
+func TestAddGeneratedPage(t *testing.T) {
+	structure := &WikiStructure{Pages: []WikiPage{{ID: "overview", Title: "Overview"}}}
+	result := &GenerationResult{Pages: map[string]*WikiPage{}}
+	result.Pages["overview"] = &structure.Pages[0]
+
+	addGeneratedPage(structure, result, &WikiPage{
+		ID:        "extra",
+		Title:     "Extra",
+		Content:   "one two three",
+		FilePaths: []string{"a.go"},
+	})
+
+	if len(structure.Pages) != 2 {
+		t.Fatalf("Expected 2 pages in structure, got %d", len(structure.Pages))
+	}
+	if result.TotalPages != 2 {
+		t.Errorf("Expected TotalPages 2, got %d", result.TotalPages)
+	}
+	if result.Pages["overview"] != &structure.Pages[0] {
+		t.Error("Existing page pointers should refer to the structure's pages")
+	}
+
+	extra := result.Pages["extra"]
+	if extra == nil || extra.WordCount != 3 || extra.SourceFiles != 1 {
+		t.Errorf("Unexpected generated page: %+v", extra)
+	}
+	if extra.CreatedAt.IsZero() {
+		t.Error("Expected CreatedAt to be set")
+	}
+}
@@ -0,0 +1,169 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/kuderr/deepwiki/pkg/generator/prompts"
+	"github.com/kuderr/deepwiki/pkg/scanner"
+)
+
+// ExistingDoc is a handwritten markdown document found in the project
+type ExistingDoc struct {
+	FilePath string `json:"filePath"`
+	Title    string `json:"title"`
+	Content  string `json:"content"`
+}
+
+// ExtractExistingDocs reads the project's markdown documents. The root README is skipped
+// because it already informs the wiki structure, and so are the pages of earlier runs: the files
+// under options.OutputDirectory, unless it holds the whole project, and options.OutputFiles.
+func ExtractExistingDocs(files []scanner.FileInfo, options GenerationOptions) []ExistingDoc {
+	generated := newGeneratedFiles(options)
+
+	var docs []ExistingDoc
+	for _, file := range files {
+		if file.Extension != ".md" || isRootReadme(file.Path) || generated.contains(file, options.ProjectPath) {
+			continue
+		}
+
+		content, err := readSourceFile(file)
+		if err != nil || strings.TrimSpace(content) == "" {
+			continue
+		}
+
+		path := filepath.ToSlash(file.Path)
+		docs = append(docs, ExistingDoc{
+			FilePath: path,
+			Title:    markdownTitle(content, path),
+			Content:  content,
+		})
+	}
+	return docs
+}
+
+// generatedFiles are the files written by earlier runs: a directory and a list of files
+type generatedFiles struct {
+	directory string
+	files     map[string]bool
+}
+
+// newGeneratedFiles resolves the output directory and files of options to absolute paths
+func newGeneratedFiles(options GenerationOptions) generatedFiles {
+	generated := generatedFiles{files: make(map[string]bool)}
+	for _, path := range options.OutputFiles {
+		if abs, err := filepath.Abs(path); err == nil {
+			generated.files[abs] = true
+		}
+	}
+
+	if options.OutputDirectory == "" {
+		return generated
+	}
+	directory, err := filepath.Abs(options.OutputDirectory)
+	if err != nil {
+		return generated
+	}
+	// An output directory holding the project would exclude every document
+	if project, err := filepath.Abs(options.ProjectPath); err == nil && withinDir(project, directory) {
+		return generated
+	}
+	generated.directory = directory
+	return generated
+}
+
+// contains reports whether a scanned file was written by an earlier run
+func (g generatedFiles) contains(file scanner.FileInfo, projectPath string) bool {
+	path := file.AbsolutePath
+	if path == "" {
+		path = filepath.Join(projectPath, file.Path)
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	return g.files[path] || (g.directory != "" && withinDir(path, g.directory))
+}
+
+// withinDir reports whether path is dir or lies under it
+func withinDir(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// isRootReadme reports whether path is the README at the project root
+func isRootReadme(path string) bool {
+	return filepath.Dir(path) == "." && strings.HasPrefix(strings.ToLower(filepath.Base(path)), "readme")
+}
+
+// markdownTitle returns the first top-level heading of a document, or its file name
+func markdownTitle(content, path string) string {
+	for _, line := range strings.Split(content, "\n") {
+		if title, ok := strings.CutPrefix(strings.TrimSpace(line), "# "); ok && strings.TrimSpace(title) != "" {
+			return strings.TrimSpace(title)
+		}
+	}
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+// existingDocSummaries lists the documents for the structure prompt
+func existingDocSummaries(docs []ExistingDoc) []prompts.ExistingDocSummary {
+	summaries := make([]prompts.ExistingDocSummary, len(docs))
+	for i, doc := range docs {
+		summaries[i] = prompts.ExistingDocSummary{Path: doc.FilePath, Title: doc.Title}
+	}
+	return summaries
+}
+
+// mergeExistingDocs fills the pages the LLM mapped to existing documents with their content
+// and adds a top-level page for every document it did not place
+func (g *WikiGenerator) mergeExistingDocs(structure *WikiStructure, docs []ExistingDoc) {
+	byPath := make(map[string]ExistingDoc, len(docs))
+	for _, doc := range docs {
+		byPath[doc.FilePath] = doc
+	}
+
+	placed := make(map[string]bool)
+	for i := range structure.Pages {
+		page := &structure.Pages[i]
+		if page.SourceDoc == "" {
+			continue
+		}
+
+		doc, ok := byPath[filepath.ToSlash(page.SourceDoc)]
+		if !ok || placed[doc.FilePath] {
+			// Unknown or duplicate documents are written like any other page
+			g.logger.Warn("Ignoring unknown or already placed document", "page", page.ID, "source_doc", page.SourceDoc)
+			page.SourceDoc = ""
+			continue
+		}
+
+		fillFromExistingDoc(page, doc)
+		placed[doc.FilePath] = true
+	}
+
+	for _, doc := range docs {
+		if placed[doc.FilePath] {
+			continue
+		}
+
+		page := WikiPage{
+			ID:          generateID("doc", strings.TrimSuffix(doc.FilePath, filepath.Ext(doc.FilePath))),
+			Title:       doc.Title,
+			Description: fmt.Sprintf("Existing documentation from %s", doc.FilePath),
+			Importance:  "medium",
+		}
+		fillFromExistingDoc(&page, doc)
+		structure.Pages = append(structure.Pages, page)
+	}
+}
+
+// fillFromExistingDoc uses a handwritten document as the page content
+func fillFromExistingDoc(page *WikiPage, doc ExistingDoc) {
+	page.SourceDoc = doc.FilePath
+	page.Content = doc.Content
+	page.FilePaths = []string{doc.FilePath}
+	page.WordCount = len(strings.Fields(doc.Content))
+	page.SourceFiles = 1
+}
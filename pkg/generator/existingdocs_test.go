@@ -0,0 +1,182 @@
+package generator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kuderr/deepwiki/pkg/scanner"
+	"github.com/kuderr/deepwiki/pkg/types"
+)
+
+const mergedStructureResponse = `<wiki_structure>
+  <title>demo Documentation</title>
+  <description>The demo project</description>
+  <pages>
+    <page>
+      <id>overview</id>
+      <title>Overview</title>
+      <description>What the project does</description>
+      <importance>high</importance>
+    </page>
+    <page>
+      <id>user-guide</id>
+      <title>User Guide</title>
+      <description>How to use the project</description>
+      <importance>medium</importance>
+      <parent_id>overview</parent_id>
+      <source_doc>docs/guide.md</source_doc>
+    </page>
+  </pages>
+</wiki_structure>`
+
+func writeExistingDocsProject(t *testing.T) []scanner.FileInfo {
+	t.Helper()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"README.md":     "# Demo\n\nThe demo project.\n",
+		"docs/guide.md": "# User Guide\n\nRun `demo serve` to start.\n",
+		"docs/faq.md":   "# FAQ\n\nAnswers to common questions.\n",
+		"main.go":       "package main\n",
+	}
+
+	var infos []scanner.FileInfo
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		infos = append(infos, scanner.FileInfo{
+			Path:         name,
+			AbsolutePath: path,
+			Name:         filepath.Base(name),
+			Extension:    filepath.Ext(name),
+		})
+	}
+	return infos
+}
+
+func TestGenerateWiki_MergesExistingDocs(t *testing.T) {
+	files := writeExistingDocsProject(t)
+	generator, provider := newScriptedGenerator(
+		choice(mergedStructureResponse, "stop"),
+		choice("# Overview\n\nThe project overview.", "stop"),
+	)
+
+	options := GenerationOptions{ProjectName: "demo", Language: types.LanguageEnglish, MergeExistingDocs: true}
+	result, err := generator.GenerateWiki(context.Background(), files, options)
+	if err != nil {
+		t.Fatalf("GenerateWiki failed: %v", err)
+	}
+
+	if len(provider.maxTokens) != 2 {
+		t.Errorf("Expected LLM calls for the structure and the overview only, got %d", len(provider.maxTokens))
+	}
+
+	guide, ok := result.Pages["user-guide"]
+	if !ok {
+		t.Fatalf("Expected docs/guide.md as a page, got %v", result.Structure.Pages)
+	}
+	if guide.ParentID != "overview" || guide.SourceDoc != "docs/guide.md" {
+		t.Errorf("Expected the guide under overview from docs/guide.md, got parent %q source %q",
+			guide.ParentID, guide.SourceDoc)
+	}
+	if !strings.Contains(guide.Content, "Run `demo serve` to start.") {
+		t.Errorf("Expected the guide content as written, got %q", guide.Content)
+	}
+
+	// Documents the LLM did not place are still included
+	faq, ok := result.Pages["doc-docs-faq"]
+	if !ok {
+		t.Fatalf("Expected docs/faq.md as a top-level page, got %v", result.Structure.Pages)
+	}
+	if faq.Title != "FAQ" || faq.ParentID != "" {
+		t.Errorf("Expected a top-level FAQ page, got title %q parent %q", faq.Title, faq.ParentID)
+	}
+
+	for _, page := range result.Structure.Pages {
+		if page.SourceDoc == "README.md" {
+			t.Error("Expected the root README not to be merged as a page")
+		}
+	}
+	if result.TotalPages != 3 {
+		t.Errorf("Expected 3 pages, got %d", result.TotalPages)
+	}
+}
+
+func TestGenerateWiki_IgnoresExistingDocsByDefault(t *testing.T) {
+	files := writeExistingDocsProject(t)
+	generator, _ := newScriptedGenerator(
+		choice(mergedStructureResponse, "stop"),
+		choice("# Page\n\nGenerated content.", "stop"),
+	)
+
+	options := GenerationOptions{ProjectName: "demo", Language: types.LanguageEnglish}
+	result, err := generator.GenerateWiki(context.Background(), files, options)
+	if err != nil {
+		t.Fatalf("GenerateWiki failed: %v", err)
+	}
+
+	if _, ok := result.Pages["doc-docs-faq"]; ok {
+		t.Error("Expected existing docs not to be merged without MergeExistingDocs")
+	}
+	if guide := result.Pages["user-guide"]; guide == nil || guide.Content != "# Page\n\nGenerated content." {
+		t.Errorf("Expected the guide page to be generated, got %+v", guide)
+	}
+}
+
+func TestExtractExistingDocs_SkipsEarlierOutput(t *testing.T) {
+	files := writeExistingDocsProject(t)
+	project := strings.TrimSuffix(files[0].AbsolutePath, filepath.FromSlash(files[0].Path))
+	for name, content := range map[string]string{
+		"wiki/overview.md": "# Overview\n\nGenerated by an earlier run.\n",
+		"notes/api.md":     "# API\n\nGenerated by an earlier run into the project.\n",
+	} {
+		path := filepath.Join(project, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		files = append(files, scanner.FileInfo{Path: name, AbsolutePath: path, Extension: ".md"})
+	}
+
+	tests := []struct {
+		name    string
+		options GenerationOptions
+		want    []string
+	}{
+		{
+			name: "output directory and manifest files",
+			options: GenerationOptions{ProjectPath: project, OutputDirectory: filepath.Join(project, "wiki"),
+				OutputFiles: []string{filepath.Join(project, "notes", "api.md")}},
+			want: []string{"docs/faq.md", "docs/guide.md"},
+		},
+		{
+			name:    "output directory holding the project",
+			options: GenerationOptions{ProjectPath: project, OutputDirectory: project},
+			want:    []string{"docs/faq.md", "docs/guide.md", "notes/api.md", "wiki/overview.md"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, doc := range ExtractExistingDocs(files, tt.options) {
+				got = append(got, doc.FilePath)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Expected existing docs %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
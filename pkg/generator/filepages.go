@@ -0,0 +1,194 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kuderr/deepwiki/pkg/generator/prompts"
+	"github.com/kuderr/deepwiki/pkg/llm"
+	"github.com/kuderr/deepwiki/pkg/scanner"
+)
+
+const (
+	// fileSummaryTokens is the token budget of the summary of a file page
+	fileSummaryTokens = 512
+	// maxFileSummaryBytes caps the source quoted in a file summary prompt
+	maxFileSummaryBytes = 32 * 1024
+)
+
+// GenerateFilePages generates one page per text file instead of planning a wiki: each page has
+// a short LLM summary of the file followed by its source. No structure is generated and no
+// documents are retrieved. A file whose summary cannot be generated keeps its page without one.
+func (g *WikiGenerator) GenerateFilePages(
+	ctx context.Context,
+	files []scanner.FileInfo,
+	options GenerationOptions,
+) (*GenerationResult, error) {
+	result := &GenerationResult{
+		GeneratedAt: time.Now(),
+		Pages:       make(map[string]*WikiPage),
+	}
+
+	start := time.Now()
+	defer func() {
+		result.ProcessingTime = time.Since(start)
+	}()
+
+	if options.ProgressTracker == nil {
+		options.ProgressTracker = &NoOpProgressTracker{}
+	}
+
+	structure := &WikiStructure{
+		ID:          generateID("wiki", options.ProjectName),
+		Title:       options.ProjectName,
+		Description: fmt.Sprintf("Summary and source of each file of %s", options.ProjectName),
+		Language:    options.Language,
+		ProjectPath: options.ProjectPath,
+		Version:     "1.0",
+		CreatedAt:   time.Now(),
+	}
+	result.Structure = structure
+
+	options.ProgressTracker.StartTask("Generating file pages", len(files))
+	for i, file := range files {
+		if file.IsDir || file.IsBinary || !file.IsText {
+			continue
+		}
+		options.ProgressTracker.UpdateProgress(i, fmt.Sprintf("Summarizing: %s", file.Path))
+
+		content, err := readSourceFile(file)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("failed to read %s: %w", file.Path, err))
+			continue
+		}
+		if strings.TrimSpace(content) == "" {
+			continue // Skip empty files
+		}
+
+		summary, err := g.summarizeFile(ctx, file, content, options)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("failed to summarize %s: %w", file.Path, err))
+			g.logger.Warn("File summary failed", "file", file.Path, "error", err)
+		}
+
+		page := BuildFilePage(file, content, summary, options)
+		page.ID = uniquePageID(page.ID, result.Pages)
+		page.Slug = page.ID
+		addGeneratedPage(structure, result, page)
+	}
+	options.ProgressTracker.CompleteTask(fmt.Sprintf("Generated %d file pages", result.TotalPages))
+
+	g.logger.Info("File page generation completed",
+		"total_pages", result.TotalPages,
+		"total_words", result.TotalWords,
+		"errors", len(result.Errors),
+		"duration", time.Since(start))
+
+	return result, nil
+}
+
+// BuildFilePage renders the page of a file in the language of options: its path as the title, the
+// summary, when there is one, and the source in a fenced code block
+func BuildFilePage(file scanner.FileInfo, content, summary string, options GenerationOptions) *WikiPage {
+	filePath := filepath.ToSlash(file.Path)
+	labels := labelsFor(options.Language)
+
+	var page strings.Builder
+	page.WriteString(fmt.Sprintf("# %s\n\n", filePath))
+	if summary != "" {
+		page.WriteString(fmt.Sprintf("## %s\n\n", labels.Summary))
+		page.WriteString(summary)
+		page.WriteString("\n\n")
+	}
+
+	language, ok := fenceLanguages[file.Language]
+	if !ok {
+		language = strings.ToLower(file.Language)
+	}
+	fence := codeFence(content)
+	page.WriteString(fmt.Sprintf("## %s\n\n", labels.Source))
+	page.WriteString(fence + language + "\n")
+	page.WriteString(strings.TrimRight(content, "\n"))
+	page.WriteString("\n" + fence + "\n")
+
+	// Dots are replaced so that the extension of the file does not give its page files a second one
+	slug := PageSlug(strings.ReplaceAll(filePath, ".", "-"))
+	return &WikiPage{
+		ID:          slug,
+		Title:       filePath,
+		Slug:        slug,
+		Description: fmt.Sprintf(labels.FileDescription, filePath),
+		Content:     page.String(),
+		FilePaths:   []string{filePath},
+		Importance:  fileImportance(file.Importance),
+	}
+}
+
+// summarizeFile asks the LLM for a short summary of a file
+func (g *WikiGenerator) summarizeFile(
+	ctx context.Context,
+	file scanner.FileInfo,
+	content string,
+	options GenerationOptions,
+) (string, error) {
+	if len(content) > maxFileSummaryBytes {
+		content = strings.ToValidUTF8(content[:maxFileSummaryBytes], "") + "\n..."
+	}
+
+	prompt, err := prompts.ExecuteFileSummaryPrompt(prompts.FileSummaryData{
+		ProjectName:  options.ProjectName,
+		Language:     options.Language,
+		FilePath:     filepath.ToSlash(file.Path),
+		FileLanguage: file.Language,
+		Content:      content,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to build file summary prompt: %w", err)
+	}
+
+	completionOptions := options.PagePhase.completionOptions()
+	completionOptions.MaxTokens = fileSummaryTokens
+	summary, err := g.completeChat(ctx, []llm.Message{{Role: "user", Content: prompt}}, completionOptions)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(summary), nil
+}
+
+// codeFence returns a backtick fence longer than any backtick run in content
+func codeFence(content string) string {
+	longest, run := 0, 0
+	for _, r := range content {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+// fileImportance maps the importance score of a scanned file (1-5) to a page importance
+func fileImportance(score int) string {
+	switch {
+	case score >= 4:
+		return "high"
+	case score == 3:
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+// uniquePageID returns id, with a numeric suffix if a page of pages already has it
+func uniquePageID(id string, pages map[string]*WikiPage) string {
+	unique := id
+	for n := 2; pages[unique] != nil; n++ {
+		unique = fmt.Sprintf("%s-%d", id, n)
+	}
+	return unique
+}
@@ -0,0 +1,89 @@
+package generator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kuderr/deepwiki/pkg/scanner"
+	"github.com/kuderr/deepwiki/pkg/types"
+)
+
+func TestGenerateFilePages(t *testing.T) {
+	files := scanCIProject(t, map[string]string{
+		"main.go":        "package main\n\nfunc main() {\n\tserve()\n}\n",
+		"server/http.go": "package server\n\n// Serve starts the HTTP server\nfunc Serve() {}\n",
+		"README.md":      "# Demo\n\nA demo project.\n",
+	})
+	generator, provider := newScriptedGenerator(choice("Starts the demo service.", "stop"))
+
+	options := GenerationOptions{ProjectName: "demo", Language: types.LanguageEnglish}
+	result, err := generator.GenerateFilePages(context.Background(), files, options)
+	if err != nil {
+		t.Fatalf("GenerateFilePages failed: %v", err)
+	}
+
+	if len(result.Errors) != 0 {
+		t.Errorf("Expected no errors, got %v", result.Errors)
+	}
+	if result.TotalPages != len(files) || len(result.Structure.Pages) != len(files) {
+		t.Fatalf("Expected one page per file (%d), got %d pages in a structure of %d",
+			len(files), result.TotalPages, len(result.Structure.Pages))
+	}
+	if len(provider.maxTokens) != len(files) {
+		t.Errorf("Expected one summary request per file and no structure request, got %d requests",
+			len(provider.maxTokens))
+	}
+
+	for _, file := range files {
+		page, ok := result.Pages[PageSlug(strings.ReplaceAll(file.Path, ".", "-"))]
+		if !ok {
+			t.Errorf("Expected a page for %s", file.Path)
+			continue
+		}
+		if page.Title != file.Path || len(page.FilePaths) != 1 || page.FilePaths[0] != file.Path {
+			t.Errorf("Expected the page to be titled after and reference %s, got %q and %v",
+				file.Path, page.Title, page.FilePaths)
+		}
+		if !strings.Contains(page.Content, "## Summary\n\nStarts the demo service.\n\n## Source\n\n") {
+			t.Errorf("Expected a summary section above the source of %s, got:\n%s", file.Path, page.Content)
+		}
+	}
+
+	source := "```go\npackage server\n\n// Serve starts the HTTP server\nfunc Serve() {}\n```\n"
+	if page := result.Pages["server-http-go"]; page == nil || !strings.Contains(page.Content, source) {
+		t.Errorf("Expected the source of server/http.go in a go code block, got %+v", page)
+	}
+}
+
+func TestBuildFilePage_FencesSourceWithBackticks(t *testing.T) {
+	file := scanner.FileInfo{Path: "docs/guide.md", Language: "Markdown", Importance: 3}
+	content := "# Guide\n\n```bash\nmake\n```\n"
+
+	page := BuildFilePage(file, content, "", GenerationOptions{})
+
+	if strings.Contains(page.Content, "## Summary") {
+		t.Errorf("Expected no summary section without a summary, got:\n%s", page.Content)
+	}
+	if !strings.Contains(page.Content, "````markdown\n"+strings.TrimSuffix(content, "\n")+"\n````\n") {
+		t.Errorf("Expected the source in a fence longer than its own, got:\n%s", page.Content)
+	}
+	if page.Importance != "medium" {
+		t.Errorf("Expected medium importance, got %s", page.Importance)
+	}
+}
+
+func TestBuildFilePage_UsesLanguage(t *testing.T) {
+	file := scanner.FileInfo{Path: "cmd/main.go", Language: "Go"}
+	options := GenerationOptions{Language: types.LanguageRussian}
+
+	page := BuildFilePage(file, "package main\n", "Точка входа.", options)
+
+	if !strings.Contains(page.Content, "## Описание\n\nТочка входа.\n\n## Исходный код\n\n") {
+		t.Errorf("Expected Russian section headings, got:\n%s", page.Content)
+	}
+	if page.ID != "cmd-main-go" || PageFileSlug(page) != "cmd-main-go" {
+		t.Errorf("Expected the extension dot replaced in the page ID and file name, got %q and %q",
+			page.ID, PageFileSlug(page))
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
@@ -13,6 +14,14 @@ import (
 	"github.com/kuderr/deepwiki/pkg/llm"
 	"github.com/kuderr/deepwiki/pkg/rag"
 	"github.com/kuderr/deepwiki/pkg/scanner"
+	"github.com/kuderr/deepwiki/pkg/tracing"
+)
+
+const (
+	// defaultRetrievalResults is the number of documents retrieved for a page when not set
+	defaultRetrievalResults = 20
+	// defaultRetrievalMinScore is the lowest score of a document retrieved for a page when not set
+	defaultRetrievalMinScore = 0.1
 )
 
 // WikiGenerator generates wiki structures and content
@@ -59,38 +68,99 @@ func (g *WikiGenerator) GenerateWiki(
 	fileTree := g.buildFileTree(files, options.ProjectPath)
 	readmeContent := g.findReadmeContent(files)
 
-	// Step 1: Generate wiki structure
+	var existingDocs []ExistingDoc
+	if options.MergeExistingDocs {
+		existingDocs = ExtractExistingDocs(files, options)
+	}
+
+	// Step 1: Generate wiki structure, unless a checkpoint recorded it
 	options.ProgressTracker.StartTask("Generating wiki structure", 1)
-	structure, err := g.GenerateWikiStructure(ctx, fileTree, readmeContent, options)
-	if err != nil {
-		result.Errors = append(result.Errors, fmt.Errorf("structure generation failed: %w", err))
-		return result, err
+	var structure *WikiStructure
+	if options.Checkpoint != nil {
+		structure = options.Checkpoint.Structure()
+	}
+	if structure != nil {
+		g.logger.Info("Resuming with the wiki structure of the checkpoint", "pages", len(structure.Pages))
+	} else {
+		var err error
+		structure, err = g.generateWikiStructure(ctx, fileTree, readmeContent, existingDocs, options)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("structure generation failed: %w", err))
+			return result, err
+		}
+		g.mergeExistingDocs(structure, existingDocs)
+		g.checkpointStructure(structure, options)
 	}
 	result.Structure = structure
 	options.ProgressTracker.CompleteTask("Wiki structure generated")
 
+	if options.PlanOnly {
+		g.planPageFiles(structure, options)
+		return result, nil
+	}
+
 	// Step 2: Generate content for each page
 	options.ProgressTracker.StartTask("Generating page content", len(structure.Pages))
+	batched := g.generatePageBatches(ctx, fileTree, structure, options)
 
 	for i, page := range structure.Pages {
 		pagePtr := &structure.Pages[i] // Get pointer to the actual page in the slice
 
 		options.ProgressTracker.UpdateProgress(i, fmt.Sprintf("Generating: %s", page.Title))
 
+		// Existing docs are included as written, unless they are empty
+		if page.SourceDoc != "" {
+			if strings.TrimSpace(pagePtr.Content) == "" {
+				g.logger.Warn("Skipping empty existing doc", "page", page.ID, "source", page.SourceDoc)
+				continue
+			}
+			result.Pages[page.ID] = pagePtr
+			result.TotalWords += pagePtr.WordCount
+			continue
+		}
+
+		if options.Checkpoint != nil {
+			if saved, ok := options.Checkpoint.Page(page.ID); ok && strings.TrimSpace(saved.Content) != "" {
+				*pagePtr = *saved
+				result.Pages[page.ID] = pagePtr
+				result.TotalWords += pagePtr.WordCount
+				continue
+			}
+		}
+
+		if batched[page.ID] {
+			result.Pages[page.ID] = pagePtr
+			result.TotalWords += pagePtr.WordCount
+			continue
+		}
+
 		if err := g.GeneratePageContent(ctx, fileTree, pagePtr, structure, options); err != nil {
 			errorMsg := fmt.Errorf("failed to generate content for page %s: %w", page.ID, err)
 			result.Errors = append(result.Errors, errorMsg)
 			g.logger.Error("Page generation failed", "page", page.ID, "error", err)
 			continue
 		}
+		g.checkpointPage(pagePtr, options)
 
 		result.Pages[page.ID] = pagePtr
 		result.TotalWords += pagePtr.WordCount
 	}
 
+	if options.DedupPages {
+		g.collapseDuplicatePages(structure, result)
+	}
+
 	result.TotalPages = len(result.Pages)
 	options.ProgressTracker.CompleteTask(fmt.Sprintf("Generated %d pages", result.TotalPages))
 
+	// Step 3: Define the domain terms of the generated pages
+	if options.IncludeGlossary {
+		g.addGlossaryPage(ctx, structure, result, options)
+	}
+
+	// Step 4: Add pages derived from static analysis of the source tree
+	g.addAnalysisPages(ctx, files, structure, result, options)
+
 	g.logger.Info("Wiki generation completed",
 		"total_pages", result.TotalPages,
 		"total_words", result.TotalWords,
@@ -100,6 +170,26 @@ func (g *WikiGenerator) GenerateWiki(
 	return result, nil
 }
 
+// checkpointStructure records a generated structure in the checkpoint of options, if any
+func (g *WikiGenerator) checkpointStructure(structure *WikiStructure, options GenerationOptions) {
+	if options.Checkpoint == nil {
+		return
+	}
+	if err := options.Checkpoint.SaveStructure(structure); err != nil {
+		g.logger.Warn("Failed to checkpoint wiki structure", "error", err)
+	}
+}
+
+// checkpointPage records a generated page in the checkpoint of options, if any
+func (g *WikiGenerator) checkpointPage(page *WikiPage, options GenerationOptions) {
+	if options.Checkpoint == nil {
+		return
+	}
+	if err := options.Checkpoint.SavePage(page); err != nil {
+		g.logger.Warn("Failed to checkpoint page", "page", page.ID, "error", err)
+	}
+}
+
 // GenerateWikiStructure generates the overall wiki structure for a project
 func (g *WikiGenerator) GenerateWikiStructure(
 	ctx context.Context,
@@ -107,6 +197,23 @@ func (g *WikiGenerator) GenerateWikiStructure(
 	readmeContent string,
 	options GenerationOptions,
 ) (*WikiStructure, error) {
+	return g.generateWikiStructure(ctx, fileTree, readmeContent, nil, options)
+}
+
+// generateWikiStructure generates the wiki structure, asking the LLM to place any existing docs
+func (g *WikiGenerator) generateWikiStructure(
+	ctx context.Context,
+	fileTree string,
+	readmeContent string,
+	existingDocs []ExistingDoc,
+	options GenerationOptions,
+) (_ *WikiStructure, err error) {
+	ctx, span := tracing.Start(ctx, "generator.structure", tracing.String("project", options.ProjectName))
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
 	g.logger.Info("Starting wiki structure generation",
 		"project", options.ProjectName,
 		"language", options.Language,
@@ -120,6 +227,8 @@ func (g *WikiGenerator) GenerateWikiStructure(
 		ReadmeFile:  readmeContent,
 		ProjectName: options.ProjectName,
 		Language:    options.Language,
+
+		ExistingDocs: existingDocSummaries(existingDocs),
 	}
 
 	// Execute the prompt
@@ -138,16 +247,21 @@ func (g *WikiGenerator) GenerateWikiStructure(
 		},
 	}
 
-	response, err := g.llmProvider.ChatCompletion(ctx, messages, llm.ChatCompletionOptions{
-		MaxTokens:   4000,
-		Temperature: 0.1,
-	})
+	completionOptions := options.StructurePhase.completionOptions()
+	promptTokens, err := g.checkRequestFits(messages, completionOptions,
+		g.promptBudget(options, completionOptions.MaxTokens))
+	if err != nil {
+		return nil, fmt.Errorf("structure prompt is too large: %w", err)
+	}
+
+	content, err := g.completeCheckedChat(ctx, messages, completionOptions,
+		g.responseLimit(options, promptTokens), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to call LLM API for structure generation: %w", err)
 	}
 
 	// Parse the XML response
-	structureResponse, err := g.xmlParser.ParseWikiStructure(response.Choices[0].Message.Content)
+	structureResponse, err := g.xmlParser.ParseWikiStructure(content)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse wiki structure response: %w", err)
 	}
@@ -155,6 +269,8 @@ func (g *WikiGenerator) GenerateWikiStructure(
 	// Convert to WikiStructure
 	structure := g.xmlParser.ConvertToWikiStructure(structureResponse, options)
 
+	span.SetAttributes(tracing.Int("pages", len(structure.Pages)))
+
 	g.logger.Info("Wiki structure generated successfully",
 		"pages", len(structure.Pages),
 		"duration", time.Since(start),
@@ -170,86 +286,73 @@ func (g *WikiGenerator) GeneratePageContent(
 	page *WikiPage,
 	structure *WikiStructure,
 	options GenerationOptions,
-) error {
+) (err error) {
+	ctx, span := tracing.Start(ctx, "generator.page", tracing.String("page.id", page.ID))
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
 	g.logger.Info("Generating content for page", "page", page.Title, "id", page.ID)
 
 	start := time.Now()
 
-	// Retrieve relevant documents for this page using a simple query based on page title
-	retrievalContext := &rag.RetrievalContext{
-		Query:      page.Title + " " + page.Description,
-		QueryType:  rag.QueryTypeHybrid,
-		MaxResults: 20,
-		MinScore:   0.1,
-	}
-
-	relevantDocs, err := g.ragRetriever.RetrieveRelevantDocuments(retrievalContext)
+	relevantDocs, err := g.retrievePageDocuments(page, options)
 	if err != nil {
-		return fmt.Errorf("failed to retrieve relevant documents for page %s: %w", page.ID, err)
+		return err
 	}
 
-	g.logger.Debug("Retrieved relevant documents", "page", page.ID, "docs", len(relevantDocs))
-
-	// Format relevant files for the prompt
-	relevantFiles := g.formatRelevantFiles(relevantDocs)
-
-	otherPagesSummaries := make([]prompts.PageSummary, 0, len(structure.Pages)-1)
-	for _, other := range structure.Pages {
-		if other.ID == page.ID {
-			continue
-		}
-
-		otherPagesSummaries = append(otherPagesSummaries, prompts.PageSummary{
-			Title:       other.Title,
-			Description: other.Description,
-		})
+	// The context lists every page, so that it is the same for all pages and can be cached
+	contextPrompt, err := prompts.ExecutePageContextPrompt(prompts.PageContextData{
+		ProjectName: options.ProjectName,
+		Language:    options.Language,
+		FileTree:    fileTree,
+		Pages:       otherPageSummaries(structure),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to generate context prompt for page %s: %w", page.ID, err)
 	}
 
 	// Prepare prompt data
 	promptData := prompts.PageContentData{
-		Title:         page.Title,
-		Description:   page.Description,
-		RelevantFiles: relevantFiles,
-		ProjectName:   options.ProjectName,
-		Language:      options.Language,
-		FileTree:      fileTree,
-		OtherPages:    otherPagesSummaries,
+		Title:       page.Title,
+		Description: page.Description,
+		ProjectName: options.ProjectName,
+		Language:    options.Language,
 	}
 
-	// Execute the prompt
-	prompt, err := prompts.ExecutePageContentPrompt(promptData)
+	// Execute the prompt, trimming the retrieved documents if it would overflow the context window
+	completionOptions := options.PagePhase.completionOptions()
+	contextText := requestText([]llm.Message{{Role: "user", Content: contextPrompt}}, completionOptions)
+	prompt, relevantDocs, promptTokens, err := g.fitPagePrompt(contextText, promptData, relevantDocs,
+		g.promptBudget(options, completionOptions.MaxTokens))
 	if err != nil {
 		return fmt.Errorf("failed to generate content prompt for page %s: %w", page.ID, err)
 	}
 
-	// Call LLM API
+	// Call LLM API, caching the shared context as a prompt prefix
 	messages := []llm.Message{
+		{
+			Role:    "user",
+			Content: contextPrompt,
+		},
 		{
 			Role:    "user",
 			Content: prompt,
 		},
 	}
+	completionOptions.CacheMessages = 1
 
-	response, err := g.llmProvider.ChatCompletion(ctx, messages, llm.ChatCompletionOptions{
-		MaxTokens:   4000,
-		Temperature: 0.1,
-	})
+	content, err := g.completePageContent(ctx, page, messages, completionOptions,
+		g.responseLimit(options, promptTokens))
 	if err != nil {
-		return fmt.Errorf("failed to call LLM API for page content generation: %w", err)
+		return err
 	}
 
 	// Update the page with generated content
-	page.Content = g.contentPostProcessor.CleanMarkdown(response.Choices[0].Message.Content)
-	page.WordCount = len(strings.Fields(page.Content))
-	page.SourceFiles = len(relevantDocs)
-	page.CreatedAt = time.Now()
+	g.setPageContent(ctx, page, content, relevantDocs, options)
 
-	// Extract file paths from relevant documents
-	filePaths := make([]string, len(relevantDocs))
-	for i, doc := range relevantDocs {
-		filePaths[i] = doc.FilePath
-	}
-	page.FilePaths = filePaths
+	span.SetAttributes(tracing.Int("words", page.WordCount), tracing.Int("source_files", page.SourceFiles))
 
 	g.logger.Info("Page content generated successfully",
 		"page", page.ID,
@@ -261,6 +364,70 @@ func (g *WikiGenerator) GeneratePageContent(
 	return nil
 }
 
+// retrievePageDocuments retrieves the source documents a page is written from, using a simple
+// query based on its title and description
+func (g *WikiGenerator) retrievePageDocuments(
+	page *WikiPage,
+	options GenerationOptions,
+) ([]rag.RetrievalResult, error) {
+	retrievalContext := options.Retrieval.retrievalContext(page.Title + " " + page.Description)
+	relevantDocs, err := g.ragRetriever.RetrieveRelevantDocuments(retrievalContext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve relevant documents for page %s: %w", page.ID, err)
+	}
+
+	if len(relevantDocs) > 0 && relevantDocs[0].Relevance.Relaxed {
+		g.logger.Info("Relaxed the min score to find documents for page",
+			"page", page.ID, "docs", len(relevantDocs), "floor", retrievalContext.MinScoreFloor)
+	}
+	g.logger.Debug("Retrieved relevant documents", "page", page.ID, "docs", len(relevantDocs))
+	return relevantDocs, nil
+}
+
+// otherPageSummaries summarizes the pages of the structure other than those with the given IDs
+func otherPageSummaries(structure *WikiStructure, ids ...string) []prompts.PageSummary {
+	summaries := make([]prompts.PageSummary, 0, len(structure.Pages))
+	for _, other := range structure.Pages {
+		if slices.Contains(ids, other.ID) {
+			continue
+		}
+
+		summaries = append(summaries, prompts.PageSummary{
+			Title:       other.Title,
+			Description: other.Description,
+		})
+	}
+	return summaries
+}
+
+// setPageContent sets the cleaned content of a page written from relevantDocs, and the
+// statistics derived from it
+func (g *WikiGenerator) setPageContent(
+	ctx context.Context,
+	page *WikiPage,
+	content string,
+	relevantDocs []rag.RetrievalResult,
+	options GenerationOptions,
+) {
+	page.Content = content
+	if options.EmbedSnippets {
+		page.Content = embedSnippets(page.Content, g.collectSnippets(relevantDocs))
+	}
+	if options.ClassifyImportance {
+		g.classifyPageImportance(ctx, page, options)
+	}
+	page.WordCount = len(strings.Fields(page.Content))
+	page.SourceFiles = len(relevantDocs)
+	page.CreatedAt = time.Now()
+
+	// Extract file paths from relevant documents
+	filePaths := make([]string, len(relevantDocs))
+	for i, doc := range relevantDocs {
+		filePaths[i] = doc.FilePath
+	}
+	page.FilePaths = filePaths
+}
+
 // buildFileTree creates a string representation of the file tree
 func (g *WikiGenerator) buildFileTree(files []scanner.FileInfo, basePath string) string {
 	var builder strings.Builder
@@ -312,12 +479,40 @@ func (g *WikiGenerator) findReadmeContent(files []scanner.FileInfo) string {
 	return "No README file found."
 }
 
+// retrievalContext returns the retrieval context of a page query, with defaults for unset settings
+func (s RetrievalSettings) retrievalContext(query string) *rag.RetrievalContext {
+	retrievalContext := &rag.RetrievalContext{
+		Query:         query,
+		QueryType:     rag.QueryTypeHybrid,
+		MaxResults:    defaultRetrievalResults,
+		MinScore:      defaultRetrievalMinScore,
+		MinResults:    s.MinResults,
+		MinScoreFloor: s.MinScoreFloor,
+	}
+	if s.MaxResults > 0 {
+		retrievalContext.MaxResults = s.MaxResults
+	}
+	if s.MinScore != nil {
+		retrievalContext.MinScore = *s.MinScore
+	}
+	if s.Strategy != "" {
+		retrievalContext.QueryType = s.Strategy
+	}
+	return retrievalContext
+}
+
 // formatRelevantFiles formats relevant documents for the prompt
 func (g *WikiGenerator) formatRelevantFiles(docs []rag.RetrievalResult) string {
 	var builder strings.Builder
 
 	for _, doc := range docs {
-		builder.WriteString(fmt.Sprintf("\n--- %s ---\n", doc.FilePath))
+		// Name the enclosing declaration of chunks cut from the middle of a function or type
+		if doc.Context != nil && doc.Context.Signature != "" &&
+			!strings.HasPrefix(strings.TrimSpace(doc.Content), doc.Context.Signature) {
+			builder.WriteString(fmt.Sprintf("\n--- %s (in %s) ---\n", doc.FilePath, doc.Context.Signature))
+		} else {
+			builder.WriteString(fmt.Sprintf("\n--- %s ---\n", doc.FilePath))
+		}
 		builder.WriteString(doc.Content)
 		builder.WriteString("\n")
 	}
@@ -2,6 +2,7 @@ package generator
 
 import (
 	"context"
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
@@ -12,6 +13,7 @@ import (
 	"github.com/kuderr/deepwiki/pkg/llm"
 	"github.com/kuderr/deepwiki/pkg/rag"
 	"github.com/kuderr/deepwiki/pkg/scanner"
+	"github.com/kuderr/deepwiki/pkg/types"
 )
 
 // MockLLMProvider implements the llm.Provider interface for testing
@@ -104,6 +106,10 @@ func (m *MockRAGRetriever) RetrieveRelatedChunks(chunkID string, maxResults int)
 	return nil, nil
 }
 
+func (m *MockRAGRetriever) RetrieveByFilePath(filePath string) ([]rag.RetrievalResult, error) {
+	return nil, nil
+}
+
 func (m *MockRAGRetriever) FilterResults(
 	results []rag.RetrievalResult,
 	filters map[string]string,
@@ -212,10 +218,19 @@ func TestFormatRelevantFiles(t *testing.T) {
 			FilePath: "config.go",
 			Content:  "package config\n\ntype Config struct {}",
 		},
+		{
+			FilePath: "server.go",
+			Content:  "return s.sessions.Start(token)\n}",
+			Context:  &rag.ChunkContext{Signature: "func (s *Server) Login(token string) error"},
+		},
 	}
 
 	formatted := generator.formatRelevantFiles(docs)
 
+	if !strings.Contains(formatted, "--- server.go (in func (s *Server) Login(token string) error) ---") {
+		t.Errorf("Expected the enclosing declaration of a fragment to be named, got:\n%s", formatted)
+	}
+
 	if !strings.Contains(formatted, "main.go") {
 		t.Error("Expected formatted output to contain main.go")
 	}
@@ -296,3 +311,63 @@ func TestGenerationStatsDuration(t *testing.T) {
 		t.Errorf("Expected duration to be 5 seconds, got %v", duration)
 	}
 }
+
+// spyRAGRetriever records the retrieval contexts the generator builds
+type spyRAGRetriever struct {
+	MockRAGRetriever
+	contexts []*rag.RetrievalContext
+}
+
+func (s *spyRAGRetriever) RetrieveRelevantDocuments(ctx *rag.RetrievalContext) ([]rag.RetrievalResult, error) {
+	s.contexts = append(s.contexts, ctx)
+	return s.MockRAGRetriever.RetrieveRelevantDocuments(ctx)
+}
+
+func TestGeneratePageContent_RetrievalSettings(t *testing.T) {
+	minScore := float32(0)
+	tests := []struct {
+		name     string
+		settings RetrievalSettings
+		want     rag.RetrievalContext
+	}{
+		{
+			name:     "defaults",
+			settings: RetrievalSettings{},
+			want:     rag.RetrievalContext{MaxResults: 20, MinScore: 0.1, QueryType: rag.QueryTypeHybrid},
+		},
+		{
+			name:     "configured",
+			settings: RetrievalSettings{MaxResults: 5, MinScore: &minScore, Strategy: rag.QueryTypeSemantic},
+			want:     rag.RetrievalContext{MaxResults: 5, MinScore: 0, QueryType: rag.QueryTypeSemantic},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retriever := &spyRAGRetriever{}
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			generator := NewWikiGenerator(&MockLLMProvider{}, retriever, logger)
+
+			page := &WikiPage{ID: "auth", Title: "Authentication", Description: "Login flow"}
+			structure := &WikiStructure{Pages: []WikiPage{*page}}
+			options := GenerationOptions{ProjectName: "demo", Language: types.LanguageEnglish, Retrieval: tt.settings}
+			if err := generator.GeneratePageContent(context.Background(), "", page, structure, options); err != nil {
+				t.Fatalf("GeneratePageContent failed: %v", err)
+			}
+
+			if len(retriever.contexts) != 1 {
+				t.Fatalf("Expected 1 retrieval, got %d", len(retriever.contexts))
+			}
+			got := retriever.contexts[0]
+			if got.MaxResults != tt.want.MaxResults || got.MinScore != tt.want.MinScore ||
+				got.QueryType != tt.want.QueryType {
+				t.Errorf("Expected max results %d, min score %v and strategy %s, got %d, %v and %s",
+					tt.want.MaxResults, tt.want.MinScore, tt.want.QueryType,
+					got.MaxResults, got.MinScore, got.QueryType)
+			}
+			if got.Query != "Authentication Login flow" {
+				t.Errorf("Expected the page title and description as query, got %q", got.Query)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/kuderr/deepwiki/pkg/generator/prompts"
+	"github.com/kuderr/deepwiki/pkg/llm"
+)
+
+// GlossaryPageID is the ID of the generated glossary page
+const GlossaryPageID = "glossary"
+
+const (
+	// glossaryTokens is the token budget of the glossary answer
+	glossaryTokens = 4000
+
+	// glossaryPageWords is the number of words of each page the glossary prompt quotes at most
+	glossaryPageWords = 300
+
+	// maxGlossaryLinks is the number of pages linked from a glossary term at most
+	maxGlossaryLinks = 3
+)
+
+// glossaryTermPattern matches a term of the glossary answer
+var glossaryTermPattern = regexp.MustCompile(
+	`(?s)<term>\s*<name>(.*?)</name>\s*<definition>(.*?)</definition>\s*</term>`)
+
+// GlossaryEntry is a domain term of the glossary and the pages that discuss it most
+type GlossaryEntry struct {
+	Term       string
+	Definition string
+	Pages      []*WikiPage
+}
+
+// addGlossaryPage asks the LLM for the domain terms of the generated pages and adds a page
+// defining them. Terms no page mentions are dropped; failures leave the wiki without a glossary.
+func (g *WikiGenerator) addGlossaryPage(
+	ctx context.Context,
+	structure *WikiStructure,
+	result *GenerationResult,
+	options GenerationOptions,
+) {
+	if _, exists := result.Pages[GlossaryPageID]; exists {
+		g.logger.Warn("Skipping glossary: the wiki already has a page with its ID", "page", GlossaryPageID)
+		return
+	}
+
+	var pages []*WikiPage
+	for i := range structure.Pages {
+		if page, ok := result.Pages[structure.Pages[i].ID]; ok && page.Content != "" {
+			pages = append(pages, page)
+		}
+	}
+	if len(pages) == 0 {
+		return
+	}
+
+	prompt, promptTokens, err := g.fitGlossaryPrompt(pages, options)
+	if err != nil {
+		g.logger.Warn("Failed to build glossary prompt", "error", err)
+		return
+	}
+
+	answer, err := g.completeCheckedChat(ctx, []llm.Message{{Role: "user", Content: prompt}}, llm.ChatCompletionOptions{
+		MaxTokens:   glossaryTokens,
+		Temperature: defaultTemperature,
+	}, g.responseLimit(options, promptTokens), nil)
+	if err != nil {
+		g.logger.Warn("Failed to generate glossary", "error", err)
+		return
+	}
+
+	entries := linkGlossaryEntries(parseGlossary(answer), pages)
+	if len(entries) == 0 {
+		g.logger.Warn("LLM answered with no glossary terms found in the pages")
+		return
+	}
+
+	page := BuildGlossaryPage(entries, options)
+	addGeneratedPage(structure, result, page)
+	g.logger.Info("Added glossary page", "terms", len(entries))
+}
+
+// fitGlossaryPrompt renders the glossary prompt, dropping the last pages until it fits the
+// context window. It returns the prompt and its estimated tokens.
+func (g *WikiGenerator) fitGlossaryPrompt(pages []*WikiPage, options GenerationOptions) (string, int, error) {
+	excerpts := make([]string, len(pages))
+	for i, page := range pages {
+		content := page.Content
+		if words := strings.Fields(content); len(words) > glossaryPageWords {
+			content = strings.Join(words[:glossaryPageWords], " ")
+		}
+		excerpts[i] = fmt.Sprintf("<page title=%q>\n%s\n</page>\n", page.Title, content)
+	}
+
+	prompt, _, tokens, err := g.fitParts(excerpts, 1, "", g.promptBudget(options, glossaryTokens),
+		func(n int) (string, error) {
+			return prompts.ExecuteGlossaryPrompt(prompts.GlossaryData{
+				ProjectName: options.ProjectName,
+				Language:    options.Language,
+				Pages:       strings.Join(excerpts[:n], ""),
+			})
+		})
+	return prompt, tokens, err
+}
+
+// parseGlossary returns the terms of a glossary answer, dropping repeated terms
+func parseGlossary(answer string) []GlossaryEntry {
+	var entries []GlossaryEntry
+	seen := make(map[string]bool)
+	for _, match := range glossaryTermPattern.FindAllStringSubmatch(answer, -1) {
+		term := strings.TrimSpace(html.UnescapeString(match[1]))
+		definition := strings.Join(strings.Fields(html.UnescapeString(match[2])), " ")
+		if term == "" || definition == "" || seen[strings.ToLower(term)] {
+			continue
+		}
+		seen[strings.ToLower(term)] = true
+		entries = append(entries, GlossaryEntry{Term: term, Definition: definition})
+	}
+	return entries
+}
+
+// linkGlossaryEntries sets the pages of each entry to the pages mentioning its term most, up to
+// maxGlossaryLinks, and drops entries no page mentions. Mentions count plurals of the term.
+func linkGlossaryEntries(entries []GlossaryEntry, pages []*WikiPage) []GlossaryEntry {
+	var linked []GlossaryEntry
+	for _, entry := range entries {
+		pattern := regexp.MustCompile(`(?i)(?:^|[^\p{L}\p{N}_])` + regexp.QuoteMeta(entry.Term) +
+			`(?:e?s)?(?:$|[^\p{L}\p{N}_])`)
+
+		mentions := make(map[string]int)
+		var mentioning []*WikiPage
+		for _, page := range pages {
+			if count := len(pattern.FindAllStringIndex(page.Content, -1)); count > 0 {
+				mentions[page.ID] = count
+				mentioning = append(mentioning, page)
+			}
+		}
+		if len(mentioning) == 0 {
+			continue
+		}
+
+		// Pages keep the structure order on ties
+		slices.SortStableFunc(mentioning, func(a, b *WikiPage) int {
+			return mentions[b.ID] - mentions[a.ID]
+		})
+		entry.Pages = mentioning[:min(len(mentioning), maxGlossaryLinks)]
+		linked = append(linked, entry)
+	}
+	return linked
+}
+
+// BuildGlossaryPage creates the glossary page in the language of options, with terms in
+// alphabetical order. Each term links to its pages at their location in the output format.
+func BuildGlossaryPage(entries []GlossaryEntry, options GenerationOptions) *WikiPage {
+	entries = slices.Clone(entries)
+	slices.SortFunc(entries, func(a, b GlossaryEntry) int {
+		return strings.Compare(strings.ToLower(a.Term), strings.ToLower(b.Term))
+	})
+
+	labels := labelsFor(options.Language)
+	var content strings.Builder
+	fmt.Fprintf(&content, "# %s\n\n%s\n", labels.Glossary, labels.GlossaryIntro)
+
+	var related []string
+	for _, entry := range entries {
+		links := make([]string, 0, len(entry.Pages))
+		for _, page := range entry.Pages {
+			links = append(links, fmt.Sprintf("[%s](%s)", page.Title, pageLink(options, page)))
+			if !slices.Contains(related, page.ID) {
+				related = append(related, page.ID)
+			}
+		}
+		fmt.Fprintf(&content, "\n## %s\n\n%s\n\n%s: %s\n",
+			entry.Term, entry.Definition, labels.See, strings.Join(links, ", "))
+	}
+
+	return &WikiPage{
+		ID:           GlossaryPageID,
+		Title:        labels.Glossary,
+		Description:  labels.GlossaryDescription,
+		Content:      content.String(),
+		Importance:   "low",
+		RelatedPages: related,
+	}
+}
@@ -0,0 +1,106 @@
+package generator
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kuderr/deepwiki/pkg/types"
+)
+
+func TestGenerateWiki_AddsGlossaryPage(t *testing.T) {
+	generator, provider := newScriptedGenerator(
+		choice(`<wiki_structure><title>demo</title><pages>
+  <page><id>sessions</id><title>Sessions</title><importance>high</importance></page>
+  <page><id>storage</id><title>Storage Layer</title><importance>medium</importance></page>
+</pages></wiki_structure>`, "stop"),
+		choice("# Sessions\n\nA session token is issued at login. Session tokens expire after an hour.", "stop"),
+		choice("# Storage Layer\n\nEach shard stores the sessions of a region. "+
+			"A shard holds one session token per user; shards are rebalanced nightly.", "stop"),
+		choice(`<glossary>
+<term><name>Shard</name><definition>A partition of the session store.</definition></term>
+<term><name>Session Token</name><definition>The secret that identifies
+  a logged-in user &amp; their session.</definition></term>
+<term><name>shard</name><definition>Repeated term.</definition></term>
+<term><name>Quorum</name><definition>Not mentioned by any page.</definition></term>
+</glossary>`, "stop"),
+	)
+
+	options := GenerationOptions{ProjectName: "demo", Language: types.LanguageEnglish, IncludeGlossary: true}
+	result, err := generator.GenerateWiki(context.Background(), nil, options)
+	if err != nil {
+		t.Fatalf("GenerateWiki failed: %v", err)
+	}
+
+	page, ok := result.Pages[GlossaryPageID]
+	if !ok {
+		t.Fatal("Expected a glossary page")
+	}
+	if result.Structure.Pages[len(result.Structure.Pages)-1].ID != GlossaryPageID || result.TotalPages != 3 {
+		t.Errorf("Expected the glossary appended to the structure, got %d pages", result.TotalPages)
+	}
+
+	// Terms are sorted, and link to the pages mentioning them most first
+	want := "## Session Token\n\nThe secret that identifies a logged-in user & their session.\n\n" +
+		"See: [Sessions](./sessions.md), [Storage Layer](./storage-layer.md)\n\n" +
+		"## Shard\n\nA partition of the session store.\n\nSee: [Storage Layer](./storage-layer.md)\n"
+	if !strings.HasSuffix(page.Content, want) {
+		t.Errorf("Expected every term with its definition and links, got:\n%s", page.Content)
+	}
+	if strings.Contains(page.Content, "Quorum") || strings.Count(page.Content, "## ") != 2 {
+		t.Errorf("Expected unmentioned and repeated terms to be dropped, got:\n%s", page.Content)
+	}
+	if !slices.Equal(page.RelatedPages, []string{"sessions", "storage"}) {
+		t.Errorf("Expected the linked pages as related pages, got %v", page.RelatedPages)
+	}
+	if len(provider.maxTokens) != 4 || provider.maxTokens[3] != glossaryTokens {
+		t.Errorf("Expected one glossary request, got token budgets %v", provider.maxTokens)
+	}
+}
+
+func TestGenerateWiki_NoGlossaryWithoutTerms(t *testing.T) {
+	generator, _ := newScriptedGenerator(
+		choice(`<wiki_structure><title>demo</title><pages>
+  <page><id>overview</id><title>Overview</title><importance>high</importance></page>
+</pages></wiki_structure>`, "stop"),
+		choice("# Overview\n\nThe project overview.", "stop"),
+		choice("I could not find any domain terms.", "stop"),
+	)
+
+	options := GenerationOptions{ProjectName: "demo", Language: types.LanguageEnglish, IncludeGlossary: true}
+	result, err := generator.GenerateWiki(context.Background(), nil, options)
+	if err != nil {
+		t.Fatalf("GenerateWiki failed: %v", err)
+	}
+	if _, ok := result.Pages[GlossaryPageID]; ok || result.TotalPages != 1 {
+		t.Errorf("Expected no glossary page, got %d pages", result.TotalPages)
+	}
+}
+
+func TestBuildGlossaryPage_UsesLanguageAndPageLocations(t *testing.T) {
+	entries := []GlossaryEntry{{
+		Term:       "Шард",
+		Definition: "Раздел хранилища сессий.",
+		Pages:      []*WikiPage{{ID: "storage", Title: "Storage Layer"}},
+	}}
+	options := GenerationOptions{
+		Language: types.LanguageRussian,
+		PageURL:  func(page *WikiPage) string { return "pages/" + PageSlug(page.Title) + ".rst" },
+	}
+
+	page := BuildGlossaryPage(entries, options)
+	if page.Title != "Глоссарий" || !strings.HasPrefix(page.Content, "# Глоссарий\n\n") {
+		t.Errorf("Expected a Russian title, got %q:\n%s", page.Title, page.Content)
+	}
+	if !strings.HasSuffix(page.Content, "См.: [Storage Layer](./storage-layer.rst)\n") {
+		t.Errorf("Expected a Russian link to the page location of the format, got:\n%s", page.Content)
+	}
+
+	// Site-absolute locations, as in Docusaurus, are kept
+	options.PageURL = func(page *WikiPage) string { return "/" + PageSlug(page.Title) }
+	page = BuildGlossaryPage(entries, options)
+	if !strings.HasSuffix(page.Content, "[Storage Layer](/storage-layer)\n") {
+		t.Errorf("Expected a site-absolute link, got:\n%s", page.Content)
+	}
+}
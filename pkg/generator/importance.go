@@ -0,0 +1,155 @@
+package generator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/kuderr/deepwiki/pkg/generator/prompts"
+	"github.com/kuderr/deepwiki/pkg/llm"
+)
+
+const (
+	// importanceTokens is the token budget of a page importance answer
+	importanceTokens = 16
+
+	// importanceContentWords is the number of words of a page the importance prompt quotes at most
+	importanceContentWords = 1500
+)
+
+// ImportanceCache keeps the importance the LLM gave to pages in a JSON file, so that regenerating
+// a wiki classifies each page once. Pages are keyed by their project, ID, title and description,
+// which define the role of a page; remove the file to classify the pages again.
+type ImportanceCache struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]string
+	dirty   bool
+}
+
+// LoadImportanceCache reads the cache at path; a missing file gives an empty cache
+func LoadImportanceCache(path string) (*ImportanceCache, error) {
+	cache := &ImportanceCache{path: path, entries: make(map[string]string)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read importance cache: %w", err)
+	}
+	if err := json.Unmarshal(data, &cache.entries); err != nil {
+		return nil, fmt.Errorf("failed to parse importance cache %s: %w", path, err)
+	}
+	return cache, nil
+}
+
+// Save writes the cache if classifications were added since it was loaded
+func (c *ImportanceCache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode importance cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create importance cache directory: %w", err)
+	}
+	if err := os.WriteFile(c.path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write importance cache: %w", err)
+	}
+	c.dirty = false
+	return nil
+}
+
+func (c *ImportanceCache) get(key string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	importance, ok := c.entries[key]
+	return importance, ok
+}
+
+func (c *ImportanceCache) set(key, importance string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = importance
+	c.dirty = true
+}
+
+// importanceCacheKey identifies a page of a project by its role
+func importanceCacheKey(projectName string, page *WikiPage) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{projectName, page.ID, page.Title, page.Description}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// classifyPageImportance asks the LLM how important a generated page is and replaces the estimate
+// of the structure with the answer. Failures keep the estimate.
+func (g *WikiGenerator) classifyPageImportance(ctx context.Context, page *WikiPage, options GenerationOptions) {
+	key := importanceCacheKey(options.ProjectName, page)
+	if importance, ok := options.ImportanceCache.get(key); ok {
+		page.Importance = importance
+		return
+	}
+
+	content := page.Content
+	if words := strings.Fields(content); len(words) > importanceContentWords {
+		content = strings.Join(words[:importanceContentWords], " ")
+	}
+	prompt, err := prompts.ExecutePageImportancePrompt(prompts.PageImportanceData{
+		ProjectName: options.ProjectName,
+		Title:       page.Title,
+		Description: page.Description,
+		Content:     content,
+	})
+	if err != nil {
+		g.logger.Warn("Failed to build page importance prompt", "page", page.ID, "error", err)
+		return
+	}
+
+	answer, err := g.completeChat(ctx, []llm.Message{{Role: "user", Content: prompt}}, llm.ChatCompletionOptions{
+		MaxTokens:   importanceTokens,
+		Temperature: defaultTemperature,
+	})
+	if err != nil {
+		g.logger.Warn("Failed to classify page importance", "page", page.ID, "error", err)
+		return
+	}
+
+	importance, ok := parseImportance(answer)
+	if !ok {
+		g.logger.Warn("LLM answered with an unknown importance", "page", page.ID, "answer", answer)
+		return
+	}
+
+	g.logger.Debug("Classified page importance", "page", page.ID, "estimate", page.Importance, "importance", importance)
+	page.Importance = importance
+	options.ImportanceCache.set(key, importance)
+}
+
+// parseImportance returns the first importance level named in an answer
+func parseImportance(answer string) (string, bool) {
+	for _, word := range strings.Fields(strings.ToLower(answer)) {
+		word = strings.Trim(word, ".,:;!\"'`*")
+		if isValidImportance(word) {
+			return word, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,74 @@
+package generator
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/kuderr/deepwiki/pkg/types"
+)
+
+func TestGeneratePageContent_ClassifiesImportance(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "importance.json")
+	cache, err := LoadImportanceCache(cachePath)
+	if err != nil {
+		t.Fatalf("LoadImportanceCache failed: %v", err)
+	}
+
+	generator, provider := newScriptedGenerator(
+		choice("# Changelog\n\nReleases of the project.", "stop"),
+		choice("**Low.**", "stop"),
+	)
+	page := &WikiPage{ID: "changelog", Title: "Changelog", Description: "Release history", Importance: "high"}
+	structure := &WikiStructure{Pages: []WikiPage{*page}}
+	options := GenerationOptions{
+		ProjectName:        "demo",
+		Language:           types.LanguageEnglish,
+		ClassifyImportance: true,
+		ImportanceCache:    cache,
+	}
+	if err := generator.GeneratePageContent(context.Background(), "", page, structure, options); err != nil {
+		t.Fatalf("GeneratePageContent failed: %v", err)
+	}
+	if page.Importance != "low" {
+		t.Errorf("Expected the LLM importance to replace the estimate, got %q", page.Importance)
+	}
+	if len(provider.maxTokens) != 2 {
+		t.Fatalf("Expected a page and a classification call, got %d calls", len(provider.maxTokens))
+	}
+	if err := cache.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// Regenerating the page reuses the saved classification
+	cache, err = LoadImportanceCache(cachePath)
+	if err != nil {
+		t.Fatalf("LoadImportanceCache failed: %v", err)
+	}
+	generator, provider = newScriptedGenerator(choice("# Changelog\n\nReleases of the project.", "stop"))
+	page.Importance = "high"
+	options.ImportanceCache = cache
+	if err := generator.GeneratePageContent(context.Background(), "", page, structure, options); err != nil {
+		t.Fatalf("GeneratePageContent failed: %v", err)
+	}
+	if page.Importance != "low" || len(provider.maxTokens) != 1 {
+		t.Errorf("Expected the cached importance without a classification call, got %q after %d calls",
+			page.Importance, len(provider.maxTokens))
+	}
+}
+
+func TestGeneratePageContent_KeepsImportanceOnUnknownAnswer(t *testing.T) {
+	generator, _ := newScriptedGenerator(
+		choice("# Setup\n\nInstall the project.", "stop"),
+		choice("It depends on the reader.", "stop"),
+	)
+	page := &WikiPage{ID: "setup", Title: "Setup", Importance: "high"}
+	structure := &WikiStructure{Pages: []WikiPage{*page}}
+	options := GenerationOptions{ProjectName: "demo", Language: types.LanguageEnglish, ClassifyImportance: true}
+	if err := generator.GeneratePageContent(context.Background(), "", page, structure, options); err != nil {
+		t.Fatalf("GeneratePageContent failed: %v", err)
+	}
+	if page.Importance != "high" {
+		t.Errorf("Expected the estimate to be kept, got %q", page.Importance)
+	}
+}
@@ -0,0 +1,221 @@
+package generator
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kuderr/deepwiki/pkg/types"
+)
+
+// pageLabels are the fixed texts of the pages built without the LLM, in one documentation language
+type pageLabels struct {
+	Glossary            string
+	GlossaryIntro       string
+	GlossaryDescription string
+	See                 string
+	Summary             string
+	Source              string
+	FileDescription     string // Description of a file page, formatted with the file path
+
+	// Analysis pages: each has a title, an introduction and a description
+	EnvVars             string
+	EnvVarsIntro        string
+	EnvVarsDescription  string
+	Variable            string
+	ReferencedIn        string
+	Tasks               string
+	TasksIntro          string
+	TasksDescription    string
+	Target              string
+	Description         string
+	Services            string
+	ServicesIntro       string
+	ServicesDescription string
+	Image               string
+	Build               string
+	Ports               string
+	DependsOn           string
+	Environment         string
+	Todos               string
+	TodosIntro          string
+	TodosDescription    string
+	TodoLine            string // Location of a marker, formatted with the line number
+	License             string
+	LicenseIntro        string
+	LicenseDescription  string
+	ProjectLicense      string
+	DeclaredIn          string // Source of a declared license, formatted with the file name
+	NoLicenseFile       string
+	SPDXHeaders         string
+	Files               string
+	Dependencies        string
+	DirectDependencies  string // Formatted with the dependency files
+	NoDependencies      string
+	Dependency          string
+	Version             string
+	Ecosystem           string
+	CI                  string
+	CIIntro             string
+	CIDescription       string
+	Provider            string
+	Triggers            string
+	Job                 string // Heading of a job, formatted with its name
+	Stage               string
+	RunsOn              string
+	Needs               string
+	Steps               string
+}
+
+// languageLabels holds the page labels of each documentation language
+var languageLabels = map[types.Language]pageLabels{
+	types.LanguageEnglish: {
+		Glossary:            "Glossary",
+		GlossaryIntro:       "Domain terms used throughout this documentation, with the pages that discuss them most.",
+		GlossaryDescription: "Domain terms of the project and the pages that discuss them",
+		See:                 "See",
+		Summary:             "Summary",
+		Source:              "Source",
+		FileDescription:     "Summary and source of %s",
+
+		EnvVars:            "Environment Variables",
+		EnvVarsIntro:       "The following environment variables are read by the code.",
+		EnvVarsDescription: "Environment variables read by the project and where they are used",
+		Variable:           "Variable",
+		ReferencedIn:       "Referenced in",
+		Tasks:              "Tasks",
+		TasksIntro:         "Development tasks defined in the project's Makefiles. Run them with `make <target>`.",
+		TasksDescription:   "Makefile targets and what they do",
+		Target:             "Target",
+		Description:        "Description",
+		Services:           "Services",
+		ServicesIntro: "Services defined in the project's docker-compose files. " +
+			"Values of secret-looking environment variables are redacted.",
+		ServicesDescription: "Services defined in docker-compose files",
+		Image:               "Image",
+		Build:               "Build",
+		Ports:               "Ports",
+		DependsOn:           "Depends on",
+		Environment:         "Environment",
+		Todos:               "Outstanding Work",
+		TodosIntro:          "Work markers left in code comments, grouped by file.",
+		TodosDescription:    "TODO, FIXME, HACK and XXX markers found in code comments",
+		TodoLine:            "line %d",
+		License:             "License",
+		LicenseIntro: "Licensing information detected from the project sources. " +
+			"Verify it against the license texts before relying on it for compliance.",
+		LicenseDescription: "Project license and third-party dependency licenses",
+		ProjectLicense:     "Project License",
+		DeclaredIn:         "declared in %s",
+		NoLicenseFile:      "No license file was found in the project root.",
+		SPDXHeaders:        "SPDX Headers",
+		Files:              "Files",
+		Dependencies:       "Dependencies",
+		DirectDependencies: "Direct dependencies from %s.",
+		NoDependencies:     "No dependencies are declared.",
+		Dependency:         "Dependency",
+		Version:            "Version",
+		Ecosystem:          "Ecosystem",
+		CI:                 "CI/CD",
+		CIIntro:            "Continuous integration and delivery pipelines defined in the repository.",
+		CIDescription:      "Jobs, triggers and steps of the project's CI/CD pipelines",
+		Provider:           "Provider",
+		Triggers:           "Triggers",
+		Job:                "Job %s",
+		Stage:              "Stage",
+		RunsOn:             "Runs on",
+		Needs:              "Needs",
+		Steps:              "Steps",
+	},
+	types.LanguageRussian: {
+		Glossary:            "Глоссарий",
+		GlossaryIntro:       "Термины предметной области и страницы, где они раскрыты подробнее всего.",
+		GlossaryDescription: "Термины предметной области проекта и страницы, где они обсуждаются",
+		See:                 "См.",
+		Summary:             "Описание",
+		Source:              "Исходный код",
+		FileDescription:     "Описание и исходный код %s",
+
+		EnvVars:            "Переменные окружения",
+		EnvVarsIntro:       "Код читает следующие переменные окружения.",
+		EnvVarsDescription: "Переменные окружения проекта и места их использования",
+		Variable:           "Переменная",
+		ReferencedIn:       "Где используется",
+		Tasks:              "Задачи",
+		TasksIntro:         "Задачи разработки из Makefile проекта. Запускаются командой `make <цель>`.",
+		TasksDescription:   "Цели Makefile и их назначение",
+		Target:             "Цель",
+		Description:        "Описание",
+		Services:           "Сервисы",
+		ServicesIntro: "Сервисы из файлов docker-compose проекта. " +
+			"Значения переменных окружения, похожих на секреты, скрыты.",
+		ServicesDescription: "Сервисы из файлов docker-compose",
+		Image:               "Образ",
+		Build:               "Сборка",
+		Ports:               "Порты",
+		DependsOn:           "Зависит от",
+		Environment:         "Окружение",
+		Todos:               "Незавершённая работа",
+		TodosIntro:          "Пометки о работе в комментариях кода, по файлам.",
+		TodosDescription:    "Пометки TODO, FIXME, HACK и XXX в комментариях кода",
+		TodoLine:            "строка %d",
+		License:             "Лицензия",
+		LicenseIntro: "Сведения о лицензиях, найденные в исходном коде проекта. " +
+			"Сверьте их с текстами лицензий, прежде чем на них полагаться.",
+		LicenseDescription: "Лицензия проекта и лицензии сторонних зависимостей",
+		ProjectLicense:     "Лицензия проекта",
+		DeclaredIn:         "указана в %s",
+		NoLicenseFile:      "Файл лицензии в корне проекта не найден.",
+		SPDXHeaders:        "Заголовки SPDX",
+		Files:              "Файлы",
+		Dependencies:       "Зависимости",
+		DirectDependencies: "Прямые зависимости из %s.",
+		NoDependencies:     "Зависимости не объявлены.",
+		Dependency:         "Зависимость",
+		Version:            "Версия",
+		Ecosystem:          "Экосистема",
+		CI:                 "CI/CD",
+		CIIntro:            "Конвейеры непрерывной интеграции и доставки, определённые в репозитории.",
+		CIDescription:      "Задания, триггеры и шаги конвейеров CI/CD проекта",
+		Provider:           "Платформа",
+		Triggers:           "Триггеры",
+		Job:                "Задание %s",
+		Stage:              "Стадия",
+		RunsOn:             "Среда выполнения",
+		Needs:              "Зависит от",
+		Steps:              "Шаги",
+	},
+}
+
+// labelsFor returns the page labels of a language, falling back to English
+func labelsFor(language types.Language) pageLabels {
+	if labels, ok := languageLabels[language]; ok {
+		return labels
+	}
+	return languageLabels[types.LanguageEnglish]
+}
+
+// pageLink returns the link from a page to another. Locations come from options.PageURL, the page
+// locations of the output format; without it pages are markdown files side by side.
+func pageLink(options GenerationOptions, to *WikiPage) string {
+	if options.PageURL == nil {
+		return fmt.Sprintf("./%s.md", PageFileSlug(to))
+	}
+
+	// Site-absolute locations are used as they are; other pages share a directory
+	target := options.PageURL(to)
+	if strings.HasPrefix(target, "/") {
+		return target
+	}
+	return "./" + path.Base(target)
+}
+
+// tableHeader returns the header row and separator of a markdown table with the given columns
+func tableHeader(columns ...string) string {
+	separators := make([]string, len(columns))
+	for i, column := range columns {
+		separators[i] = strings.Repeat("-", max(3, utf8.RuneCountInString(column)+2))
+	}
+	return "| " + strings.Join(columns, " | ") + " |\n|" + strings.Join(separators, "|") + "|\n"
+}
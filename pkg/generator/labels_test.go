@@ -0,0 +1,55 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kuderr/deepwiki/pkg/processor"
+	"github.com/kuderr/deepwiki/pkg/types"
+)
+
+func TestAnalysisPages_UseLanguage(t *testing.T) {
+	russian := types.LanguageRussian
+	pages := []*WikiPage{
+		BuildEnvVarsPage([]EnvVar{{
+			Name:       "PORT",
+			References: []SourceLocation{{FilePath: "main.go", Line: 3}},
+		}}, russian),
+		BuildMakefileTasksPage(map[string][]MakefileTarget{"Makefile": {{Name: "build"}}}, russian),
+		BuildServicesPage([]processor.ComposeProject{{
+			FilePath: "docker-compose.yml",
+			Services: []processor.ComposeService{{Name: "db", Image: "postgres:16"}},
+		}}, russian),
+		BuildTodosPage([]TodoItem{{FilePath: "main.go", Line: 7, Marker: "TODO", Text: "retry"}}, russian),
+		BuildLicensePage(&LicenseReport{DeclaredLicense: "MIT"}, russian),
+		BuildCIPage([]CIWorkflow{{
+			FilePath: ".gitlab-ci.yml",
+			Provider: CIProviderGitLab,
+			Name:     "GitLab CI",
+			Jobs:     []CIJob{{ID: "test", Stage: "test", Steps: []string{"go test ./..."}}},
+		}}, russian),
+	}
+
+	want := []struct {
+		title   string
+		content string
+	}{
+		{"Переменные окружения", "| Переменная | Где используется |"},
+		{"Задачи", "| Цель | Описание |"},
+		{"Сервисы", "- **Образ:** `postgres:16`"},
+		{"Незавершённая работа", "- **TODO** (строка 7): retry"},
+		{"Лицензия", "- **MIT** (указана в `package.json`)"},
+		{"CI/CD", "### Задание `test`"},
+	}
+	for i, page := range pages {
+		if page == nil {
+			t.Fatalf("Expected page %d to be built", i)
+		}
+		if page.Title != want[i].title || !strings.HasPrefix(page.Content, "# "+want[i].title+"\n") {
+			t.Errorf("Expected the title %q, got %q", want[i].title, page.Title)
+		}
+		if !strings.Contains(page.Content, want[i].content) {
+			t.Errorf("Expected page %s to contain %q, got:\n%s", page.ID, want[i].content, page.Content)
+		}
+	}
+}
@@ -0,0 +1,412 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/kuderr/deepwiki/pkg/scanner"
+	"github.com/kuderr/deepwiki/pkg/types"
+)
+
+// LicensePageID is the ID of the generated license page
+const LicensePageID = "license"
+
+// UnknownLicense is reported when a license cannot be determined
+const UnknownLicense = "Unknown"
+
+// LicenseFile is a license file found in the project root
+type LicenseFile struct {
+	FilePath string `json:"filePath"`
+	License  string `json:"license"` // SPDX identifier, or UnknownLicense
+}
+
+// DependencyLicense is a third-party dependency and its license, if known
+type DependencyLicense struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	Ecosystem string `json:"ecosystem"` // "Go" or "npm"
+	License   string `json:"license"`   // SPDX identifier, or UnknownLicense
+}
+
+// LicenseReport collects the licensing information of a project
+type LicenseReport struct {
+	LicenseFiles      []LicenseFile       `json:"licenseFiles"`
+	DeclaredLicense   string              `json:"declaredLicense,omitempty"` // From package.json "license"
+	SPDXHeaders       map[string][]string `json:"spdxHeaders"`               // SPDX identifier -> files
+	Dependencies      []DependencyLicense `json:"dependencies"`
+	DependencySources []string            `json:"dependencySources"` // Manifests the dependencies were read from
+}
+
+// licenseSignature identifies a license by phrases that appear in its text
+type licenseSignature struct {
+	id      string
+	phrases []string
+}
+
+// licenseSignatures are checked in order, so more specific licenses come first
+var licenseSignatures = []licenseSignature{
+	{"AGPL-3.0", []string{"gnu affero general public license", "version 3"}},
+	{"LGPL-3.0", []string{"gnu lesser general public license", "version 3"}},
+	{"LGPL-2.1", []string{"gnu lesser general public license", "version 2.1"}},
+	{"GPL-3.0", []string{"gnu general public license", "version 3"}},
+	{"GPL-2.0", []string{"gnu general public license", "version 2"}},
+	{"Apache-2.0", []string{"apache license", "version 2.0"}},
+	{"MPL-2.0", []string{"mozilla public license", "2.0"}},
+	{"MIT", []string{"permission is hereby granted, free of charge", "the above copyright notice"}},
+	{"ISC", []string{"permission to use, copy, modify, and/or distribute this software"}},
+	{"BSD-3-Clause", []string{"redistribution and use in source and binary forms", "neither the name"}},
+	{"BSD-2-Clause", []string{"redistribution and use in source and binary forms"}},
+	{"Unlicense", []string{"this is free and unencumbered software released into the public domain"}},
+}
+
+// licenseFileNames are the base names (without extension) recognized as license files
+var licenseFileNames = map[string]bool{
+	"license": true, "licence": true, "copying": true, "unlicense": true,
+	"license-mit": true, "license-apache": true,
+}
+
+// spdxHeaderPattern matches an SPDX-License-Identifier tag in a source file
+var spdxHeaderPattern = regexp.MustCompile(`SPDX-License-Identifier:\s*([A-Za-z0-9.+\-() ]+?)\s*(?:\*/|-->|$)`)
+
+// spdxHeaderLines limits the SPDX scan to the top of each file, where headers live
+const spdxHeaderLines = 20
+
+// DetectLicense identifies the license in a license text, or returns UnknownLicense
+func DetectLicense(text string) string {
+	if match := spdxHeaderPattern.FindStringSubmatch(text); match != nil {
+		return match[1]
+	}
+
+	normalized := strings.ToLower(strings.Join(strings.Fields(text), " "))
+	for _, signature := range licenseSignatures {
+		matched := true
+		for _, phrase := range signature.phrases {
+			if !strings.Contains(normalized, phrase) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return signature.id
+		}
+	}
+	return UnknownLicense
+}
+
+// ExtractLicenseReport detects the project license, SPDX headers in scanned files and
+// dependency licenses from go.mod and package.json in the project root
+func ExtractLicenseReport(projectPath string, files []scanner.FileInfo) *LicenseReport {
+	report := &LicenseReport{SPDXHeaders: make(map[string][]string)}
+
+	if projectPath != "" {
+		report.LicenseFiles = findLicenseFiles(projectPath)
+		report.addGoDependencies(projectPath)
+		report.addNpmDependencies(projectPath)
+	}
+
+	for _, file := range files {
+		if file.IsDir || file.IsBinary {
+			continue
+		}
+
+		content, err := readSourceFile(file)
+		if err != nil {
+			continue
+		}
+
+		lines := strings.SplitN(content, "\n", spdxHeaderLines+1)
+		for _, line := range lines[:min(len(lines), spdxHeaderLines)] {
+			if match := spdxHeaderPattern.FindStringSubmatch(line); match != nil {
+				report.SPDXHeaders[match[1]] = append(report.SPDXHeaders[match[1]], file.Path)
+				break
+			}
+		}
+	}
+
+	return report
+}
+
+// findLicenseFiles returns the license files in dir, sorted by name
+func findLicenseFiles(dir string) []LicenseFile {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var licenses []LicenseFile
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		name := entry.Name()
+		base := strings.ToLower(strings.TrimSuffix(name, filepath.Ext(name)))
+		if !licenseFileNames[base] {
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		licenses = append(licenses, LicenseFile{FilePath: name, License: DetectLicense(string(content))})
+	}
+
+	return licenses
+}
+
+// addGoDependencies lists the requirements of go.mod. Licenses are resolved from the
+// vendor directory or the local module cache when the module source is available.
+func (r *LicenseReport) addGoDependencies(projectPath string) {
+	content, err := os.ReadFile(filepath.Join(projectPath, "go.mod"))
+	if err != nil {
+		return
+	}
+	r.DependencySources = append(r.DependencySources, "go.mod")
+
+	moduleCache := goModuleCache()
+	for _, dep := range parseGoModRequires(string(content)) {
+		dirs := []string{filepath.Join(projectPath, "vendor", filepath.FromSlash(dep.Name))}
+		if moduleCache != "" {
+			dirs = append(dirs, filepath.Join(moduleCache, escapeModulePath(dep.Name)+"@"+dep.Version))
+		}
+
+		dep.License = UnknownLicense
+		for _, dir := range dirs {
+			if licenses := findLicenseFiles(dir); len(licenses) > 0 {
+				dep.License = licenses[0].License
+				break
+			}
+		}
+		r.Dependencies = append(r.Dependencies, dep)
+	}
+}
+
+// addNpmDependencies lists the dependencies of package.json. Licenses are read from the
+// installed package manifests in node_modules when present.
+func (r *LicenseReport) addNpmDependencies(projectPath string) {
+	content, err := os.ReadFile(filepath.Join(projectPath, "package.json"))
+	if err != nil {
+		return
+	}
+
+	var manifest struct {
+		License         json.RawMessage   `json:"license"`
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return
+	}
+	r.DependencySources = append(r.DependencySources, "package.json")
+	r.DeclaredLicense = npmLicense(manifest.License)
+
+	deps := make(map[string]string, len(manifest.Dependencies)+len(manifest.DevDependencies))
+	for name, version := range manifest.DevDependencies {
+		deps[name] = version
+	}
+	for name, version := range manifest.Dependencies {
+		deps[name] = version
+	}
+
+	names := make([]string, 0, len(deps))
+	for name := range deps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		license := UnknownLicense
+		pkgPath := filepath.Join(projectPath, "node_modules", filepath.FromSlash(name), "package.json")
+		if pkgContent, err := os.ReadFile(pkgPath); err == nil {
+			var pkg struct {
+				License json.RawMessage `json:"license"`
+			}
+			if json.Unmarshal(pkgContent, &pkg) == nil {
+				if declared := npmLicense(pkg.License); declared != "" {
+					license = declared
+				}
+			}
+		}
+
+		r.Dependencies = append(r.Dependencies, DependencyLicense{
+			Name:      name,
+			Version:   deps[name],
+			Ecosystem: "npm",
+			License:   license,
+		})
+	}
+}
+
+// npmLicense decodes a package.json license field, which is either a string or {"type": ...}
+func npmLicense(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+
+	var license string
+	if json.Unmarshal(raw, &license) == nil {
+		return license
+	}
+
+	var legacy struct {
+		Type string `json:"type"`
+	}
+	if json.Unmarshal(raw, &legacy) == nil {
+		return legacy.Type
+	}
+	return ""
+}
+
+// parseGoModRequires returns the direct requirements of a go.mod file.
+// Indirect requirements are skipped since they are not chosen by the project.
+func parseGoModRequires(content string) []DependencyLicense {
+	var deps []DependencyLicense
+	inBlock := false
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+
+		switch {
+		case line == "require (":
+			inBlock = true
+			continue
+		case inBlock && line == ")":
+			inBlock = false
+			continue
+		case strings.HasPrefix(line, "require "):
+			line = strings.TrimSpace(strings.TrimPrefix(line, "require "))
+		case !inBlock:
+			continue
+		}
+
+		if strings.Contains(line, "// indirect") {
+			continue
+		}
+		if idx := strings.Index(line, "//"); idx >= 0 {
+			line = line[:idx]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		deps = append(deps, DependencyLicense{Name: fields[0], Version: fields[1], Ecosystem: "Go"})
+	}
+
+	return deps
+}
+
+// goModuleCache returns the local Go module cache directory, if it can be determined
+func goModuleCache() string {
+	if cache := os.Getenv("GOMODCACHE"); cache != "" {
+		return cache
+	}
+	if gopath := os.Getenv("GOPATH"); gopath != "" {
+		return filepath.Join(filepath.SplitList(gopath)[0], "pkg", "mod")
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, "go", "pkg", "mod")
+	}
+	return ""
+}
+
+// escapeModulePath applies the module cache case encoding ("A" becomes "!a")
+func escapeModulePath(path string) string {
+	var escaped strings.Builder
+	for _, r := range path {
+		if unicode.IsUpper(r) {
+			escaped.WriteRune('!')
+			r = unicode.ToLower(r)
+		}
+		escaped.WriteRune(r)
+	}
+	return filepath.FromSlash(escaped.String())
+}
+
+// BuildLicensePage renders the license page in language, or returns nil if there is no licensing
+// information
+func BuildLicensePage(report *LicenseReport, language types.Language) *WikiPage {
+	if report == nil || (len(report.LicenseFiles) == 0 && report.DeclaredLicense == "" &&
+		len(report.SPDXHeaders) == 0 && len(report.DependencySources) == 0) {
+		return nil
+	}
+
+	filePaths := make(map[string]bool)
+	labels := labelsFor(language)
+	declared := fmt.Sprintf("- **%s** (%s)\n", report.DeclaredLicense, fmt.Sprintf(labels.DeclaredIn, "`package.json`"))
+
+	var content strings.Builder
+	fmt.Fprintf(&content, "# %s\n\n%s\n\n", labels.License, labels.LicenseIntro)
+
+	fmt.Fprintf(&content, "## %s\n\n", labels.ProjectLicense)
+	switch {
+	case len(report.LicenseFiles) > 0:
+		for _, file := range report.LicenseFiles {
+			content.WriteString(fmt.Sprintf("- **%s** (`%s`)\n", file.License, file.FilePath))
+			filePaths[file.FilePath] = true
+		}
+		if report.DeclaredLicense != "" {
+			content.WriteString(declared)
+		}
+	case report.DeclaredLicense != "":
+		content.WriteString(declared)
+	default:
+		content.WriteString(labels.NoLicenseFile + "\n")
+	}
+
+	if len(report.SPDXHeaders) > 0 {
+		fmt.Fprintf(&content, "\n## %s\n\n", labels.SPDXHeaders)
+		content.WriteString(tableHeader(labels.License, labels.Files))
+		for _, id := range sortedMapKeys(report.SPDXHeaders) {
+			paths := report.SPDXHeaders[id]
+			content.WriteString(fmt.Sprintf("| %s | %d |\n", id, len(paths)))
+			for _, path := range paths {
+				filePaths[path] = true
+			}
+		}
+	}
+
+	if len(report.DependencySources) > 0 {
+		fmt.Fprintf(&content, "\n## %s\n\n", labels.Dependencies)
+		content.WriteString(fmt.Sprintf(labels.DirectDependencies+"\n\n", codeList(report.DependencySources)))
+		for _, source := range report.DependencySources {
+			filePaths[source] = true
+		}
+
+		if len(report.Dependencies) == 0 {
+			content.WriteString(labels.NoDependencies + "\n")
+		} else {
+			content.WriteString(tableHeader(labels.Dependency, labels.Version, labels.Ecosystem, labels.License))
+			for _, dep := range report.Dependencies {
+				content.WriteString(fmt.Sprintf("| `%s` | %s | %s | %s |\n",
+					dep.Name, dep.Version, dep.Ecosystem, dep.License))
+			}
+		}
+	}
+
+	return &WikiPage{
+		ID:          LicensePageID,
+		Title:       labels.License,
+		Description: labels.LicenseDescription,
+		Content:     content.String(),
+		FilePaths:   sortedKeys(filePaths),
+		Importance:  "low",
+	}
+}
+
+// sortedMapKeys returns the keys of m in sorted order
+func sortedMapKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
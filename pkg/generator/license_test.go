@@ -0,0 +1,145 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kuderr/deepwiki/pkg/scanner"
+	"github.com/kuderr/deepwiki/pkg/types"
+)
+
+const mitLicenseText = `MIT License
+
+Copyright (c) 2024 Example Authors
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction.
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+`
+
+func TestDetectLicense(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"mit", mitLicenseText, "MIT"},
+		{"apache", "Apache License\nVersion 2.0, January 2004", "Apache-2.0"},
+		{"lgpl before gpl", "GNU LESSER GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007", "LGPL-3.0"},
+		{"spdx tag", "// SPDX-License-Identifier: BSD-3-Clause\n", "BSD-3-Clause"},
+		{"unknown", "All rights reserved.", UnknownLicense},
+	}
+
+	for _, test := range tests {
+		if got := DetectLicense(test.text); got != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, got)
+		}
+	}
+}
+
+func TestExtractLicenseReport_MIT(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, "LICENSE", mitLicenseText, "")
+	writeFixture(t, dir, "go.mod", `module example.com/app
+
+go 1.24
+
+require (
+	github.com/spf13/cobra v1.9.1
+	golang.org/x/sys v0.33.0 // indirect
+)
+
+require gopkg.in/yaml.v3 v3.0.1
+`, "")
+	writeFixture(t, dir, "vendor/github.com/spf13/cobra/LICENSE.txt", "Apache License\nVersion 2.0", "")
+
+	files := []scanner.FileInfo{
+		writeFixture(t, dir, "main.go", "// SPDX-License-Identifier: MIT\npackage main\n", "code"),
+	}
+
+	t.Setenv("GOMODCACHE", t.TempDir())
+	report := ExtractLicenseReport(dir, files)
+
+	if len(report.LicenseFiles) != 1 || report.LicenseFiles[0].License != "MIT" {
+		t.Fatalf("Expected MIT LICENSE file, got %+v", report.LicenseFiles)
+	}
+	if paths := report.SPDXHeaders["MIT"]; len(paths) != 1 || paths[0] != "main.go" {
+		t.Errorf("Expected SPDX header in main.go, got %v", report.SPDXHeaders)
+	}
+
+	if len(report.Dependencies) != 2 {
+		t.Fatalf("Expected 2 direct Go dependencies, got %+v", report.Dependencies)
+	}
+	if dep := report.Dependencies[0]; dep.Name != "github.com/spf13/cobra" || dep.License != "Apache-2.0" {
+		t.Errorf("Expected vendored cobra to be Apache-2.0, got %+v", dep)
+	}
+	if dep := report.Dependencies[1]; dep.Name != "gopkg.in/yaml.v3" || dep.License != UnknownLicense {
+		t.Errorf("Expected yaml.v3 with unknown license, got %+v", dep)
+	}
+
+	page := BuildLicensePage(report, types.LanguageEnglish)
+	if page == nil {
+		t.Fatal("Expected page to be built")
+	}
+	if page.ID != LicensePageID {
+		t.Errorf("Expected page ID %s, got %s", LicensePageID, page.ID)
+	}
+	for _, want := range []string{
+		"**MIT** (`LICENSE`)",
+		"| MIT | 1 |",
+		"| `github.com/spf13/cobra` | v1.9.1 | Go | Apache-2.0 |",
+	} {
+		if !strings.Contains(page.Content, want) {
+			t.Errorf("Expected page to contain %q, got:\n%s", want, page.Content)
+		}
+	}
+}
+
+func TestExtractLicenseReport_NPM(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, "package.json", `{
+  "name": "app",
+  "license": "ISC",
+  "dependencies": {"left-pad": "^1.3.0"},
+  "devDependencies": {"@types/node": "^20.0.0"}
+}`, "")
+	writeFixture(t, dir, "node_modules/left-pad/package.json", `{"license": {"type": "WTFPL"}}`, "")
+
+	report := ExtractLicenseReport(dir, nil)
+
+	if report.DeclaredLicense != "ISC" {
+		t.Errorf("Expected declared ISC license, got %q", report.DeclaredLicense)
+	}
+	if len(report.Dependencies) != 2 {
+		t.Fatalf("Expected 2 npm dependencies, got %+v", report.Dependencies)
+	}
+	if dep := report.Dependencies[1]; dep.Name != "left-pad" || dep.License != "WTFPL" {
+		t.Errorf("Expected left-pad with WTFPL license, got %+v", dep)
+	}
+}
+
+func TestBuildLicensePage_MissingLicense(t *testing.T) {
+	if page := BuildLicensePage(ExtractLicenseReport(t.TempDir(), nil), types.LanguageEnglish); page != nil {
+		t.Error("Expected no page for a project without any licensing information")
+	}
+	if page := BuildLicensePage(ExtractLicenseReport("", nil), types.LanguageEnglish); page != nil {
+		t.Error("Expected no page without a project path")
+	}
+
+	dir := t.TempDir()
+	writeFixture(t, dir, "go.mod", "module example.com/app\n\ngo 1.24\n", "")
+
+	page := BuildLicensePage(ExtractLicenseReport(dir, nil), types.LanguageEnglish)
+	if page == nil {
+		t.Fatal("Expected page to be built from go.mod")
+	}
+	if !strings.Contains(page.Content, "No license file was found") {
+		t.Errorf("Expected missing license note, got:\n%s", page.Content)
+	}
+	if !strings.Contains(page.Content, "No dependencies are declared") {
+		t.Errorf("Expected empty dependency note, got:\n%s", page.Content)
+	}
+}
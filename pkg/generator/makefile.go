@@ -0,0 +1,142 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/kuderr/deepwiki/pkg/scanner"
+	"github.com/kuderr/deepwiki/pkg/types"
+)
+
+// MakefileTasksPageID is the ID of the generated Makefile tasks page
+const MakefileTasksPageID = "makefile-tasks"
+
+// MakefileTarget is a target defined in a Makefile
+type MakefileTarget struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Line        int    `json:"line"`
+}
+
+// makefileRulePattern matches a rule line: targets, a single or double colon, and the rest.
+// Variable assignments (":=", "::=") are rejected separately.
+var makefileRulePattern = regexp.MustCompile(`^([A-Za-z0-9_./\- ]+?)\s*::?(.*)$`)
+
+// ParseMakefileTargets extracts targets and their documentation from Makefile content.
+// A target's description is taken from the comment block directly above it, or from
+// a trailing "## description" comment on the rule line.
+func ParseMakefileTargets(content string) []MakefileTarget {
+	var targets []MakefileTarget
+	var comments []string
+	seen := make(map[string]bool)
+
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		if strings.HasPrefix(line, "#") {
+			comments = append(comments, strings.TrimSpace(strings.TrimLeft(line, "#")))
+			continue
+		}
+
+		// Recipe lines and blank lines end any pending comment block
+		if strings.HasPrefix(line, "\t") || strings.TrimSpace(line) == "" {
+			comments = nil
+			continue
+		}
+
+		match := makefileRulePattern.FindStringSubmatch(line)
+		if match == nil || strings.HasPrefix(match[2], "=") || strings.HasPrefix(match[2], ":=") {
+			comments = nil
+			continue
+		}
+
+		description := strings.Join(comments, " ")
+		if idx := strings.Index(match[2], "##"); idx >= 0 {
+			description = strings.TrimSpace(match[2][idx+2:])
+		}
+		comments = nil
+
+		for _, name := range strings.Fields(match[1]) {
+			// Special targets (.PHONY, .DEFAULT, ...) and pattern rules are not tasks
+			if strings.HasPrefix(name, ".") || strings.Contains(name, "%") || seen[name] {
+				continue
+			}
+			seen[name] = true
+			targets = append(targets, MakefileTarget{
+				Name:        name,
+				Description: description,
+				Line:        i + 1,
+			})
+		}
+	}
+
+	return targets
+}
+
+// isMakefile reports whether a scanned file is a Makefile
+func isMakefile(file scanner.FileInfo) bool {
+	return file.Category == string(scanner.CategoryConfig) && file.Extension == ".makefile"
+}
+
+// ExtractMakefileTargets parses every Makefile in files, keyed by file path
+func ExtractMakefileTargets(files []scanner.FileInfo) map[string][]MakefileTarget {
+	result := make(map[string][]MakefileTarget)
+
+	for _, file := range files {
+		if file.IsDir || !isMakefile(file) {
+			continue
+		}
+
+		content, err := readSourceFile(file)
+		if err != nil {
+			continue
+		}
+
+		if targets := ParseMakefileTargets(content); len(targets) > 0 {
+			result[file.Path] = targets
+		}
+	}
+
+	return result
+}
+
+// BuildMakefileTasksPage renders the Makefile tasks page in language, or returns nil if there are
+// no targets
+func BuildMakefileTasksPage(targetsByFile map[string][]MakefileTarget, language types.Language) *WikiPage {
+	if len(targetsByFile) == 0 {
+		return nil
+	}
+
+	paths := make([]string, 0, len(targetsByFile))
+	for path := range targetsByFile {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	labels := labelsFor(language)
+	var content strings.Builder
+	fmt.Fprintf(&content, "# %s\n\n%s\n", labels.Tasks, labels.TasksIntro)
+
+	for _, path := range paths {
+		content.WriteString(fmt.Sprintf("\n## `%s`\n\n", path))
+		content.WriteString(tableHeader(labels.Target, labels.Description))
+		for _, target := range targetsByFile[path] {
+			description := target.Description
+			if description == "" {
+				description = "-"
+			}
+			content.WriteString(fmt.Sprintf("| `%s` | %s |\n", target.Name, strings.ReplaceAll(description, "|", "\\|")))
+		}
+	}
+
+	return &WikiPage{
+		ID:          MakefileTasksPageID,
+		Title:       labels.Tasks,
+		Description: labels.TasksDescription,
+		Content:     content.String(),
+		FilePaths:   paths,
+		Importance:  "medium",
+	}
+}
@@ -0,0 +1,95 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kuderr/deepwiki/pkg/scanner"
+	"github.com/kuderr/deepwiki/pkg/types"
+)
+
+const testMakefile = `.PHONY: build test lint help
+
+GO ?= go
+VERSION := 1.0.0
+
+# Build the deepwiki binary
+build:
+	$(GO) build -o bin/deepwiki .
+
+# Run the test suite
+# with race detection enabled
+test: build
+	$(GO) test -race ./...
+
+lint: ## Run golangci-lint
+	golangci-lint run
+
+%.o: %.c
+	cc -c $<
+
+clean:
+	rm -rf bin
+`
+
+func TestParseMakefileTargets(t *testing.T) {
+	targets := ParseMakefileTargets(testMakefile)
+
+	expected := []MakefileTarget{
+		{Name: "build", Description: "Build the deepwiki binary", Line: 7},
+		{Name: "test", Description: "Run the test suite with race detection enabled", Line: 12},
+		{Name: "lint", Description: "Run golangci-lint", Line: 15},
+		{Name: "clean", Description: "", Line: 21},
+	}
+
+	if len(targets) != len(expected) {
+		t.Fatalf("Expected %d targets, got %d: %+v", len(expected), len(targets), targets)
+	}
+
+	for i, want := range expected {
+		if targets[i] != want {
+			t.Errorf("Target %d: expected %+v, got %+v", i, want, targets[i])
+		}
+	}
+}
+
+func TestExtractMakefileTargets(t *testing.T) {
+	dir := t.TempDir()
+
+	makefile := writeFixture(t, dir, "Makefile", testMakefile, string(scanner.CategoryConfig))
+	makefile.Extension = scanner.GetFileExtension(makefile.Name)
+
+	other := writeFixture(t, dir, "main.go", "package main\n\nfunc main() {}\n", string(scanner.CategoryCode))
+	other.Extension = ".go"
+
+	targetsByFile := ExtractMakefileTargets([]scanner.FileInfo{makefile, other})
+	if len(targetsByFile) != 1 {
+		t.Fatalf("Expected targets from 1 file, got %d", len(targetsByFile))
+	}
+
+	page := BuildMakefileTasksPage(targetsByFile, types.LanguageEnglish)
+	if page == nil {
+		t.Fatal("Expected tasks page to be built")
+	}
+
+	for _, row := range []string{
+		"| `build` | Build the deepwiki binary |",
+		"| `test` | Run the test suite with race detection enabled |",
+		"| `lint` | Run golangci-lint |",
+		"| `clean` | - |",
+	} {
+		if !strings.Contains(page.Content, row) {
+			t.Errorf("Expected tasks page to contain %q", row)
+		}
+	}
+
+	if strings.Contains(page.Content, ".PHONY") || strings.Contains(page.Content, "%.o") {
+		t.Error("Special targets and pattern rules should not be listed")
+	}
+}
+
+func TestBuildMakefileTasksPage_Empty(t *testing.T) {
+	if page := BuildMakefileTasksPage(nil, types.LanguageEnglish); page != nil {
+		t.Error("Expected no page without Makefile targets")
+	}
+}
@@ -124,6 +124,7 @@ func (p *XMLParser) ConvertToWikiStructure(response *WikiStructureResponse, opti
 			Description: pageReq.Description,
 			Importance:  normalizeImportance(pageReq.Importance),
 			ParentID:    pageReq.ParentID,
+			SourceDoc:   strings.TrimSpace(pageReq.SourceDoc),
 			CreatedAt:   time.Now(),
 		}
 	}
@@ -0,0 +1,66 @@
+package generator
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// planPageFiles sets the files each generated page of structure would be written from, as
+// retrieved for it, without generating content
+func (g *WikiGenerator) planPageFiles(structure *WikiStructure, options GenerationOptions) {
+	for i := range structure.Pages {
+		page := &structure.Pages[i]
+		if page.SourceDoc != "" {
+			continue
+		}
+
+		docs, err := g.retrievePageDocuments(page, options)
+		if err != nil {
+			g.logger.Warn("Failed to plan page files", "page", page.ID, "error", err)
+			continue
+		}
+		page.FilePaths = nil
+		for _, doc := range docs {
+			if !slices.Contains(page.FilePaths, doc.FilePath) {
+				page.FilePaths = append(page.FilePaths, doc.FilePath)
+			}
+		}
+		page.SourceFiles = len(docs)
+	}
+}
+
+// WritePlan writes the pages of a wiki structure that generation would write: the title,
+// importance and source files of each page, child pages indented below their parent
+func WritePlan(w io.Writer, structure *WikiStructure) error {
+	if _, err := fmt.Fprintf(w, "Planned wiki: %s (%d pages)\n", structure.Title, len(structure.Pages)); err != nil {
+		return fmt.Errorf("failed to write plan: %w", err)
+	}
+
+	for i, page := range structure.Pages {
+		indent := ""
+		if page.ParentID != "" {
+			indent = "   "
+		}
+
+		var plan strings.Builder
+		fmt.Fprintf(&plan, "\n%s%d. %s [%s] (%s)\n", indent, i+1, page.Title, page.Importance, page.ID)
+		if page.Description != "" {
+			fmt.Fprintf(&plan, "%s   %s\n", indent, page.Description)
+		}
+		switch {
+		case page.SourceDoc != "":
+			fmt.Fprintf(&plan, "%s   Existing doc: %s\n", indent, page.SourceDoc)
+		case len(page.FilePaths) > 0:
+			fmt.Fprintf(&plan, "%s   Files: %s\n", indent, strings.Join(page.FilePaths, ", "))
+		default:
+			fmt.Fprintf(&plan, "%s   Files: none retrieved\n", indent)
+		}
+
+		if _, err := io.WriteString(w, plan.String()); err != nil {
+			return fmt.Errorf("failed to write plan: %w", err)
+		}
+	}
+	return nil
+}
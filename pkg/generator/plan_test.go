@@ -0,0 +1,52 @@
+package generator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kuderr/deepwiki/pkg/types"
+)
+
+func TestGenerateWiki_PlanOnly(t *testing.T) {
+	generator, provider := newScriptedGenerator(
+		choice(`<wiki_structure><title>demo</title><pages>
+  <page><id>overview</id><title>Overview</title><description>What demo does</description>
+    <importance>high</importance></page>
+  <page><id>cli</id><title>Command Line</title><importance>medium</importance><parent_id>overview</parent_id></page>
+</pages></wiki_structure>`, "stop"),
+		choice("# Page\n\nContent that must not be generated.", "stop"),
+	)
+
+	options := GenerationOptions{ProjectName: "demo", Language: types.LanguageEnglish, PlanOnly: true}
+	result, err := generator.GenerateWiki(context.Background(), nil, options)
+	if err != nil {
+		t.Fatalf("GenerateWiki failed: %v", err)
+	}
+
+	if len(provider.maxTokens) != 1 {
+		t.Errorf("Expected only the structure request, got %d LLM calls", len(provider.maxTokens))
+	}
+	if len(result.Pages) != 0 || result.TotalPages != 0 {
+		t.Errorf("Expected no generated pages, got %d", len(result.Pages))
+	}
+	for _, page := range result.Structure.Pages {
+		if page.Content != "" {
+			t.Errorf("Expected page %s to have no content, got %q", page.ID, page.Content)
+		}
+	}
+
+	var plan strings.Builder
+	if err := WritePlan(&plan, result.Structure); err != nil {
+		t.Fatalf("WritePlan failed: %v", err)
+	}
+	for _, want := range []string{
+		"Planned wiki: demo (2 pages)",
+		"1. Overview [high] (overview)\n   What demo does\n   Files: test.go\n",
+		"   2. Command Line [medium] (cli)\n      Files: test.go\n",
+	} {
+		if !strings.Contains(plan.String(), want) {
+			t.Errorf("Expected the plan to contain %q, got:\n%s", want, plan.String())
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package prompts
+
+// AskData contains data for answering a question about a project
+type AskData struct {
+	ProjectName string
+	Question    string
+	Files       string // Source excerpts retrieved for the question
+}
+
+// AskPrompt is the template for answering a question from retrieved source files
+const AskPrompt = `
+You are an expert software engineer answering questions about the **{{.ProjectName}}** codebase.
+
+<files>
+{{.Files}}
+</files>
+
+<question>
+{{.Question}}
+</question>
+
+# RULES
+1. Answer using only the files above; say so when they do not contain the answer.
+2. Name the files your answer relies on.
+3. Answer in the language of the question, in concise markdown.
+`
+
+// RegisterAskPrompt registers the question answering prompt template
+func RegisterAskPrompt(tm *TemplateManager) error {
+	return tm.RegisterTemplate("ask", AskPrompt)
+}
@@ -0,0 +1,31 @@
+package prompts
+
+import "github.com/kuderr/deepwiki/pkg/types"
+
+// CISummaryData contains data for summarizing CI/CD pipelines
+type CISummaryData struct {
+	ProjectName string
+	Language    types.Language
+	Pipelines   string // Markdown listing of workflows, triggers, jobs and steps
+}
+
+// CISummaryPrompt is the template for summarizing the CI/CD page
+const CISummaryPrompt = `
+You are an expert technical writer and DevOps engineer.
+
+Task → Summarize how CI/CD works for **{{.ProjectName}}** in **{{.Language}}**.
+
+<pipelines>
+{{.Pipelines}}
+</pipelines>
+
+# RULES
+1. Write one or two short paragraphs of plain Markdown: no headings, lists or diagrams.
+2. Explain when pipelines run, what they check or build, and what they publish or deploy.
+3. Only describe what the pipelines above show; do not invent jobs or tools.
+`
+
+// RegisterCISummaryPrompt registers the CI/CD summary prompt template
+func RegisterCISummaryPrompt(tm *TemplateManager) error {
+	return tm.RegisterTemplate("ci_summary", CISummaryPrompt)
+}
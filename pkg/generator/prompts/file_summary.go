@@ -0,0 +1,33 @@
+package prompts
+
+import "github.com/kuderr/deepwiki/pkg/types"
+
+// FileSummaryData contains data for summarizing a single source file
+type FileSummaryData struct {
+	ProjectName  string
+	Language     types.Language
+	FilePath     string
+	FileLanguage string // Language of the source, as detected by the scanner
+	Content      string // File content, possibly cut short
+}
+
+// FileSummaryPrompt is the template for the summary above the source of a file page
+const FileSummaryPrompt = `
+You are an expert software engineer and technical writer.
+
+Task → Summarize the file **{{.FilePath}}** of **{{.ProjectName}}** in **{{.Language}}**.
+
+<file path="{{.FilePath}}" language="{{.FileLanguage}}">
+{{.Content}}
+</file>
+
+# RULES
+1. Write one short paragraph of plain Markdown: no headings, lists, diagrams or code blocks.
+2. Explain what the file is for and name its most important declarations or settings.
+3. Only describe what the file above shows; do not invent behavior.
+`
+
+// RegisterFileSummaryPrompt registers the file summary prompt template
+func RegisterFileSummaryPrompt(tm *TemplateManager) error {
+	return tm.RegisterTemplate("file_summary", FileSummaryPrompt)
+}
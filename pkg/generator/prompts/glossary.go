@@ -0,0 +1,38 @@
+package prompts
+
+import "github.com/kuderr/deepwiki/pkg/types"
+
+// GlossaryData contains data for extracting the glossary of a wiki
+type GlossaryData struct {
+	ProjectName string
+	Language    types.Language
+	Pages       string // Titles and opening words of the generated pages
+}
+
+// GlossaryPrompt is the template for extracting domain terms from the generated pages
+const GlossaryPrompt = `
+You are an expert technical writer creating the glossary of the **{{.ProjectName}}** documentation.
+
+Task → List the domain-specific terms a newcomer to **{{.ProjectName}}** needs explained and define them
+in **{{.Language}}**.
+
+<pages>
+{{.Pages}}
+</pages>
+
+# RULES
+1. Pick terms that recur across the pages above and have a meaning specific to this project or its domain.
+2. Skip general programming words such as function, variable, API or database.
+3. Write each term exactly as the pages spell it, in its singular form.
+4. Define each term in one or two sentences, using only what the pages say.
+5. List at most 40 terms, in this exact format and nothing else:
+
+<glossary>
+<term><name>Term</name><definition>Definition.</definition></term>
+</glossary>
+`
+
+// RegisterGlossaryPrompt registers the glossary prompt template
+func RegisterGlossaryPrompt(tm *TemplateManager) error {
+	return tm.RegisterTemplate("glossary", GlossaryPrompt)
+}
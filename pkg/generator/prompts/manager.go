@@ -31,6 +31,36 @@ func initializeDefaultPrompts(tm *TemplateManager) {
 		fmt.Println(err.Error())
 		panic("failed to register page content prompt: " + err.Error())
 	}
+
+	// Register page batch prompt
+	if err := RegisterPageBatchPrompt(tm); err != nil {
+		panic("failed to register page batch prompt: " + err.Error())
+	}
+
+	// Register CI/CD summary prompt
+	if err := RegisterCISummaryPrompt(tm); err != nil {
+		panic("failed to register CI summary prompt: " + err.Error())
+	}
+
+	// Register page importance prompt
+	if err := RegisterPageImportancePrompt(tm); err != nil {
+		panic("failed to register page importance prompt: " + err.Error())
+	}
+
+	// Register glossary prompt
+	if err := RegisterGlossaryPrompt(tm); err != nil {
+		panic("failed to register glossary prompt: " + err.Error())
+	}
+
+	// Register file summary prompt
+	if err := RegisterFileSummaryPrompt(tm); err != nil {
+		panic("failed to register file summary prompt: " + err.Error())
+	}
+
+	// Register question answering prompt
+	if err := RegisterAskPrompt(tm); err != nil {
+		panic("failed to register ask prompt: " + err.Error())
+	}
 }
 
 // ExecuteWikiStructurePrompt executes the wiki structure generation prompt
@@ -38,7 +68,42 @@ func ExecuteWikiStructurePrompt(data WikiStructureData) (string, error) {
 	return GetDefaultManager().Execute("wiki_structure", data)
 }
 
+// ExecutePageContextPrompt executes the prompt of the context shared by the pages of a wiki
+func ExecutePageContextPrompt(data PageContextData) (string, error) {
+	return GetDefaultManager().Execute("page_context", data)
+}
+
 // ExecutePageContentPrompt executes the page content generation prompt
 func ExecutePageContentPrompt(data PageContentData) (string, error) {
 	return GetDefaultManager().Execute("page_content", data)
 }
+
+// ExecutePageBatchPrompt executes the page batch generation prompt
+func ExecutePageBatchPrompt(data PageBatchData) (string, error) {
+	return GetDefaultManager().Execute("page_batch", data)
+}
+
+// ExecuteCISummaryPrompt executes the CI/CD summary prompt
+func ExecuteCISummaryPrompt(data CISummaryData) (string, error) {
+	return GetDefaultManager().Execute("ci_summary", data)
+}
+
+// ExecutePageImportancePrompt executes the page importance prompt
+func ExecutePageImportancePrompt(data PageImportanceData) (string, error) {
+	return GetDefaultManager().Execute("page_importance", data)
+}
+
+// ExecuteGlossaryPrompt executes the glossary prompt
+func ExecuteGlossaryPrompt(data GlossaryData) (string, error) {
+	return GetDefaultManager().Execute("glossary", data)
+}
+
+// ExecuteFileSummaryPrompt executes the file summary prompt
+func ExecuteFileSummaryPrompt(data FileSummaryData) (string, error) {
+	return GetDefaultManager().Execute("file_summary", data)
+}
+
+// ExecuteAskPrompt executes the question answering prompt
+func ExecuteAskPrompt(data AskData) (string, error) {
+	return GetDefaultManager().Execute("ask", data)
+}
@@ -0,0 +1,75 @@
+package prompts
+
+import "github.com/kuderr/deepwiki/pkg/types"
+
+// PageBatchData contains data for generating several wiki pages with one request
+type PageBatchData struct {
+	ProjectName string
+	Language    types.Language
+	FileTree    string
+	Pages       []BatchPage
+	OtherPages  []PageSummary
+}
+
+// BatchPage is a page of a batch, with the source files retrieved for it
+type BatchPage struct {
+	ID            string
+	Title         string
+	Description   string
+	RelevantFiles string
+}
+
+// PageBatchPrompt is the template for generating several short wiki pages as a JSON array
+const PageBatchPrompt = `
+You are an expert technical writer and software architect.
+
+Task → Write the {{len .Pages}} short pages below for {{.ProjectName}}.
+Generate everything in **{{.Language}}**.
+
+<file_tree>
+{{.FileTree}}
+</file_tree>
+
+# PAGES TO WRITE
+{{range $page := .Pages}}
+<page id="{{$page.ID}}">
+  <title>{{$page.Title}}</title>
+  <page_description>
+{{$page.Description}}
+  </page_description>
+  <relevant_files>
+{{$page.RelevantFiles}}
+  </relevant_files>
+</page>
+{{end}}
+
+# Registry of already-covered pages (do not repeat)
+<other_pages>
+  <pages>
+	{{range $page := .OtherPages }}
+    <page>
+      <title>{{$page.Title}}</title>
+      <description>{{$page.Description}}</description>
+    </page>
+	{{end}}
+  </pages>
+</other_pages>
+
+If you need to mention a topic that belongs to an entry in <other_pages>, replace detailed text with md link.
+
+# HARD RULES
+1. **Truth-only**: base every statement of a page on its <relevant_files>; do not invent behaviour.
+2. **Scope**: each page covers only its <page_description>; link other pages instead of duplicating them.
+3. **Structure**: each page starts with a "# <title>" heading, a short overview, and a Mermaid diagram
+   when the sources show a flow or relationships worth drawing. Diagrams must compile.
+4. **Length target**: <=600 words per page.
+5. **Language**: all prose, code comments, tables in **{{.Language}}**.
+6. **Output**: return only a JSON array with one object per page, in the order above, and nothing else:
+   [{"id": "<page id>", "content": "<page markdown>"}]
+   Escape the markdown as JSON strings; do not wrap the array in a code block.
+`
+
+// RegisterPageBatchPrompt registers the page batch prompt template
+func RegisterPageBatchPrompt(tm *TemplateManager) error {
+	return tm.RegisterTemplate("page_batch", PageBatchPrompt)
+}
@@ -2,6 +2,14 @@ package prompts
 
 import "github.com/kuderr/deepwiki/pkg/types"
 
+// PageContextData contains the data shared by the page content prompts of a wiki
+type PageContextData struct {
+	ProjectName string
+	Language    types.Language
+	FileTree    string
+	Pages       []PageSummary
+}
+
 // PageContentData contains data for page content generation
 type PageContentData struct {
 	Title         string
@@ -9,8 +17,6 @@ type PageContentData struct {
 	RelevantFiles string
 	ProjectName   string
 	Language      types.Language
-	FileTree      string
-	OtherPages    []PageSummary
 }
 
 type PageSummary struct {
@@ -18,27 +24,29 @@ type PageSummary struct {
 	Description string
 }
 
-// PageContentPrompt is the template for generating wiki page content
-const PageContentPrompt = `
-You are an expert technical writer and software architect.
-
-Task → Write the **{{.Title}}** page for {{.ProjectName}}.
-Generate everything in **{{.Language}}** and include diagrams.
-
-# PAGE SCOPE (from outline)
-<page_description>
-{{.Description}}
-</page_description>
-
-# SOURCES (the only ground truth)
-<relevant_files>
-{{.RelevantFiles}}
-</relevant_files>
+// PageContextPrompt is the template of the context shared by every page of a wiki: the project,
+// its file tree, the pages of the wiki and the writing rules. It is sent ahead of the page
+// content prompt and stays the same from page to page, so providers can cache it.
+const PageContextPrompt = `
+You are an expert technical writer and software architect, writing the wiki of {{.ProjectName}}
+page by page. Generate everything in **{{.Language}}** and include diagrams.
 
 <file_tree>
 {{.FileTree}}
 </file_tree>
 
+# Registry of the wiki pages (each page covers its own topic)
+<wiki_pages>
+  <pages>
+	{{range $page := .Pages }}
+    <page>
+      <title>{{$page.Title}}</title>
+      <description>{{$page.Description}}</description>
+    </page>
+	{{end}}
+  </pages>
+</wiki_pages>
+
 # PAGE PLAN
 ### 1. Overview  (≤ 100 words)
 
@@ -59,23 +67,9 @@ Generate everything in **{{.Language}}** and include diagrams.
 
 ### 7. Troubleshooting / Gotchas  *(optional; only if code reveals error handling)*
 
-# Registry of already-covered pages (do not repeat)
-<other_pages>
-  <pages>
-	{{range $page := .OtherPages }}
-    <page>
-      <title>{{$page.Title}}</title>
-      <description>{{$page.Description}}</description>
-    </page>
-	{{end}}
-  </pages>
-</other_pages>
-
-If you need to mention a topic that belongs to an entry in <other_pages>, replace detailed text with md link.
-
 # HARD RULES
 1. **Truth-only**: do not invent behaviour; base every statement on the source.
-2. **No duplication**: if another page covers a topic, replace with with md link.
+2. **No duplication**: if another page of <wiki_pages> covers a topic, replace detailed text with md link.
 3. **Skip build/lint/CI chatter** unless this page’s title implies it.
 4. **Mermaid validity** required; diagrams must compile.
 5. **Length target**: <=1200 words.
@@ -104,9 +98,31 @@ If you need to mention a topic that belongs to an entry in <other_pages>, replac
 - All sections present & scoped correctly  
 - Code blocks run/compile  
 - Diagram syntax valid  
+
+The page to write follows.
+`
+
+// PageContentPrompt is the template for generating wiki page content, sent after the page
+// context prompt
+const PageContentPrompt = `
+Task → Write the **{{.Title}}** page for {{.ProjectName}}, in **{{.Language}}**.
+The other pages of <wiki_pages> are written separately: link to them rather than repeat them.
+
+# PAGE SCOPE (from outline)
+<page_description>
+{{.Description}}
+</page_description>
+
+# SOURCES (the only ground truth)
+<relevant_files>
+{{.RelevantFiles}}
+</relevant_files>
 `
 
-// RegisterPageContentPrompt registers the page content prompt template
+// RegisterPageContentPrompt registers the page context and page content prompt templates
 func RegisterPageContentPrompt(tm *TemplateManager) error {
+	if err := tm.RegisterTemplate("page_context", PageContextPrompt); err != nil {
+		return err
+	}
 	return tm.RegisterTemplate("page_content", PageContentPrompt)
 }
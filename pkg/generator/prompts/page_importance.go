@@ -0,0 +1,35 @@
+package prompts
+
+// PageImportanceData contains data for classifying the importance of a generated page
+type PageImportanceData struct {
+	ProjectName string
+	Title       string
+	Description string
+	Content     string // Page markdown, possibly cut short
+}
+
+// PageImportancePrompt is the template for classifying the importance of a generated page
+const PageImportancePrompt = `
+You are an expert technical writer organizing the documentation of **{{.ProjectName}}**.
+
+Task → Rate how important the page below is for readers of the documentation.
+
+<page>
+<title>{{.Title}}</title>
+<description>{{.Description}}</description>
+<content>
+{{.Content}}
+</content>
+</page>
+
+# RULES
+1. high: pages most readers need, such as the overview, architecture, setup and core workflows.
+2. medium: pages about individual components, features or APIs.
+3. low: reference material few readers need, such as minor internals, changelogs or licenses.
+4. Answer with exactly one word: high, medium or low.
+`
+
+// RegisterPageImportancePrompt registers the page importance prompt template
+func RegisterPageImportancePrompt(tm *TemplateManager) error {
+	return tm.RegisterTemplate("page_importance", PageImportancePrompt)
+}
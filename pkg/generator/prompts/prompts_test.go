@@ -37,7 +37,7 @@ func TestDefaultManager(t *testing.T) {
 
 	// Check that templates are registered
 	templates := tm.ListTemplates()
-	expectedTemplates := []string{"wiki_structure", "page_content"}
+	expectedTemplates := []string{"wiki_structure", "page_context", "page_content"}
 
 	for _, expected := range expectedTemplates {
 		found := false
@@ -77,6 +77,30 @@ func TestWikiStructurePrompt(t *testing.T) {
 	}
 }
 
+func TestWikiStructurePrompt_ExistingDocs(t *testing.T) {
+	data := WikiStructureData{ProjectName: "test-project"}
+
+	result, err := ExecuteWikiStructurePrompt(data)
+	if err != nil {
+		t.Fatalf("Failed to execute wiki structure prompt: %v", err)
+	}
+	if strings.Contains(result, "existing_docs") || strings.Contains(result, "source_doc") {
+		t.Error("Result should not mention existing docs when there are none")
+	}
+
+	data.ExistingDocs = []ExistingDocSummary{{Path: "docs/guide.md", Title: "User Guide"}}
+	result, err = ExecuteWikiStructurePrompt(data)
+	if err != nil {
+		t.Fatalf("Failed to execute wiki structure prompt: %v", err)
+	}
+	if !strings.Contains(result, "- docs/guide.md: User Guide") {
+		t.Error("Result should list the existing docs")
+	}
+	if !strings.Contains(result, "<source_doc>") {
+		t.Error("Result should ask for the source_doc of placed docs")
+	}
+}
+
 func TestPageContentPrompt(t *testing.T) {
 	data := PageContentData{
 		Title:         "Core Architecture",
@@ -84,7 +108,6 @@ func TestPageContentPrompt(t *testing.T) {
 		RelevantFiles: "main.go:\npackage main\n\nfunc main() {}\n",
 		ProjectName:   "test-project",
 		Language:      "en",
-		FileTree:      "src/\n  main.go\n",
 	}
 
 	result, err := ExecutePageContentPrompt(data)
@@ -104,6 +127,29 @@ func TestPageContentPrompt(t *testing.T) {
 	}
 }
 
+func TestPageContextPrompt(t *testing.T) {
+	data := PageContextData{
+		ProjectName: "test-project",
+		Language:    "en",
+		FileTree:    "src/\n  main.go\n",
+		Pages: []PageSummary{
+			{Title: "Core Architecture", Description: "Overview of the system architecture"},
+			{Title: "Configuration", Description: "Configuration options"},
+		},
+	}
+
+	result, err := ExecutePageContextPrompt(data)
+	if err != nil {
+		t.Fatalf("Failed to execute page context prompt: %v", err)
+	}
+
+	for _, want := range []string{"test-project", "src/\n  main.go", "<title>Configuration</title>", "HARD RULES"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Result should contain %q", want)
+		}
+	}
+}
+
 func TestTemplateRegistrationError(t *testing.T) {
 	tm := NewTemplateManager()
 
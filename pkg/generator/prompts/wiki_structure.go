@@ -8,6 +8,14 @@ type WikiStructureData struct {
 	ReadmeFile  string
 	ProjectName string
 	Language    types.Language
+
+	ExistingDocs []ExistingDocSummary // Handwritten docs to place in the structure
+}
+
+// ExistingDocSummary describes a handwritten document for the structure prompt
+type ExistingDocSummary struct {
+	Path  string
+	Title string
 }
 
 // WikiStructurePrompt is the template for generating wiki structure
@@ -25,7 +33,11 @@ All pages assume diagrams are required.
 <readme>
 {{.ReadmeFile}}
 </readme>
-
+{{if .ExistingDocs}}
+<existing_docs>
+{{range .ExistingDocs}}- {{.Path}}: {{.Title}}
+{{end}}</existing_docs>
+{{end}}
 # OUTPUT  (return exactly this XML)
 <wiki_structure>
   <title>{{.ProjectName}} Documentation</title>
@@ -40,7 +52,8 @@ All pages assume diagrams are required.
       <description>[Brief description of what this page covers]</description>
 
       <importance>high|medium|low</importance>
-      <parent_id>optional-parent-id</parent_id>
+      <parent_id>optional-parent-id</parent_id>{{if .ExistingDocs}}
+      <source_doc>optional path from existing_docs</source_doc>{{end}}
     </page>
     <!-- More pages... -->
   </pages>
@@ -68,7 +81,9 @@ All pages assume diagrams are required.
   - Component relationships
   - Process workflows
   - State machines
-  - Class hierarchies
+  - Class hierarchies{{if .ExistingDocs}}
+8. **Existing docs**: every document in <existing_docs> becomes exactly one page whose <source_doc> is its path.
+   Place it where it fits using parent_id, and do not add another page covering the same topic.{{end}}
 
 # BEFORE RETURNING
 **Self-check** before output:
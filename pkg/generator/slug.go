@@ -0,0 +1,119 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// pageSlugReplacer replaces the characters of a title that are unsafe in file names and URLs
+var pageSlugReplacer = strings.NewReplacer(
+	" ", "-", "/", "-", "\\", "-", ":", "-", "*", "-", "?", "-", "\"", "-", "<", "-", ">", "-", "|", "-",
+)
+
+// slugLetters transliterates the lowercase Cyrillic letters of Russian, Ukrainian and Belarusian
+// titles, and the Latin letters that are not an ASCII letter with diacritics
+var slugLetters = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "zh", 'з': "z",
+	'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r",
+	'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+	'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	'є': "ye", 'і': "i", 'ї': "yi", 'ґ': "g", 'ў': "u",
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'đ': "d", 'ð': "d", 'þ': "th", 'ħ': "h", 'ı': "i",
+	'ł': "l", 'ơ': "o", 'ư': "u",
+}
+
+// Base letters of the Latin-1 Supplement and Latin Extended-A blocks, and of the Vietnamese
+// letters of Latin Extended Additional, starting at their first rune; "-" marks runes without one
+const (
+	latinSlugBase  = 0x00C0
+	latinSlugChars = "aaaaaa-ceeeeiiii-nooooo--uuuuy--aaaaaa-ceeeeiiii-nooooo--uuuuy-yaaaaaaccccccccdd--eeeeeeeeee" +
+		"gggggggghh--iiiiiiiii---jjkk-llllll----nnnnnn---oooooo--rrrrrrsssssssstttt--uuuuuuuuuuuuwwyyyzzzzzz-"
+	vietnameseSlugBase  = 0x1EA0
+	vietnameseSlugChars = "aaaaaaaaaaaaaaaaaaaaaaaaeeeeeeeeeeeeeeeeiiiioooooooooooooooooooooooouuuuuuuuuuuuuuyyyyyyyy"
+)
+
+// PageSlug converts a page title into the file-system and URL safe name of its page files.
+// Cyrillic titles are transliterated and diacritics are dropped from Latin letters. Letters of
+// other scripts, such as Chinese, Japanese and Korean, cannot be spelled in ASCII; a title with
+// such letters gets a hash of the title appended, so that its slug is stable and differs from
+// the slugs of other titles.
+func PageSlug(title string) string {
+	lower := strings.ToLower(title)
+
+	var slug strings.Builder
+	untransliterated := false
+	for _, r := range lower {
+		if r < utf8.RuneSelf {
+			slug.WriteRune(r)
+			continue
+		}
+		if letters, ok := slugLetters[r]; ok {
+			slug.WriteString(letters)
+			continue
+		}
+		if base := slugBaseLetter(r); base != 0 {
+			slug.WriteByte(base)
+			continue
+		}
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Combining diacritics of decomposed letters
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			untransliterated = true
+			slug.WriteByte('-')
+		default:
+			slug.WriteByte('-')
+		}
+	}
+	result := pageSlugReplacer.Replace(slug.String())
+
+	// Remove multiple consecutive dashes
+	for strings.Contains(result, "--") {
+		result = strings.ReplaceAll(result, "--", "-")
+	}
+
+	// Trim dashes from start and end
+	result = strings.Trim(result, "-")
+
+	if untransliterated {
+		sum := sha256.Sum256([]byte(strings.TrimSpace(lower)))
+		suffix := hex.EncodeToString(sum[:4])
+		if result == "" {
+			return "page-" + suffix
+		}
+		return result + "-" + suffix
+	}
+
+	// Ensure not empty
+	if result == "" {
+		result = "untitled"
+	}
+
+	return result
+}
+
+// PageFileSlug returns the name of the files of a page: its Slug, or the slug of its title
+func PageFileSlug(page *WikiPage) string {
+	if page.Slug != "" {
+		return page.Slug
+	}
+	return PageSlug(page.Title)
+}
+
+// slugBaseLetter returns the ASCII letter a Latin letter with diacritics is based on, or 0
+func slugBaseLetter(r rune) byte {
+	var base byte
+	switch {
+	case r >= latinSlugBase && int(r-latinSlugBase) < len(latinSlugChars):
+		base = latinSlugChars[r-latinSlugBase]
+	case r >= vietnameseSlugBase && int(r-vietnameseSlugBase) < len(vietnameseSlugChars):
+		base = vietnameseSlugChars[r-vietnameseSlugBase]
+	}
+	if base == '-' {
+		return 0
+	}
+	return base
+}
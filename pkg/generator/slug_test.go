@@ -0,0 +1,82 @@
+package generator
+
+import (
+	"regexp"
+	"testing"
+)
+
+var urlSafeSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9().,&'!+=@_-]*$`)
+
+func TestPageSlug(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Getting Started", "getting-started"},
+		{"API / Reference: v2", "api-reference-v2"},
+		{"Node.js Setup", "node.js-setup"},
+		{"", "untitled"},
+		{"???", "untitled"},
+		{"Архитектура системы", "arkhitektura-sistemy"},
+		{"Щука и ёж", "shchuka-i-yozh"},
+		{"Конфігурація", "konfiguratsiya"},
+		{"Café Übersicht", "cafe-ubersicht"},
+		{"Cài đặt hệ thống", "cai-dat-he-thong"},
+	}
+	for _, tt := range tests {
+		if got := PageSlug(tt.title); got != tt.want {
+			t.Errorf("PageSlug(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestPageSlug_NonLatinTitlesAreDistinct(t *testing.T) {
+	titles := []string{
+		"概要", "アーキテクチャ", "インストール", "設定", "API リファレンス",
+		"Обзор", "Архитектура", "Установка", "Настройка", "Справочник API",
+		"架构设计", "개요", "설치",
+	}
+
+	slugs := make(map[string]string)
+	for _, title := range titles {
+		slug := PageSlug(title)
+		if !urlSafeSlugPattern.MatchString(slug) {
+			t.Errorf("PageSlug(%q) = %q, want a URL-safe slug", title, slug)
+		}
+		if other, exists := slugs[slug]; exists {
+			t.Errorf("PageSlug(%q) = PageSlug(%q) = %q", title, other, slug)
+		}
+		slugs[slug] = title
+
+		if again := PageSlug(title); again != slug {
+			t.Errorf("PageSlug(%q) changed from %q to %q", title, slug, again)
+		}
+	}
+
+	if slug := PageSlug("API リファレンス"); slug[:4] != "api-" {
+		t.Errorf("PageSlug kept no Latin part of a mixed title: %q", slug)
+	}
+}
+
+func TestPageFileSlug(t *testing.T) {
+	if slug := PageFileSlug(&WikiPage{Title: "Node.js Setup"}); slug != "node.js-setup" {
+		t.Errorf("Expected the title slug without a page slug, got %q", slug)
+	}
+	if slug := PageFileSlug(&WikiPage{Title: "cmd/main.go", Slug: "cmd-main-go"}); slug != "cmd-main-go" {
+		t.Errorf("Expected the page slug to name the files, got %q", slug)
+	}
+}
+
+func TestSlugBaseLetterTables(t *testing.T) {
+	if len(latinSlugChars) != 0x180-latinSlugBase {
+		t.Errorf("latinSlugChars has %d letters, want %d", len(latinSlugChars), 0x180-latinSlugBase)
+	}
+	if len(vietnameseSlugChars) != 0x1EFA-vietnameseSlugBase {
+		t.Errorf("vietnameseSlugChars has %d letters, want %d", len(vietnameseSlugChars), 0x1EFA-vietnameseSlugBase)
+	}
+	for r, want := range map[rune]byte{'é': 'e', 'ñ': 'n', 'ž': 'z', 'ő': 'o', 'ệ': 'e', 'ỹ': 'y'} {
+		if got := slugBaseLetter(r); got != want {
+			t.Errorf("slugBaseLetter(%q) = %q, want %q", r, got, want)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/kuderr/deepwiki/pkg/rag"
+)
+
+const (
+	// maxSnippetsPerPage is the number of source snippets embedded into a page at most
+	maxSnippetsPerPage = 3
+
+	// maxSnippetLines is the number of source lines a snippet shows at most
+	maxSnippetLines = 40
+)
+
+// codeSnippet is the source of a declaration, quoted into a page where the page references it
+type codeSnippet struct {
+	Symbol    string
+	FilePath  string
+	Language  string
+	StartLine int
+	EndLine   int
+	Code      string
+}
+
+// snippetReferencePattern matches inline code naming a symbol, such as `Run`, `Run()` or
+// `Server.Run`; the symbol is the last group
+var snippetReferencePattern = regexp.MustCompile("`(?:[A-Za-z_]\\w*\\.)?([A-Za-z_]\\w*)(?:\\(\\))?`")
+
+// fenceLanguages maps the language names of the scanner to markdown fence languages; other
+// languages are lower-cased
+var fenceLanguages = map[string]string{
+	"C++":        "cpp",
+	"C#":         "csharp",
+	"Shell":      "bash",
+	"TypeScript": "typescript",
+	"JavaScript": "javascript",
+}
+
+// collectSnippets returns the declarations in the code files of docs, keyed by name. Chunks are
+// retrieved by file path for their exact source and lines; a declaration is quoted from the chunk
+// that starts with it. Where names clash, the file of the more relevant document wins.
+func (g *WikiGenerator) collectSnippets(docs []rag.RetrievalResult) map[string]codeSnippet {
+	snippets := make(map[string]codeSnippet)
+	seen := make(map[string]bool)
+	for _, doc := range docs {
+		if doc.Category != "code" || seen[doc.FilePath] {
+			continue
+		}
+		seen[doc.FilePath] = true
+
+		chunks, err := g.ragRetriever.RetrieveByFilePath(doc.FilePath)
+		if err != nil {
+			g.logger.Debug("Failed to retrieve file for snippets", "file", doc.FilePath, "error", err)
+			continue
+		}
+
+		for _, chunk := range chunks {
+			snippet, ok := newCodeSnippet(chunk)
+			if _, exists := snippets[snippet.Symbol]; ok && !exists {
+				snippets[snippet.Symbol] = snippet
+			}
+		}
+	}
+	return snippets
+}
+
+// newCodeSnippet returns the snippet of a chunk that starts with a declaration and has known
+// source lines. Trailing blank and comment lines, which usually document the next declaration,
+// are dropped and long declarations are cut at maxSnippetLines.
+func newCodeSnippet(chunk rag.RetrievalResult) (codeSnippet, bool) {
+	context := chunk.Context
+	if context == nil || context.Signature == "" || len(context.LineNumbers) != 2 {
+		return codeSnippet{}, false
+	}
+	if !strings.HasPrefix(strings.Join(strings.Fields(chunk.Content), " "), context.Signature) {
+		return codeSnippet{}, false
+	}
+
+	symbol := context.FunctionName
+	if symbol == "" {
+		symbol = context.ClassName
+	}
+
+	lines := strings.Split(strings.TrimRight(chunk.Content, "\n"), "\n")
+	lines = lines[:min(len(lines), maxSnippetLines)]
+	for len(lines) > 1 && isTrailingLine(lines[len(lines)-1]) {
+		lines = lines[:len(lines)-1]
+	}
+
+	return codeSnippet{
+		Symbol:    symbol,
+		FilePath:  chunk.FilePath,
+		Language:  chunk.Language,
+		StartLine: context.LineNumbers[0],
+		EndLine:   context.LineNumbers[0] + len(lines) - 1,
+		Code:      strings.Join(lines, "\n"),
+	}, symbol != ""
+}
+
+// isTrailingLine reports whether a line is blank or a comment
+func isTrailingLine(line string) bool {
+	line = strings.TrimSpace(line)
+	for _, prefix := range []string{"//", "#", "/*", "*"} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return line == ""
+}
+
+// embedSnippets inserts the source of declarations referenced in content after the paragraph that
+// first references each of them, up to maxSnippetsPerPage. References inside code blocks and
+// declarations whose header the page already shows are skipped.
+func embedSnippets(content string, snippets map[string]codeSnippet) string {
+	if len(snippets) == 0 {
+		return content
+	}
+
+	var builder strings.Builder
+	var pending []codeSnippet
+	used := make(map[string]bool)
+	inFence := false
+
+	flush := func() {
+		for _, snippet := range pending {
+			builder.WriteString("\n")
+			builder.WriteString(snippet.markdown())
+		}
+		pending = nil
+	}
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+
+		if !inFence && trimmed == "" {
+			flush()
+		}
+		builder.WriteString(line)
+		if i < len(lines)-1 {
+			builder.WriteString("\n")
+		}
+		if inFence || trimmed == "" {
+			continue
+		}
+
+		for _, match := range snippetReferencePattern.FindAllStringSubmatch(line, -1) {
+			snippet, ok := snippets[match[1]]
+			if !ok || used[snippet.Symbol] || len(used) == maxSnippetsPerPage ||
+				strings.Contains(content, strings.SplitN(snippet.Code, "\n", 2)[0]) {
+				continue
+			}
+			used[snippet.Symbol] = true
+			pending = append(pending, snippet)
+		}
+	}
+
+	if len(pending) > 0 {
+		builder.WriteString("\n")
+		flush()
+	}
+	return builder.String()
+}
+
+// markdown renders the snippet as a fenced code block followed by its file and lines
+func (s codeSnippet) markdown() string {
+	language, ok := fenceLanguages[s.Language]
+	if !ok {
+		language = strings.ToLower(s.Language)
+	}
+	return fmt.Sprintf("```%s\n%s\n```\n\nSource: `%s:%d-%d`\n",
+		language, s.Code, s.FilePath, s.StartLine, s.EndLine)
+}
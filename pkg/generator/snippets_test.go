@@ -0,0 +1,106 @@
+package generator
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/kuderr/deepwiki/pkg/llm"
+	"github.com/kuderr/deepwiki/pkg/rag"
+	"github.com/kuderr/deepwiki/pkg/types"
+)
+
+// fileChunksRetriever retrieves the chunks of files by path
+type fileChunksRetriever struct {
+	MockRAGRetriever
+	files map[string][]rag.RetrievalResult
+}
+
+func (r *fileChunksRetriever) RetrieveRelevantDocuments(ctx *rag.RetrievalContext) ([]rag.RetrievalResult, error) {
+	return []rag.RetrievalResult{
+		{FilePath: "server/server.go", Category: "code", Language: "Go", Content: "func (s *Server) Login("},
+		{FilePath: "README.md", Category: "documentation", Content: "# Server"},
+	}, nil
+}
+
+func (r *fileChunksRetriever) RetrieveByFilePath(filePath string) ([]rag.RetrievalResult, error) {
+	return r.files[filePath], nil
+}
+
+func TestGeneratePageContent_EmbedsSnippets(t *testing.T) {
+	login := "func (s *Server) Login(token string) error {\n" +
+		"\treturn s.sessions.Start(token)\n" +
+		"}\n" +
+		"\n" +
+		"// Logout ends the session\n"
+	retriever := &fileChunksRetriever{files: map[string][]rag.RetrievalResult{
+		"server/server.go": {
+			{
+				FilePath: "server/server.go", Language: "Go", Category: "code",
+				Content: "package server\n\ntype Server struct {\n\tsessions *Store\n}\n",
+				Context: &rag.ChunkContext{ClassName: "Server", Signature: "type Server struct",
+					LineNumbers: []int{1, 6}},
+			},
+			{
+				FilePath: "server/server.go", Language: "Go", Category: "code", Content: login,
+				Context: &rag.ChunkContext{FunctionName: "Login", ClassName: "Server",
+					Signature: "func (s *Server) Login(token string) error", LineNumbers: []int{7, 11}},
+			},
+		},
+	}}
+
+	content := "# Sessions\n\nRequests are authenticated by `Server.Login()` before they are handled.\n" +
+		"It starts a session.\n\n## Storage\n\nSessions live in the store.\n\n" +
+		"```go\nsrv.Login(token)\n```\n"
+	provider := &scriptedLLMProvider{responses: []llm.Choice{choice(content, "stop")}}
+	generator := NewWikiGenerator(provider, retriever, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	page := &WikiPage{ID: "sessions", Title: "Sessions"}
+	structure := &WikiStructure{Pages: []WikiPage{*page}}
+	options := GenerationOptions{ProjectName: "demo", Language: types.LanguageEnglish, EmbedSnippets: true}
+	if err := generator.GeneratePageContent(context.Background(), "", page, structure, options); err != nil {
+		t.Fatalf("GeneratePageContent failed: %v", err)
+	}
+
+	snippet := "It starts a session.\n\n" +
+		"```go\nfunc (s *Server) Login(token string) error {\n\treturn s.sessions.Start(token)\n}\n```\n\n" +
+		"Source: `server/server.go:7-9`\n\n## Storage"
+	if !strings.Contains(page.Content, snippet) {
+		t.Errorf("Expected the Login snippet after the paragraph referencing it, got:\n%s", page.Content)
+	}
+	if strings.Count(page.Content, "Source: `") != 1 {
+		t.Errorf("Expected a single snippet, got:\n%s", page.Content)
+	}
+
+	// Without the option the page is left as written
+	provider.responses = []llm.Choice{choice(content, "stop")}
+	options.EmbedSnippets = false
+	if err := generator.GeneratePageContent(context.Background(), "", page, structure, options); err != nil {
+		t.Fatalf("GeneratePageContent failed: %v", err)
+	}
+	if strings.Contains(page.Content, "Source: `") {
+		t.Errorf("Expected no snippets without EmbedSnippets, got:\n%s", page.Content)
+	}
+}
+
+func TestEmbedSnippets_SkipsQuotedAndFencedReferences(t *testing.T) {
+	snippets := map[string]codeSnippet{
+		"Run": {Symbol: "Run", FilePath: "main.go", Language: "Go", StartLine: 3, EndLine: 5,
+			Code: "func Run() error {\n\treturn nil\n}"},
+		"Store": {Symbol: "Store", FilePath: "store.py", Language: "Python", StartLine: 1, EndLine: 2,
+			Code: "class Store:\n    pass"},
+	}
+
+	content := "Call `Run` to start.\n\n```go\nfunc Run() error {\n\treturn nil\n}\n```"
+	if got := embedSnippets(content, snippets); got != content {
+		t.Errorf("Expected an already quoted declaration to be skipped, got:\n%s", got)
+	}
+
+	content = "```python\nStore()  # `Store`\n```\n\nData goes to `Store`."
+	want := content + "\n\n```python\nclass Store:\n    pass\n```\n\nSource: `store.py:1-2`\n"
+	if got := embedSnippets(content, snippets); got != want {
+		t.Errorf("Expected the snippet after the last paragraph, got:\n%q", got)
+	}
+}
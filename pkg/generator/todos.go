@@ -0,0 +1,175 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/kuderr/deepwiki/pkg/processor"
+	"github.com/kuderr/deepwiki/pkg/scanner"
+	"github.com/kuderr/deepwiki/pkg/types"
+)
+
+// TodosPageID is the ID of the generated outstanding work page
+const TodosPageID = "todos"
+
+// TodoItem is a work marker found in a code comment
+type TodoItem struct {
+	Marker   string `json:"marker"` // TODO, FIXME, HACK or XXX
+	Text     string `json:"text"`
+	FilePath string `json:"filePath"`
+	Line     int    `json:"line"`
+}
+
+// todoMarkerPattern matches a marker, an optional "(owner)" and the remaining comment text
+var todoMarkerPattern = regexp.MustCompile(`\b(TODO|FIXME|HACK|XXX)\b(?:\([^)]*\))?:?\s*(.*)`)
+
+// todoCategories are the file categories scanned for work markers
+var todoCategories = map[string]bool{
+	string(scanner.CategoryCode):   true,
+	string(scanner.CategoryTest):   true,
+	string(scanner.CategoryBuild):  true,
+	string(scanner.CategoryConfig): true,
+}
+
+// ExtractTodos finds TODO/FIXME/HACK/XXX markers in code comments, ordered by file and line
+func ExtractTodos(files []scanner.FileInfo) []TodoItem {
+	var todos []TodoItem
+
+	for _, file := range files {
+		if file.IsDir || file.IsBinary || !todoCategories[file.Category] {
+			continue
+		}
+
+		content, err := readSourceFile(file)
+		if err != nil {
+			continue
+		}
+
+		todos = append(todos, findTodos(file.Path, content, processor.GetLanguageProcessor(file.Language))...)
+	}
+
+	sort.SliceStable(todos, func(i, j int) bool {
+		if todos[i].FilePath != todos[j].FilePath {
+			return todos[i].FilePath < todos[j].FilePath
+		}
+		return todos[i].Line < todos[j].Line
+	})
+
+	return todos
+}
+
+// findTodos scans the comments of a single file using the language's comment syntax
+func findTodos(filePath, content string, lang *processor.LanguageSpecificProcessor) []TodoItem {
+	var todos []TodoItem
+	blockEnd := "" // End delimiter of the block comment we are inside, if any
+
+	for i, line := range strings.Split(content, "\n") {
+		for _, comment := range commentSegments(line, lang, &blockEnd) {
+			match := todoMarkerPattern.FindStringSubmatch(comment)
+			if match == nil {
+				continue
+			}
+			todos = append(todos, TodoItem{
+				Marker:   match[1],
+				Text:     strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(match[2]), "*/")),
+				FilePath: filePath,
+				Line:     i + 1,
+			})
+			break
+		}
+	}
+
+	return todos
+}
+
+// commentSegments returns the comment portions of a line. blockEnd carries block comment
+// state across lines: it is non-empty while inside a block comment.
+func commentSegments(line string, lang *processor.LanguageSpecificProcessor, blockEnd *string) []string {
+	var segments []string
+	rest := line
+
+	for rest != "" {
+		if *blockEnd != "" {
+			idx := strings.Index(rest, *blockEnd)
+			if idx < 0 {
+				segments = append(segments, rest)
+				return segments
+			}
+			segments = append(segments, rest[:idx])
+			rest = rest[idx+len(*blockEnd):]
+			*blockEnd = ""
+			continue
+		}
+
+		// Find whichever comment opener comes first on the line
+		start, opener, closer := -1, "", ""
+		for _, prefix := range lang.CommentPrefixes {
+			if idx := strings.Index(rest, prefix); idx >= 0 && (start < 0 || idx < start) {
+				start, opener, closer = idx, prefix, ""
+			}
+		}
+		for _, block := range lang.CommentBlocks {
+			if idx := strings.Index(rest, block[0]); idx >= 0 && (start < 0 || idx < start) {
+				start, opener, closer = idx, block[0], block[1]
+			}
+		}
+
+		if start < 0 {
+			return segments
+		}
+		if closer == "" {
+			// Line comment: the remainder of the line is the comment
+			return append(segments, rest[start+len(opener):])
+		}
+
+		*blockEnd = closer
+		rest = rest[start+len(opener):]
+	}
+
+	return segments
+}
+
+// BuildTodosPage renders the outstanding work page in language, or returns nil if there are no
+// markers
+func BuildTodosPage(todos []TodoItem, language types.Language) *WikiPage {
+	if len(todos) == 0 {
+		return nil
+	}
+
+	counts := make(map[string]int)
+	for _, todo := range todos {
+		counts[todo.Marker]++
+	}
+
+	labels := labelsFor(language)
+	var content strings.Builder
+	fmt.Fprintf(&content, "# %s\n\n%s\n\n", labels.Todos, labels.TodosIntro)
+	for _, marker := range []string{"TODO", "FIXME", "HACK", "XXX"} {
+		if counts[marker] > 0 {
+			content.WriteString(fmt.Sprintf("- **%s:** %d\n", marker, counts[marker]))
+		}
+	}
+
+	var filePaths []string
+	currentFile := ""
+	for _, todo := range todos {
+		if todo.FilePath != currentFile {
+			currentFile = todo.FilePath
+			filePaths = append(filePaths, currentFile)
+			content.WriteString(fmt.Sprintf("\n## `%s`\n\n", currentFile))
+		}
+		line := fmt.Sprintf(labels.TodoLine, todo.Line)
+		content.WriteString(fmt.Sprintf("- **%s** (%s): %s\n", todo.Marker, line, todo.Text))
+	}
+
+	return &WikiPage{
+		ID:          TodosPageID,
+		Title:       labels.Todos,
+		Description: labels.TodosDescription,
+		Content:     content.String(),
+		FilePaths:   filePaths,
+		Importance:  "low",
+	}
+}
@@ -3,6 +3,8 @@ package generator
 import (
 	"time"
 
+	"github.com/kuderr/deepwiki/pkg/processor"
+	"github.com/kuderr/deepwiki/pkg/rag"
 	"github.com/kuderr/deepwiki/pkg/types"
 )
 
@@ -31,8 +33,22 @@ type WikiPage struct {
 	CreatedAt    time.Time `json:"createdAt"              xml:"createdAt"`
 	WordCount    int       `json:"wordCount"              xml:"wordCount"`
 	SourceFiles  int       `json:"sourceFiles"            xml:"sourceFiles"`
+	SourceDoc    string    `json:"sourceDoc,omitempty"    xml:"sourceDoc,omitempty"` // Existing document used as-is
+	Slug         string    `json:"slug,omitempty"         xml:"slug,omitempty"`      // Page file name
 }
 
+// GenerationMode selects how the pages of the documentation are produced
+type GenerationMode string
+
+// Generation modes
+const (
+	ModeWiki          GenerationMode = "wiki"            // Pages planned and written by the LLM from retrieved sources
+	ModeFilePerSource GenerationMode = "file-per-source" // One page per source file: an LLM summary and the source
+)
+
+// GenerationModes lists the supported generation modes
+var GenerationModes = []GenerationMode{ModeWiki, ModeFilePerSource}
+
 // GenerationOptions contains options for wiki generation
 type GenerationOptions struct {
 	ProjectName     string
@@ -41,6 +57,82 @@ type GenerationOptions struct {
 	OutputFormat    string
 	MaxConcurrency  int
 	ProgressTracker ProgressTracker
+
+	// Location of a page in the output format, for links between pages built without the LLM
+	// (nil = markdown files side by side)
+	PageURL func(page *WikiPage) string
+
+	// Static analysis pages
+	IncludeEnvVars bool // Generate an environment variables page
+	IncludeTodos   bool // Generate a page listing TODO/FIXME/HACK/XXX comments
+	IncludeLicense bool // Generate a page with the project and dependency licenses
+	IncludeCI      bool // Generate a page describing CI/CD workflows
+	SummarizeCI    bool // Add an LLM-written overview to the CI/CD page
+
+	// Render a Mermaid class diagram of Go types into the architecture or API page
+	IncludeClassDiagram bool
+
+	// Include existing markdown docs as pages, placed in the structure by the LLM
+	MergeExistingDocs bool
+	// Directory the wiki is written to, and the files an earlier run wrote (from its manifest).
+	// They are never taken for existing docs.
+	OutputDirectory string
+	OutputFiles     []string
+
+	// Collapse generated pages whose content nearly duplicates another page
+	DedupPages bool
+
+	// Generate up to BatchPages low importance pages with one request (0 or 1 = one request per page)
+	BatchPages int
+
+	// Quote the source of declarations a page references below the paragraph that references them
+	EmbedSnippets bool
+
+	// Add a glossary page of domain terms the LLM extracts from the generated pages
+	IncludeGlossary bool
+
+	// Ask the LLM to rate the importance of each generated page from its content, replacing the
+	// estimate of the structure. Answers are kept in ImportanceCache when it is set.
+	ClassifyImportance bool
+	ImportanceCache    *ImportanceCache
+
+	// Stop after the structure: pages are planned but their content is not generated
+	PlanOnly bool
+
+	// Checkpoint records the structure and each page as they are generated, and supplies those
+	// recorded by an earlier run that did not finish
+	Checkpoint Checkpoint
+
+	// LLM settings of the structure and page generation phases
+	StructurePhase PhaseSettings
+	PagePhase      PhaseSettings
+
+	// Retrieval of the source documents each page is written from
+	Retrieval RetrievalSettings
+
+	// Context window of the model in tokens (0 = look it up by model name). Retrieved documents are
+	// dropped from page prompts that would not fit, and prompts that still do not fit fail.
+	ContextWindow int
+
+	// Structured data extracted during processing
+	ComposeProjects []processor.ComposeProject // Parsed docker-compose files for the services page
+}
+
+// PhaseSettings overrides the LLM settings of a generation phase; zero values keep the defaults
+type PhaseSettings struct {
+	Temperature *float64 // Defaults to 0.1
+	MaxTokens   int      // Defaults to 4000
+}
+
+// RetrievalSettings overrides how documents are retrieved for a page; zero values keep the defaults
+type RetrievalSettings struct {
+	MaxResults int           // Defaults to 20
+	MinScore   *float32      // Defaults to 0.1
+	Strategy   rag.QueryType // Defaults to hybrid
+
+	// MinResults relaxes MinScore down to MinScoreFloor while fewer documents are found (0 = never)
+	MinResults    int
+	MinScoreFloor float32
 }
 
 // GenerationResult represents the result of wiki generation
@@ -62,6 +154,15 @@ type ProgressTracker interface {
 	SetError(err error)
 }
 
+// Checkpoint keeps the wiki structure and pages of a run as they are generated, so that a run
+// that dies partway can resume without generating them again
+type Checkpoint interface {
+	Structure() *WikiStructure // Recorded structure, or nil
+	SaveStructure(structure *WikiStructure) error
+	Page(id string) (*WikiPage, bool)
+	SavePage(page *WikiPage) error
+}
+
 // NoOpProgressTracker is a no-op implementation of ProgressTracker
 type NoOpProgressTracker struct{}
 
@@ -92,6 +193,7 @@ type WikiPageRequest struct {
 	Description string `xml:"description"`
 	Importance  string `xml:"importance"`
 	ParentID    string `xml:"parent_id,omitempty"`
+	SourceDoc   string `xml:"source_doc,omitempty"`
 }
 
 // GenerationStats tracks statistics during generation
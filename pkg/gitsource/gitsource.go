@@ -0,0 +1,126 @@
+package gitsource
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultDepth is the clone depth used when none is given
+const DefaultDepth = 1
+
+// CloneOptions controls how a repository is cloned
+type CloneOptions struct {
+	Branch string // Branch or tag to check out (empty = remote HEAD)
+	Depth  int    // History depth; 0 or less clones full history
+}
+
+// Checkout is a cloned repository in a temporary directory
+type Checkout struct {
+	URL  string // Repository URL the checkout was cloned from
+	Path string // Working tree of the clone
+	root string // Temporary directory holding the clone
+}
+
+// scpLikePattern matches scp-style git addresses such as git@github.com:org/repo.git
+var scpLikePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+@[A-Za-z0-9.-]+:[^/]`)
+
+// remoteSchemes are URL schemes that git can clone from
+var remoteSchemes = []string{"https://", "http://", "git://", "ssh://", "file://"}
+
+// IsRemoteURL reports whether source is a git URL rather than a local path
+func IsRemoteURL(source string) bool {
+	lower := strings.ToLower(source)
+	for _, scheme := range remoteSchemes {
+		if strings.HasPrefix(lower, scheme) {
+			return true
+		}
+	}
+	return scpLikePattern.MatchString(source)
+}
+
+// RepoName derives a directory name from a repository URL
+func RepoName(url string) string {
+	url = strings.TrimRight(url, "/")
+	if idx := strings.LastIndex(url, ":"); idx >= 0 && scpLikePattern.MatchString(url) {
+		url = url[idx+1:]
+	}
+
+	name := strings.TrimSuffix(path.Base(url), ".git")
+	if name == "" || name == "." || name == "/" {
+		return "repo"
+	}
+	return name
+}
+
+// Clone shallow-clones url into a new temporary directory.
+// The caller must call Remove on the returned checkout when done.
+func Clone(ctx context.Context, url string, options CloneOptions) (*Checkout, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("git is required to clone %s: %w", url, err)
+	}
+
+	root, err := os.MkdirTemp("", "deepwiki-clone-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create clone directory: %w", err)
+	}
+
+	checkout := &Checkout{
+		URL:  url,
+		Path: filepath.Join(root, RepoName(url)),
+		root: root,
+	}
+
+	args := []string{"clone", "--quiet", "--single-branch"}
+	if options.Depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(options.Depth))
+	}
+	if options.Branch != "" {
+		args = append(args, "--branch", options.Branch)
+	}
+	args = append(args, "--", url, checkout.Path)
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stderr = &stderr
+	// Never block on credential prompts
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	if err := cmd.Run(); err != nil {
+		_ = checkout.Remove()
+		return nil, fmt.Errorf("failed to clone %s: %w: %s", url, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return checkout, nil
+}
+
+// Remove deletes the checkout and its temporary directory
+func (c *Checkout) Remove() error {
+	if c.root == "" {
+		return nil
+	}
+	if err := os.RemoveAll(c.root); err != nil {
+		return fmt.Errorf("failed to remove clone directory: %w", err)
+	}
+	return nil
+}
+
+// HeadCommit returns the abbreviated hash of the commit checked out in dir
+func HeadCommit(ctx context.Context, dir string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", "-C", dir, "rev-parse", "--short", "HEAD")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to read HEAD commit of %s: %w: %s", dir, err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
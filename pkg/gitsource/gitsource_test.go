@@ -0,0 +1,186 @@
+package gitsource
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kuderr/deepwiki/pkg/scanner"
+)
+
+// runGit runs a git command in dir, failing the test on error
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+
+	args = append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, output)
+	}
+}
+
+// createBareRepo creates a bare repository with a main and a feature branch, returning its file:// URL
+func createBareRepo(t *testing.T) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root := t.TempDir()
+	bare := filepath.Join(root, "project.git")
+	work := filepath.Join(root, "work")
+
+	runGit(t, root, "init", "--quiet", "--bare", "--initial-branch=main", bare)
+	runGit(t, root, "clone", "--quiet", bare, work)
+
+	files := map[string]string{
+		"main.go":   "package main\n\nfunc main() {}\n",
+		"README.md": "# Project\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(work, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	runGit(t, work, "add", ".")
+	runGit(t, work, "commit", "--quiet", "-m", "initial")
+	runGit(t, work, "push", "--quiet", "origin", "HEAD:main")
+
+	runGit(t, work, "checkout", "--quiet", "-b", "feature")
+	if err := os.WriteFile(filepath.Join(work, "feature.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write feature.go: %v", err)
+	}
+	runGit(t, work, "add", ".")
+	runGit(t, work, "commit", "--quiet", "-m", "feature")
+	runGit(t, work, "push", "--quiet", "origin", "feature")
+
+	return "file://" + filepath.ToSlash(bare)
+}
+
+func TestIsRemoteURL(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected bool
+	}{
+		{"https://github.com/org/repo", true},
+		{"http://example.com/repo.git", true},
+		{"git@github.com:org/repo.git", true},
+		{"ssh://git@example.com/repo.git", true},
+		{"file:///srv/git/repo.git", true},
+		{"/home/user/project", false},
+		{"./project", false},
+		{"C:\\projects\\app", false},
+		{".", false},
+	}
+
+	for _, test := range tests {
+		if got := IsRemoteURL(test.source); got != test.expected {
+			t.Errorf("IsRemoteURL(%q) = %v, expected %v", test.source, got, test.expected)
+		}
+	}
+}
+
+func TestRepoName(t *testing.T) {
+	tests := map[string]string{
+		"https://github.com/org/repo":      "repo",
+		"https://github.com/org/repo.git/": "repo",
+		"git@github.com:org/tool.git":      "tool",
+		"file:///srv/git/project.git":      "project",
+	}
+
+	for url, expected := range tests {
+		if got := RepoName(url); got != expected {
+			t.Errorf("RepoName(%q) = %q, expected %q", url, got, expected)
+		}
+	}
+}
+
+func TestClone(t *testing.T) {
+	url := createBareRepo(t)
+
+	checkout, err := Clone(context.Background(), url, CloneOptions{Depth: DefaultDepth})
+	if err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+
+	if filepath.Base(checkout.Path) != "project" {
+		t.Errorf("Expected checkout directory named after the repo, got %s", checkout.Path)
+	}
+
+	// The checkout must be usable as a normal project directory
+	options := scanner.DefaultScanOptions()
+	result, err := scanner.NewScanner(options).ScanDirectory(checkout.Path)
+	if err != nil {
+		t.Fatalf("ScanDirectory failed: %v", err)
+	}
+
+	scanned := make(map[string]bool)
+	for _, file := range result.Files {
+		scanned[file.Path] = true
+	}
+	if !scanned["main.go"] || !scanned["README.md"] {
+		t.Errorf("Expected main.go and README.md in the checkout, got %v", scanned)
+	}
+	if scanned["feature.go"] {
+		t.Error("Expected only the default branch to be checked out")
+	}
+
+	if err := checkout.Remove(); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := os.Stat(checkout.Path); !os.IsNotExist(err) {
+		t.Errorf("Expected checkout to be removed, stat error: %v", err)
+	}
+}
+
+func TestClone_Branch(t *testing.T) {
+	url := createBareRepo(t)
+
+	checkout, err := Clone(context.Background(), url, CloneOptions{Branch: "feature", Depth: DefaultDepth})
+	if err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+	defer checkout.Remove()
+
+	if _, err := os.Stat(filepath.Join(checkout.Path, "feature.go")); err != nil {
+		t.Errorf("Expected feature.go on the feature branch: %v", err)
+	}
+}
+
+func TestClone_InvalidURL(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	url := "file://" + filepath.ToSlash(filepath.Join(t.TempDir(), "missing.git"))
+	if _, err := Clone(context.Background(), url, CloneOptions{}); err == nil {
+		t.Error("Expected error cloning a missing repository")
+	}
+}
+
+func TestHeadCommit(t *testing.T) {
+	url := createBareRepo(t)
+
+	checkout, err := Clone(context.Background(), url, CloneOptions{Depth: DefaultDepth})
+	if err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+	defer checkout.Remove()
+
+	commit, err := HeadCommit(context.Background(), checkout.Path)
+	if err != nil {
+		t.Fatalf("HeadCommit failed: %v", err)
+	}
+	if len(commit) < 7 || strings.Trim(commit, "0123456789abcdef") != "" {
+		t.Errorf("Expected an abbreviated commit hash, got %q", commit)
+	}
+
+	if _, err := HeadCommit(context.Background(), t.TempDir()); err == nil {
+		t.Error("Expected an error outside a git repository")
+	}
+}
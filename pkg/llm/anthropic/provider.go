@@ -4,7 +4,9 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -14,37 +16,109 @@ import (
 	"time"
 
 	"github.com/kuderr/deepwiki/internal/logging"
+	"github.com/kuderr/deepwiki/pkg/apierror"
 	"github.com/kuderr/deepwiki/pkg/llm"
 	"golang.org/x/time/rate"
 )
 
 const anthropicVersion = "2023-06-01"
 
+// countTokensTimeout bounds a request to the token counting endpoint
+const countTokensTimeout = 10 * time.Second
+
 // AnthropicProvider implements llm.Provider for Anthropic Claude API
 type AnthropicProvider struct {
 	config      *llm.Config
 	httpClient  *http.Client
 	rateLimiter *rate.Limiter
+	rateLimits  *llm.RateLimitTracker // Rate limits reported by the API
 	logger      *logging.Logger
 
 	// Usage tracking
 	usageMutex sync.RWMutex
 	totalUsage llm.TokenCount
+
+	// Token counting
+	tokenMutex       sync.Mutex
+	tokenCounts      map[[sha256.Size]byte]int // Counts of the token counting endpoint, by text hash
+	tokenCountFailed bool                      // The endpoint is not supported; the heuristic is used from then on
 }
 
 // Anthropic API request/response types
 type MessagesRequest struct {
-	Model       string    `json:"model"`
-	Messages    []Message `json:"messages"`
-	MaxTokens   int       `json:"max_tokens"`
-	Temperature float64   `json:"temperature,omitempty"`
-	System      string    `json:"system,omitempty"`
-	Stream      bool      `json:"stream,omitempty"`
+	Model       string       `json:"model"`
+	Messages    []Message    `json:"messages"`
+	MaxTokens   int          `json:"max_tokens"`
+	Temperature float64      `json:"temperature,omitempty"`
+	System      SystemPrompt `json:"system,omitzero"`
+	Stream      bool         `json:"stream,omitempty"`
 }
 
+// Message is a message of a request. A message with CacheControl is sent as a text content
+// block carrying it, which ends a cached prompt prefix.
 type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role         string        `json:"role"`
+	Content      string        `json:"content"`
+	CacheControl *CacheControl `json:"-"`
+}
+
+// SystemPrompt is the system prompt of a request. With CacheControl it is sent as a text content
+// block carrying it, which ends a cached prompt prefix.
+type SystemPrompt struct {
+	Text         string
+	CacheControl *CacheControl
+}
+
+// MarshalJSON encodes the prompt as a string, or as a content block carrying the cache control
+func (s SystemPrompt) MarshalJSON() ([]byte, error) {
+	if s.CacheControl == nil {
+		return json.Marshal(s.Text)
+	}
+	return json.Marshal([]Content{{Type: "text", Text: s.Text, CacheControl: s.CacheControl}})
+}
+
+// UnmarshalJSON decodes a prompt sent as a string or as text content blocks
+func (s *SystemPrompt) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &s.Text); err == nil {
+		return nil
+	}
+
+	var blocks []Content
+	if err := json.Unmarshal(data, &blocks); err != nil {
+		return err
+	}
+	texts := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		texts = append(texts, block.Text)
+		if block.CacheControl != nil {
+			s.CacheControl = block.CacheControl
+		}
+	}
+	s.Text = strings.Join(texts, "\n\n")
+	return nil
+}
+
+// CacheControl marks the end of a cached prompt prefix
+type CacheControl struct {
+	Type string `json:"type"`
+}
+
+// ephemeralCache is the cache control of prompt prefixes, which Anthropic keeps for five minutes
+var ephemeralCache = &CacheControl{Type: "ephemeral"}
+
+// MarshalJSON encodes the content as a string, or as a content block carrying the cache control
+func (m Message) MarshalJSON() ([]byte, error) {
+	if m.CacheControl == nil {
+		type plainMessage Message
+		return json.Marshal(plainMessage(m))
+	}
+	return json.Marshal(struct {
+		Role    string    `json:"role"`
+		Content []Content `json:"content"`
+	}{
+		Role:    m.Role,
+		Content: []Content{{Type: "text", Text: m.Content, CacheControl: m.CacheControl}},
+	})
 }
 
 type MessagesResponse struct {
@@ -59,13 +133,23 @@ type MessagesResponse struct {
 }
 
 type Content struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
+	Type         string        `json:"type"`
+	Text         string        `json:"text"`
+	CacheControl *CacheControl `json:"cache_control,omitempty"`
 }
 
+// Usage is the token usage of a response. Input tokens exclude the prompt tokens written to or
+// read from the cache.
 type Usage struct {
-	InputTokens  int `json:"input_tokens"`
-	OutputTokens int `json:"output_tokens"`
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
+}
+
+// promptTokens returns all prompt tokens, cached or not
+func (u Usage) promptTokens() int {
+	return u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
 }
 
 type StreamResponse struct {
@@ -93,6 +177,15 @@ type ErrorResponse struct {
 	Error APIError `json:"error"`
 }
 
+type CountTokensRequest struct {
+	Model    string    `json:"model"`
+	Messages []Message `json:"messages"`
+}
+
+type CountTokensResponse struct {
+	InputTokens int `json:"input_tokens"`
+}
+
 // NewProvider creates a new Anthropic LLM provider
 func NewProvider(config *llm.Config) (llm.Provider, error) {
 	if config == nil {
@@ -112,15 +205,18 @@ func NewProvider(config *llm.Config) (llm.Provider, error) {
 
 	// Create HTTP client with timeout
 	httpClient := &http.Client{
-		Timeout: config.RequestTimeout,
+		Timeout:   config.RequestTimeout,
+		Transport: config.Scheduler.Transport(nil, llm.SchedulerClass),
 	}
 
 	provider := &AnthropicProvider{
 		config:      config,
 		httpClient:  httpClient,
 		rateLimiter: rateLimiter,
+		rateLimits:  llm.NewRateLimitTracker(),
 		logger:      logging.GetGlobalLogger().WithComponent("anthropic-llm"),
 		totalUsage:  llm.TokenCount{},
+		tokenCounts: make(map[[sha256.Size]byte]int),
 	}
 
 	return provider, nil
@@ -146,16 +242,13 @@ func (p *AnthropicProvider) ChatCompletion(
 			options.Temperature = opts[0].Temperature
 		}
 		options.Stream = opts[0].Stream
+		options.SystemPrompt = opts[0].SystemPrompt
+		options.CacheMessages = opts[0].CacheMessages
+		options.CacheSystemPrompt = opts[0].CacheSystemPrompt
 	}
 
 	// Convert messages to Anthropic format
-	anthropicMessages := make([]Message, len(messages))
-	for i, msg := range messages {
-		anthropicMessages[i] = Message{
-			Role:    msg.Role,
-			Content: msg.Content,
-		}
-	}
+	system, anthropicMessages := convertMessages(messages, options)
 
 	// Create request
 	request := MessagesRequest{
@@ -163,6 +256,7 @@ func (p *AnthropicProvider) ChatCompletion(
 		Messages:    anthropicMessages,
 		MaxTokens:   options.MaxTokens,
 		Temperature: options.Temperature,
+		System:      system,
 		Stream:      false,
 	}
 
@@ -172,11 +266,13 @@ func (p *AnthropicProvider) ChatCompletion(
 	}
 
 	// Update usage stats
-	p.updateUsageStats(response.Usage.InputTokens, response.Usage.OutputTokens)
+	p.updateUsageStats(response.Usage)
 
 	p.logger.InfoContext(ctx, "chat completion successful",
-		slog.Int("input_tokens", response.Usage.InputTokens),
+		slog.Int("input_tokens", response.Usage.promptTokens()),
 		slog.Int("output_tokens", response.Usage.OutputTokens),
+		slog.Int("cache_read_tokens", response.Usage.CacheReadInputTokens),
+		slog.Int("cache_write_tokens", response.Usage.CacheCreationInputTokens),
 	)
 
 	// Convert response to common format
@@ -203,16 +299,13 @@ func (p *AnthropicProvider) ChatCompletionStream(
 		if opts[0].Temperature >= 0 {
 			options.Temperature = opts[0].Temperature
 		}
+		options.SystemPrompt = opts[0].SystemPrompt
+		options.CacheMessages = opts[0].CacheMessages
+		options.CacheSystemPrompt = opts[0].CacheSystemPrompt
 	}
 
 	// Convert messages to Anthropic format
-	anthropicMessages := make([]Message, len(messages))
-	for i, msg := range messages {
-		anthropicMessages[i] = Message{
-			Role:    msg.Role,
-			Content: msg.Content,
-		}
-	}
+	system, anthropicMessages := convertMessages(messages, options)
 
 	// Create request
 	request := MessagesRequest{
@@ -220,17 +313,60 @@ func (p *AnthropicProvider) ChatCompletionStream(
 		Messages:    anthropicMessages,
 		MaxTokens:   options.MaxTokens,
 		Temperature: options.Temperature,
+		System:      system,
 		Stream:      true,
 	}
 
 	return p.sendStreamRequest(ctx, request, handler)
 }
 
-// CountTokens estimates the number of tokens in a text
+// CountTokens counts the tokens of a text sent as a user message with the token counting
+// endpoint, caching the count of each text. When the endpoint fails it estimates ~4 characters
+// per token; when the endpoint is not supported, it keeps estimating for the rest of the run.
 func (p *AnthropicProvider) CountTokens(text string) (int, error) {
-	// Anthropic uses a similar tokenization to GPT models
-	// Rough estimation: ~4 characters per token
-	return len(text) / 4, nil
+	if text == "" {
+		return 0, nil
+	}
+
+	key := sha256.Sum256([]byte(text))
+	p.tokenMutex.Lock()
+	count, cached := p.tokenCounts[key]
+	failed := p.tokenCountFailed
+	p.tokenMutex.Unlock()
+	if cached {
+		return count, nil
+	}
+	if failed {
+		return estimateTokens(text), nil
+	}
+
+	// The lock is not held during the request, so that other texts are counted meanwhile
+	ctx, cancel := context.WithTimeout(context.Background(), countTokensTimeout)
+	defer cancel()
+	count, err := p.requestTokenCount(ctx, text)
+	if err != nil {
+		if errors.Is(err, errTokenCountUnsupported) {
+			p.tokenMutex.Lock()
+			p.tokenCountFailed = true
+			p.tokenMutex.Unlock()
+			p.logger.Warn("token counting endpoint not supported, estimating token counts",
+				slog.String("error", err.Error()))
+		} else {
+			p.logger.Warn("token counting failed, estimating the token count",
+				slog.String("error", err.Error()))
+		}
+		return estimateTokens(text), nil
+	}
+
+	p.tokenMutex.Lock()
+	p.tokenCounts[key] = count
+	p.tokenMutex.Unlock()
+	return count, nil
+}
+
+// estimateTokens estimates the tokens of a text at ~4 characters per token
+func estimateTokens(text string) int {
+	return len(text) / 4
 }
 
 // EstimateCost estimates the cost for the given token usage
@@ -242,11 +378,20 @@ func (p *AnthropicProvider) EstimateCost(promptTokens, completionTokens int) flo
 	return inputCost + outputCost
 }
 
+// estimateUsageCost estimates the cost of a response; cache writes cost 1.25 times the input
+// price and cache reads 0.1 times
+func (p *AnthropicProvider) estimateUsageCost(usage Usage) float64 {
+	cachedInput := 1.25*float64(usage.CacheCreationInputTokens) + 0.1*float64(usage.CacheReadInputTokens)
+	return p.EstimateCost(usage.InputTokens, usage.OutputTokens) + cachedInput*3.0/1000000
+}
+
 // GetUsageStats returns current usage statistics
 func (p *AnthropicProvider) GetUsageStats() llm.TokenCount {
 	p.usageMutex.RLock()
 	defer p.usageMutex.RUnlock()
-	return p.totalUsage
+	stats := p.totalUsage
+	stats.RateLimits = p.rateLimits.Limits()
+	return stats
 }
 
 // ResetUsageStats resets usage statistics
@@ -286,14 +431,18 @@ func (p *AnthropicProvider) sendRequest(ctx context.Context, request MessagesReq
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
+	p.logger.Trace("messages request", slog.String("url", req.URL.String()), slog.String("body", string(requestBody)))
+
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("x-api-key", p.config.APIKey)
 	req.Header.Set("anthropic-version", anthropicVersion)
+	p.config.ApplyHeaders(req)
 
-	// Send request with retries
+	// Send request with retries, waiting first when the reported rate limits are exhausted
 	var response *MessagesResponse
 	var lastErr error
+	tokens := len(requestBody)/4 + request.MaxTokens
 
 	for attempt := 0; attempt <= p.config.MaxRetries; attempt++ {
 		if attempt > 0 {
@@ -302,6 +451,12 @@ func (p *AnthropicProvider) sendRequest(ctx context.Context, request MessagesReq
 				return nil, ctx.Err()
 			case <-time.After(p.config.RetryDelay * time.Duration(attempt)):
 			}
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, fmt.Errorf("failed to create request: %w", err)
+			}
+		}
+		if err := p.rateLimits.Wait(ctx, tokens); err != nil {
+			return nil, err
 		}
 
 		resp, err := p.httpClient.Do(req)
@@ -311,6 +466,7 @@ func (p *AnthropicProvider) sendRequest(ctx context.Context, request MessagesReq
 		}
 
 		defer resp.Body.Close()
+		p.rateLimits.Record(p.logger.Logger, resp)
 
 		body, err := io.ReadAll(resp.Body)
 		if err != nil {
@@ -318,12 +474,14 @@ func (p *AnthropicProvider) sendRequest(ctx context.Context, request MessagesReq
 			continue
 		}
 
+		p.logger.Trace("messages response", slog.Int("status", resp.StatusCode), slog.String("body", string(body)))
+
 		if resp.StatusCode >= 400 {
 			var errorResp ErrorResponse
 			if err := json.Unmarshal(body, &errorResp); err == nil {
-				lastErr = fmt.Errorf("API error: %s", errorResp.Error.Message)
+				lastErr = apierror.New(resp.StatusCode, fmt.Errorf("API error: %s", errorResp.Error.Message))
 			} else {
-				lastErr = fmt.Errorf("API error: %s", string(body))
+				lastErr = apierror.New(resp.StatusCode, fmt.Errorf("API error: %s", string(body)))
 			}
 			continue
 		}
@@ -339,6 +497,89 @@ func (p *AnthropicProvider) sendRequest(ctx context.Context, request MessagesReq
 	return nil, fmt.Errorf("all retry attempts failed, last error: %w", lastErr)
 }
 
+// errTokenCountUnsupported reports that the API has no token counting endpoint, as with
+// proxies and compatible servers that only implement messages
+var errTokenCountUnsupported = errors.New("token counting endpoint not supported")
+
+// requestTokenCount counts the tokens of text as a user message with the token counting
+// endpoint, under the rate limit and with the configured retries for transient errors
+func (p *AnthropicProvider) requestTokenCount(ctx context.Context, text string) (int, error) {
+	requestBody, err := json.Marshal(CountTokensRequest{
+		Model:    p.config.Model,
+		Messages: []Message{{Role: "user", Content: text}},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= p.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			case <-time.After(p.config.RetryDelay * time.Duration(attempt)):
+			}
+		}
+		if err := p.rateLimiter.Wait(ctx); err != nil {
+			return 0, fmt.Errorf("rate limit exceeded: %w", err)
+		}
+
+		count, retryable, err := p.sendTokenCount(ctx, requestBody)
+		if err == nil {
+			return count, nil
+		}
+		lastErr = err
+		if !retryable {
+			return 0, err
+		}
+	}
+
+	return 0, fmt.Errorf("all retry attempts failed, last error: %w", lastErr)
+}
+
+// sendTokenCount sends one token counting request and reports whether a failure is transient
+func (p *AnthropicProvider) sendTokenCount(ctx context.Context, requestBody []byte) (int, bool, error) {
+	url := p.config.BaseURL + "/messages/count_tokens"
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(requestBody))
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", p.config.APIKey)
+	req.Header.Set("anthropic-version", anthropicVersion)
+	p.config.ApplyHeaders(req)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return 0, ctx.Err() == nil, fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, true, fmt.Errorf("failed to read response: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed ||
+		resp.StatusCode == http.StatusNotImplemented:
+		return 0, false, fmt.Errorf("%w: status %d", errTokenCountUnsupported, resp.StatusCode)
+	case resp.StatusCode >= 400:
+		retryable := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		var errorResp ErrorResponse
+		if err := json.Unmarshal(body, &errorResp); err == nil && errorResp.Error.Message != "" {
+			return 0, retryable, fmt.Errorf("API error: %s", errorResp.Error.Message)
+		}
+		return 0, retryable, fmt.Errorf("API error: %s", string(body))
+	}
+
+	var response CountTokensResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return 0, false, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return response.InputTokens, true, nil
+}
+
 func (p *AnthropicProvider) sendStreamRequest(
 	ctx context.Context,
 	request MessagesRequest,
@@ -366,21 +607,26 @@ func (p *AnthropicProvider) sendStreamRequest(
 	req.Header.Set("x-api-key", p.config.APIKey)
 	req.Header.Set("anthropic-version", anthropicVersion)
 	req.Header.Set("Accept", "text/event-stream")
+	p.config.ApplyHeaders(req)
 
 	// Send request
+	if err := p.rateLimits.Wait(ctx, len(requestBody)/4+request.MaxTokens); err != nil {
+		return err
+	}
 	resp, err := p.httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("HTTP request failed: %w", err)
 	}
 	defer resp.Body.Close()
+	p.rateLimits.Record(p.logger.Logger, resp)
 
 	if resp.StatusCode >= 400 {
 		body, _ := io.ReadAll(resp.Body)
 		var errorResp ErrorResponse
 		if err := json.Unmarshal(body, &errorResp); err == nil {
-			return fmt.Errorf("API error: %s", errorResp.Error.Message)
+			return apierror.New(resp.StatusCode, fmt.Errorf("API error: %s", errorResp.Error.Message))
 		}
-		return fmt.Errorf("API error: %s", string(body))
+		return apierror.New(resp.StatusCode, fmt.Errorf("API error: %s", string(body)))
 	}
 
 	// Process streaming response
@@ -412,14 +658,52 @@ func (p *AnthropicProvider) sendStreamRequest(
 	return scanner.Err()
 }
 
-func (p *AnthropicProvider) updateUsageStats(inputTokens, outputTokens int) {
+func (p *AnthropicProvider) updateUsageStats(usage Usage) {
 	p.usageMutex.Lock()
 	defer p.usageMutex.Unlock()
 
-	p.totalUsage.PromptTokens += inputTokens
-	p.totalUsage.CompletionTokens += outputTokens
-	p.totalUsage.TotalTokens += inputTokens + outputTokens
-	p.totalUsage.EstimatedCost += p.EstimateCost(inputTokens, outputTokens)
+	p.totalUsage.PromptTokens += usage.promptTokens()
+	p.totalUsage.CompletionTokens += usage.OutputTokens
+	p.totalUsage.TotalTokens += usage.promptTokens() + usage.OutputTokens
+	p.totalUsage.CacheWriteTokens += usage.CacheCreationInputTokens
+	p.totalUsage.CacheReadTokens += usage.CacheReadInputTokens
+	p.totalUsage.EstimatedCost += p.estimateUsageCost(usage)
+}
+
+// convertMessages converts messages to the Anthropic format. Anthropic takes no system messages,
+// so their content is returned after the system prompt as the top-level system prompt. The last
+// message of the first CacheMessages messages is marked as the end of a cached prefix, and so is
+// the system prompt with CacheSystemPrompt or when that prefix holds only system messages.
+func convertMessages(messages []llm.Message, options llm.ChatCompletionOptions) (SystemPrompt, []Message) {
+	var system []string
+	if options.SystemPrompt != "" {
+		system = append(system, options.SystemPrompt)
+	}
+
+	converted := make([]Message, 0, len(messages))
+	lastCached := -1
+	for i, msg := range messages {
+		if msg.Role == "system" {
+			system = append(system, msg.Content)
+			continue
+		}
+		converted = append(converted, Message{
+			Role:    msg.Role,
+			Content: msg.Content,
+		})
+		if i < options.CacheMessages {
+			lastCached = len(converted) - 1
+		}
+	}
+	if lastCached >= 0 {
+		converted[lastCached].CacheControl = ephemeralCache
+	}
+
+	prompt := SystemPrompt{Text: strings.Join(system, "\n\n")}
+	if prompt.Text != "" && (options.CacheSystemPrompt || (options.CacheMessages > 0 && lastCached < 0)) {
+		prompt.CacheControl = ephemeralCache
+	}
+	return prompt, converted
 }
 
 func (p *AnthropicProvider) convertResponse(resp *MessagesResponse) *llm.ChatCompletionResponse {
@@ -444,9 +728,11 @@ func (p *AnthropicProvider) convertResponse(resp *MessagesResponse) *llm.ChatCom
 			},
 		},
 		Usage: llm.Usage{
-			PromptTokens:     resp.Usage.InputTokens,
+			PromptTokens:     resp.Usage.promptTokens(),
 			CompletionTokens: resp.Usage.OutputTokens,
-			TotalTokens:      resp.Usage.InputTokens + resp.Usage.OutputTokens,
+			TotalTokens:      resp.Usage.promptTokens() + resp.Usage.OutputTokens,
+			CacheWriteTokens: resp.Usage.CacheCreationInputTokens,
+			CacheReadTokens:  resp.Usage.CacheReadInputTokens,
 		},
 	}
 }
@@ -428,10 +428,178 @@ func TestAnthropicProvider_ChatCompletionStream(t *testing.T) {
 	}
 }
 
+func TestAnthropicProvider_SystemPrompt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request MessagesRequest
+		json.NewDecoder(r.Body).Decode(&request)
+
+		wantSystem := "You are a technical writer.\n\nAnswer in English."
+		if request.System.Text != wantSystem || request.System.CacheControl != nil {
+			t.Errorf("Expected the system prompt and system messages as the system field, got %+v", request.System)
+		}
+		if len(request.Messages) != 1 || request.Messages[0].Role != "user" || request.Messages[0].Content != "Test" {
+			t.Errorf("Expected only the user message in messages, got %+v", request.Messages)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(MessagesResponse{
+			Role:       "assistant",
+			Content:    []Content{{Type: "text", Text: "Test response"}},
+			StopReason: "end_turn",
+		})
+	}))
+	defer server.Close()
+
+	provider, err := NewProvider(&llm.Config{
+		Provider:       llm.ProviderAnthropic,
+		APIKey:         "test-key",
+		Model:          "claude-3-5-sonnet-20241022",
+		BaseURL:        server.URL,
+		MaxTokens:      4000,
+		RequestTimeout: 30 * time.Second,
+		RateLimitRPS:   2.0,
+	})
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+
+	messages := []llm.Message{
+		{Role: "system", Content: "Answer in English."},
+		{Role: "user", Content: "Test"},
+	}
+	options := llm.ChatCompletionOptions{SystemPrompt: "You are a technical writer."}
+	if _, err := provider.ChatCompletion(context.Background(), messages, options); err != nil {
+		t.Fatalf("ChatCompletion() error = %v", err)
+	}
+}
+
+func TestAnthropicProvider_PromptCaching(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Content json.RawMessage `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		if len(req.Messages) != 2 {
+			t.Fatalf("Expected 2 messages, got %d", len(req.Messages))
+		}
+
+		var blocks []Content
+		if err := json.Unmarshal(req.Messages[0].Content, &blocks); err != nil {
+			t.Errorf("Expected the cached message as content blocks, got %s", req.Messages[0].Content)
+		}
+		if len(blocks) != 1 || blocks[0].Text != "Shared context" || blocks[0].CacheControl == nil ||
+			blocks[0].CacheControl.Type != "ephemeral" {
+			t.Errorf("Expected an ephemeral cache_control on the cached message, got %s", req.Messages[0].Content)
+		}
+		if string(req.Messages[1].Content) != `"Question"` {
+			t.Errorf("Expected the uncached message as a plain string, got %s", req.Messages[1].Content)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","model":"claude-3-5-sonnet-20241022",
+"content":[{"type":"text","text":"Answer"}],"stop_reason":"end_turn",
+"usage":{"input_tokens":10,"output_tokens":5,"cache_creation_input_tokens":200,"cache_read_input_tokens":800}}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewProvider(&llm.Config{
+		Provider:       llm.ProviderAnthropic,
+		APIKey:         "test-key",
+		Model:          "claude-3-5-sonnet-20241022",
+		BaseURL:        server.URL,
+		MaxTokens:      4000,
+		RequestTimeout: 30 * time.Second,
+		RateLimitRPS:   2.0,
+	})
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+
+	messages := []llm.Message{
+		{Role: "user", Content: "Shared context"},
+		{Role: "user", Content: "Question"},
+	}
+	options := llm.ChatCompletionOptions{CacheMessages: 1}
+	response, err := provider.ChatCompletion(context.Background(), messages, options)
+	if err != nil {
+		t.Fatalf("ChatCompletion() error = %v", err)
+	}
+
+	wantUsage := llm.Usage{
+		PromptTokens:     1010,
+		CompletionTokens: 5,
+		TotalTokens:      1015,
+		CacheWriteTokens: 200,
+		CacheReadTokens:  800,
+	}
+	if response.Usage != wantUsage {
+		t.Errorf("Expected usage %+v, got %+v", wantUsage, response.Usage)
+	}
+
+	stats := provider.GetUsageStats()
+	if stats.PromptTokens != 1010 || stats.CacheWriteTokens != 200 || stats.CacheReadTokens != 800 {
+		t.Errorf("Expected cached tokens in usage stats, got %+v", stats)
+	}
+	if uncached := provider.EstimateCost(1010, 5); stats.EstimatedCost >= uncached {
+		t.Errorf("Expected cache reads to lower the cost below %f, got %f", uncached, stats.EstimatedCost)
+	}
+}
+
+func TestConvertMessages_CachesSystemPrompt(t *testing.T) {
+	messages := []llm.Message{
+		{Role: "system", Content: "Answer in English."},
+		{Role: "user", Content: "Question"},
+	}
+	tests := []struct {
+		name       string
+		options    llm.ChatCompletionOptions
+		wantSystem bool
+		wantUser   bool
+	}{
+		{"no caching", llm.ChatCompletionOptions{}, false, false},
+		{"system prompt", llm.ChatCompletionOptions{CacheSystemPrompt: true}, true, false},
+		{"system messages prefix", llm.ChatCompletionOptions{CacheMessages: 1}, true, false},
+		{"message prefix", llm.ChatCompletionOptions{CacheMessages: 2}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			system, converted := convertMessages(messages, tt.options)
+			if (system.CacheControl != nil) != tt.wantSystem || (converted[0].CacheControl != nil) != tt.wantUser {
+				t.Errorf("Expected system cached %v and user message cached %v, got %+v and %+v",
+					tt.wantSystem, tt.wantUser, system, converted)
+			}
+		})
+	}
+
+	system, _ := convertMessages(messages, llm.ChatCompletionOptions{CacheSystemPrompt: true})
+	data, err := json.Marshal(system)
+	if err != nil {
+		t.Fatalf("Failed to encode system prompt: %v", err)
+	}
+	want := `[{"type":"text","text":"Answer in English.","cache_control":{"type":"ephemeral"}}]`
+	if string(data) != want {
+		t.Errorf("Expected the cached system prompt as a content block, got %s", data)
+	}
+}
+
 func TestAnthropicProvider_CountTokens(t *testing.T) {
+	// Without a token counting endpoint, tokens are estimated
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
 	config := &llm.Config{
 		Provider:       llm.ProviderAnthropic,
 		APIKey:         "test-key",
+		BaseURL:        server.URL,
 		Model:          "claude-3-5-sonnet-20241022",
 		MaxTokens:      4000,
 		Temperature:    0.1,
@@ -467,6 +635,106 @@ func TestAnthropicProvider_CountTokens(t *testing.T) {
 			}
 		})
 	}
+
+	if requests != 1 {
+		t.Errorf("Expected estimates without retries once the endpoint is missing, got %d requests", requests)
+	}
+}
+
+func TestAnthropicProvider_CountTokensTransientErrors(t *testing.T) {
+	requests, failures := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(CountTokensResponse{InputTokens: 11})
+	}))
+	defer server.Close()
+
+	provider, err := NewProvider(&llm.Config{
+		Provider:       llm.ProviderAnthropic,
+		APIKey:         "test-key",
+		Model:          "claude-3-5-sonnet-20241022",
+		BaseURL:        server.URL,
+		RequestTimeout: 30 * time.Second,
+		MaxRetries:     2,
+		RetryDelay:     time.Millisecond,
+		RateLimitRPS:   100.0,
+	})
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+
+	// A failure within the retries is retried
+	failures = 1
+	if count, _ := provider.CountTokens("hello world"); count != 11 || requests != 2 {
+		t.Errorf("Expected the endpoint count after a retry, got %d after %d requests", count, requests)
+	}
+
+	// A failure of every retry is estimated, without giving up on the endpoint
+	requests, failures = 0, 3
+	if count, _ := provider.CountTokens("hello again"); count != estimateTokens("hello again") || requests != 3 {
+		t.Errorf("Expected an estimate after 3 requests, got %d after %d requests", count, requests)
+	}
+	requests, failures = 0, 0
+	if count, _ := provider.CountTokens("hello again"); count != 11 || requests != 1 {
+		t.Errorf("Expected the endpoint count once it recovers, got %d after %d requests", count, requests)
+	}
+}
+
+func TestAnthropicProvider_CountTokensEndpoint(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/messages/count_tokens" {
+			t.Errorf("Expected path '/messages/count_tokens', got %s", r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "test-key" {
+			t.Errorf("Expected x-api-key 'test-key', got %s", r.Header.Get("x-api-key"))
+		}
+
+		var req CountTokensRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		if req.Model != "claude-3-5-sonnet-20241022" || len(req.Messages) != 1 ||
+			req.Messages[0].Role != "user" || req.Messages[0].Content != "hello world" {
+			t.Errorf("Expected the text as a user message, got %+v", req)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(CountTokensResponse{InputTokens: 11})
+	}))
+	defer server.Close()
+
+	provider, err := NewProvider(&llm.Config{
+		Provider:       llm.ProviderAnthropic,
+		APIKey:         "test-key",
+		Model:          "claude-3-5-sonnet-20241022",
+		BaseURL:        server.URL,
+		RequestTimeout: 30 * time.Second,
+		RateLimitRPS:   2.0,
+	})
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+
+	for range 2 {
+		count, err := provider.CountTokens("hello world")
+		if err != nil {
+			t.Fatalf("CountTokens() error = %v", err)
+		}
+		if count != 11 {
+			t.Errorf("CountTokens() = %d, want the endpoint count 11", count)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Expected the repeated text to be counted once, got %d requests", requests)
+	}
 }
 
 func TestAnthropicProvider_EstimateCost(t *testing.T) {
@@ -527,7 +795,7 @@ func TestAnthropicProvider_UsageStats(t *testing.T) {
 
 	// Simulate usage update
 	anthropicProvider := provider.(*AnthropicProvider)
-	anthropicProvider.updateUsageStats(100, 50)
+	anthropicProvider.updateUsageStats(Usage{InputTokens: 100, OutputTokens: 50})
 
 	// Check updated stats
 	stats = provider.GetUsageStats()
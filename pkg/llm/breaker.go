@@ -0,0 +1,71 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kuderr/deepwiki/pkg/circuitbreaker"
+)
+
+// CircuitBreakerProvider wraps a provider so that requests fail fast while the provider is
+// consistently failing, instead of each one waiting out the full retry budget
+type CircuitBreakerProvider struct {
+	Provider
+	breaker *circuitbreaker.Breaker
+}
+
+// NewCircuitBreakerProvider creates a provider that stops calling provider for cooldown
+// after threshold consecutive failed requests
+func NewCircuitBreakerProvider(
+	provider Provider,
+	threshold int,
+	cooldown time.Duration,
+) (*CircuitBreakerProvider, error) {
+	if provider == nil {
+		return nil, fmt.Errorf("provider cannot be nil")
+	}
+
+	name := fmt.Sprintf("llm/%s", provider.GetProviderType())
+	breaker, err := circuitbreaker.NewBreaker(name, threshold, cooldown)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CircuitBreakerProvider{
+		Provider: provider,
+		breaker:  breaker,
+	}, nil
+}
+
+// ChatCompletion calls the wrapped provider unless the breaker is open
+func (p *CircuitBreakerProvider) ChatCompletion(
+	ctx context.Context,
+	messages []Message,
+	opts ...ChatCompletionOptions,
+) (*ChatCompletionResponse, error) {
+	var response *ChatCompletionResponse
+	err := p.breaker.Execute(ctx, func() error {
+		var err error
+		response, err = p.Provider.ChatCompletion(ctx, messages, opts...)
+		return err
+	})
+	return response, err
+}
+
+// ChatCompletionStream calls the wrapped provider unless the breaker is open
+func (p *CircuitBreakerProvider) ChatCompletionStream(
+	ctx context.Context,
+	messages []Message,
+	handler StreamHandler,
+	opts ...ChatCompletionOptions,
+) error {
+	return p.breaker.Execute(ctx, func() error {
+		return p.Provider.ChatCompletionStream(ctx, messages, handler, opts...)
+	})
+}
+
+// BreakerState returns the state of the circuit breaker
+func (p *CircuitBreakerProvider) BreakerState() circuitbreaker.State {
+	return p.breaker.State()
+}
@@ -0,0 +1,61 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kuderr/deepwiki/pkg/apierror"
+	"github.com/kuderr/deepwiki/pkg/circuitbreaker"
+)
+
+// failingProvider fails every chat completion and counts calls
+type failingProvider struct {
+	countingProvider
+}
+
+func (p *failingProvider) ChatCompletion(
+	ctx context.Context,
+	messages []Message,
+	opts ...ChatCompletionOptions,
+) (*ChatCompletionResponse, error) {
+	p.calls++
+	return nil, apierror.New(http.StatusServiceUnavailable, errors.New("API request failed with status 503"))
+}
+
+func TestCircuitBreakerProvider_FailsFastOnceOpen(t *testing.T) {
+	inner := &failingProvider{}
+	provider, err := NewCircuitBreakerProvider(inner, 3, time.Minute)
+	if err != nil {
+		t.Fatalf("NewCircuitBreakerProvider failed: %v", err)
+	}
+
+	messages := []Message{{Role: "user", Content: "Describe the project"}}
+	for range 3 {
+		if _, err := provider.ChatCompletion(context.Background(), messages); err == nil {
+			t.Fatal("Expected the provider error")
+		}
+	}
+
+	_, err = provider.ChatCompletion(context.Background(), messages)
+	if !errors.Is(err, circuitbreaker.ErrOpen) {
+		t.Errorf("Expected ErrOpen after 3 failures, got %v", err)
+	}
+	if inner.calls != 3 {
+		t.Errorf("Expected the wrapped provider to be skipped once open, got %d calls", inner.calls)
+	}
+	if state := provider.BreakerState(); state != circuitbreaker.StateOpen {
+		t.Errorf("Expected open breaker, got %s", state)
+	}
+}
+
+func TestNewCircuitBreakerProvider_Validation(t *testing.T) {
+	if _, err := NewCircuitBreakerProvider(nil, 3, time.Minute); err == nil {
+		t.Error("Expected an error for a nil provider")
+	}
+	if _, err := NewCircuitBreakerProvider(&countingProvider{}, 0, time.Minute); err == nil {
+		t.Error("Expected an error for a zero threshold")
+	}
+}
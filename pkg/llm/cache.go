@@ -0,0 +1,195 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kuderr/deepwiki/internal/logging"
+)
+
+// DefaultCacheTTL is how long cached responses are reused when no TTL is configured
+const DefaultCacheTTL = 24 * time.Hour
+
+// CacheOptions configures the response cache
+type CacheOptions struct {
+	Directory string        // Directory holding cached responses
+	TTL       time.Duration // Age after which a cached response is ignored (0 = DefaultCacheTTL)
+}
+
+// cacheEntry is the on-disk form of a cached response
+type cacheEntry struct {
+	CreatedAt time.Time               `json:"created_at"`
+	Response  *ChatCompletionResponse `json:"response"`
+}
+
+// cacheKeyInput is everything that determines a chat completion response
+type cacheKeyInput struct {
+	Provider    ProviderType `json:"provider"`
+	Model       string       `json:"model"`
+	Messages    []Message    `json:"messages"`
+	System      string       `json:"system,omitempty"`
+	MaxTokens   int          `json:"max_tokens"`
+	Temperature float64      `json:"temperature"`
+}
+
+// CachingProvider wraps a provider and serves repeated identical chat completions from disk.
+// Streaming requests are passed through uncached, and so are empty and truncated responses,
+// which callers retry.
+type CachingProvider struct {
+	Provider
+	options CacheOptions
+	logger  *logging.Logger
+}
+
+// NewCachingProvider creates a provider that caches responses of provider in options.Directory
+func NewCachingProvider(provider Provider, options CacheOptions) (*CachingProvider, error) {
+	if provider == nil {
+		return nil, fmt.Errorf("provider cannot be nil")
+	}
+	if options.Directory == "" {
+		return nil, fmt.Errorf("cache directory is required")
+	}
+	if options.TTL <= 0 {
+		options.TTL = DefaultCacheTTL
+	}
+	if err := os.MkdirAll(options.Directory, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	return &CachingProvider{
+		Provider: provider,
+		options:  options,
+		logger:   logging.GetGlobalLogger().WithComponent("llm-cache"),
+	}, nil
+}
+
+// ChatCompletion returns a cached response for an identical earlier request, or calls the provider.
+// With BypassCache set, it always calls the provider and drops the entry of an unusable response.
+func (p *CachingProvider) ChatCompletion(
+	ctx context.Context,
+	messages []Message,
+	opts ...ChatCompletionOptions,
+) (*ChatCompletionResponse, error) {
+	key, err := p.cacheKey(messages, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	bypass := len(opts) > 0 && opts[0].BypassCache
+	if !bypass {
+		if response, ok := p.load(key); ok {
+			p.logger.Debug("serving chat completion from cache", slog.String("key", key[:12]))
+			return response, nil
+		}
+	}
+
+	response, err := p.Provider.ChatCompletion(ctx, messages, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if !cacheable(response) {
+		if bypass {
+			os.Remove(p.path(key))
+		}
+		return response, nil
+	}
+
+	// A failed write only costs a future cache miss
+	if err := p.store(key, response); err != nil {
+		p.logger.Warn("failed to cache chat completion", slog.String("error", err.Error()))
+	}
+
+	return response, nil
+}
+
+// cacheable reports whether response is worth serving again: it has content that the token
+// limit did not cut off
+func cacheable(response *ChatCompletionResponse) bool {
+	if response == nil || len(response.Choices) == 0 {
+		return false
+	}
+	choice := response.Choices[0]
+	return strings.TrimSpace(choice.Message.Content) != "" && !choice.Truncated()
+}
+
+// cacheKey hashes the model, messages and options of a request
+func (p *CachingProvider) cacheKey(messages []Message, opts []ChatCompletionOptions) (string, error) {
+	input := cacheKeyInput{
+		Provider: p.GetProviderType(),
+		Model:    p.GetModel(),
+		Messages: messages,
+	}
+	if len(opts) > 0 {
+		input.MaxTokens = opts[0].MaxTokens
+		input.Temperature = opts[0].Temperature
+		input.System = opts[0].SystemPrompt
+	}
+
+	data, err := json.Marshal(input)
+	if err != nil {
+		return "", fmt.Errorf("failed to build cache key: %w", err)
+	}
+
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// path returns the cache file for key
+func (p *CachingProvider) path(key string) string {
+	return filepath.Join(p.options.Directory, key+".json")
+}
+
+// load returns the cached response for key if it exists and has not expired
+func (p *CachingProvider) load(key string) (*ChatCompletionResponse, bool) {
+	data, err := os.ReadFile(p.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || !cacheable(entry.Response) {
+		return nil, false
+	}
+	if time.Since(entry.CreatedAt) > p.options.TTL {
+		return nil, false
+	}
+
+	return entry.Response, true
+}
+
+// store writes response to the cache under key
+func (p *CachingProvider) store(key string, response *ChatCompletionResponse) error {
+	data, err := json.Marshal(cacheEntry{CreatedAt: time.Now(), Response: response})
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+
+	// Write to a temporary file first so concurrent readers never see a partial entry
+	tmp, err := os.CreateTemp(p.options.Directory, key+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create cache file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), p.path(key)); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package llm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// countingProvider echoes the first message back and counts calls
+type countingProvider struct {
+	calls        int
+	finishReason string
+}
+
+func (p *countingProvider) ChatCompletion(
+	ctx context.Context,
+	messages []Message,
+	opts ...ChatCompletionOptions,
+) (*ChatCompletionResponse, error) {
+	p.calls++
+	return &ChatCompletionResponse{
+		Model: "test-model",
+		Choices: []Choice{{
+			Message:      Message{Role: "assistant", Content: messages[0].Content},
+			FinishReason: p.finishReason,
+		}},
+		Usage: Usage{TotalTokens: p.calls},
+	}, nil
+}
+
+func (p *countingProvider) ChatCompletionStream(
+	ctx context.Context,
+	messages []Message,
+	handler StreamHandler,
+	opts ...ChatCompletionOptions,
+) error {
+	p.calls++
+	return nil
+}
+
+func (p *countingProvider) CountTokens(text string) (int, error)                    { return len(text) / 4, nil }
+func (p *countingProvider) EstimateCost(promptTokens, completionTokens int) float64 { return 0 }
+func (p *countingProvider) GetUsageStats() TokenCount                               { return TokenCount{} }
+func (p *countingProvider) ResetUsageStats()                                        {}
+func (p *countingProvider) GetProviderType() ProviderType                           { return ProviderOpenAI }
+func (p *countingProvider) GetModel() string                                        { return "test-model" }
+
+func TestCachingProvider_ServesRepeatedRequests(t *testing.T) {
+	inner := &countingProvider{}
+	provider, err := NewCachingProvider(inner, CacheOptions{Directory: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewCachingProvider failed: %v", err)
+	}
+
+	ctx := context.Background()
+	messages := []Message{{Role: "user", Content: "Describe the project"}}
+	options := ChatCompletionOptions{MaxTokens: 100, Temperature: 0.1}
+
+	first, err := provider.ChatCompletion(ctx, messages, options)
+	if err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+	second, err := provider.ChatCompletion(ctx, messages, options)
+	if err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+
+	if inner.calls != 1 {
+		t.Errorf("Expected 1 provider call, got %d", inner.calls)
+	}
+	if second.Choices[0].Message.Content != first.Choices[0].Message.Content {
+		t.Errorf("Expected cached content %q, got %q", first.Choices[0].Message.Content,
+			second.Choices[0].Message.Content)
+	}
+
+	// Different options or messages are different requests
+	if _, err := provider.ChatCompletion(ctx, messages, ChatCompletionOptions{MaxTokens: 200}); err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+	if _, err := provider.ChatCompletion(ctx, []Message{{Role: "user", Content: "Other"}}, options); err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+	if inner.calls != 3 {
+		t.Errorf("Expected 3 provider calls, got %d", inner.calls)
+	}
+}
+
+func TestCachingProvider_ExpiredEntry(t *testing.T) {
+	dir := t.TempDir()
+	inner := &countingProvider{}
+	provider, err := NewCachingProvider(inner, CacheOptions{Directory: dir, TTL: time.Hour})
+	if err != nil {
+		t.Fatalf("NewCachingProvider failed: %v", err)
+	}
+
+	ctx := context.Background()
+	messages := []Message{{Role: "user", Content: "Describe the project"}}
+	if _, err := provider.ChatCompletion(ctx, messages); err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+
+	// Age the entry past the TTL
+	key, err := provider.cacheKey(messages, nil)
+	if err != nil {
+		t.Fatalf("cacheKey failed: %v", err)
+	}
+	stale := []byte(`{"created_at":"2000-01-01T00:00:00Z","response":{"model":"stale"}}`)
+	if err := os.WriteFile(filepath.Join(dir, key+".json"), stale, 0o644); err != nil {
+		t.Fatalf("failed to write stale entry: %v", err)
+	}
+
+	response, err := provider.ChatCompletion(ctx, messages)
+	if err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+	if inner.calls != 2 || response.Model == "stale" {
+		t.Errorf("Expected an expired entry to be refreshed, got %d calls and model %q", inner.calls, response.Model)
+	}
+}
+
+func TestCachingProvider_SkipsUnusableResponses(t *testing.T) {
+	inner := &countingProvider{}
+	provider, err := NewCachingProvider(inner, CacheOptions{Directory: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewCachingProvider failed: %v", err)
+	}
+
+	ctx := context.Background()
+	blank := []Message{{Role: "user", Content: "  \n"}}
+	for range 2 {
+		if _, err := provider.ChatCompletion(ctx, blank); err != nil {
+			t.Fatalf("ChatCompletion failed: %v", err)
+		}
+	}
+	if inner.calls != 2 {
+		t.Errorf("Expected blank responses not to be cached, got %d calls", inner.calls)
+	}
+
+	inner.calls, inner.finishReason = 0, "length"
+	messages := []Message{{Role: "user", Content: "Describe the project"}}
+	for range 2 {
+		if _, err := provider.ChatCompletion(ctx, messages); err != nil {
+			t.Fatalf("ChatCompletion failed: %v", err)
+		}
+	}
+	if inner.calls != 2 {
+		t.Errorf("Expected truncated responses not to be cached, got %d calls", inner.calls)
+	}
+}
+
+func TestCachingProvider_BypassCache(t *testing.T) {
+	dir := t.TempDir()
+	inner := &countingProvider{}
+	provider, err := NewCachingProvider(inner, CacheOptions{Directory: dir})
+	if err != nil {
+		t.Fatalf("NewCachingProvider failed: %v", err)
+	}
+
+	ctx := context.Background()
+	messages := []Message{{Role: "user", Content: "Describe the project"}}
+	if _, err := provider.ChatCompletion(ctx, messages); err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+
+	// A bypassing request reaches the provider, and an unusable answer evicts the entry
+	inner.finishReason = "max_tokens"
+	if _, err := provider.ChatCompletion(ctx, messages, ChatCompletionOptions{BypassCache: true}); err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+	if inner.calls != 2 {
+		t.Errorf("Expected the bypassing request to reach the provider, got %d calls", inner.calls)
+	}
+	key, err := provider.cacheKey(messages, nil)
+	if err != nil {
+		t.Fatalf("cacheKey failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, key+".json")); !os.IsNotExist(err) {
+		t.Errorf("Expected the cache entry to be evicted, got %v", err)
+	}
+}
+
+func TestCachingProvider_StreamNotCached(t *testing.T) {
+	inner := &countingProvider{}
+	provider, err := NewCachingProvider(inner, CacheOptions{Directory: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewCachingProvider failed: %v", err)
+	}
+
+	messages := []Message{{Role: "user", Content: "Describe the project"}}
+	for range 2 {
+		if err := provider.ChatCompletionStream(context.Background(), messages, nil); err != nil {
+			t.Fatalf("ChatCompletionStream failed: %v", err)
+		}
+	}
+	if inner.calls != 2 {
+		t.Errorf("Expected streaming requests to bypass the cache, got %d calls", inner.calls)
+	}
+}
+
+func TestNewCachingProvider_RequiresDirectory(t *testing.T) {
+	if _, err := NewCachingProvider(&countingProvider{}, CacheOptions{}); err == nil {
+		t.Error("Expected error without a cache directory")
+	}
+}
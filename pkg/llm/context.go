@@ -0,0 +1,33 @@
+package llm
+
+import "strings"
+
+// modelContextWindows lists the context window in tokens of well-known models by name prefix.
+// More specific prefixes come first.
+var modelContextWindows = []struct {
+	prefix string
+	tokens int
+}{
+	{"gpt-4.1", 1047576},
+	{"gpt-4o", 128000},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4-32k", 32768},
+	{"gpt-4", 8192},
+	{"gpt-3.5-turbo", 16385},
+	{"o1", 200000},
+	{"o3", 200000},
+	{"o4", 200000},
+	{"claude-", 200000},
+}
+
+// ContextWindow returns the context window in tokens of a model, shared by the prompt and the
+// response, or 0 when the model is not known
+func ContextWindow(model string) int {
+	model = strings.ToLower(model)
+	for _, window := range modelContextWindows {
+		if strings.HasPrefix(model, window.prefix) {
+			return window.tokens
+		}
+	}
+	return 0
+}
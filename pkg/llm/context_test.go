@@ -0,0 +1,19 @@
+package llm
+
+import "testing"
+
+func TestContextWindow(t *testing.T) {
+	tests := map[string]int{
+		"gpt-4o-mini":                128000,
+		"gpt-4.1-nano":               1047576,
+		"gpt-4":                      8192,
+		"GPT-3.5-Turbo":              16385,
+		"claude-3-5-sonnet-20241022": 200000,
+		"llama3.2":                   0,
+	}
+	for model, want := range tests {
+		if got := ContextWindow(model); got != want {
+			t.Errorf("ContextWindow(%q) = %d, want %d", model, got, want)
+		}
+	}
+}
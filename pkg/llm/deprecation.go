@@ -0,0 +1,34 @@
+package llm
+
+import "strings"
+
+// deprecatedModels maps models their provider has deprecated or retired to a suggested replacement
+var deprecatedModels = map[string]string{
+	"gpt-3.5-turbo-0301":         "gpt-4o-mini",
+	"gpt-3.5-turbo-0613":         "gpt-4o-mini",
+	"gpt-3.5-turbo-16k":          "gpt-4o-mini",
+	"gpt-3.5-turbo-16k-0613":     "gpt-4o-mini",
+	"gpt-4-0314":                 "gpt-4o",
+	"gpt-4-32k":                  "gpt-4o",
+	"gpt-4-32k-0314":             "gpt-4o",
+	"gpt-4-32k-0613":             "gpt-4o",
+	"gpt-4-vision-preview":       "gpt-4o",
+	"gpt-4-1106-vision-preview":  "gpt-4o",
+	"gpt-4.5-preview":            "gpt-4.1",
+	"o1-preview":                 "o3",
+	"o1-mini":                    "o4-mini",
+	"claude-instant-1.2":         "claude-3-5-haiku-latest",
+	"claude-2.0":                 "claude-sonnet-4-0",
+	"claude-2.1":                 "claude-sonnet-4-0",
+	"claude-3-sonnet-20240229":   "claude-sonnet-4-0",
+	"claude-3-opus-20240229":     "claude-opus-4-1",
+	"claude-3-5-sonnet-20240620": "claude-sonnet-4-0",
+	"claude-3-5-sonnet-20241022": "claude-sonnet-4-0",
+}
+
+// DeprecatedModel reports whether a model has been deprecated by its provider and returns the
+// suggested replacement
+func DeprecatedModel(model string) (replacement string, deprecated bool) {
+	replacement, deprecated = deprecatedModels[strings.ToLower(model)]
+	return replacement, deprecated
+}
@@ -0,0 +1,144 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/kuderr/deepwiki/internal/logging"
+)
+
+// redactedSecret replaces secrets in dumped requests and responses
+const redactedSecret = "[REDACTED]"
+
+// dumpSecretPatterns match API keys that may appear in prompts or responses
+var dumpSecretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\bsk-(?:proj-|ant-)?[A-Za-z0-9_\-]{32,}\b`), // OpenAI / Anthropic key
+	regexp.MustCompile(`(?i)\bBearer\s+[A-Za-z0-9._\-]{16,}`),       // Authorization header value
+}
+
+// dumpOptions are the request options written to a dump
+type dumpOptions struct {
+	MaxTokens    int     `json:"max_tokens"`
+	Temperature  float64 `json:"temperature"`
+	SystemPrompt string  `json:"system_prompt,omitempty"`
+}
+
+// dumpEntry is the on-disk form of one chat completion call
+type dumpEntry struct {
+	Call     int                     `json:"call"`
+	Time     time.Time               `json:"time"`
+	Duration string                  `json:"duration"`
+	Provider ProviderType            `json:"provider"`
+	Model    string                  `json:"model"`
+	Messages []Message               `json:"messages"`
+	Options  *dumpOptions            `json:"options,omitempty"`
+	Response *ChatCompletionResponse `json:"response,omitempty"`
+	Error    string                  `json:"error,omitempty"`
+}
+
+// DumpingProvider wraps a provider and writes each chat completion request and its response
+// to a numbered JSON file, for debugging prompts. Streaming requests are passed through.
+type DumpingProvider struct {
+	Provider
+	directory string
+	secrets   []string
+	calls     atomic.Int64
+	logger    *logging.Logger
+}
+
+// NewDumpingProvider creates a provider that dumps the calls of provider into directory.
+// Occurrences of secrets, such as the configured API keys, are redacted from the dumps.
+func NewDumpingProvider(provider Provider, directory string, secrets ...string) (*DumpingProvider, error) {
+	if provider == nil {
+		return nil, fmt.Errorf("provider cannot be nil")
+	}
+	if directory == "" {
+		return nil, fmt.Errorf("dump directory is required")
+	}
+	if err := os.MkdirAll(directory, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create dump directory: %w", err)
+	}
+
+	var nonEmpty []string
+	for _, secret := range secrets {
+		if secret != "" {
+			nonEmpty = append(nonEmpty, secret)
+		}
+	}
+
+	return &DumpingProvider{
+		Provider:  provider,
+		directory: directory,
+		secrets:   nonEmpty,
+		logger:    logging.GetGlobalLogger().WithComponent("llm-dump"),
+	}, nil
+}
+
+// ChatCompletion calls the wrapped provider and dumps the request with its response or error
+func (p *DumpingProvider) ChatCompletion(
+	ctx context.Context,
+	messages []Message,
+	opts ...ChatCompletionOptions,
+) (*ChatCompletionResponse, error) {
+	entry := dumpEntry{
+		Call:     int(p.calls.Add(1)),
+		Time:     time.Now(),
+		Provider: p.GetProviderType(),
+		Model:    p.GetModel(),
+		Messages: messages,
+	}
+	if len(opts) > 0 {
+		entry.Options = &dumpOptions{
+			MaxTokens:    opts[0].MaxTokens,
+			Temperature:  opts[0].Temperature,
+			SystemPrompt: opts[0].SystemPrompt,
+		}
+	}
+
+	response, err := p.Provider.ChatCompletion(ctx, messages, opts...)
+	entry.Duration = time.Since(entry.Time).String()
+	entry.Response = response
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	// A failed dump must not fail the request
+	if dumpErr := p.write(entry); dumpErr != nil {
+		p.logger.Warn("failed to dump chat completion", slog.String("error", dumpErr.Error()))
+	}
+
+	return response, err
+}
+
+// write stores entry as call-NNNN.json with secrets redacted
+func (p *DumpingProvider) write(entry dumpEntry) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal dump: %w", err)
+	}
+
+	path := filepath.Join(p.directory, fmt.Sprintf("call-%04d.json", entry.Call))
+	if err := os.WriteFile(path, []byte(p.redact(string(data))), 0o600); err != nil {
+		return fmt.Errorf("failed to write dump: %w", err)
+	}
+	return nil
+}
+
+// redact replaces the configured secrets and well-known key formats in text
+func (p *DumpingProvider) redact(text string) string {
+	for _, secret := range p.secrets {
+		text = strings.ReplaceAll(text, secret, redactedSecret)
+	}
+	for _, pattern := range dumpSecretPatterns {
+		text = pattern.ReplaceAllString(text, redactedSecret)
+	}
+	return text
+}
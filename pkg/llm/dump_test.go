@@ -0,0 +1,72 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDumpingProvider_DumpsEachCall(t *testing.T) {
+	const apiKey = "test-api-key-0123456789"
+	openAIKey := "sk-proj-" + strings.Repeat("a1B2", 10)
+
+	dir := filepath.Join(t.TempDir(), "dump")
+	inner := &countingProvider{}
+	provider, err := NewDumpingProvider(inner, dir, apiKey, "")
+	if err != nil {
+		t.Fatalf("NewDumpingProvider failed: %v", err)
+	}
+
+	prompts := []string{
+		"Plan the wiki structure",
+		"Write the overview page. Config: api_key=" + apiKey,
+		"Write the setup page. export OPENAI_API_KEY=" + openAIKey,
+	}
+	for _, prompt := range prompts {
+		messages := []Message{{Role: "user", Content: prompt}}
+		options := ChatCompletionOptions{MaxTokens: 100}
+		if _, err := provider.ChatCompletion(context.Background(), messages, options); err != nil {
+			t.Fatalf("ChatCompletion failed: %v", err)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read dump directory: %v", err)
+	}
+	if len(entries) != inner.calls || len(entries) != len(prompts) {
+		t.Fatalf("Expected %d dumps for %d calls, got %d", len(prompts), inner.calls, len(entries))
+	}
+
+	for i, prompt := range prompts {
+		data, err := os.ReadFile(filepath.Join(dir, entries[i].Name()))
+		if err != nil {
+			t.Fatalf("Failed to read dump: %v", err)
+		}
+		if strings.Contains(string(data), apiKey) || strings.Contains(string(data), openAIKey) {
+			t.Errorf("Dump %s contains an API key:\n%s", entries[i].Name(), data)
+		}
+
+		var entry dumpEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			t.Fatalf("Failed to parse dump %s: %v", entries[i].Name(), err)
+		}
+		if entry.Call != i+1 || entry.Options == nil || entry.Options.MaxTokens != 100 {
+			t.Errorf("Expected call %d with its options, got %+v", i+1, entry)
+		}
+		if entry.Response == nil || len(entry.Messages) != 1 {
+			t.Fatalf("Expected the request and response in dump %d, got %+v", i+1, entry)
+		}
+
+		// Prompts without secrets are dumped as sent
+		if i == 0 && entry.Messages[0].Content != prompt {
+			t.Errorf("Expected the prompt %q, got %q", prompt, entry.Messages[0].Content)
+		}
+		if i > 0 && !strings.Contains(entry.Response.Choices[0].Message.Content, redactedSecret) {
+			t.Errorf("Expected the echoed secret to be redacted, got %q", entry.Response.Choices[0].Message.Content)
+		}
+	}
+}
@@ -2,7 +2,9 @@ package factory
 
 import (
 	"fmt"
+	"log/slog"
 
+	"github.com/kuderr/deepwiki/internal/logging"
 	"github.com/kuderr/deepwiki/pkg/llm"
 	llmanthropic "github.com/kuderr/deepwiki/pkg/llm/anthropic"
 	llmollama "github.com/kuderr/deepwiki/pkg/llm/ollama"
@@ -22,15 +24,56 @@ func NewLLMProvider(config *llm.Config) (llm.Provider, error) {
 	if config.RateLimitRPS < 0 {
 		return nil, fmt.Errorf("rate limit RPS cannot be negative")
 	}
+	if config.MaxConcurrentRequests < 0 {
+		return nil, fmt.Errorf("max concurrent requests cannot be negative")
+	}
+	if config.CircuitBreakerThreshold < 0 {
+		return nil, fmt.Errorf("circuit breaker threshold cannot be negative")
+	}
 
+	var provider llm.Provider
+	var err error
 	switch config.Provider {
 	case llm.ProviderOpenAI:
-		return llmopenai.NewProvider(config)
+		provider, err = llmopenai.NewProvider(config)
 	case llm.ProviderAnthropic:
-		return llmanthropic.NewProvider(config)
+		provider, err = llmanthropic.NewProvider(config)
 	case llm.ProviderOllama:
-		return llmollama.NewProvider(config)
+		provider, err = llmollama.NewProvider(config)
 	default:
 		return nil, fmt.Errorf("unsupported LLM provider: %s", config.Provider)
 	}
+	if err != nil {
+		return nil, err
+	}
+	warnDeprecatedModel(config)
+
+	if config.CircuitBreakerThreshold > 0 {
+		provider, err = llm.NewCircuitBreakerProvider(
+			provider, config.CircuitBreakerThreshold, config.CircuitBreakerCooldown)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if config.MaxConcurrentRequests == 0 {
+		return provider, nil
+	}
+
+	return llm.NewConcurrencyLimitedProvider(provider, config.MaxConcurrentRequests)
+}
+
+// warnDeprecatedModel logs a warning when the configured model has been deprecated by its
+// provider. The model is still used, so that it can be overridden deliberately.
+func warnDeprecatedModel(config *llm.Config) {
+	replacement, deprecated := llm.DeprecatedModel(config.Model)
+	if !deprecated {
+		return
+	}
+	logging.GetGlobalLogger().WithComponent("llm").Warn(
+		"LLM model is deprecated by its provider; consider switching to the replacement",
+		slog.String("provider", string(config.Provider)),
+		slog.String("model", config.Model),
+		slog.String("replacement", replacement),
+	)
 }
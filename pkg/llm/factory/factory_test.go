@@ -1,10 +1,18 @@
 package factory
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/kuderr/deepwiki/internal/logging"
 	"github.com/kuderr/deepwiki/pkg/llm"
 )
 
@@ -384,3 +392,126 @@ func TestLLMProviderConfigValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestNewLLMProvider_MaxConcurrentRequests(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		mu.Unlock()
+
+		time.Sleep(30 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"1","model":"gpt-4o","choices":[{"message":{"role":"assistant","content":"ok"}}]}`)
+	}))
+	defer server.Close()
+
+	config := &llm.Config{
+		Provider:              llm.ProviderOpenAI,
+		APIKey:                "test-key",
+		Model:                 "gpt-4o",
+		BaseURL:               server.URL,
+		RequestTimeout:        30 * time.Second,
+		RateLimitRPS:          1000,
+		MaxConcurrentRequests: 2,
+	}
+
+	provider, err := NewLLMProvider(config)
+	if err != nil {
+		t.Fatalf("NewLLMProvider() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			messages := []llm.Message{{Role: "user", Content: "Test"}}
+			if _, err := provider.ChatCompletion(context.Background(), messages); err != nil {
+				t.Errorf("ChatCompletion() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if maxInFlight > 2 {
+		t.Errorf("Expected at most 2 requests in flight, got %d", maxInFlight)
+	}
+	if maxInFlight < 2 {
+		t.Errorf("Expected requests to run in parallel up to the limit, got %d", maxInFlight)
+	}
+}
+
+// captureLogs sends the global logger to a file for the rest of the test and returns a function
+// reading what was logged
+func captureLogs(t *testing.T) func() string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "deepwiki.log")
+	logger, err := logging.NewLogger(&logging.LogConfig{Level: logging.LevelInfo, Output: path})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	previous := logging.GetGlobalLogger()
+	logging.SetGlobalLogger(logger)
+	t.Cleanup(func() {
+		logging.SetGlobalLogger(previous)
+		logger.Close()
+	})
+
+	return func() string {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read log: %v", err)
+		}
+		return string(data)
+	}
+}
+
+func TestNewLLMProvider_WarnsOnDeprecatedModel(t *testing.T) {
+	tests := []struct {
+		model    string
+		wantWarn bool
+	}{
+		{model: "gpt-4-32k", wantWarn: true},
+		{model: "gpt-4o", wantWarn: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			logs := captureLogs(t)
+			config := &llm.Config{
+				Provider:     llm.ProviderOpenAI,
+				APIKey:       "test-openai-key",
+				Model:        tt.model,
+				MaxTokens:    4000,
+				RateLimitRPS: 1,
+			}
+
+			// A deprecated model is still used
+			provider, err := NewLLMProvider(config)
+			if err != nil {
+				t.Fatalf("NewLLMProvider failed: %v", err)
+			}
+			if provider.GetModel() != tt.model {
+				t.Errorf("Expected model %s, got %s", tt.model, provider.GetModel())
+			}
+
+			logged := logs()
+			warned := strings.Contains(logged, "LLM model is deprecated")
+			if warned != tt.wantWarn {
+				t.Errorf("Expected warning %v, got log:\n%s", tt.wantWarn, logged)
+			}
+			if tt.wantWarn && !strings.Contains(logged, "replacement=gpt-4o") {
+				t.Errorf("Expected the replacement to be suggested, got log:\n%s", logged)
+			}
+		})
+	}
+}
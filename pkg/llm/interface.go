@@ -3,6 +3,8 @@ package llm
 import (
 	"context"
 	"time"
+
+	"github.com/kuderr/deepwiki/pkg/ratelimit"
 )
 
 // ProviderType represents the type of LLM provider
@@ -37,11 +39,26 @@ type Choice struct {
 	FinishReason string  `json:"finish_reason"`
 }
 
+// truncatedFinishReasons are finish reasons reporting that the token limit cut the response off
+var truncatedFinishReasons = map[string]bool{
+	"length":     true, // OpenAI
+	"max_tokens": true, // Anthropic
+}
+
+// Truncated reports whether the token limit cut the choice off
+func (c Choice) Truncated() bool {
+	return truncatedFinishReasons[c.FinishReason]
+}
+
 // Usage represents token usage information
 type Usage struct {
-	PromptTokens     int `json:"prompt_tokens"`
+	PromptTokens     int `json:"prompt_tokens"` // Including cached prompt tokens
 	CompletionTokens int `json:"completion_tokens"`
 	TotalTokens      int `json:"total_tokens"`
+
+	// Prompt tokens written to and read from the prompt cache
+	CacheWriteTokens int `json:"cache_write_tokens,omitempty"`
+	CacheReadTokens  int `json:"cache_read_tokens,omitempty"`
 }
 
 // StreamResponse represents a streaming response chunk
@@ -61,10 +78,13 @@ type StreamChoice struct {
 
 // TokenCount represents token usage statistics
 type TokenCount struct {
-	PromptTokens     int
+	PromptTokens     int // Including cached prompt tokens
 	CompletionTokens int
 	TotalTokens      int
+	CacheWriteTokens int
+	CacheReadTokens  int
 	EstimatedCost    float64
+	RateLimits       RateLimits // Reported with the latest response
 }
 
 // StreamHandler is a function type for handling streaming responses
@@ -76,6 +96,31 @@ type ChatCompletionOptions struct {
 	Temperature float64
 	Stream      bool
 	OnStream    StreamHandler
+
+	// SystemPrompt holds instructions for the model, which each provider sends the way its API
+	// expects: a top-level system prompt for Anthropic, a leading system message for others
+	SystemPrompt string
+
+	// CacheMessages is the number of leading messages that repeat across requests. Providers
+	// with prompt caching (Anthropic) cache them as a prefix; others ignore it.
+	CacheMessages int
+
+	// CacheSystemPrompt caches the system prompt as a prefix of its own, for requests whose
+	// messages differ but whose system prompt repeats
+	CacheSystemPrompt bool
+
+	// BypassCache asks a CachingProvider for a fresh response, which replaces the cached one.
+	// Retries set it so that they do not get the response they are retrying back.
+	BypassCache bool
+}
+
+// WithSystemPrompt returns messages preceded by a system message holding systemPrompt, or
+// messages unchanged when it is empty
+func WithSystemPrompt(messages []Message, systemPrompt string) []Message {
+	if systemPrompt == "" {
+		return messages
+	}
+	return append([]Message{{Role: "system", Content: systemPrompt}}, messages...)
 }
 
 // Provider interface defines the LLM provider methods
@@ -119,10 +164,30 @@ type Config struct {
 	RetryDelay     time.Duration `yaml:"retry_delay"`
 	RateLimitRPS   float64       `yaml:"rate_limit_rps"`
 
+	// Maximum chat completion requests in flight at once (0 = unlimited)
+	MaxConcurrentRequests int `yaml:"max_concurrent_requests,omitempty"`
+
+	// Consecutive failed requests that open the circuit breaker (0 = disabled),
+	// and how long requests fail fast before the provider is probed again
+	CircuitBreakerThreshold int           `yaml:"circuit_breaker_threshold,omitempty"`
+	CircuitBreakerCooldown  time.Duration `yaml:"circuit_breaker_cooldown,omitempty"`
+
 	// Provider-specific configurations
 	BaseURL string `yaml:"base_url,omitempty"` // For custom endpoints
+
+	// Extra headers sent with every request, e.g. for API gateways.
+	// OverrideHeaders lets them replace the provider's auth and content type headers.
+	Headers         map[string]string `yaml:"headers,omitempty"`
+	OverrideHeaders bool              `yaml:"override_headers,omitempty"`
+
+	// Scheduler shares a request budget with the other providers of the same account, such as
+	// the embedding provider. Every HTTP request waits for its turn as a SchedulerClass request.
+	Scheduler *ratelimit.Scheduler `yaml:"-"`
 }
 
+// SchedulerClass is the class of LLM requests in a ratelimit.Scheduler
+const SchedulerClass = "llm"
+
 // DefaultConfig returns default configuration for the specified provider
 func DefaultConfig(provider ProviderType, apiKey string) *Config {
 	base := &Config{
@@ -141,7 +206,7 @@ func DefaultConfig(provider ProviderType, apiKey string) *Config {
 		base.Model = "gpt-4o"
 		base.BaseURL = "https://api.openai.com/v1"
 	case ProviderAnthropic:
-		base.Model = "claude-3-5-sonnet-20241022"
+		base.Model = "claude-sonnet-4-0"
 		base.BaseURL = "https://api.anthropic.com/v1"
 	case ProviderOllama:
 		base.Model = "llama3.1"
@@ -44,7 +44,7 @@ func TestDefaultConfig(t *testing.T) {
 			name:        "Anthropic default config",
 			provider:    ProviderAnthropic,
 			apiKey:      "test-anthropic-key",
-			wantModel:   "claude-3-5-sonnet-20241022",
+			wantModel:   "claude-sonnet-4-0",
 			wantBaseURL: "https://api.anthropic.com/v1",
 		},
 		{
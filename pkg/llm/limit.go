@@ -0,0 +1,72 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+)
+
+// ConcurrencyLimitedProvider wraps a provider so that at most a fixed number of
+// chat completion requests are in flight at once
+type ConcurrencyLimitedProvider struct {
+	Provider
+	slots chan struct{}
+}
+
+// NewConcurrencyLimitedProvider creates a provider allowing maxConcurrent simultaneous requests
+func NewConcurrencyLimitedProvider(provider Provider, maxConcurrent int) (*ConcurrencyLimitedProvider, error) {
+	if provider == nil {
+		return nil, fmt.Errorf("provider cannot be nil")
+	}
+	if maxConcurrent <= 0 {
+		return nil, fmt.Errorf("max concurrent requests must be positive")
+	}
+
+	return &ConcurrencyLimitedProvider{
+		Provider: provider,
+		slots:    make(chan struct{}, maxConcurrent),
+	}, nil
+}
+
+// ChatCompletion waits for a free slot and then calls the wrapped provider
+func (p *ConcurrencyLimitedProvider) ChatCompletion(
+	ctx context.Context,
+	messages []Message,
+	opts ...ChatCompletionOptions,
+) (*ChatCompletionResponse, error) {
+	if err := p.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer p.release()
+
+	return p.Provider.ChatCompletion(ctx, messages, opts...)
+}
+
+// ChatCompletionStream waits for a free slot and holds it until the stream ends
+func (p *ConcurrencyLimitedProvider) ChatCompletionStream(
+	ctx context.Context,
+	messages []Message,
+	handler StreamHandler,
+	opts ...ChatCompletionOptions,
+) error {
+	if err := p.acquire(ctx); err != nil {
+		return err
+	}
+	defer p.release()
+
+	return p.Provider.ChatCompletionStream(ctx, messages, handler, opts...)
+}
+
+// acquire blocks until a slot is free or ctx is done
+func (p *ConcurrencyLimitedProvider) acquire(ctx context.Context) error {
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for a request slot: %w", ctx.Err())
+	}
+}
+
+// release frees a slot taken by acquire
+func (p *ConcurrencyLimitedProvider) release() {
+	<-p.slots
+}
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/kuderr/deepwiki/internal/logging"
+	"github.com/kuderr/deepwiki/pkg/apierror"
 	"github.com/kuderr/deepwiki/pkg/llm"
 	"golang.org/x/time/rate"
 )
@@ -96,7 +97,8 @@ func NewProvider(config *llm.Config) (llm.Provider, error) {
 
 	// Set up HTTP client with timeout
 	httpClient := &http.Client{
-		Timeout: config.RequestTimeout,
+		Timeout:   config.RequestTimeout,
+		Transport: config.Scheduler.Transport(nil, llm.SchedulerClass),
 	}
 
 	provider := &OllamaProvider{
@@ -130,6 +132,7 @@ func (p *OllamaProvider) ChatCompletion(
 			options.Temperature = opts[0].Temperature
 		}
 		options.Stream = opts[0].Stream
+		options.SystemPrompt = opts[0].SystemPrompt
 	}
 
 	// Wait for rate limiting
@@ -137,7 +140,8 @@ func (p *OllamaProvider) ChatCompletion(
 		return nil, fmt.Errorf("rate limiting failed: %w", err)
 	}
 
-	// Convert messages
+	// Convert messages, the system prompt first
+	messages = llm.WithSystemPrompt(messages, options.SystemPrompt)
 	ollamaMessages := make([]Message, len(messages))
 	for i, msg := range messages {
 		ollamaMessages[i] = Message{
@@ -206,6 +210,7 @@ func (p *OllamaProvider) ChatCompletionStream(
 		if opts[0].Temperature >= 0 {
 			options.Temperature = opts[0].Temperature
 		}
+		options.SystemPrompt = opts[0].SystemPrompt
 	}
 
 	// Wait for rate limiting
@@ -213,7 +218,8 @@ func (p *OllamaProvider) ChatCompletionStream(
 		return fmt.Errorf("rate limiting failed: %w", err)
 	}
 
-	// Convert messages
+	// Convert messages, the system prompt first
+	messages = llm.WithSystemPrompt(messages, options.SystemPrompt)
 	ollamaMessages := make([]Message, len(messages))
 	for i, msg := range messages {
 		ollamaMessages[i] = Message{
@@ -295,7 +301,10 @@ func (p *OllamaProvider) sendRequest(
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
+	p.logger.Trace("chat completion request", slog.String("url", url), slog.String("body", string(jsonData)))
+
 	req.Header.Set("Content-Type", "application/json")
+	p.config.ApplyHeaders(req)
 
 	// Perform request with retries
 	var response *http.Response
@@ -315,7 +324,8 @@ func (p *OllamaProvider) sendRequest(
 			break // Success or client error (don't retry client errors)
 		}
 
-		if response != nil {
+		// The last response is read below
+		if response != nil && attempt < p.config.MaxRetries {
 			response.Body.Close()
 		}
 	}
@@ -331,12 +341,17 @@ func (p *OllamaProvider) sendRequest(
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
+	p.logger.Trace("chat completion response",
+		slog.Int("status", response.StatusCode),
+		slog.String("body", string(body)))
+
 	if response.StatusCode != http.StatusOK {
 		var errorResp ErrorResponse
 		if err := json.Unmarshal(body, &errorResp); err != nil {
-			return nil, fmt.Errorf("API request failed with status %d: %s", response.StatusCode, string(body))
+			return nil, apierror.New(response.StatusCode,
+				fmt.Errorf("API request failed with status %d: %s", response.StatusCode, string(body)))
 		}
-		return nil, fmt.Errorf("API error: %s", errorResp.Error)
+		return nil, apierror.New(response.StatusCode, fmt.Errorf("API error: %s", errorResp.Error))
 	}
 
 	var chatResponse ChatCompletionResponse
@@ -364,6 +379,7 @@ func (p *OllamaProvider) sendStreamingRequest(
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	p.config.ApplyHeaders(req)
 
 	response, err := p.httpClient.Do(req)
 	if err != nil {
@@ -373,7 +389,8 @@ func (p *OllamaProvider) sendStreamingRequest(
 
 	if response.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(response.Body)
-		return fmt.Errorf("streaming request failed with status %d: %s", response.StatusCode, string(body))
+		return apierror.New(response.StatusCode,
+			fmt.Errorf("streaming request failed with status %d: %s", response.StatusCode, string(body)))
 	}
 
 	scanner := bufio.NewScanner(response.Body)
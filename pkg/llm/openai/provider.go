@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/kuderr/deepwiki/internal/logging"
+	"github.com/kuderr/deepwiki/pkg/apierror"
 	"github.com/kuderr/deepwiki/pkg/llm"
 	"golang.org/x/time/rate"
 )
@@ -23,6 +24,7 @@ type OpenAIProvider struct {
 	config      *llm.Config
 	httpClient  *http.Client
 	rateLimiter *rate.Limiter
+	rateLimits  *llm.RateLimitTracker // Rate limits reported by the API
 	logger      *logging.Logger
 
 	// Usage tracking
@@ -119,13 +121,15 @@ func NewProvider(config *llm.Config) (llm.Provider, error) {
 
 	// Set up HTTP client with timeout
 	httpClient := &http.Client{
-		Timeout: config.RequestTimeout,
+		Timeout:   config.RequestTimeout,
+		Transport: config.Scheduler.Transport(nil, llm.SchedulerClass),
 	}
 
 	provider := &OpenAIProvider{
 		config:      config,
 		httpClient:  httpClient,
 		rateLimiter: rateLimiter,
+		rateLimits:  llm.NewRateLimitTracker(),
 		logger:      logging.GetGlobalLogger().WithComponent("openai-llm"),
 		totalUsage:  llm.TokenCount{},
 	}
@@ -153,6 +157,7 @@ func (p *OpenAIProvider) ChatCompletion(
 			options.Temperature = opts[0].Temperature
 		}
 		options.Stream = opts[0].Stream
+		options.SystemPrompt = opts[0].SystemPrompt
 	}
 
 	// Wait for rate limiting
@@ -160,7 +165,8 @@ func (p *OpenAIProvider) ChatCompletion(
 		return nil, fmt.Errorf("rate limiting failed: %w", err)
 	}
 
-	// Convert messages
+	// Convert messages, the system prompt first
+	messages = llm.WithSystemPrompt(messages, options.SystemPrompt)
 	openaiMessages := make([]Message, len(messages))
 	for i, msg := range messages {
 		openaiMessages[i] = Message{
@@ -221,6 +227,7 @@ func (p *OpenAIProvider) ChatCompletionStream(
 		if opts[0].Temperature >= 0 {
 			options.Temperature = opts[0].Temperature
 		}
+		options.SystemPrompt = opts[0].SystemPrompt
 	}
 
 	// Wait for rate limiting
@@ -228,7 +235,8 @@ func (p *OpenAIProvider) ChatCompletionStream(
 		return fmt.Errorf("rate limiting failed: %w", err)
 	}
 
-	// Convert messages
+	// Convert messages, the system prompt first
+	messages = llm.WithSystemPrompt(messages, options.SystemPrompt)
 	openaiMessages := make([]Message, len(messages))
 	for i, msg := range messages {
 		openaiMessages[i] = Message{
@@ -285,7 +293,9 @@ func (p *OpenAIProvider) EstimateCost(promptTokens, completionTokens int) float6
 func (p *OpenAIProvider) GetUsageStats() llm.TokenCount {
 	p.usageMutex.RLock()
 	defer p.usageMutex.RUnlock()
-	return p.totalUsage
+	stats := p.totalUsage
+	stats.RateLimits = p.rateLimits.Limits()
+	return stats
 }
 
 // ResetUsageStats resets usage statistics
@@ -322,12 +332,16 @@ func (p *OpenAIProvider) sendRequest(
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
+	p.logger.Trace("chat completion request", slog.String("url", url), slog.String("body", string(jsonData)))
+
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+	p.config.ApplyHeaders(req)
 
-	// Perform request with retries
+	// Perform request with retries, waiting first when the reported rate limits are exhausted
 	var response *http.Response
 	var lastErr error
+	tokens := len(jsonData)/4 + request.MaxTokens
 
 	for attempt := 0; attempt <= p.config.MaxRetries; attempt++ {
 		if attempt > 0 {
@@ -336,16 +350,26 @@ func (p *OpenAIProvider) sendRequest(
 				return nil, ctx.Err()
 			case <-time.After(p.config.RetryDelay * time.Duration(attempt)):
 			}
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, fmt.Errorf("failed to create request: %w", err)
+			}
+		}
+		if err := p.rateLimits.Wait(ctx, tokens); err != nil {
+			return nil, err
 		}
 
 		response, lastErr = p.httpClient.Do(req)
-		if lastErr == nil && response.StatusCode < 500 {
-			break // Success or client error (don't retry client errors)
+		if lastErr != nil {
+			continue
 		}
+		p.rateLimits.Record(p.logger.Logger, response)
 
-		if response != nil {
-			response.Body.Close()
+		// Server errors and rate limiting are retried; other client errors are not
+		retryable := response.StatusCode >= 500 || response.StatusCode == http.StatusTooManyRequests
+		if !retryable || attempt == p.config.MaxRetries {
+			break
 		}
+		response.Body.Close()
 	}
 
 	if lastErr != nil {
@@ -359,12 +383,17 @@ func (p *OpenAIProvider) sendRequest(
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
+	p.logger.Trace("chat completion response",
+		slog.Int("status", response.StatusCode),
+		slog.String("body", string(body)))
+
 	if response.StatusCode != http.StatusOK {
 		var apiError APIError
 		if err := json.Unmarshal(body, &apiError); err != nil {
-			return nil, fmt.Errorf("API request failed with status %d: %s", response.StatusCode, string(body))
+			return nil, apierror.New(response.StatusCode,
+				fmt.Errorf("API request failed with status %d: %s", response.StatusCode, string(body)))
 		}
-		return nil, fmt.Errorf("API error: %w", apiError)
+		return nil, apierror.New(response.StatusCode, fmt.Errorf("API error: %w", apiError))
 	}
 
 	var chatResponse ChatCompletionResponse
@@ -394,16 +423,22 @@ func (p *OpenAIProvider) sendStreamingRequest(
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+p.config.APIKey)
 	req.Header.Set("Accept", "text/event-stream")
+	p.config.ApplyHeaders(req)
 
+	if err := p.rateLimits.Wait(ctx, len(jsonData)/4+request.MaxTokens); err != nil {
+		return err
+	}
 	response, err := p.httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
 	}
 	defer response.Body.Close()
+	p.rateLimits.Record(p.logger.Logger, response)
 
 	if response.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(response.Body)
-		return fmt.Errorf("streaming request failed with status %d: %s", response.StatusCode, string(body))
+		return apierror.New(response.StatusCode,
+			fmt.Errorf("streaming request failed with status %d: %s", response.StatusCode, string(body)))
 	}
 
 	scanner := bufio.NewScanner(response.Body)
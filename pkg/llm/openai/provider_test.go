@@ -5,10 +5,13 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/kuderr/deepwiki/internal/logging"
 	"github.com/kuderr/deepwiki/pkg/llm"
 )
 
@@ -304,6 +307,51 @@ func TestOpenAIProvider_ChatCompletionWithOptions(t *testing.T) {
 	}
 }
 
+func TestOpenAIProvider_SystemPrompt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request ChatCompletionRequest
+		json.NewDecoder(r.Body).Decode(&request)
+
+		want := []Message{
+			{Role: "system", Content: "You are a technical writer."},
+			{Role: "user", Content: "Test"},
+		}
+		if len(request.Messages) != len(want) {
+			t.Fatalf("Expected messages %+v, got %+v", want, request.Messages)
+		}
+		for i := range want {
+			if request.Messages[i] != want[i] {
+				t.Errorf("Expected message %d to be %+v, got %+v", i, want[i], request.Messages[i])
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ChatCompletionResponse{
+			Choices: []Choice{{Message: Message{Role: "assistant", Content: "Test response"}, FinishReason: "stop"}},
+		})
+	}))
+	defer server.Close()
+
+	provider, err := NewProvider(&llm.Config{
+		Provider:       llm.ProviderOpenAI,
+		APIKey:         "test-key",
+		Model:          "gpt-4o",
+		BaseURL:        server.URL,
+		MaxTokens:      4000,
+		RequestTimeout: 30 * time.Second,
+		RateLimitRPS:   10.0,
+	})
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+
+	messages := []llm.Message{{Role: "user", Content: "Test"}}
+	options := llm.ChatCompletionOptions{SystemPrompt: "You are a technical writer."}
+	if _, err := provider.ChatCompletion(context.Background(), messages, options); err != nil {
+		t.Fatalf("ChatCompletion() error = %v", err)
+	}
+}
+
 func TestOpenAIProvider_ChatCompletionError(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
@@ -587,3 +635,187 @@ func TestOpenAIProvider_ProviderInfo(t *testing.T) {
 		t.Errorf("GetModel() = %v, want %v", provider.GetModel(), "gpt-4o")
 	}
 }
+
+func TestOpenAIProvider_CustomHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Helicone-Auth"); got != "Bearer gateway-key" {
+			t.Errorf("Expected Helicone-Auth header, got %q", got)
+		}
+		if got := r.Header.Get("X-Route"); got != "docs" {
+			t.Errorf("Expected X-Route header, got %q", got)
+		}
+		// Protected headers keep the provider's values
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("Expected provider Authorization header, got %q", got)
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("Expected provider Content-Type header, got %q", got)
+		}
+
+		response := ChatCompletionResponse{
+			ID:      "chatcmpl-123",
+			Object:  "chat.completion",
+			Model:   "gpt-4o",
+			Choices: []Choice{{Message: Message{Role: "assistant", Content: "ok"}, FinishReason: "stop"}},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	config := &llm.Config{
+		Provider:       llm.ProviderOpenAI,
+		APIKey:         "test-key",
+		Model:          "gpt-4o",
+		BaseURL:        server.URL,
+		RequestTimeout: 30 * time.Second,
+		RateLimitRPS:   10.0,
+		Headers: map[string]string{
+			"Helicone-Auth": "Bearer gateway-key",
+			"X-Route":       "docs",
+			"authorization": "Bearer overridden",
+			"Content-Type":  "text/plain",
+		},
+	}
+
+	provider, err := NewProvider(config)
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+
+	messages := []llm.Message{{Role: "user", Content: "Test"}}
+	if _, err := provider.ChatCompletion(context.Background(), messages); err != nil {
+		t.Fatalf("ChatCompletion() error = %v", err)
+	}
+}
+
+func TestOpenAIProvider_CachedResponses(t *testing.T) {
+	serverCalls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serverCalls++
+		response := ChatCompletionResponse{
+			ID:      "chatcmpl-123",
+			Object:  "chat.completion",
+			Model:   "gpt-4o",
+			Choices: []Choice{{Message: Message{Role: "assistant", Content: "cached"}, FinishReason: "stop"}},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	config := &llm.Config{
+		Provider:       llm.ProviderOpenAI,
+		APIKey:         "test-key",
+		Model:          "gpt-4o",
+		BaseURL:        server.URL,
+		RequestTimeout: 30 * time.Second,
+		RateLimitRPS:   10.0,
+	}
+
+	provider, err := NewProvider(config)
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+
+	cacheDir := t.TempDir()
+	messages := []llm.Message{{Role: "user", Content: "Test"}}
+
+	// A fresh wrapper over the same directory behaves like a new run
+	for range 2 {
+		cached, err := llm.NewCachingProvider(provider, llm.CacheOptions{Directory: cacheDir})
+		if err != nil {
+			t.Fatalf("NewCachingProvider() error = %v", err)
+		}
+
+		response, err := cached.ChatCompletion(context.Background(), messages)
+		if err != nil {
+			t.Fatalf("ChatCompletion() error = %v", err)
+		}
+		if response.Choices[0].Message.Content != "cached" {
+			t.Errorf("Expected content 'cached', got %q", response.Choices[0].Message.Content)
+		}
+	}
+
+	if serverCalls != 1 {
+		t.Errorf("Expected 1 server call, got %d", serverCalls)
+	}
+}
+
+func TestOpenAIProvider_RateLimitHeaders(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "deepwiki.log")
+	logger, err := logging.NewLogger(&logging.LogConfig{Level: logging.LevelDebug, Output: logPath})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	previous := logging.GetGlobalLogger()
+	logging.SetGlobalLogger(logger)
+	t.Cleanup(func() {
+		logging.SetGlobalLogger(previous)
+		logger.Close()
+	})
+
+	serverCalls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serverCalls++
+		var request ChatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || len(request.Messages) != 1 {
+			t.Errorf("Expected the request body on every attempt, got %+v (%v)", request, err)
+		}
+
+		if serverCalls == 1 {
+			w.Header().Set("retry-after-ms", "10")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error": {"message": "Rate limit reached", "type": "requests"}}`))
+			return
+		}
+
+		w.Header().Set("x-ratelimit-remaining-requests", "59")
+		w.Header().Set("x-ratelimit-remaining-tokens", "149000")
+		w.Header().Set("x-ratelimit-reset-requests", "1s")
+		w.Header().Set("x-ratelimit-reset-tokens", "6m0s")
+		json.NewEncoder(w).Encode(ChatCompletionResponse{
+			Choices: []Choice{{Message: Message{Role: "assistant", Content: "Test response"}, FinishReason: "stop"}},
+		})
+	}))
+	defer server.Close()
+
+	provider, err := NewProvider(&llm.Config{
+		Provider:       llm.ProviderOpenAI,
+		APIKey:         "test-key",
+		Model:          "gpt-4o",
+		BaseURL:        server.URL,
+		RequestTimeout: 30 * time.Second,
+		MaxRetries:     1,
+		RetryDelay:     time.Millisecond,
+		RateLimitRPS:   10.0,
+	})
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+
+	messages := []llm.Message{{Role: "user", Content: "Test"}}
+	if _, err := provider.ChatCompletion(context.Background(), messages); err != nil {
+		t.Fatalf("Expected the rate limited request to be retried, got %v", err)
+	}
+
+	limits := provider.GetUsageStats().RateLimits
+	if limits.RemainingRequests != 59 || limits.RemainingTokens != 149000 || limits.Throttled != 1 {
+		t.Errorf("Expected the reported rate limits in usage stats, got %+v", limits)
+	}
+	if reset := time.Until(limits.TokensReset); reset < 5*time.Minute || reset > 6*time.Minute {
+		t.Errorf("Expected the token budget to reset in 6 minutes, got %v", reset)
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read log: %v", err)
+	}
+	logs := string(data)
+	if !strings.Contains(logs, "rate limited by the API") {
+		t.Errorf("Expected the 429 response to be logged, got:\n%s", logs)
+	}
+	if !strings.Contains(logs, "rate_limits.remaining_requests=59") {
+		t.Errorf("Expected the remaining requests to be logged, got:\n%s", logs)
+	}
+}
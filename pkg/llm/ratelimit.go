@@ -0,0 +1,219 @@
+package llm
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxRateLimitWait caps how long a request waits for a reported rate limit to reset
+const maxRateLimitWait = time.Minute
+
+// Rate limit headers of OpenAI compatible APIs and of Anthropic, in lookup order
+var (
+	remainingRequestsHeaders = []string{"x-ratelimit-remaining-requests", "anthropic-ratelimit-requests-remaining"}
+	remainingTokensHeaders   = []string{"x-ratelimit-remaining-tokens", "anthropic-ratelimit-tokens-remaining"}
+	requestsResetHeaders     = []string{"x-ratelimit-reset-requests", "anthropic-ratelimit-requests-reset"}
+	tokensResetHeaders       = []string{"x-ratelimit-reset-tokens", "anthropic-ratelimit-tokens-reset"}
+)
+
+// RateLimits is the rate limit state a provider reported with its latest response
+type RateLimits struct {
+	RemainingRequests int       // Requests left in the current window; -1 when not reported
+	RemainingTokens   int       // Tokens left in the current window; -1 when not reported
+	RequestsReset     time.Time // When the request budget is restored
+	TokensReset       time.Time // When the token budget is restored
+	RetryAfter        time.Time // Time before which the provider asked not to send requests
+	Throttled         int       // Responses rejected for exceeding the rate limit (status 429)
+}
+
+// LogValue logs the reported limits, leaving out those that were not reported
+func (r RateLimits) LogValue() slog.Value {
+	var attrs []slog.Attr
+	if r.RemainingRequests >= 0 {
+		attrs = append(attrs, slog.Int("remaining_requests", r.RemainingRequests))
+	}
+	if r.RemainingTokens >= 0 {
+		attrs = append(attrs, slog.Int("remaining_tokens", r.RemainingTokens))
+	}
+	if !r.RequestsReset.IsZero() {
+		attrs = append(attrs, slog.Time("requests_reset", r.RequestsReset))
+	}
+	if !r.TokensReset.IsZero() {
+		attrs = append(attrs, slog.Time("tokens_reset", r.TokensReset))
+	}
+	if !r.RetryAfter.IsZero() {
+		attrs = append(attrs, slog.Time("retry_after", r.RetryAfter))
+	}
+	if r.Throttled > 0 {
+		attrs = append(attrs, slog.Int("throttled", r.Throttled))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// ParseRateLimitHeaders reads the rate limit headers of a response received at now: the
+// x-ratelimit-* headers of OpenAI compatible APIs, the anthropic-ratelimit-* headers, and
+// retry-after. It reports whether any of them was present.
+func ParseRateLimitHeaders(header http.Header, now time.Time) (RateLimits, bool) {
+	limits := RateLimits{RemainingRequests: -1, RemainingTokens: -1}
+	found := false
+
+	if value, ok := firstHeader(header, remainingRequestsHeaders); ok {
+		if n, err := strconv.Atoi(value); err == nil {
+			limits.RemainingRequests = n
+			found = true
+		}
+	}
+	if value, ok := firstHeader(header, remainingTokensHeaders); ok {
+		if n, err := strconv.Atoi(value); err == nil {
+			limits.RemainingTokens = n
+			found = true
+		}
+	}
+	if value, ok := firstHeader(header, requestsResetHeaders); ok {
+		if reset, ok := parseResetTime(value, now); ok {
+			limits.RequestsReset = reset
+			found = true
+		}
+	}
+	if value, ok := firstHeader(header, tokensResetHeaders); ok {
+		if reset, ok := parseResetTime(value, now); ok {
+			limits.TokensReset = reset
+			found = true
+		}
+	}
+	if retryAfter, ok := parseRetryAfter(header, now); ok {
+		limits.RetryAfter = retryAfter
+		found = true
+	}
+
+	return limits, found
+}
+
+// firstHeader returns the value of the first of names that is set in header
+func firstHeader(header http.Header, names []string) (string, bool) {
+	for _, name := range names {
+		if value := header.Get(name); value != "" {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// parseResetTime parses a reset header: a duration such as "6m0s" (OpenAI) or an RFC 3339
+// time (Anthropic)
+func parseResetTime(value string, now time.Time) (time.Time, bool) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(d), true
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// parseRetryAfter parses retry-after-ms, or retry-after in seconds or as an HTTP date
+func parseRetryAfter(header http.Header, now time.Time) (time.Time, bool) {
+	if value := header.Get("retry-after-ms"); value != "" {
+		if ms, err := strconv.ParseFloat(value, 64); err == nil {
+			return now.Add(time.Duration(ms * float64(time.Millisecond))), true
+		}
+	}
+
+	value := header.Get("retry-after")
+	if value == "" {
+		return time.Time{}, false
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return now.Add(time.Duration(seconds * float64(time.Second))), true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// RateLimitTracker records the rate limits a provider reports with its responses, and delays
+// requests that would exceed them instead of sending them to be rejected
+type RateLimitTracker struct {
+	mu     sync.Mutex
+	limits RateLimits
+}
+
+// NewRateLimitTracker creates a tracker with no limits reported yet
+func NewRateLimitTracker() *RateLimitTracker {
+	return &RateLimitTracker{limits: RateLimits{RemainingRequests: -1, RemainingTokens: -1}}
+}
+
+// Observe records the rate limit headers of a response with status code. It returns the
+// current limits and whether the response reported any.
+func (t *RateLimitTracker) Observe(status int, header http.Header) (RateLimits, bool) {
+	parsed, found := ParseRateLimitHeaders(header, time.Now())
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if found {
+		parsed.Throttled = t.limits.Throttled
+		t.limits = parsed
+	}
+	if status == http.StatusTooManyRequests {
+		t.limits.Throttled++
+	}
+	return t.limits, found
+}
+
+// Limits returns the latest reported limits
+func (t *RateLimitTracker) Limits() RateLimits {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.limits
+}
+
+// Delay returns how long a request needing about tokens tokens should wait: until the time
+// retry-after asked for, or until the budget resets when no requests or too few tokens are
+// left. It waits at most maxRateLimitWait.
+func (t *RateLimitTracker) Delay(tokens int) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	until := t.limits.RetryAfter
+	if t.limits.RemainingRequests == 0 && t.limits.RequestsReset.After(until) {
+		until = t.limits.RequestsReset
+	}
+	if t.limits.RemainingTokens >= 0 && t.limits.RemainingTokens < tokens && t.limits.TokensReset.After(until) {
+		until = t.limits.TokensReset
+	}
+	return min(max(time.Until(until), 0), maxRateLimitWait)
+}
+
+// Wait blocks for Delay(tokens), or until ctx is done
+func (t *RateLimitTracker) Wait(ctx context.Context, tokens int) error {
+	delay := t.Delay(tokens)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Record observes the rate limits of response and logs them: at debug level when reported, and
+// as a warning when the response was rejected for exceeding the rate limit
+func (t *RateLimitTracker) Record(logger *slog.Logger, response *http.Response) {
+	limits, found := t.Observe(response.StatusCode, response.Header)
+	switch {
+	case response.StatusCode == http.StatusTooManyRequests:
+		logger.Warn("rate limited by the API", slog.Any("rate_limits", limits))
+	case found:
+		logger.Debug("rate limits", slog.Any("rate_limits", limits))
+	}
+}
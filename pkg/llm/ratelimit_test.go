@@ -0,0 +1,109 @@
+package llm
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimitHeaders(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    RateLimits
+		found   bool
+	}{
+		{
+			name: "openai",
+			headers: map[string]string{
+				"x-ratelimit-remaining-requests": "59",
+				"x-ratelimit-remaining-tokens":   "149000",
+				"x-ratelimit-reset-requests":     "1s",
+				"x-ratelimit-reset-tokens":       "6m0s",
+			},
+			want: RateLimits{
+				RemainingRequests: 59,
+				RemainingTokens:   149000,
+				RequestsReset:     now.Add(time.Second),
+				TokensReset:       now.Add(6 * time.Minute),
+			},
+			found: true,
+		},
+		{
+			name: "anthropic",
+			headers: map[string]string{
+				"anthropic-ratelimit-requests-remaining": "0",
+				"anthropic-ratelimit-tokens-remaining":   "8000",
+				"anthropic-ratelimit-requests-reset":     "2024-05-01T12:00:30Z",
+				"retry-after":                            "30",
+			},
+			want: RateLimits{
+				RemainingRequests: 0,
+				RemainingTokens:   8000,
+				RequestsReset:     now.Add(30 * time.Second),
+				RetryAfter:        now.Add(30 * time.Second),
+			},
+			found: true,
+		},
+		{
+			name:    "retry-after date",
+			headers: map[string]string{"Retry-After": "Wed, 01 May 2024 12:01:00 GMT"},
+			want:    RateLimits{RemainingRequests: -1, RemainingTokens: -1, RetryAfter: now.Add(time.Minute)},
+			found:   true,
+		},
+		{
+			name:    "none",
+			headers: map[string]string{"x-ratelimit-remaining-requests": "many"},
+			want:    RateLimits{RemainingRequests: -1, RemainingTokens: -1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for name, value := range tt.headers {
+				header.Set(name, value)
+			}
+
+			got, found := ParseRateLimitHeaders(header, now)
+			if found != tt.found {
+				t.Errorf("Expected found %v, got %v", tt.found, found)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRateLimitTracker_Delay(t *testing.T) {
+	tracker := NewRateLimitTracker()
+	if delay := tracker.Delay(1000); delay != 0 {
+		t.Errorf("Expected no delay before any limits are reported, got %v", delay)
+	}
+
+	header := http.Header{}
+	header.Set("x-ratelimit-remaining-requests", "10")
+	header.Set("x-ratelimit-remaining-tokens", "500")
+	header.Set("x-ratelimit-reset-tokens", "20s")
+	tracker.Observe(http.StatusOK, header)
+
+	if delay := tracker.Delay(100); delay != 0 {
+		t.Errorf("Expected no delay while the budget allows the request, got %v", delay)
+	}
+	if delay := tracker.Delay(1000); delay < 19*time.Second || delay > 20*time.Second {
+		t.Errorf("Expected to wait for the token budget to reset, got %v", delay)
+	}
+
+	header = http.Header{}
+	header.Set("retry-after", "600")
+	tracker.Observe(http.StatusTooManyRequests, header)
+	if delay := tracker.Delay(0); delay != maxRateLimitWait {
+		t.Errorf("Expected the retry-after wait capped at %v, got %v", maxRateLimitWait, delay)
+	}
+	if limits := tracker.Limits(); limits.Throttled != 1 {
+		t.Errorf("Expected 1 throttled response, got %d", limits.Throttled)
+	}
+}
@@ -0,0 +1,136 @@
+package output
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	outputgen "github.com/kuderr/deepwiki/pkg/output/generator"
+)
+
+// archiveEntry is a generated file and its slash-separated path inside the archive
+type archiveEntry struct {
+	path string
+	name string
+}
+
+// writeArchive packs the files in result into an archive next to the output directory, keeping
+// their paths relative to it, and records the archive in result.ArchivePath
+func writeArchive(result *outputgen.OutputResult, options outputgen.OutputOptions) error {
+	outputDir, err := filepath.Abs(options.Directory)
+	if err != nil {
+		return fmt.Errorf("failed to resolve output directory: %w", err)
+	}
+
+	entries := make([]archiveEntry, 0, len(result.FilesGenerated))
+	for _, path := range result.FilesGenerated {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", path, err)
+		}
+		relPath, err := filepath.Rel(outputDir, absPath)
+		if err != nil {
+			return fmt.Errorf("failed to archive %s: %w", path, err)
+		}
+		entries = append(entries, archiveEntry{path: path, name: filepath.ToSlash(relPath)})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].name < entries[j].name
+	})
+
+	archivePath := outputDir + "." + string(options.Archive)
+	file, err := os.Create(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+
+	switch options.Archive {
+	case outputgen.ArchiveZip:
+		err = writeZip(file, entries)
+	case outputgen.ArchiveTarGz:
+		err = writeTarGz(file, entries)
+	default:
+		err = fmt.Errorf("unsupported archive format: %s", options.Archive)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(archivePath)
+		return fmt.Errorf("failed to write archive %s: %w", archivePath, err)
+	}
+
+	result.ArchivePath = archivePath
+	return nil
+}
+
+// writeZip writes entries as a zip archive
+func writeZip(w io.Writer, entries []archiveEntry) error {
+	archive := zip.NewWriter(w)
+	for _, entry := range entries {
+		info, err := os.Stat(entry.path)
+		if err != nil {
+			return err
+		}
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = entry.name
+		header.Method = zip.Deflate
+
+		writer, err := archive.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		if err := copyInto(writer, entry.path); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// writeTarGz writes entries as a gzip-compressed tar archive
+func writeTarGz(w io.Writer, entries []archiveEntry) error {
+	compressed := gzip.NewWriter(w)
+	archive := tar.NewWriter(compressed)
+	for _, entry := range entries {
+		info, err := os.Stat(entry.path)
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = entry.name
+
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+		if err := copyInto(archive, entry.path); err != nil {
+			return err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	return compressed.Close()
+}
+
+// copyInto copies the contents of the file at path to w
+func copyInto(w io.Writer, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(w, file)
+	return err
+}
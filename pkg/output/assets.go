@@ -0,0 +1,200 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/kuderr/deepwiki/pkg/generator"
+	outputgen "github.com/kuderr/deepwiki/pkg/output/generator"
+)
+
+// assetDir is where referenced assets are copied, relative to the output directory
+var assetDir = filepath.Join("static", "img")
+
+// markdownLinkPattern matches markdown image and link references: ![alt](target) and [text](target)
+var markdownLinkPattern = regexp.MustCompile(`(!?\[[^\]]*\]\()([^)\s]+)((?:\s+"[^"]*")?\))`)
+
+// assetExtensions lists file extensions that are treated as static assets
+var assetExtensions = map[string]bool{
+	".png":  true,
+	".jpg":  true,
+	".jpeg": true,
+	".gif":  true,
+	".svg":  true,
+	".webp": true,
+	".bmp":  true,
+	".ico":  true,
+	".pdf":  true,
+}
+
+// assetCollector finds in-repo assets referenced by pages and rewrites links to their output location
+type assetCollector struct {
+	projectRoot string
+	linkPrefix  string
+	assets      map[string]string // repo-relative slash path -> absolute source path
+}
+
+// newAssetCollector creates a collector for the given options, or nil if there is no project to copy from
+func newAssetCollector(options outputgen.OutputOptions) *assetCollector {
+	if options.ProjectPath == "" {
+		return nil
+	}
+
+	root, err := filepath.Abs(options.ProjectPath)
+	if err != nil {
+		return nil
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+
+	return &assetCollector{
+		projectRoot: root,
+		linkPrefix:  assetLinkPrefix(options.Format),
+		assets:      make(map[string]string),
+	}
+}
+
+// assetLinkPrefix returns the URL prefix under which copied assets are reachable from generated pages
+func assetLinkPrefix(format outputgen.OutputFormat) string {
+	switch format {
+	case outputgen.FormatDocusaurus2, outputgen.FormatDocusaurus3,
+		outputgen.FormatSimpleDocusaurus2, outputgen.FormatSimpleDocusaurus3:
+		// Docusaurus serves the static directory from the site root
+		return "/img/"
+	case outputgen.FormatMarkdown, outputgen.FormatRST, outputgen.FormatGitBook:
+		// Markdown, reStructuredText and GitBook pages live in the pages/ subdirectory
+		return "../static/img/"
+	default:
+		return "static/img/"
+	}
+}
+
+// rewritePages returns copies of pages whose asset references point at the output static tree.
+// The original pages are left untouched.
+func (ac *assetCollector) rewritePages(pages map[string]*generator.WikiPage) map[string]*generator.WikiPage {
+	rewritten := make(map[string]*generator.WikiPage, len(pages))
+	for id, page := range pages {
+		if page == nil {
+			rewritten[id] = page
+			continue
+		}
+		pageCopy := *page
+		pageCopy.Content = ac.rewriteContent(page.Content)
+		rewritten[id] = &pageCopy
+	}
+	return rewritten
+}
+
+// rewriteContent replaces links to in-repo assets and records them for copying
+func (ac *assetCollector) rewriteContent(content string) string {
+	return markdownLinkPattern.ReplaceAllStringFunc(content, func(match string) string {
+		parts := markdownLinkPattern.FindStringSubmatch(match)
+		relPath, ok := ac.resolve(parts[2])
+		if !ok {
+			return match
+		}
+		return parts[1] + ac.linkPrefix + relPath + parts[3]
+	})
+}
+
+// resolve checks that target refers to an asset file inside the project and returns its repo-relative path
+func (ac *assetCollector) resolve(target string) (string, bool) {
+	if strings.Contains(target, "://") || strings.HasPrefix(target, "#") ||
+		strings.HasPrefix(target, "mailto:") || strings.HasPrefix(target, "data:") {
+		return "", false
+	}
+
+	// Drop any query string or fragment
+	if idx := strings.IndexAny(target, "?#"); idx >= 0 {
+		target = target[:idx]
+	}
+	if !assetExtensions[strings.ToLower(path.Ext(target))] {
+		return "", false
+	}
+
+	// Links are interpreted relative to the project root
+	cleaned := path.Clean("/" + strings.TrimPrefix(target, "./"))
+	sourcePath := filepath.Join(ac.projectRoot, filepath.FromSlash(cleaned))
+
+	resolved, err := filepath.EvalSymlinks(sourcePath)
+	if err != nil {
+		return "", false
+	}
+	if !ac.withinProject(resolved) {
+		return "", false
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil || !info.Mode().IsRegular() {
+		return "", false
+	}
+
+	relPath := strings.TrimPrefix(cleaned, "/")
+	ac.assets[relPath] = resolved
+	return relPath, true
+}
+
+// withinProject reports whether path is inside the project root
+func (ac *assetCollector) withinProject(path string) bool {
+	rel, err := filepath.Rel(ac.projectRoot, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// copyAssets copies every collected asset into the output directory and returns the written paths
+func (ac *assetCollector) copyAssets(outputDir string) ([]string, int64, error) {
+	var written []string
+	var totalSize int64
+
+	relPaths := make([]string, 0, len(ac.assets))
+	for relPath := range ac.assets {
+		relPaths = append(relPaths, relPath)
+	}
+	sort.Strings(relPaths)
+
+	for _, relPath := range relPaths {
+		sourcePath := ac.assets[relPath]
+		destPath := filepath.Join(outputDir, assetDir, filepath.FromSlash(relPath))
+		size, err := copyFile(sourcePath, destPath)
+		if err != nil {
+			return written, totalSize, fmt.Errorf("failed to copy asset %s: %w", relPath, err)
+		}
+		written = append(written, destPath)
+		totalSize += size
+	}
+
+	return written, totalSize, nil
+}
+
+// copyFile copies src to dst, creating parent directories as needed
+func copyFile(src, dst string) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return 0, err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return 0, err
+	}
+
+	size, err := io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return size, err
+}
@@ -2,7 +2,9 @@ package output
 
 import (
 	"fmt"
+	"io"
 	"log/slog"
+	"os"
 	"strings"
 	"time"
 
@@ -15,6 +17,8 @@ type CLIManager struct {
 	verboseMode     bool
 	quietMode       bool
 	dryRunMode      bool
+	colorMode       bool
+	out             io.Writer
 	progressTracker *EnhancedProgressTracker
 	multiTracker    *MultiTaskTracker
 	startTime       time.Time
@@ -52,6 +56,8 @@ func NewCLIManager(logger *slog.Logger, verbose, quiet, dryRun bool) *CLIManager
 		verboseMode:     verbose,
 		quietMode:       quiet,
 		dryRunMode:      dryRun,
+		colorMode:       ColorEnabled(os.Stdout, false),
+		out:             os.Stdout,
 		progressTracker: NewEnhancedProgressTracker(logger),
 		multiTracker:    NewMultiTaskTracker(logger),
 		startTime:       time.Now(),
@@ -62,6 +68,16 @@ func NewCLIManager(logger *slog.Logger, verbose, quiet, dryRun bool) *CLIManager
 	}
 }
 
+// SetColor enables or disables ANSI colors in CLI output
+func (c *CLIManager) SetColor(enabled bool) {
+	c.colorMode = enabled
+}
+
+// SetOutput redirects CLI output, which defaults to stdout
+func (c *CLIManager) SetOutput(out io.Writer) {
+	c.out = out
+}
+
 // StartOperation starts a new operation with welcome banner
 func (c *CLIManager) StartOperation(projectName, outputDir string) {
 	if c.quietMode {
@@ -71,19 +87,19 @@ func (c *CLIManager) StartOperation(projectName, outputDir string) {
 	c.printBanner()
 
 	if c.dryRunMode {
-		fmt.Println("🧪 DRY RUN MODE - No files will be generated")
-		fmt.Println()
+		c.println(colorize(colorYellow, "🧪 DRY RUN MODE - No files will be generated"))
+		c.println()
 	}
 
-	fmt.Printf("📁 Project: %s\n", projectName)
-	fmt.Printf("📂 Output: %s\n", outputDir)
-	fmt.Println()
+	c.printf("📁 Project: %s\n", projectName)
+	c.printf("📂 Output: %s\n", outputDir)
+	c.println()
 }
 
 // StartPhase starts a new phase of the operation
 func (c *CLIManager) StartPhase(phaseName, description string, total int) {
 	if !c.quietMode {
-		fmt.Printf("🔄 %s: %s\n", phaseName, description)
+		c.printf("🔄 %s: %s\n", colorize(colorCyan, phaseName), description)
 	}
 
 	c.stats.Phases[phaseName] = PhaseStats{
@@ -167,7 +183,7 @@ func (c *CLIManager) ShowVerboseStats() {
 		return
 	}
 
-	fmt.Println("\n📊 Detailed Statistics:")
+	c.println("\n📊 Detailed Statistics:")
 
 	// Show phase breakdown
 	for phaseName, phase := range c.stats.Phases {
@@ -176,15 +192,15 @@ func (c *CLIManager) ShowVerboseStats() {
 			duration = phase.EndTime.Sub(phase.StartTime).Round(time.Second).String()
 		}
 
-		fmt.Printf("  📋 %s: %d items, %d errors, %s\n",
+		c.printf("  📋 %s: %d items, %d errors, %s\n",
 			phaseName, phase.ItemsProcessed, phase.ErrorsCount, duration)
 	}
 
 	// Show resource usage
-	fmt.Printf("  🔢 API calls: %d\n", c.stats.APICallsMade)
-	fmt.Printf("  📝 Tokens used: %d\n", c.stats.TokensUsed)
-	fmt.Printf("  🧮 Embeddings: %d\n", c.stats.EmbeddingsCreated)
-	fmt.Printf("  ⚡ Chunks: %d\n", c.stats.ChunksGenerated)
+	c.printf("  🔢 API calls: %d\n", c.stats.APICallsMade)
+	c.printf("  📝 Tokens used: %d\n", c.stats.TokensUsed)
+	c.printf("  🧮 Embeddings: %d\n", c.stats.EmbeddingsCreated)
+	c.printf("  ⚡ Chunks: %d\n", c.stats.ChunksGenerated)
 }
 
 // UpdateStats updates various statistics
@@ -220,30 +236,30 @@ func (c *CLIManager) printBanner() {
 ║     AI-Powered Documentation          ║
 ╚═══════════════════════════════════════╝
 `
-	fmt.Println(banner)
+	c.println(colorize(colorCyan, banner))
 }
 
 func (c *CLIManager) showSuccessSummary(result *outputgen.OutputResult) {
 	if c.quietMode {
-		fmt.Printf("Generated %d files in %s\n", result.TotalFiles, result.OutputDir)
+		c.printf("Generated %d files in %s\n", result.TotalFiles, result.OutputDir)
 		return
 	}
 
-	fmt.Println("\n🎉 Generation completed successfully!")
-	fmt.Printf("📁 Output directory: %s\n", result.OutputDir)
-	fmt.Printf("📄 Files generated: %d\n", result.TotalFiles)
-	fmt.Printf("💾 Total size: %s\n", formatBytes(result.TotalSize))
-	fmt.Printf("⏱️  Processing time: %v\n", result.ProcessingTime.Round(time.Second))
+	c.println(colorize(colorBold+colorGreen, "\n🎉 Generation completed successfully!"))
+	c.printf("📁 Output directory: %s\n", result.OutputDir)
+	c.printf("📄 Files generated: %d\n", result.TotalFiles)
+	c.printf("💾 Total size: %s\n", formatBytes(result.TotalSize))
+	c.printf("⏱️  Processing time: %v\n", result.ProcessingTime.Round(time.Second))
 
 	// Show key files
 	if len(result.FilesGenerated) > 0 {
-		fmt.Println("\n📋 Key files:")
+		c.println("\n📋 Key files:")
 		keyFiles := []string{"index.md", "index.json", "wiki.json", "wiki-structure.json"}
 
 		for _, keyFile := range keyFiles {
 			for _, generated := range result.FilesGenerated {
 				if strings.HasSuffix(generated, keyFile) {
-					fmt.Printf("  • %s\n", generated)
+					c.printf("  • %s\n", generated)
 					break
 				}
 			}
@@ -257,37 +273,56 @@ func (c *CLIManager) showSuccessSummary(result *outputgen.OutputResult) {
 
 func (c *CLIManager) showErrorSummary(errors []error) {
 	if c.quietMode {
-		fmt.Printf("Completed with %d errors\n", len(errors))
+		c.printf("Completed with %d errors\n", len(errors))
 		return
 	}
 
-	fmt.Printf("\n⚠️  Completed with %d errors:\n", len(errors))
+	c.println(colorize(colorYellow, fmt.Sprintf("\n⚠️  Completed with %d errors:", len(errors))))
 
 	for i, err := range errors {
 		if i < 5 { // Show first 5 errors
-			fmt.Printf("  %d. %v\n", i+1, err)
+			c.printf("  %d. %s\n", i+1, colorize(colorRed, err.Error()))
 		} else if i == 5 {
-			fmt.Printf("  ... and %d more errors\n", len(errors)-5)
+			c.printf("  ... and %d more errors\n", len(errors)-5)
 			break
 		}
 	}
 
 	if c.verboseMode {
-		fmt.Println("\nFor detailed error information, check the logs.")
+		c.println("\nFor detailed error information, check the logs.")
 	}
 }
 
 func (c *CLIManager) showDryRunSummary(result *outputgen.OutputResult) {
-	fmt.Println("\n🧪 Dry run completed!")
-	fmt.Printf("📁 Would create output in: %s\n", result.OutputDir)
-	fmt.Printf("📄 Would generate: %d files\n", result.TotalFiles)
-	fmt.Printf("⏱️  Analysis time: %v\n", result.ProcessingTime.Round(time.Second))
+	c.println(colorize(colorBold+colorYellow, "\n🧪 Dry run completed!"))
+	c.printf("📁 Would create output in: %s\n", result.OutputDir)
+	c.printf("📄 Would generate: %d files\n", result.TotalFiles)
+	c.printf("⏱️  Analysis time: %v\n", result.ProcessingTime.Round(time.Second))
 
 	if len(result.Errors) > 0 {
-		fmt.Printf("⚠️  Would encounter %d errors\n", len(result.Errors))
+		c.printf("⚠️  Would encounter %d errors\n", len(result.Errors))
 	}
 
-	fmt.Println("\nTo actually generate files, run without --dry-run flag.")
+	c.println("\nTo actually generate files, run without --dry-run flag.")
+}
+
+// printf writes formatted output, stripping colors when they are disabled
+func (c *CLIManager) printf(format string, args ...any) {
+	text := fmt.Sprintf(format, args...)
+	if !c.colorMode {
+		text = StripANSI(text)
+	}
+	fmt.Fprint(c.out, text)
+}
+
+// println writes a line of output, stripping colors when they are disabled
+func (c *CLIManager) println(args ...any) {
+	c.printf("%s", fmt.Sprintln(args...))
+}
+
+// colorize wraps text in an ANSI color; printf strips it again when colors are disabled
+func colorize(color, text string) string {
+	return color + text + colorReset
 }
 
 // formatBytes formats byte size in human-readable format
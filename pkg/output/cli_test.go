@@ -0,0 +1,74 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	outputgen "github.com/kuderr/deepwiki/pkg/output/generator"
+)
+
+// runCLISummary drives a CLI manager through a phase and a summary with errors
+func runCLISummary(t *testing.T, color bool) string {
+	t.Helper()
+
+	var out bytes.Buffer
+	manager := NewCLIManager(slog.Default(), false, false, false)
+	manager.SetOutput(&out)
+	manager.SetColor(color)
+
+	manager.StartPhase("Phase 1", "Scanning", 0)
+	manager.CompleteOperation(
+		&outputgen.OutputResult{OutputDir: "docs", TotalFiles: 2},
+		[]error{errors.New("page failed")},
+	)
+
+	return out.String()
+}
+
+func TestCLIManager_Color(t *testing.T) {
+	output := runCLISummary(t, true)
+
+	if !strings.Contains(output, colorGreen) || !strings.Contains(output, colorRed) {
+		t.Errorf("Expected colored output, got %q", output)
+	}
+}
+
+func TestCLIManager_NoColorStripsANSI(t *testing.T) {
+	output := runCLISummary(t, false)
+
+	if strings.Contains(output, "\x1b[") {
+		t.Errorf("Expected no ANSI codes, got %q", output)
+	}
+	for _, expected := range []string{"Phase 1: Scanning", "Generation completed successfully!", "1. page failed"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain %q, got %q", expected, output)
+		}
+	}
+}
+
+func TestStripANSI(t *testing.T) {
+	text := colorize(colorBold+colorGreen, "done") + " " + colorize(colorRed, "failed")
+	if stripped := StripANSI(text); stripped != "done failed" {
+		t.Errorf("Expected 'done failed', got %q", stripped)
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	file, err := os.Create(filepath.Join(t.TempDir(), "out.log"))
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	defer file.Close()
+
+	if ColorEnabled(file, false) {
+		t.Error("Expected color to be disabled when output is not a terminal")
+	}
+	if ColorEnabled(os.Stdout, true) {
+		t.Error("Expected --no-color to disable color")
+	}
+}
@@ -0,0 +1,43 @@
+package output
+
+import (
+	"os"
+	"regexp"
+)
+
+// ANSI escape sequences used for CLI output
+const (
+	colorReset  = "\033[0m"
+	colorBold   = "\033[1m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorCyan   = "\033[36m"
+)
+
+// ansiPattern matches ANSI escape sequences
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+// StripANSI removes ANSI escape sequences from text
+func StripANSI(text string) string {
+	return ansiPattern.ReplaceAllString(text, "")
+}
+
+// IsTerminal reports whether the file is attached to a terminal
+func IsTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// ColorEnabled reports whether colored output should be written to the file.
+// Color is disabled by --no-color, by the NO_COLOR environment variable, and when
+// the file is not a terminal (e.g. output is piped or redirected).
+func ColorEnabled(file *os.File, noColor bool) bool {
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return IsTerminal(file)
+}
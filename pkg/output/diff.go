@@ -0,0 +1,187 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// ChangeType describes how a file differs between two generation runs
+type ChangeType string
+
+const (
+	ChangeAdded    ChangeType = "added"
+	ChangeRemoved  ChangeType = "removed"
+	ChangeModified ChangeType = "modified"
+)
+
+// FileChange is a file that differs between two output directories
+type FileChange struct {
+	Path string     `json:"path"` // Path relative to the output directory, slash-separated
+	Type ChangeType `json:"type"`
+	Diff string     `json:"diff,omitempty"` // Unified diff of the contents; empty for binary files
+}
+
+// DiffReport lists the differences between two output directories
+type DiffReport struct {
+	OldDir    string       `json:"oldDir"`
+	NewDir    string       `json:"newDir"`
+	Changes   []FileChange `json:"changes"`   // Sorted by path
+	Unchanged int          `json:"unchanged"` // Files present in both runs with identical contents
+}
+
+// HasChanges reports whether any file was added, removed or modified
+func (r *DiffReport) HasChanges() bool {
+	return len(r.Changes) > 0
+}
+
+// Count returns the number of changes of the given type
+func (r *DiffReport) Count(changeType ChangeType) int {
+	count := 0
+	for _, change := range r.Changes {
+		if change.Type == changeType {
+			count++
+		}
+	}
+	return count
+}
+
+// DiffOutputs compares two output directories file by file. Checksums from each directory's
+// manifest are used when available, so unchanged files are skipped without being read.
+func DiffOutputs(oldDir, newDir string) (*DiffReport, error) {
+	oldFiles, err := outputChecksums(oldDir)
+	if err != nil {
+		return nil, err
+	}
+	newFiles, err := outputChecksums(newDir)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DiffReport{OldDir: oldDir, NewDir: newDir}
+
+	for path, oldSum := range oldFiles {
+		newSum, exists := newFiles[path]
+		switch {
+		case !exists:
+			change, err := fileChange(ChangeRemoved, path, oldDir, newDir)
+			if err != nil {
+				return nil, err
+			}
+			report.Changes = append(report.Changes, change)
+		case oldSum != "" && oldSum == newSum:
+			report.Unchanged++
+		default:
+			change, err := fileChange(ChangeModified, path, oldDir, newDir)
+			if err != nil {
+				return nil, err
+			}
+			// Without checksums on both sides equality is only known after reading the files
+			if change.Type == "" {
+				report.Unchanged++
+				continue
+			}
+			report.Changes = append(report.Changes, change)
+		}
+	}
+
+	for path := range newFiles {
+		if _, exists := oldFiles[path]; exists {
+			continue
+		}
+		change, err := fileChange(ChangeAdded, path, oldDir, newDir)
+		if err != nil {
+			return nil, err
+		}
+		report.Changes = append(report.Changes, change)
+	}
+
+	sort.Slice(report.Changes, func(i, j int) bool {
+		return report.Changes[i].Path < report.Changes[j].Path
+	})
+
+	return report, nil
+}
+
+// outputChecksums maps every file of an output directory to its sha256 checksum.
+// The manifest is used when present; otherwise the directory is walked and checksums are left
+// empty, to be resolved by comparing contents.
+func outputChecksums(dir string) (map[string]string, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read output directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	checksums := make(map[string]string)
+
+	manifest, err := ReadManifest(dir)
+	if err == nil {
+		for _, entry := range manifest.Files {
+			checksums[entry.Path] = entry.SHA256
+		}
+		return checksums, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	err = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		checksums[filepath.ToSlash(relPath)] = ""
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list output directory: %w", err)
+	}
+
+	return checksums, nil
+}
+
+// fileChange reads both versions of a file and builds the change with its diff.
+// For a modified file whose contents turn out identical, the returned change has no type.
+func fileChange(changeType ChangeType, path, oldDir, newDir string) (FileChange, error) {
+	var oldContent, newContent []byte
+	var err error
+
+	if changeType != ChangeAdded {
+		if oldContent, err = os.ReadFile(filepath.Join(oldDir, filepath.FromSlash(path))); err != nil {
+			return FileChange{}, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+	}
+	if changeType != ChangeRemoved {
+		if newContent, err = os.ReadFile(filepath.Join(newDir, filepath.FromSlash(path))); err != nil {
+			return FileChange{}, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+	}
+
+	if changeType == ChangeModified && bytes.Equal(oldContent, newContent) {
+		return FileChange{Path: path}, nil
+	}
+
+	change := FileChange{Path: path, Type: changeType}
+	if !isBinary(oldContent) && !isBinary(newContent) {
+		change.Diff = UnifiedDiff("a/"+path, "b/"+path, string(oldContent), string(newContent))
+	}
+	return change, nil
+}
+
+// isBinary reports whether content looks like binary data
+func isBinary(content []byte) bool {
+	return bytes.IndexByte(content, 0) >= 0
+}
@@ -0,0 +1,215 @@
+package output
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	outputgen "github.com/kuderr/deepwiki/pkg/output/generator"
+)
+
+// writeOutputFixture writes files into a new output directory, optionally with a manifest
+func writeOutputFixture(t *testing.T, files map[string]string, withManifest bool) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	result := &outputgen.OutputResult{}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		result.FilesGenerated = append(result.FilesGenerated, path)
+	}
+
+	if withManifest {
+		options := outputgen.OutputOptions{Format: outputgen.FormatMarkdown, Directory: dir}
+		if err := writeManifest(result, options); err != nil {
+			t.Fatalf("Failed to write manifest: %v", err)
+		}
+	}
+
+	return dir
+}
+
+func TestDiffOutputs(t *testing.T) {
+	oldFiles := map[string]string{
+		"index.md":           "# Wiki\n\n- Overview\n- Setup\n",
+		"pages/overview.md":  "# Overview\n\nline 1\nline 2\nline 3\n",
+		"pages/setup.md":     "# Setup\n\nRun make.\n",
+		"pages/obsolete.md":  "# Obsolete\n",
+		"assets/diagram.png": "\x89PNG\x00old",
+	}
+	newFiles := map[string]string{
+		"index.md":           "# Wiki\n\n- Overview\n- Setup\n",
+		"pages/overview.md":  "# Overview\n\nline 1\nline two\nline 3\n",
+		"pages/setup.md":     "# Setup\n\nRun make.\n",
+		"pages/usage.md":     "# Usage\n",
+		"assets/diagram.png": "\x89PNG\x00new",
+	}
+
+	for _, withManifest := range []bool{true, false} {
+		name := "without manifest"
+		if withManifest {
+			name = "with manifest"
+		}
+		t.Run(name, func(t *testing.T) {
+			oldDir := writeOutputFixture(t, oldFiles, withManifest)
+			newDir := writeOutputFixture(t, newFiles, withManifest)
+
+			report, err := DiffOutputs(oldDir, newDir)
+			if err != nil {
+				t.Fatalf("DiffOutputs failed: %v", err)
+			}
+
+			expected := []FileChange{
+				{Path: "assets/diagram.png", Type: ChangeModified},
+				{Path: "pages/obsolete.md", Type: ChangeRemoved},
+				{Path: "pages/overview.md", Type: ChangeModified},
+				{Path: "pages/usage.md", Type: ChangeAdded},
+			}
+			if len(report.Changes) != len(expected) {
+				t.Fatalf("Expected %d changes, got %+v", len(expected), report.Changes)
+			}
+			for i, change := range report.Changes {
+				if change.Path != expected[i].Path || change.Type != expected[i].Type {
+					t.Errorf("Change %d: expected %s %s, got %s %s",
+						i, expected[i].Type, expected[i].Path, change.Type, change.Path)
+				}
+			}
+
+			if report.Unchanged != 2 {
+				t.Errorf("Expected 2 unchanged files, got %d", report.Unchanged)
+			}
+
+			overview := report.Changes[2]
+			if !strings.Contains(overview.Diff, "-line 2\n+line two\n") {
+				t.Errorf("Expected overview diff to show the changed line, got:\n%s", overview.Diff)
+			}
+			if report.Changes[0].Diff != "" {
+				t.Errorf("Expected no diff for binary file, got:\n%s", report.Changes[0].Diff)
+			}
+			if !strings.Contains(report.Changes[1].Diff, "-# Obsolete\n") {
+				t.Errorf("Expected removed page diff, got:\n%s", report.Changes[1].Diff)
+			}
+			if !strings.Contains(report.Changes[3].Diff, "+# Usage\n") {
+				t.Errorf("Expected added page diff, got:\n%s", report.Changes[3].Diff)
+			}
+		})
+	}
+}
+
+func TestDiffOutputs_NoChanges(t *testing.T) {
+	files := map[string]string{
+		"index.md":          "# Wiki\n",
+		"pages/overview.md": "# Overview\n",
+	}
+
+	report, err := DiffOutputs(writeOutputFixture(t, files, false), writeOutputFixture(t, files, false))
+	if err != nil {
+		t.Fatalf("DiffOutputs failed: %v", err)
+	}
+	if report.HasChanges() {
+		t.Errorf("Expected no changes, got %+v", report.Changes)
+	}
+	if report.Unchanged != 2 {
+		t.Errorf("Expected 2 unchanged files, got %d", report.Unchanged)
+	}
+}
+
+func TestDiffOutputs_TrustsManifestChecksums(t *testing.T) {
+	files := map[string]string{"pages/overview.md": "# Overview\n"}
+	oldDir := writeOutputFixture(t, files, true)
+	newDir := writeOutputFixture(t, files, true)
+
+	// Files with matching checksums are not read, so an edit made after the manifest goes unnoticed
+	if err := os.WriteFile(filepath.Join(newDir, "pages", "overview.md"), []byte("edited\n"), 0o644); err != nil {
+		t.Fatalf("Failed to edit page: %v", err)
+	}
+
+	report, err := DiffOutputs(oldDir, newDir)
+	if err != nil {
+		t.Fatalf("DiffOutputs failed: %v", err)
+	}
+	if report.HasChanges() || report.Unchanged != 1 {
+		t.Errorf("Expected the page to be skipped as unchanged, got %+v", report)
+	}
+}
+
+func TestDiffOutputs_MissingDirectory(t *testing.T) {
+	if _, err := DiffOutputs(filepath.Join(t.TempDir(), "missing"), t.TempDir()); err == nil {
+		t.Error("Expected error for missing directory")
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	oldText := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+	newText := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\n"
+
+	expected := "--- old\n+++ new\n" +
+		"@@ -1,5 +1,5 @@\n a\n-b\n+B\n c\n d\n e\n" +
+		"@@ -11,3 +11,4 @@\n k\n l\n m\n+n\n"
+
+	if diff := UnifiedDiff("old", "new", oldText, newText); diff != expected {
+		t.Errorf("Unexpected diff:\n%s\nexpected:\n%s", diff, expected)
+	}
+
+	if diff := UnifiedDiff("old", "new", oldText, oldText); diff != "" {
+		t.Errorf("Expected empty diff for equal texts, got:\n%s", diff)
+	}
+
+	if diff := UnifiedDiff("old", "new", "", "x\n"); diff != "--- old\n+++ new\n@@ -0,0 +1 @@\n+x\n" {
+		t.Errorf("Unexpected diff for added file:\n%s", diff)
+	}
+}
+
+func TestUnifiedDiff_LargeInput(t *testing.T) {
+	lines := make([]string, 20000)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i)
+	}
+	oldText := strings.Join(lines, "\n") + "\n"
+	lines[10000] = "changed"
+	newText := strings.Join(lines, "\n") + "\n"
+
+	expected := "--- old\n+++ new\n" +
+		"@@ -9998,7 +9998,7 @@\n line 9997\n line 9998\n line 9999\n-line 10000\n+changed\n" +
+		" line 10001\n line 10002\n line 10003\n"
+	if diff := UnifiedDiff("old", "new", oldText, newText); diff != expected {
+		t.Errorf("Unexpected diff:\n%s\nexpected:\n%s", diff, expected)
+	}
+}
+
+func TestDiffLines_Minimal(t *testing.T) {
+	oldLines := strings.Split("a b c a b b a", " ")
+	newLines := strings.Split("c b a b a c", " ")
+
+	ops := diffLines(oldLines, newLines)
+
+	var gotOld, gotNew []string
+	edits := 0
+	for _, op := range ops {
+		if op.kind != '+' {
+			gotOld = append(gotOld, op.text)
+		}
+		if op.kind != '-' {
+			gotNew = append(gotNew, op.text)
+		}
+		if op.kind != ' ' {
+			edits++
+		}
+	}
+	if !slices.Equal(gotOld, oldLines) || !slices.Equal(gotNew, newLines) {
+		t.Fatalf("Edit script does not turn %v into %v: %v", oldLines, newLines, ops)
+	}
+	// The shortest edit script of this classic example has 5 edits
+	if edits != 5 {
+		t.Errorf("Expected 5 edits, got %d: %v", edits, ops)
+	}
+}
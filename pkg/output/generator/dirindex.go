@@ -0,0 +1,146 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kuderr/deepwiki/pkg/generator"
+)
+
+// directoryIndexRoot is the file name (without extension) of the index linking all directory indexes
+const directoryIndexRoot = "index"
+
+// DirectoryIndex lists the pages derived from files under one top-level directory
+type DirectoryIndex struct {
+	Directory string                // Top-level directory, relative to the project root
+	Slug      string                // File name of the index page without extension
+	Pages     []*generator.WikiPage // Pages with at least one source file in the directory, by title
+}
+
+// directoryIndexLayout describes where a format writes directory indexes and how it links them
+type directoryIndexLayout struct {
+	dir         string                                // Directory the index pages are written to
+	pageLink    func(page *generator.WikiPage) string // Link from a directory index to a wiki page
+	indexLink   func(slug string) string              // Link from the root index to a directory index
+	frontMatter func(slug, title string) string       // Front matter for an index page, if any
+}
+
+// BuildDirectoryIndexes groups pages by the top-level directories of their source files.
+// Files in the project root belong to no directory and are not indexed.
+func BuildDirectoryIndexes(pages map[string]*generator.WikiPage) []DirectoryIndex {
+	byDirectory := make(map[string]map[string]*generator.WikiPage)
+	for _, page := range pages {
+		for _, path := range page.FilePaths {
+			directory, _, found := strings.Cut(filepath.ToSlash(filepath.Clean(path)), "/")
+			if !found || directory == "" || directory == "." || directory == ".." {
+				continue
+			}
+			if byDirectory[directory] == nil {
+				byDirectory[directory] = make(map[string]*generator.WikiPage)
+			}
+			byDirectory[directory][page.ID] = page
+		}
+	}
+
+	indexes := make([]DirectoryIndex, 0, len(byDirectory))
+	for directory, dirPages := range byDirectory {
+		index := DirectoryIndex{
+			Directory: directory,
+			Slug:      "dir-" + SanitizeFileName(directory),
+		}
+		for _, page := range dirPages {
+			index.Pages = append(index.Pages, page)
+		}
+		sort.Slice(index.Pages, func(i, j int) bool {
+			if index.Pages[i].Title != index.Pages[j].Title {
+				return index.Pages[i].Title < index.Pages[j].Title
+			}
+			return index.Pages[i].ID < index.Pages[j].ID
+		})
+		indexes = append(indexes, index)
+	}
+
+	sort.Slice(indexes, func(i, j int) bool {
+		return indexes[i].Directory < indexes[j].Directory
+	})
+
+	return indexes
+}
+
+// writeDirectoryIndexes writes one index page per top-level directory plus the root index
+func writeDirectoryIndexes(
+	pages map[string]*generator.WikiPage,
+	layout directoryIndexLayout,
+) (filesGenerated []string, totalSize int64, errors []error) {
+	if err := os.MkdirAll(layout.dir, 0o755); err != nil {
+		return nil, 0, []error{fmt.Errorf("failed to create directory %s: %w", layout.dir, err)}
+	}
+
+	indexes := BuildDirectoryIndexes(pages)
+
+	write := func(slug, content string) {
+		path := filepath.Join(layout.dir, slug+".md")
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			errors = append(errors, fmt.Errorf("failed to write directory index %s: %w", slug, err))
+			return
+		}
+		totalSize += int64(len(content))
+		filesGenerated = append(filesGenerated, path)
+	}
+
+	var root strings.Builder
+	if layout.frontMatter != nil {
+		root.WriteString(layout.frontMatter(directoryIndexRoot, "Directories"))
+	}
+	root.WriteString("# 📁 Directories\n\n")
+	if len(indexes) == 0 {
+		root.WriteString("No pages are derived from files in subdirectories.\n")
+	}
+	for _, index := range indexes {
+		root.WriteString(fmt.Sprintf("- [%s/](%s) - %d pages\n",
+			index.Directory, layout.indexLink(index.Slug), len(index.Pages)))
+	}
+	write(directoryIndexRoot, root.String())
+
+	for _, index := range indexes {
+		var content strings.Builder
+		if layout.frontMatter != nil {
+			content.WriteString(layout.frontMatter(index.Slug, index.Directory+"/"))
+		}
+		content.WriteString(fmt.Sprintf("# 📁 %s/\n\n", index.Directory))
+		content.WriteString(fmt.Sprintf("Pages documenting files under `%s/`.\n\n", index.Directory))
+		for _, page := range index.Pages {
+			content.WriteString(fmt.Sprintf("- [%s](%s)", page.Title, layout.pageLink(page)))
+			if page.Description != "" {
+				content.WriteString(" - " + page.Description)
+			}
+			content.WriteString("\n")
+		}
+		write(index.Slug, content.String())
+	}
+
+	return filesGenerated, totalSize, errors
+}
+
+// docusaurusDirectoryIndexLayout returns the directory index layout shared by the Docusaurus formats
+func docusaurusDirectoryIndexLayout(outputDir string) directoryIndexLayout {
+	return directoryIndexLayout{
+		dir: filepath.Join(outputDir, "docs", "directories"),
+		pageLink: func(page *generator.WikiPage) string {
+			return "/" + PageFileName(page)
+		},
+		indexLink: func(slug string) string {
+			return "/directories/" + slug
+		},
+		frontMatter: func(slug, title string) string {
+			urlSlug := "/directories/" + slug
+			if slug == directoryIndexRoot {
+				urlSlug = "/directories"
+			}
+			return fmt.Sprintf("---\nid: %s\ntitle: %s\nslug: %s\n---\n\n", slug, title, urlSlug)
+		},
+	}
+}
@@ -59,19 +59,25 @@ func (d2g *Docusaurus2Generator) Generate(
 
 	// Generate individual page files with Docusaurus frontmatter
 	docsDir := filepath.Join(options.Directory, "docs")
-	for pageID, page := range pages {
-		fileName := d2g.sanitizeFileName(page.Title) + ".md"
-		pagePath := filepath.Join(docsDir, fileName)
-
-		if err := d2g.generatePage(page, pagePath, structure, options); err != nil {
-			errors = append(errors, fmt.Errorf("failed to generate page %s: %w", pageID, err))
-			continue
-		}
-
-		if stat, err := os.Stat(pagePath); err == nil {
-			totalSize += stat.Size()
-		}
-		filesGenerated = append(filesGenerated, pagePath)
+	pageFiles, pagesSize, pageErrors := writePages(pages, options.MaxWorkers,
+		func(pageID string, page *generator.WikiPage) (string, error) {
+			pagePath := filepath.Join(docsDir, PageFileName(page)+".md")
+			if err := d2g.generatePage(page, pagePath, structure, options); err != nil {
+				return "", fmt.Errorf("failed to generate page %s: %w", pageID, err)
+			}
+			return pagePath, nil
+		})
+	filesGenerated = append(filesGenerated, pageFiles...)
+	totalSize += pagesSize
+	errors = append(errors, pageErrors...)
+
+	// Generate per-directory index pages
+	if options.DirectoryIndexes {
+		layout := docusaurusDirectoryIndexLayout(options.Directory)
+		indexFiles, indexSize, indexErrors := writeDirectoryIndexes(pages, layout)
+		filesGenerated = append(filesGenerated, indexFiles...)
+		totalSize += indexSize
+		errors = append(errors, indexErrors...)
 	}
 
 	// Generate sidebars.js configuration
@@ -107,6 +113,19 @@ func (d2g *Docusaurus2Generator) Generate(
 		filesGenerated = append(filesGenerated, packagePath)
 	}
 
+	// Generate sitemap.xml when the site location is known
+	if options.SiteURL != "" {
+		sitemapPath := filepath.Join(options.Directory, "static", "sitemap.xml")
+		if err := writeSitemap(pages, options, sitemapPath); err != nil {
+			errors = append(errors, fmt.Errorf("failed to generate sitemap: %w", err))
+		} else {
+			if stat, err := os.Stat(sitemapPath); err == nil {
+				totalSize += stat.Size()
+			}
+			filesGenerated = append(filesGenerated, sitemapPath)
+		}
+	}
+
 	return &OutputResult{
 		OutputDir:      options.Directory,
 		FilesGenerated: filesGenerated,
@@ -185,7 +204,7 @@ func (d2g *Docusaurus2Generator) generateIndex(
 	if len(importanceGroups["high"]) > 0 {
 		content.WriteString("### 🔥 Essential Documentation\n\n")
 		for _, page := range importanceGroups["high"] {
-			fileName := d2g.sanitizeFileName(page.Title)
+			fileName := PageFileName(page)
 			content.WriteString(fmt.Sprintf("- [%s](./%s) - %s\n", page.Title, fileName, page.Description))
 		}
 		content.WriteString("\n")
@@ -195,12 +214,18 @@ func (d2g *Docusaurus2Generator) generateIndex(
 	if len(importanceGroups["medium"]) > 0 {
 		content.WriteString("### 📋 Core Documentation\n\n")
 		for _, page := range importanceGroups["medium"] {
-			fileName := d2g.sanitizeFileName(page.Title)
+			fileName := PageFileName(page)
 			content.WriteString(fmt.Sprintf("- [%s](./%s) - %s\n", page.Title, fileName, page.Description))
 		}
 		content.WriteString("\n")
 	}
 
+	// Link the directory indexes
+	if options.DirectoryIndexes {
+		content.WriteString("## 📁 Directories\n\n")
+		content.WriteString("- [Browse pages by directory](/directories)\n\n")
+	}
+
 	return os.WriteFile(filePath, []byte(content.String()), 0o644)
 }
 
@@ -217,43 +242,8 @@ func (d2g *Docusaurus2Generator) generatePage(
 	fileName := strings.TrimSuffix(filepath.Base(filePath), ".md")
 
 	// Write Docusaurus frontmatter
-	content.WriteString("---\n")
-	content.WriteString(fmt.Sprintf("id: %s\n", page.ID))
-	content.WriteString(fmt.Sprintf("title: %s\n", page.Title))
-	content.WriteString(fmt.Sprintf("slug: /%s\n", fileName))
-	if page.Description != "" {
-		content.WriteString(fmt.Sprintf("description: %s\n", page.Description))
-	}
-	if len(page.FilePaths) > 0 {
-		content.WriteString("tags:\n")
-		// Add language tags based on file extensions
-		languages := make(map[string]bool)
-		for _, path := range page.FilePaths {
-			ext := filepath.Ext(path)
-			switch ext {
-			case ".go":
-				languages["golang"] = true
-			case ".js", ".jsx":
-				languages["javascript"] = true
-			case ".ts", ".tsx":
-				languages["typescript"] = true
-			case ".py":
-				languages["python"] = true
-			case ".java":
-				languages["java"] = true
-			case ".cpp", ".cc", ".cxx":
-				languages["cpp"] = true
-			case ".rs":
-				languages["rust"] = true
-			case ".md":
-				languages["documentation"] = true
-			}
-		}
-		for lang := range languages {
-			content.WriteString(fmt.Sprintf("  - %s\n", lang))
-		}
-	}
-	content.WriteString("---\n\n")
+	frontmatter := pageFrontmatter(page, fileName, 0, languageTags(page.FilePaths))
+	writeFrontmatter(&content, frontmatter, options.Frontmatter)
 
 	// Write description if available
 	if page.Description != "" {
@@ -348,6 +338,21 @@ func (d2g *Docusaurus2Generator) generateSidebar(
 		content.WriteString("    },\n")
 	}
 
+	// Add directory indexes section
+	if options.DirectoryIndexes {
+		content.WriteString("    {\n")
+		content.WriteString("      type: 'category',\n")
+		content.WriteString("      label: '📁 Directories',\n")
+		content.WriteString("      collapsed: true,\n")
+		content.WriteString("      items: [\n")
+		content.WriteString(fmt.Sprintf("        'directories/%s',\n", directoryIndexRoot))
+		for _, index := range BuildDirectoryIndexes(pages) {
+			content.WriteString(fmt.Sprintf("        'directories/%s',\n", index.Slug))
+		}
+		content.WriteString("      ],\n")
+		content.WriteString("    },\n")
+	}
+
 	content.WriteString("  ],\n")
 	content.WriteString("};\n\n")
 	content.WriteString("module.exports = sidebars;\n")
@@ -383,9 +388,13 @@ func (d2g *Docusaurus2Generator) generateConfig(
 	content.WriteString("  favicon: 'img/favicon.ico',\n\n")
 
 	content.WriteString("  // Set the production url of your site here\n")
-	content.WriteString("  url: 'https://your-docusaurus-test-site.com',\n")
+	siteURL := "https://your-docusaurus-test-site.com"
+	if options.SiteURL != "" {
+		siteURL = strings.TrimRight(options.SiteURL, "/")
+	}
+	content.WriteString(fmt.Sprintf("  url: '%s',\n", siteURL))
 	content.WriteString("  // Set the /<baseUrl>/ pathname under which your site is served\n")
-	content.WriteString("  baseUrl: '/',\n\n")
+	content.WriteString(fmt.Sprintf("  baseUrl: '%s',\n\n", SiteBaseURL(options)))
 
 	content.WriteString("  // GitHub pages deployment config.\n")
 	content.WriteString("  organizationName: 'your-org',\n")
@@ -505,33 +514,3 @@ func (d2g *Docusaurus2Generator) writeJSONFile(data interface{}, filePath string
 
 	return os.WriteFile(filePath, jsonData, 0o644)
 }
-
-func (d2g *Docusaurus2Generator) sanitizeFileName(name string) string {
-	// Basic sanitization
-	result := strings.ToLower(name)
-	result = strings.ReplaceAll(result, " ", "-")
-	result = strings.ReplaceAll(result, "/", "-")
-	result = strings.ReplaceAll(result, "\\", "-")
-	result = strings.ReplaceAll(result, ":", "-")
-	result = strings.ReplaceAll(result, "*", "-")
-	result = strings.ReplaceAll(result, "?", "-")
-	result = strings.ReplaceAll(result, "\"", "-")
-	result = strings.ReplaceAll(result, "<", "-")
-	result = strings.ReplaceAll(result, ">", "-")
-	result = strings.ReplaceAll(result, "|", "-")
-
-	// Remove multiple consecutive dashes
-	for strings.Contains(result, "--") {
-		result = strings.ReplaceAll(result, "--", "-")
-	}
-
-	// Trim dashes from start and end
-	result = strings.Trim(result, "-")
-
-	// Ensure not empty
-	if result == "" {
-		result = "untitled"
-	}
-
-	return result
-}
@@ -0,0 +1,115 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/kuderr/deepwiki/pkg/generator"
+)
+
+// FrontmatterFields lists the page frontmatter keys, in the order they are written
+var FrontmatterFields = []string{"id", "title", "sidebar_position", "slug", "description", "tags"}
+
+// FrontmatterOptions selects the frontmatter written at the top of each page
+type FrontmatterOptions struct {
+	// Fields lists the keys to emit. Nil keeps the format's default keys and an empty
+	// list omits the frontmatter block. Markdown pages only get frontmatter when set.
+	Fields []string `json:"fields,omitempty"`
+}
+
+// ValidateFrontmatterFields returns an error for keys not in FrontmatterFields
+func ValidateFrontmatterFields(fields []string) error {
+	for _, field := range fields {
+		if !slices.Contains(FrontmatterFields, field) {
+			return fmt.Errorf("unknown frontmatter field %q (valid: %s)", field, strings.Join(FrontmatterFields, ", "))
+		}
+	}
+	return nil
+}
+
+// frontmatterEntry is a single frontmatter key with a scalar or list value
+type frontmatterEntry struct {
+	key   string
+	value string
+	list  []string
+}
+
+// pageFrontmatter returns the frontmatter entries available for a page. Empty values are left out.
+func pageFrontmatter(page *generator.WikiPage, slug string, sidebarPosition int, tags []string) []frontmatterEntry {
+	entries := []frontmatterEntry{
+		{key: "id", value: page.ID},
+		{key: "title", value: page.Title},
+	}
+	if sidebarPosition > 0 {
+		entries = append(entries, frontmatterEntry{key: "sidebar_position", value: fmt.Sprintf("%d", sidebarPosition)})
+	}
+	entries = append(entries, frontmatterEntry{key: "slug", value: "/" + slug})
+	if page.Description != "" {
+		entries = append(entries, frontmatterEntry{key: "description", value: page.Description})
+	}
+	tags = slices.DeleteFunc(slices.Clone(tags), func(tag string) bool { return tag == "" })
+	if len(tags) > 0 {
+		entries = append(entries, frontmatterEntry{key: "tags", list: tags})
+	}
+	return entries
+}
+
+// writeFrontmatter writes the entries selected by options as a YAML frontmatter block
+func writeFrontmatter(content *strings.Builder, entries []frontmatterEntry, options FrontmatterOptions) {
+	if options.Fields != nil {
+		entries = slices.DeleteFunc(slices.Clone(entries), func(entry frontmatterEntry) bool {
+			return !slices.Contains(options.Fields, entry.key)
+		})
+	}
+	if len(entries) == 0 {
+		return
+	}
+
+	content.WriteString("---\n")
+	for _, entry := range entries {
+		if entry.list == nil {
+			content.WriteString(fmt.Sprintf("%s: %s\n", entry.key, entry.value))
+			continue
+		}
+		content.WriteString(entry.key + ":\n")
+		for _, item := range entry.list {
+			content.WriteString(fmt.Sprintf("  - %s\n", item))
+		}
+	}
+	content.WriteString("---\n\n")
+}
+
+// languageTags returns sorted tags for the languages of the given source files
+func languageTags(filePaths []string) []string {
+	languages := make(map[string]bool)
+	for _, path := range filePaths {
+		switch filepath.Ext(path) {
+		case ".go":
+			languages["golang"] = true
+		case ".js", ".jsx":
+			languages["javascript"] = true
+		case ".ts", ".tsx":
+			languages["typescript"] = true
+		case ".py":
+			languages["python"] = true
+		case ".java":
+			languages["java"] = true
+		case ".cpp", ".cc", ".cxx":
+			languages["cpp"] = true
+		case ".rs":
+			languages["rust"] = true
+		case ".md":
+			languages["documentation"] = true
+		}
+	}
+
+	tags := make([]string, 0, len(languages))
+	for language := range languages {
+		tags = append(tags, language)
+	}
+	sort.Strings(tags)
+	return tags
+}
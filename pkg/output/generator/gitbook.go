@@ -0,0 +1,186 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kuderr/deepwiki/pkg/generator"
+)
+
+// GitBookGenerator generates a GitBook space with README.md and SUMMARY.md
+type GitBookGenerator struct {
+	markdown *MarkdownGenerator
+}
+
+// NewGitBookGenerator creates a new GitBook generator
+func NewGitBookGenerator() *GitBookGenerator {
+	return &GitBookGenerator{markdown: NewMarkdownGenerator()}
+}
+
+// FormatType returns the format type this generator handles
+func (gg *GitBookGenerator) FormatType() OutputFormat {
+	return FormatGitBook
+}
+
+// Description returns a human-readable description of the format
+func (gg *GitBookGenerator) Description() string {
+	return "GitBook markdown with README.md and a SUMMARY.md navigation tree"
+}
+
+// Generate creates GitBook output files
+func (gg *GitBookGenerator) Generate(
+	structure *generator.WikiStructure,
+	pages map[string]*generator.WikiPage,
+	options OutputOptions,
+) (*OutputResult, error) {
+	startTime := time.Now()
+
+	pagesDir := filepath.Join(options.Directory, "pages")
+	if err := os.MkdirAll(pagesDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create directory structure: %w", err)
+	}
+
+	var filesGenerated []string
+	var totalSize int64
+	var errors []error
+
+	// Generate the introduction and the navigation summary
+	for name, content := range map[string]string{
+		"README.md":  gg.buildReadme(structure),
+		"SUMMARY.md": gg.buildSummary(pages),
+	} {
+		path := filepath.Join(options.Directory, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			errors = append(errors, fmt.Errorf("failed to generate %s: %w", name, err))
+			continue
+		}
+		totalSize += int64(len(content))
+		filesGenerated = append(filesGenerated, path)
+	}
+
+	// Generate individual page files
+	pageFiles, pagesSize, pageErrors := writePages(pages, options.MaxWorkers,
+		func(pageID string, page *generator.WikiPage) (string, error) {
+			pagePath := filepath.Join(options.Directory, filepath.FromSlash(PageURL(FormatGitBook, page)))
+			if err := gg.markdown.generatePage(page, pagePath, structure, options); err != nil {
+				return "", fmt.Errorf("failed to generate page %s: %w", pageID, err)
+			}
+			return pagePath, nil
+		})
+	filesGenerated = append(filesGenerated, pageFiles...)
+	totalSize += pagesSize
+	errors = append(errors, pageErrors...)
+
+	sort.Strings(filesGenerated)
+
+	return &OutputResult{
+		OutputDir:      options.Directory,
+		FilesGenerated: filesGenerated,
+		TotalFiles:     len(filesGenerated),
+		TotalSize:      totalSize,
+		GeneratedAt:    time.Now(),
+		ProcessingTime: time.Since(startTime),
+		Errors:         errors,
+	}, nil
+}
+
+// buildReadme returns the introduction page GitBook shows first
+func (gg *GitBookGenerator) buildReadme(structure *generator.WikiStructure) string {
+	var content strings.Builder
+
+	content.WriteString(fmt.Sprintf("# %s\n\n", structure.Title))
+	if structure.Description != "" {
+		content.WriteString(fmt.Sprintf("%s\n\n", structure.Description))
+	}
+	content.WriteString("Use the navigation to browse the pages of this wiki.\n")
+
+	return content.String()
+}
+
+// buildSummary returns SUMMARY.md: top-level pages are grouped by importance and child pages
+// are nested under their parent, whatever their own importance
+func (gg *GitBookGenerator) buildSummary(pages map[string]*generator.WikiPage) string {
+	children := make(map[string][]*generator.WikiPage)
+	groups := make(map[string][]*generator.WikiPage)
+	for _, page := range pages {
+		if page == nil {
+			continue
+		}
+		if hasValidParent(page, pages) {
+			children[page.ParentID] = append(children[page.ParentID], page)
+			continue
+		}
+		importance := page.Importance
+		if importance == "" {
+			importance = "medium"
+		}
+		groups[importance] = append(groups[importance], page)
+	}
+
+	var content strings.Builder
+	content.WriteString("# Summary\n\n")
+	content.WriteString("* [Introduction](README.md)\n")
+
+	written := make(map[string]bool)
+	var writeItem func(page *generator.WikiPage, depth int)
+	writeItem = func(page *generator.WikiPage, depth int) {
+		if written[page.ID] {
+			return
+		}
+		written[page.ID] = true
+
+		content.WriteString(fmt.Sprintf("%s* [%s](%s)\n",
+			strings.Repeat("  ", depth), summaryTitle(page.Title), PageURL(FormatGitBook, page)))
+		for _, child := range sortedByTitle(children[page.ID]) {
+			writeItem(child, depth+1)
+		}
+	}
+
+	for _, group := range importanceGroups {
+		if len(groups[group.importance]) == 0 {
+			continue
+		}
+		content.WriteString(fmt.Sprintf("\n## %s\n\n", group.caption))
+		for _, page := range sortedByTitle(groups[group.importance]) {
+			writeItem(page, 0)
+		}
+	}
+
+	return content.String()
+}
+
+// hasValidParent reports whether the page's parent exists and its ancestors do not form a cycle
+func hasValidParent(page *generator.WikiPage, pages map[string]*generator.WikiPage) bool {
+	visited := map[string]bool{page.ID: true}
+	for current := page; ; {
+		parent := pages[current.ParentID]
+		if parent == nil {
+			return current != page
+		}
+		if visited[parent.ID] {
+			return false
+		}
+		visited[parent.ID] = true
+		current = parent
+	}
+}
+
+// summaryTitle escapes brackets that would end a SUMMARY.md link label early
+func summaryTitle(title string) string {
+	return strings.NewReplacer("[", `\[`, "]", `\]`).Replace(title)
+}
+
+// sortedByTitle returns pages ordered by title, then ID
+func sortedByTitle(pages []*generator.WikiPage) []*generator.WikiPage {
+	sort.Slice(pages, func(i, j int) bool {
+		if pages[i].Title != pages[j].Title {
+			return pages[i].Title < pages[j].Title
+		}
+		return pages[i].ID < pages[j].ID
+	})
+	return pages
+}
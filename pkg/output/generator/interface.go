@@ -17,6 +17,17 @@ const (
 	FormatDocusaurus3       OutputFormat = "docusaurus3"
 	FormatSimpleDocusaurus2 OutputFormat = "simple-docusaurus2"
 	FormatSimpleDocusaurus3 OutputFormat = "simple-docusaurus3"
+	FormatRST               OutputFormat = "rst"
+	FormatGitBook           OutputFormat = "gitbook"
+)
+
+// ArchiveFormat selects the archive the output directory is packed into
+type ArchiveFormat string
+
+const (
+	ArchiveNone  ArchiveFormat = "none"
+	ArchiveZip   ArchiveFormat = "zip"
+	ArchiveTarGz ArchiveFormat = "tar.gz"
 )
 
 // OutputOptions contains configuration for output generation
@@ -26,6 +37,45 @@ type OutputOptions struct {
 	Language    types.Language `json:"language"`
 	ProjectName string         `json:"projectName"`
 	ProjectPath string         `json:"projectPath"`
+	ToolVersion string         `json:"toolVersion"`
+
+	// SiteURL and BaseURL locate the published site; a sitemap is only written when SiteURL is set
+	SiteURL string `json:"siteUrl,omitempty"`
+	BaseURL string `json:"baseUrl,omitempty"`
+
+	// GenerateSearchIndex writes a client-side search index alongside the pages
+	GenerateSearchIndex bool `json:"generateSearchIndex"`
+
+	// Frontmatter selects the frontmatter keys written at the top of each page
+	Frontmatter FrontmatterOptions `json:"frontmatter"`
+
+	// EmitStructure writes structure.json, a format-independent description of the pages
+	EmitStructure bool `json:"emitStructure"`
+
+	// DirectoryIndexes writes an index page per top-level source directory plus a root index
+	DirectoryIndexes bool `json:"directoryIndexes"`
+
+	// LinkPages links the first mentions of other pages' titles in the content of each page
+	LinkPages bool `json:"linkPages,omitempty"`
+
+	// MaxPageWords and MaxPageBytes limit the content of a page (0 = no limit). Longer pages are
+	// split into continuation pages, or cut with a marker when TruncateLongPages is set.
+	MaxPageWords      int  `json:"maxPageWords,omitempty"`
+	MaxPageBytes      int  `json:"maxPageBytes,omitempty"`
+	TruncateLongPages bool `json:"truncateLongPages,omitempty"`
+
+	// NotionParentPageID exports every page as a child of that Notion page using NotionToken.
+	// NotionAPIURL overrides the Notion API base URL.
+	NotionToken        string `json:"-"`
+	NotionParentPageID string `json:"notionParentPageId,omitempty"`
+	NotionAPIURL       string `json:"notionApiUrl,omitempty"`
+
+	// Archive packs the generated files into <directory>.zip or <directory>.tar.gz next to the
+	// output directory ("" or ArchiveNone = no archive)
+	Archive ArchiveFormat `json:"archive,omitempty"`
+
+	// MaxWorkers bounds concurrent page writes (0 = DefaultMaxWorkers)
+	MaxWorkers int `json:"maxWorkers,omitempty"`
 }
 
 // OutputResult represents the result of output generation
@@ -36,20 +86,22 @@ type OutputResult struct {
 	TotalSize      int64         `json:"totalSize"`
 	GeneratedAt    time.Time     `json:"generatedAt"`
 	ProcessingTime time.Duration `json:"processingTime"`
+	ArchivePath    string        `json:"archivePath,omitempty"`
 	Errors         []error       `json:"errors,omitempty"`
 }
 
 // WikiIndex represents the structure of the wiki index
 type WikiIndex struct {
-	Title       string                 `json:"title"`
-	Description string                 `json:"description"`
-	Pages       []IndexPage            `json:"pages"`
-	Sections    map[string][]IndexPage `json:"sections,omitempty"`
-	GeneratedAt time.Time              `json:"generatedAt"`
-	Version     string                 `json:"version"`
-	Language    types.Language         `json:"language"`
-	ProjectPath string                 `json:"projectPath"`
-	Stats       IndexStats             `json:"stats"`
+	SchemaVersion int                    `json:"schemaVersion"`
+	Title         string                 `json:"title"`
+	Description   string                 `json:"description"`
+	Pages         []IndexPage            `json:"pages"`
+	Sections      map[string][]IndexPage `json:"sections,omitempty"`
+	GeneratedAt   time.Time              `json:"generatedAt"`
+	Version       string                 `json:"version"`
+	Language      types.Language         `json:"language"`
+	ProjectPath   string                 `json:"projectPath"`
+	Stats         IndexStats             `json:"stats"`
 }
 
 // IndexPage represents a page in the wiki index
@@ -75,6 +127,13 @@ type IndexStats struct {
 	LowImportance    int `json:"lowImportance"`
 }
 
+// importanceGroups lists page importance levels in navigation order with their section captions
+var importanceGroups = []struct{ importance, caption string }{
+	{"high", "High Importance"},
+	{"medium", "Medium Importance"},
+	{"low", "Additional Information"},
+}
+
 // NavigationItem represents an item in the navigation structure
 type NavigationItem struct {
 	ID         string
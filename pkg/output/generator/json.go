@@ -10,6 +10,17 @@ import (
 	"github.com/kuderr/deepwiki/pkg/generator"
 )
 
+// JSONSchemaVersion is the version of the shape of wiki.json, index.json and the page files,
+// written as their top-level schemaVersion. It is bumped on any change that can break consumers:
+// a removed or renamed key or a changed value type. See docs/json-output.md.
+const JSONSchemaVersion = 1
+
+// jsonPage is the content of a page file: the page with the schema version
+type jsonPage struct {
+	SchemaVersion int `json:"schemaVersion"`
+	*generator.WikiPage
+}
+
 // JSONGenerator generates JSON output files
 type JSONGenerator struct{}
 
@@ -47,8 +58,9 @@ func (jg *JSONGenerator) Generate(
 
 	// Generate main wiki JSON file
 	wikiData := map[string]interface{}{
-		"structure": structure,
-		"pages":     pages,
+		"schemaVersion": JSONSchemaVersion,
+		"structure":     structure,
+		"pages":         pages,
 		"metadata": map[string]interface{}{
 			"generatedAt": time.Now(),
 			"projectName": options.ProjectName,
@@ -73,20 +85,18 @@ func (jg *JSONGenerator) Generate(
 	if err := os.MkdirAll(pagesDir, 0o755); err != nil {
 		errors = append(errors, fmt.Errorf("failed to create pages directory: %w", err))
 	} else {
-		for pageID, page := range pages {
-			fileName := pageID + ".json"
-			pagePath := filepath.Join(pagesDir, fileName)
-
-			if err := jg.writeJSONFile(page, pagePath); err != nil {
-				errors = append(errors, fmt.Errorf("failed to generate page JSON %s: %w", pageID, err))
-				continue
-			}
-
-			if stat, err := os.Stat(pagePath); err == nil {
-				totalSize += stat.Size()
-			}
-			filesGenerated = append(filesGenerated, pagePath)
-		}
+		pageFiles, pagesSize, pageErrors := writePages(pages, options.MaxWorkers,
+			func(pageID string, page *generator.WikiPage) (string, error) {
+				pagePath := filepath.Join(pagesDir, pageID+".json")
+				data := jsonPage{SchemaVersion: JSONSchemaVersion, WikiPage: page}
+				if err := jg.writeJSONFile(data, pagePath); err != nil {
+					return "", fmt.Errorf("failed to generate page JSON %s: %w", pageID, err)
+				}
+				return pagePath, nil
+			})
+		filesGenerated = append(filesGenerated, pageFiles...)
+		totalSize += pagesSize
+		errors = append(errors, pageErrors...)
 	}
 
 	// Generate index JSON
@@ -150,14 +160,15 @@ func (jg *JSONGenerator) generateIndex(
 	}
 
 	index := WikiIndex{
-		Title:       structure.Title,
-		Description: structure.Description,
-		Pages:       indexPages,
-		GeneratedAt: time.Now(),
-		Version:     structure.Version,
-		Language:    options.Language,
-		ProjectPath: options.ProjectPath,
-		Stats:       stats,
+		SchemaVersion: JSONSchemaVersion,
+		Title:         structure.Title,
+		Description:   structure.Description,
+		Pages:         indexPages,
+		GeneratedAt:   time.Now(),
+		Version:       structure.Version,
+		Language:      options.Language,
+		ProjectPath:   options.ProjectPath,
+		Stats:         stats,
 	}
 
 	return jg.writeJSONFile(index, filePath)
@@ -59,19 +59,24 @@ func (mg *MarkdownGenerator) Generate(
 
 	// Generate individual page files
 	pagesDir := filepath.Join(options.Directory, "pages")
-	for pageID, page := range pages {
-		fileName := mg.sanitizeFileName(page.Title) + ".md"
-		pagePath := filepath.Join(pagesDir, fileName)
-
-		if err := mg.generatePage(page, pagePath, structure, options); err != nil {
-			errors = append(errors, fmt.Errorf("failed to generate page %s: %w", pageID, err))
-			continue
-		}
-
-		if stat, err := os.Stat(pagePath); err == nil {
-			totalSize += stat.Size()
-		}
-		filesGenerated = append(filesGenerated, pagePath)
+	pageFiles, pagesSize, pageErrors := writePages(pages, options.MaxWorkers,
+		func(pageID string, page *generator.WikiPage) (string, error) {
+			pagePath := filepath.Join(pagesDir, PageFileName(page)+".md")
+			if err := mg.generatePage(page, pagePath, structure, options); err != nil {
+				return "", fmt.Errorf("failed to generate page %s: %w", pageID, err)
+			}
+			return pagePath, nil
+		})
+	filesGenerated = append(filesGenerated, pageFiles...)
+	totalSize += pagesSize
+	errors = append(errors, pageErrors...)
+
+	// Generate per-directory index pages
+	if options.DirectoryIndexes {
+		indexFiles, indexSize, indexErrors := writeDirectoryIndexes(pages, mg.directoryIndexLayout(options.Directory))
+		filesGenerated = append(filesGenerated, indexFiles...)
+		totalSize += indexSize
+		errors = append(errors, indexErrors...)
 	}
 
 	// Generate wiki structure JSON for reference
@@ -148,7 +153,7 @@ func (mg *MarkdownGenerator) generateIndex(
 	if len(importanceGroups["high"]) > 0 {
 		content.WriteString("### 🔥 High Importance\n\n")
 		for _, page := range importanceGroups["high"] {
-			fileName := mg.sanitizeFileName(page.Title) + ".md"
+			fileName := PageFileName(page) + ".md"
 			content.WriteString(fmt.Sprintf("- [%s](pages/%s) - %s\n", page.Title, fileName, page.Description))
 		}
 		content.WriteString("\n")
@@ -158,7 +163,7 @@ func (mg *MarkdownGenerator) generateIndex(
 	if len(importanceGroups["medium"]) > 0 {
 		content.WriteString("### 📋 Medium Importance\n\n")
 		for _, page := range importanceGroups["medium"] {
-			fileName := mg.sanitizeFileName(page.Title) + ".md"
+			fileName := PageFileName(page) + ".md"
 			content.WriteString(fmt.Sprintf("- [%s](pages/%s) - %s\n", page.Title, fileName, page.Description))
 		}
 		content.WriteString("\n")
@@ -168,12 +173,18 @@ func (mg *MarkdownGenerator) generateIndex(
 	if len(importanceGroups["low"]) > 0 {
 		content.WriteString("### 📝 Additional Information\n\n")
 		for _, page := range importanceGroups["low"] {
-			fileName := mg.sanitizeFileName(page.Title) + ".md"
+			fileName := PageFileName(page) + ".md"
 			content.WriteString(fmt.Sprintf("- [%s](pages/%s) - %s\n", page.Title, fileName, page.Description))
 		}
 		content.WriteString("\n")
 	}
 
+	// Link the directory indexes
+	if options.DirectoryIndexes {
+		content.WriteString("## 📁 Directories\n\n")
+		content.WriteString("- [Browse pages by directory](directories/index.md)\n\n")
+	}
+
 	return os.WriteFile(filePath, []byte(content.String()), 0o644)
 }
 
@@ -185,6 +196,13 @@ func (mg *MarkdownGenerator) generatePage(
 ) error {
 	var content strings.Builder
 
+	// Markdown pages only carry frontmatter when fields are selected
+	if len(options.Frontmatter.Fields) > 0 {
+		slug := strings.TrimSuffix(filepath.Base(filePath), ".md")
+		frontmatter := pageFrontmatter(page, slug, 0, languageTags(page.FilePaths))
+		writeFrontmatter(&content, frontmatter, options.Frontmatter)
+	}
+
 	// Write header
 	content.WriteString(fmt.Sprintf("# %s\n\n", page.Title))
 
@@ -226,32 +244,15 @@ func (mg *MarkdownGenerator) writeJSONFile(data interface{}, filePath string) er
 	return os.WriteFile(filePath, jsonData, 0o644)
 }
 
-func (mg *MarkdownGenerator) sanitizeFileName(name string) string {
-	// Basic sanitization
-	result := strings.ToLower(name)
-	result = strings.ReplaceAll(result, " ", "-")
-	result = strings.ReplaceAll(result, "/", "-")
-	result = strings.ReplaceAll(result, "\\", "-")
-	result = strings.ReplaceAll(result, ":", "-")
-	result = strings.ReplaceAll(result, "*", "-")
-	result = strings.ReplaceAll(result, "?", "-")
-	result = strings.ReplaceAll(result, "\"", "-")
-	result = strings.ReplaceAll(result, "<", "-")
-	result = strings.ReplaceAll(result, ">", "-")
-	result = strings.ReplaceAll(result, "|", "-")
-
-	// Remove multiple consecutive dashes
-	for strings.Contains(result, "--") {
-		result = strings.ReplaceAll(result, "--", "-")
-	}
-
-	// Trim dashes from start and end
-	result = strings.Trim(result, "-")
-
-	// Ensure not empty
-	if result == "" {
-		result = "untitled"
+// directoryIndexLayout writes directory indexes to directories/ next to the pages directory
+func (mg *MarkdownGenerator) directoryIndexLayout(outputDir string) directoryIndexLayout {
+	return directoryIndexLayout{
+		dir: filepath.Join(outputDir, "directories"),
+		pageLink: func(page *generator.WikiPage) string {
+			return "../pages/" + PageFileName(page) + ".md"
+		},
+		indexLink: func(slug string) string {
+			return slug + ".md"
+		},
 	}
-
-	return result
 }
@@ -0,0 +1,30 @@
+package generator
+
+import (
+	"github.com/kuderr/deepwiki/pkg/generator"
+)
+
+// SanitizeFileName converts a page title into a file-system and URL safe name
+func SanitizeFileName(name string) string {
+	return generator.PageSlug(name)
+}
+
+// PageFileName returns the file-system and URL safe name of the files of a page
+func PageFileName(page *generator.WikiPage) string {
+	return generator.PageFileSlug(page)
+}
+
+// PageURL returns the location of a rendered page relative to the site or output root
+func PageURL(format OutputFormat, page *generator.WikiPage) string {
+	switch format {
+	case FormatJSON:
+		return "pages/" + page.ID + ".json"
+	case FormatDocusaurus2, FormatDocusaurus3, FormatSimpleDocusaurus2, FormatSimpleDocusaurus3:
+		// Docusaurus docs are served from the site root using the page slug
+		return "/" + PageFileName(page)
+	case FormatRST:
+		return "pages/" + PageFileName(page) + ".rst"
+	default:
+		return "pages/" + PageFileName(page) + ".md"
+	}
+}
@@ -0,0 +1,468 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/kuderr/deepwiki/pkg/generator"
+)
+
+// RSTGenerator generates reStructuredText output files for Sphinx
+type RSTGenerator struct{}
+
+// NewRSTGenerator creates a new reStructuredText generator
+func NewRSTGenerator() *RSTGenerator {
+	return &RSTGenerator{}
+}
+
+// FormatType returns the format type this generator handles
+func (rg *RSTGenerator) FormatType() OutputFormat {
+	return FormatRST
+}
+
+// Description returns a human-readable description of the format
+func (rg *RSTGenerator) Description() string {
+	return "reStructuredText files with a Sphinx toctree index"
+}
+
+// Generate creates reStructuredText output files
+func (rg *RSTGenerator) Generate(
+	structure *generator.WikiStructure,
+	pages map[string]*generator.WikiPage,
+	options OutputOptions,
+) (*OutputResult, error) {
+	startTime := time.Now()
+
+	pagesDir := filepath.Join(options.Directory, "pages")
+	if err := os.MkdirAll(pagesDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create directory structure: %w", err)
+	}
+
+	var filesGenerated []string
+	var totalSize int64
+	var errors []error
+
+	// Generate the toctree index and a minimal Sphinx configuration
+	for name, write := range map[string]func(string) error{
+		"index.rst": func(path string) error { return rg.generateIndex(structure, pages, path) },
+		"conf.py":   func(path string) error { return rg.generateConfig(structure, options, path) },
+	} {
+		path := filepath.Join(options.Directory, name)
+		if err := write(path); err != nil {
+			errors = append(errors, fmt.Errorf("failed to generate %s: %w", name, err))
+			continue
+		}
+		if stat, err := os.Stat(path); err == nil {
+			totalSize += stat.Size()
+		}
+		filesGenerated = append(filesGenerated, path)
+	}
+
+	// Generate individual page files
+	pageFiles, pagesSize, pageErrors := writePages(pages, options.MaxWorkers,
+		func(pageID string, page *generator.WikiPage) (string, error) {
+			pagePath := filepath.Join(pagesDir, PageFileName(page)+".rst")
+			if err := os.WriteFile(pagePath, []byte(rg.renderPage(page)), 0o644); err != nil {
+				return "", fmt.Errorf("failed to generate page %s: %w", pageID, err)
+			}
+			return pagePath, nil
+		})
+	filesGenerated = append(filesGenerated, pageFiles...)
+	totalSize += pagesSize
+	errors = append(errors, pageErrors...)
+
+	sort.Strings(filesGenerated)
+
+	return &OutputResult{
+		OutputDir:      options.Directory,
+		FilesGenerated: filesGenerated,
+		TotalFiles:     len(filesGenerated),
+		TotalSize:      totalSize,
+		GeneratedAt:    time.Now(),
+		ProcessingTime: time.Since(startTime),
+		Errors:         errors,
+	}, nil
+}
+
+// generateIndex writes index.rst with one toctree per importance group
+func (rg *RSTGenerator) generateIndex(
+	structure *generator.WikiStructure,
+	pages map[string]*generator.WikiPage,
+	filePath string,
+) error {
+	var content strings.Builder
+
+	content.WriteString(rstTitle(structure.Title))
+	if structure.Description != "" {
+		content.WriteString(convertInline(structure.Description) + "\n\n")
+	}
+
+	groups := map[string][]*generator.WikiPage{}
+	for _, page := range pages {
+		importance := page.Importance
+		if importance == "" {
+			importance = "medium"
+		}
+		groups[importance] = append(groups[importance], page)
+	}
+
+	for _, group := range importanceGroups {
+		groupPages := groups[group.importance]
+		if len(groupPages) == 0 {
+			continue
+		}
+		sort.Slice(groupPages, func(i, j int) bool {
+			return groupPages[i].Title < groupPages[j].Title
+		})
+
+		content.WriteString(".. toctree::\n")
+		content.WriteString("   :maxdepth: 2\n")
+		content.WriteString(fmt.Sprintf("   :caption: %s\n\n", group.caption))
+		for _, page := range groupPages {
+			content.WriteString(fmt.Sprintf("   pages/%s\n", PageFileName(page)))
+		}
+		content.WriteString("\n")
+	}
+
+	return os.WriteFile(filePath, []byte(content.String()), 0o644)
+}
+
+// generateConfig writes the conf.py needed to build the output with sphinx-build
+func (rg *RSTGenerator) generateConfig(
+	structure *generator.WikiStructure,
+	options OutputOptions,
+	filePath string,
+) error {
+	project := options.ProjectName
+	if project == "" {
+		project = structure.Title
+	}
+
+	var content strings.Builder
+	content.WriteString("# Sphinx configuration generated by deepwiki\n\n")
+	content.WriteString(fmt.Sprintf("project = %q\n", project))
+	content.WriteString("root_doc = \"index\"\n")
+	content.WriteString("extensions = []\n")
+	content.WriteString("exclude_patterns = [\"_build\"]\n")
+
+	return os.WriteFile(filePath, []byte(content.String()), 0o644)
+}
+
+// renderPage renders a page as a reStructuredText document
+func (rg *RSTGenerator) renderPage(page *generator.WikiPage) string {
+	var content strings.Builder
+
+	content.WriteString(rstTitle(page.Title))
+	if page.Description != "" {
+		content.WriteString("*" + strings.TrimSpace(page.Description) + "*\n\n")
+	}
+	content.WriteString(MarkdownToRST(page.Content))
+
+	return content.String()
+}
+
+// rstSectionAdornments are the underline characters for markdown heading levels 1-6, in order of depth.
+// The page title uses "=" with an overline, which Sphinx treats as a distinct, higher level.
+var rstSectionAdornments = []byte{'=', '-', '~', '^', '"', '\''}
+
+var (
+	mdHeadingPattern   = regexp.MustCompile(`^(#{1,6})\s+(.+?)(?:\s+#+)?\s*$`)
+	mdFencePattern     = regexp.MustCompile("^(\\s*)(```+|~~~+)\\s*([\\w+#.-]*)")
+	mdTableRowPattern  = regexp.MustCompile(`^\s*\|.*\|\s*$`)
+	mdTableSepPattern  = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	mdRulePattern      = regexp.MustCompile(`^\s*([-*_])(\s*([-*_]))(\s*([-*_]))+\s*$`)
+	mdImageLinePattern = regexp.MustCompile(`^\s*!\[([^\]]*)\]\(([^)\s]+)[^)]*\)\s*$`)
+	mdImagePattern     = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)[^)]*\)`)
+	mdLinkPattern      = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)[^)]*\)`)
+	mdListItemPattern  = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+`)
+)
+
+// rstTitle renders a document title with a matching overline and underline
+func rstTitle(title string) string {
+	title = strings.TrimSpace(title)
+	adornment := strings.Repeat("=", max(rstWidth(title), 1))
+	return adornment + "\n" + title + "\n" + adornment + "\n\n"
+}
+
+// rstSection renders a section title underlined with the adornment for the given depth (0-based)
+func rstSection(title string, depth int) string {
+	depth = min(depth, len(rstSectionAdornments)-1)
+	return title + "\n" + strings.Repeat(string(rstSectionAdornments[depth]), max(rstWidth(title), 1)) + "\n\n"
+}
+
+// rstWidth returns the display width of text, counting East Asian wide characters and emoji as two
+// columns as docutils does when checking that an adornment is long enough
+func rstWidth(text string) int {
+	width := 0
+	for _, r := range text {
+		switch {
+		case r >= 0x1100 && r <= 0x115F, r >= 0x2E80 && r <= 0xA4CF, r >= 0xAC00 && r <= 0xD7A3,
+			r >= 0xF900 && r <= 0xFAFF, r >= 0xFE30 && r <= 0xFE4F, r >= 0xFF00 && r <= 0xFF60,
+			r >= 0xFFE0 && r <= 0xFFE6, r >= 0x1F300 && r <= 0x1FAFF, r >= 0x20000 && r <= 0x3FFFD:
+			width += 2
+		default:
+			width++
+		}
+	}
+	return width
+}
+
+// MarkdownToRST converts the markdown produced for wiki pages to reStructuredText. Headings become
+// underlined section titles relative to the shallowest heading on the page, fenced code becomes
+// code-block directives, pipe tables become list-table directives and inline links, images and
+// code spans are rewritten to their reStructuredText forms.
+func MarkdownToRST(markdown string) string {
+	lines := strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n")
+
+	// Sections are numbered from the shallowest heading outside code blocks
+	minLevel := 7
+	inFence := false
+	for _, line := range lines {
+		if mdFencePattern.MatchString(line) {
+			inFence = !inFence
+			continue
+		}
+		if match := mdHeadingPattern.FindStringSubmatch(line); match != nil && !inFence {
+			minLevel = min(minLevel, len(match[1]))
+		}
+	}
+
+	var out strings.Builder
+	blank := true // whether the last emitted line was blank
+	prev := ""    // kind of the previous line: "paragraph", "list", "quote" or "" after a blank line or block
+	listIndent := 0
+	emit := func(text string) {
+		if text == "" {
+			if !blank {
+				out.WriteString("\n")
+			}
+			blank = true
+			return
+		}
+		out.WriteString(text + "\n")
+		blank = strings.HasSuffix(text, "\n")
+	}
+	block := func(text string) {
+		emit("")
+		out.WriteString(text)
+		blank = strings.HasSuffix(text, "\n\n")
+		emit("")
+		prev = ""
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if match := mdFencePattern.FindStringSubmatch(line); match != nil {
+			indent, fence, language := match[1], match[2], match[3]
+			if language == "" {
+				language = "text"
+			}
+			var code []string
+			for i++; i < len(lines); i++ {
+				if strings.HasPrefix(strings.TrimSpace(lines[i]), fence[:3]) {
+					break
+				}
+				code = append(code, strings.TrimPrefix(lines[i], indent))
+			}
+			block(rstCodeBlock(language, code, indent))
+			continue
+		}
+
+		if match := mdHeadingPattern.FindStringSubmatch(line); match != nil {
+			block(rstSection(convertInline(match[2]), len(match[1])-minLevel))
+			continue
+		}
+
+		if mdTableRowPattern.MatchString(line) && i+1 < len(lines) && mdTableSepPattern.MatchString(lines[i+1]) {
+			rows := [][]string{splitTableRow(line)}
+			for i += 2; i < len(lines) && mdTableRowPattern.MatchString(lines[i]); i++ {
+				rows = append(rows, splitTableRow(lines[i]))
+			}
+			i--
+			block(rstListTable(rows))
+			continue
+		}
+
+		if mdRulePattern.MatchString(line) {
+			block("----\n")
+			continue
+		}
+
+		if match := mdImageLinePattern.FindStringSubmatch(line); match != nil {
+			image := ".. image:: " + match[2] + "\n"
+			if match[1] != "" {
+				image += "   :alt: " + match[1] + "\n"
+			}
+			block(image)
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			emit("")
+			prev = ""
+			continue
+		}
+
+		// Block quotes and lists must be separated from surrounding paragraphs by a blank line
+		if quote, ok := strings.CutPrefix(strings.TrimLeft(line, " "), ">"); ok {
+			if prev != "quote" {
+				emit("")
+			}
+			if quote = strings.TrimSpace(quote); quote == "" {
+				emit("")
+			} else {
+				emit("   " + convertInline(quote))
+			}
+			prev = "quote"
+			continue
+		}
+
+		if match := mdListItemPattern.FindStringSubmatch(line); match != nil {
+			if prev != "list" || len(match[1]) != listIndent {
+				emit("")
+			}
+			emit(convertInline(line))
+			prev, listIndent = "list", len(match[1])
+			continue
+		}
+
+		if prev == "quote" || (prev == "list" && !strings.HasPrefix(line, " ")) {
+			emit("")
+		}
+		emit(convertInline(line))
+		if prev != "list" {
+			prev = "paragraph"
+		}
+	}
+
+	return strings.TrimRight(out.String(), "\n") + "\n"
+}
+
+// rstCodeBlock renders fenced code lines as a code-block directive
+func rstCodeBlock(language string, code []string, indent string) string {
+	var block strings.Builder
+	block.WriteString(indent + ".. code-block:: " + language + "\n\n")
+	for _, line := range code {
+		if strings.TrimSpace(line) == "" {
+			block.WriteString("\n")
+			continue
+		}
+		block.WriteString(indent + "   " + line + "\n")
+	}
+	return block.String()
+}
+
+// splitTableRow returns the trimmed cells of a markdown pipe table row
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimSuffix(strings.TrimPrefix(line, "|"), "|")
+	cells := strings.Split(line, "|")
+	for i, cell := range cells {
+		cells[i] = convertInline(strings.TrimSpace(cell))
+	}
+	return cells
+}
+
+// rstListTable renders table rows as a list-table directive with the first row as header
+func rstListTable(rows [][]string) string {
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+
+	var table strings.Builder
+	table.WriteString(".. list-table::\n")
+	table.WriteString("   :header-rows: 1\n\n")
+	for _, row := range rows {
+		for col := range columns {
+			cell := ""
+			if col < len(row) {
+				cell = row[col]
+			}
+			prefix := "     - "
+			if col == 0 {
+				prefix = "   * - "
+			}
+			table.WriteString(strings.TrimRight(prefix+cell, " ") + "\n")
+		}
+	}
+	return table.String()
+}
+
+// convertInline rewrites inline markdown outside code spans: links and images become hyperlink
+// references and code spans use double backquotes
+func convertInline(text string) string {
+	var result strings.Builder
+	parts := strings.Split(text, "`")
+	for i, part := range parts {
+		if i%2 == 0 || i == len(parts)-1 {
+			// Text outside code spans, including a trailing unmatched backquote
+			if i%2 == 1 {
+				result.WriteString("`")
+			}
+			part = replaceInline(part, mdImagePattern)
+			result.WriteString(replaceInline(part, mdLinkPattern))
+			continue
+		}
+		if part == "" {
+			continue
+		}
+		code := "``" + part + "``"
+		if i > 0 && endsWithWordChar(parts[i-1]) {
+			code = `\ ` + code
+		}
+		if i+1 < len(parts) && startsWithWordChar(parts[i+1]) {
+			code += `\ `
+		}
+		result.WriteString(code)
+	}
+	return result.String()
+}
+
+// replaceInline rewrites the [text](target) links matched by pattern as hyperlink references
+func replaceInline(text string, pattern *regexp.Regexp) string {
+	var result strings.Builder
+	last := 0
+	for _, match := range pattern.FindAllStringSubmatchIndex(text, -1) {
+		before, after := text[last:match[0]], text[match[1]:]
+		result.WriteString(before)
+		if endsWithWordChar(text[:match[0]]) {
+			result.WriteString(`\ `)
+		}
+		label, target := text[match[2]:match[3]], text[match[4]:match[5]]
+		if label == "" {
+			label = target
+		}
+		result.WriteString("`" + label + " <" + target + ">`__")
+		if startsWithWordChar(after) {
+			result.WriteString(`\ `)
+		}
+		last = match[1]
+	}
+	result.WriteString(text[last:])
+	return result.String()
+}
+
+// startsWithWordChar and endsWithWordChar report whether inline markup next to text would be
+// glued to a word, which reStructuredText does not recognize as markup
+func startsWithWordChar(text string) bool {
+	r, _ := utf8.DecodeRuneInString(text)
+	return isWordChar(r)
+}
+
+func endsWithWordChar(text string) bool {
+	r, _ := utf8.DecodeLastRuneInString(text)
+	return isWordChar(r)
+}
+
+func isWordChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
@@ -64,19 +64,38 @@ func (sdg *SimpleDocusaurus3Generator) Generate(
 	}
 
 	// Generate individual page files with proper navigation
-	for pageID, page := range pages {
-		fileName := sdg.sanitizeFileName(page.Title) + ".md"
-		pagePath := filepath.Join(docsDir, fileName)
-
-		if err := sdg.generatePage(page, pagePath, structure, options, navStructure); err != nil {
-			errors = append(errors, fmt.Errorf("failed to generate page %s: %w", pageID, err))
-			continue
-		}
+	pageFiles, pagesSize, pageErrors := writePages(pages, options.MaxWorkers,
+		func(pageID string, page *generator.WikiPage) (string, error) {
+			pagePath := filepath.Join(docsDir, PageFileName(page)+".md")
+			if err := sdg.generatePage(page, pagePath, structure, options, navStructure); err != nil {
+				return "", fmt.Errorf("failed to generate page %s: %w", pageID, err)
+			}
+			return pagePath, nil
+		})
+	filesGenerated = append(filesGenerated, pageFiles...)
+	totalSize += pagesSize
+	errors = append(errors, pageErrors...)
+
+	// Generate per-directory index pages
+	if options.DirectoryIndexes {
+		layout := docusaurusDirectoryIndexLayout(options.Directory)
+		indexFiles, indexSize, indexErrors := writeDirectoryIndexes(pages, layout)
+		filesGenerated = append(filesGenerated, indexFiles...)
+		totalSize += indexSize
+		errors = append(errors, indexErrors...)
+	}
 
-		if stat, err := os.Stat(pagePath); err == nil {
-			totalSize += stat.Size()
+	// Generate sitemap.xml when the site location is known
+	if options.SiteURL != "" {
+		sitemapPath := filepath.Join(options.Directory, "sitemap.xml")
+		if err := writeSitemap(pages, options, sitemapPath); err != nil {
+			errors = append(errors, fmt.Errorf("failed to generate sitemap: %w", err))
+		} else {
+			if stat, err := os.Stat(sitemapPath); err == nil {
+				totalSize += stat.Size()
+			}
+			filesGenerated = append(filesGenerated, sitemapPath)
 		}
-		filesGenerated = append(filesGenerated, pagePath)
 	}
 
 	return &OutputResult{
@@ -106,7 +125,7 @@ func (sdg *SimpleDocusaurus3Generator) buildNavigationStructure(
 		item := NavigationItem{
 			ID:         page.ID,
 			Title:      page.Title,
-			FileName:   sdg.sanitizeFileName(page.Title),
+			FileName:   PageFileName(page),
 			Importance: importance,
 		}
 
@@ -186,6 +205,12 @@ func (sdg *SimpleDocusaurus3Generator) generateIntro(
 		content.WriteString("\n")
 	}
 
+	// Link the directory indexes
+	if options.DirectoryIndexes {
+		content.WriteString("## 📁 Directories\n\n")
+		content.WriteString("- [Browse pages by directory](/directories)\n\n")
+	}
+
 	return os.WriteFile(filePath, []byte(content.String()), 0o644)
 }
 
@@ -235,49 +260,10 @@ func (sdg *SimpleDocusaurus3Generator) generatePage(
 	// Generate slug from filename
 	fileName := strings.TrimSuffix(filepath.Base(filePath), ".md")
 
-	// Write enhanced Docusaurus v3 frontmatter with navigation
-	content.WriteString("---\n")
-	content.WriteString(fmt.Sprintf("id: %s\n", page.ID))
-	content.WriteString(fmt.Sprintf("title: %s\n", page.Title))
-	content.WriteString(fmt.Sprintf("sidebar_position: %d\n", sidebarPosition))
-	content.WriteString(fmt.Sprintf("slug: /%s\n", fileName))
-	if page.Description != "" {
-		content.WriteString(fmt.Sprintf("description: %s\n", page.Description))
-	}
-
-	// Add tags based on file extensions and importance
-	content.WriteString("tags:\n")
-	content.WriteString(fmt.Sprintf("  - %s\n", page.Importance))
-
-	if len(page.FilePaths) > 0 {
-		// Add language tags based on file extensions
-		languages := make(map[string]bool)
-		for _, path := range page.FilePaths {
-			ext := filepath.Ext(path)
-			switch ext {
-			case ".go":
-				languages["golang"] = true
-			case ".js", ".jsx":
-				languages["javascript"] = true
-			case ".ts", ".tsx":
-				languages["typescript"] = true
-			case ".py":
-				languages["python"] = true
-			case ".java":
-				languages["java"] = true
-			case ".cpp", ".cc", ".cxx":
-				languages["cpp"] = true
-			case ".rs":
-				languages["rust"] = true
-			case ".md":
-				languages["documentation"] = true
-			}
-		}
-		for lang := range languages {
-			content.WriteString(fmt.Sprintf("  - %s\n", lang))
-		}
-	}
-	content.WriteString("---\n\n")
+	// Write enhanced Docusaurus v3 frontmatter with navigation, tagged by importance and languages
+	tags := append([]string{page.Importance}, languageTags(page.FilePaths)...)
+	frontmatter := pageFrontmatter(page, fileName, sidebarPosition, tags)
+	writeFrontmatter(&content, frontmatter, options.Frontmatter)
 
 	// Write description if available
 	if page.Description != "" {
@@ -290,33 +276,3 @@ func (sdg *SimpleDocusaurus3Generator) generatePage(
 
 	return os.WriteFile(filePath, []byte(content.String()), 0o644)
 }
-
-func (sdg *SimpleDocusaurus3Generator) sanitizeFileName(name string) string {
-	// Basic sanitization for Docusaurus-compatible filenames
-	result := strings.ToLower(name)
-	result = strings.ReplaceAll(result, " ", "-")
-	result = strings.ReplaceAll(result, "/", "-")
-	result = strings.ReplaceAll(result, "\\", "-")
-	result = strings.ReplaceAll(result, ":", "-")
-	result = strings.ReplaceAll(result, "*", "-")
-	result = strings.ReplaceAll(result, "?", "-")
-	result = strings.ReplaceAll(result, "\"", "-")
-	result = strings.ReplaceAll(result, "<", "-")
-	result = strings.ReplaceAll(result, ">", "-")
-	result = strings.ReplaceAll(result, "|", "-")
-
-	// Remove multiple consecutive dashes
-	for strings.Contains(result, "--") {
-		result = strings.ReplaceAll(result, "--", "-")
-	}
-
-	// Trim dashes from start and end
-	result = strings.Trim(result, "-")
-
-	// Ensure not empty
-	if result == "" {
-		result = "untitled"
-	}
-
-	return result
-}
@@ -0,0 +1,83 @@
+package generator
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/kuderr/deepwiki/pkg/generator"
+)
+
+const sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// Sitemap is the root element of a sitemap.xml document
+type Sitemap struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []SitemapURL `xml:"url"`
+}
+
+// SitemapURL is a single page entry in a sitemap
+type SitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// SiteBase returns the absolute site prefix built from SiteURL and BaseURL, always ending in "/"
+func SiteBase(options OutputOptions) string {
+	site := strings.TrimRight(options.SiteURL, "/")
+	base := strings.Trim(options.BaseURL, "/")
+	if base == "" {
+		return site + "/"
+	}
+	return site + "/" + base + "/"
+}
+
+// SiteBaseURL returns the site pathname in Docusaurus form, e.g. "/" or "/docs/"
+func SiteBaseURL(options OutputOptions) string {
+	base := strings.Trim(options.BaseURL, "/")
+	if base == "" {
+		return "/"
+	}
+	return "/" + base + "/"
+}
+
+// buildSitemap creates a sitemap with one entry per page, ordered by location
+func buildSitemap(pages map[string]*generator.WikiPage, options OutputOptions) *Sitemap {
+	siteBase := SiteBase(options)
+	sitemap := &Sitemap{
+		Xmlns: sitemapNamespace,
+		URLs:  make([]SitemapURL, 0, len(pages)),
+	}
+
+	for _, page := range pages {
+		entry := SitemapURL{
+			Loc: siteBase + PageFileName(page),
+		}
+		if !page.CreatedAt.IsZero() {
+			entry.LastMod = page.CreatedAt.UTC().Format("2006-01-02")
+		}
+		sitemap.URLs = append(sitemap.URLs, entry)
+	}
+
+	sort.Slice(sitemap.URLs, func(i, j int) bool {
+		return sitemap.URLs[i].Loc < sitemap.URLs[j].Loc
+	})
+
+	return sitemap
+}
+
+// writeSitemap writes sitemap.xml for pages to filePath
+func writeSitemap(pages map[string]*generator.WikiPage, options OutputOptions, filePath string) error {
+	data, err := xml.MarshalIndent(buildSitemap(pages, options), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal sitemap: %w", err)
+	}
+
+	content := append([]byte(xml.Header), data...)
+	content = append(content, '\n')
+
+	return os.WriteFile(filePath, content, 0o644)
+}
@@ -0,0 +1,80 @@
+package generator
+
+import (
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/kuderr/deepwiki/pkg/generator"
+)
+
+// DefaultMaxWorkers is the number of pages written concurrently when OutputOptions.MaxWorkers is unset
+const DefaultMaxWorkers = 8
+
+// pageWriteFunc renders a single page and returns the path it was written to
+type pageWriteFunc func(pageID string, page *generator.WikiPage) (string, error)
+
+// pageWriteResult holds the outcome of writing one page
+type pageWriteResult struct {
+	path string
+	size int64
+	err  error
+}
+
+// writePages writes every page with a bounded pool of workers. Each worker stores its result
+// in the slot of its page, so the returned files and errors follow page ID order regardless
+// of which write finishes first.
+func writePages(
+	pages map[string]*generator.WikiPage,
+	maxWorkers int,
+	write pageWriteFunc,
+) (filesGenerated []string, totalSize int64, errors []error) {
+	pageIDs := make([]string, 0, len(pages))
+	for pageID := range pages {
+		pageIDs = append(pageIDs, pageID)
+	}
+	sort.Strings(pageIDs)
+
+	if maxWorkers <= 0 {
+		maxWorkers = DefaultMaxWorkers
+	}
+	maxWorkers = min(maxWorkers, len(pageIDs))
+
+	results := make([]pageWriteResult, len(pageIDs))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for range maxWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				path, err := write(pageIDs[i], pages[pageIDs[i]])
+				results[i] = pageWriteResult{path: path, err: err}
+				if err != nil {
+					continue
+				}
+				if stat, statErr := os.Stat(path); statErr == nil {
+					results[i].size = stat.Size()
+				}
+			}
+		}()
+	}
+
+	for i := range pageIDs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, result := range results {
+		if result.err != nil {
+			errors = append(errors, result.err)
+			continue
+		}
+		totalSize += result.size
+		filesGenerated = append(filesGenerated, result.path)
+	}
+
+	return filesGenerated, totalSize, errors
+}
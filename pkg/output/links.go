@@ -0,0 +1,190 @@
+package output
+
+import (
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/kuderr/deepwiki/pkg/generator"
+	outputgen "github.com/kuderr/deepwiki/pkg/output/generator"
+)
+
+const (
+	// maxPageLinks is the number of links to other pages inserted into a page at most
+	maxPageLinks = 5
+
+	// minLinkTitleLength is the number of characters a title needs for its mentions to be linked
+	minLinkTitleLength = 4
+)
+
+// protectedSpanPattern matches inline code, links, HTML tags and URLs, in which mentions of a page
+// are left as they are
+var protectedSpanPattern = regexp.MustCompile("`[^`]*`|!?\\[[^\\]]*\\]\\([^)]*\\)|<[^>]+>|https?://\\S+")
+
+// linkTarget is a page whose title is linked where another page mentions it
+type linkTarget struct {
+	id      string
+	title   string
+	file    string
+	pattern *regexp.Regexp
+	related bool
+}
+
+// linksPages reports whether the pages of a format are markdown files side by side, so that a page
+// reaches another as ./<slug>.md
+func linksPages(format outputgen.OutputFormat) bool {
+	switch format {
+	case outputgen.FormatMarkdown, outputgen.FormatGitBook,
+		outputgen.FormatDocusaurus2, outputgen.FormatDocusaurus3,
+		outputgen.FormatSimpleDocusaurus2, outputgen.FormatSimpleDocusaurus3:
+		return true
+	default:
+		return false
+	}
+}
+
+// linkPages returns copies of pages in which the first mention of another page's title links to
+// that page, up to maxPageLinks per page. Related pages, the parent and the children of a page are
+// linked first; other pages only when their title has several words, so that common words are not
+// linked. Mentions in headings, code, existing links and URLs are skipped, as are pages the page
+// already links to. Existing documents are left as written, and so are all pages of formats whose
+// pages are not markdown files. The original pages are left untouched.
+func linkPages(
+	pages map[string]*generator.WikiPage,
+	format outputgen.OutputFormat,
+) map[string]*generator.WikiPage {
+	if !linksPages(format) {
+		return pages
+	}
+
+	linked := make(map[string]*generator.WikiPage, len(pages))
+	for id, page := range pages {
+		if page == nil || page.SourceDoc != "" {
+			linked[id] = page
+			continue
+		}
+		pageCopy := *page
+		pageCopy.Content = linkContent(page.Content, linkTargets(page, pages))
+		linked[id] = &pageCopy
+	}
+	return linked
+}
+
+// linkTargets returns the pages a page may link to, in the order they are linked
+func linkTargets(page *generator.WikiPage, pages map[string]*generator.WikiPage) []linkTarget {
+	related := make(map[string]bool)
+	for _, id := range page.RelatedPages {
+		related[id] = true
+	}
+	if page.ParentID != "" {
+		related[page.ParentID] = true
+	}
+
+	ownFile := outputgen.PageFileName(page) + ".md"
+	linkedFiles := make(map[string]bool)
+	for _, match := range markdownLinkPattern.FindAllStringSubmatch(page.Content, -1) {
+		target, _, _ := strings.Cut(match[2], "#")
+		linkedFiles[path.Base(target)] = true
+	}
+
+	var targets []linkTarget
+	for id, other := range pages {
+		if other == nil || id == page.ID {
+			continue
+		}
+		title := strings.TrimSpace(other.Title)
+		file := outputgen.PageFileName(other) + ".md"
+		isRelated := related[id] || other.ParentID == page.ID
+		if utf8.RuneCountInString(title) < minLinkTitleLength || file == ownFile || linkedFiles[file] ||
+			(!isRelated && len(strings.Fields(title)) < 2) {
+			continue
+		}
+		targets = append(targets, linkTarget{
+			id:      id,
+			title:   title,
+			file:    file,
+			pattern: regexp.MustCompile("(?i)" + regexp.QuoteMeta(title)),
+			related: isRelated,
+		})
+	}
+
+	// Longer titles go first so that a title is not linked inside a longer one
+	slices.SortFunc(targets, func(a, b linkTarget) int {
+		if a.related != b.related {
+			if a.related {
+				return -1
+			}
+			return 1
+		}
+		if len(a.title) != len(b.title) {
+			return len(b.title) - len(a.title)
+		}
+		return strings.Compare(a.id, b.id)
+	})
+	return targets
+}
+
+// linkContent links the first mention of each target in content, up to maxPageLinks targets
+func linkContent(content string, targets []linkTarget) string {
+	lines := strings.Split(content, "\n")
+	files := make(map[string]bool)
+	for _, target := range targets {
+		if len(files) == maxPageLinks {
+			break
+		}
+		if !files[target.file] && linkFirstMention(lines, target) {
+			files[target.file] = true
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// linkFirstMention links the first mention of a target in prose lines and reports whether it found one
+func linkFirstMention(lines []string, target linkTarget) bool {
+	inFence := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence || trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		protected := protectedSpanPattern.FindAllStringIndex(line, -1)
+		for _, match := range target.pattern.FindAllStringIndex(line, -1) {
+			start, end := match[0], match[1]
+			if !isWordBoundary(line, start, end) || overlapsAny(start, end, protected) {
+				continue
+			}
+			lines[i] = line[:start] + "[" + line[start:end] + "](./" + target.file + ")" + line[end:]
+			return true
+		}
+	}
+	return false
+}
+
+// isWordBoundary reports whether text[start:end] is neither preceded nor followed by a word character
+func isWordBoundary(text string, start, end int) bool {
+	before, _ := utf8.DecodeLastRuneInString(text[:start])
+	after, _ := utf8.DecodeRuneInString(text[end:])
+	return !isWordRune(before) && !isWordRune(after)
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// overlapsAny reports whether [start, end) overlaps any of spans
+func overlapsAny(start, end int, spans [][]int) bool {
+	for _, span := range spans {
+		if start < span[1] && span[0] < end {
+			return true
+		}
+	}
+	return false
+}
@@ -23,6 +23,8 @@ func NewOutputManager() *OutputManager {
 	registry.Register(outputgen.NewDocusaurus3Generator())
 	registry.Register(outputgen.NewSimpleDocusaurus2Generator())
 	registry.Register(outputgen.NewSimpleDocusaurus3Generator())
+	registry.Register(outputgen.NewRSTGenerator())
+	registry.Register(outputgen.NewGitBookGenerator())
 
 	return &OutputManager{
 		registry: registry,
@@ -41,8 +43,64 @@ func (om *OutputManager) GenerateOutput(
 		return nil, fmt.Errorf("unsupported output format: %s", options.Format)
 	}
 
+	// Link mentions of other pages before splitting, so that the limits count the links
+	if options.LinkPages {
+		pages = linkPages(pages, options.Format)
+	}
+
+	// Split pages over the size limits before anything is rendered or exported
+	structure, pages = splitLongPages(structure, pages, options)
+
+	// Point asset references at the output static tree before rendering
+	assets := newAssetCollector(options)
+	if assets != nil {
+		pages = assets.rewritePages(pages)
+	}
+
 	// Generate output using the appropriate generator
-	return gen.Generate(structure, pages, options)
+	result, err := gen.Generate(structure, pages, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if assets != nil {
+		copied, size, err := assets.copyAssets(options.Directory)
+		if err != nil {
+			result.Errors = append(result.Errors, err)
+		}
+		result.FilesGenerated = append(result.FilesGenerated, copied...)
+		result.TotalFiles = len(result.FilesGenerated)
+		result.TotalSize += size
+	}
+
+	if options.GenerateSearchIndex {
+		if err := writeSearchIndex(result, pages, options); err != nil {
+			result.Errors = append(result.Errors, err)
+		}
+	}
+
+	if options.EmitStructure {
+		if err := writeStructureFile(result, structure, pages, options); err != nil {
+			result.Errors = append(result.Errors, err)
+		}
+	}
+
+	if options.NotionParentPageID != "" {
+		exportToNotion(result, structure, pages, options)
+	}
+
+	// Record everything that was written so the output can be verified later
+	if err := writeManifest(result, options); err != nil {
+		return nil, fmt.Errorf("failed to generate manifest: %w", err)
+	}
+
+	if options.Archive != "" && options.Archive != outputgen.ArchiveNone {
+		if err := writeArchive(result, options); err != nil {
+			result.Errors = append(result.Errors, err)
+		}
+	}
+
+	return result, nil
 }
 
 // GetRegistry returns the format generator registry
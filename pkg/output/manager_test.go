@@ -1,15 +1,27 @@
 package output
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"maps"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/kuderr/deepwiki/pkg/generator"
 	outputgen "github.com/kuderr/deepwiki/pkg/output/generator"
+	"github.com/kuderr/deepwiki/pkg/types"
 )
 
 func TestNewOutputManager(t *testing.T) {
@@ -189,6 +201,32 @@ func TestOutputManager_GenerateOutput_JSON(t *testing.T) {
 	if wikiData["pages"] == nil {
 		t.Error("wiki.json should contain pages")
 	}
+
+	// Every file carries the schema version and its required top-level keys
+	required := map[string][]string{
+		"wiki.json":        {"structure", "pages", "metadata"},
+		"index.json":       {"title", "description", "pages", "generatedAt", "stats"},
+		"pages/page1.json": {"id", "title", "content", "importance", "filePaths"},
+	}
+	for name, keys := range required {
+		data, err := os.ReadFile(filepath.Join(tempDir, name))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			t.Fatalf("Failed to parse %s: %v", name, err)
+		}
+
+		if version := string(fields["schemaVersion"]); version != fmt.Sprint(outputgen.JSONSchemaVersion) {
+			t.Errorf("Expected %s to have schemaVersion %d, got %q", name, outputgen.JSONSchemaVersion, version)
+		}
+		for _, key := range keys {
+			if _, ok := fields[key]; !ok {
+				t.Errorf("Expected %s to contain %q", name, key)
+			}
+		}
+	}
 }
 
 func TestOutputManager_GenerateOutput_Docusaurus2(t *testing.T) {
@@ -334,3 +372,1399 @@ func TestOutputManager_UnsupportedFormat(t *testing.T) {
 		t.Errorf("Expected unsupported format error, got: %v", err)
 	}
 }
+
+func TestOutputManager_GenerateOutput_Manifest(t *testing.T) {
+	manager := NewOutputManager()
+	tempDir := t.TempDir()
+
+	structure := &generator.WikiStructure{
+		ID:        "test-wiki",
+		Title:     "Test Wiki",
+		CreatedAt: time.Now(),
+	}
+
+	pages := map[string]*generator.WikiPage{
+		"page1": {
+			ID:         "page1",
+			Title:      "Test Page 1",
+			Content:    "# Test Content",
+			Importance: "high",
+			CreatedAt:  time.Now(),
+		},
+		"page2": {
+			ID:         "page2",
+			Title:      "Test Page 2",
+			Content:    "# More Content",
+			Importance: "low",
+			CreatedAt:  time.Now(),
+		},
+	}
+
+	options := outputgen.OutputOptions{
+		Format:      outputgen.FormatMarkdown,
+		Directory:   tempDir,
+		ProjectName: "test-project",
+		ToolVersion: "1.2.3",
+	}
+
+	result, err := manager.GenerateOutput(structure, pages, options)
+	if err != nil {
+		t.Fatalf("GenerateOutput failed: %v", err)
+	}
+
+	manifestPath := filepath.Join(tempDir, ManifestFileName)
+	if result.FilesGenerated[len(result.FilesGenerated)-1] != manifestPath {
+		t.Errorf("Expected manifest to be the last generated file, got %v", result.FilesGenerated)
+	}
+	if result.TotalFiles != len(result.FilesGenerated) {
+		t.Errorf("Expected TotalFiles %d, got %d", len(result.FilesGenerated), result.TotalFiles)
+	}
+
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		t.Fatalf("Failed to read manifest: %v", err)
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("Failed to parse manifest: %v", err)
+	}
+
+	if manifest.Format != outputgen.FormatMarkdown {
+		t.Errorf("Expected format markdown, got %s", manifest.Format)
+	}
+	if manifest.ToolVersion != "1.2.3" {
+		t.Errorf("Expected tool version 1.2.3, got %s", manifest.ToolVersion)
+	}
+	if manifest.GeneratedAt.IsZero() {
+		t.Error("Expected generation timestamp to be set")
+	}
+
+	otherFiles := result.FilesGenerated[:len(result.FilesGenerated)-1]
+	if len(manifest.Files) != len(otherFiles) {
+		t.Fatalf("Expected %d manifest entries, got %d", len(otherFiles), len(manifest.Files))
+	}
+
+	entries := make(map[string]ManifestEntry)
+	for _, entry := range manifest.Files {
+		entries[entry.Path] = entry
+	}
+
+	for _, file := range otherFiles {
+		relPath, _ := filepath.Rel(tempDir, file)
+		entry, ok := entries[filepath.ToSlash(relPath)]
+		if !ok {
+			t.Errorf("Manifest is missing %s", relPath)
+			continue
+		}
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", file, err)
+		}
+
+		sum := sha256.Sum256(content)
+		if entry.SHA256 != hex.EncodeToString(sum[:]) {
+			t.Errorf("Checksum mismatch for %s", relPath)
+		}
+		if entry.Size != int64(len(content)) {
+			t.Errorf("Expected size %d for %s, got %d", len(content), relPath, entry.Size)
+		}
+	}
+}
+
+func TestOutputManager_GenerateOutput_CopiesAssets(t *testing.T) {
+	manager := NewOutputManager()
+	projectDir := t.TempDir()
+	outputDir := t.TempDir()
+
+	imageData := []byte("fake-png-data")
+	if err := os.MkdirAll(filepath.Join(projectDir, "docs", "images"), 0o755); err != nil {
+		t.Fatalf("Failed to create image dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, "docs", "images", "arch.png"), imageData, 0o644); err != nil {
+		t.Fatalf("Failed to write image: %v", err)
+	}
+
+	// A file outside the project must never be copied
+	outsideDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outsideDir, "secret.png"), []byte("secret"), 0o644); err != nil {
+		t.Fatalf("Failed to write outside file: %v", err)
+	}
+	traversal := filepath.ToSlash(filepath.Join("..", filepath.Base(outsideDir), "secret.png"))
+
+	originalContent := "# Architecture\n\n![Diagram](docs/images/arch.png)\n\n" +
+		"![Outside](" + traversal + ")\n\n![Remote](https://example.com/logo.png)\n"
+
+	structure := &generator.WikiStructure{
+		ID:        "test-wiki",
+		Title:     "Test Wiki",
+		CreatedAt: time.Now(),
+	}
+	pages := map[string]*generator.WikiPage{
+		"page1": {
+			ID:         "page1",
+			Title:      "Architecture",
+			Content:    originalContent,
+			Importance: "high",
+			CreatedAt:  time.Now(),
+		},
+	}
+
+	options := outputgen.OutputOptions{
+		Format:      outputgen.FormatMarkdown,
+		Directory:   outputDir,
+		ProjectName: "test-project",
+		ProjectPath: projectDir,
+	}
+
+	result, err := manager.GenerateOutput(structure, pages, options)
+	if err != nil {
+		t.Fatalf("GenerateOutput failed: %v", err)
+	}
+	if len(result.Errors) > 0 {
+		t.Fatalf("Unexpected errors: %v", result.Errors)
+	}
+
+	copiedPath := filepath.Join(outputDir, "static", "img", "docs", "images", "arch.png")
+	copied, err := os.ReadFile(copiedPath)
+	if err != nil {
+		t.Fatalf("Expected asset to be copied: %v", err)
+	}
+	if string(copied) != string(imageData) {
+		t.Error("Copied asset content does not match source")
+	}
+
+	pageContent, err := os.ReadFile(filepath.Join(outputDir, "pages", "architecture.md"))
+	if err != nil {
+		t.Fatalf("Failed to read page: %v", err)
+	}
+	pageStr := string(pageContent)
+	if !strings.Contains(pageStr, "![Diagram](../static/img/docs/images/arch.png)") {
+		t.Errorf("Expected image link to be rewritten, got:\n%s", pageStr)
+	}
+	if !strings.Contains(pageStr, "![Outside]("+traversal+")") {
+		t.Error("Link outside the project should be left untouched")
+	}
+	if !strings.Contains(pageStr, "![Remote](https://example.com/logo.png)") {
+		t.Error("Remote link should be left untouched")
+	}
+
+	if _, err := os.Stat(filepath.Join(outputDir, "static", "img", "secret.png")); !os.IsNotExist(err) {
+		t.Error("File outside the project must not be copied")
+	}
+
+	if pages["page1"].Content != originalContent {
+		t.Error("Input page content should not be modified")
+	}
+}
+
+func TestAssetCollector_CopyAssetsSorted(t *testing.T) {
+	projectDir := t.TempDir()
+	outputDir := t.TempDir()
+
+	collector := &assetCollector{projectRoot: projectDir, assets: make(map[string]string)}
+	for _, name := range []string{"c.png", "a.png", "b.png"} {
+		sourcePath := filepath.Join(projectDir, name)
+		if err := os.WriteFile(sourcePath, []byte(name), 0o644); err != nil {
+			t.Fatalf("Failed to write asset: %v", err)
+		}
+		collector.assets[name] = sourcePath
+	}
+
+	written, size, err := collector.copyAssets(outputDir)
+	if err != nil {
+		t.Fatalf("copyAssets failed: %v", err)
+	}
+	if size != 15 {
+		t.Errorf("Expected 15 bytes copied, got %d", size)
+	}
+
+	var names []string
+	for _, path := range written {
+		names = append(names, filepath.Base(path))
+	}
+	if strings.Join(names, ",") != "a.png,b.png,c.png" {
+		t.Errorf("Expected assets in sorted order, got %v", names)
+	}
+}
+
+func TestOutputManager_GenerateOutput_SearchIndex(t *testing.T) {
+	manager := NewOutputManager()
+	tempDir := t.TempDir()
+
+	structure := &generator.WikiStructure{
+		ID:        "test-wiki",
+		Title:     "Test Wiki",
+		CreatedAt: time.Now(),
+	}
+	pages := map[string]*generator.WikiPage{
+		"overview": {
+			ID:          "overview",
+			Title:       "Project Overview",
+			Description: "What the project does",
+			Content:     "# Overview\n\nThe Scanner walks the repository.",
+			Importance:  "high",
+			CreatedAt:   time.Now(),
+		},
+		"setup": {
+			ID:         "setup",
+			Title:      "Setup Guide",
+			Content:    "Run `make build` to compile.",
+			Importance: "medium",
+			CreatedAt:  time.Now(),
+		},
+	}
+
+	options := outputgen.OutputOptions{
+		Format:              outputgen.FormatMarkdown,
+		Directory:           tempDir,
+		ProjectName:         "test-project",
+		GenerateSearchIndex: true,
+	}
+
+	if _, err := manager.GenerateOutput(structure, pages, options); err != nil {
+		t.Fatalf("GenerateOutput failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tempDir, SearchIndexFileName))
+	if err != nil {
+		t.Fatalf("Failed to read search index: %v", err)
+	}
+
+	var index SearchIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		t.Fatalf("Failed to parse search index: %v", err)
+	}
+
+	if index.Ref != "id" {
+		t.Errorf("Expected ref 'id', got %q", index.Ref)
+	}
+	if len(index.Documents) != len(pages) {
+		t.Fatalf("Expected %d documents, got %d", len(pages), len(index.Documents))
+	}
+
+	expectedTokens := map[string][]string{
+		"overview": {"project", "overview", "scanner", "walks", "repository"},
+		"setup":    {"setup", "guide", "make", "build", "compile"},
+	}
+
+	for _, doc := range index.Documents {
+		page, ok := pages[doc.ID]
+		if !ok {
+			t.Errorf("Unexpected document %s", doc.ID)
+			continue
+		}
+		if doc.Title != page.Title {
+			t.Errorf("Expected title %q, got %q", page.Title, doc.Title)
+		}
+		if doc.URL != outputgen.PageURL(options.Format, page) {
+			t.Errorf("Unexpected URL for %s: %s", doc.ID, doc.URL)
+		}
+
+		tokens := make(map[string]bool)
+		for _, token := range doc.Tokens {
+			if token != strings.ToLower(token) {
+				t.Errorf("Token %q is not lowercased", token)
+			}
+			tokens[token] = true
+		}
+		for _, want := range expectedTokens[doc.ID] {
+			if !tokens[want] {
+				t.Errorf("Document %s is missing token %q (got %v)", doc.ID, want, doc.Tokens)
+			}
+		}
+	}
+}
+
+func TestOutputManager_GenerateOutput_NoSearchIndexByDefault(t *testing.T) {
+	manager := NewOutputManager()
+	tempDir := t.TempDir()
+
+	structure := &generator.WikiStructure{Title: "Test Wiki", CreatedAt: time.Now()}
+	pages := map[string]*generator.WikiPage{
+		"page1": {ID: "page1", Title: "Page", Content: "Content", CreatedAt: time.Now()},
+	}
+
+	options := outputgen.OutputOptions{
+		Format:    outputgen.FormatMarkdown,
+		Directory: tempDir,
+	}
+
+	if _, err := manager.GenerateOutput(structure, pages, options); err != nil {
+		t.Fatalf("GenerateOutput failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(tempDir, SearchIndexFileName)); !os.IsNotExist(err) {
+		t.Error("Search index should not be written unless requested")
+	}
+}
+
+func TestOutputManager_GenerateOutput_Sitemap(t *testing.T) {
+	created := time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC)
+
+	structure := &generator.WikiStructure{
+		ID:        "test-wiki",
+		Title:     "Test Wiki",
+		CreatedAt: created,
+	}
+	pages := map[string]*generator.WikiPage{
+		"overview": {ID: "overview", Title: "Project Overview", Content: "Overview", CreatedAt: created},
+		"setup":    {ID: "setup", Title: "Setup Guide", Content: "Setup", CreatedAt: created},
+	}
+
+	tests := []struct {
+		format      outputgen.OutputFormat
+		sitemapPath string
+	}{
+		{outputgen.FormatDocusaurus2, filepath.Join("static", "sitemap.xml")},
+		{outputgen.FormatDocusaurus3, filepath.Join("static", "sitemap.xml")},
+		{outputgen.FormatSimpleDocusaurus2, "sitemap.xml"},
+		{outputgen.FormatSimpleDocusaurus3, "sitemap.xml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			manager := NewOutputManager()
+			tempDir := t.TempDir()
+
+			options := outputgen.OutputOptions{
+				Format:      tt.format,
+				Directory:   tempDir,
+				ProjectName: "test-project",
+				SiteURL:     "https://example.com/",
+				BaseURL:     "/wiki/",
+			}
+
+			if _, err := manager.GenerateOutput(structure, pages, options); err != nil {
+				t.Fatalf("GenerateOutput failed: %v", err)
+			}
+
+			data, err := os.ReadFile(filepath.Join(tempDir, tt.sitemapPath))
+			if err != nil {
+				t.Fatalf("Failed to read sitemap: %v", err)
+			}
+
+			var sitemap outputgen.Sitemap
+			if err := xml.Unmarshal(data, &sitemap); err != nil {
+				t.Fatalf("Sitemap is not well-formed XML: %v", err)
+			}
+
+			if len(sitemap.URLs) != len(pages) {
+				t.Fatalf("Expected %d <url> entries, got %d", len(pages), len(sitemap.URLs))
+			}
+
+			expectedLocs := map[string]bool{
+				"https://example.com/wiki/project-overview": true,
+				"https://example.com/wiki/setup-guide":      true,
+			}
+			for _, u := range sitemap.URLs {
+				if !expectedLocs[u.Loc] {
+					t.Errorf("Unexpected loc %q", u.Loc)
+				}
+				if u.LastMod != "2024-03-15" {
+					t.Errorf("Expected lastmod 2024-03-15, got %q", u.LastMod)
+				}
+			}
+		})
+	}
+}
+
+func TestOutputManager_GenerateOutput_NoSitemapWithoutSiteURL(t *testing.T) {
+	manager := NewOutputManager()
+	tempDir := t.TempDir()
+
+	structure := &generator.WikiStructure{Title: "Test Wiki", CreatedAt: time.Now()}
+	pages := map[string]*generator.WikiPage{
+		"page1": {ID: "page1", Title: "Page", Content: "Content", CreatedAt: time.Now()},
+	}
+
+	options := outputgen.OutputOptions{
+		Format:    outputgen.FormatDocusaurus3,
+		Directory: tempDir,
+	}
+
+	if _, err := manager.GenerateOutput(structure, pages, options); err != nil {
+		t.Fatalf("GenerateOutput failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(tempDir, "static", "sitemap.xml")); !os.IsNotExist(err) {
+		t.Error("Sitemap should not be written without a site URL")
+	}
+}
+
+// manyPages builds count pages with distinct titles
+func manyPages(count int) map[string]*generator.WikiPage {
+	pages := make(map[string]*generator.WikiPage, count)
+	for i := range count {
+		id := fmt.Sprintf("page-%03d", i)
+		pages[id] = &generator.WikiPage{
+			ID:        id,
+			Title:     fmt.Sprintf("Page %03d", i),
+			Content:   fmt.Sprintf("# Page %03d\n\nContent of page %d.", i, i),
+			CreatedAt: time.Now(),
+		}
+	}
+	return pages
+}
+
+func TestOutputManager_GenerateOutput_ManyPages(t *testing.T) {
+	const pageCount = 200
+
+	structure := &generator.WikiStructure{ID: "test-wiki", Title: "Test Wiki", CreatedAt: time.Now()}
+	pages := manyPages(pageCount)
+
+	formats := map[outputgen.OutputFormat]string{
+		outputgen.FormatMarkdown:          "pages",
+		outputgen.FormatDocusaurus3:       "docs",
+		outputgen.FormatSimpleDocusaurus3: "docs",
+	}
+
+	for format, pagesDir := range formats {
+		t.Run(string(format), func(t *testing.T) {
+			var previous []string
+			for run := range 2 {
+				tempDir := t.TempDir()
+				options := outputgen.OutputOptions{Format: format, Directory: tempDir, MaxWorkers: 4}
+
+				result, err := NewOutputManager().GenerateOutput(structure, pages, options)
+				if err != nil {
+					t.Fatalf("GenerateOutput failed: %v", err)
+				}
+				if len(result.Errors) > 0 {
+					t.Fatalf("Unexpected errors: %v", result.Errors)
+				}
+
+				// Page files appear in page ID order, whichever worker wrote them
+				var pageFiles []string
+				for _, file := range result.FilesGenerated {
+					rel, _ := filepath.Rel(tempDir, file)
+					if filepath.Dir(rel) == pagesDir && strings.HasPrefix(filepath.Base(rel), "page-") {
+						pageFiles = append(pageFiles, filepath.Base(rel))
+					}
+				}
+				if len(pageFiles) != pageCount {
+					t.Fatalf("Expected %d page files, got %d", pageCount, len(pageFiles))
+				}
+				for i, name := range pageFiles {
+					if expected := fmt.Sprintf("page-%03d.md", i); name != expected {
+						t.Fatalf("Expected file %d to be %s, got %s", i, expected, name)
+					}
+					if _, err := os.Stat(filepath.Join(tempDir, pagesDir, name)); err != nil {
+						t.Errorf("Expected %s to exist: %v", name, err)
+					}
+				}
+
+				if run == 1 && strings.Join(previous, ",") != strings.Join(pageFiles, ",") {
+					t.Error("Expected identical FilesGenerated order across runs")
+				}
+				previous = pageFiles
+			}
+		})
+	}
+}
+
+func TestOutputManager_GenerateOutput_CollectsPageErrors(t *testing.T) {
+	tempDir := t.TempDir()
+	pages := manyPages(50)
+
+	// A directory in place of a page file makes that page's write fail
+	blocked := []string{"page-005.md", "page-020.md", "page-041.md"}
+	for _, name := range blocked {
+		if err := os.MkdirAll(filepath.Join(tempDir, "pages", name), 0o755); err != nil {
+			t.Fatalf("Failed to create blocking directory: %v", err)
+		}
+	}
+
+	structure := &generator.WikiStructure{ID: "test-wiki", Title: "Test Wiki", CreatedAt: time.Now()}
+	options := outputgen.OutputOptions{Format: outputgen.FormatMarkdown, Directory: tempDir}
+
+	result, err := NewOutputManager().GenerateOutput(structure, pages, options)
+	if err != nil {
+		t.Fatalf("GenerateOutput failed: %v", err)
+	}
+
+	if len(result.Errors) != len(blocked) {
+		t.Fatalf("Expected %d errors, got %d: %v", len(blocked), len(result.Errors), result.Errors)
+	}
+	for i, id := range []string{"page-005", "page-020", "page-041"} {
+		if !strings.Contains(result.Errors[i].Error(), id) {
+			t.Errorf("Expected error %d to mention %s, got %v", i, id, result.Errors[i])
+		}
+	}
+
+	written := 0
+	for _, file := range result.FilesGenerated {
+		if filepath.Base(filepath.Dir(file)) == "pages" {
+			written++
+		}
+	}
+	if written != len(pages)-len(blocked) {
+		t.Errorf("Expected %d pages written, got %d", len(pages)-len(blocked), written)
+	}
+}
+
+func TestOutputManager_GenerateOutput_DirectoryIndexes(t *testing.T) {
+	structure := &generator.WikiStructure{ID: "test-wiki", Title: "Test Wiki"}
+	pages := map[string]*generator.WikiPage{
+		"api": {
+			ID: "api", Title: "API Handlers", Content: "API",
+			FilePaths: []string{"cmd/server/main.go", "pkg/api/handlers.go"},
+		},
+		"storage": {
+			ID: "storage", Title: "Storage Layer", Content: "Storage",
+			FilePaths: []string{"pkg/storage/db.go"},
+		},
+		"readme": {
+			ID: "readme", Title: "Readme", Content: "Readme",
+			FilePaths: []string{"README.md"},
+		},
+	}
+
+	tests := []struct {
+		format    outputgen.OutputFormat
+		indexDir  string
+		pkgLinks  []string
+		rootLinks []string
+	}{
+		{
+			format:    outputgen.FormatMarkdown,
+			indexDir:  "directories",
+			pkgLinks:  []string{"(../pages/api-handlers.md)", "(../pages/storage-layer.md)"},
+			rootLinks: []string{"[cmd/](dir-cmd.md)", "[pkg/](dir-pkg.md)"},
+		},
+		{
+			format:    outputgen.FormatDocusaurus3,
+			indexDir:  filepath.Join("docs", "directories"),
+			pkgLinks:  []string{"(/api-handlers)", "(/storage-layer)"},
+			rootLinks: []string{"[cmd/](/directories/dir-cmd)", "[pkg/](/directories/dir-pkg)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			manager := NewOutputManager()
+			tempDir := t.TempDir()
+
+			options := outputgen.OutputOptions{
+				Format:           tt.format,
+				Directory:        tempDir,
+				ProjectName:      "test-project",
+				DirectoryIndexes: true,
+			}
+
+			if _, err := manager.GenerateOutput(structure, pages, options); err != nil {
+				t.Fatalf("GenerateOutput failed: %v", err)
+			}
+
+			entries, err := os.ReadDir(filepath.Join(tempDir, tt.indexDir))
+			if err != nil {
+				t.Fatalf("Failed to read directory indexes: %v", err)
+			}
+			var names []string
+			for _, entry := range entries {
+				names = append(names, entry.Name())
+			}
+			// One index per top-level directory plus the root index; root files get none
+			expected := []string{"dir-cmd.md", "dir-pkg.md", "index.md"}
+			if strings.Join(names, ",") != strings.Join(expected, ",") {
+				t.Fatalf("Expected index files %v, got %v", expected, names)
+			}
+
+			pkgIndex := readOutputFile(t, filepath.Join(tempDir, tt.indexDir, "dir-pkg.md"))
+			for _, link := range tt.pkgLinks {
+				if !strings.Contains(pkgIndex, link) {
+					t.Errorf("Expected pkg index to contain link %s:\n%s", link, pkgIndex)
+				}
+			}
+
+			cmdIndex := readOutputFile(t, filepath.Join(tempDir, tt.indexDir, "dir-cmd.md"))
+			if strings.Contains(cmdIndex, "storage-layer") {
+				t.Errorf("Expected cmd index to list only pages with files under cmd/:\n%s", cmdIndex)
+			}
+
+			rootIndex := readOutputFile(t, filepath.Join(tempDir, tt.indexDir, "index.md"))
+			for _, link := range tt.rootLinks {
+				if !strings.Contains(rootIndex, link) {
+					t.Errorf("Expected root index to contain link %s:\n%s", link, rootIndex)
+				}
+			}
+		})
+	}
+}
+
+func TestOutputManager_GenerateOutput_NoDirectoryIndexesByDefault(t *testing.T) {
+	manager := NewOutputManager()
+	tempDir := t.TempDir()
+
+	structure := &generator.WikiStructure{ID: "test-wiki", Title: "Test Wiki"}
+	pages := map[string]*generator.WikiPage{
+		"api": {ID: "api", Title: "API", Content: "API", FilePaths: []string{"pkg/api/handlers.go"}},
+	}
+
+	options := outputgen.OutputOptions{Format: outputgen.FormatMarkdown, Directory: tempDir}
+	if _, err := manager.GenerateOutput(structure, pages, options); err != nil {
+		t.Fatalf("GenerateOutput failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(tempDir, "directories")); !os.IsNotExist(err) {
+		t.Error("Expected no directory indexes unless enabled")
+	}
+}
+
+func TestOutputManager_GenerateOutput_StructureFile(t *testing.T) {
+	structure := &generator.WikiStructure{
+		ID:          "test-wiki",
+		Title:       "Test Wiki",
+		Description: "A test wiki",
+		Pages: []generator.WikiPage{
+			{ID: "overview", Title: "Overview", Importance: "high"},
+			{ID: "api", Title: "API Handlers", ParentID: "overview", RelatedPages: []string{"storage"}},
+			{ID: "storage", Title: "Storage Layer", ParentID: "overview", Importance: "low"},
+		},
+	}
+	pages := map[string]*generator.WikiPage{
+		"overview": {ID: "overview", Title: "Overview", Content: "Overview", Importance: "high"},
+		"api": {
+			ID: "api", Title: "API Handlers", Content: "API", ParentID: "overview",
+			RelatedPages: []string{"storage"}, FilePaths: []string{"pkg/api/handlers.go"},
+		},
+		"storage": {
+			ID: "storage", Title: "Storage Layer", Content: "Storage", ParentID: "overview", Importance: "low",
+		},
+		"env-vars": {ID: "env-vars", Title: "Environment Variables", Content: "Env", Importance: "medium"},
+	}
+
+	manager := NewOutputManager()
+	for _, format := range manager.ListFormats() {
+		t.Run(string(format), func(t *testing.T) {
+			tempDir := t.TempDir()
+
+			options := outputgen.OutputOptions{
+				Format:        format,
+				Directory:     tempDir,
+				Language:      types.LanguageEnglish,
+				ProjectName:   "test-project",
+				EmitStructure: true,
+			}
+
+			result, err := manager.GenerateOutput(structure, pages, options)
+			if err != nil {
+				t.Fatalf("GenerateOutput failed: %v", err)
+			}
+
+			structurePath := filepath.Join(tempDir, StructureFileName)
+			if !slices.Contains(result.FilesGenerated, structurePath) {
+				t.Errorf("Expected %s in generated files", StructureFileName)
+			}
+
+			var file StructureFile
+			if err := json.Unmarshal([]byte(readOutputFile(t, structurePath)), &file); err != nil {
+				t.Fatalf("Failed to parse structure file: %v", err)
+			}
+
+			if file.Title != "Test Wiki" || file.Format != format || file.Language != types.LanguageEnglish {
+				t.Errorf("Unexpected structure header: %+v", file)
+			}
+
+			// Structure order first, then pages only known from generation
+			var ids []string
+			for _, page := range file.Pages {
+				ids = append(ids, page.ID)
+			}
+			if expected := []string{"overview", "api", "storage", "env-vars"}; !slices.Equal(ids, expected) {
+				t.Fatalf("Expected pages %v, got %v", expected, ids)
+			}
+
+			overview, api := file.Pages[0], file.Pages[1]
+			if !slices.Equal(overview.Children, []string{"api", "storage"}) {
+				t.Errorf("Expected overview children [api storage], got %v", overview.Children)
+			}
+			if api.ParentID != "overview" || !slices.Equal(api.RelatedPages, []string{"storage"}) {
+				t.Errorf("Expected api parent and relations, got %+v", api)
+			}
+			if api.Importance != "medium" || file.Pages[2].Importance != "low" {
+				t.Errorf("Expected importance to default to medium, got %+v", file.Pages)
+			}
+			if api.URL != outputgen.PageURL(format, pages["api"]) {
+				t.Errorf("Unexpected URL for api: %s", api.URL)
+			}
+		})
+	}
+}
+
+func TestOutputManager_GenerateOutput_NoStructureFileUnlessEnabled(t *testing.T) {
+	manager := NewOutputManager()
+	tempDir := t.TempDir()
+
+	structure := &generator.WikiStructure{Title: "Test Wiki"}
+	pages := map[string]*generator.WikiPage{
+		"page1": {ID: "page1", Title: "Page", Content: "Content"},
+	}
+
+	options := outputgen.OutputOptions{Format: outputgen.FormatJSON, Directory: tempDir}
+	if _, err := manager.GenerateOutput(structure, pages, options); err != nil {
+		t.Fatalf("GenerateOutput failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(tempDir, StructureFileName)); !os.IsNotExist(err) {
+		t.Error("Expected no structure file unless enabled")
+	}
+}
+
+func TestOutputManager_GenerateOutput_RST(t *testing.T) {
+	manager := NewOutputManager()
+	tempDir := t.TempDir()
+
+	structure := &generator.WikiStructure{ID: "test-wiki", Title: "Test Wiki", Description: "The `demo` project"}
+	pages := map[string]*generator.WikiPage{
+		"overview": {
+			ID:          "overview",
+			Title:       "Project Overview",
+			Description: "What the project does",
+			Importance:  "high",
+			Content: "## Getting Started\n\nRun `make build` and see [the guide](https://example.com/guide).\n" +
+				"- first\n- second\n\n### Code\n\n```go\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n```\n\n" +
+				"| Name | Value |\n|------|-------|\n| a | 1 |\n\n```\nplain\n```\n",
+		},
+		"setup": {ID: "setup", Title: "概要", Content: "# 設定\n\nSetup.", Importance: "low"},
+	}
+
+	options := outputgen.OutputOptions{
+		Format:      outputgen.FormatRST,
+		Directory:   tempDir,
+		ProjectName: "test-project",
+	}
+
+	result, err := manager.GenerateOutput(structure, pages, options)
+	if err != nil {
+		t.Fatalf("GenerateOutput failed: %v", err)
+	}
+	if len(result.Errors) > 0 {
+		t.Fatalf("Unexpected output errors: %v", result.Errors)
+	}
+
+	overview := readOutputFile(t, filepath.Join(tempDir, "pages", "project-overview.rst"))
+	setupSlug := generator.PageSlug("概要")
+	setup := readOutputFile(t, filepath.Join(tempDir, "pages", setupSlug+".rst"))
+	index := readOutputFile(t, filepath.Join(tempDir, "index.rst"))
+	for name, doc := range map[string]string{"overview": overview, "setup": setup, "index": index} {
+		assertRSTAdornments(t, name, doc)
+	}
+
+	for _, expected := range []string{
+		"================\nProject Overview\n================\n\n*What the project does*\n",
+		"Getting Started\n===============\n",
+		"Code\n----\n",
+		"Run ``make build`` and see `the guide <https://example.com/guide>`__.\n\n- first\n- second\n",
+		".. code-block:: go\n\n   func main() {\n   \tfmt.Println(\"hi\")\n   }\n",
+		".. code-block:: text\n\n   plain\n",
+		".. list-table::\n   :header-rows: 1\n\n   * - Name\n     - Value\n   * - a\n     - 1\n",
+	} {
+		if !strings.Contains(overview, expected) {
+			t.Errorf("Expected overview page to contain %q, got:\n%s", expected, overview)
+		}
+	}
+	if strings.Contains(overview, "```") {
+		t.Errorf("Expected fenced code to be converted, got:\n%s", overview)
+	}
+
+	// Wide characters take two columns, so the adornment must be twice the rune count
+	if !strings.HasPrefix(setup, "====\n概要\n====\n") || !strings.Contains(setup, "設定\n====\n") {
+		t.Errorf("Expected adornments sized for wide characters, got:\n%s", setup)
+	}
+
+	for _, expected := range []string{
+		"Test Wiki\n=========\n\nThe ``demo`` project\n",
+		".. toctree::\n   :maxdepth: 2\n   :caption: High Importance\n\n   pages/project-overview\n",
+		"   :caption: Additional Information\n\n   pages/" + setupSlug + "\n",
+	} {
+		if !strings.Contains(index, expected) {
+			t.Errorf("Expected index to contain %q, got:\n%s", expected, index)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "conf.py")); err != nil {
+		t.Errorf("Expected a Sphinx conf.py: %v", err)
+	}
+}
+
+func TestOutputManager_GenerateOutput_GitBook(t *testing.T) {
+	manager := NewOutputManager()
+	tempDir := t.TempDir()
+
+	structure := &generator.WikiStructure{ID: "test-wiki", Title: "Test Wiki", Description: "A test wiki"}
+	pages := map[string]*generator.WikiPage{
+		"overview": {ID: "overview", Title: "Overview", Content: "Overview", Importance: "high"},
+		"api":      {ID: "api", Title: "API", Content: "API", Importance: "high"},
+		"handlers": {ID: "handlers", Title: "Handlers", Content: "Handlers", ParentID: "api", Importance: "low"},
+		"routing":  {ID: "routing", Title: "Routing [v2]", Content: "Routing", ParentID: "handlers"},
+		"setup":    {ID: "setup", Title: "Setup", Content: "Setup", Importance: "medium"},
+		"faq":      {ID: "faq", Title: "FAQ", Content: "FAQ", Importance: "low", ParentID: "missing"},
+	}
+
+	options := outputgen.OutputOptions{
+		Format:      outputgen.FormatGitBook,
+		Directory:   tempDir,
+		ProjectName: "test-project",
+	}
+
+	result, err := manager.GenerateOutput(structure, pages, options)
+	if err != nil {
+		t.Fatalf("GenerateOutput failed: %v", err)
+	}
+	if len(result.Errors) > 0 {
+		t.Fatalf("Unexpected output errors: %v", result.Errors)
+	}
+
+	summary := readOutputFile(t, filepath.Join(tempDir, "SUMMARY.md"))
+	expected := `# Summary
+
+* [Introduction](README.md)
+
+## High Importance
+
+* [API](pages/api.md)
+  * [Handlers](pages/handlers.md)
+    * [Routing \[v2\]](pages/routing-[v2].md)
+* [Overview](pages/overview.md)
+
+## Medium Importance
+
+* [Setup](pages/setup.md)
+
+## Additional Information
+
+* [FAQ](pages/faq.md)
+`
+	if summary != expected {
+		t.Errorf("Unexpected SUMMARY.md:\n%s\nexpected:\n%s", summary, expected)
+	}
+
+	// Every page is listed once and every link resolves to a generated file
+	links := regexp.MustCompile(`\]\(([^)]+)\)`).FindAllStringSubmatch(summary, -1)
+	if len(links) != len(pages)+1 {
+		t.Errorf("Expected %d links in SUMMARY.md, got %d", len(pages)+1, len(links))
+	}
+	for _, link := range links {
+		if _, err := os.Stat(filepath.Join(tempDir, filepath.FromSlash(link[1]))); err != nil {
+			t.Errorf("SUMMARY.md links to missing file %s", link[1])
+		}
+	}
+
+	readme := readOutputFile(t, filepath.Join(tempDir, "README.md"))
+	if !strings.HasPrefix(readme, "# Test Wiki\n\nA test wiki\n") {
+		t.Errorf("Expected README.md intro, got:\n%s", readme)
+	}
+	page := readOutputFile(t, filepath.Join(tempDir, "pages", "handlers.md"))
+	if !strings.HasPrefix(page, "# Handlers\n") {
+		t.Errorf("Expected markdown page content, got:\n%s", page)
+	}
+}
+
+func TestOutputManager_GenerateOutput_FrontmatterFields(t *testing.T) {
+	structure := &generator.WikiStructure{ID: "test-wiki", Title: "Test Wiki"}
+	pages := map[string]*generator.WikiPage{
+		"api": {
+			ID: "api", Title: "API Handlers", Description: "HTTP handlers", Content: "Handlers.",
+			Importance: "high", FilePaths: []string{"pkg/api/handlers.go"},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		format      outputgen.OutputFormat
+		pagePath    string
+		fields      []string
+		frontmatter string // Expected frontmatter block, empty for none
+	}{
+		{
+			name:     "docusaurus defaults",
+			format:   outputgen.FormatDocusaurus3,
+			pagePath: filepath.Join("docs", "api-handlers.md"),
+			frontmatter: "---\nid: api\ntitle: API Handlers\nslug: /api-handlers\n" +
+				"description: HTTP handlers\ntags:\n  - golang\n---\n\n",
+		},
+		{
+			name:        "selected docusaurus fields",
+			format:      outputgen.FormatSimpleDocusaurus2,
+			pagePath:    filepath.Join("docs", "api-handlers.md"),
+			fields:      []string{"tags", "title"},
+			frontmatter: "---\ntitle: API Handlers\ntags:\n  - high\n  - golang\n---\n\n",
+		},
+		{
+			name:     "no docusaurus frontmatter",
+			format:   outputgen.FormatDocusaurus2,
+			pagePath: filepath.Join("docs", "api-handlers.md"),
+			fields:   []string{},
+		},
+		{
+			name:     "markdown defaults",
+			format:   outputgen.FormatMarkdown,
+			pagePath: filepath.Join("pages", "api-handlers.md"),
+		},
+		{
+			name:        "selected markdown fields",
+			format:      outputgen.FormatMarkdown,
+			pagePath:    filepath.Join("pages", "api-handlers.md"),
+			fields:      []string{"id", "slug"},
+			frontmatter: "---\nid: api\nslug: /api-handlers\n---\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			options := outputgen.OutputOptions{
+				Format:      tt.format,
+				Directory:   tempDir,
+				ProjectName: "test-project",
+				Frontmatter: outputgen.FrontmatterOptions{Fields: tt.fields},
+			}
+
+			if _, err := NewOutputManager().GenerateOutput(structure, pages, options); err != nil {
+				t.Fatalf("GenerateOutput failed: %v", err)
+			}
+
+			page := readOutputFile(t, filepath.Join(tempDir, tt.pagePath))
+			if tt.frontmatter == "" {
+				if strings.HasPrefix(page, "---") {
+					t.Errorf("Expected no frontmatter, got:\n%s", page)
+				}
+				return
+			}
+			if !strings.HasPrefix(page, tt.frontmatter) {
+				t.Errorf("Expected page to start with:\n%s\ngot:\n%s", tt.frontmatter, page)
+			}
+		})
+	}
+}
+
+// assertRSTAdornments checks that every section underline and overline is at least as long as its title
+// and that overlines match their underlines
+func assertRSTAdornments(t *testing.T, name, doc string) {
+	t.Helper()
+
+	isAdornment := func(line string) bool {
+		return len(line) >= 2 && strings.Trim(line, line[:1]) == "" && strings.ContainsAny(line[:1], "=-~^\"'")
+	}
+	lines := strings.Split(doc, "\n")
+	for i := 1; i < len(lines); i++ {
+		if !isAdornment(lines[i]) || strings.TrimSpace(lines[i-1]) == "" || isAdornment(lines[i-1]) {
+			continue
+		}
+		title, underline := lines[i-1], lines[i]
+		width := 0
+		for _, r := range title {
+			width++
+			if r >= 0x2E80 {
+				width++
+			}
+		}
+		if len(underline) < width {
+			t.Errorf("%s: underline %q is shorter than title %q", name, underline, title)
+		}
+		if i >= 2 && isAdornment(lines[i-2]) && lines[i-2] != underline {
+			t.Errorf("%s: overline %q does not match underline %q", name, lines[i-2], underline)
+		}
+	}
+}
+
+// readOutputFile returns the contents of a generated file
+func TestSplitLongPages_PreservesContent(t *testing.T) {
+	var content strings.Builder
+	content.WriteString("# Overview\n\n")
+	for i := 1; i <= 6; i++ {
+		content.WriteString(fmt.Sprintf("Paragraph %d %s\n\n", i, strings.Repeat("word ", 12)))
+	}
+	content.WriteString("```go\n")
+	for i := 1; i <= 30; i++ {
+		content.WriteString(fmt.Sprintf("fmt.Println(%d)\n", i))
+	}
+	content.WriteString("```\n\nClosing paragraph.\n")
+
+	original := &generator.WikiPage{
+		ID:          "overview",
+		Title:       "Overview",
+		Content:     content.String(),
+		FilePaths:   []string{"main.go"},
+		SourceFiles: 1,
+		Importance:  "high",
+	}
+	structure := &generator.WikiStructure{Title: "Test Wiki", Pages: []generator.WikiPage{*original, {ID: "setup"}}}
+	pages := map[string]*generator.WikiPage{
+		"overview": original,
+		"setup":    {ID: "setup", Title: "Setup", Content: "Short page"},
+	}
+	options := outputgen.OutputOptions{MaxPageWords: 40, MaxPageBytes: 400}
+
+	splitStructure, splitPages := splitLongPages(structure, pages, options)
+	if pages["overview"].Content != content.String() || len(pages) != 2 || len(structure.Pages) != 2 {
+		t.Fatal("Expected the original pages and structure to be left untouched")
+	}
+	if splitPages["setup"] != pages["setup"] {
+		t.Error("Expected pages within the limits to be kept as is")
+	}
+
+	var parts []*generator.WikiPage
+	for _, page := range splitStructure.Pages {
+		if page.ID != "setup" {
+			parts = append(parts, splitPages[page.ID])
+		}
+	}
+	if len(parts) < 3 || len(splitPages) != len(parts)+1 {
+		t.Fatalf("Expected the page to be split into several parts, got %d", len(parts))
+	}
+
+	notes := regexp.MustCompile(`(?m)^\*Continued (from|in) .*\.\*$`)
+	var body []string
+	for i, part := range parts {
+		if !(pageLimits{words: 40, bytes: 400}).fits(part.Content) {
+			t.Errorf("Part %d exceeds the limits: %d words, %d bytes",
+				i+1, len(strings.Fields(part.Content)), len(part.Content))
+		}
+		if strings.Count(part.Content, "```")%2 != 0 {
+			t.Errorf("Part %d leaves a code fence open:\n%s", i+1, part.Content)
+		}
+		if i == 0 {
+			if part.ID != "overview" || part.Title != "Overview" || part.SourceFiles != 1 {
+				t.Errorf("Expected the first part to keep the page identity, got %+v", part)
+			}
+		} else {
+			expectedTitle := fmt.Sprintf("Overview (part %d)", i+1)
+			if part.ParentID != "overview" || part.Title != expectedTitle || len(part.FilePaths) != 0 {
+				t.Errorf("Unexpected continuation page %+v", part)
+			}
+			if !strings.HasPrefix(part.Content, fmt.Sprintf("*Continued from %s.*", parts[i-1].Title)) {
+				t.Errorf("Expected part %d to start with a continuation note, got:\n%s", i+1, part.Content)
+			}
+		}
+		if i < len(parts)-1 && !strings.Contains(part.Content, fmt.Sprintf("*Continued in %s.*", parts[i+1].Title)) {
+			t.Errorf("Expected part %d to point at the next part", i+1)
+		}
+		for _, line := range strings.Split(notes.ReplaceAllString(part.Content, ""), "\n") {
+			if !isFence(line) {
+				body = append(body, line)
+			}
+		}
+	}
+
+	// Every word of the original appears once, in order, across the parts
+	var expected []string
+	for _, line := range strings.Split(content.String(), "\n") {
+		if !isFence(line) {
+			expected = append(expected, line)
+		}
+	}
+	got, want := strings.Fields(strings.Join(body, "\n")), strings.Fields(strings.Join(expected, "\n"))
+	if !slices.Equal(got, want) {
+		t.Errorf("Content not preserved across parts:\ngot  %v\nwant %v", got, want)
+	}
+}
+
+func TestOutputManager_GenerateOutput_SplitsLongPages(t *testing.T) {
+	tempDir := t.TempDir()
+
+	structure := &generator.WikiStructure{Title: "Test Wiki"}
+	pages := map[string]*generator.WikiPage{
+		"guide": {
+			ID:         "guide",
+			Title:      "Guide",
+			Content:    strings.Repeat("A paragraph of eight words in the guide.\n\n", 5),
+			Importance: "high",
+		},
+	}
+	options := outputgen.OutputOptions{
+		Format:        outputgen.FormatGitBook,
+		Directory:     tempDir,
+		MaxPageWords:  30,
+		EmitStructure: true,
+	}
+
+	result, err := NewOutputManager().GenerateOutput(structure, pages, options)
+	if err != nil {
+		t.Fatalf("GenerateOutput failed: %v", err)
+	}
+	if len(result.Errors) > 0 {
+		t.Fatalf("Unexpected output errors: %v", result.Errors)
+	}
+
+	// Continuation pages are nested under the first part in the navigation
+	summary := readOutputFile(t, filepath.Join(tempDir, "SUMMARY.md"))
+	for _, title := range []string{"Guide (part 2)", "Guide (part 3)"} {
+		url := outputgen.PageURL(outputgen.FormatGitBook, &generator.WikiPage{Title: title})
+		if !strings.Contains(summary, fmt.Sprintf("  * [%s](%s)\n", title, url)) {
+			t.Errorf("Expected %q nested in SUMMARY.md:\n%s", title, summary)
+		}
+		if _, err := os.Stat(filepath.Join(tempDir, filepath.FromSlash(url))); err != nil {
+			t.Errorf("Expected a page file for %q: %v", title, err)
+		}
+	}
+
+	var structureFile StructureFile
+	structureData := readOutputFile(t, filepath.Join(tempDir, StructureFileName))
+	if err := json.Unmarshal([]byte(structureData), &structureFile); err != nil {
+		t.Fatalf("Failed to parse structure file: %v", err)
+	}
+	if len(structureFile.Pages) != 3 || structureFile.Pages[0].ID != "guide" ||
+		!slices.Equal(structureFile.Pages[0].Children, []string{"guide-part-2", "guide-part-3"}) {
+		t.Errorf("Expected the parts in the structure file, got %+v", structureFile.Pages)
+	}
+}
+
+func TestOutputManager_GenerateOutput_TruncatesLongPages(t *testing.T) {
+	tempDir := t.TempDir()
+
+	structure := &generator.WikiStructure{Title: "Test Wiki"}
+	pages := map[string]*generator.WikiPage{
+		"guide": {ID: "guide", Title: "Guide", Content: strings.Repeat("Some words that repeat.\n\n", 50)},
+	}
+	options := outputgen.OutputOptions{
+		Format:            outputgen.FormatJSON,
+		Directory:         tempDir,
+		MaxPageBytes:      200,
+		TruncateLongPages: true,
+	}
+
+	result, err := NewOutputManager().GenerateOutput(structure, pages, options)
+	if err != nil {
+		t.Fatalf("GenerateOutput failed: %v", err)
+	}
+	if len(result.Errors) > 0 {
+		t.Fatalf("Unexpected output errors: %v", result.Errors)
+	}
+
+	var page generator.WikiPage
+	pageData := readOutputFile(t, filepath.Join(tempDir, "pages", "guide.json"))
+	if err := json.Unmarshal([]byte(pageData), &page); err != nil {
+		t.Fatalf("Failed to parse page: %v", err)
+	}
+	if len(page.Content) > 200 || !strings.HasSuffix(page.Content, truncationMarker+"\n") {
+		t.Errorf("Expected truncated content with a marker within 200 bytes, got %d bytes:\n%s",
+			len(page.Content), page.Content)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "pages", "guide-part-2.json")); err == nil {
+		t.Error("Expected no continuation pages when truncating")
+	}
+}
+
+func TestOutputManager_GenerateOutput_LinksPages(t *testing.T) {
+	for _, format := range []outputgen.OutputFormat{outputgen.FormatMarkdown, outputgen.FormatDocusaurus3} {
+		t.Run(string(format), func(t *testing.T) {
+			tempDir := t.TempDir()
+
+			structure := &generator.WikiStructure{Title: "Test Wiki"}
+			pages := map[string]*generator.WikiPage{
+				"auth": {
+					ID:    "auth",
+					Title: "Authentication",
+					Content: "# Authentication\n\nTokens are checked before the Request Pipeline runs.\n\n" +
+						"```go\n// Request Pipeline\n```\n",
+					ParentID:     "pipeline",
+					RelatedPages: []string{"pipeline"},
+				},
+				"pipeline": {
+					ID:      "pipeline",
+					Title:   "Request Pipeline",
+					Content: "# Request Pipeline\n\nThe request pipeline calls authentication first.\n",
+				},
+			}
+			options := outputgen.OutputOptions{Format: format, Directory: tempDir, EmitStructure: true, LinkPages: true}
+
+			result, err := NewOutputManager().GenerateOutput(structure, pages, options)
+			if err != nil {
+				t.Fatalf("GenerateOutput failed: %v", err)
+			}
+			if len(result.Errors) > 0 {
+				t.Fatalf("Unexpected output errors: %v", result.Errors)
+			}
+
+			pagesDir := "pages"
+			if format == outputgen.FormatDocusaurus3 {
+				pagesDir = "docs"
+			}
+			auth := readOutputFile(t, filepath.Join(tempDir, pagesDir, "authentication.md"))
+			want := "checked before the [Request Pipeline](./request-pipeline.md) runs.\n\n" +
+				"```go\n// Request Pipeline\n```"
+			if !strings.Contains(auth, want) || strings.Contains(auth, "](./authentication.md)") {
+				t.Errorf("Expected one link to the related page and no self-link, got:\n%s", auth)
+			}
+			pipeline := readOutputFile(t, filepath.Join(tempDir, pagesDir, "request-pipeline.md"))
+			if !strings.Contains(pipeline, "calls [authentication](./authentication.md) first") {
+				t.Errorf("Expected the mention of the child page to link to it, got:\n%s", pipeline)
+			}
+			if pages["auth"].Content != "# Authentication\n\nTokens are checked before the Request Pipeline runs.\n\n"+
+				"```go\n// Request Pipeline\n```\n" {
+				t.Errorf("Expected the original page to be left untouched, got:\n%s", pages["auth"].Content)
+			}
+
+			report, err := ValidateOutput(tempDir, format)
+			if err != nil {
+				t.Fatalf("ValidateOutput failed: %v", err)
+			}
+			if report.HasIssues() {
+				t.Errorf("Expected the inserted links to resolve, got %+v", report.Issues)
+			}
+		})
+	}
+}
+
+func TestLinkPages_SkipsLinkedAndCapsLinks(t *testing.T) {
+	pages := map[string]*generator.WikiPage{
+		"hub": {
+			ID:    "hub",
+			Title: "Overview",
+			Content: "See [the cache](./cache-layer.md) before the Cache Layer internals.\n\n" +
+				"## Storage Engine\n\nUse `Storage Engine` or Storage Engines.\n",
+		},
+		"cache":   {ID: "cache", Title: "Cache Layer", Content: "Listed in the overview."},
+		"storage": {ID: "storage", Title: "Storage Engine"},
+	}
+	var mentions []string
+	for i := range maxPageLinks + 2 {
+		id := fmt.Sprintf("topic-%d", i)
+		title := fmt.Sprintf("Topic Number %d", i)
+		pages[id] = &generator.WikiPage{ID: id, Title: title}
+		mentions = append(mentions, title)
+	}
+	pages["hub"].Content += strings.Join(mentions, ", ") + "\n"
+
+	linked := linkPages(pages, outputgen.FormatMarkdown)
+	content := linked["hub"].Content
+	if strings.Count(content, "cache-layer.md") != 1 {
+		t.Errorf("Expected an already linked page not to be linked again, got:\n%s", content)
+	}
+	if strings.Contains(content, "storage-engine.md") {
+		t.Errorf("Expected mentions in headings, code and longer words to be skipped, got:\n%s", content)
+	}
+	if got := strings.Count(content, "](./topic-number-"); got != maxPageLinks {
+		t.Errorf("Expected %d inserted links, got %d:\n%s", maxPageLinks, got, content)
+	}
+	if strings.Contains(linked["cache"].Content, "](") {
+		t.Errorf("Expected the single-word title of an unrelated page not to be linked, got:\n%s",
+			linked["cache"].Content)
+	}
+
+	if linkPages(pages, outputgen.FormatJSON)["hub"].Content != pages["hub"].Content {
+		t.Error("Expected JSON pages to be left as written")
+	}
+}
+
+func TestOutputManager_GenerateOutput_Archive(t *testing.T) {
+	structure := &generator.WikiStructure{Title: "Test Wiki"}
+	pages := map[string]*generator.WikiPage{
+		"overview": {ID: "overview", Title: "Overview", Content: "Overview content", Importance: "high"},
+		"setup":    {ID: "setup", Title: "Setup", Content: "Setup content"},
+	}
+
+	for _, archive := range []outputgen.ArchiveFormat{outputgen.ArchiveZip, outputgen.ArchiveTarGz} {
+		t.Run(string(archive), func(t *testing.T) {
+			outputDir := filepath.Join(t.TempDir(), "docs")
+			options := outputgen.OutputOptions{
+				Format:        outputgen.FormatMarkdown,
+				Directory:     outputDir,
+				EmitStructure: true,
+				Archive:       archive,
+			}
+
+			result, err := NewOutputManager().GenerateOutput(structure, pages, options)
+			if err != nil {
+				t.Fatalf("GenerateOutput failed: %v", err)
+			}
+			if len(result.Errors) > 0 {
+				t.Fatalf("Unexpected output errors: %v", result.Errors)
+			}
+			if result.ArchivePath != outputDir+"."+string(archive) {
+				t.Fatalf("Expected the archive next to the output directory, got %q", result.ArchivePath)
+			}
+
+			// The archive holds every generated file, including the manifest, at its relative path
+			expected := make(map[string]string)
+			for _, path := range result.FilesGenerated {
+				relPath, err := filepath.Rel(outputDir, path)
+				if err != nil {
+					t.Fatalf("Failed to relativize %s: %v", path, err)
+				}
+				expected[filepath.ToSlash(relPath)] = readOutputFile(t, path)
+			}
+			if _, ok := expected[ManifestFileName]; !ok {
+				t.Fatalf("Expected the manifest among the generated files")
+			}
+
+			archived := readArchive(t, result.ArchivePath, archive)
+			if len(archived) != len(expected) {
+				t.Errorf("Expected %d archived files, got %d: %v",
+					len(expected), len(archived), slices.Sorted(maps.Keys(archived)))
+			}
+			for name, content := range expected {
+				if archived[name] != content {
+					t.Errorf("Expected %s in the archive with the generated content", name)
+				}
+			}
+			if _, ok := archived["pages/overview.md"]; !ok {
+				t.Errorf("Expected pages/overview.md in the archive, got %v", slices.Sorted(maps.Keys(archived)))
+			}
+		})
+	}
+}
+
+// readArchive returns the file contents of a zip or tar.gz archive by entry name
+func readArchive(t *testing.T, path string, format outputgen.ArchiveFormat) map[string]string {
+	t.Helper()
+
+	files := make(map[string]string)
+	if format == outputgen.ArchiveZip {
+		archive, err := zip.OpenReader(path)
+		if err != nil {
+			t.Fatalf("Failed to open zip archive: %v", err)
+		}
+		defer archive.Close()
+		for _, file := range archive.File {
+			reader, err := file.Open()
+			if err != nil {
+				t.Fatalf("Failed to open %s: %v", file.Name, err)
+			}
+			data, err := io.ReadAll(reader)
+			reader.Close()
+			if err != nil {
+				t.Fatalf("Failed to read %s: %v", file.Name, err)
+			}
+			files[file.Name] = string(data)
+		}
+		return files
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open tar.gz archive: %v", err)
+	}
+	defer file.Close()
+	compressed, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("Failed to decompress archive: %v", err)
+	}
+	archive := tar.NewReader(compressed)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read archive: %v", err)
+		}
+		data, err := io.ReadAll(archive)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", header.Name, err)
+		}
+		files[header.Name] = string(data)
+	}
+	return files
+}
+
+func readOutputFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	return string(data)
+}
@@ -0,0 +1,108 @@
+package output
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	outputgen "github.com/kuderr/deepwiki/pkg/output/generator"
+)
+
+// ManifestFileName is the name of the manifest written to the output directory
+const ManifestFileName = "manifest.json"
+
+// Manifest describes every file produced by a generation run
+type Manifest struct {
+	Format      outputgen.OutputFormat `json:"format"`
+	GeneratedAt time.Time              `json:"generatedAt"`
+	ToolVersion string                 `json:"toolVersion"`
+	Files       []ManifestEntry        `json:"files"`
+}
+
+// ManifestEntry describes a single generated file
+type ManifestEntry struct {
+	Path   string `json:"path"`   // Path relative to the output directory, slash-separated
+	Size   int64  `json:"size"`   // File size in bytes
+	SHA256 string `json:"sha256"` // Hex-encoded sha256 of the file contents
+}
+
+// ReadManifest reads the manifest of an output directory. The error wraps os.ErrNotExist when
+// the directory has no manifest.
+func ReadManifest(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest in %s: %w", dir, err)
+	}
+	return &manifest, nil
+}
+
+// writeManifest builds a manifest for the files in result and writes it to the output directory.
+// The manifest itself is appended to result.FilesGenerated.
+func writeManifest(result *outputgen.OutputResult, options outputgen.OutputOptions) error {
+	manifest := Manifest{
+		Format:      options.Format,
+		GeneratedAt: time.Now(),
+		ToolVersion: options.ToolVersion,
+		Files:       make([]ManifestEntry, 0, len(result.FilesGenerated)),
+	}
+
+	for _, path := range result.FilesGenerated {
+		entry, err := newManifestEntry(options.Directory, path)
+		if err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, entry)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	manifestPath := filepath.Join(options.Directory, ManifestFileName)
+	if err := os.WriteFile(manifestPath, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	result.FilesGenerated = append(result.FilesGenerated, manifestPath)
+	result.TotalFiles = len(result.FilesGenerated)
+	result.TotalSize += int64(len(data))
+
+	return nil
+}
+
+// newManifestEntry hashes a generated file and records its path relative to the output directory
+func newManifestEntry(outputDir, path string) (ManifestEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return ManifestEntry{}, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return ManifestEntry{}, fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+
+	relPath, err := filepath.Rel(outputDir, path)
+	if err != nil {
+		relPath = path
+	}
+
+	return ManifestEntry{
+		Path:   filepath.ToSlash(relPath),
+		Size:   size,
+		SHA256: hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
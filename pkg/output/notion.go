@@ -0,0 +1,468 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kuderr/deepwiki/internal/logging"
+	"github.com/kuderr/deepwiki/pkg/generator"
+	outputgen "github.com/kuderr/deepwiki/pkg/output/generator"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// NotionAPIURL is the default Notion API base URL
+	NotionAPIURL = "https://api.notion.com/v1"
+
+	notionVersion           = "2022-06-28"
+	notionRequestsPerSecond = 3 // Average rate allowed per integration
+	notionMaxRetries        = 3
+	notionRetryDelay        = time.Second
+	notionRequestTimeout    = 30 * time.Second
+	notionMaxBlocks         = 100  // Children per create or append request
+	notionMaxTextLength     = 2000 // Characters per rich text object
+)
+
+// NotionBlock is a Notion block object. Exactly one of the content fields is set, matching Type.
+type NotionBlock struct {
+	Object           string           `json:"object"`
+	Type             string           `json:"type"`
+	Heading1         *NotionRichTexts `json:"heading_1,omitempty"`
+	Heading2         *NotionRichTexts `json:"heading_2,omitempty"`
+	Heading3         *NotionRichTexts `json:"heading_3,omitempty"`
+	Paragraph        *NotionRichTexts `json:"paragraph,omitempty"`
+	BulletedListItem *NotionRichTexts `json:"bulleted_list_item,omitempty"`
+	NumberedListItem *NotionRichTexts `json:"numbered_list_item,omitempty"`
+	Quote            *NotionRichTexts `json:"quote,omitempty"`
+	Code             *NotionCode      `json:"code,omitempty"`
+}
+
+// NotionRichTexts is the content of a text block
+type NotionRichTexts struct {
+	RichText []NotionRichText `json:"rich_text"`
+}
+
+// NotionCode is the content of a code block
+type NotionCode struct {
+	RichText []NotionRichText `json:"rich_text"`
+	Language string           `json:"language"`
+}
+
+// NotionRichText is a run of text with optional annotations
+type NotionRichText struct {
+	Type        string             `json:"type"`
+	Text        NotionText         `json:"text"`
+	Annotations *NotionAnnotations `json:"annotations,omitempty"`
+}
+
+// NotionText is the text of a rich text run
+type NotionText struct {
+	Content string      `json:"content"`
+	Link    *NotionLink `json:"link,omitempty"`
+}
+
+// NotionLink is the target of a linked text run
+type NotionLink struct {
+	URL string `json:"url"`
+}
+
+// NotionAnnotations holds the inline styles of a rich text run
+type NotionAnnotations struct {
+	Bold bool `json:"bold,omitempty"`
+	Code bool `json:"code,omitempty"`
+}
+
+// notionCreatePageRequest is the body of POST /pages
+type notionCreatePageRequest struct {
+	Parent     notionParent         `json:"parent"`
+	Properties notionPageProperties `json:"properties"`
+	Children   []NotionBlock        `json:"children,omitempty"`
+}
+
+type notionParent struct {
+	PageID string `json:"page_id"`
+}
+
+type notionPageProperties struct {
+	Title notionTitleProperty `json:"title"`
+}
+
+type notionTitleProperty struct {
+	Title []NotionRichText `json:"title"`
+}
+
+// notionAppendRequest is the body of PATCH /blocks/{id}/children
+type notionAppendRequest struct {
+	Children []NotionBlock `json:"children"`
+}
+
+// notionObject is the part of a Notion response used by the exporter
+type notionObject struct {
+	ID      string `json:"id"`
+	URL     string `json:"url"`
+	Message string `json:"message"`
+}
+
+// NotionExporter creates a Notion child page for every wiki page
+type NotionExporter struct {
+	token        string
+	parentPageID string
+	baseURL      string
+	httpClient   *http.Client
+	rateLimiter  *rate.Limiter
+	retryDelay   time.Duration
+	logger       *logging.Logger
+}
+
+// NewNotionExporter creates an exporter that adds pages under parentPageID.
+// An empty baseURL uses NotionAPIURL.
+func NewNotionExporter(token, parentPageID, baseURL string) (*NotionExporter, error) {
+	if token == "" {
+		return nil, fmt.Errorf("notion integration token is required")
+	}
+	if parentPageID == "" {
+		return nil, fmt.Errorf("notion parent page ID is required")
+	}
+	if baseURL == "" {
+		baseURL = NotionAPIURL
+	}
+
+	return &NotionExporter{
+		token:        token,
+		parentPageID: parentPageID,
+		baseURL:      strings.TrimSuffix(baseURL, "/"),
+		httpClient:   &http.Client{Timeout: notionRequestTimeout},
+		rateLimiter:  rate.NewLimiter(notionRequestsPerSecond, 1),
+		retryDelay:   notionRetryDelay,
+		logger:       logging.GetGlobalLogger().WithComponent("notion"),
+	}, nil
+}
+
+// Export creates one child page per wiki page in structure order. A page that cannot be created
+// does not stop the export; its error is returned with those of the other failed pages.
+func (ne *NotionExporter) Export(
+	ctx context.Context,
+	structure *generator.WikiStructure,
+	pages map[string]*generator.WikiPage,
+) (exported int, errors []error) {
+	for _, entry := range buildStructureFile(structure, pages, outputgen.OutputOptions{}).Pages {
+		page := pages[entry.ID]
+		if page == nil {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return exported, append(errors, fmt.Errorf("notion export cancelled: %w", err))
+		}
+
+		created, err := ne.exportPage(ctx, page)
+		if err != nil {
+			errors = append(errors, fmt.Errorf("failed to export page %s to Notion: %w", page.ID, err))
+			continue
+		}
+		exported++
+		ne.logger.InfoContext(ctx, "exported page to notion",
+			slog.String("page_id", page.ID), slog.String("notion_url", created.URL))
+	}
+
+	return exported, errors
+}
+
+// exportPage creates the Notion page with its first blocks, then appends the rest in batches
+func (ne *NotionExporter) exportPage(ctx context.Context, page *generator.WikiPage) (*notionObject, error) {
+	var blocks []NotionBlock
+	if page.Description != "" {
+		blocks = append(blocks, notionTextBlock("paragraph", page.Description))
+	}
+	blocks = append(blocks, MarkdownToNotionBlocks(page.Content)...)
+
+	first := blocks[:min(len(blocks), notionMaxBlocks)]
+	request := notionCreatePageRequest{
+		Parent: notionParent{PageID: ne.parentPageID},
+		Properties: notionPageProperties{
+			Title: notionTitleProperty{Title: notionPlainText(page.Title)},
+		},
+		Children: first,
+	}
+
+	var created notionObject
+	if err := ne.send(ctx, http.MethodPost, "/pages", request, &created); err != nil {
+		return nil, err
+	}
+
+	for start := len(first); start < len(blocks); start += notionMaxBlocks {
+		batch := blocks[start:min(start+notionMaxBlocks, len(blocks))]
+		path := "/blocks/" + created.ID + "/children"
+		if err := ne.send(ctx, http.MethodPatch, path, notionAppendRequest{Children: batch}, nil); err != nil {
+			return nil, fmt.Errorf("failed to append blocks to %s: %w", created.ID, err)
+		}
+	}
+
+	return &created, nil
+}
+
+// send performs a rate-limited API request, retrying rate limit and server errors
+func (ne *NotionExporter) send(ctx context.Context, method, path string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= notionMaxRetries; attempt++ {
+		if err := ne.rateLimiter.Wait(ctx); err != nil {
+			return fmt.Errorf("rate limiting failed: %w", err)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, ne.baseURL+path, bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+ne.token)
+		req.Header.Set("Notion-Version", notionVersion)
+
+		delay := ne.retryDelay * time.Duration(attempt+1)
+		response, err := ne.httpClient.Do(req)
+		if err != nil {
+			lastErr = err
+		} else {
+			responseBody, readErr := io.ReadAll(response.Body)
+			response.Body.Close()
+
+			switch {
+			case readErr != nil:
+				lastErr = fmt.Errorf("failed to read response: %w", readErr)
+			case response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500:
+				lastErr = notionAPIError(response.StatusCode, responseBody)
+				if seconds, err := strconv.Atoi(response.Header.Get("Retry-After")); err == nil {
+					delay = time.Duration(seconds) * time.Second
+				}
+			case response.StatusCode != http.StatusOK:
+				// Client errors will not succeed on retry
+				return notionAPIError(response.StatusCode, responseBody)
+			default:
+				if out == nil {
+					return nil
+				}
+				if err := json.Unmarshal(responseBody, out); err != nil {
+					return fmt.Errorf("failed to unmarshal response: %w", err)
+				}
+				return nil
+			}
+		}
+
+		if attempt == notionMaxRetries {
+			break
+		}
+		ne.logger.DebugContext(ctx, "retrying notion request",
+			slog.String("path", path), slog.Int("attempt", attempt+1), slog.Any("error", lastErr))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+
+	return fmt.Errorf("request failed after %d retries: %w", notionMaxRetries, lastErr)
+}
+
+// notionAPIError describes a failed API response using the Notion error message when present
+func notionAPIError(status int, body []byte) error {
+	var object notionObject
+	if err := json.Unmarshal(body, &object); err == nil && object.Message != "" {
+		return fmt.Errorf("notion API error (status %d): %s", status, object.Message)
+	}
+	return fmt.Errorf("notion API request failed with status %d: %s", status, string(body))
+}
+
+// exportToNotion exports pages when a Notion token is configured and records failures in result
+func exportToNotion(
+	result *outputgen.OutputResult,
+	structure *generator.WikiStructure,
+	pages map[string]*generator.WikiPage,
+	options outputgen.OutputOptions,
+) {
+	exporter, err := NewNotionExporter(options.NotionToken, options.NotionParentPageID, options.NotionAPIURL)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Errorf("failed to export to Notion: %w", err))
+		return
+	}
+
+	_, errors := exporter.Export(context.Background(), structure, pages)
+	result.Errors = append(result.Errors, errors...)
+}
+
+var (
+	notionHeadingPattern  = regexp.MustCompile(`^(#{1,6})\s+(.+?)(?:\s+#+)?\s*$`)
+	notionFencePattern    = regexp.MustCompile("^\\s*(```+|~~~+)\\s*([\\w+#.-]*)")
+	notionBulletPattern   = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	notionNumberedPattern = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+	notionInlinePattern   = regexp.MustCompile("`([^`]+)`|\\*\\*([^*]+)\\*\\*|\\[([^\\]]+)\\]\\(([^)\\s]+)\\)")
+)
+
+// notionLanguages maps markdown fence languages to the code block languages Notion accepts
+var notionLanguages = map[string]string{
+	"bash": "bash", "sh": "shell", "shell": "shell", "zsh": "shell", "console": "shell",
+	"c": "c", "cpp": "c++", "c++": "c++", "cs": "c#", "csharp": "c#", "css": "css",
+	"dockerfile": "docker", "docker": "docker", "go": "go", "golang": "go", "graphql": "graphql",
+	"html": "html", "java": "java", "javascript": "javascript", "js": "javascript", "json": "json",
+	"kotlin": "kotlin", "makefile": "makefile", "make": "makefile", "markdown": "markdown", "md": "markdown",
+	"mermaid": "mermaid", "php": "php", "protobuf": "protobuf", "proto": "protobuf", "python": "python",
+	"py": "python", "ruby": "ruby", "rb": "ruby", "rust": "rust", "rs": "rust", "scala": "scala",
+	"sql": "sql", "swift": "swift", "toml": "toml", "typescript": "typescript", "ts": "typescript",
+	"xml": "xml", "yaml": "yaml", "yml": "yaml",
+}
+
+// MarkdownToNotionBlocks converts page markdown to Notion blocks: headings, paragraphs, fenced code,
+// bulleted and numbered list items and quotes. Consecutive text lines form one paragraph and inline
+// code, bold text and links become rich text annotations.
+func MarkdownToNotionBlocks(markdown string) []NotionBlock {
+	lines := strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n")
+
+	var blocks []NotionBlock
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) > 0 {
+			blocks = append(blocks, notionTextBlock("paragraph", strings.Join(paragraph, " ")))
+			paragraph = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if match := notionFencePattern.FindStringSubmatch(line); match != nil {
+			flush()
+			var code []string
+			for i++; i < len(lines); i++ {
+				if strings.HasPrefix(strings.TrimSpace(lines[i]), match[1][:3]) {
+					break
+				}
+				code = append(code, lines[i])
+			}
+			language, ok := notionLanguages[strings.ToLower(match[2])]
+			if !ok {
+				language = "plain text"
+			}
+			blocks = append(blocks, NotionBlock{
+				Object: "block",
+				Type:   "code",
+				Code:   &NotionCode{RichText: notionPlainText(strings.Join(code, "\n")), Language: language},
+			})
+			continue
+		}
+
+		if match := notionHeadingPattern.FindStringSubmatch(line); match != nil {
+			flush()
+			blocks = append(blocks, notionTextBlock(fmt.Sprintf("heading_%d", min(len(match[1]), 3)), match[2]))
+			continue
+		}
+
+		if match := notionBulletPattern.FindStringSubmatch(line); match != nil {
+			flush()
+			blocks = append(blocks, notionTextBlock("bulleted_list_item", match[1]))
+			continue
+		}
+
+		if match := notionNumberedPattern.FindStringSubmatch(line); match != nil {
+			flush()
+			blocks = append(blocks, notionTextBlock("numbered_list_item", match[1]))
+			continue
+		}
+
+		if quote, ok := strings.CutPrefix(strings.TrimSpace(line), ">"); ok {
+			flush()
+			blocks = append(blocks, notionTextBlock("quote", strings.TrimSpace(quote)))
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		paragraph = append(paragraph, strings.TrimSpace(line))
+	}
+	flush()
+
+	return blocks
+}
+
+// notionTextBlock creates a text block of the given type from inline markdown
+func notionTextBlock(blockType, text string) NotionBlock {
+	content := &NotionRichTexts{RichText: notionRichText(text)}
+	block := NotionBlock{Object: "block", Type: blockType}
+	switch blockType {
+	case "heading_1":
+		block.Heading1 = content
+	case "heading_2":
+		block.Heading2 = content
+	case "heading_3":
+		block.Heading3 = content
+	case "bulleted_list_item":
+		block.BulletedListItem = content
+	case "numbered_list_item":
+		block.NumberedListItem = content
+	case "quote":
+		block.Quote = content
+	default:
+		block.Type = "paragraph"
+		block.Paragraph = content
+	}
+	return block
+}
+
+// notionRichText converts inline markdown to rich text runs
+func notionRichText(text string) []NotionRichText {
+	var runs []NotionRichText
+	last := 0
+	for _, match := range notionInlinePattern.FindAllStringSubmatchIndex(text, -1) {
+		runs = append(runs, notionPlainText(text[last:match[0]])...)
+		switch {
+		case match[2] >= 0:
+			runs = append(runs, annotate(notionPlainText(text[match[2]:match[3]]), NotionAnnotations{Code: true})...)
+		case match[4] >= 0:
+			runs = append(runs, annotate(notionPlainText(text[match[4]:match[5]]), NotionAnnotations{Bold: true})...)
+		default:
+			label, target := text[match[6]:match[7]], text[match[8]:match[9]]
+			linked := notionPlainText(label)
+			// Notion only accepts absolute link URLs
+			if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+				for i := range linked {
+					linked[i].Text.Link = &NotionLink{URL: target}
+				}
+			}
+			runs = append(runs, linked...)
+		}
+		last = match[1]
+	}
+	return append(runs, notionPlainText(text[last:])...)
+}
+
+// notionPlainText splits text into rich text runs within the Notion length limit
+func notionPlainText(text string) []NotionRichText {
+	runs := []NotionRichText{}
+	runes := []rune(text)
+	for start := 0; start < len(runes); start += notionMaxTextLength {
+		end := min(start+notionMaxTextLength, len(runes))
+		runs = append(runs, NotionRichText{Type: "text", Text: NotionText{Content: string(runes[start:end])}})
+	}
+	return runs
+}
+
+// annotate applies the annotations to every run
+func annotate(runs []NotionRichText, annotations NotionAnnotations) []NotionRichText {
+	for i := range runs {
+		styled := annotations
+		runs[i].Annotations = &styled
+	}
+	return runs
+}
@@ -0,0 +1,201 @@
+package output
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kuderr/deepwiki/pkg/generator"
+	outputgen "github.com/kuderr/deepwiki/pkg/output/generator"
+	"golang.org/x/time/rate"
+)
+
+// mockNotionAPI records page creation requests and answers them with handle
+type mockNotionAPI struct {
+	mu       sync.Mutex
+	requests []notionCreatePageRequest
+	handle   func(w http.ResponseWriter, request notionCreatePageRequest, attempt int)
+	attempts map[string]int
+}
+
+func newMockNotionAPI(
+	t *testing.T,
+	handle func(http.ResponseWriter, notionCreatePageRequest, int),
+) (*httptest.Server, *mockNotionAPI) {
+	t.Helper()
+
+	api := &mockNotionAPI{handle: handle, attempts: make(map[string]int)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/pages" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			http.Error(w, "unexpected", http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret-token" || r.Header.Get("Notion-Version") == "" {
+			t.Errorf("Missing Notion headers: %v", r.Header)
+		}
+
+		var request notionCreatePageRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+			return
+		}
+
+		api.mu.Lock()
+		title := request.Properties.Title.Title[0].Text.Content
+		api.attempts[title]++
+		attempt := api.attempts[title]
+		api.requests = append(api.requests, request)
+		api.mu.Unlock()
+
+		if api.handle != nil {
+			api.handle(w, request, attempt)
+			return
+		}
+		json.NewEncoder(w).Encode(notionObject{ID: "id-" + title, URL: "https://notion.so/" + title})
+	}))
+	t.Cleanup(server.Close)
+	return server, api
+}
+
+func TestOutputManager_GenerateOutput_Notion(t *testing.T) {
+	server, api := newMockNotionAPI(t, nil)
+
+	structure := &generator.WikiStructure{
+		Title: "Test Wiki",
+		Pages: []generator.WikiPage{{ID: "overview"}, {ID: "api"}},
+	}
+	pages := map[string]*generator.WikiPage{
+		"overview": {
+			ID:          "overview",
+			Title:       "Overview",
+			Description: "What the project does",
+			Content: "# Overview\n\nThe `Scanner` walks the\nrepository.\n\n" +
+				"## Features\n\n- **Fast** scanning\n- See [docs](https://example.com)\n\n" +
+				"```go\nfunc main() {}\n```\n",
+		},
+		"api": {ID: "api", Title: "API", Content: "1. Create\n2. Delete\n\n```\nplain\n```"},
+	}
+
+	options := outputgen.OutputOptions{
+		Format:             outputgen.FormatMarkdown,
+		Directory:          t.TempDir(),
+		NotionToken:        "secret-token",
+		NotionParentPageID: "parent-page",
+		NotionAPIURL:       server.URL,
+	}
+
+	result, err := NewOutputManager().GenerateOutput(structure, pages, options)
+	if err != nil {
+		t.Fatalf("GenerateOutput failed: %v", err)
+	}
+	if len(result.Errors) > 0 {
+		t.Fatalf("Unexpected output errors: %v", result.Errors)
+	}
+
+	requests := api.requests
+	if len(requests) != len(pages) {
+		t.Fatalf("Expected one create call per page, got %d", len(requests))
+	}
+	for _, request := range requests {
+		if request.Parent.PageID != "parent-page" {
+			t.Errorf("Expected pages under parent-page, got %q", request.Parent.PageID)
+		}
+	}
+
+	// Pages are created in structure order
+	overview := requests[0]
+	if title := overview.Properties.Title.Title[0].Text.Content; title != "Overview" {
+		t.Fatalf("Expected the overview page first, got %q", title)
+	}
+
+	var types []string
+	for _, block := range overview.Children {
+		types = append(types, block.Type)
+	}
+	expectedTypes := "paragraph,heading_1,paragraph,heading_2,bulleted_list_item,bulleted_list_item,code"
+	if strings.Join(types, ",") != expectedTypes {
+		t.Fatalf("Expected blocks %s, got %s", expectedTypes, strings.Join(types, ","))
+	}
+
+	paragraph := overview.Children[2].Paragraph.RichText
+	if len(paragraph) != 3 || paragraph[1].Text.Content != "Scanner" || !paragraph[1].Annotations.Code {
+		t.Errorf("Expected inline code in the paragraph, got %+v", paragraph)
+	}
+	if paragraph[2].Text.Content != " walks the repository." {
+		t.Errorf("Expected paragraph lines to be joined, got %q", paragraph[2].Text.Content)
+	}
+	bold := overview.Children[4].BulletedListItem.RichText[0]
+	if bold.Text.Content != "Fast" || bold.Annotations == nil || !bold.Annotations.Bold {
+		t.Errorf("Expected a bold run, got %+v", bold)
+	}
+	if link := overview.Children[5].BulletedListItem.RichText[1]; link.Text.Link == nil ||
+		link.Text.Link.URL != "https://example.com" {
+		t.Errorf("Expected a linked run, got %+v", link)
+	}
+	if code := overview.Children[6].Code; code.Language != "go" || code.RichText[0].Text.Content != "func main() {}" {
+		t.Errorf("Expected a go code block, got %+v", code)
+	}
+
+	apiBlocks := requests[1].Children
+	if len(apiBlocks) != 3 || apiBlocks[0].Type != "numbered_list_item" || apiBlocks[2].Code.Language != "plain text" {
+		t.Errorf("Expected numbered items and a plain text code block, got %+v", apiBlocks)
+	}
+}
+
+func TestNotionExporter_RetriesRateLimitsAndContinuesAfterFailures(t *testing.T) {
+	server, api := newMockNotionAPI(t, func(w http.ResponseWriter, request notionCreatePageRequest, attempt int) {
+		switch title := request.Properties.Title.Title[0].Text.Content; {
+		case title == "Limited" && attempt == 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			json.NewEncoder(w).Encode(notionObject{Message: "rate limited"})
+		case title == "Invalid":
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(notionObject{Message: "body failed validation"})
+		default:
+			json.NewEncoder(w).Encode(notionObject{ID: "id-" + title})
+		}
+	})
+
+	exporter, err := NewNotionExporter("secret-token", "parent-page", server.URL)
+	if err != nil {
+		t.Fatalf("NewNotionExporter failed: %v", err)
+	}
+	exporter.rateLimiter = rate.NewLimiter(rate.Inf, 1)
+
+	structure := &generator.WikiStructure{Title: "Test Wiki"}
+	pages := map[string]*generator.WikiPage{
+		"a-limited": {ID: "a-limited", Title: "Limited", Content: "Retried"},
+		"b-invalid": {ID: "b-invalid", Title: "Invalid", Content: "Rejected"},
+		"c-valid":   {ID: "c-valid", Title: "Valid", Content: "Created"},
+	}
+
+	exported, errors := exporter.Export(context.Background(), structure, pages)
+	if exported != 2 {
+		t.Errorf("Expected 2 exported pages, got %d", exported)
+	}
+	if len(errors) != 1 || !strings.Contains(errors[0].Error(), "b-invalid") ||
+		!strings.Contains(errors[0].Error(), "body failed validation") {
+		t.Errorf("Expected one error for the invalid page, got %v", errors)
+	}
+
+	// The rate limited page is retried and client errors are not
+	attempts := api.attempts
+	if attempts["Limited"] != 2 || attempts["Invalid"] != 1 || attempts["Valid"] != 1 {
+		t.Errorf("Unexpected attempts per page: %v", attempts)
+	}
+}
+
+func TestNewNotionExporter_RequiresTokenAndParent(t *testing.T) {
+	if _, err := NewNotionExporter("", "parent-page", ""); err == nil {
+		t.Error("Expected an error without a token")
+	}
+	if _, err := NewNotionExporter("secret-token", "", ""); err == nil {
+		t.Error("Expected an error without a parent page")
+	}
+}
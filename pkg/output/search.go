@@ -0,0 +1,110 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/kuderr/deepwiki/pkg/generator"
+	outputgen "github.com/kuderr/deepwiki/pkg/output/generator"
+)
+
+// SearchIndexFileName is the name of the search index written to the output directory
+const SearchIndexFileName = "search-index.json"
+
+// SearchIndex is a lunr.js-compatible document set: the client builds the index with
+// `ref` as the document reference and `fields` as the indexed fields.
+type SearchIndex struct {
+	Ref       string           `json:"ref"`
+	Fields    []string         `json:"fields"`
+	Documents []SearchDocument `json:"documents"`
+}
+
+// SearchDocument is a single searchable page
+type SearchDocument struct {
+	ID          string   `json:"id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description,omitempty"`
+	URL         string   `json:"url"`
+	Content     string   `json:"content"`
+	Tokens      []string `json:"tokens"`
+}
+
+// buildSearchIndex creates a search index from the generated pages, ordered by page ID
+func buildSearchIndex(
+	pages map[string]*generator.WikiPage,
+	format outputgen.OutputFormat,
+) *SearchIndex {
+	index := &SearchIndex{
+		Ref:       "id",
+		Fields:    []string{"title", "description", "content"},
+		Documents: make([]SearchDocument, 0, len(pages)),
+	}
+
+	for _, page := range pages {
+		if page == nil {
+			continue
+		}
+		index.Documents = append(index.Documents, SearchDocument{
+			ID:          page.ID,
+			Title:       page.Title,
+			Description: page.Description,
+			URL:         outputgen.PageURL(format, page),
+			Content:     page.Content,
+			Tokens:      tokenize(page.Title + " " + page.Description + " " + page.Content),
+		})
+	}
+
+	sort.Slice(index.Documents, func(i, j int) bool {
+		return index.Documents[i].ID < index.Documents[j].ID
+	})
+
+	return index
+}
+
+// writeSearchIndex writes the search index for pages and records it in result
+func writeSearchIndex(
+	result *outputgen.OutputResult,
+	pages map[string]*generator.WikiPage,
+	options outputgen.OutputOptions,
+) error {
+	data, err := json.MarshalIndent(buildSearchIndex(pages, options.Format), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal search index: %w", err)
+	}
+
+	indexPath := filepath.Join(options.Directory, SearchIndexFileName)
+	if err := os.WriteFile(indexPath, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write search index: %w", err)
+	}
+
+	result.FilesGenerated = append(result.FilesGenerated, indexPath)
+	result.TotalFiles = len(result.FilesGenerated)
+	result.TotalSize += int64(len(data))
+
+	return nil
+}
+
+// tokenize lowercases text and splits it into unique word tokens in order of first appearance
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	seen := make(map[string]bool, len(words))
+	tokens := make([]string, 0, len(words))
+	for _, word := range words {
+		// Single characters carry no search value
+		if len([]rune(word)) < 2 || seen[word] {
+			continue
+		}
+		seen[word] = true
+		tokens = append(tokens, word)
+	}
+
+	return tokens
+}
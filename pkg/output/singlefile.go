@@ -0,0 +1,47 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kuderr/deepwiki/pkg/generator"
+	outputgen "github.com/kuderr/deepwiki/pkg/output/generator"
+)
+
+// WriteSingleMarkdown writes the whole wiki to w as one markdown document: the wiki title and
+// description followed by every page in structure order, rendered as in the markdown format
+func WriteSingleMarkdown(
+	w io.Writer,
+	structure *generator.WikiStructure,
+	pages map[string]*generator.WikiPage,
+	options outputgen.OutputOptions,
+) error {
+	structure, pages = splitLongPages(structure, pages, options)
+
+	var content strings.Builder
+	content.WriteString(fmt.Sprintf("# %s\n\n", structure.Title))
+	if structure.Description != "" {
+		content.WriteString(fmt.Sprintf("%s\n\n", structure.Description))
+	}
+
+	for _, entry := range buildStructureFile(structure, pages, options).Pages {
+		page := pages[entry.ID]
+		if page == nil {
+			continue
+		}
+
+		content.WriteString("---\n\n")
+		content.WriteString(fmt.Sprintf("# %s\n\n", page.Title))
+		if page.Description != "" {
+			content.WriteString(fmt.Sprintf("## %s\n\n", page.Description))
+		}
+		content.WriteString(strings.TrimRight(page.Content, "\n"))
+		content.WriteString("\n\n")
+	}
+
+	if _, err := io.WriteString(w, content.String()); err != nil {
+		return fmt.Errorf("failed to write markdown: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kuderr/deepwiki/pkg/generator"
+	outputgen "github.com/kuderr/deepwiki/pkg/output/generator"
+)
+
+// truncationMarker ends a page whose content was cut to fit the page limits
+const truncationMarker = "*[Truncated: the rest of this page exceeded the output size limit]*"
+
+// Levels at which page content is cut, from coarsest to finest
+const (
+	cutBlocks = iota
+	cutLines
+	cutWords
+	cutRunes
+)
+
+// pageLimits bounds the content of a single page; zero disables a limit
+type pageLimits struct {
+	words int
+	bytes int
+}
+
+// fits reports whether text stays within the limits
+func (pl pageLimits) fits(text string) bool {
+	if pl.words > 0 && len(strings.Fields(text)) > pl.words {
+		return false
+	}
+	return pl.bytes <= 0 || len(text) <= pl.bytes
+}
+
+// reserve returns the limits left after setting aside room for text, keeping at least one word or byte
+func (pl pageLimits) reserve(text string) pageLimits {
+	if pl.words > 0 {
+		pl.words = max(pl.words-len(strings.Fields(text)), 1)
+	}
+	if pl.bytes > 0 {
+		pl.bytes = max(pl.bytes-len(text), 1)
+	}
+	return pl
+}
+
+// splitLongPages returns the structure and pages with every page over MaxPageWords or MaxPageBytes
+// split into continuation pages, or truncated when TruncateLongPages is set. Continuation pages are
+// children of the first part and follow it in the structure; the first part keeps the page ID and
+// its source files. The original structure and pages are left untouched.
+func splitLongPages(
+	structure *generator.WikiStructure,
+	pages map[string]*generator.WikiPage,
+	options outputgen.OutputOptions,
+) (*generator.WikiStructure, map[string]*generator.WikiPage) {
+	limits := pageLimits{words: options.MaxPageWords, bytes: options.MaxPageBytes}
+	if limits.words <= 0 && limits.bytes <= 0 {
+		return structure, pages
+	}
+
+	parts := make(map[string][]*generator.WikiPage)
+	for id, page := range pages {
+		if page == nil || limits.fits(page.Content) {
+			continue
+		}
+		if options.TruncateLongPages {
+			parts[id] = []*generator.WikiPage{truncatePage(page, limits)}
+		} else {
+			parts[id] = splitPage(page, limits)
+		}
+	}
+	if len(parts) == 0 {
+		return structure, pages
+	}
+
+	result := make(map[string]*generator.WikiPage, len(pages))
+	for id, page := range pages {
+		result[id] = page
+		for _, part := range parts[id] {
+			result[part.ID] = part
+		}
+	}
+
+	splitStructure := *structure
+	splitStructure.Pages = make([]generator.WikiPage, 0, len(structure.Pages))
+	for _, page := range structure.Pages {
+		split, ok := parts[page.ID]
+		if !ok {
+			splitStructure.Pages = append(splitStructure.Pages, page)
+			continue
+		}
+		for _, part := range split {
+			entry := *part
+			entry.Content = ""
+			splitStructure.Pages = append(splitStructure.Pages, entry)
+		}
+	}
+
+	return &splitStructure, result
+}
+
+// splitPage cuts a page into parts that each fit the limits, with notes linking consecutive parts
+func splitPage(page *generator.WikiPage, limits pageLimits) []*generator.WikiPage {
+	title := func(part int) string {
+		if part == 1 {
+			return page.Title
+		}
+		return fmt.Sprintf("%s (part %d)", page.Title, part)
+	}
+	from := func(part int) string { return fmt.Sprintf("*Continued from %s.*\n\n", title(part-1)) }
+	in := func(part int) string { return fmt.Sprintf("\n\n*Continued in %s.*\n", title(part+1)) }
+
+	// Leave room for both notes, sized for a generous part number
+	chunks := splitContent(page.Content, limits.reserve(from(1000)+in(1000)))
+
+	parts := make([]*generator.WikiPage, len(chunks))
+	for i, chunk := range chunks {
+		number := i + 1
+		part := *page
+		part.Title = title(number)
+		content := strings.TrimRight(chunk, " \t\n")
+		if number > 1 {
+			part.ID = fmt.Sprintf("%s-part-%d", page.ID, number)
+			part.ParentID = page.ID
+			part.RelatedPages = nil
+			part.FilePaths = nil
+			part.SourceFiles = 0
+			content = from(number) + content
+		}
+		if number < len(chunks) {
+			content += in(number)
+		} else {
+			content += "\n"
+		}
+		part.Content = content
+		part.WordCount = len(strings.Fields(content))
+		parts[i] = &part
+	}
+	return parts
+}
+
+// truncatePage keeps the leading content of a page that fits the limits and marks the cut
+func truncatePage(page *generator.WikiPage, limits pageLimits) *generator.WikiPage {
+	marker := "\n\n" + truncationMarker + "\n"
+	chunks := splitContent(page.Content, limits.reserve(marker))
+
+	truncated := *page
+	truncated.Content = strings.TrimRight(chunks[0], " \t\n") + marker
+	truncated.WordCount = len(strings.Fields(truncated.Content))
+	return &truncated
+}
+
+// splitContent cuts content into chunks within the limits, preferring paragraph boundaries, then
+// lines, then words. Fenced code blocks are kept whole where possible and otherwise closed and
+// reopened around each cut.
+func splitContent(content string, limits pageLimits) []string {
+	return splitText(content, limits, cutBlocks)
+}
+
+func splitText(text string, limits pageLimits, level int) []string {
+	var chunks []string
+	var current string
+	for _, piece := range splitPieces(text, level) {
+		if limits.fits(current+piece) || (level == cutRunes && current == "") {
+			current += piece
+			continue
+		}
+		if current != "" {
+			chunks = append(chunks, current)
+			current = ""
+		}
+		if limits.fits(piece) || level == cutRunes {
+			current = piece
+			continue
+		}
+
+		var pieceChunks []string
+		if level == cutBlocks && isFencedBlock(piece) {
+			pieceChunks = splitFencedBlock(piece, limits)
+		} else {
+			pieceChunks = splitText(piece, limits, level+1)
+		}
+		chunks = append(chunks, pieceChunks[:len(pieceChunks)-1]...)
+		current = pieceChunks[len(pieceChunks)-1]
+	}
+	if current != "" || len(chunks) == 0 {
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
+
+// splitPieces cuts text at the given level; the pieces concatenate back to text
+func splitPieces(text string, level int) []string {
+	switch level {
+	case cutBlocks:
+		return contentBlocks(text)
+	case cutLines:
+		return strings.SplitAfter(text, "\n")
+	case cutWords:
+		return strings.SplitAfter(text, " ")
+	default:
+		pieces := make([]string, 0, utf8.RuneCountInString(text))
+		for len(text) > 0 {
+			_, size := utf8.DecodeRuneInString(text)
+			pieces = append(pieces, text[:size])
+			text = text[size:]
+		}
+		return pieces
+	}
+}
+
+// contentBlocks splits markdown into paragraphs, each ending with its trailing blank lines.
+// Blank lines inside fenced code blocks do not end a block.
+func contentBlocks(content string) []string {
+	var blocks []string
+	var current strings.Builder
+	inFence, blank := false, false
+	for _, line := range strings.SplitAfter(content, "\n") {
+		isBlank := strings.TrimSpace(line) == ""
+		if blank && !isBlank && !inFence {
+			blocks = append(blocks, current.String())
+			current.Reset()
+		}
+		if isFence(line) {
+			inFence = !inFence
+		}
+		current.WriteString(line)
+		blank = isBlank
+	}
+	if current.Len() > 0 {
+		blocks = append(blocks, current.String())
+	}
+	return blocks
+}
+
+// isFence reports whether a line opens or closes a fenced code block
+func isFence(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")
+}
+
+// isFencedBlock reports whether a block starts with a code fence
+func isFencedBlock(block string) bool {
+	return isFence(strings.SplitAfter(block, "\n")[0])
+}
+
+// splitFencedBlock cuts a fenced code block by lines, closing the fence at the end of each chunk
+// and reopening it with the original fence line at the start of the next
+func splitFencedBlock(block string, limits pageLimits) []string {
+	opening, _, _ := strings.Cut(block, "\n")
+	opening += "\n"
+	closing := strings.TrimSpace(opening)[:3] + "\n"
+
+	chunks := splitText(block, limits.reserve(opening+closing), cutLines)
+	for i := range chunks {
+		if i > 0 {
+			chunks[i] = opening + chunks[i]
+		}
+		if i < len(chunks)-1 {
+			if !strings.HasSuffix(chunks[i], "\n") {
+				chunks[i] += "\n"
+			}
+			chunks[i] += closing
+		}
+	}
+	return chunks
+}
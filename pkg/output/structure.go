@@ -0,0 +1,136 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/kuderr/deepwiki/pkg/generator"
+	outputgen "github.com/kuderr/deepwiki/pkg/output/generator"
+	"github.com/kuderr/deepwiki/pkg/types"
+)
+
+// StructureFileName is the name of the format-independent structure file written to the output directory
+const StructureFileName = "structure.json"
+
+// StructureFile is the canonical, machine-readable description of a generated wiki. It has the
+// same shape for every output format so post-processing does not depend on the format.
+type StructureFile struct {
+	Title       string                 `json:"title"`
+	Description string                 `json:"description"`
+	Format      outputgen.OutputFormat `json:"format"`
+	Language    types.Language         `json:"language,omitempty"`
+	GeneratedAt time.Time              `json:"generatedAt"`
+	ToolVersion string                 `json:"toolVersion"`
+	Pages       []StructurePage        `json:"pages"`
+}
+
+// StructurePage describes a single page, its place in the hierarchy and its relations
+type StructurePage struct {
+	ID           string   `json:"id"`
+	Title        string   `json:"title"`
+	Description  string   `json:"description,omitempty"`
+	URL          string   `json:"url"`
+	Importance   string   `json:"importance"`
+	ParentID     string   `json:"parentId,omitempty"`
+	Children     []string `json:"children,omitempty"`
+	RelatedPages []string `json:"relatedPages,omitempty"`
+	FilePaths    []string `json:"filePaths,omitempty"`
+}
+
+// buildStructureFile lists pages in wiki structure order, followed by any pages missing from the
+// structure in page ID order. Generated page details take precedence over the structure entry.
+func buildStructureFile(
+	structure *generator.WikiStructure,
+	pages map[string]*generator.WikiPage,
+	options outputgen.OutputOptions,
+) *StructureFile {
+	file := &StructureFile{
+		Title:       structure.Title,
+		Description: structure.Description,
+		Format:      options.Format,
+		Language:    options.Language,
+		GeneratedAt: time.Now(),
+		ToolVersion: options.ToolVersion,
+		Pages:       []StructurePage{},
+	}
+
+	var ordered []*generator.WikiPage
+	seen := make(map[string]bool)
+	for i := range structure.Pages {
+		page := &structure.Pages[i]
+		if generated, ok := pages[page.ID]; ok && generated != nil {
+			page = generated
+		}
+		if seen[page.ID] {
+			continue
+		}
+		seen[page.ID] = true
+		ordered = append(ordered, page)
+	}
+
+	var extra []*generator.WikiPage
+	for id, page := range pages {
+		if page != nil && !seen[id] {
+			extra = append(extra, page)
+		}
+	}
+	sort.Slice(extra, func(i, j int) bool {
+		return extra[i].ID < extra[j].ID
+	})
+	ordered = append(ordered, extra...)
+
+	children := make(map[string][]string)
+	for _, page := range ordered {
+		if page.ParentID != "" {
+			children[page.ParentID] = append(children[page.ParentID], page.ID)
+		}
+	}
+
+	for _, page := range ordered {
+		importance := page.Importance
+		if importance == "" {
+			importance = "medium"
+		}
+		file.Pages = append(file.Pages, StructurePage{
+			ID:           page.ID,
+			Title:        page.Title,
+			Description:  page.Description,
+			URL:          outputgen.PageURL(options.Format, page),
+			Importance:   importance,
+			ParentID:     page.ParentID,
+			Children:     children[page.ID],
+			RelatedPages: page.RelatedPages,
+			FilePaths:    page.FilePaths,
+		})
+	}
+
+	return file
+}
+
+// writeStructureFile writes the structure file and records it in result
+func writeStructureFile(
+	result *outputgen.OutputResult,
+	structure *generator.WikiStructure,
+	pages map[string]*generator.WikiPage,
+	options outputgen.OutputOptions,
+) error {
+	data, err := json.MarshalIndent(buildStructureFile(structure, pages, options), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal structure file: %w", err)
+	}
+
+	structurePath := filepath.Join(options.Directory, StructureFileName)
+	if err := os.WriteFile(structurePath, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write structure file: %w", err)
+	}
+
+	result.FilesGenerated = append(result.FilesGenerated, structurePath)
+	result.TotalFiles = len(result.FilesGenerated)
+	result.TotalSize += int64(len(data))
+
+	return nil
+}
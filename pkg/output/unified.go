@@ -0,0 +1,274 @@
+package output
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// diffContextLines is the number of unchanged lines shown around each change
+const diffContextLines = 3
+
+// diffOp is one line of an edit script
+type diffOp struct {
+	kind byte // ' ' unchanged, '-' removed, '+' added
+	text string
+}
+
+// UnifiedDiff returns a unified diff turning oldText into newText, or "" if they are equal
+func UnifiedDiff(oldName, newName, oldText, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+
+	ops := diffLines(splitLines(oldText), splitLines(newText))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+
+	// Walk the edit script, emitting a hunk for each run of changes with its context
+	oldLine, newLine := 1, 1
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			oldLine++
+			newLine++
+			i++
+			continue
+		}
+
+		start := max(i-diffContextLines, 0)
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			// Merge the next change into this hunk if the gap is small enough
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+			if next == len(ops) || next-end > 2*diffContextLines {
+				end = min(end+diffContextLines, len(ops))
+				break
+			}
+			end = next
+		}
+
+		hunkOld, hunkNew := oldLine-(i-start), newLine-(i-start)
+		var oldCount, newCount int
+		var body strings.Builder
+		for _, op := range ops[start:end] {
+			body.WriteByte(op.kind)
+			body.WriteString(op.text)
+			body.WriteByte('\n')
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(hunkOld, oldCount), hunkRange(hunkNew, newCount))
+		out.WriteString(body.String())
+
+		for _, op := range ops[i:end] {
+			if op.kind != '+' {
+				oldLine++
+			}
+			if op.kind != '-' {
+				newLine++
+			}
+		}
+		i = end
+	}
+
+	return out.String()
+}
+
+// hunkRange formats the start,count pair of a hunk header
+func hunkRange(start, count int) string {
+	if count == 0 {
+		// An empty range refers to the line before the change
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// splitLines splits text into lines without their terminators
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines computes a minimal line edit script with Myers' algorithm in linear space, so that
+// large pages can be diffed without a table of all line pairs
+func diffLines(oldLines, newLines []string) []diffOp {
+	// Compare line numbers rather than strings
+	ids := make(map[string]int)
+	lineIDs := func(lines []string) []int {
+		out := make([]int, len(lines))
+		for i, line := range lines {
+			id, ok := ids[line]
+			if !ok {
+				id = len(ids)
+				ids[line] = id
+			}
+			out[i] = id
+		}
+		return out
+	}
+
+	d := &myersDiff{
+		oldLines: oldLines,
+		newLines: newLines,
+		a:        lineIDs(oldLines),
+		b:        lineIDs(newLines),
+		ops:      make([]diffOp, 0, len(oldLines)+len(newLines)),
+	}
+	d.compare(0, len(oldLines), 0, len(newLines))
+
+	// Show the removed lines of each change before the added ones
+	ops := d.ops
+	for start := 0; start < len(ops); {
+		if ops[start].kind == ' ' {
+			start++
+			continue
+		}
+		end := start
+		for end < len(ops) && ops[end].kind != ' ' {
+			end++
+		}
+		slices.SortStableFunc(ops[start:end], func(x, y diffOp) int {
+			return cmp.Compare(y.kind, x.kind) // '-' sorts after '+' in ASCII
+		})
+		start = end
+	}
+	return ops
+}
+
+// myersDiff holds the state of a line diff: the lines, their ids and the edit script so far
+type myersDiff struct {
+	oldLines, newLines []string
+	a, b               []int
+	ops                []diffOp
+}
+
+// compare appends the edit script turning a[aLo:aHi] into b[bLo:bHi]
+func (d *myersDiff) compare(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		d.ops = append(d.ops, diffOp{' ', d.oldLines[aLo]})
+		aLo++
+		bLo++
+	}
+	suffix := 0
+	for aHi-suffix > aLo && bHi-suffix > bLo && d.a[aHi-suffix-1] == d.b[bHi-suffix-1] {
+		suffix++
+	}
+	aEnd, bEnd := aHi-suffix, bHi-suffix
+
+	switch {
+	case aLo == aEnd:
+		for j := bLo; j < bEnd; j++ {
+			d.ops = append(d.ops, diffOp{'+', d.newLines[j]})
+		}
+	case bLo == bEnd:
+		for i := aLo; i < aEnd; i++ {
+			d.ops = append(d.ops, diffOp{'-', d.oldLines[i]})
+		}
+	default:
+		x, y := d.split(aLo, aEnd, bLo, bEnd)
+		d.compare(aLo, x, bLo, y)
+		d.compare(x, aEnd, y, bEnd)
+	}
+
+	for i := aEnd; i < aHi; i++ {
+		d.ops = append(d.ops, diffOp{' ', d.oldLines[i]})
+	}
+}
+
+// split finds the middle snake of a shortest edit script of a[aLo:aHi] into b[bLo:bHi], searching
+// forward from the start and backward from the end at once, and returns where to split both
+// ranges. The ranges are non-empty and differ in their first and last lines.
+func (d *myersDiff) split(aLo, aHi, bLo, bHi int) (int, int) {
+	n, m := aHi-aLo, bHi-bLo
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	// forward[offset+k] and backward[offset+k] are the furthest x reached on diagonal k from
+	// the start and from the end, or -1
+	forward := make([]int, 2*maxD+2)
+	backward := make([]int, 2*maxD+2)
+	for i := range forward {
+		forward[i], backward[i] = -1, -1
+	}
+	forward[offset+1], backward[offset+1] = 0, 0
+
+	delta := n - m
+	odd := delta%2 != 0
+	// Diagonals that ran off the edit graph are no longer searched
+	var forwardStart, forwardEnd, backwardStart, backwardEnd int
+	for e := range maxD {
+		for k := -e + forwardStart; k <= e-forwardEnd; k += 2 {
+			var x int
+			if k == -e || (k != e && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.a[aLo+x] == d.b[bLo+y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+			switch {
+			case x > n:
+				forwardEnd += 2
+			case y > m:
+				forwardStart += 2
+			case odd:
+				back := offset + delta - k
+				if back >= 0 && back < len(backward) && backward[back] != -1 && x >= n-backward[back] {
+					return aLo + x, bLo + y
+				}
+			}
+		}
+
+		for k := -e + backwardStart; k <= e-backwardEnd; k += 2 {
+			var x int
+			if k == -e || (k != e && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.a[aHi-x-1] == d.b[bHi-y-1] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+			switch {
+			case x > n:
+				backwardEnd += 2
+			case y > m:
+				backwardStart += 2
+			case !odd:
+				fwd := offset + delta - k
+				if fwd >= 0 && fwd < len(forward) && forward[fwd] != -1 && forward[fwd] >= n-x {
+					fx := forward[fwd]
+					return aLo + fx, bLo + fx - (fwd - offset)
+				}
+			}
+		}
+	}
+
+	// Unreachable for differing ranges; replace one with the other
+	return aHi, bLo
+}
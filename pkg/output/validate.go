@@ -0,0 +1,404 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/kuderr/deepwiki/pkg/generator"
+	outputgen "github.com/kuderr/deepwiki/pkg/output/generator"
+	"gopkg.in/yaml.v3"
+)
+
+// IssueKind classifies a problem found in a generated output directory
+type IssueKind string
+
+const (
+	IssueEmptyPage          IssueKind = "empty-page"
+	IssueInvalidFrontmatter IssueKind = "invalid-frontmatter"
+	IssueBrokenLink         IssueKind = "broken-link"
+	IssueUnknownPage        IssueKind = "unknown-page" // Parent, child or related page that does not exist
+	IssueInvalidFile        IssueKind = "invalid-file" // File that cannot be parsed
+)
+
+// ValidationIssue is a single problem in a generated file
+type ValidationIssue struct {
+	Path    string    `json:"path"` // Path relative to the output directory, slash-separated
+	Kind    IssueKind `json:"kind"`
+	Message string    `json:"message"`
+}
+
+// ValidationReport lists the problems found in an output directory
+type ValidationReport struct {
+	Dir    string                 `json:"dir"`
+	Format outputgen.OutputFormat `json:"format"`
+	Files  int                    `json:"files"`  // Page and structure files checked
+	Issues []ValidationIssue      `json:"issues"` // Sorted by path
+}
+
+// HasIssues reports whether any problem was found
+func (r *ValidationReport) HasIssues() bool {
+	return len(r.Issues) > 0
+}
+
+// Count returns the number of issues of the given kind
+func (r *ValidationReport) Count(kind IssueKind) int {
+	count := 0
+	for _, issue := range r.Issues {
+		if issue.Kind == kind {
+			count++
+		}
+	}
+	return count
+}
+
+// rstLinkPattern matches hyperlink references such as `text <target>`__. Like
+// markdownLinkPattern, the second group is the target.
+var rstLinkPattern = regexp.MustCompile("(`[^`<]*<)([^>`]+)(>`__?)")
+
+// validator collects the issues of one output directory
+type validator struct {
+	dir    string
+	format outputgen.OutputFormat
+	report *ValidationReport
+}
+
+// ValidateOutput checks the output directory written by the given format for empty pages,
+// invalid frontmatter, internal links to missing files and references to unknown pages. An
+// empty format is read from the directory's structure.json, falling back to markdown.
+func ValidateOutput(dir string, format outputgen.OutputFormat) (*ValidationReport, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read output directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	structure, structureErr := readStructureFile(dir)
+	if format == "" {
+		format = outputgen.FormatMarkdown
+		if structure != nil && structure.Format != "" {
+			format = structure.Format
+		}
+	}
+
+	v := &validator{dir: dir, format: format, report: &ValidationReport{Dir: dir, Format: format}}
+
+	switch {
+	case structureErr != nil:
+		v.addIssue(StructureFileName, IssueInvalidFile, "%v", structureErr)
+	case structure != nil:
+		v.report.Files++
+		v.checkStructure(structure)
+	}
+
+	if err := v.checkPages(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(v.report.Issues, func(i, j int) bool {
+		return v.report.Issues[i].Path < v.report.Issues[j].Path
+	})
+	return v.report, nil
+}
+
+// structureSummary is the part of structure.json that is validated
+type structureSummary struct {
+	Format outputgen.OutputFormat `json:"format"`
+	Pages  []StructurePage        `json:"pages"`
+}
+
+// readStructureFile parses structure.json, returning nil when the directory has none
+func readStructureFile(dir string) (*structureSummary, error) {
+	data, err := os.ReadFile(filepath.Join(dir, StructureFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read: %w", err)
+	}
+
+	var structure structureSummary
+	if err := json.Unmarshal(data, &structure); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+	return &structure, nil
+}
+
+func (v *validator) addIssue(relPath string, kind IssueKind, format string, args ...any) {
+	v.report.Issues = append(v.report.Issues, ValidationIssue{
+		Path:    relPath,
+		Kind:    kind,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// checkStructure verifies that every page of structure.json exists and refers to known pages
+func (v *validator) checkStructure(structure *structureSummary) {
+	ids := make(map[string]bool, len(structure.Pages))
+	for _, page := range structure.Pages {
+		ids[page.ID] = true
+	}
+
+	for _, page := range structure.Pages {
+		if page.ParentID != "" && !ids[page.ParentID] {
+			v.addIssue(StructureFileName, IssueUnknownPage, "page %s has unknown parent %s", page.ID, page.ParentID)
+		}
+		for _, child := range page.Children {
+			if !ids[child] {
+				v.addIssue(StructureFileName, IssueUnknownPage, "page %s has unknown child %s", page.ID, child)
+			}
+		}
+		for _, related := range page.RelatedPages {
+			if !ids[related] {
+				v.addIssue(StructureFileName, IssueUnknownPage, "page %s has unknown related page %s", page.ID, related)
+			}
+		}
+		if page.URL != "" && v.resolveLink(v.dir, page.URL) == "" {
+			v.addIssue(StructureFileName, IssueBrokenLink, "page %s links to missing %s", page.ID, page.URL)
+		}
+	}
+}
+
+// checkPages validates every page file of the format
+func (v *validator) checkPages() error {
+	if v.format == outputgen.FormatJSON {
+		return v.checkJSONPages()
+	}
+
+	return filepath.WalkDir(v.dir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if entry.Name() == "node_modules" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		switch strings.ToLower(filepath.Ext(filePath)) {
+		case ".md", ".mdx":
+			if v.format == outputgen.FormatRST {
+				return nil
+			}
+		case ".rst":
+			if v.format != outputgen.FormatRST {
+				return nil
+			}
+		default:
+			return nil
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", filePath, err)
+		}
+		v.report.Files++
+		v.checkPage(filePath, string(content))
+		return nil
+	})
+}
+
+// checkPage validates the frontmatter, body and links of a markdown or reStructuredText page
+func (v *validator) checkPage(filePath, content string) {
+	relPath := v.relPath(filePath)
+
+	body := content
+	if v.format != outputgen.FormatRST {
+		var problem string
+		body, problem = splitFrontmatter(content)
+		if problem != "" {
+			v.addIssue(relPath, IssueInvalidFrontmatter, "%s", problem)
+		}
+	}
+	if strings.TrimSpace(body) == "" {
+		v.addIssue(relPath, IssueEmptyPage, "page has no content")
+		return
+	}
+
+	pattern := markdownLinkPattern
+	if v.format == outputgen.FormatRST {
+		pattern = rstLinkPattern
+	}
+	for _, target := range pageLinks(body, pattern) {
+		if v.resolveLink(filepath.Dir(filePath), target) == "" {
+			v.addIssue(relPath, IssueBrokenLink, "link to missing %s", target)
+		}
+	}
+}
+
+// splitFrontmatter returns the body of a page after its YAML frontmatter block, and a
+// description of the problem when the block is unterminated or not valid YAML
+func splitFrontmatter(content string) (body, problem string) {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	if lines[0] != "---" {
+		return content, ""
+	}
+
+	end := slices.Index(lines[1:], "---")
+	if end < 0 {
+		return content, "frontmatter is not terminated by ---"
+	}
+	block := strings.Join(lines[1:end+1], "\n")
+	body = strings.Join(lines[end+2:], "\n")
+
+	var fields map[string]any
+	if err := yaml.Unmarshal([]byte(block), &fields); err != nil {
+		return body, fmt.Sprintf("frontmatter is not valid YAML: %v", err)
+	}
+	return body, ""
+}
+
+// pageLinks returns the internal link targets of a page, skipping fenced code blocks, external
+// URLs and in-page anchors
+func pageLinks(body string, pattern *regexp.Regexp) []string {
+	var links []string
+	inFence := false
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		for _, match := range pattern.FindAllStringSubmatch(line, -1) {
+			target := match[2]
+			if strings.HasPrefix(target, "#") || strings.Contains(target, "://") ||
+				strings.HasPrefix(target, "mailto:") || strings.HasPrefix(target, "tel:") {
+				continue
+			}
+			links = append(links, target)
+		}
+	}
+	return links
+}
+
+// resolveLink returns the file an internal link from a page in baseDir points to, or "" when
+// it points nowhere. Links without an extension are tried as markdown and index files, and
+// absolute links resolve against the site root of the format.
+func (v *validator) resolveLink(baseDir, target string) string {
+	target, _, _ = strings.Cut(target, "#")
+	target, _, _ = strings.Cut(target, "?")
+	if unescaped, err := url.PathUnescape(target); err == nil {
+		target = unescaped
+	}
+	if target == "" {
+		return baseDir
+	}
+
+	base := baseDir
+	if strings.HasPrefix(target, "/") {
+		base = v.siteRoot()
+	}
+	resolved := filepath.Join(base, filepath.FromSlash(path.Clean(target)))
+
+	candidates := []string{resolved}
+	for _, suffix := range []string{".md", ".mdx", ".rst"} {
+		candidates = append(candidates, resolved+suffix)
+	}
+	for _, index := range []string{"index.md", "index.mdx", "README.md", "index.rst"} {
+		candidates = append(candidates, filepath.Join(resolved, index))
+	}
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// siteRoot returns the directory that absolute links resolve against
+func (v *validator) siteRoot() string {
+	switch v.format {
+	case outputgen.FormatDocusaurus2, outputgen.FormatDocusaurus3,
+		outputgen.FormatSimpleDocusaurus2, outputgen.FormatSimpleDocusaurus3:
+		return filepath.Join(v.dir, "docs")
+	default:
+		return v.dir
+	}
+}
+
+// checkJSONPages validates the page files of the JSON format and their page references
+func (v *validator) checkJSONPages() error {
+	files, err := filepath.Glob(filepath.Join(v.dir, "pages", "*.json"))
+	if err != nil {
+		return fmt.Errorf("failed to list JSON pages: %w", err)
+	}
+
+	pages := make(map[string]*generator.WikiPage, len(files))
+	paths := make(map[string]string, len(files))
+	for _, file := range files {
+		relPath := v.relPath(file)
+		v.report.Files++
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		var page generator.WikiPage
+		if err := json.Unmarshal(data, &page); err != nil || page.ID == "" {
+			if err == nil {
+				err = fmt.Errorf("missing page id")
+			}
+			v.addIssue(relPath, IssueInvalidFile, "invalid page JSON: %v", err)
+			continue
+		}
+		pages[page.ID] = &page
+		paths[page.ID] = relPath
+	}
+
+	for id, page := range pages {
+		if strings.TrimSpace(page.Content) == "" {
+			v.addIssue(paths[id], IssueEmptyPage, "page has no content")
+		}
+		if page.ParentID != "" && pages[page.ParentID] == nil {
+			v.addIssue(paths[id], IssueUnknownPage, "unknown parent %s", page.ParentID)
+		}
+		for _, related := range page.RelatedPages {
+			if pages[related] == nil {
+				v.addIssue(paths[id], IssueUnknownPage, "unknown related page %s", related)
+			}
+		}
+	}
+
+	for _, name := range []string{"wiki.json", "index.json"} {
+		data, err := os.ReadFile(filepath.Join(v.dir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		v.report.Files++
+		if !json.Valid(bytes.TrimSpace(data)) {
+			v.addIssue(name, IssueInvalidFile, "invalid JSON")
+		}
+	}
+	return nil
+}
+
+// relPath returns filePath relative to the output directory, slash-separated
+func (v *validator) relPath(filePath string) string {
+	rel, err := filepath.Rel(v.dir, filePath)
+	if err != nil {
+		return filepath.ToSlash(filePath)
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,150 @@
+package output
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kuderr/deepwiki/pkg/generator"
+	outputgen "github.com/kuderr/deepwiki/pkg/output/generator"
+)
+
+func TestValidateOutput_ReportsProblems(t *testing.T) {
+	structure := `{
+  "title": "Broken Wiki",
+  "format": "docusaurus3",
+  "pages": [
+    {"id": "overview", "title": "Overview", "url": "/overview", "importance": "high",
+     "children": ["setup"], "relatedPages": ["install"]},
+    {"id": "setup", "title": "Setup", "url": "/setup", "importance": "medium", "parentId": "overview"},
+    {"id": "api", "title": "API", "url": "/api", "importance": "low", "parentId": "reference"}
+  ]
+}`
+	dir := writeOutputFixture(t, map[string]string{
+		"structure.json": structure,
+		"docs/intro.md": "---\nid: intro\ntitle: Intro\n---\n\n" +
+			"- [Overview](./overview)\n- [Directories](/directories)\n",
+		"docs/overview.md": "---\nid: overview\ntitle: Overview: the big picture\n---\n\n# Overview\n\n" +
+			"See [setup](./setup.md#requirements), [the site](https://example.com) and [top](#overview).\n\n" +
+			"```markdown\n[ignored](./in-code-block.md)\n```\n",
+		"docs/setup.md": "---\nid: setup\ntitle: Setup\n---\n\n# Setup\n\n" +
+			"Read [the guide](./guide.md) and ![diagram](../static/img/flow.png).\n",
+		"docs/empty.md":             "---\nid: empty\ntitle: Empty\n---\n\n   \n",
+		"docs/unterminated.md":      "---\nid: unterminated\ntitle: Unterminated\n\n# Unterminated\n",
+		"docs/directories/index.md": "# Directories\n\n- [pkg/](./pkg.md)\n",
+		"docs/directories/pkg.md":   "# pkg\n\n- [Setup](../setup.md)\n",
+	}, false)
+
+	report, err := ValidateOutput(dir, "")
+	if err != nil {
+		t.Fatalf("ValidateOutput failed: %v", err)
+	}
+	if report.Format != outputgen.FormatDocusaurus3 {
+		t.Errorf("Expected the format to be read from structure.json, got %s", report.Format)
+	}
+
+	expected := []ValidationIssue{
+		{Path: "docs/empty.md", Kind: IssueEmptyPage},
+		{Path: "docs/overview.md", Kind: IssueInvalidFrontmatter},
+		{Path: "docs/setup.md", Kind: IssueBrokenLink, Message: "link to missing ./guide.md"},
+		{Path: "docs/setup.md", Kind: IssueBrokenLink, Message: "link to missing ../static/img/flow.png"},
+		{Path: "docs/unterminated.md", Kind: IssueInvalidFrontmatter},
+		{Path: "structure.json", Kind: IssueUnknownPage, Message: "page overview has unknown related page install"},
+		{Path: "structure.json", Kind: IssueUnknownPage, Message: "page api has unknown parent reference"},
+		{Path: "structure.json", Kind: IssueBrokenLink, Message: "page api links to missing /api"},
+	}
+	for _, want := range expected {
+		found := false
+		for _, issue := range report.Issues {
+			if issue.Path == want.Path && issue.Kind == want.Kind &&
+				(want.Message == "" || issue.Message == want.Message) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("Expected issue %+v, got %+v", want, report.Issues)
+		}
+	}
+	if len(report.Issues) != len(expected) {
+		t.Errorf("Expected %d issues, got %d: %+v", len(expected), len(report.Issues), report.Issues)
+	}
+}
+
+func TestValidateOutput_JSONPages(t *testing.T) {
+	dir := writeOutputFixture(t, map[string]string{
+		"wiki.json":           `{"structure": {}, "pages": {}`,
+		"pages/overview.json": `{"id": "overview", "content": "# Overview", "relatedPages": ["setup", "deploy"]}`,
+		"pages/setup.json":    `{"id": "setup", "content": "", "parentId": "overview"}`,
+		"pages/broken.json":   `{"id": `,
+	}, false)
+
+	report, err := ValidateOutput(dir, outputgen.FormatJSON)
+	if err != nil {
+		t.Fatalf("ValidateOutput failed: %v", err)
+	}
+
+	for kind, count := range map[IssueKind]int{
+		IssueEmptyPage:   1, // setup
+		IssueUnknownPage: 1, // deploy
+		IssueInvalidFile: 2, // broken.json and wiki.json
+	} {
+		if report.Count(kind) != count {
+			t.Errorf("Expected %d %s issues, got %+v", count, kind, report.Issues)
+		}
+	}
+}
+
+func TestValidateOutput_GeneratedOutputIsValid(t *testing.T) {
+	structure := &generator.WikiStructure{
+		ID:          "wiki",
+		Title:       "Test Wiki",
+		Description: "A wiki generated for validation",
+		CreatedAt:   time.Now(),
+	}
+	pages := map[string]*generator.WikiPage{
+		"overview": {
+			ID: "overview", Title: "Overview", Description: "What the project does",
+			Content:    "# Overview\n\nSee the [setup guide](https://example.com/setup) for details.",
+			Importance: "high", RelatedPages: []string{"setup"}, FilePaths: []string{"main.go"},
+			CreatedAt: time.Now(),
+		},
+		"setup": {
+			ID: "setup", Title: "Setup", Description: "How to install it",
+			Content:    "# Setup\n\nRun `make install`.",
+			Importance: "medium", ParentID: "overview", FilePaths: []string{"Makefile"},
+			CreatedAt: time.Now(),
+		},
+	}
+
+	for _, format := range NewOutputManager().ListFormats() {
+		t.Run(string(format), func(t *testing.T) {
+			dir := t.TempDir()
+			options := outputgen.OutputOptions{
+				Format:        format,
+				Directory:     dir,
+				Language:      "en",
+				ProjectName:   "demo",
+				EmitStructure: true,
+			}
+			if _, err := NewOutputManager().GenerateOutput(structure, pages, options); err != nil {
+				t.Fatalf("GenerateOutput failed: %v", err)
+			}
+
+			report, err := ValidateOutput(dir, format)
+			if err != nil {
+				t.Fatalf("ValidateOutput failed: %v", err)
+			}
+			if report.HasIssues() {
+				var issues []string
+				for _, issue := range report.Issues {
+					issues = append(issues, issue.Path+": "+issue.Message)
+				}
+				t.Errorf("Expected generated %s output to be valid, got:\n%s", format, strings.Join(issues, "\n"))
+			}
+			if report.Files == 0 {
+				t.Error("Expected files to be checked")
+			}
+		})
+	}
+}
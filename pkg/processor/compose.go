@@ -0,0 +1,219 @@
+package processor
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RedactedValue replaces environment values that look like secrets
+const RedactedValue = "********"
+
+// ComposeProject describes the services defined in a docker-compose file
+type ComposeProject struct {
+	FilePath string           `json:"filePath"` // Path of the compose file
+	Services []ComposeService `json:"services"` // Services, sorted by name
+}
+
+// ComposeService describes a single docker-compose service
+type ComposeService struct {
+	Name        string            `json:"name"`
+	Image       string            `json:"image,omitempty"`
+	Build       string            `json:"build,omitempty"`       // Build context (and Dockerfile, if set)
+	Ports       []string          `json:"ports,omitempty"`       // Port mappings as written in the file
+	DependsOn   []string          `json:"dependsOn,omitempty"`   // Names of services this one depends on
+	Environment map[string]string `json:"environment,omitempty"` // Environment keys with secrets redacted
+}
+
+// composeFile mirrors the parts of the compose schema we document.
+// Fields with several allowed shapes are decoded as raw nodes.
+type composeFile struct {
+	Services map[string]struct {
+		Image       string    `yaml:"image"`
+		Build       yaml.Node `yaml:"build"`
+		Ports       yaml.Node `yaml:"ports"`
+		DependsOn   yaml.Node `yaml:"depends_on"`
+		Environment yaml.Node `yaml:"environment"`
+	} `yaml:"services"`
+}
+
+// secretKeyMarkers identify environment keys whose values must not be published
+var secretKeyMarkers = []string{
+	"PASSWORD", "PASSWD", "SECRET", "TOKEN", "KEY", "CREDENTIAL", "PRIVATE", "AUTH", "DSN",
+}
+
+// IsComposeFile reports whether a file name is a docker-compose file
+func IsComposeFile(name string) bool {
+	name = strings.ToLower(filepath.Base(name))
+	ext := filepath.Ext(name)
+	if ext != ".yml" && ext != ".yaml" {
+		return false
+	}
+
+	base := strings.TrimSuffix(name, ext)
+	return base == "compose" || base == "docker-compose" || strings.HasPrefix(base, "docker-compose.") ||
+		strings.HasPrefix(base, "compose.")
+}
+
+// ParseComposeFile parses docker-compose content into a ComposeProject
+func ParseComposeFile(filePath string, content []byte) (*ComposeProject, error) {
+	var file composeFile
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse compose file %s: %w", filePath, err)
+	}
+
+	project := &ComposeProject{
+		FilePath: filePath,
+		Services: make([]ComposeService, 0, len(file.Services)),
+	}
+
+	for name, svc := range file.Services {
+		project.Services = append(project.Services, ComposeService{
+			Name:        name,
+			Image:       svc.Image,
+			Build:       composeBuild(&svc.Build),
+			Ports:       composePorts(&svc.Ports),
+			DependsOn:   composeDependsOn(&svc.DependsOn),
+			Environment: composeEnvironment(&svc.Environment),
+		})
+	}
+
+	sort.Slice(project.Services, func(i, j int) bool {
+		return project.Services[i].Name < project.Services[j].Name
+	})
+
+	return project, nil
+}
+
+// composeBuild handles both `build: ./dir` and `build: {context: ., dockerfile: ...}`
+func composeBuild(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return node.Value
+	case yaml.MappingNode:
+		var build struct {
+			Context    string `yaml:"context"`
+			Dockerfile string `yaml:"dockerfile"`
+		}
+		if err := node.Decode(&build); err != nil {
+			return ""
+		}
+		if build.Dockerfile != "" {
+			return fmt.Sprintf("%s (%s)", build.Context, build.Dockerfile)
+		}
+		return build.Context
+	}
+	return ""
+}
+
+// composePorts handles short ("8080:80") and long ({published, target}) port syntax
+func composePorts(node *yaml.Node) []string {
+	if node.Kind != yaml.SequenceNode {
+		return nil
+	}
+
+	ports := make([]string, 0, len(node.Content))
+	for _, item := range node.Content {
+		switch item.Kind {
+		case yaml.ScalarNode:
+			ports = append(ports, item.Value)
+		case yaml.MappingNode:
+			var port struct {
+				Target    string `yaml:"target"`
+				Published string `yaml:"published"`
+				Protocol  string `yaml:"protocol"`
+			}
+			if err := item.Decode(&port); err != nil {
+				continue
+			}
+			mapping := port.Target
+			if port.Published != "" {
+				mapping = port.Published + ":" + port.Target
+			}
+			if port.Protocol != "" {
+				mapping += "/" + port.Protocol
+			}
+			ports = append(ports, mapping)
+		}
+	}
+	return ports
+}
+
+// composeDependsOn handles both list and map forms of depends_on
+func composeDependsOn(node *yaml.Node) []string {
+	var deps []string
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			deps = append(deps, item.Value)
+		}
+	case yaml.MappingNode:
+		for i := 0; i < len(node.Content); i += 2 {
+			deps = append(deps, node.Content[i].Value)
+		}
+	}
+	sort.Strings(deps)
+	return deps
+}
+
+// composeEnvironment handles list ("KEY=value") and map forms, redacting secret values
+func composeEnvironment(node *yaml.Node) map[string]string {
+	env := make(map[string]string)
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			key, value, _ := strings.Cut(item.Value, "=")
+			env[key] = redactEnvValue(key, value)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			env[key] = redactEnvValue(key, node.Content[i+1].Value)
+		}
+	}
+
+	if len(env) == 0 {
+		return nil
+	}
+	return env
+}
+
+// redactEnvValue masks values of secret-looking keys; interpolations such as ${VAR} are kept
+func redactEnvValue(key, value string) string {
+	if value == "" || (strings.HasPrefix(value, "${") && strings.HasSuffix(value, "}")) {
+		return value
+	}
+
+	upperKey := strings.ToUpper(key)
+	for _, marker := range secretKeyMarkers {
+		if strings.Contains(upperKey, marker) {
+			return RedactedValue
+		}
+	}
+	return value
+}
+
+// extractComposeProjects parses the compose files among the processed documents
+func (tp *TextProcessor) extractComposeProjects(result *ProcessingResult) {
+	for _, doc := range result.Documents {
+		if !IsComposeFile(doc.FilePath) {
+			continue
+		}
+
+		project, err := ParseComposeFile(doc.FilePath, []byte(doc.Content))
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+			continue
+		}
+		if len(project.Services) > 0 {
+			result.ComposeProjects = append(result.ComposeProjects, *project)
+		}
+	}
+
+	sort.Slice(result.ComposeProjects, func(i, j int) bool {
+		return result.ComposeProjects[i].FilePath < result.ComposeProjects[j].FilePath
+	})
+}
@@ -0,0 +1,146 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kuderr/deepwiki/pkg/scanner"
+)
+
+const testComposeFile = `services:
+  api:
+    build:
+      context: ./api
+      dockerfile: Dockerfile.dev
+    ports:
+      - "8080:80"
+      - target: 9090
+        published: 9091
+        protocol: tcp
+    depends_on:
+      db:
+        condition: service_healthy
+      cache:
+        condition: service_started
+    environment:
+      LOG_LEVEL: debug
+      DATABASE_PASSWORD: hunter2
+      API_TOKEN: ${API_TOKEN}
+  db:
+    image: postgres:16
+    ports:
+      - 5432:5432
+    environment:
+      - POSTGRES_USER=app
+      - POSTGRES_PASSWORD=supersecret
+      - PGDATA
+  cache:
+    image: redis:7
+`
+
+func TestIsComposeFile(t *testing.T) {
+	tests := map[string]bool{
+		"docker-compose.yml":             true,
+		"docker-compose.yaml":            true,
+		"compose.yaml":                   true,
+		"deploy/docker-compose.prod.yml": true,
+		"config.yaml":                    false,
+		"docker-compose.json":            false,
+	}
+
+	for name, expected := range tests {
+		if got := IsComposeFile(name); got != expected {
+			t.Errorf("IsComposeFile(%q) = %v, expected %v", name, got, expected)
+		}
+	}
+}
+
+func TestParseComposeFile(t *testing.T) {
+	project, err := ParseComposeFile("docker-compose.yml", []byte(testComposeFile))
+	if err != nil {
+		t.Fatalf("ParseComposeFile failed: %v", err)
+	}
+
+	if len(project.Services) != 3 {
+		t.Fatalf("Expected 3 services, got %d", len(project.Services))
+	}
+
+	services := make(map[string]ComposeService)
+	for _, svc := range project.Services {
+		services[svc.Name] = svc
+	}
+
+	api := services["api"]
+	if api.Build != "./api (Dockerfile.dev)" {
+		t.Errorf("Unexpected api build: %q", api.Build)
+	}
+	if len(api.Ports) != 2 || api.Ports[0] != "8080:80" || api.Ports[1] != "9091:9090/tcp" {
+		t.Errorf("Unexpected api ports: %v", api.Ports)
+	}
+	if len(api.DependsOn) != 2 || api.DependsOn[0] != "cache" || api.DependsOn[1] != "db" {
+		t.Errorf("Unexpected api depends_on: %v", api.DependsOn)
+	}
+	if api.Environment["LOG_LEVEL"] != "debug" {
+		t.Errorf("Non-secret value should be kept, got %q", api.Environment["LOG_LEVEL"])
+	}
+	if api.Environment["DATABASE_PASSWORD"] != RedactedValue {
+		t.Errorf("Password should be redacted, got %q", api.Environment["DATABASE_PASSWORD"])
+	}
+	if api.Environment["API_TOKEN"] != "${API_TOKEN}" {
+		t.Errorf("Interpolation should be kept, got %q", api.Environment["API_TOKEN"])
+	}
+
+	db := services["db"]
+	if db.Image != "postgres:16" {
+		t.Errorf("Unexpected db image: %q", db.Image)
+	}
+	if len(db.Ports) != 1 || db.Ports[0] != "5432:5432" {
+		t.Errorf("Unexpected db ports: %v", db.Ports)
+	}
+	if db.Environment["POSTGRES_PASSWORD"] != RedactedValue {
+		t.Errorf("Password should be redacted, got %q", db.Environment["POSTGRES_PASSWORD"])
+	}
+	if value, ok := db.Environment["PGDATA"]; !ok || value != "" {
+		t.Errorf("Expected PGDATA key without value, got %q (present: %v)", value, ok)
+	}
+}
+
+func TestParseComposeFile_Invalid(t *testing.T) {
+	if _, err := ParseComposeFile("docker-compose.yml", []byte("services: [unclosed")); err == nil {
+		t.Error("Expected error for invalid YAML")
+	}
+}
+
+func TestProcessFiles_ComposeProjects(t *testing.T) {
+	tempDir := t.TempDir()
+	composePath := filepath.Join(tempDir, "docker-compose.yml")
+	if err := os.WriteFile(composePath, []byte(testComposeFile), 0o644); err != nil {
+		t.Fatalf("Failed to write compose file: %v", err)
+	}
+
+	files := []scanner.FileInfo{
+		{
+			Path:         "docker-compose.yml",
+			AbsolutePath: composePath,
+			Name:         "docker-compose.yml",
+			Extension:    ".yaml",
+			Language:     "YAML",
+			Category:     "config",
+			IsText:       true,
+		},
+	}
+
+	processor := NewTextProcessor(DefaultProcessingOptions())
+	result, err := processor.ProcessFiles(files)
+	if err != nil {
+		t.Fatalf("ProcessFiles failed: %v", err)
+	}
+
+	if len(result.ComposeProjects) != 1 {
+		t.Fatalf("Expected 1 compose project, got %d", len(result.ComposeProjects))
+	}
+	if len(result.ComposeProjects[0].Services) != 3 {
+		t.Errorf("Expected 3 services, got %d", len(result.ComposeProjects[0].Services))
+	}
+}
@@ -0,0 +1,216 @@
+package processor
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DeclarationKind is the kind of a declaration found in source code
+type DeclarationKind string
+
+const (
+	DeclarationFunction  DeclarationKind = "function"
+	DeclarationMethod    DeclarationKind = "method"
+	DeclarationClass     DeclarationKind = "class" // Classes, structs, enums and records
+	DeclarationInterface DeclarationKind = "interface"
+	DeclarationType      DeclarationKind = "type" // Other named types and type aliases
+)
+
+// Declaration is a function, method or type declared in source code
+type Declaration struct {
+	Kind      DeclarationKind `json:"kind"`
+	Name      string          `json:"name"`
+	Receiver  string          `json:"receiver,omitempty"` // Receiver type of a Go method
+	Signature string          `json:"signature"`          // Declaration header before its body, on one line
+	Offset    int             `json:"offset"`             // Byte offset of the name in the content
+}
+
+// maxSignatureLength caps declaration headers, which may run on in whitespace-normalized chunks
+const maxSignatureLength = 160
+
+// declarationPattern finds declarations of one kind; the declared name is the "name" group
+type declarationPattern struct {
+	kind DeclarationKind
+	re   *regexp.Regexp
+}
+
+// declarationStart anchors a declaration at the start of the content, a line or a statement.
+// Chunks are usually whitespace-normalized, so line starts cannot be relied on.
+const declarationStart = `(?:^|[\s;{}])`
+
+// jsIdentifier matches a JavaScript or TypeScript identifier
+const jsIdentifier = `[A-Za-z_$][\w$]*`
+
+// jsKeywords are keywords that look like method names before a parenthesis and a block
+var jsKeywords = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "catch": true, "with": true,
+	"function": true, "return": true, "else": true, "do": true, "try": true,
+}
+
+func declaration(kind DeclarationKind, expr string) declarationPattern {
+	return declarationPattern{kind: kind, re: regexp.MustCompile(declarationStart + expr)}
+}
+
+// javaScriptDeclarations are shared by JavaScript and TypeScript
+var javaScriptDeclarations = []declarationPattern{
+	declaration(DeclarationClass,
+		`(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+(?P<name>`+jsIdentifier+`)`),
+	declaration(DeclarationFunction,
+		`(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*(?P<name>`+jsIdentifier+`)\s*[<(]`),
+	// Arrow functions and function expressions assigned to a variable
+	declaration(DeclarationFunction,
+		`(?:export\s+)?(?:const|let|var)\s+(?P<name>`+jsIdentifier+`)\s*(?::[^=]+)?=\s*(?:async\s+)?`+
+			`(?:function\b|(?:<[^>]*>\s*)?(?:\([^)]*\)|`+jsIdentifier+`)\s*(?::\s*[^=]+?)?\s*=>)`),
+	declaration(DeclarationMethod,
+		`(?:(?:public|private|protected|static|async|readonly|override|get|set)\s+)*`+
+			`(?P<name>`+jsIdentifier+`)\s*\([^)]*\)\s*(?::\s*[^{;=]+)?\{`),
+}
+
+// declarationPatterns are the declaration patterns of each language, checked in order. Where
+// several patterns match the same name, the first one decides its kind.
+var declarationPatterns = map[string][]declarationPattern{
+	"Go": {
+		declaration(DeclarationMethod,
+			`func\s*\(\s*(?:\w+\s+)?\*?\s*(?P<receiver>\w+)(?:\[[^\]]*\])?\s*\)\s*(?P<name>\w+)\s*[\[(]`),
+		declaration(DeclarationFunction, `func\s+(?P<name>\w+)\s*[\[(]`),
+		declaration(DeclarationInterface, `type\s+(?P<name>\w+)(?:\[[^\]]*\])?\s+interface\s*\{`),
+		declaration(DeclarationClass, `type\s+(?P<name>\w+)(?:\[[^\]]*\])?\s+struct\s*\{`),
+		declaration(DeclarationType, `type\s+(?P<name>\w+)(?:\[[^\]]*\])?\s+(?:=\s*)?[\w*\[]`),
+	},
+	"Python": {
+		declaration(DeclarationClass, `class\s+(?P<name>\w+)\s*[(:]`),
+		declaration(DeclarationMethod, `(?:async\s+)?def\s+(?P<name>\w+)\s*\(\s*(?:self|cls)\b`),
+		declaration(DeclarationFunction, `(?:async\s+)?def\s+(?P<name>\w+)\s*\(`),
+	},
+	"JavaScript": javaScriptDeclarations,
+	"TypeScript": append([]declarationPattern{
+		declaration(DeclarationInterface, `(?:export\s+)?interface\s+(?P<name>`+jsIdentifier+`)`),
+		declaration(DeclarationType,
+			`(?:export\s+)?type\s+(?P<name>`+jsIdentifier+`)\s*(?:<[^>]*>)?\s*=`),
+	}, javaScriptDeclarations...),
+	"Java": {
+		declaration(DeclarationInterface,
+			`(?:(?:public|private|protected|abstract|static|sealed)\s+)*@?interface\s+(?P<name>\w+)`),
+		declaration(DeclarationClass,
+			`(?:(?:public|private|protected|abstract|final|static|sealed)\s+)*(?:class|enum|record)\s+(?P<name>\w+)`),
+		declaration(DeclarationMethod,
+			`(?:(?:public|private|protected|static|final|abstract|synchronized|default)\s+)+`+
+				`(?:<[^>]*>\s*)?[\w.]+(?:<[^>]*>)?(?:\[\])*\s+(?P<name>\w+)\s*\(`),
+	},
+}
+
+// genericDeclarations are used for languages without their own patterns
+var genericDeclarations = []declarationPattern{
+	declaration(DeclarationClass, `(?:class|struct|trait)\s+(?P<name>[A-Za-z_]\w*)`),
+	declaration(DeclarationInterface, `interface\s+(?P<name>[A-Za-z_]\w*)`),
+	declaration(DeclarationFunction, `(?:function|func|def|fn|sub)\s+(?P<name>[A-Za-z_]\w*)\s*[<(]`),
+}
+
+// FindDeclarations returns the functions, methods and types declared in content, in the order
+// they appear
+func (p *LanguageSpecificProcessor) FindDeclarations(content string) []Declaration {
+	patterns, ok := declarationPatterns[p.Language]
+	if !ok {
+		patterns = genericDeclarations
+	}
+
+	// Python bodies start after a colon, the others with a brace
+	bodyStart := byte('{')
+	if p.Language == "Python" {
+		bodyStart = ':'
+	}
+
+	type found struct {
+		Declaration
+		start, nameEnd int
+	}
+
+	var matches []found
+	seen := make(map[int]bool)
+	for _, pattern := range patterns {
+		nameGroup := pattern.re.SubexpIndex("name")
+		receiverGroup := pattern.re.SubexpIndex("receiver")
+		for _, match := range pattern.re.FindAllStringSubmatchIndex(content, -1) {
+			start, end := match[2*nameGroup], match[2*nameGroup+1]
+			name := content[start:end]
+			if seen[start] || (p.Language == "JavaScript" || p.Language == "TypeScript") && jsKeywords[name] {
+				continue
+			}
+			seen[start] = true
+
+			declaration := found{
+				Declaration: Declaration{Kind: pattern.kind, Name: name, Offset: start},
+				start:       match[0],
+				nameEnd:     end,
+			}
+			if receiverGroup >= 0 && match[2*receiverGroup] >= 0 {
+				declaration.Receiver = content[match[2*receiverGroup]:match[2*receiverGroup+1]]
+			}
+			matches = append(matches, declaration)
+		}
+	}
+
+	if len(matches) == 0 {
+		return nil
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Offset < matches[j].Offset
+	})
+
+	// A header ends where the next declaration starts at the latest
+	declarations := make([]Declaration, len(matches))
+	for i, match := range matches {
+		limit := len(content)
+		if i+1 < len(matches) {
+			limit = max(matches[i+1].start, match.nameEnd)
+		}
+		match.Signature = signature(content[:limit], match.start, match.nameEnd, bodyStart)
+		declarations[i] = match.Declaration
+	}
+	return declarations
+}
+
+// IsDefinition reports whether content defines a function, method, class, interface or type,
+// detected with the declaration rules of language
+func IsDefinition(content, language string) bool {
+	return GetLanguageProcessor(language).IsDefinition(content)
+}
+
+// IsDefinition reports whether content defines a function, method, class, interface or type
+func (p *LanguageSpecificProcessor) IsDefinition(content string) bool {
+	return len(p.FindDeclarations(content)) > 0
+}
+
+// HasDeclaration reports whether declarations include one of the given kinds
+func HasDeclaration(declarations []Declaration, kinds ...DeclarationKind) bool {
+	for _, declaration := range declarations {
+		for _, kind := range kinds {
+			if declaration.Kind == kind {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// signature returns the header of the declaration matched from start, whose name ends at nameEnd:
+// the text up to its body, a semicolon or the end of the line, with whitespace collapsed
+func signature(content string, start, nameEnd int, bodyStart byte) string {
+	start += len(content[start:nameEnd]) - len(strings.TrimLeft(content[start:nameEnd], " \t\r\n;{}"))
+
+	end, depth := nameEnd, 0
+scan:
+	for ; end < len(content) && end-start < maxSignatureLength; end++ {
+		switch c := content[end]; {
+		case c == '(' || c == '[' || c == '<':
+			depth++
+		case (c == ')' || c == ']' || c == '>') && depth > 0 && content[end-1] != '=':
+			depth--
+		case c == '\n' || c == ';' || depth == 0 && c == bodyStart:
+			break scan
+		}
+	}
+
+	return strings.Join(strings.Fields(content[start:end]), " ")
+}
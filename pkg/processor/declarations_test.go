@@ -0,0 +1,141 @@
+package processor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindDeclarations(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		content  string
+		want     []Declaration
+	}{
+		{
+			name:     "Go method",
+			language: "Go",
+			content: "// Search finds documents\n" +
+				"func (vdb *BoltVectorDB) Search(vector []float32) ([]Result, error) {\n" +
+				"\tresults := rank(vector)\n\treturn results, nil\n}\n",
+			want: []Declaration{{Kind: DeclarationMethod, Name: "Search", Receiver: "BoltVectorDB",
+				Signature: "func (vdb *BoltVectorDB) Search(vector []float32) ([]Result, error)"}},
+		},
+		{
+			name:     "Go types and function",
+			language: "Go",
+			content: "type Store interface {\n\tGet(id string) error\n}\n\n" +
+				"type Options struct {\n\tLimit int\n}\n\ntype Metric string\n\n" +
+				"func NewStore[T any](opts Options) *Store { return nil }\n",
+			want: []Declaration{
+				{Kind: DeclarationInterface, Name: "Store", Signature: "type Store interface"},
+				{Kind: DeclarationClass, Name: "Options", Signature: "type Options struct"},
+				{Kind: DeclarationType, Name: "Metric", Signature: "type Metric string"},
+				{Kind: DeclarationFunction, Name: "NewStore", Signature: "func NewStore[T any](opts Options) *Store"},
+			},
+		},
+		{
+			name:     "Python class",
+			language: "Python",
+			content: "class UserRepository(BaseRepository):\n" +
+				"    def find(self, user_id):\n        return self.db.get(user_id)\n\n" +
+				"def connect(url):\n    return Client(url)\n",
+			want: []Declaration{
+				{Kind: DeclarationClass, Name: "UserRepository", Signature: "class UserRepository(BaseRepository)"},
+				{Kind: DeclarationMethod, Name: "find", Signature: "def find(self, user_id)"},
+				{Kind: DeclarationFunction, Name: "connect", Signature: "def connect(url)"},
+			},
+		},
+		{
+			name:     "TypeScript arrow function",
+			language: "TypeScript",
+			content: "export const fetchUser = async (id: string): Promise<User> => {\n" +
+				"  const response = await fetch(`/users/${id}`);\n  if (!response.ok) {\n" +
+				"    throw new Error('failed');\n  }\n  return response.json();\n};\n",
+			want: []Declaration{{Kind: DeclarationFunction, Name: "fetchUser",
+				Signature: "export const fetchUser = async (id: string): Promise<User> =>"}},
+		},
+		{
+			name:     "JavaScript class and function",
+			language: "JavaScript",
+			content: "class Cache {\n  get(key) {\n    return this.items[key];\n  }\n}\n\n" +
+				"function createCache() { return new Cache(); }\n",
+			want: []Declaration{
+				{Kind: DeclarationClass, Name: "Cache", Signature: "class Cache"},
+				{Kind: DeclarationMethod, Name: "get", Signature: "get(key)"},
+				{Kind: DeclarationFunction, Name: "createCache", Signature: "function createCache()"},
+			},
+		},
+		{
+			name:     "no declarations",
+			language: "Go",
+			content:  "\tresult := compute(input)\n\tif result > 0 {\n\t\tfmt.Println(\"done\", result)\n\t}\n",
+			want:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor := GetLanguageProcessor(tt.language)
+
+			// Chunks are usually whitespace-normalized, so both forms must give the same declarations
+			for _, content := range []string{tt.content, strings.Join(strings.Fields(tt.content), " ")} {
+				got := processor.FindDeclarations(content)
+				for i := range got {
+					if !strings.HasPrefix(content[got[i].Offset:], got[i].Name) {
+						t.Errorf("Expected offset %d to point at %s", got[i].Offset, got[i].Name)
+					}
+					got[i].Offset = 0
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("Expected declarations %+v, got %+v", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestIsDefinition(t *testing.T) {
+	tests := []struct {
+		language string
+		content  string
+		want     bool
+	}{
+		{"Go", "func Serve(addr string) error {", true},
+		{"Go", "func (s *Server) Close() error {", true},
+		{"Go", "type Server struct {", true},
+		{"Go", "type Handler interface {", true},
+		{"Go", "type Metric string", true},
+		{"Go", "return serve(addr)", false},
+		{"Go", "// Serve listens on addr", false},
+		{"Python", "def load(path):", true},
+		{"Python", "    def close(self):", true},
+		{"Python", "class Loader(Base):", true},
+		{"Python", "loader = Loader(path)", false},
+		{"JavaScript", "function render(props) {", true},
+		{"JavaScript", "export const render = (props) => {", true},
+		{"JavaScript", "class View extends Base {", true},
+		{"JavaScript", "if (ready) {", false},
+		{"JavaScript", "render(props);", false},
+		{"TypeScript", "export interface Props {", true},
+		{"TypeScript", "type Handler = (event: Event) => void;", true},
+		{"TypeScript", "export async function load(id: string): Promise<Item> {", true},
+		{"TypeScript", "const item = await load(id);", false},
+		{"Java", "public class Server {", true},
+		{"Java", "public interface Handler {", true},
+		{"Java", "enum Color { RED, GREEN }", true},
+		{"Java", "private static int count(List<String> items) {", true},
+		{"Java", "int total = count(items);", false},
+		{"Rust", "fn parse(input: &str) -> Result<Ast> {", true},
+		{"Rust", "let ast = parse(input)?;", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.language+"/"+tt.content, func(t *testing.T) {
+			if got := IsDefinition(tt.content, tt.language); got != tt.want {
+				t.Errorf("IsDefinition(%q, %s) = %v, want %v", tt.content, tt.language, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,12 +1,14 @@
 package processor
 
 import (
+	"bytes"
 	"crypto/md5"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"strings"
 	"sync"
 	"time"
@@ -33,6 +35,20 @@ func NewTextProcessor(options *ProcessingOptions) *TextProcessor {
 
 // ProcessFiles processes multiple files and returns documents with chunks
 func (tp *TextProcessor) ProcessFiles(files []scanner.FileInfo) (*ProcessingResult, error) {
+	return tp.ProcessFilesReusing(files, nil)
+}
+
+// ProcessFilesReusing is ProcessFiles taking the documents reuse returns, such as those processed
+// earlier from unchanged files, instead of processing their files again. A nil reuse processes
+// every file.
+func (tp *TextProcessor) ProcessFilesReusing(
+	files []scanner.FileInfo,
+	reuse func(file scanner.FileInfo) (*Document, bool),
+) (*ProcessingResult, error) {
+	if err := tp.options.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid processing options: %w", err)
+	}
+
 	startTime := time.Now()
 
 	result := &ProcessingResult{
@@ -41,16 +57,36 @@ func (tp *TextProcessor) ProcessFiles(files []scanner.FileInfo) (*ProcessingResu
 		Errors:     make([]string, 0),
 	}
 
+	process := tp.ProcessFile
+	if reuse != nil {
+		process = func(file scanner.FileInfo) (*Document, error) {
+			if doc, ok := reuse(file); ok {
+				return doc, nil
+			}
+			return tp.ProcessFile(file)
+		}
+	}
+
+	var err error
 	if tp.options.Concurrent {
-		return tp.processFilesConcurrent(files, result, startTime)
+		result, err = tp.processFilesConcurrent(files, process, result, startTime)
+	} else {
+		result, err = tp.processFilesSequential(files, process, result, startTime)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	return tp.processFilesSequential(files, result, startTime)
+	// Extract structured data used for generated pages
+	tp.extractComposeProjects(result)
+
+	return result, nil
 }
 
-// processFilesSequential processes files one by one
+// processFilesSequential processes files one by one with process
 func (tp *TextProcessor) processFilesSequential(
 	files []scanner.FileInfo,
+	process func(file scanner.FileInfo) (*Document, error),
 	result *ProcessingResult,
 	startTime time.Time,
 ) (*ProcessingResult, error) {
@@ -59,7 +95,7 @@ func (tp *TextProcessor) processFilesSequential(
 			continue
 		}
 
-		doc, err := tp.ProcessFile(file)
+		doc, err := process(file)
 		if err != nil {
 			result.Errors = append(result.Errors, fmt.Sprintf("Error processing %s: %v", file.Path, err))
 			continue
@@ -78,9 +114,11 @@ func (tp *TextProcessor) processFilesSequential(
 	return result, nil
 }
 
-// processFilesConcurrent processes files concurrently
+// processFilesConcurrent processes files with process in MaxWorkers workers. Documents and
+// errors are collected in file order, as by processFilesSequential.
 func (tp *TextProcessor) processFilesConcurrent(
 	files []scanner.FileInfo,
+	process func(file scanner.FileInfo) (*Document, error),
 	result *ProcessingResult,
 	startTime time.Time,
 ) (*ProcessingResult, error) {
@@ -94,69 +132,48 @@ func (tp *TextProcessor) processFilesConcurrent(
 
 	maxWorkers := tp.options.MaxWorkers
 	if maxWorkers <= 0 {
-		maxWorkers = 4
+		maxWorkers = runtime.NumCPU()
 	}
 
-	jobs := make(chan scanner.FileInfo, len(validFiles))
-	results := make(chan *Document, len(validFiles))
-	errors := make(chan error, len(validFiles))
+	docs := make([]*Document, len(validFiles))
+	errs := make([]error, len(validFiles))
+	jobs := make(chan int, len(validFiles))
 
 	// Start workers
 	var wg sync.WaitGroup
-	for i := 0; i < maxWorkers; i++ {
+	for range min(maxWorkers, len(validFiles)) {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for file := range jobs {
-				doc, err := tp.ProcessFile(file)
-				if err != nil {
-					errors <- fmt.Errorf("error processing %s: %v", file.Path, err)
-					continue
-				}
-				if doc != nil {
-					results <- doc
-				}
+			for i := range jobs {
+				docs[i], errs[i] = process(validFiles[i])
 			}
 		}()
 	}
 
 	// Send jobs
-	for _, file := range validFiles {
-		jobs <- file
+	for i := range validFiles {
+		jobs <- i
 	}
 	close(jobs)
+	wg.Wait()
 
-	// Wait for completion and close channels
-	go func() {
-		wg.Wait()
-		close(results)
-		close(errors)
-	}()
-
-	// Collect results
-	documents := make([]Document, 0, len(validFiles))
-	errorMsgs := make([]string, 0)
-	totalChunks := 0
-	totalTokens := 0
-
-	for doc := range results {
-		documents = append(documents, *doc)
-		totalChunks += len(doc.Chunks)
-		for _, chunk := range doc.Chunks {
-			totalTokens += chunk.TokenCount
+	// Collect results in file order
+	for i, doc := range docs {
+		if errs[i] != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("Error processing %s: %v", validFiles[i].Path, errs[i]))
+			continue
+		}
+		if doc != nil {
+			result.Documents = append(result.Documents, *doc)
+			result.TotalChunks += len(doc.Chunks)
+			for _, chunk := range doc.Chunks {
+				result.TotalTokens += chunk.TokenCount
+			}
 		}
 	}
 
-	for err := range errors {
-		errorMsgs = append(errorMsgs, err.Error())
-	}
-
-	result.Documents = documents
-	result.TotalChunks = totalChunks
-	result.TotalTokens = totalTokens
-	result.Errors = errorMsgs
 	result.ProcessingTime = time.Since(startTime)
-
 	return result, nil
 }
 
@@ -164,12 +181,13 @@ func (tp *TextProcessor) processFilesConcurrent(
 func (tp *TextProcessor) ProcessFile(fileInfo scanner.FileInfo) (*Document, error) {
 	content, err := tp.readFileContent(fileInfo.AbsolutePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file %s: %v", fileInfo.Path, err)
+		return nil, fmt.Errorf("failed to read file %s: %w", fileInfo.Path, err)
 	}
 
 	if len(content) == 0 {
 		return nil, nil // Skip empty files
 	}
+	text := normalizeLineEndings(string(content))
 
 	// Create document
 	doc := &Document{
@@ -177,7 +195,7 @@ func (tp *TextProcessor) ProcessFile(fileInfo scanner.FileInfo) (*Document, erro
 		FilePath:    fileInfo.Path,
 		Language:    fileInfo.Language,
 		Category:    fileInfo.Category,
-		Content:     string(content),
+		Content:     text,
 		ProcessedAt: time.Now(),
 		Size:        fileInfo.Size,
 		LineCount:   fileInfo.LineCount,
@@ -190,11 +208,8 @@ func (tp *TextProcessor) ProcessFile(fileInfo scanner.FileInfo) (*Document, erro
 	doc.Metadata["extension"] = fileInfo.Extension
 	doc.Metadata["modTime"] = fileInfo.ModTime.Format(time.RFC3339)
 
-	// Preprocess content
-	processedContent := tp.preprocessContent(string(content), fileInfo.Language)
-
 	// Create chunks
-	chunks, err := tp.ChunkText(processedContent, fileInfo)
+	chunks, err := tp.ChunkText(text, fileInfo)
 	if err != nil {
 		return nil, fmt.Errorf("failed to chunk text: %v", err)
 	}
@@ -203,27 +218,61 @@ func (tp *TextProcessor) ProcessFile(fileInfo scanner.FileInfo) (*Document, erro
 	return doc, nil
 }
 
-// ChunkText splits text into chunks based on the configured strategy
+// ChunkText preprocesses and splits text into chunks based on the configured strategy
 func (tp *TextProcessor) ChunkText(content string, fileInfo scanner.FileInfo) ([]TextChunk, error) {
 	if len(content) == 0 {
 		return nil, nil
 	}
+	if err := tp.options.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid processing options: %w", err)
+	}
+
+	// Chunk boundaries are found line by line, whatever the line endings of the file
+	content = normalizeLineEndings(content)
 
 	// Get language-specific processor
 	langProcessor := GetLanguageProcessor(fileInfo.Language)
 
-	// Try semantic chunking first for code files
+	// Try semantic chunking first for code files, falling back to word-based chunking
+	var chunks []TextChunk
 	if fileInfo.Category == string(scanner.CategoryCode) {
-		if chunks := tp.chunkBySemanticBoundaries(content, langProcessor, fileInfo); len(chunks) > 0 {
-			return chunks, nil
-		}
+		chunks = tp.chunkBySemanticBoundaries(content, langProcessor, fileInfo)
+	}
+	if len(chunks) == 0 {
+		chunks = tp.chunkByWords(tp.preprocessContent(content, fileInfo.Language), fileInfo)
+	}
+
+	// Files shorter than MinChunkWords, such as a small main.go or config file, are kept whole
+	// rather than dropped
+	if len(chunks) == 0 {
+		chunks = tp.chunkWholeFile(content, fileInfo)
+	}
+	return chunks, nil
+}
+
+// chunkWholeFile returns a single chunk with all of content, flagged with wholeFile metadata, or
+// no chunk when content is blank
+func (tp *TextProcessor) chunkWholeFile(content string, fileInfo scanner.FileInfo) []TextChunk {
+	text := tp.preprocessContent(content, fileInfo.Language)
+	if strings.TrimSpace(text) == "" {
+		return nil
 	}
 
-	// Fall back to word-based chunking
-	return tp.chunkByWords(content, fileInfo), nil
+	chunk := tp.newChunk(fileInfo, 0, text, 0, map[string]string{
+		"wholeFile": "true",
+		"startLine": "1",
+		"endLine":   fmt.Sprintf("%d", len(strings.Split(content, "\n"))),
+	})
+	chunk.EndPos = len(content)
+	return []TextChunk{chunk}
 }
 
-// chunkBySemanticBoundaries attempts to chunk code by semantic boundaries
+// chunkBySemanticBoundaries splits source content into chunks at declaration boundaries.
+// Sections shorter than MinChunkWords are merged into the following section, and a short
+// final section is merged into the previous chunk. Chunks are cut at MaxChunkWords, or at the
+// chunk size of the file's category when one is configured. At most MaxChunks chunks are returned.
+// Chunks record the 1-based source lines they cover as startLine and endLine metadata, and
+// StartPos and EndPos as byte offsets into content; chunk text is preprocessed after the split.
 func (tp *TextProcessor) chunkBySemanticBoundaries(
 	content string,
 	langProcessor *LanguageSpecificProcessor,
@@ -232,111 +281,94 @@ func (tp *TextProcessor) chunkBySemanticBoundaries(
 	lines := strings.Split(content, "\n")
 	chunks := make([]TextChunk, 0)
 
+	// lineOffsets[i] is the byte offset of line i; the extra entry marks the end of content
+	lineOffsets := make([]int, len(lines)+1)
+	for i, line := range lines {
+		lineOffsets[i+1] = lineOffsets[i] + len(line) + 1
+	}
+	lineOffsets[len(lines)] = len(content)
+
 	currentChunk := make([]string, 0)
-	currentPos := 0
-	chunkID := 0
+	startLine := 0
+
+	maxWords := tp.options.MaxChunkWords
+	if size := tp.options.ChunkSizes[fileInfo.Category]; size > 0 {
+		maxWords = size
+	}
+
+	// emit turns the current lines, ending before line endLine, into a chunk
+	emit := func(endLine int, metadata map[string]string) {
+		chunkText := tp.preprocessContent(strings.Join(currentChunk, "\n"), fileInfo.Language)
+		metadata["semantic"] = "true"
+		metadata["startLine"] = fmt.Sprintf("%d", startLine+1)
+		metadata["endLine"] = fmt.Sprintf("%d", endLine)
+
+		chunk := tp.newChunk(fileInfo, len(chunks), chunkText, lineOffsets[startLine], metadata)
+		chunk.EndPos = lineOffsets[endLine]
+		chunks = append(chunks, chunk)
+		currentChunk = make([]string, 0)
+		startLine = endLine
+	}
 
 	for i, line := range lines {
-		// Check if line starts a new semantic boundary
+		// Check if line starts a new semantic boundary: a boundary keyword or a definition
+		trimmed := strings.TrimSpace(line)
 		isNewBoundary := false
 		for _, boundary := range langProcessor.ChunkBoundaries {
-			if strings.HasPrefix(strings.TrimSpace(line), boundary) {
+			if strings.HasPrefix(trimmed, boundary) {
 				isNewBoundary = true
 				break
 			}
 		}
+		if !isNewBoundary && trimmed != "" {
+			isNewBoundary = langProcessor.IsDefinition(trimmed)
+		}
 
-		// If we hit a boundary and have content, finalize current chunk
-		if isNewBoundary && len(currentChunk) > 0 {
-			chunkText := strings.Join(currentChunk, "\n")
-			wordCount := countWords(chunkText)
-
-			if wordCount >= tp.options.MinChunkWords {
-				chunk := TextChunk{
-					ID:        fmt.Sprintf("%s_chunk_%d", tp.generateDocumentID(fileInfo.Path), chunkID),
-					Text:      chunkText,
-					WordCount: wordCount,
-					StartPos:  currentPos,
-					EndPos:    currentPos + len(chunkText),
-					Metadata: map[string]string{
-						"semantic":  "true",
-						"startLine": fmt.Sprintf("%d", currentPos),
-						"endLine":   fmt.Sprintf("%d", i),
-					},
-				}
-
-				if tp.options.CountTokens {
-					chunk.TokenCount = tp.estimateTokenCount(chunkText)
-				}
-
-				chunks = append(chunks, chunk)
-				chunkID++
+		// At a boundary, finalize the current chunk unless it is too small to stand alone
+		if isNewBoundary && len(currentChunk) > 0 &&
+			countWords(strings.Join(currentChunk, "\n")) >= tp.options.MinChunkWords {
+			emit(i, map[string]string{})
+			if tp.reachedMaxChunks(chunks) {
+				return chunks
 			}
-
-			currentPos += len(chunkText) + 1
-			currentChunk = make([]string, 0)
 		}
 
 		currentChunk = append(currentChunk, line)
 
 		// Check if chunk is getting too large
-		if len(currentChunk) > 0 {
-			chunkText := strings.Join(currentChunk, "\n")
-			if countWords(chunkText) > tp.options.MaxChunkWords {
-				chunk := TextChunk{
-					ID:        fmt.Sprintf("%s_chunk_%d", tp.generateDocumentID(fileInfo.Path), chunkID),
-					Text:      chunkText,
-					WordCount: countWords(chunkText),
-					StartPos:  currentPos,
-					EndPos:    currentPos + len(chunkText),
-					Metadata: map[string]string{
-						"semantic":  "true",
-						"truncated": "true",
-					},
-				}
-
-				if tp.options.CountTokens {
-					chunk.TokenCount = tp.estimateTokenCount(chunkText)
-				}
-
-				chunks = append(chunks, chunk)
-				chunkID++
-				currentPos += len(chunkText) + 1
-				currentChunk = make([]string, 0)
+		if countWords(strings.Join(currentChunk, "\n")) > maxWords {
+			emit(i+1, map[string]string{"truncated": "true"})
+			if tp.reachedMaxChunks(chunks) {
+				return chunks
 			}
 		}
 	}
 
 	// Handle remaining content
-	if len(currentChunk) > 0 {
-		chunkText := strings.Join(currentChunk, "\n")
-		wordCount := countWords(chunkText)
-
-		if wordCount >= tp.options.MinChunkWords {
-			chunk := TextChunk{
-				ID:        fmt.Sprintf("%s_chunk_%d", tp.generateDocumentID(fileInfo.Path), chunkID),
-				Text:      chunkText,
-				WordCount: wordCount,
-				StartPos:  currentPos,
-				EndPos:    currentPos + len(chunkText),
-				Metadata: map[string]string{
-					"semantic": "true",
-					"final":    "true",
-				},
-			}
-
-			if tp.options.CountTokens {
-				chunk.TokenCount = tp.estimateTokenCount(chunkText)
-			}
+	if len(currentChunk) == 0 {
+		return chunks
+	}
 
-			chunks = append(chunks, chunk)
-		}
+	chunkText := strings.Join(currentChunk, "\n")
+	wordCount := countWords(chunkText)
+	switch {
+	case wordCount >= tp.options.MinChunkWords:
+		emit(len(lines), map[string]string{"final": "true"})
+	case wordCount > 0 && len(chunks) > 0:
+		last := &chunks[len(chunks)-1]
+		tp.setChunkText(last, last.Text+"\n"+tp.preprocessContent(chunkText, fileInfo.Language))
+		last.EndPos = lineOffsets[len(lines)]
+		last.Metadata["endLine"] = fmt.Sprintf("%d", len(lines))
+		last.Metadata["final"] = "true"
 	}
 
 	return chunks
 }
 
-// chunkByWords splits content into word-based chunks with overlap
+// chunkByWords splits content into word-based chunks of the chunk size of the file's category,
+// with overlap.
+// A final chunk shorter than MinChunkWords is merged into the previous chunk.
+// At most MaxChunks chunks are returned.
 func (tp *TextProcessor) chunkByWords(content string, fileInfo scanner.FileInfo) []TextChunk {
 	words := strings.Fields(content)
 	if len(words) == 0 {
@@ -344,9 +376,9 @@ func (tp *TextProcessor) chunkByWords(content string, fileInfo scanner.FileInfo)
 	}
 
 	chunks := make([]TextChunk, 0)
-	chunkSize := tp.options.ChunkSize
+	chunkSize := tp.options.ChunkSizeFor(fileInfo.Category)
 	overlap := tp.options.ChunkOverlap
-	chunkID := 0
+	lastStart := 0
 
 	for i := 0; i < len(words); i += (chunkSize - overlap) {
 		end := i + chunkSize
@@ -355,39 +387,31 @@ func (tp *TextProcessor) chunkByWords(content string, fileInfo scanner.FileInfo)
 		}
 
 		chunkWords := words[i:end]
-		chunkText := strings.Join(chunkWords, " ")
 
-		// Skip if chunk is too small
+		// Merge a too small chunk into the previous one
 		if len(chunkWords) < tp.options.MinChunkWords {
+			if len(chunks) > 0 {
+				last := &chunks[len(chunks)-1]
+				tp.setChunkText(last, strings.Join(words[lastStart:end], " "))
+				last.EndPos = end - 1
+				last.Metadata["wordEnd"] = fmt.Sprintf("%d", end-1)
+			}
 			break
 		}
 
-		// Calculate positions (approximate)
-		startPos := i
-		endPos := end - 1
-
-		chunk := TextChunk{
-			ID:        fmt.Sprintf("%s_chunk_%d", tp.generateDocumentID(fileInfo.Path), chunkID),
-			Text:      chunkText,
-			WordCount: len(chunkWords),
-			StartPos:  startPos,
-			EndPos:    endPos,
-			Metadata: map[string]string{
-				"chunkType": "word_based",
-				"wordStart": fmt.Sprintf("%d", i),
-				"wordEnd":   fmt.Sprintf("%d", end-1),
-			},
-		}
-
-		if tp.options.CountTokens {
-			chunk.TokenCount = tp.estimateTokenCount(chunkText)
-		}
+		chunk := tp.newChunk(fileInfo, len(chunks), strings.Join(chunkWords, " "), i, map[string]string{
+			"chunkType": "word_based",
+			"wordStart": fmt.Sprintf("%d", i),
+			"wordEnd":   fmt.Sprintf("%d", end-1),
+		})
+		// Positions are word indexes
+		chunk.EndPos = end - 1
 
 		chunks = append(chunks, chunk)
-		chunkID++
+		lastStart = i
 
 		// Check max chunks limit
-		if tp.options.MaxChunks > 0 && len(chunks) >= tp.options.MaxChunks {
+		if tp.reachedMaxChunks(chunks) {
 			break
 		}
 
@@ -400,6 +424,38 @@ func (tp *TextProcessor) chunkByWords(content string, fileInfo scanner.FileInfo)
 	return chunks
 }
 
+// newChunk creates the chunkID-th chunk of a file
+func (tp *TextProcessor) newChunk(
+	fileInfo scanner.FileInfo,
+	chunkID int,
+	text string,
+	startPos int,
+	metadata map[string]string,
+) TextChunk {
+	chunk := TextChunk{
+		ID:       fmt.Sprintf("%s_chunk_%d", tp.generateDocumentID(fileInfo.Path), chunkID),
+		StartPos: startPos,
+		EndPos:   startPos + len(text),
+		Metadata: metadata,
+	}
+	tp.setChunkText(&chunk, text)
+	return chunk
+}
+
+// setChunkText sets the text of chunk and updates its word and token counts
+func (tp *TextProcessor) setChunkText(chunk *TextChunk, text string) {
+	chunk.Text = text
+	chunk.WordCount = countWords(text)
+	if tp.options.CountTokens {
+		chunk.TokenCount = tp.estimateTokenCount(text)
+	}
+}
+
+// reachedMaxChunks reports whether chunks has reached the MaxChunks limit
+func (tp *TextProcessor) reachedMaxChunks(chunks []TextChunk) bool {
+	return tp.options.MaxChunks > 0 && len(chunks) >= tp.options.MaxChunks
+}
+
 // preprocessContent applies preprocessing to content based on options
 func (tp *TextProcessor) preprocessContent(content, language string) string {
 	if tp.options.NormalizeWhitespace {
@@ -413,6 +469,12 @@ func (tp *TextProcessor) preprocessContent(content, language string) string {
 	return content
 }
 
+// normalizeLineEndings converts Windows (\r\n) and classic Mac (\r) line endings to \n
+func normalizeLineEndings(content string) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	return strings.ReplaceAll(content, "\r", "\n")
+}
+
 // normalizeWhitespace normalizes whitespace in content
 func (tp *TextProcessor) normalizeWhitespace(content string) string {
 	// Replace multiple spaces with single space
@@ -420,8 +482,7 @@ func (tp *TextProcessor) normalizeWhitespace(content string) string {
 	content = re.ReplaceAllString(content, " ")
 
 	// Normalize line endings
-	content = strings.ReplaceAll(content, "\r\n", "\n")
-	content = strings.ReplaceAll(content, "\r", "\n")
+	content = normalizeLineEndings(content)
 
 	// Remove excessive blank lines (more than 2 consecutive)
 	re = regexp.MustCompile(`\n{3,}`)
@@ -487,6 +548,9 @@ func (tp *TextProcessor) detectContentType(filePath string) ContentType {
 	}
 }
 
+// utf8BOM is the UTF-8 encoding of the byte order mark U+FEFF
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
 // readFileContent reads file content and handles encoding
 func (tp *TextProcessor) readFileContent(filePath string) ([]byte, error) {
 	file, err := os.Open(filePath)
@@ -503,16 +567,10 @@ func (tp *TextProcessor) readFileContent(filePath string) ([]byte, error) {
 
 	// Determine content type and get appropriate size limit
 	contentType := tp.detectContentType(filePath)
-	sizeLimit := tp.options.MaxFileSizeLimits[contentType]
-
-	// Fall back to default if content type not configured
-	if sizeLimit == 0 {
-		sizeLimit = int64(tp.options.MaxChunkWords * 10)
-	}
-
+	sizeLimit := tp.options.FileSizeLimit(contentType)
 	if info.Size() > sizeLimit {
-		return nil, fmt.Errorf("file too large: %d bytes (limit: %d bytes for %s files)",
-			info.Size(), sizeLimit, contentType)
+		return nil, fmt.Errorf("%w, skipped: %d bytes (limit: %d bytes for %s files)",
+			ErrFileTooLarge, info.Size(), sizeLimit, contentType)
 	}
 
 	// Read content
@@ -521,6 +579,9 @@ func (tp *TextProcessor) readFileContent(filePath string) ([]byte, error) {
 		return nil, err
 	}
 
+	// Drop the byte order mark some editors write, so that it does not end up in the first chunk
+	content = bytes.TrimPrefix(content, utf8BOM)
+
 	// Validate UTF-8
 	if !utf8.Valid(content) {
 		// Try to clean invalid UTF-8
@@ -1,8 +1,12 @@
 package processor
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -131,6 +135,175 @@ func (u User) String() string {
 	}
 }
 
+func TestProcessFile_SemanticChunkLineRanges(t *testing.T) {
+	source := `package main
+
+import "fmt"
+
+// greet prints a greeting for name
+func greet(name string) {
+	fmt.Println("Hello,", name)
+}
+
+// User is a person with a name and an age
+type User struct {
+	Name string
+	Age  int
+}
+
+func (u User) String() string {
+	return fmt.Sprintf("User{Name: %s, Age: %d}", u.Name, u.Age)
+}
+
+var defaultUser = User{Name: "gopher", Age: 10}
+`
+	path := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	options := DefaultProcessingOptions()
+	options.MinChunkWords = 3
+	doc, err := NewTextProcessor(options).ProcessFile(scanner.FileInfo{
+		Path:         "main.go",
+		AbsolutePath: path,
+		Size:         int64(len(source)),
+		Language:     "Go",
+		Category:     "code",
+	})
+	if err != nil {
+		t.Fatalf("ProcessFile failed: %v", err)
+	}
+
+	// Each declaration must fall within the source lines its chunk reports
+	sourceLines := strings.Split(source, "\n")
+	declarations := []string{"func greet", "type User struct", "func (u User) String()", "var defaultUser"}
+	for _, declaration := range declarations {
+		var chunk *TextChunk
+		for i := range doc.Chunks {
+			if strings.Contains(doc.Chunks[i].Text, declaration) {
+				chunk = &doc.Chunks[i]
+				break
+			}
+		}
+		if chunk == nil {
+			t.Fatalf("Expected a chunk containing %q, got %+v", declaration, doc.Chunks)
+		}
+
+		var startLine, endLine int
+		fmt.Sscan(chunk.Metadata["startLine"], &startLine)
+		fmt.Sscan(chunk.Metadata["endLine"], &endLine)
+		if startLine < 1 || endLine < startLine || endLine > len(sourceLines) {
+			t.Fatalf("Chunk %s has invalid line range %d-%d", chunk.ID, startLine, endLine)
+		}
+		covered := strings.Join(sourceLines[startLine-1:endLine], "\n")
+		if !strings.Contains(covered, declaration) {
+			t.Errorf("Chunk %s reports lines %d-%d, which do not contain %q",
+				chunk.ID, startLine, endLine, declaration)
+		}
+		if source[chunk.StartPos:chunk.EndPos] != covered && source[chunk.StartPos:chunk.EndPos] != covered+"\n" {
+			t.Errorf("Chunk %s positions %d-%d do not match lines %d-%d",
+				chunk.ID, chunk.StartPos, chunk.EndPos, startLine, endLine)
+		}
+	}
+
+	if first := doc.Chunks[0]; first.Metadata["startLine"] != "1" {
+		t.Errorf("Expected the first chunk to start at line 1, got %s", first.Metadata["startLine"])
+	}
+	if last := doc.Chunks[len(doc.Chunks)-1]; last.Metadata["endLine"] != fmt.Sprint(len(sourceLines)) {
+		t.Errorf("Expected the last chunk to end at line %d, got %s", len(sourceLines), last.Metadata["endLine"])
+	}
+}
+
+func TestProcessFile_SemanticChunksAtDefinitions(t *testing.T) {
+	// Rust has no chunk boundary keywords; its definitions are detected by the generic rules
+	source := `fn parse(input: &str) -> Result<Ast> {
+    let tokens = lex(input)?;
+    build(tokens)
+}
+
+struct Parser {
+    tokens: Vec<Token>,
+    position: usize,
+}
+`
+	path := filepath.Join(t.TempDir(), "parser.rs")
+	if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	options := DefaultProcessingOptions()
+	options.MinChunkWords = 3
+	doc, err := NewTextProcessor(options).ProcessFile(scanner.FileInfo{
+		Path:         "parser.rs",
+		AbsolutePath: path,
+		Size:         int64(len(source)),
+		Language:     "Rust",
+		Category:     "code",
+	})
+	if err != nil {
+		t.Fatalf("ProcessFile failed: %v", err)
+	}
+
+	if len(doc.Chunks) != 2 || !strings.HasPrefix(doc.Chunks[1].Text, "struct Parser") {
+		t.Errorf("Expected a chunk per definition, got %+v", doc.Chunks)
+	}
+}
+
+func TestProcessFile_BOMAndMixedLineEndings(t *testing.T) {
+	greet := "package main\n\nimport \"fmt\"\n\n// greet prints a greeting for name\n" +
+		"func greet(name string) {\n\tfmt.Println(\"Hello,\", name)\n}\n\n"
+	farewell := "// farewell prints a farewell for name\n" +
+		"func farewell(name string) {\n\tfmt.Println(\"Goodbye,\", name)\n}\n"
+
+	// The same source saved with a BOM and Windows line endings, with one function pasted from
+	// a file with classic Mac line endings
+	mixed := "\ufeff" + strings.ReplaceAll(greet, "\n", "\r\n") + strings.ReplaceAll(farewell, "\n", "\r")
+
+	options := DefaultProcessingOptions()
+	options.MinChunkWords = 3
+	tp := NewTextProcessor(options)
+	process := func(name, source string) *Document {
+		path := filepath.Join(t.TempDir(), name)
+		if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		doc, err := tp.ProcessFile(scanner.FileInfo{
+			Path:         name,
+			AbsolutePath: path,
+			Size:         int64(len(source)),
+			Language:     "Go",
+			Category:     "code",
+		})
+		if err != nil {
+			t.Fatalf("ProcessFile failed: %v", err)
+		}
+		return doc
+	}
+	want := process("unix.go", greet+farewell)
+	doc := process("windows.go", mixed)
+
+	if doc.Content != greet+farewell {
+		t.Errorf("Expected the content without BOM and with \\n line endings, got %q", doc.Content)
+	}
+	if len(want.Chunks) != 3 || !strings.HasPrefix(want.Chunks[2].Text, "func farewell") {
+		t.Fatalf("Expected the \\n file to be split before each function, got %+v", want.Chunks)
+	}
+	if len(doc.Chunks) != len(want.Chunks) {
+		t.Fatalf("Expected %d chunks as for the \\n file, got %d: %+v", len(want.Chunks), len(doc.Chunks), doc.Chunks)
+	}
+	for i, chunk := range doc.Chunks {
+		if strings.ContainsAny(chunk.Text, "\r\ufeff") {
+			t.Errorf("Chunk %d keeps a BOM or carriage return: %q", i, chunk.Text)
+		}
+		if chunk.Text != want.Chunks[i].Text ||
+			chunk.Metadata["startLine"] != want.Chunks[i].Metadata["startLine"] ||
+			chunk.Metadata["endLine"] != want.Chunks[i].Metadata["endLine"] {
+			t.Errorf("Chunk %d differs from the \\n file: %+v, want %+v", i, chunk, want.Chunks[i])
+		}
+	}
+}
+
 func TestProcessFile(t *testing.T) {
 	// Create a temporary test file
 	tempDir := t.TempDir()
@@ -257,6 +430,139 @@ func TestProcessFiles(t *testing.T) {
 	}
 }
 
+func TestProcessFiles_WorkerCountKeepsOutput(t *testing.T) {
+	tempDir := t.TempDir()
+
+	var files []scanner.FileInfo
+	for i := range 20 {
+		name := fmt.Sprintf("file%02d.go", i)
+		content := fmt.Sprintf("package main\n\nfunc f%d() int {\n\treturn %d\n}\n", i, i)
+		path := filepath.Join(tempDir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+		files = append(files, scanner.FileInfo{
+			Path:         name,
+			AbsolutePath: path,
+			Name:         name,
+			Extension:    ".go",
+			Size:         int64(len(content)),
+			IsText:       true,
+			Language:     "Go",
+			Category:     "code",
+		})
+	}
+
+	process := func(maxWorkers int) []Document {
+		options := DefaultProcessingOptions()
+		options.MaxWorkers = maxWorkers
+		result, err := NewTextProcessor(options).ProcessFiles(files)
+		if err != nil {
+			t.Fatalf("ProcessFiles with %d workers failed: %v", maxWorkers, err)
+		}
+		for i := range result.Documents {
+			result.Documents[i].ProcessedAt = time.Time{}
+		}
+		return result.Documents
+	}
+
+	want := process(1)
+	if len(want) != len(files) {
+		t.Fatalf("Expected %d documents, got %d", len(files), len(want))
+	}
+	for _, workers := range []int{2, 8} {
+		if got := process(workers); !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %d workers to produce the documents of 1 worker, in the same order", workers)
+		}
+	}
+}
+
+func TestProcessFiles_FileSizeLimits(t *testing.T) {
+	tempDir := t.TempDir()
+
+	options := DefaultProcessingOptions()
+	options.Concurrent = false
+	options.MaxFileSizeLimits = map[ContentType]int64{
+		ContentTypeCode:          100,
+		ContentTypeDocumentation: 200,
+		ContentTypeConfiguration: 50,
+		ContentTypeData:          80,
+		ContentTypeTest:          120,
+		ContentTypeUnknown:       60,
+	}
+	names := map[ContentType]string{
+		ContentTypeCode:          "main.go",
+		ContentTypeDocumentation: "guide.md",
+		ContentTypeConfiguration: "settings.yaml",
+		ContentTypeData:          "rows.csv",
+		ContentTypeTest:          "main_test.go",
+		ContentTypeUnknown:       "notes.xyz",
+	}
+
+	var files []scanner.FileInfo
+	addFile := func(name string, size int64) {
+		path := filepath.Join(tempDir, name)
+		if err := os.WriteFile(path, []byte(strings.Repeat("x", int(size))), 0o644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+		files = append(files, scanner.FileInfo{
+			Path:         name,
+			AbsolutePath: path,
+			Name:         name,
+			Extension:    filepath.Ext(name),
+			Size:         size,
+			IsText:       true,
+		})
+	}
+	for contentType, name := range names {
+		limit := options.MaxFileSizeLimits[contentType]
+		addFile("under-"+name, limit)
+		addFile("over-"+name, limit+1)
+	}
+
+	result, err := NewTextProcessor(options).ProcessFiles(files)
+	if err != nil {
+		t.Fatalf("ProcessFiles failed: %v", err)
+	}
+
+	processed := make(map[string]bool)
+	for _, doc := range result.Documents {
+		processed[doc.FilePath] = true
+	}
+	for contentType, name := range names {
+		if !processed["under-"+name] {
+			t.Errorf("Expected %s file at the limit to be processed", contentType)
+		}
+		if processed["over-"+name] {
+			t.Errorf("Expected %s file over the limit to be skipped", contentType)
+		}
+
+		limit := options.MaxFileSizeLimits[contentType]
+		reason := fmt.Sprintf("file too large, skipped: %d bytes (limit: %d bytes for %s files)",
+			limit+1, limit, contentType)
+		reason = fmt.Sprintf("over-%s: failed to read file over-%s: %s", name, name, reason)
+		if !slices.ContainsFunc(result.Errors, func(msg string) bool { return strings.HasSuffix(msg, reason) }) {
+			t.Errorf("Expected the skip reason %q in %v", reason, result.Errors)
+		}
+	}
+	if len(result.Errors) != len(names) {
+		t.Errorf("Expected %d skipped files, got %v", len(names), result.Errors)
+	}
+}
+
+func TestProcessingOptions_FileSizeLimitDefaults(t *testing.T) {
+	options := DefaultProcessingOptions()
+	options.MaxFileSizeLimits = map[ContentType]int64{ContentTypeCode: 10}
+
+	if limit := options.FileSizeLimit(ContentTypeCode); limit != 10 {
+		t.Errorf("Expected the configured code limit 10, got %d", limit)
+	}
+	defaults := DefaultMaxFileSizeLimits()
+	if limit := options.FileSizeLimit(ContentTypeDocumentation); limit != defaults[ContentTypeDocumentation] {
+		t.Errorf("Expected the default documentation limit %d, got %d", defaults[ContentTypeDocumentation], limit)
+	}
+}
+
 func TestPreprocessContent(t *testing.T) {
 	tp := NewTextProcessor(&ProcessingOptions{
 		NormalizeWhitespace: true,
@@ -499,3 +805,281 @@ func BenchmarkProcessFiles(b *testing.B) {
 		}
 	}
 }
+
+func TestChunkText_LimitsApplyToAllChunkers(t *testing.T) {
+	// Go code is chunked at semantic boundaries, prose by words
+	var goCode strings.Builder
+	goCode.WriteString("package main\n\nimport \"fmt\"\n")
+	for i := range 12 {
+		fmt.Fprintf(&goCode, "\nfunc step%d() {\n", i)
+		for j := range i%3 + 1 {
+			fmt.Fprintf(&goCode, "\tfmt.Println(\"step %d prints line %d of its progress report\")\n", i, j)
+		}
+		goCode.WriteString("}\n")
+	}
+
+	prose := strings.Repeat("Prose documents are split into overlapping chunks of words. ", 60)
+
+	files := []struct {
+		name     string
+		content  string
+		fileInfo scanner.FileInfo
+		semantic bool
+	}{
+		{"code", goCode.String(), scanner.FileInfo{Path: "main.go", Language: "Go", Category: "code"}, true},
+		{"prose", prose, scanner.FileInfo{Path: "README.md", Language: "Markdown", Category: "docs"}, false},
+	}
+
+	for _, file := range files {
+		t.Run(file.name, func(t *testing.T) {
+			options := DefaultProcessingOptions()
+			options.ChunkSize = 100
+			options.ChunkOverlap = 20
+			options.MinChunkWords = 30
+			options.MaxChunkWords = 120
+
+			chunks, err := NewTextProcessor(options).ChunkText(file.content, file.fileInfo)
+			if err != nil {
+				t.Fatalf("ChunkText failed: %v", err)
+			}
+			if len(chunks) < 3 {
+				t.Fatalf("Expected several chunks, got %d", len(chunks))
+			}
+			if semantic := chunks[0].Metadata["semantic"] == "true"; semantic != file.semantic {
+				t.Fatalf("Expected semantic chunking %v, got %v", file.semantic, semantic)
+			}
+
+			for i, chunk := range chunks {
+				if chunk.WordCount < options.MinChunkWords {
+					t.Errorf("Chunk %d has %d words, below the minimum of %d",
+						i, chunk.WordCount, options.MinChunkWords)
+				}
+			}
+
+			// Undersized content is merged, not dropped. Chunk text has its whitespace normalized.
+			last := chunks[len(chunks)-1].Text
+			normalized := strings.Join(strings.Fields(file.content), " ")
+			if !strings.HasSuffix(normalized, strings.TrimSpace(last[len(last)-20:])) {
+				t.Errorf("Expected the last chunk to end with the end of the file, got %q", last)
+			}
+			if file.semantic {
+				total := 0
+				for _, chunk := range chunks {
+					total += chunk.WordCount
+				}
+				if total != countWords(file.content) {
+					t.Errorf("Expected all %d words to be chunked, got %d", countWords(file.content), total)
+				}
+			}
+
+			options.MaxChunks = 2
+			limited, err := NewTextProcessor(options).ChunkText(file.content, file.fileInfo)
+			if err != nil {
+				t.Fatalf("ChunkText failed: %v", err)
+			}
+			if len(limited) != 2 {
+				t.Errorf("Expected MaxChunks to limit the file to 2 chunks, got %d", len(limited))
+			}
+		})
+	}
+}
+
+func TestChunkText_MergesShortFinalChunk(t *testing.T) {
+	options := DefaultProcessingOptions()
+	options.ChunkSize = 50
+	options.ChunkOverlap = 0
+	options.MinChunkWords = 10
+
+	// 105 words: two full chunks and a 5-word remainder
+	content := strings.Repeat("word ", 105)
+	fileInfo := scanner.FileInfo{Path: "notes.txt", Language: "Text", Category: "docs"}
+
+	chunks, err := NewTextProcessor(options).ChunkText(content, fileInfo)
+	if err != nil {
+		t.Fatalf("ChunkText failed: %v", err)
+	}
+	if len(chunks) != 2 {
+		t.Fatalf("Expected 2 chunks, got %d", len(chunks))
+	}
+	if chunks[1].WordCount != 55 {
+		t.Errorf("Expected the short remainder merged into the last chunk, got %d words", chunks[1].WordCount)
+	}
+	if chunks[1].Metadata["wordEnd"] != "104" {
+		t.Errorf("Expected the last chunk to end at word 104, got %s", chunks[1].Metadata["wordEnd"])
+	}
+}
+
+func TestProcessingOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		chunkSize int
+		overlap   int
+		wantErr   bool
+	}{
+		{"default overlap", 350, 100, false},
+		{"no overlap", 350, 0, false},
+		{"overlap equal to chunk size", 100, 100, true},
+		{"overlap larger than chunk size", 50, 100, true},
+		{"negative overlap", 100, -1, true},
+		{"zero chunk size", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := DefaultProcessingOptions()
+			options.ChunkSize = tt.chunkSize
+			options.ChunkOverlap = tt.overlap
+
+			err := options.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			// Invalid options are rejected instead of looping over the content
+			_, err = NewTextProcessor(options).ChunkText("some words to chunk", scanner.FileInfo{Path: "a.txt"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ChunkText() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestChunkText_ZeroOverlap(t *testing.T) {
+	options := DefaultProcessingOptions()
+	options.ChunkSize = 20
+	options.ChunkOverlap = 0
+	options.MinChunkWords = 5
+
+	words := make([]string, 100)
+	for i := range words {
+		words[i] = fmt.Sprintf("w%d", i)
+	}
+	fileInfo := scanner.FileInfo{Path: "notes.txt", Language: "Text", Category: "docs"}
+
+	chunks, err := NewTextProcessor(options).ChunkText(strings.Join(words, " "), fileInfo)
+	if err != nil {
+		t.Fatalf("ChunkText failed: %v", err)
+	}
+	if len(chunks) != 5 {
+		t.Fatalf("Expected 5 chunks, got %d", len(chunks))
+	}
+
+	seen := make(map[string]bool)
+	for i, chunk := range chunks {
+		for _, word := range strings.Fields(chunk.Text) {
+			if seen[word] {
+				t.Errorf("Chunk %d repeats word %q from an earlier chunk", i, word)
+			}
+			seen[word] = true
+		}
+	}
+	if len(seen) != len(words) {
+		t.Errorf("Expected all %d words to be chunked once, got %d", len(words), len(seen))
+	}
+}
+
+func TestChunkText_KeepsSmallFilesWhole(t *testing.T) {
+	tp := NewTextProcessor(DefaultProcessingOptions())
+
+	files := []struct {
+		name     string
+		content  string
+		fileInfo scanner.FileInfo
+	}{
+		{"code", "package main\n\nfunc main() {}\n", scanner.FileInfo{
+			Path: "main.go", Language: "Go", Category: string(scanner.CategoryCode), Importance: 5,
+		}},
+		{"config", "port: 8080\nhost: localhost\n", scanner.FileInfo{
+			Path: "config.yaml", Language: "YAML", Category: string(scanner.CategoryConfig), Importance: 4,
+		}},
+	}
+
+	for _, file := range files {
+		t.Run(file.name, func(t *testing.T) {
+			chunks, err := tp.ChunkText(file.content, file.fileInfo)
+			if err != nil {
+				t.Fatalf("ChunkText failed: %v", err)
+			}
+			if len(chunks) != 1 {
+				t.Fatalf("Expected 1 chunk for a file below MinChunkWords, got %d", len(chunks))
+			}
+
+			chunk := chunks[0]
+			if chunk.Metadata["wholeFile"] != "true" {
+				t.Errorf("Expected the chunk to be flagged as a whole-file chunk, got %v", chunk.Metadata)
+			}
+			if chunk.WordCount != len(strings.Fields(file.content)) {
+				t.Errorf("Expected the chunk to hold all %d words, got %d",
+					len(strings.Fields(file.content)), chunk.WordCount)
+			}
+			if chunk.Metadata["startLine"] != "1" || chunk.EndPos != len(file.content) {
+				t.Errorf("Expected the chunk to cover the whole file, got %v ending at %d",
+					chunk.Metadata, chunk.EndPos)
+			}
+		})
+	}
+
+	chunks, err := tp.ChunkText(" \n\t\n", files[0].fileInfo)
+	if err != nil {
+		t.Fatalf("ChunkText failed: %v", err)
+	}
+	if len(chunks) != 0 {
+		t.Errorf("Expected no chunk for a blank file, got %d", len(chunks))
+	}
+}
+
+func TestChunkText_ChunkSizesByCategory(t *testing.T) {
+	options := DefaultProcessingOptions()
+	options.ChunkSize = 100
+	options.ChunkOverlap = 0
+	options.MinChunkWords = 10
+	options.ChunkSizes = map[string]int{"code": 40, "docs": 80}
+
+	// One statement per line, with no declaration to split at
+	var goCode strings.Builder
+	goCode.WriteString("package main\n\nfunc main() {\n")
+	for i := range 60 {
+		fmt.Fprintf(&goCode, "\tx%d := %d\n", i, i)
+	}
+	goCode.WriteString("}\n")
+
+	files := []struct {
+		name      string
+		content   string
+		fileInfo  scanner.FileInfo
+		chunkSize int
+	}{
+		{"code", goCode.String(), scanner.FileInfo{Path: "main.go", Language: "Go", Category: "code"}, 40},
+		{"docs", strings.Repeat("word ", 400), scanner.FileInfo{Path: "README.md", Category: "docs"}, 80},
+		{"config", strings.Repeat("key ", 400), scanner.FileInfo{Path: "app.yaml", Category: "config"}, 100},
+	}
+
+	for _, file := range files {
+		t.Run(file.name, func(t *testing.T) {
+			chunks, err := NewTextProcessor(options).ChunkText(file.content, file.fileInfo)
+			if err != nil {
+				t.Fatalf("ChunkText failed: %v", err)
+			}
+			if len(chunks) < 2 {
+				t.Fatalf("Expected several chunks, got %d", len(chunks))
+			}
+			// Semantic chunks are cut after the line that goes over the size
+			for i, chunk := range chunks[:len(chunks)-1] {
+				if chunk.WordCount < file.chunkSize || chunk.WordCount > file.chunkSize+3 {
+					t.Errorf("Chunk %d has %d words, expected about %d", i, chunk.WordCount, file.chunkSize)
+				}
+			}
+		})
+	}
+
+	options.ChunkSizes["docs"] = 0
+	if got := options.ChunkSizeFor("docs"); got != 100 {
+		t.Errorf("Expected an unset size to fall back to ChunkSize, got %d", got)
+	}
+
+	options.ChunkSizes["code"] = 10
+	options.ChunkOverlap = 20
+	if err := options.Validate(); err == nil {
+		t.Error("Expected an error for a chunk size not greater than the overlap")
+	}
+}
@@ -0,0 +1,150 @@
+package processor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kuderr/deepwiki/pkg/scanner"
+)
+
+// documentSetVersion is bumped when the saved document set changes incompatibly; sets saved with
+// another version are ignored
+const documentSetVersion = 3
+
+// documentSet is the content of a saved document set
+type documentSet struct {
+	Version    int               `json:"version"`
+	Project    string            `json:"project"`
+	SourceHash string            `json:"sourceHash"`
+	Result     *ProcessingResult `json:"result"`
+}
+
+// SourceHash identifies the files a document set is processed from and the options it is
+// processed with: the path, content and scanner classification of each file, and every option
+// except the concurrency ones, which do not change the documents.
+func SourceHash(files []scanner.FileInfo, options *ProcessingOptions) (string, error) {
+	if options == nil {
+		options = DefaultProcessingOptions()
+	}
+	hashed := *options
+	hashed.Concurrent, hashed.MaxWorkers = false, 0
+	optionsJSON, err := json.Marshal(hashed)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode processing options: %w", err)
+	}
+
+	hash := sha256.New()
+	hash.Write(optionsJSON)
+	for _, file := range files {
+		content, err := contentHash(file.AbsolutePath)
+		if err != nil {
+			return "", fmt.Errorf("failed to hash %s: %w", file.Path, err)
+		}
+		for _, part := range []string{
+			file.Path, content, file.Language, file.Category, strconv.Itoa(file.Importance),
+		} {
+			hash.Write([]byte(part + "\x00"))
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// contentHash returns the hex SHA-256 of the content of a file
+func contentHash(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// DefaultDocumentSetPath returns the file the processed documents of a project are saved to, in
+// the user cache directory so that scans of the project never see it; each project and embeddings
+// namespace has its own
+func DefaultDocumentSetPath(project, namespace string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate user cache directory: %w", err)
+	}
+	sum := sha256.Sum256([]byte(project))
+	name := "documents-" + hex.EncodeToString(sum[:6])
+	if namespace != "" {
+		name = "documents-" + strings.ReplaceAll(namespace, "/", "_") + "-" + hex.EncodeToString(sum[:6])
+	}
+	return filepath.Join(cacheDir, "deepwiki", "documents", name+".json"), nil
+}
+
+// SaveDocumentSet writes the processed documents of result to path, with the project directory
+// and the source hash of the files they were processed from
+func SaveDocumentSet(path, project, sourceHash string, result *ProcessingResult) error {
+	data, err := json.Marshal(documentSet{
+		Version:    documentSetVersion,
+		Project:    project,
+		SourceHash: sourceHash,
+		Result:     result,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode document set: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create document set directory: %w", err)
+	}
+
+	// Write through a temporary file so that a run killed while writing leaves the previous set
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create document set file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write document set: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write document set: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write document set: %w", err)
+	}
+	return nil
+}
+
+// LoadDocumentSet reads the processing result saved at path. It reports false when there is no
+// saved set, when it was saved by another version or for another project directory, or when its
+// source hash differs from sourceHash because the files or options changed. An empty project or
+// sourceHash accepts any saved one.
+func LoadDocumentSet(path, project, sourceHash string) (*ProcessingResult, bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read document set: %w", err)
+	}
+
+	var saved documentSet
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, false, fmt.Errorf("failed to parse document set %s: %w", path, err)
+	}
+	if saved.Version != documentSetVersion || saved.Result == nil ||
+		(project != "" && saved.Project != project) ||
+		(sourceHash != "" && saved.SourceHash != sourceHash) {
+		return nil, false, nil
+	}
+	return saved.Result, true, nil
+}
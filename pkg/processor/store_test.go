@@ -0,0 +1,175 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kuderr/deepwiki/pkg/scanner"
+)
+
+func TestDefaultDocumentSetPath(t *testing.T) {
+	cacheDir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheDir)
+	t.Setenv("HOME", cacheDir)
+
+	path, err := DefaultDocumentSetPath("/src/app", "")
+	if err != nil {
+		t.Fatalf("DefaultDocumentSetPath failed: %v", err)
+	}
+	if !strings.HasPrefix(path, cacheDir+string(filepath.Separator)) {
+		t.Errorf("Expected the document set in the cache directory %s, got %s", cacheDir, path)
+	}
+
+	other, _ := DefaultDocumentSetPath("/src/other", "")
+	namespaced, _ := DefaultDocumentSetPath("/src/app", "team/app")
+	if other == path || namespaced == path {
+		t.Errorf("Expected a document set per project and namespace, got %s, %s and %s", path, other, namespaced)
+	}
+	if !strings.Contains(filepath.Base(namespaced), "team_app") {
+		t.Errorf("Expected the namespace in the file name, got %s", namespaced)
+	}
+}
+
+func TestDocumentSet_RoundTrip(t *testing.T) {
+	tempDir := t.TempDir()
+	source := filepath.Join(tempDir, "main.go")
+	content := "package main\n\n// main starts the server\nfunc main() {\n\tserve()\n}\n"
+	if err := os.WriteFile(source, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write source file: %v", err)
+	}
+	files := []scanner.FileInfo{{
+		Path: "main.go", AbsolutePath: source, Name: "main.go", Extension: ".go",
+		Size: int64(len(content)), ModTime: time.Unix(1700000000, 0), IsText: true,
+		Language: "Go", Category: "code", Importance: 4,
+	}}
+
+	options := DefaultProcessingOptions()
+	options.MinChunkWords = 1
+	result, err := NewTextProcessor(options).ProcessFiles(files)
+	if err != nil {
+		t.Fatalf("ProcessFiles failed: %v", err)
+	}
+	if len(result.Documents) != 1 || len(result.Documents[0].Chunks) == 0 {
+		t.Fatalf("Expected one chunked document, got %+v", result.Documents)
+	}
+
+	hash, err := SourceHash(files, options)
+	if err != nil {
+		t.Fatalf("SourceHash failed: %v", err)
+	}
+	path := filepath.Join(tempDir, "cache", "documents.json")
+	if err := SaveDocumentSet(path, tempDir, hash, result); err != nil {
+		t.Fatalf("SaveDocumentSet failed: %v", err)
+	}
+
+	loaded, found, err := LoadDocumentSet(path, tempDir, hash)
+	if err != nil || !found {
+		t.Fatalf("LoadDocumentSet() = %v, %v, want the saved set", found, err)
+	}
+
+	if len(loaded.Documents) != len(result.Documents) {
+		t.Fatalf("Expected %d documents, got %d", len(result.Documents), len(loaded.Documents))
+	}
+
+	// Times are compared apart, as they lose their monotonic clock reading and location when saved
+	for i := range result.Documents {
+		if !loaded.Documents[i].ProcessedAt.Equal(result.Documents[i].ProcessedAt) {
+			t.Errorf("Expected processing time %v, got %v",
+				result.Documents[i].ProcessedAt, loaded.Documents[i].ProcessedAt)
+		}
+		loaded.Documents[i].ProcessedAt = result.Documents[i].ProcessedAt
+	}
+	if !reflect.DeepEqual(loaded.Documents, result.Documents) {
+		t.Errorf("Expected the reloaded documents to equal the processed ones\ngot:  %+v\nwant: %+v",
+			loaded.Documents, result.Documents)
+	}
+	if loaded.TotalChunks != result.TotalChunks || loaded.TotalTokens != result.TotalTokens {
+		t.Errorf("Expected the totals of the processed result, got %d chunks and %d tokens",
+			loaded.TotalChunks, loaded.TotalTokens)
+	}
+}
+
+func TestDocumentSet_InvalidatedByChanges(t *testing.T) {
+	projectDir := t.TempDir()
+	writeSource := func(name, content string) scanner.FileInfo {
+		t.Helper()
+		path := filepath.Join(projectDir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		return scanner.FileInfo{Path: name, AbsolutePath: path, Size: int64(len(content))}
+	}
+	files := []scanner.FileInfo{writeSource("main.go", "package main\n")}
+	options := DefaultProcessingOptions()
+	hash, err := SourceHash(files, options)
+	if err != nil {
+		t.Fatalf("SourceHash failed: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "documents.json")
+	if _, found, err := LoadDocumentSet(path, projectDir, hash); err != nil || found {
+		t.Fatalf("Expected no set before saving, got %v, %v", found, err)
+	}
+	if err := SaveDocumentSet(path, projectDir, hash, &ProcessingResult{TotalFiles: 1}); err != nil {
+		t.Fatalf("SaveDocumentSet failed: %v", err)
+	}
+
+	// A new modification time alone keeps the saved set
+	touched := time.Now().Add(time.Hour)
+	if err := os.Chtimes(files[0].AbsolutePath, touched, touched); err != nil {
+		t.Fatalf("Failed to touch main.go: %v", err)
+	}
+	files[0].ModTime = touched
+	if touchedHash, err := SourceHash(files, options); err != nil || touchedHash != hash {
+		t.Errorf("Expected an unchanged file to keep the source hash, got %v", err)
+	}
+
+	// The other project is checked before the hash
+	if _, found, err := LoadDocumentSet(path, t.TempDir(), hash); err != nil || found {
+		t.Errorf("Expected the set of another project to be ignored, got %v, %v", found, err)
+	}
+
+	added := append(slices.Clone(files), writeSource("util.go", "package main\n"))
+	rechunked := DefaultProcessingOptions()
+	rechunked.ChunkSize = 200
+	changes := map[string]struct {
+		files   []scanner.FileInfo
+		options *ProcessingOptions
+	}{
+		"added file":      {added, options},
+		"changed options": {files, rechunked},
+	}
+	for name, changed := range changes {
+		changedHash, err := SourceHash(changed.files, changed.options)
+		if err != nil {
+			t.Fatalf("SourceHash failed: %v", err)
+		}
+		if _, found, err := LoadDocumentSet(path, projectDir, changedHash); err != nil || found {
+			t.Errorf("%s: expected the saved set to be invalidated, got %v, %v", name, found, err)
+		}
+	}
+
+	// Content of the same size is a change
+	writeSource("main.go", "package util\n")
+	if modifiedHash, err := SourceHash(files, options); err != nil || modifiedHash == hash {
+		t.Errorf("Expected modified content to change the source hash, got %v", err)
+	}
+
+	// The worker count does not change the documents, so it keeps the saved set
+	writeSource("main.go", "package main\n")
+	parallel := DefaultProcessingOptions()
+	parallel.MaxWorkers = options.MaxWorkers + 8
+	if parallelHash, err := SourceHash(files, parallel); err != nil || parallelHash != hash {
+		t.Errorf("Expected the worker count to keep the source hash, got %v", err)
+	}
+
+	// An empty project and hash accept the saved set whatever it was built from
+	if result, found, err := LoadDocumentSet(path, "", ""); err != nil || !found || result.TotalFiles != 1 {
+		t.Errorf("Expected the saved set without checks, got %v, %v, %v", result, found, err)
+	}
+}
@@ -1,6 +1,9 @@
 package processor
 
 import (
+	"errors"
+	"fmt"
+	"runtime"
 	"time"
 
 	"github.com/kuderr/deepwiki/pkg/scanner"
@@ -36,9 +39,12 @@ type Document struct {
 type ProcessingOptions struct {
 	// Chunking options
 	ChunkSize    int `json:"chunkSize"`    // Target chunk size in words (default: 350)
-	ChunkOverlap int `json:"chunkOverlap"` // Overlap between chunks in words (default: 100)
+	ChunkOverlap int `json:"chunkOverlap"` // Overlap between chunks in words (default: 100, 0 = no overlap)
 	MaxChunks    int `json:"maxChunks"`    // Maximum chunks per document (0 = unlimited)
 
+	// Chunk sizes in words by file category (code, docs, config); categories without a size use ChunkSize
+	ChunkSizes map[string]int `json:"chunkSizes"`
+
 	// Content preprocessing
 	RemoveComments      bool `json:"removeComments"`      // Remove code comments
 	NormalizeWhitespace bool `json:"normalizeWhitespace"` // Normalize whitespace
@@ -54,12 +60,53 @@ type ProcessingOptions struct {
 
 	// Performance options
 	Concurrent bool `json:"concurrent"` // Process documents concurrently
-	MaxWorkers int  `json:"maxWorkers"` // Maximum worker goroutines
+	MaxWorkers int  `json:"maxWorkers"` // Maximum worker goroutines; 0 uses one per CPU
 
-	// File size limits by content type (in bytes)
+	// File size limits by content type (in bytes); types without a limit use the default limits
 	MaxFileSizeLimits map[ContentType]int64 `json:"maxFileSizeLimits"` // Content type specific size limits
 }
 
+// ErrFileTooLarge is returned for files over the size limit of their content type
+var ErrFileTooLarge = errors.New("file too large")
+
+// DefaultMaxFileSizeLimits returns the default file size limits in bytes by content type
+func DefaultMaxFileSizeLimits() map[ContentType]int64 {
+	return map[ContentType]int64{
+		ContentTypeCode:          1024 * 1024 * 2, // 2MB for code files
+		ContentTypeDocumentation: 1024 * 1024 * 5, // 5MB for documentation
+		ContentTypeConfiguration: 1024 * 512,      // 512KB for config files
+		ContentTypeData:          1024 * 256,      // 256KB for data files
+		ContentTypeTest:          1024 * 1024 * 1, // 1MB for test files
+		ContentTypeUnknown:       1024 * 1024 * 1, // 1MB for unknown files
+	}
+}
+
+// FileSizeLimit returns the size limit in bytes for files of the content type
+func (o *ProcessingOptions) FileSizeLimit(contentType ContentType) int64 {
+	if limit := o.MaxFileSizeLimits[contentType]; limit > 0 {
+		return limit
+	}
+	if limit := DefaultMaxFileSizeLimits()[contentType]; limit > 0 {
+		return limit
+	}
+	return DefaultMaxFileSizeLimits()[ContentTypeUnknown]
+}
+
+// ChunkSizeCategories are the file categories that can have their own chunk size
+var ChunkSizeCategories = []string{
+	string(scanner.CategoryCode),
+	string(scanner.CategoryDocs),
+	string(scanner.CategoryConfig),
+}
+
+// ChunkSizeFor returns the chunk size in words for files of the category
+func (o *ProcessingOptions) ChunkSizeFor(category string) int {
+	if size := o.ChunkSizes[category]; size > 0 {
+		return size
+	}
+	return o.ChunkSize
+}
+
 // DefaultProcessingOptions returns default processing options
 func DefaultProcessingOptions() *ProcessingOptions {
 	return &ProcessingOptions{
@@ -74,16 +121,32 @@ func DefaultProcessingOptions() *ProcessingOptions {
 		SkipEmptyChunks:     true,
 		CountTokens:         true,
 		Concurrent:          true,
-		MaxWorkers:          4,
-		MaxFileSizeLimits: map[ContentType]int64{
-			ContentTypeCode:          1024 * 1024 * 2, // 2MB for code files
-			ContentTypeDocumentation: 1024 * 1024 * 5, // 5MB for documentation
-			ContentTypeConfiguration: 1024 * 512,      // 512KB for config files
-			ContentTypeData:          1024 * 256,      // 256KB for data files
-			ContentTypeTest:          1024 * 1024 * 1, // 1MB for test files
-			ContentTypeUnknown:       1024 * 1024 * 1, // 1MB for unknown files
-		},
+		MaxWorkers:          runtime.NumCPU(),
+		MaxFileSizeLimits:   DefaultMaxFileSizeLimits(),
+	}
+}
+
+// Validate checks that the chunking options describe a valid chunking
+func (o *ProcessingOptions) Validate() error {
+	if o.ChunkSize <= 0 {
+		return fmt.Errorf("chunk size must be positive, got %d", o.ChunkSize)
 	}
+	if o.ChunkOverlap < 0 {
+		return fmt.Errorf("chunk overlap cannot be negative, got %d", o.ChunkOverlap)
+	}
+	if o.ChunkOverlap >= o.ChunkSize {
+		return fmt.Errorf("chunk overlap (%d) must be less than chunk size (%d)", o.ChunkOverlap, o.ChunkSize)
+	}
+	for category, size := range o.ChunkSizes {
+		if size < 0 {
+			return fmt.Errorf("%s chunk size cannot be negative, got %d", category, size)
+		}
+		if size > 0 && o.ChunkOverlap >= size {
+			return fmt.Errorf("chunk overlap (%d) must be less than the %s chunk size (%d)",
+				o.ChunkOverlap, category, size)
+		}
+	}
+	return nil
 }
 
 // ProcessingResult represents the result of document processing
@@ -94,6 +157,8 @@ type ProcessingResult struct {
 	TotalTokens    int           `json:"totalTokens"`    // Total tokens counted
 	ProcessingTime time.Duration `json:"processingTime"` // Time taken to process
 	Errors         []string      `json:"errors"`         // Any errors encountered
+
+	ComposeProjects []ComposeProject `json:"composeProjects,omitempty"` // Parsed docker-compose files
 }
 
 // ChunkingStrategy represents different strategies for text chunking
@@ -118,6 +183,16 @@ const (
 	ContentTypeUnknown       ContentType = "unknown"       // Unknown content type
 )
 
+// ContentTypes lists every content type
+var ContentTypes = []ContentType{
+	ContentTypeCode,
+	ContentTypeDocumentation,
+	ContentTypeConfiguration,
+	ContentTypeData,
+	ContentTypeTest,
+	ContentTypeUnknown,
+}
+
 // LanguageSpecificProcessor represents language-specific processing rules
 type LanguageSpecificProcessor struct {
 	Language         string     `json:"language"`         // Programming language
@@ -1,11 +1,13 @@
 package rag
 
 import (
+	"slices"
 	"testing"
 	"time"
 
 	"github.com/kuderr/deepwiki/pkg/embeddings"
 	"github.com/kuderr/deepwiki/pkg/processor"
+	"github.com/kuderr/deepwiki/pkg/scanner"
 )
 
 func TestDefaultRAGConfig(t *testing.T) {
@@ -236,6 +238,257 @@ func TestRetrievalStrategies(t *testing.T) {
 	}
 }
 
+func TestStructuralRetrieval_IdentifiesDeclarations(t *testing.T) {
+	docs := []processor.Document{
+		{
+			ID: "server", FilePath: "server.go", Language: "Go", Category: "code",
+			Chunks: []processor.TextChunk{
+				{ID: "go-call", Text: "session := auth.Login(request) if session == nil { return errUnauthorized }"},
+				{ID: "go-method", Text: "func (s *Server) Login(w http.ResponseWriter, r *http.Request) { s.auth(r) }"},
+			},
+		},
+		{
+			ID: "models", FilePath: "models.py", Language: "Python", Category: "code",
+			Chunks: []processor.TextChunk{
+				{ID: "py-use", Text: "repository = UserRepository(db) users = repository.all()"},
+				{ID: "py-class", Text: "class UserRepository(Base): def all(self): return self.db.query(User)"},
+			},
+		},
+		{
+			ID: "api", FilePath: "api.ts", Language: "TypeScript", Category: "code",
+			Chunks: []processor.TextChunk{
+				{ID: "ts-call", Text: "const user = await fetchProfile(id); render(user);"},
+				{ID: "ts-arrow", Text: "export const fetchProfile = async (id: string): Promise<User> => get(id);"},
+			},
+		},
+	}
+	retriever := NewDocumentRetriever(nil, &MockVectorDB{}, &MockEmbeddingGenerator{}, docs, DefaultRAGConfig())
+
+	for query, want := range map[string]string{
+		"login":          "go-method",
+		"userrepository": "py-class",
+		"fetchprofile":   "ts-arrow",
+	} {
+		results, err := retriever.RetrieveRelevantDocuments(&RetrievalContext{
+			Query:      query,
+			QueryType:  QueryTypeStructural,
+			MaxResults: 10,
+			MinScore:   0.1,
+		})
+		if err != nil {
+			t.Fatalf("Structural retrieval failed for %q: %v", query, err)
+		}
+		if len(results) == 0 || results[0].ChunkID != want {
+			t.Errorf("Expected %s to rank first for %q, got %+v", want, query, results)
+		}
+	}
+}
+
+func TestRetrieval_AddsEnclosingDeclaration(t *testing.T) {
+	docs := []processor.Document{
+		{
+			ID: "server", FilePath: "server.go", Language: "Go", Category: "code",
+			Chunks: []processor.TextChunk{
+				{ID: "chunk-0", Text: "package server import \"net/http\" type Server struct { sessions *Store }"},
+				{ID: "chunk-1", Text: "func (s *Server) Login(w http.ResponseWriter, r *http.Request) error { " +
+					"token := r.Header.Get(\"Authorization\")"},
+				{ID: "chunk-2", Text: "if token == \"\" { return errUnauthorized } return s.sessions.Start(token) }"},
+			},
+		},
+		{
+			ID: "cache", FilePath: "cache.ts", Language: "TypeScript", Category: "code",
+			Chunks: []processor.TextChunk{
+				{ID: "chunk-0", Text: "export class Cache { evict(key: string): void { const entry = this.get(key);"},
+				{ID: "chunk-1", Text: "if (entry) { this.items.delete(key); this.expirations.cancel(key); } } }"},
+			},
+		},
+	}
+	retriever := NewDocumentRetriever(nil, &MockVectorDB{}, &MockEmbeddingGenerator{}, docs, DefaultRAGConfig())
+
+	tests := []struct {
+		query, chunkID             string
+		function, class, signature string
+	}{
+		{"sessions start", "chunk-2", "Login", "Server",
+			"func (s *Server) Login(w http.ResponseWriter, r *http.Request) error"},
+		{"expirations cancel", "chunk-1", "evict", "Cache", "evict(key: string): void"},
+	}
+	for _, tt := range tests {
+		results, err := retriever.RetrieveRelevantDocuments(&RetrievalContext{
+			Query:      tt.query,
+			QueryType:  QueryTypeKeyword,
+			MaxResults: 1,
+			MinScore:   0.5,
+		})
+		if err != nil {
+			t.Fatalf("Retrieval failed for %q: %v", tt.query, err)
+		}
+		if len(results) != 1 || results[0].ChunkID != tt.chunkID || results[0].Context == nil {
+			t.Fatalf("Expected %s with context for %q, got %+v", tt.chunkID, tt.query, results)
+		}
+
+		context := results[0].Context
+		if context.FunctionName != tt.function || context.ClassName != tt.class || context.Signature != tt.signature {
+			t.Errorf("Expected %s.%s with signature %q, got %s.%s with %q", tt.class, tt.function, tt.signature,
+				context.ClassName, context.FunctionName, context.Signature)
+		}
+	}
+}
+
+func TestRetrieveByFilePath(t *testing.T) {
+	source := "package server\n\nimport \"net/http\"\n\n" +
+		"// Server handles requests\ntype Server struct {\n\tsessions *Store\n\ttimeout  int\n}\n\n" +
+		"func (s *Server) Login(w http.ResponseWriter, r *http.Request) error {\n" +
+		"\ttoken := r.Header.Get(\"Authorization\")\n\treturn s.sessions.Start(token)\n}"
+
+	options := processor.DefaultProcessingOptions()
+	options.MinChunkWords = 5
+	fileInfo := scanner.FileInfo{Path: "server.go", Language: "Go", Category: "code"}
+	chunks, err := processor.NewTextProcessor(options).ChunkText(source, fileInfo)
+	if err != nil {
+		t.Fatalf("ChunkText failed: %v", err)
+	}
+	docs := []processor.Document{{
+		ID: "server", FilePath: "server.go", Language: "Go", Category: "code", Content: source, Chunks: chunks,
+	}}
+	retriever := NewDocumentRetriever(nil, &MockVectorDB{}, &MockEmbeddingGenerator{}, docs, DefaultRAGConfig())
+
+	results, err := retriever.RetrieveByFilePath("server.go")
+	if err != nil {
+		t.Fatalf("RetrieveByFilePath failed: %v", err)
+	}
+	if len(results) != len(chunks) {
+		t.Fatalf("Expected %d chunks, got %d", len(chunks), len(results))
+	}
+
+	login := results[len(results)-1]
+	want := "func (s *Server) Login(w http.ResponseWriter, r *http.Request) error {\n" +
+		"\ttoken := r.Header.Get(\"Authorization\")\n\treturn s.sessions.Start(token)\n}"
+	if login.Content != want {
+		t.Errorf("Expected the exact source of Login, got %q", login.Content)
+	}
+	if login.Context == nil || !slices.Equal(login.Context.LineNumbers, []int{11, 14}) {
+		t.Errorf("Expected Login on lines 11-14, got %+v", login.Context)
+	}
+	if login.Context != nil && login.Context.FunctionName != "Login" {
+		t.Errorf("Expected the Login declaration, got %q", login.Context.FunctionName)
+	}
+
+	if _, err := retriever.RetrieveByFilePath("missing.go"); err == nil {
+		t.Error("Expected an error for an unknown file")
+	}
+}
+
+func TestRetrieval_BoostFactors(t *testing.T) {
+	// Both chunks match the query equally well
+	docs := []processor.Document{
+		{
+			ID: "guide", FilePath: "docs/guide.md", Language: "Markdown", Category: "documentation",
+			Chunks: []processor.TextChunk{
+				{ID: "guide-1", Text: "Configure the retry policy", Metadata: map[string]string{"section": "guide"}},
+			},
+		},
+		{
+			ID: "api", FilePath: "docs/api.md", Language: "Markdown", Category: "documentation",
+			Chunks: []processor.TextChunk{
+				{ID: "api-1", Text: "Configure the retry policy", Metadata: map[string]string{"section": "api"}},
+			},
+		},
+	}
+	retriever := NewDocumentRetriever(nil, &MockVectorDB{}, &MockEmbeddingGenerator{}, docs, DefaultRAGConfig())
+
+	for _, boosted := range []string{"guide", "api"} {
+		results, err := retriever.RetrieveRelevantDocuments(&RetrievalContext{
+			Query:        "retry policy",
+			QueryType:    QueryTypeKeyword,
+			MaxResults:   5,
+			BoostFactors: map[string]float32{"section=" + boosted: 1.5, "context_match": 2},
+		})
+		if err != nil {
+			t.Fatalf("Retrieval failed: %v", err)
+		}
+		if len(results) != 2 {
+			t.Fatalf("Expected 2 results, got %d", len(results))
+		}
+
+		top, other := results[0], results[1]
+		if top.ChunkID != boosted+"-1" {
+			t.Errorf("Expected the %s chunk to rank first, got %s", boosted, top.ChunkID)
+		}
+		if top.Score != other.Score*1.5 {
+			t.Errorf("Expected the boosted score to be 1.5 times %v, got %v", other.Score, top.Score)
+		}
+		if !slices.Equal(top.Relevance.BoostFactors, []string{"section=" + boosted}) ||
+			len(other.Relevance.BoostFactors) != 0 {
+			t.Errorf("Expected only the boosted result to record its boost, got %v and %v",
+				top.Relevance.BoostFactors, other.Relevance.BoostFactors)
+		}
+	}
+}
+
+func TestRetrieval_RelaxesMinScore(t *testing.T) {
+	docs := []processor.Document{
+		{
+			ID: "guide", FilePath: "docs/guide.md", Language: "Markdown", Category: "documentation",
+			Chunks: []processor.TextChunk{{ID: "guide-1", Text: "Configure the retry policy"}},
+		},
+	}
+	retriever := NewDocumentRetriever(nil, &MockVectorDB{}, &MockEmbeddingGenerator{}, docs, DefaultRAGConfig())
+
+	// One of the four query terms matches, scoring 0.25
+	strict := &RetrievalContext{
+		Query:      "retry backoff jitter timeout",
+		QueryType:  QueryTypeKeyword,
+		MaxResults: 5,
+		MinScore:   0.6,
+	}
+	results, err := retriever.RetrieveRelevantDocuments(strict)
+	if err != nil {
+		t.Fatalf("Retrieval failed: %v", err)
+	}
+	if len(results) != 0 {
+		t.Fatalf("Expected no results above the strict min score, got %d", len(results))
+	}
+
+	relaxing := *strict
+	relaxing.MinResults = 1
+	relaxing.MinScoreFloor = 0.2
+	results, err = retriever.RetrieveRelevantDocuments(&relaxing)
+	if err != nil {
+		t.Fatalf("Retrieval failed: %v", err)
+	}
+	if len(results) != 1 || results[0].ChunkID != "guide-1" {
+		t.Fatalf("Expected the guide chunk once the min score is relaxed, got %+v", results)
+	}
+	if !results[0].Relevance.Relaxed {
+		t.Error("Expected the result to be flagged as relaxed")
+	}
+	if stats := retriever.GetRetrievalStats(); stats.RelaxedQueries != 1 {
+		t.Errorf("Expected 1 relaxed query, got %d", stats.RelaxedQueries)
+	}
+
+	// The floor bounds the relaxation
+	relaxing.MinScoreFloor = 0.3
+	results, err = retriever.RetrieveRelevantDocuments(&relaxing)
+	if err != nil {
+		t.Fatalf("Retrieval failed: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("Expected no results above the floor, got %d", len(results))
+	}
+
+	// Queries that find enough results are not relaxed
+	relaxing.MinScore = 0.2
+	relaxing.MinScoreFloor = 0
+	results, err = retriever.RetrieveRelevantDocuments(&relaxing)
+	if err != nil {
+		t.Fatalf("Retrieval failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Relevance.Relaxed {
+		t.Errorf("Expected the result without relaxation, got %+v", results)
+	}
+}
+
 func TestReranking(t *testing.T) {
 	config := DefaultRAGConfig()
 	config.RerankResults = true
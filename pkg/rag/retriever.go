@@ -2,7 +2,10 @@ package rag
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -20,6 +23,12 @@ type DefaultDocumentRetriever struct {
 	config           *RAGConfig
 	stats            *RetrievalStats
 	mu               sync.RWMutex
+
+	// Prepared state, built lazily on first use or ahead of time by Warm
+	keywordIdx      *keywordIndex
+	keywordIdxOnce  sync.Once
+	queryEmbeddings *queryEmbeddingCache
+	cacheMu         sync.Mutex
 }
 
 // NewDocumentRetriever creates a new document retriever
@@ -44,18 +53,55 @@ func NewDocumentRetriever(
 			PerformanceMetrics: make(map[string]float64),
 			MostCommonQueries:  make([]QueryStats, 0),
 		},
+		queryEmbeddings: newQueryEmbeddingCache(maxCachedQueryEmbeddings),
 	}
 
 	return retriever
 }
 
-// RetrieveRelevantDocuments retrieves documents based on a retrieval context
+// minScoreRelaxationSteps is the number of steps in which MinScore is lowered to MinScoreFloor
+const minScoreRelaxationSteps = 3
+
+// RetrieveRelevantDocuments retrieves documents based on a retrieval context. When fewer than
+// ctx.MinResults are found, the query is retried with MinScore lowered step by step down to
+// ctx.MinScoreFloor, and the results of a relaxed query are flagged as relaxed.
 func (r *DefaultDocumentRetriever) RetrieveRelevantDocuments(ctx *RetrievalContext) ([]RetrievalResult, error) {
 	startTime := time.Now()
 	defer func() {
 		r.updateStats("RetrieveRelevantDocuments", time.Since(startTime))
 	}()
 
+	results, err := r.retrieve(ctx)
+	if err != nil || ctx.MinScoreFloor >= ctx.MinScore {
+		return results, err
+	}
+
+	wanted := ctx.MinResults
+	if ctx.MaxResults > 0 {
+		wanted = min(wanted, ctx.MaxResults)
+	}
+	for step := 1; step <= minScoreRelaxationSteps && len(results) < wanted; step++ {
+		relaxed := *ctx
+		relaxed.MinScore = ctx.MinScore - (ctx.MinScore-ctx.MinScoreFloor)*float32(step)/minScoreRelaxationSteps
+		results, err = r.retrieve(&relaxed)
+		if err != nil {
+			return nil, err
+		}
+		for i := range results {
+			results[i].Relevance.Relaxed = true
+		}
+		if step == 1 {
+			r.mu.Lock()
+			r.stats.RelaxedQueries++
+			r.mu.Unlock()
+		}
+	}
+
+	return results, nil
+}
+
+// retrieve runs one query with the min score of ctx
+func (r *DefaultDocumentRetriever) retrieve(ctx *RetrievalContext) ([]RetrievalResult, error) {
 	var results []RetrievalResult
 	var err error
 
@@ -93,6 +139,9 @@ func (r *DefaultDocumentRetriever) RetrieveRelevantDocuments(ctx *RetrievalConte
 		}
 	}
 
+	// Boost results by their metadata
+	results = r.applyBoostFactors(results, ctx.BoostFactors)
+
 	// Apply diversity filtering
 	results = r.applyDiversityFiltering(results)
 
@@ -204,7 +253,7 @@ func (r *DefaultDocumentRetriever) RetrieveCodeExamples(
 	for _, result := range results {
 		if result.Language == language && result.Category == "code" {
 			// Boost score for function/class definitions
-			if r.isCodeDefinition(result.Content) {
+			if processor.IsDefinition(result.Content, result.Language) {
 				result.Score *= 1.2
 			}
 			codeResults = append(codeResults, result)
@@ -343,6 +392,39 @@ func (r *DefaultDocumentRetriever) RetrieveRelatedChunks(chunkID string, maxResu
 	return filteredResults, nil
 }
 
+// RetrieveByFilePath returns the chunks of a file in source order. Unlike search results, the
+// content of chunks with known source lines is the source as written rather than the
+// preprocessed chunk text, so that it can be quoted.
+func (r *DefaultDocumentRetriever) RetrieveByFilePath(filePath string) ([]RetrievalResult, error) {
+	index := slices.IndexFunc(r.documents, func(doc processor.Document) bool {
+		return doc.FilePath == filePath
+	})
+	if index < 0 {
+		return nil, fmt.Errorf("document not found: %s", filePath)
+	}
+
+	doc := &r.documents[index]
+	source := strings.ReplaceAll(doc.Content, "\r\n", "\n") // Chunk offsets skip carriage returns
+	results := make([]RetrievalResult, len(doc.Chunks))
+	for i, chunk := range doc.Chunks {
+		content := chunk.Text
+		if _, _, ok := chunkLines(chunk); ok && chunk.StartPos <= chunk.EndPos && chunk.EndPos <= len(source) {
+			content = source[chunk.StartPos:chunk.EndPos]
+		}
+		results[i] = RetrievalResult{
+			DocumentID: doc.ID,
+			ChunkID:    chunk.ID,
+			FilePath:   doc.FilePath,
+			Content:    content,
+			Language:   doc.Language,
+			Category:   doc.Category,
+			Metadata:   chunk.Metadata,
+		}
+	}
+
+	return r.enrichWithContext(results), nil
+}
+
 // FilterResults filters results based on metadata filters
 func (r *DefaultDocumentRetriever) FilterResults(
 	results []RetrievalResult,
@@ -357,26 +439,8 @@ func (r *DefaultDocumentRetriever) FilterResults(
 		matches := true
 
 		for key, value := range filters {
-			switch key {
-			case "language":
-				if result.Language != value {
-					matches = false
-				}
-			case "category":
-				if result.Category != value {
-					matches = false
-				}
-			case "filePath":
-				if !strings.Contains(result.FilePath, value) {
-					matches = false
-				}
-			default:
-				if result.Metadata[key] != value {
-					matches = false
-				}
-			}
-
-			if !matches {
+			if !matchesField(result, key, value) {
+				matches = false
 				break
 			}
 		}
@@ -389,6 +453,50 @@ func (r *DefaultDocumentRetriever) FilterResults(
 	return filtered
 }
 
+// matchesField reports whether a result has value for a filter or boost field: language, category,
+// a part of filePath, or any metadata key
+func matchesField(result RetrievalResult, field, value string) bool {
+	switch field {
+	case "language":
+		return result.Language == value
+	case "category":
+		return result.Category == value
+	case "filePath":
+		return strings.Contains(result.FilePath, value)
+	default:
+		return result.Metadata[field] == value
+	}
+}
+
+// applyBoostFactors multiplies the score of results matching a boost factor key of the form
+// "field=value", such as "language=Go" or "category=documentation", and sorts the results again.
+// Fields match as in FilterResults. Keys without "=" name boosts applied elsewhere.
+func (r *DefaultDocumentRetriever) applyBoostFactors(
+	results []RetrievalResult,
+	boostFactors map[string]float32,
+) []RetrievalResult {
+	if len(boostFactors) == 0 {
+		return results
+	}
+
+	keys := slices.Sorted(maps.Keys(boostFactors))
+	for i := range results {
+		for _, key := range keys {
+			field, value, ok := strings.Cut(key, "=")
+			if !ok || !matchesField(results[i], field, value) {
+				continue
+			}
+			results[i].Score *= boostFactors[key]
+			results[i].Relevance.BoostFactors = append(results[i].Relevance.BoostFactors, key)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	return results
+}
+
 // RerankResults reranks results based on the query
 func (r *DefaultDocumentRetriever) RerankResults(results []RetrievalResult, query string) ([]RetrievalResult, error) {
 	// Simple reranking based on keyword matching and other factors
@@ -438,7 +546,7 @@ func (r *DefaultDocumentRetriever) GetRetrievalStats() *RetrievalStats {
 // retrieveSemantic performs semantic search using embeddings
 func (r *DefaultDocumentRetriever) retrieveSemantic(ctx *RetrievalContext) ([]RetrievalResult, error) {
 	// Generate query embedding
-	queryEmbedding, err := r.embeddingGen.GenerateEmbedding(ctx.Query)
+	queryEmbedding, err := r.queryEmbedding(ctx.Query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate query embedding: %v", err)
 	}
@@ -482,10 +590,11 @@ func (r *DefaultDocumentRetriever) retrieveSemantic(ctx *RetrievalContext) ([]Re
 func (r *DefaultDocumentRetriever) retrieveKeyword(ctx *RetrievalContext) ([]RetrievalResult, error) {
 	queryTerms := strings.Fields(strings.ToLower(ctx.Query))
 	results := make([]RetrievalResult, 0)
+	index := r.keywordIndex()
 
-	for _, doc := range r.documents {
-		for _, chunk := range doc.Chunks {
-			score := r.calculateKeywordScore(chunk.Text, queryTerms)
+	for i, doc := range r.documents {
+		for j, chunk := range doc.Chunks {
+			score := keywordScore(index.chunkText[i][j], queryTerms)
 			if score >= ctx.MinScore {
 				result := RetrievalResult{
 					DocumentID: doc.ID,
@@ -568,19 +677,19 @@ func (r *DefaultDocumentRetriever) retrieveStructural(ctx *RetrievalContext) ([]
 	results := make([]RetrievalResult, 0)
 	queryLower := strings.ToLower(ctx.Query)
 
+	queryTerms := strings.Fields(queryLower)
+
 	for _, doc := range r.documents {
 		if doc.Category != "code" {
 			continue // Only apply to code files
 		}
 
 		for _, chunk := range doc.Chunks {
-			score := r.calculateStructuralScore(RetrievalResult{
-				Content:  chunk.Text,
-				Language: doc.Language,
-			})
+			declarations := findDeclarations(chunk.Text, doc.Language)
+			score := r.structuralScore(chunk.Text, declarations)
 
 			// Check if query matches structural elements
-			if r.matchesStructuralQuery(chunk.Text, queryLower, doc.Language) {
+			if matchesStructuralQuery(declarations, queryTerms) {
 				score *= 2.0 // Boost structural matches
 			}
 
@@ -614,7 +723,11 @@ func (r *DefaultDocumentRetriever) retrieveStructural(ctx *RetrievalContext) ([]
 // Helper functions for scoring and matching
 
 func (r *DefaultDocumentRetriever) calculateKeywordScore(content string, queryTerms []string) float32 {
-	contentLower := strings.ToLower(content)
+	return keywordScore(strings.ToLower(content), queryTerms)
+}
+
+// keywordScore returns the fraction of query terms found in already lowercased content
+func keywordScore(contentLower string, queryTerms []string) float32 {
 	matches := 0
 	totalTerms := len(queryTerms)
 
@@ -632,27 +745,30 @@ func (r *DefaultDocumentRetriever) calculateKeywordScore(content string, queryTe
 }
 
 func (r *DefaultDocumentRetriever) calculateStructuralScore(result RetrievalResult) float32 {
-	score := float32(0.1) // Base score
+	return r.structuralScore(result.Content, findDeclarations(result.Content, result.Language))
+}
 
-	content := result.Content
+// structuralScore scores content by the kinds of declarations it contains
+func (r *DefaultDocumentRetriever) structuralScore(content string, declarations []processor.Declaration) float32 {
+	score := float32(0.1) // Base score
 
 	// Boost for function definitions
-	if r.isFunctionDefinition(content, result.Language) {
+	if processor.HasDeclaration(declarations, processor.DeclarationFunction, processor.DeclarationMethod) {
 		score += 0.3
 	}
 
 	// Boost for class definitions
-	if r.isClassDefinition(content, result.Language) {
+	if processor.HasDeclaration(declarations, processor.DeclarationClass) {
 		score += 0.3
 	}
 
-	// Boost for interface definitions
-	if r.isInterfaceDefinition(content, result.Language) {
+	// Boost for interface and type definitions
+	if processor.HasDeclaration(declarations, processor.DeclarationInterface, processor.DeclarationType) {
 		score += 0.2
 	}
 
 	// Boost for important keywords
-	if r.containsImportantKeywords(content, result.Language) {
+	if r.containsImportantKeywords(content) {
 		score += 0.1
 	}
 
@@ -672,49 +788,12 @@ func (r *DefaultDocumentRetriever) findMatchedTerms(content string, queryTerms [
 	return matched
 }
 
-func (r *DefaultDocumentRetriever) isCodeDefinition(content string) bool {
-	// TODO: Use the LanguageSpecificProcessor`s from pkg/processor for more accurate code structure detection
-	definitionKeywords := []string{"func ", "function ", "class ", "def ", "interface ", "type "}
-	contentLower := strings.ToLower(content)
-
-	for _, keyword := range definitionKeywords {
-		if strings.Contains(contentLower, keyword) {
-			return true
-		}
-	}
-
-	return false
+// findDeclarations returns the declarations in content, detected with the rules of its language
+func findDeclarations(content, language string) []processor.Declaration {
+	return processor.GetLanguageProcessor(language).FindDeclarations(content)
 }
 
-func (r *DefaultDocumentRetriever) isFunctionDefinition(content, language string) bool {
-	contentLower := strings.ToLower(content)
-
-	switch language {
-	case "Go":
-		return strings.Contains(contentLower, "func ")
-	case "Python":
-		return strings.Contains(contentLower, "def ")
-	case "JavaScript", "TypeScript":
-		return strings.Contains(contentLower, "function ") || strings.Contains(contentLower, "const ") ||
-			strings.Contains(contentLower, "let ")
-	case "Java", "C#":
-		return strings.Contains(contentLower, "public ") || strings.Contains(contentLower, "private ") ||
-			strings.Contains(contentLower, "protected ")
-	default:
-		return strings.Contains(contentLower, "function") || strings.Contains(contentLower, "def") ||
-			strings.Contains(contentLower, "func")
-	}
-}
-
-func (r *DefaultDocumentRetriever) isClassDefinition(content, language string) bool {
-	return strings.Contains(strings.ToLower(content), "class ")
-}
-
-func (r *DefaultDocumentRetriever) isInterfaceDefinition(content, language string) bool {
-	return strings.Contains(strings.ToLower(content), "interface ")
-}
-
-func (r *DefaultDocumentRetriever) containsImportantKeywords(content, language string) bool {
+func (r *DefaultDocumentRetriever) containsImportantKeywords(content string) bool {
 	importantKeywords := []string{"import", "export", "main", "init", "setup", "config"}
 	contentLower := strings.ToLower(content)
 
@@ -727,9 +806,18 @@ func (r *DefaultDocumentRetriever) containsImportantKeywords(content, language s
 	return false
 }
 
-func (r *DefaultDocumentRetriever) matchesStructuralQuery(content, query, language string) bool {
-	// Check if query matches function/class names or structural elements
-	return strings.Contains(strings.ToLower(content), query)
+// matchesStructuralQuery reports whether a declared name contains a term of the query, such as
+// GeneratePageContent for the query "page content"
+func matchesStructuralQuery(declarations []processor.Declaration, queryTerms []string) bool {
+	for _, declaration := range declarations {
+		name := strings.ToLower(declaration.Name)
+		for _, term := range queryTerms {
+			if len(term) >= 3 && strings.Contains(name, term) {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // Additional helper functions
@@ -745,17 +833,91 @@ func (r *DefaultDocumentRetriever) enrichWithDocumentInfo(result *RetrievalResul
 	}
 }
 
+// enrichWithContext adds the source lines of results and the enclosing declaration of code
+// results, so that a chunk from the middle of a function still names the function and its type
 func (r *DefaultDocumentRetriever) enrichWithContext(results []RetrievalResult) []RetrievalResult {
-	// TODO: Implement proper context enrichment with surrounding chunks and code structure
+	documents := make(map[string]*processor.Document, len(r.documents))
+	for i := range r.documents {
+		documents[r.documents[i].ID] = &r.documents[i]
+	}
+
 	for i := range results {
-		// This would involve finding surrounding chunks, function context, etc.
-		results[i].Context = &ChunkContext{
-			// Populate with actual context information
+		results[i].Context = &ChunkContext{}
+
+		doc := documents[results[i].DocumentID]
+		if doc == nil {
+			continue
+		}
+		index := slices.IndexFunc(doc.Chunks, func(chunk processor.TextChunk) bool {
+			return chunk.ID == results[i].ChunkID
+		})
+		if index < 0 {
+			continue
+		}
+
+		if start, end, ok := chunkLines(doc.Chunks[index]); ok {
+			results[i].Context.LineNumbers = []int{start, end}
+		}
+		if doc.Category == "code" {
+			setEnclosingDeclaration(results[i].Context, doc, index)
 		}
 	}
 	return results
 }
 
+// chunkLines returns the first and last source line of a chunk, which are known for chunks split
+// at declaration boundaries
+func chunkLines(chunk processor.TextChunk) (start, end int, ok bool) {
+	start, err := strconv.Atoi(chunk.Metadata["startLine"])
+	if err != nil {
+		return 0, 0, false
+	}
+	end, err = strconv.Atoi(chunk.Metadata["endLine"])
+	if err != nil || end < start {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// setEnclosingDeclaration sets the function, type and header of the declaration enclosing chunk
+// index of doc. A chunk that starts with a declaration belongs to it; any other chunk belongs to
+// the last declaration before it, searching back through earlier chunks.
+func setEnclosingDeclaration(context *ChunkContext, doc *processor.Document, index int) {
+	language := processor.GetLanguageProcessor(doc.Language)
+
+	// Declarations before the start of the chunk, nearest last
+	var before []processor.Declaration
+	for i := index - 1; i >= 0 && len(before) == 0; i-- {
+		before = language.FindDeclarations(doc.Chunks[i].Text)
+	}
+
+	declarations := language.FindDeclarations(doc.Chunks[index].Text)
+	if len(declarations) > 0 &&
+		strings.HasPrefix(strings.TrimSpace(doc.Chunks[index].Text), declarations[0].Signature) {
+		before = append(before, declarations[0])
+	}
+	if len(before) == 0 {
+		return
+	}
+
+	enclosing := before[len(before)-1]
+	context.Signature = enclosing.Signature
+	switch enclosing.Kind {
+	case processor.DeclarationFunction:
+		context.FunctionName = enclosing.Name
+	case processor.DeclarationMethod:
+		context.FunctionName = enclosing.Name
+		context.ClassName = enclosing.Receiver
+		for i := len(before) - 2; context.ClassName == "" && i >= 0; i-- {
+			if before[i].Kind == processor.DeclarationClass {
+				context.ClassName = before[i].Name
+			}
+		}
+	default:
+		context.ClassName = enclosing.Name
+	}
+}
+
 func (r *DefaultDocumentRetriever) extractContextTerms(context []RetrievalResult) []string {
 	terms := make(map[string]bool)
 
@@ -0,0 +1,130 @@
+package rag
+
+import (
+	"fmt"
+)
+
+// LabeledQuery pairs a query with the chunks a good retrieval should return for it
+type LabeledQuery struct {
+	Query            string   `json:"query"`
+	ExpectedChunkIDs []string `json:"expectedChunkIds"`
+}
+
+// TuningOptions controls the weight grid search
+type TuningOptions struct {
+	Step float32 `json:"step"` // Grid step for the semantic weight (default: 0.1)
+	TopK int     `json:"topK"` // Results considered per query (default: 10)
+}
+
+// DefaultTuningOptions returns default tuning options
+func DefaultTuningOptions() *TuningOptions {
+	return &TuningOptions{
+		Step: 0.1,
+		TopK: 10,
+	}
+}
+
+// TuningResult reports the weights chosen by TuneWeights.
+// Scores are the mean reciprocal rank of the first expected chunk over all labeled queries.
+type TuningResult struct {
+	SemanticWeight  float32 `json:"semanticWeight"`
+	KeywordWeight   float32 `json:"keywordWeight"`
+	Score           float64 `json:"score"`
+	BaselineScore   float64 `json:"baselineScore"`   // Score of the weights before tuning
+	CandidatesTried int     `json:"candidatesTried"` // Number of weight pairs evaluated
+}
+
+// TuneWeights grid-searches SemanticWeight/KeywordWeight against a labeled query set and
+// writes the best pair back into the retriever configuration. StructuralWeight is kept fixed
+// and the two tuned weights always share the remaining budget. The current weights are kept
+// unless another pair scores strictly better.
+func (r *DefaultDocumentRetriever) TuneWeights(samples []LabeledQuery, options *TuningOptions) (*TuningResult, error) {
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("at least one labeled query is required")
+	}
+	if options == nil {
+		options = DefaultTuningOptions()
+	}
+	if options.Step <= 0 || options.Step > 1 {
+		return nil, fmt.Errorf("invalid tuning step: %v", options.Step)
+	}
+
+	// Evaluate on a copy of the configuration; the evaluator caches each query embedding,
+	// so every labeled query is embedded once across all candidates
+	config := *r.config
+	evaluator := NewDocumentRetriever(r.embeddingService, r.vectorDB, r.embeddingGen, r.documents, &config)
+
+	evaluate := func(semantic, keyword float32) (float64, error) {
+		config.SemanticWeight = semantic
+		config.KeywordWeight = keyword
+		return evaluator.meanReciprocalRank(samples, options.TopK)
+	}
+
+	baseline, err := evaluate(r.config.SemanticWeight, r.config.KeywordWeight)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TuningResult{
+		SemanticWeight:  r.config.SemanticWeight,
+		KeywordWeight:   r.config.KeywordWeight,
+		Score:           baseline,
+		BaselineScore:   baseline,
+		CandidatesTried: 1,
+	}
+
+	budget := 1 - r.config.StructuralWeight
+	steps := int(budget/options.Step + 0.5)
+	for i := 0; i <= steps; i++ {
+		semantic := min(float32(i)*options.Step, budget)
+		keyword := budget - semantic
+
+		score, err := evaluate(semantic, keyword)
+		if err != nil {
+			return nil, err
+		}
+		result.CandidatesTried++
+
+		if score > result.Score {
+			result.SemanticWeight = semantic
+			result.KeywordWeight = keyword
+			result.Score = score
+		}
+	}
+
+	r.mu.Lock()
+	r.config.SemanticWeight = result.SemanticWeight
+	r.config.KeywordWeight = result.KeywordWeight
+	r.mu.Unlock()
+
+	return result, nil
+}
+
+// meanReciprocalRank scores retrieval against labeled queries using the configured strategy
+func (r *DefaultDocumentRetriever) meanReciprocalRank(samples []LabeledQuery, topK int) (float64, error) {
+	if topK <= 0 {
+		topK = DefaultTuningOptions().TopK
+	}
+
+	var total float64
+	for _, sample := range samples {
+		results, err := r.RetrieveByQuery(sample.Query, topK)
+		if err != nil {
+			return 0, fmt.Errorf("failed to evaluate query %q: %w", sample.Query, err)
+		}
+
+		expected := make(map[string]bool, len(sample.ExpectedChunkIDs))
+		for _, id := range sample.ExpectedChunkIDs {
+			expected[id] = true
+		}
+
+		for rank, result := range results {
+			if expected[result.ChunkID] {
+				total += 1 / float64(rank+1)
+				break
+			}
+		}
+	}
+
+	return total / float64(len(samples)), nil
+}
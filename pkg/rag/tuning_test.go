@@ -0,0 +1,156 @@
+package rag
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/kuderr/deepwiki/pkg/embeddings"
+	"github.com/kuderr/deepwiki/pkg/processor"
+)
+
+// labeledEmbeddingGenerator embeds each known query as its index and counts calls
+type labeledEmbeddingGenerator struct {
+	MockEmbeddingGenerator
+	queries map[string]int
+	calls   int
+}
+
+func (g *labeledEmbeddingGenerator) GenerateEmbedding(text string) ([]float32, error) {
+	g.calls++
+	return []float32{float32(g.queries[text])}, nil
+}
+
+// labeledVectorDB returns canned semantic results for each query index
+type labeledVectorDB struct {
+	MockVectorDB
+	results map[int][]embeddings.VectorSearchResult
+}
+
+func (db *labeledVectorDB) Search(
+	vector []float32,
+	options *embeddings.VectorSearchOptions,
+) ([]embeddings.VectorSearchResult, error) {
+	return db.results[int(vector[0])], nil
+}
+
+// newTuningFixture builds a corpus where semantic search prefers a misleading chunk for every
+// query while the keyword match points at the right one, so keyword-heavy weights win
+func newTuningFixture() (*DefaultDocumentRetriever, *labeledEmbeddingGenerator, []LabeledQuery) {
+	queries := []string{"parse config file", "open database connection", "render html template"}
+
+	embGen := &labeledEmbeddingGenerator{queries: make(map[string]int)}
+	vectorDB := &labeledVectorDB{results: make(map[int][]embeddings.VectorSearchResult)}
+
+	var docs []processor.Document
+	var samples []LabeledQuery
+	for i, query := range queries {
+		target := processor.Document{
+			ID:       fmt.Sprintf("target-doc-%d", i),
+			FilePath: fmt.Sprintf("src/target%d.go", i),
+			Language: "Go",
+			Category: "code",
+			Chunks: []processor.TextChunk{
+				{ID: fmt.Sprintf("target-%d", i), Text: "func handler() { // " + query + " }"},
+			},
+		}
+		noise := processor.Document{
+			ID:       fmt.Sprintf("noise-doc-%d", i),
+			FilePath: fmt.Sprintf("notes/noise%d.md", i),
+			Language: "Markdown",
+			Category: "docs",
+			Chunks: []processor.TextChunk{
+				{ID: fmt.Sprintf("noise-%d", i), Text: "The class layout is described here"},
+			},
+		}
+		docs = append(docs, target, noise)
+
+		embGen.queries[query] = i
+		vectorDB.results[i] = []embeddings.VectorSearchResult{
+			vectorResult(noise, 1.0),
+			vectorResult(target, 0.2),
+		}
+
+		samples = append(samples, LabeledQuery{Query: query, ExpectedChunkIDs: []string{target.Chunks[0].ID}})
+	}
+
+	return NewDocumentRetriever(nil, vectorDB, embGen, docs, DefaultRAGConfig()), embGen, samples
+}
+
+// vectorResult returns a semantic search hit for the first chunk of doc
+func vectorResult(doc processor.Document, score float32) embeddings.VectorSearchResult {
+	return embeddings.VectorSearchResult{
+		DocumentID: doc.ID,
+		ChunkID:    doc.Chunks[0].ID,
+		FilePath:   doc.FilePath,
+		Content:    doc.Chunks[0].Text,
+		Score:      score,
+	}
+}
+
+func TestTuneWeights(t *testing.T) {
+	retriever, embGen, samples := newTuningFixture()
+	defaults := DefaultRAGConfig()
+
+	result, err := retriever.TuneWeights(samples, nil)
+	if err != nil {
+		t.Fatalf("TuneWeights failed: %v", err)
+	}
+
+	if result.Score <= result.BaselineScore {
+		t.Errorf("Expected tuned score to beat defaults, got %f vs %f", result.Score, result.BaselineScore)
+	}
+	if result.Score != 1 {
+		t.Errorf("Expected every expected chunk ranked first after tuning, got MRR %f", result.Score)
+	}
+	if result.KeywordWeight <= defaults.KeywordWeight {
+		t.Errorf("Expected keyword weight above default %f, got %f", defaults.KeywordWeight, result.KeywordWeight)
+	}
+	if result.CandidatesTried < 10 {
+		t.Errorf("Expected the full grid to be evaluated, got %d candidates", result.CandidatesTried)
+	}
+
+	// Tuned weights are written back into the retriever configuration
+	config := retriever.config
+	if config.SemanticWeight != result.SemanticWeight || config.KeywordWeight != result.KeywordWeight {
+		t.Errorf("Expected config weights %f/%f, got %f/%f", result.SemanticWeight, result.KeywordWeight,
+			config.SemanticWeight, config.KeywordWeight)
+	}
+	if config.StructuralWeight != defaults.StructuralWeight {
+		t.Errorf("Expected structural weight to stay %f, got %f", defaults.StructuralWeight, config.StructuralWeight)
+	}
+
+	// Each query is embedded once regardless of how many candidates were tried
+	if embGen.calls != len(samples) {
+		t.Errorf("Expected %d embedding calls, got %d", len(samples), embGen.calls)
+	}
+}
+
+func TestTuneWeights_KeepsWeightsWithoutImprovement(t *testing.T) {
+	retriever, _, samples := newTuningFixture()
+
+	// With no expected chunk retrievable, no candidate can beat the current weights
+	for i := range samples {
+		samples[i].ExpectedChunkIDs = []string{"missing"}
+	}
+
+	result, err := retriever.TuneWeights(samples, nil)
+	if err != nil {
+		t.Fatalf("TuneWeights failed: %v", err)
+	}
+
+	defaults := DefaultRAGConfig()
+	if result.SemanticWeight != defaults.SemanticWeight || result.KeywordWeight != defaults.KeywordWeight {
+		t.Errorf("Expected default weights to be kept, got %f/%f", result.SemanticWeight, result.KeywordWeight)
+	}
+}
+
+func TestTuneWeights_InvalidInput(t *testing.T) {
+	retriever, _, samples := newTuningFixture()
+
+	if _, err := retriever.TuneWeights(nil, nil); err == nil {
+		t.Error("Expected error without labeled queries")
+	}
+	if _, err := retriever.TuneWeights(samples, &TuningOptions{Step: 0}); err == nil {
+		t.Error("Expected error for a zero step")
+	}
+}
@@ -1,6 +1,7 @@
 package rag
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/kuderr/deepwiki/pkg/processor"
@@ -13,8 +14,13 @@ type RetrievalContext struct {
 	MaxResults   int                `json:"maxResults"`   // Maximum number of results to return
 	MinScore     float32            `json:"minScore"`     // Minimum similarity score
 	Filters      map[string]string  `json:"filters"`      // Metadata filters
-	BoostFactors map[string]float32 `json:"boostFactors"` // Boost factors for different attributes
+	BoostFactors map[string]float32 `json:"boostFactors"` // Score multipliers by "field=value", e.g. "language=Go"
 	TimeWindow   *TimeWindow        `json:"timeWindow"`   // Optional time window for filtering
+
+	// MinResults relaxes MinScore step by step, down to MinScoreFloor, while fewer results are
+	// found (0 = no relaxation)
+	MinResults    int     `json:"minResults"`
+	MinScoreFloor float32 `json:"minScoreFloor"`
 }
 
 // QueryType represents different types of queries
@@ -27,6 +33,22 @@ const (
 	QueryTypeStructural QueryType = "structural" // Structure-based search (classes, functions, etc.)
 )
 
+// QueryTypes lists the supported query types
+var QueryTypes = []QueryType{QueryTypeSemantic, QueryTypeKeyword, QueryTypeHybrid, QueryTypeStructural}
+
+// ParseQueryType returns the query type with the given name; empty selects hybrid
+func ParseQueryType(name string) (QueryType, error) {
+	if name == "" {
+		return QueryTypeHybrid, nil
+	}
+	for _, queryType := range QueryTypes {
+		if string(queryType) == name {
+			return queryType, nil
+		}
+	}
+	return "", fmt.Errorf("unknown retrieval strategy: %s", name)
+}
+
 // TimeWindow represents a time range for filtering documents
 type TimeWindow struct {
 	Start time.Time `json:"start"`
@@ -54,7 +76,8 @@ type ChunkContext struct {
 	DocumentStart string               `json:"documentStart"` // Beginning of document
 	FunctionName  string               `json:"functionName"`  // Function/method name if applicable
 	ClassName     string               `json:"className"`     // Class name if applicable
-	LineNumbers   []int                `json:"lineNumbers"`   // Source line numbers
+	Signature     string               `json:"signature"`     // Header of the enclosing declaration
+	LineNumbers   []int                `json:"lineNumbers"`   // First and last source line of the chunk
 }
 
 // RelevanceInfo provides information about why a chunk is relevant
@@ -66,6 +89,7 @@ type RelevanceInfo struct {
 	MatchedTerms    []string `json:"matchedTerms"`    // Keywords/terms that matched
 	MatchedConcepts []string `json:"matchedConcepts"` // Semantic concepts that matched
 	BoostFactors    []string `json:"boostFactors"`    // Applied boost factors
+	Relaxed         bool     `json:"relaxed"`         // Retrieved with MinScore relaxed below the requested one
 }
 
 // RAGConfig represents configuration for the RAG system
@@ -140,6 +164,7 @@ type DocumentRetriever interface {
 	// Context-aware retrieval
 	RetrieveWithContext(query string, context []RetrievalResult, maxResults int) ([]RetrievalResult, error)
 	RetrieveRelatedChunks(chunkID string, maxResults int) ([]RetrievalResult, error)
+	RetrieveByFilePath(filePath string) ([]RetrievalResult, error)
 
 	// Filtering and ranking
 	FilterResults(results []RetrievalResult, filters map[string]string) []RetrievalResult
@@ -154,6 +179,7 @@ type RetrievalStats struct {
 	TotalQueries       int                `json:"totalQueries"`       // Total number of queries
 	AverageQueryTime   time.Duration      `json:"averageQueryTime"`   // Average query execution time
 	AverageResultCount float64            `json:"averageResultCount"` // Average number of results returned
+	RelaxedQueries     int                `json:"relaxedQueries"`     // Queries answered with a relaxed MinScore
 	MostCommonQueries  []QueryStats       `json:"mostCommonQueries"`  // Most common query patterns
 	PerformanceMetrics map[string]float64 `json:"performanceMetrics"` // Various performance metrics
 }
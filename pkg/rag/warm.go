@@ -0,0 +1,126 @@
+package rag
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxCachedQueryEmbeddings is the number of query embeddings kept at most; the least recently
+// used are dropped beyond it
+const maxCachedQueryEmbeddings = 1024
+
+// queryEmbeddingCache keeps the embeddings of recent queries, dropping the least recently used
+type queryEmbeddingCache struct {
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // Most recently used at the front
+}
+
+// queryEmbeddingEntry is a query and its embedding, as stored in the cache order
+type queryEmbeddingEntry struct {
+	query     string
+	embedding []float32
+}
+
+// newQueryEmbeddingCache creates a cache holding up to capacity embeddings
+func newQueryEmbeddingCache(capacity int) *queryEmbeddingCache {
+	return &queryEmbeddingCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// get returns the embedding of query and marks it as recently used
+func (c *queryEmbeddingCache) get(query string) ([]float32, bool) {
+	element, ok := c.entries[query]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*queryEmbeddingEntry).embedding, true
+}
+
+// put stores the embedding of query, dropping the least recently used beyond the capacity
+func (c *queryEmbeddingCache) put(query string, embedding []float32) {
+	if element, ok := c.entries[query]; ok {
+		element.Value.(*queryEmbeddingEntry).embedding = embedding
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[query] = c.order.PushFront(&queryEmbeddingEntry{query: query, embedding: embedding})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*queryEmbeddingEntry).query)
+	}
+}
+
+// keywordIndex holds the lowercased text of every chunk, aligned with the retriever's
+// documents, so keyword scoring does not re-normalize the corpus on each query
+type keywordIndex struct {
+	chunkText [][]string // [document][chunk] lowercased chunk text
+}
+
+// Warm prepares the retriever ahead of the first query: it builds the keyword index and
+// embeds the given seed queries so that retrieving them later skips the embedding call
+func (r *DefaultDocumentRetriever) Warm(ctx context.Context, seedQueries ...string) error {
+	startTime := time.Now()
+
+	r.keywordIndex()
+
+	for _, query := range seedQueries {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("warm-up interrupted: %w", err)
+		}
+		if _, err := r.queryEmbedding(query); err != nil {
+			return fmt.Errorf("failed to embed seed query %q: %w", query, err)
+		}
+	}
+
+	r.mu.Lock()
+	r.stats.PerformanceMetrics["Warm"] = float64(time.Since(startTime).Milliseconds())
+	r.mu.Unlock()
+
+	return nil
+}
+
+// keywordIndex returns the keyword index, building it on first use
+func (r *DefaultDocumentRetriever) keywordIndex() *keywordIndex {
+	r.keywordIdxOnce.Do(func() {
+		index := &keywordIndex{chunkText: make([][]string, len(r.documents))}
+		for i, doc := range r.documents {
+			index.chunkText[i] = make([]string, len(doc.Chunks))
+			for j, chunk := range doc.Chunks {
+				index.chunkText[i][j] = strings.ToLower(chunk.Text)
+			}
+		}
+		r.keywordIdx = index
+	})
+	return r.keywordIdx
+}
+
+// queryEmbedding returns the embedding of a query, reusing it if the query was embedded before
+func (r *DefaultDocumentRetriever) queryEmbedding(query string) ([]float32, error) {
+	r.cacheMu.Lock()
+	embedding, ok := r.queryEmbeddings.get(query)
+	r.cacheMu.Unlock()
+	if ok {
+		return embedding, nil
+	}
+
+	// Embed outside the lock so concurrent queries are not serialized on the provider call
+	embedding, err := r.embeddingGen.GenerateEmbedding(query)
+	if err != nil {
+		return nil, err
+	}
+
+	r.cacheMu.Lock()
+	r.queryEmbeddings.put(query, embedding)
+	r.cacheMu.Unlock()
+	return embedding, nil
+}
@@ -0,0 +1,101 @@
+package rag
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWarm(t *testing.T) {
+	retriever, embGen, samples := newTuningFixture()
+
+	if retriever.keywordIdx != nil {
+		t.Fatal("Expected keyword index to be built lazily")
+	}
+
+	if err := retriever.Warm(context.Background(), samples[0].Query); err != nil {
+		t.Fatalf("Warm failed: %v", err)
+	}
+
+	index := retriever.keywordIdx
+	if index == nil {
+		t.Fatal("Expected Warm to build the keyword index")
+	}
+	if len(index.chunkText) != len(retriever.documents) {
+		t.Errorf("Expected %d indexed documents, got %d", len(retriever.documents), len(index.chunkText))
+	}
+	if index.chunkText[1][0] != "the class layout is described here" {
+		t.Errorf("Expected lowercased chunk text aligned with documents, got %v", index.chunkText)
+	}
+	if embGen.calls != 1 {
+		t.Fatalf("Expected the seed query to be embedded once, got %d calls", embGen.calls)
+	}
+	if _, ok := retriever.GetRetrievalStats().PerformanceMetrics["Warm"]; !ok {
+		t.Error("Expected warm-up time to be recorded")
+	}
+
+	// The first query after warming uses the prepared index and the cached seed embedding
+	results, err := retriever.RetrieveByQuery(samples[0].Query, 5)
+	if err != nil {
+		t.Fatalf("RetrieveByQuery failed: %v", err)
+	}
+	if len(results) == 0 {
+		t.Fatal("Expected results for the seed query")
+	}
+	if embGen.calls != 1 {
+		t.Errorf("Expected seed query to be served from the embedding cache, got %d calls", embGen.calls)
+	}
+	if retriever.keywordIdx != index {
+		t.Error("Expected the keyword index to be reused, not rebuilt")
+	}
+
+	// Queries that were not seeded are embedded on demand and cached afterwards
+	for range 2 {
+		if _, err := retriever.RetrieveByQuery(samples[1].Query, 5); err != nil {
+			t.Fatalf("RetrieveByQuery failed: %v", err)
+		}
+	}
+	if embGen.calls != 2 {
+		t.Errorf("Expected one additional embedding call, got %d calls", embGen.calls)
+	}
+}
+
+func TestWarm_Cancelled(t *testing.T) {
+	retriever, embGen, samples := newTuningFixture()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := retriever.Warm(ctx, samples[0].Query); err == nil {
+		t.Error("Expected error for a cancelled context")
+	}
+	if embGen.calls != 0 {
+		t.Errorf("Expected no embedding calls after cancellation, got %d", embGen.calls)
+	}
+	if retriever.keywordIdx == nil {
+		t.Error("Expected the keyword index to be built before seed queries")
+	}
+}
+
+func TestQueryEmbeddingCache_DropsLeastRecentlyUsed(t *testing.T) {
+	cache := newQueryEmbeddingCache(2)
+	cache.put("a", []float32{1})
+	cache.put("b", []float32{2})
+
+	// Reading a makes b the least recently used
+	if _, ok := cache.get("a"); !ok {
+		t.Fatal("Expected a to be cached")
+	}
+	cache.put("c", []float32{3})
+
+	if _, ok := cache.get("b"); ok {
+		t.Error("Expected b to be dropped")
+	}
+	for _, query := range []string{"a", "c"} {
+		if _, ok := cache.get(query); !ok {
+			t.Errorf("Expected %s to be cached", query)
+		}
+	}
+	if cache.order.Len() != 2 || len(cache.entries) != 2 {
+		t.Errorf("Expected 2 cached embeddings, got %d", cache.order.Len())
+	}
+}
@@ -0,0 +1,146 @@
+// Package ratelimit schedules requests of several kinds, such as LLM and embedding calls against
+// the same account, under one shared rate limit.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Scheduler lets requests through at most at a fixed rate shared by all classes of requests.
+// When requests of several classes are waiting, the classes take turns, so that a burst of one
+// class does not starve the others.
+type Scheduler struct {
+	interval time.Duration
+
+	mu          sync.Mutex
+	next        time.Time            // Earliest time of the next request
+	classes     []string             // Classes in turn order, by first request
+	turn        int                  // Index in classes of the class whose turn is next
+	queues      map[string][]*waiter // Waiting requests of each class, oldest first
+	dispatching bool                 // Whether the dispatch goroutine is running
+	granted     map[string]int       // Requests let through per class
+}
+
+// waiter is a request waiting for its turn
+type waiter struct {
+	ready chan struct{}
+}
+
+// NewScheduler creates a scheduler letting requestsPerSecond requests through per second
+func NewScheduler(requestsPerSecond float64) (*Scheduler, error) {
+	if requestsPerSecond <= 0 {
+		return nil, fmt.Errorf("rate limit must be positive, got %v", requestsPerSecond)
+	}
+
+	return &Scheduler{
+		interval: time.Duration(float64(time.Second) / requestsPerSecond),
+		queues:   make(map[string][]*waiter),
+		granted:  make(map[string]int),
+	}, nil
+}
+
+// Wait blocks until a request of class may be sent, or until ctx is done
+func (s *Scheduler) Wait(ctx context.Context, class string) error {
+	w := &waiter{ready: make(chan struct{})}
+
+	s.mu.Lock()
+	if !slices.Contains(s.classes, class) {
+		s.classes = append(s.classes, class)
+	}
+	s.queues[class] = append(s.queues[class], w)
+	if !s.dispatching {
+		s.dispatching = true
+		go s.dispatch()
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		s.queues[class] = slices.DeleteFunc(s.queues[class], func(queued *waiter) bool { return queued == w })
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Granted returns the number of requests of class let through so far
+func (s *Scheduler) Granted(class string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.granted[class]
+}
+
+// dispatch lets the waiting requests through one per interval, taking the classes in turn, and
+// returns once no request is waiting
+func (s *Scheduler) dispatch() {
+	for {
+		s.mu.Lock()
+		class, ok := s.nextClass()
+		if !ok {
+			s.dispatching = false
+			s.mu.Unlock()
+			return
+		}
+
+		// Sleep without the lock, so that requests arriving meanwhile take part in the next turn
+		if delay := time.Until(s.next); delay > 0 {
+			s.mu.Unlock()
+			time.Sleep(delay)
+			continue
+		}
+
+		w := s.queues[class][0]
+		s.queues[class] = s.queues[class][1:]
+		s.turn = (slices.Index(s.classes, class) + 1) % len(s.classes)
+		s.granted[class]++
+		s.next = time.Now().Add(s.interval)
+		close(w.ready)
+		s.mu.Unlock()
+	}
+}
+
+// nextClass returns the class with waiting requests whose turn is next
+func (s *Scheduler) nextClass() (string, bool) {
+	for i := range s.classes {
+		class := s.classes[(s.turn+i)%len(s.classes)]
+		if len(s.queues[class]) > 0 {
+			return class, true
+		}
+	}
+	return "", false
+}
+
+// Transport returns a round tripper that sends each request through base once the scheduler lets
+// a request of class through. Every HTTP request is scheduled, retries and auxiliary requests such
+// as token counting included. A nil scheduler returns base unchanged.
+func (s *Scheduler) Transport(base http.RoundTripper, class string) http.RoundTripper {
+	if s == nil {
+		return base
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &scheduledTransport{base: base, scheduler: s, class: class}
+}
+
+// scheduledTransport is the round tripper returned by Scheduler.Transport
+type scheduledTransport struct {
+	base      http.RoundTripper
+	scheduler *Scheduler
+	class     string
+}
+
+// RoundTrip waits for the turn of the request, then sends it
+func (t *scheduledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.scheduler.Wait(req.Context(), t.class); err != nil {
+		return nil, fmt.Errorf("rate limiting failed: %w", err)
+	}
+	return t.base.RoundTrip(req)
+}
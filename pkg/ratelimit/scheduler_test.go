@@ -0,0 +1,199 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// queued returns the number of requests of class waiting in s
+func (s *Scheduler) queued(class string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queues[class])
+}
+
+// waitQueued polls until n requests of class were let through or are waiting
+func waitQueued(t *testing.T, s *Scheduler, class string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for s.Granted(class)+s.queued(class) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %d %s requests", n, class)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestNewScheduler_RejectsNonPositiveRate(t *testing.T) {
+	for _, rps := range []float64{0, -1} {
+		if _, err := NewScheduler(rps); err == nil {
+			t.Errorf("NewScheduler(%v) expected error", rps)
+		}
+	}
+}
+
+func TestScheduler_MixedLoadStaysWithinAccountLimit(t *testing.T) {
+	const rps = 50
+	scheduler, err := NewScheduler(rps)
+	if err != nil {
+		t.Fatalf("NewScheduler failed: %v", err)
+	}
+
+	var mu sync.Mutex
+	var sent []time.Time
+	var wg sync.WaitGroup
+	// Each class alone sends as fast as its workers can, as independent limiters would allow
+	for _, class := range []string{"llm", "embedding"} {
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 5 {
+					if err := scheduler.Wait(context.Background(), class); err != nil {
+						t.Errorf("Wait failed: %v", err)
+						return
+					}
+					mu.Lock()
+					sent = append(sent, time.Now())
+					mu.Unlock()
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	if len(sent) != 40 {
+		t.Fatalf("Expected 40 requests, got %d", len(sent))
+	}
+	if scheduler.Granted("llm") != 20 || scheduler.Granted("embedding") != 20 {
+		t.Errorf("Expected 20 requests per class, got %d llm and %d embedding",
+			scheduler.Granted("llm"), scheduler.Granted("embedding"))
+	}
+
+	// Any 10 consecutive requests span at least 10 intervals of the combined budget, give or
+	// take the time goroutines take to wake up
+	slices.SortFunc(sent, func(a, b time.Time) int { return a.Compare(b) })
+	interval := time.Second / rps
+	const window = 10
+	for i := 0; i+window < len(sent); i++ {
+		if span := sent[i+window].Sub(sent[i]); span < window*interval-interval/2 {
+			t.Fatalf("Requests %d-%d were sent within %v, above %d requests per second", i, i+window, span, rps)
+		}
+	}
+	if elapsed := sent[len(sent)-1].Sub(sent[0]); elapsed < 39*interval-interval/2 {
+		t.Errorf("40 requests were sent within %v, above %d requests per second", elapsed, rps)
+	}
+}
+
+func TestScheduler_ClassesTakeTurns(t *testing.T) {
+	scheduler, err := NewScheduler(50)
+	if err != nil {
+		t.Fatalf("NewScheduler failed: %v", err)
+	}
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	send := func(class string, n int) {
+		for range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := scheduler.Wait(context.Background(), class); err != nil {
+					t.Errorf("Wait failed: %v", err)
+					return
+				}
+				mu.Lock()
+				order = append(order, class)
+				mu.Unlock()
+			}()
+		}
+	}
+
+	// A burst of LLM requests is queued before the embedding requests arrive
+	send("llm", 10)
+	waitQueued(t, scheduler, "llm", 10)
+	send("embedding", 3)
+	waitQueued(t, scheduler, "embedding", 3)
+	wg.Wait()
+
+	// The embedding requests alternate with the LLM requests instead of waiting for the burst
+	last := -1
+	for i, class := range order {
+		if class == "embedding" {
+			last = i
+		}
+	}
+	if last < 0 || last > 7 {
+		t.Errorf("Expected the embedding requests to be interleaved with the LLM burst, got %v", order)
+	}
+}
+
+func TestScheduler_WaitStopsWhenContextIsDone(t *testing.T) {
+	scheduler, err := NewScheduler(1)
+	if err != nil {
+		t.Fatalf("NewScheduler failed: %v", err)
+	}
+
+	// The first request takes the budget of the next second
+	if err := scheduler.Wait(context.Background(), "llm"); err != nil {
+		t.Fatalf("Wait failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := scheduler.Wait(ctx, "embedding"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the deadline error, got %v", err)
+	}
+	if queued := scheduler.queued("embedding"); queued != 0 {
+		t.Errorf("Expected the cancelled request to leave the queue, got %d queued", queued)
+	}
+	if granted := scheduler.Granted("embedding"); granted != 0 {
+		t.Errorf("Expected no embedding request let through, got %d", granted)
+	}
+}
+
+func TestScheduler_Transport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	var scheduler *Scheduler
+	if transport := scheduler.Transport(http.DefaultTransport, "llm"); transport != http.DefaultTransport {
+		t.Errorf("Expected a nil scheduler to return the base transport, got %T", transport)
+	}
+
+	scheduler, err := NewScheduler(1)
+	if err != nil {
+		t.Fatalf("NewScheduler failed: %v", err)
+	}
+	client := &http.Client{Transport: scheduler.Transport(nil, "llm")}
+
+	response, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	response.Body.Close()
+	if scheduler.Granted("llm") != 1 {
+		t.Errorf("Expected 1 scheduled request, got %d", scheduler.Granted("llm"))
+	}
+
+	// The budget of the next second is taken, so a cancelled request is never sent
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatalf("NewRequest failed: %v", err)
+	}
+	if _, err := client.Do(request); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the cancellation error, got %v", err)
+	}
+	if scheduler.Granted("llm") != 1 {
+		t.Errorf("Expected the cancelled request not to be scheduled, got %d", scheduler.Granted("llm"))
+	}
+}
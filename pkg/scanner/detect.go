@@ -0,0 +1,121 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// detectionHeadSize is how much of a file is read to detect its language from content
+const detectionHeadSize = 1024
+
+// shebangInterpreters maps script interpreters to the extension used to classify them
+var shebangInterpreters = map[string]string{
+	"sh":     ".sh",
+	"dash":   ".sh",
+	"ksh":    ".sh",
+	"zsh":    ".sh",
+	"bash":   ".bash",
+	"python": ".py",
+	"node":   ".js",
+	"nodejs": ".js",
+	"deno":   ".ts",
+	"ruby":   ".rb",
+	"php":    ".php",
+	"pwsh":   ".ps1",
+}
+
+// contentSignature classifies a file whose first meaningful line matches pattern
+type contentSignature struct {
+	pattern   *regexp.Regexp
+	extension string
+}
+
+// contentSignatures are checked in order against the first non-blank, non-comment line
+var contentSignatures = []contentSignature{
+	{regexp.MustCompile(`^<\?php\b`), ".php"},
+	{regexp.MustCompile(`^<\?xml\b`), ".xml"},
+	{regexp.MustCompile(`^(?i)<!doctype html|^<html\b`), ".html"},
+	{regexp.MustCompile(`^(FROM|ARG)\s+\S+`), ".dockerfile"},
+	{regexp.MustCompile(`^package [a-z_][a-z0-9_]*$`), ".go"},
+	{regexp.MustCompile(`^#include\s*[<"]`), ".c"},
+}
+
+// isAmbiguousExtension reports whether a file's extension does not identify its language
+func isAmbiguousExtension(ext string) bool {
+	return ext == "" || ext == ".txt"
+}
+
+// DetectExtension classifies a file with a missing or ambiguous extension by its content.
+// It returns the extension used to classify the file, or "" when the content is not recognized.
+func DetectExtension(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	head := make([]byte, detectionHeadSize)
+	n, _ := file.Read(head)
+	return detectExtensionFromContent(head[:n])
+}
+
+// detectExtensionFromContent classifies content by its shebang or first meaningful line
+func detectExtensionFromContent(head []byte) string {
+	if bytes.IndexByte(head, 0) >= 0 {
+		return ""
+	}
+
+	if ext := extensionFromShebang(head); ext != "" {
+		return ext
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(head))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "#include") {
+			continue
+		}
+
+		for _, signature := range contentSignatures {
+			if signature.pattern.MatchString(line) {
+				return signature.extension
+			}
+		}
+		return ""
+	}
+
+	return ""
+}
+
+// extensionFromShebang classifies a script by the interpreter named in its #! line,
+// e.g. "#!/bin/bash" or "#!/usr/bin/env -S python3 -u"
+func extensionFromShebang(head []byte) string {
+	if !bytes.HasPrefix(head, []byte("#!")) {
+		return ""
+	}
+
+	line, _, _ := bytes.Cut(head[2:], []byte("\n"))
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return ""
+	}
+
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" {
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") && !strings.Contains(field, "=") {
+				interpreter = field
+				break
+			}
+		}
+	}
+
+	// Drop version suffixes such as python3 or python3.12
+	interpreter = strings.TrimRight(interpreter, "0123456789.")
+	return shebangInterpreters[interpreter]
+}
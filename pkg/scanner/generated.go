@@ -0,0 +1,66 @@
+package scanner
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// generatedHeaderPattern matches the standard marker of generated Go source (see `go help generate`)
+var generatedHeaderPattern = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// DefaultGeneratedFilePatterns returns file name patterns of common machine-generated Go files
+func DefaultGeneratedFilePatterns() []string {
+	return []string{
+		// Protocol buffers and gRPC
+		"*.pb.go", "*.pb.gw.go", "*.pb.validate.go",
+		// Mocks
+		"mock_*.go", "*_mock.go",
+		// Code generators
+		"*_gen.go", "*_generated.go", "zz_generated.*.go", "bindata.go",
+	}
+}
+
+// isGeneratedFile reports whether a file should be skipped because of its name
+func (s *Scanner) isGeneratedFile(fileInfo *FileInfo) bool {
+	if !s.options.ExcludeGenerated {
+		return false
+	}
+
+	patterns := s.options.GeneratedPatterns
+	if patterns == nil {
+		patterns = DefaultGeneratedFilePatterns()
+	}
+	return matchesAnyPattern(fileInfo, patterns)
+}
+
+// hasGeneratedHeader reports whether a Go file should be skipped because it is marked as generated
+func (s *Scanner) hasGeneratedHeader(fileInfo *FileInfo) bool {
+	if !s.options.ExcludeGenerated || !fileInfo.IsText || fileInfo.Extension != ".go" {
+		return false
+	}
+	return IsGeneratedGoFile(fileInfo.AbsolutePath)
+}
+
+// IsGeneratedGoFile reports whether a Go file carries the "// Code generated ... DO NOT EDIT." marker
+// before its package clause
+func IsGeneratedGoFile(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if generatedHeaderPattern.MatchString(line) {
+			return true
+		}
+		if strings.HasPrefix(line, "package ") {
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package scanner
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ImportanceRule assigns an importance score to files matching a pattern.
+// Patterns are globs matched against the file name or relative path; a pattern
+// ending in "/**" matches every file below a directory with that name or path.
+type ImportanceRule struct {
+	Pattern    string `json:"pattern"`
+	Importance int    `json:"importance"` // 1-5 scale
+}
+
+// Validate checks that the rule has a usable pattern and score
+func (r ImportanceRule) Validate() error {
+	if r.Pattern == "" {
+		return fmt.Errorf("importance rule pattern is required")
+	}
+	if _, err := filepath.Match(strings.TrimSuffix(r.Pattern, "/**"), ""); err != nil {
+		return fmt.Errorf("invalid importance rule pattern %q: %w", r.Pattern, err)
+	}
+	if r.Importance < 1 || r.Importance > 5 {
+		return fmt.Errorf("importance for pattern %q must be between 1 and 5, got %d", r.Pattern, r.Importance)
+	}
+	return nil
+}
+
+// matches reports whether the rule applies to a file
+func (r ImportanceRule) matches(fileInfo *FileInfo) bool {
+	dirPattern, isDirPattern := strings.CutSuffix(r.Pattern, "/**")
+	if !isDirPattern {
+		return matchesAnyPattern(fileInfo, []string{r.Pattern})
+	}
+
+	// Match each ancestor directory by its name and by its path from the scan root
+	dir := path.Dir(filepath.ToSlash(fileInfo.Path))
+	for dir != "." && dir != "/" {
+		if matched, _ := path.Match(dirPattern, dir); matched {
+			return true
+		}
+		if matched, _ := path.Match(dirPattern, path.Base(dir)); matched {
+			return true
+		}
+		dir = path.Dir(dir)
+	}
+	return false
+}
+
+// ruleImportance returns the score of the first rule matching a file
+func (s *Scanner) ruleImportance(fileInfo *FileInfo) (int, bool) {
+	for _, rule := range s.options.ImportanceRules {
+		if rule.matches(fileInfo) {
+			return rule.Importance, true
+		}
+	}
+	return 0, false
+}
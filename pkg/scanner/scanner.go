@@ -5,8 +5,11 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -21,6 +24,9 @@ type Scanner struct {
 	stats   ScanStats
 	mutex   sync.RWMutex
 	logger  *logging.Logger
+
+	// includeExtensions is the normalized extension allowlist, nil when every extension is allowed
+	includeExtensions map[string]bool
 }
 
 // ScanStats tracks scanning statistics
@@ -39,11 +45,38 @@ func NewScanner(options *ScanOptions) *Scanner {
 		options = DefaultScanOptions()
 	}
 
+	var includeExtensions map[string]bool
+	if len(options.IncludeExtensions) > 0 {
+		includeExtensions = make(map[string]bool, len(options.IncludeExtensions))
+		for _, ext := range options.IncludeExtensions {
+			includeExtensions[normalizeExtension(ext)] = true
+		}
+	}
+
 	return &Scanner{
-		options: options,
-		stats:   ScanStats{},
-		logger:  logging.GetGlobalLogger().WithComponent("scanner"),
+		options:           options,
+		includeExtensions: includeExtensions,
+		stats:             ScanStats{},
+		logger:            logging.GetGlobalLogger().WithComponent("scanner"),
+	}
+}
+
+// normalizeExtension lowercases an allowlist entry and adds the leading dot if it is missing
+func normalizeExtension(ext string) string {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
 	}
+	return ext
+}
+
+// extensionFilter describes the effective extension allowlist for logging
+func (s *Scanner) extensionFilter() string {
+	if s.includeExtensions == nil {
+		return "all text files"
+	}
+	extensions := slices.Sorted(maps.Keys(s.includeExtensions))
+	return fmt.Sprintf("only %s, before exclude patterns", strings.Join(extensions, " "))
 }
 
 // ScanDirectory scans a directory and returns information about all relevant files
@@ -74,8 +107,10 @@ func (s *Scanner) ScanDirectory(rootPath string) (*ScanResult, error) {
 	s.logger.InfoContext(context.Background(), "starting directory scan",
 		slog.String("path", absRoot),
 		slog.Bool("concurrent", s.options.Concurrent),
+		slog.Int("max_workers", s.maxWorkers()),
 		slog.Int("max_files", s.options.MaxFiles),
 		slog.Int("max_depth", s.options.MaxDepth),
+		slog.String("extensions", s.extensionFilter()),
 	)
 
 	var files []FileInfo
@@ -87,6 +122,14 @@ func (s *Scanner) ScanDirectory(rootPath string) (*ScanResult, error) {
 		files, allErrors = s.scanSequential(absRoot)
 	}
 
+	if s.options.MaxFiles > 0 && len(files) > s.options.MaxFiles {
+		s.logger.WarnContext(context.Background(), "max files limit reached, keeping the most important files",
+			slog.Int("max_files", s.options.MaxFiles),
+			slog.Int("dropped", len(files)-s.options.MaxFiles),
+		)
+		files = mostImportantFiles(files, s.options.MaxFiles)
+	}
+
 	s.mutex.Lock()
 	s.stats.EndTime = time.Now()
 	scanTime := s.stats.EndTime.Sub(s.stats.StartTime)
@@ -101,6 +144,7 @@ func (s *Scanner) ScanDirectory(rootPath string) (*ScanResult, error) {
 		Errors:        allErrors,
 		ScanTime:      scanTime,
 	}
+	result.computeStats()
 
 	return result, nil
 }
@@ -110,6 +154,26 @@ func (s *Scanner) scanSequential(rootPath string) ([]FileInfo, []string) {
 	var files []FileInfo
 	var errors []string
 
+	walkErrors := s.walk(rootPath, func(path string, info os.FileInfo) {
+		fileInfo, shouldInclude, err := s.processFile(path, rootPath, info)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("error processing %s: %v", path, err))
+			return
+		}
+		if s.countFile(shouldInclude) {
+			files = append(files, *fileInfo)
+		}
+	})
+
+	return files, append(walkErrors, errors...)
+}
+
+// walk walks the directory tree below rootPath, skipping excluded directories, symlinks that are
+// not followed and entries deeper than the maximum depth, and calls visit for each other file in
+// walk order. It returns the errors met while walking.
+func (s *Scanner) walk(rootPath string, visit func(path string, info os.FileInfo)) []string {
+	var errors []string
+
 	err := filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			errors = append(errors, fmt.Sprintf("error accessing %s: %v", path, err))
@@ -148,43 +212,102 @@ func (s *Scanner) scanSequential(rootPath string) ([]FileInfo, []string) {
 			return nil
 		}
 
-		// Process file
-		fileInfo, shouldInclude, err := s.processFile(path, rootPath, info)
-		if err != nil {
-			errors = append(errors, fmt.Sprintf("error processing %s: %v", path, err))
-			return nil
-		}
+		visit(path, info)
+		return nil
+	})
 
-		s.mutex.Lock()
-		s.stats.FilesProcessed++
-		if shouldInclude {
-			files = append(files, *fileInfo)
-		} else {
-			s.stats.FilesFiltered++
-		}
+	if err != nil {
+		errors = append(errors, fmt.Sprintf("walk error: %v", err))
+	}
 
-		// Check max files limit
-		if s.options.MaxFiles > 0 && len(files) >= s.options.MaxFiles {
-			s.mutex.Unlock()
-			return fmt.Errorf("max files limit reached")
+	return errors
+}
+
+// countFile records a processed file in the statistics and returns whether it is included
+func (s *Scanner) countFile(shouldInclude bool) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.stats.FilesProcessed++
+	if !shouldInclude {
+		s.stats.FilesFiltered++
+	}
+	return shouldInclude
+}
+
+// mostImportantFiles returns the maxFiles files of highest importance, breaking ties by path so
+// that the selection does not depend on the walk order. The files keep their order.
+func mostImportantFiles(files []FileInfo, maxFiles int) []FileInfo {
+	ranked := slices.Clone(files)
+	slices.SortStableFunc(ranked, func(a, b FileInfo) int {
+		if a.Importance != b.Importance {
+			return b.Importance - a.Importance
 		}
-		s.mutex.Unlock()
+		return strings.Compare(a.Path, b.Path)
+	})
 
-		return nil
+	kept := make(map[string]bool, maxFiles)
+	for _, file := range ranked[:maxFiles] {
+		kept[file.Path] = true
+	}
+	return slices.DeleteFunc(files, func(file FileInfo) bool { return !kept[file.Path] })
+}
+
+// scanConcurrent walks the directory tree first and then processes the files with MaxWorkers
+// workers. Files are returned in walk order, as by scanSequential.
+func (s *Scanner) scanConcurrent(rootPath string) ([]FileInfo, []string) {
+	type walkedFile struct {
+		path string
+		info os.FileInfo
+	}
+	var walked []walkedFile
+	errors := s.walk(rootPath, func(path string, info os.FileInfo) {
+		walked = append(walked, walkedFile{path: path, info: info})
 	})
 
-	if err != nil && !strings.Contains(err.Error(), "max files limit") {
-		errors = append(errors, fmt.Sprintf("walk error: %v", err))
+	type processed struct {
+		fileInfo *FileInfo
+		include  bool
+		err      error
+	}
+	results := make([]processed, len(walked))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(s.maxWorkers(), len(walked)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fileInfo, include, err := s.processFile(walked[i].path, rootPath, walked[i].info)
+				results[i] = processed{fileInfo: fileInfo, include: include, err: err}
+			}
+		}()
+	}
+	for i := range walked {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	var files []FileInfo
+	for i, result := range results {
+		if result.err != nil {
+			errors = append(errors, fmt.Sprintf("error processing %s: %v", walked[i].path, result.err))
+			continue
+		}
+		if s.countFile(result.include) {
+			files = append(files, *result.fileInfo)
+		}
 	}
 
 	return files, errors
 }
 
-// scanConcurrent performs concurrent directory scanning
-func (s *Scanner) scanConcurrent(rootPath string) ([]FileInfo, []string) {
-	// For now, implement a simple concurrent version
-	// In a production implementation, you might want a more sophisticated worker pool
-	return s.scanSequential(rootPath) // Fallback to sequential for now
+// maxWorkers returns the number of workers of a concurrent scan
+func (s *Scanner) maxWorkers() int {
+	if s.options.MaxWorkers > 0 {
+		return s.options.MaxWorkers
+	}
+	return runtime.NumCPU()
 }
 
 // processFile processes a single file and returns its information
@@ -200,17 +323,36 @@ func (s *Scanner) processFile(path, rootPath string, info os.FileInfo) (*FileInf
 		Path:         relPath,
 		AbsolutePath: path,
 		Name:         info.Name(),
-		Extension:    strings.ToLower(filepath.Ext(info.Name())),
+		Extension:    GetFileExtension(info.Name()),
 		Size:         info.Size(),
 		ModTime:      info.ModTime(),
 		IsDir:        info.IsDir(),
 	}
 
+	// Classify extensionless and ambiguous files by their content
+	if isAmbiguousExtension(fileInfo.Extension) && !fileInfo.IsDir && !s.isSecretFile(fileInfo) {
+		if ext := DetectExtension(path); ext != "" {
+			fileInfo.Extension = ext
+		}
+	}
+
 	// Check if file should be excluded
 	if s.shouldExcludeFile(fileInfo) {
 		return fileInfo, false, nil
 	}
 
+	// Never read files that typically hold credentials
+	if s.isSecretFile(fileInfo) {
+		s.logger.DebugContext(context.Background(), "skipping secret-bearing file", slog.String("path", relPath))
+		return fileInfo, false, nil
+	}
+
+	// Skip machine-generated code by name
+	if s.isGeneratedFile(fileInfo) {
+		s.logger.DebugContext(context.Background(), "skipping generated file", slog.String("path", relPath))
+		return fileInfo, false, nil
+	}
+
 	// Check file size limit
 	if s.options.MaxFileSize > 0 && fileInfo.Size > s.options.MaxFileSize {
 		return fileInfo, false, nil
@@ -224,6 +366,23 @@ func (s *Scanner) processFile(path, rootPath string, info os.FileInfo) (*FileInf
 		}
 	}
 
+	// Without an extension allowlist only text files are included
+	if s.includeExtensions == nil && s.isBinary(fileInfo) {
+		s.logger.DebugContext(context.Background(), "skipping binary file", slog.String("path", relPath))
+		return fileInfo, false, nil
+	}
+
+	if s.hasSecretContent(fileInfo) {
+		s.logger.WarnContext(context.Background(), "skipping file with credential-like content",
+			slog.String("path", relPath))
+		return fileInfo, false, nil
+	}
+
+	if s.hasGeneratedHeader(fileInfo) {
+		s.logger.DebugContext(context.Background(), "skipping file marked as generated", slog.String("path", relPath))
+		return fileInfo, false, nil
+	}
+
 	// Set language and category information
 	lang := GetLanguageByExtension(fileInfo.Extension)
 	fileInfo.Language = lang.Name
@@ -236,6 +395,11 @@ func (s *Scanner) processFile(path, rootPath string, info os.FileInfo) (*FileInf
 		fileInfo.Importance = 2
 	}
 
+	// Path and file name rules take precedence over the language score
+	if importance, ok := s.ruleImportance(fileInfo); ok {
+		fileInfo.Importance = importance
+	}
+
 	return fileInfo, true, nil
 }
 
@@ -279,6 +443,33 @@ func (s *Scanner) analyzeFile(fileInfo *FileInfo) error {
 	return nil
 }
 
+// IsIgnored reports whether a path below rootPath is skipped by the directory, file, secret and
+// generated-file name rules. Content-based checks are not applied, so the path need not exist.
+func (s *Scanner) IsIgnored(path, rootPath string, isDir bool) bool {
+	relPath, err := filepath.Rel(rootPath, path)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return true
+	}
+	if isDir {
+		return relPath != "." && s.shouldExcludeDir(path, rootPath)
+	}
+
+	// Files in excluded directories are ignored with them
+	for dir := filepath.Dir(relPath); dir != "."; dir = filepath.Dir(dir) {
+		if s.shouldExcludeDir(filepath.Join(rootPath, dir), rootPath) {
+			return true
+		}
+	}
+
+	fileInfo := &FileInfo{
+		Path:         relPath,
+		AbsolutePath: path,
+		Name:         filepath.Base(path),
+		Extension:    GetFileExtension(filepath.Base(path)),
+	}
+	return s.shouldExcludeFile(fileInfo) || s.isSecretFile(fileInfo) || s.isGeneratedFile(fileInfo)
+}
+
 // shouldExcludeDir determines if a directory should be excluded
 func (s *Scanner) shouldExcludeDir(dirPath, rootPath string) bool {
 	relPath, _ := filepath.Rel(rootPath, dirPath)
@@ -291,8 +482,13 @@ func (s *Scanner) shouldExcludeDir(dirPath, rootPath string) bool {
 		if matched, _ := filepath.Match(excludePattern, relPath); matched {
 			return true
 		}
-		// Also check if the pattern is a substring
-		if strings.Contains(relPath, excludePattern) {
+		// Also check if the pattern is a substring. ".github" holds the CI definitions, so the
+		// ".git" pattern does not exclude it.
+		substringPath := relPath
+		if excludePattern == ".git" {
+			substringPath = strings.ReplaceAll(relPath, ".github", "")
+		}
+		if strings.Contains(substringPath, excludePattern) {
 			return true
 		}
 	}
@@ -302,18 +498,9 @@ func (s *Scanner) shouldExcludeDir(dirPath, rootPath string) bool {
 
 // shouldExcludeFile determines if a file should be excluded
 func (s *Scanner) shouldExcludeFile(fileInfo *FileInfo) bool {
-	// Check extension inclusion list
-	if len(s.options.IncludeExtensions) > 0 {
-		included := false
-		for _, ext := range s.options.IncludeExtensions {
-			if fileInfo.Extension == ext {
-				included = true
-				break
-			}
-		}
-		if !included {
-			return true
-		}
+	// The extension allowlist is checked first; exclude patterns then apply to allowed files
+	if s.includeExtensions != nil && !s.includeExtensions[fileInfo.Extension] {
+		return true
 	}
 
 	// Check file exclusion patterns
@@ -326,6 +513,19 @@ func (s *Scanner) shouldExcludeFile(fileInfo *FileInfo) bool {
 		}
 	}
 
+	// Exact paths are compared in absolute form, whatever root the scan started from
+	if len(s.options.ExcludePaths) > 0 {
+		absPath, err := filepath.Abs(fileInfo.AbsolutePath)
+		if err != nil {
+			return false
+		}
+		for _, excludePath := range s.options.ExcludePaths {
+			if excluded, err := filepath.Abs(excludePath); err == nil && excluded == absPath {
+				return true
+			}
+		}
+	}
+
 	return false
 }
 
@@ -336,6 +536,17 @@ func isSymlink(info os.FileInfo) bool {
 	return info.Mode()&os.ModeSymlink != 0
 }
 
+// isBinary reports whether a file is binary, using the content analysis when it was done
+func (s *Scanner) isBinary(fileInfo *FileInfo) bool {
+	if fileInfo.IsBinary {
+		return true
+	}
+	if s.options.AnalyzeContent && fileInfo.IsText {
+		return false
+	}
+	return isBinaryFile(fileInfo.AbsolutePath)
+}
+
 // isBinaryFile attempts to determine if a file is binary
 func isBinaryFile(path string) bool {
 	file, err := os.Open(path)
@@ -0,0 +1,100 @@
+package scanner
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// DefaultSecretFilePatterns returns file name patterns that commonly hold credentials.
+// Matching files are skipped unless they are explicitly allowed.
+func DefaultSecretFilePatterns() []string {
+	return []string{
+		// Environment files
+		".env", ".env.*", "*.env",
+		// Keys and certificates
+		"*.pem", "*.key", "*.p12", "*.pfx", "*.jks", "*.keystore", "*.ppk", "*.asc", "*.gpg",
+		// SSH keys
+		"id_rsa", "id_dsa", "id_ecdsa", "id_ed25519",
+		// Credential stores
+		"credentials", "credentials.json", "credentials.yml", "credentials.yaml",
+		"secrets.json", "secrets.yml", "secrets.yaml",
+		".netrc", ".npmrc", ".pypirc", ".pgpass", ".htpasswd", ".git-credentials",
+		"*.kdbx", "*.tfstate", "*.tfstate.*",
+	}
+}
+
+// secretContentPatterns match well-known key and token formats
+var secretContentPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY( BLOCK)?-----`),
+	regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`),                      // AWS access key ID
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`),            // GitHub token
+	regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{40,}\b`),          // GitHub fine-grained token
+	regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`),          // Slack token
+	regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`),                // Google API key
+	regexp.MustCompile(`\b[rs]k_live_[0-9A-Za-z]{24,}\b`),           // Stripe live key
+	regexp.MustCompile(`\bsk-(?:proj-|ant-)?[A-Za-z0-9_\-]{32,}\b`), // OpenAI / Anthropic key
+}
+
+// matchesAnyPattern reports whether the file's name or relative path matches one of patterns
+func matchesAnyPattern(fileInfo *FileInfo, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, fileInfo.Name); matched {
+			return true
+		}
+		if matched, _ := filepath.Match(pattern, filepath.ToSlash(fileInfo.Path)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// isSecretFile reports whether a file should be skipped because of its name
+func (s *Scanner) isSecretFile(fileInfo *FileInfo) bool {
+	if !s.options.ExcludeSecrets || matchesAnyPattern(fileInfo, s.options.AllowFiles) {
+		return false
+	}
+
+	patterns := s.options.SecretPatterns
+	if patterns == nil {
+		patterns = DefaultSecretFilePatterns()
+	}
+	return matchesAnyPattern(fileInfo, patterns)
+}
+
+// hasSecretContent reports whether a text file should be skipped because it contains credentials
+func (s *Scanner) hasSecretContent(fileInfo *FileInfo) bool {
+	if !s.options.ExcludeSecrets || !fileInfo.IsText || matchesAnyPattern(fileInfo, s.options.AllowFiles) {
+		return false
+	}
+	return ContainsSecrets(fileInfo.AbsolutePath, s.options.MaxFileSize)
+}
+
+// ContainsSecrets reports whether the first maxBytes of a file match a known key or token format.
+// A non-positive maxBytes reads the whole file.
+func ContainsSecrets(path string, maxBytes int64) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if maxBytes > 0 {
+		reader = io.LimitReader(file, maxBytes)
+	}
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return false
+	}
+
+	text := string(content)
+	for _, pattern := range secretContentPatterns {
+		if pattern.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
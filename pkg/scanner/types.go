@@ -1,6 +1,9 @@
 package scanner
 
 import (
+	"path/filepath"
+	"runtime"
+	"strings"
 	"time"
 )
 
@@ -35,28 +38,69 @@ type ScanResult struct {
 	Files         []FileInfo    `json:"files"`         // List of scanned files
 	Errors        []string      `json:"errors"`        // Any errors encountered during scan
 	ScanTime      time.Duration `json:"scanTime"`      // Time taken to complete scan
+
+	// Aggregates over Files
+	LanguageStats map[string]int `json:"languageStats"` // Number of files per language
+	CategoryStats map[string]int `json:"categoryStats"` // Number of files per category
+	TotalLines    int            `json:"totalLines"`    // Lines in all text files
+	LinesOfCode   int            `json:"linesOfCode"`   // Lines in files of the code category
+}
+
+// computeStats fills the aggregate statistics from Files
+func (r *ScanResult) computeStats() {
+	r.LanguageStats = make(map[string]int)
+	r.CategoryStats = make(map[string]int)
+	r.TotalLines = 0
+	r.LinesOfCode = 0
+
+	for _, file := range r.Files {
+		if file.Language != "" {
+			r.LanguageStats[file.Language]++
+		}
+		if file.Category != "" {
+			r.CategoryStats[file.Category]++
+		}
+		r.TotalLines += file.LineCount
+		if file.Category == string(CategoryCode) {
+			r.LinesOfCode += file.LineCount
+		}
+	}
 }
 
 // ScanOptions represents options for directory scanning
 type ScanOptions struct {
-	// Filtering options
+	// Filtering options. A non-empty IncludeExtensions keeps only files with those extensions
+	// and is checked before the exclude patterns; an empty list includes every text file.
 	IncludeExtensions []string `json:"includeExtensions"` // Extensions to include
 	ExcludeDirs       []string `json:"excludeDirs"`       // Directories to exclude
 	ExcludeFiles      []string `json:"excludeFiles"`      // File patterns to exclude
+	ExcludePaths      []string `json:"excludePaths"`      // Exact files to exclude, such as saved state
 
 	// Scanning options
 	FollowSymlinks bool `json:"followSymlinks"` // Whether to follow symbolic links
 	MaxDepth       int  `json:"maxDepth"`       // Maximum directory depth (0 = unlimited)
-	MaxFiles       int  `json:"maxFiles"`       // Maximum number of files to process
+	MaxFiles       int  `json:"maxFiles"`       // Maximum number of files to keep, the most important first
 
 	// Content analysis options
 	AnalyzeContent  bool  `json:"analyzeContent"`  // Whether to analyze file content
 	MaxFileSize     int64 `json:"maxFileSize"`     // Maximum file size to analyze (bytes)
 	SkipBinaryFiles bool  `json:"skipBinaryFiles"` // Whether to skip binary files
 
+	// Secret protection options
+	ExcludeSecrets bool     `json:"excludeSecrets"` // Skip files that look like they hold credentials
+	SecretPatterns []string `json:"secretPatterns"` // Secret file name patterns (nil = DefaultSecretFilePatterns)
+	AllowFiles     []string `json:"allowFiles"`     // Patterns exempt from secret detection
+
+	// Generated code options
+	ExcludeGenerated  bool     `json:"excludeGenerated"`  // Skip machine-generated Go files
+	GeneratedPatterns []string `json:"generatedPatterns"` // Name patterns (nil = DefaultGeneratedFilePatterns)
+
+	// Importance options
+	ImportanceRules []ImportanceRule `json:"importanceRules"` // Checked before the language score
+
 	// Performance options
 	Concurrent bool `json:"concurrent"` // Whether to use concurrent processing
-	MaxWorkers int  `json:"maxWorkers"` // Maximum number of worker goroutines
+	MaxWorkers int  `json:"maxWorkers"` // Maximum number of worker goroutines; 0 uses one per CPU
 }
 
 // DefaultScanOptions returns default scanning options
@@ -135,14 +179,17 @@ func DefaultScanOptions() *ScanOptions {
 			// OS files
 			".DS_Store", "Thumbs.db", "desktop.ini",
 		},
-		FollowSymlinks:  false,
-		MaxDepth:        0, // unlimited
-		MaxFiles:        10000,
-		AnalyzeContent:  true,
-		MaxFileSize:     1024 * 1024, // 1MB
-		SkipBinaryFiles: true,
-		Concurrent:      true,
-		MaxWorkers:      4,
+		FollowSymlinks:   false,
+		MaxDepth:         0, // unlimited
+		MaxFiles:         10000,
+		AnalyzeContent:   true,
+		MaxFileSize:      1024 * 1024, // 1MB
+		SkipBinaryFiles:  true,
+		ExcludeSecrets:   true,
+		SecretPatterns:   DefaultSecretFilePatterns(),
+		ExcludeGenerated: true,
+		Concurrent:       true,
+		MaxWorkers:       runtime.NumCPU(),
 	}
 }
 
@@ -168,6 +215,28 @@ type LanguageInfo struct {
 	Importance int          `json:"importance"` // 1-5 scale
 }
 
+// wellKnownFileNames maps extensionless file names to the extension used to classify them
+var wellKnownFileNames = map[string]string{
+	"Makefile":      ".makefile",
+	"makefile":      ".makefile",
+	"GNUmakefile":   ".makefile",
+	"Dockerfile":    ".dockerfile",
+	"Containerfile": ".dockerfile",
+}
+
+// GetFileExtension returns the lowercased extension of a file name, or the classification
+// extension for well-known extensionless files such as Makefile and Dockerfile
+func GetFileExtension(name string) string {
+	if ext, ok := wellKnownFileNames[name]; ok {
+		return ext
+	}
+	// Variants such as Dockerfile.dev
+	if strings.HasPrefix(name, "Dockerfile.") {
+		return ".dockerfile"
+	}
+	return strings.ToLower(filepath.Ext(name))
+}
+
 // GetLanguageByExtension returns language information for a file extension
 func GetLanguageByExtension(ext string) *LanguageInfo {
 	languages := map[string]*LanguageInfo{
@@ -214,7 +283,7 @@ func GetLanguageByExtension(ext string) *LanguageInfo {
 			Category:   CategoryBuild,
 			Importance: 3,
 		},
-		".makefile": {Name: "Makefile", Extensions: []string{".makefile"}, Category: CategoryBuild, Importance: 3},
+		".makefile": {Name: "Makefile", Extensions: []string{".makefile"}, Category: CategoryConfig, Importance: 3},
 
 		// Shell scripts
 		".sh":   {Name: "Shell", Extensions: []string{".sh"}, Category: CategoryCode, Importance: 3},
@@ -0,0 +1,53 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	// DefaultServiceName is reported as service.name when none is configured
+	DefaultServiceName = "deepwiki"
+	// DefaultOTLPTimeout bounds a single export request
+	DefaultOTLPTimeout = 10 * time.Second
+
+	otlpTracesPath = "/v1/traces"
+)
+
+// OTLPOptions configures the OTLP exporter
+type OTLPOptions struct {
+	Endpoint string            // Collector URL, e.g. http://localhost:4318
+	Headers  map[string]string // Extra request headers, e.g. for authentication
+	Timeout  time.Duration
+}
+
+// NewOTLPExporter creates an exporter sending spans to the collector at options.Endpoint with
+// OTLP over HTTP. The traces path is appended unless the endpoint already ends with it.
+func NewOTLPExporter(options OTLPOptions) (sdktrace.SpanExporter, error) {
+	if options.Endpoint == "" {
+		return nil, fmt.Errorf("OTLP endpoint is required")
+	}
+
+	url := strings.TrimSuffix(options.Endpoint, "/")
+	if !strings.HasSuffix(url, otlpTracesPath) {
+		url += otlpTracesPath
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultOTLPTimeout
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(),
+		otlptracehttp.WithEndpointURL(url),
+		otlptracehttp.WithHeaders(options.Headers),
+		otlptracehttp.WithTimeout(options.Timeout),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+	return exporter, nil
+}
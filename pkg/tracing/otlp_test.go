@@ -0,0 +1,115 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestOTLPExporter_ExportSpans(t *testing.T) {
+	var (
+		path    string
+		header  string
+		request collectortrace.ExportTraceServiceRequest
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		header = r.Header.Get("Authorization")
+		body, err := io.ReadAll(r.Body)
+		if err == nil {
+			err = proto.Unmarshal(body, &request)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	exporter, err := NewOTLPExporter(OTLPOptions{
+		Endpoint: server.URL,
+		Headers:  map[string]string{"Authorization": "Bearer token"},
+	})
+	if err != nil {
+		t.Fatalf("NewOTLPExporter failed: %v", err)
+	}
+
+	tracer := NewTracer(exporter, "test-service")
+	_, span := tracer.Start(context.Background(), "generate", Int("pages", 4), Bool("cached", false))
+	span.RecordError(errors.New("rate limited"))
+	span.End()
+
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	if path != "/v1/traces" {
+		t.Errorf("Expected POST to /v1/traces, got %q", path)
+	}
+	if header != "Bearer token" {
+		t.Errorf("Expected configured header to be sent, got %q", header)
+	}
+
+	resourceSpans := request.GetResourceSpans()
+	if len(resourceSpans) != 1 || len(resourceSpans[0].GetScopeSpans()) != 1 {
+		t.Fatalf("Unexpected request shape: %v", &request)
+	}
+	var service string
+	for _, attr := range resourceSpans[0].GetResource().GetAttributes() {
+		if attr.GetKey() == "service.name" {
+			service = attr.GetValue().GetStringValue()
+		}
+	}
+	if service != "test-service" {
+		t.Errorf("Expected service.name 'test-service', got %q", service)
+	}
+
+	spans := resourceSpans[0].GetScopeSpans()[0].GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	exported := spans[0]
+	if exported.GetName() != "generate" {
+		t.Errorf("Expected span name 'generate', got %q", exported.GetName())
+	}
+	if status := exported.GetStatus(); status.GetCode() != tracepb.Status_STATUS_CODE_ERROR ||
+		status.GetMessage() != "rate limited" {
+		t.Errorf("Expected error status, got %v", status)
+	}
+	if attrs := exported.GetAttributes(); len(attrs) != 2 ||
+		attrs[0].GetValue().GetIntValue() != 4 || attrs[1].GetValue().GetBoolValue() {
+		t.Errorf("Expected pages=4 and cached=false attributes, got %v", attrs)
+	}
+}
+
+func TestOTLPExporter_CollectorError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	exporter, err := NewOTLPExporter(OTLPOptions{Endpoint: server.URL + "/v1/traces", Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("NewOTLPExporter failed: %v", err)
+	}
+
+	tracer := NewTracer(exporter, "")
+	_, span := tracer.Start(context.Background(), "scan")
+	span.End()
+	if err := tracer.Shutdown(context.Background()); err == nil {
+		t.Fatal("Expected an error when the collector rejects spans")
+	}
+}
+
+func TestNewOTLPExporter_RequiresEndpoint(t *testing.T) {
+	if _, err := NewOTLPExporter(OTLPOptions{}); err == nil {
+		t.Error("Expected an error without an endpoint")
+	}
+}
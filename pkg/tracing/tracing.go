@@ -0,0 +1,135 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// scopeName is the instrumentation scope of the spans recorded by deepwiki
+const scopeName = "github.com/kuderr/deepwiki"
+
+// Attribute is a key/value pair attached to a span
+type Attribute = attribute.KeyValue
+
+// String returns a string attribute
+func String(key, value string) Attribute {
+	return attribute.String(key, value)
+}
+
+// Int returns an integer attribute
+func Int(key string, value int) Attribute {
+	return attribute.Int(key, value)
+}
+
+// Float64 returns a floating point attribute
+func Float64(key string, value float64) Attribute {
+	return attribute.Float64(key, value)
+}
+
+// Bool returns a boolean attribute
+func Bool(key string, value bool) Attribute {
+	return attribute.Bool(key, value)
+}
+
+// Tracer records spans with the OpenTelemetry SDK. Finished spans are exported in batches by
+// the SDK's background processor, so that ending a span never waits for the collector.
+type Tracer struct {
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer
+}
+
+// NewTracer creates a tracer exporting finished spans to exporter, reporting serviceName as the
+// service.name resource attribute (DefaultServiceName when empty)
+func NewTracer(exporter sdktrace.SpanExporter, serviceName string) *Tracer {
+	if serviceName == "" {
+		serviceName = DefaultServiceName
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(sdkresource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+	return &Tracer{provider: provider, tracer: provider.Tracer(scopeName)}
+}
+
+// Start begins a span as a child of the span in ctx, if any
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	ctx, span := t.tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+	return ctx, &Span{span: span}
+}
+
+// Flush exports all finished spans
+func (t *Tracer) Flush(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	return t.provider.ForceFlush(ctx)
+}
+
+// Shutdown exports the remaining spans and shuts the exporter down. The SDK only reports export
+// failures from a flush, so the spans are flushed first.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	return errors.Join(t.provider.ForceFlush(ctx), t.provider.Shutdown(ctx))
+}
+
+// Span is an operation being traced. A nil span is valid and records nothing.
+type Span struct {
+	span trace.Span
+}
+
+// SetAttributes adds attributes to the span
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.span.SetAttributes(attrs...)
+}
+
+// RecordError records err on the span and marks the span as failed
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+// End finishes the span. Calls after the first are ignored.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.span.End()
+}
+
+type tracerKey struct{}
+
+// ContextWithTracer returns a context whose spans are recorded by tracer
+func ContextWithTracer(ctx context.Context, tracer *Tracer) context.Context {
+	return context.WithValue(ctx, tracerKey{}, tracer)
+}
+
+// TracerFromContext returns the tracer stored in ctx, or nil
+func TracerFromContext(ctx context.Context) *Tracer {
+	tracer, _ := ctx.Value(tracerKey{}).(*Tracer)
+	return tracer
+}
+
+// Start begins a span with the tracer in ctx. Without a tracer it returns ctx and a nil span,
+// so instrumented code pays nothing when tracing is disabled.
+func Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	return TracerFromContext(ctx).Start(ctx, name, attrs...)
+}
@@ -0,0 +1,103 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// spanByName returns the first recorded span with the given name
+func spanByName(t *testing.T, exporter *tracetest.InMemoryExporter, name string) tracetest.SpanStub {
+	t.Helper()
+
+	for _, span := range exporter.GetSpans() {
+		if span.Name == name {
+			return span
+		}
+	}
+	t.Fatalf("Expected a %s span to be exported", name)
+	return tracetest.SpanStub{}
+}
+
+func TestTracer_ParentChild(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tracer := NewTracer(exporter, "")
+	ctx := ContextWithTracer(context.Background(), tracer)
+
+	ctx, parent := Start(ctx, "parent", String("phase", "scan"))
+	_, child := Start(ctx, "child")
+	child.SetAttributes(Int("files", 3))
+	child.End()
+	parent.End()
+
+	if err := tracer.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if spans := exporter.GetSpans(); len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+
+	parentStub := spanByName(t, exporter, "parent")
+	childStub := spanByName(t, exporter, "child")
+	if parentStub.Parent.IsValid() {
+		t.Errorf("Expected root span without parent, got %s", parentStub.Parent.SpanID())
+	}
+	if childStub.Parent.SpanID() != parentStub.SpanContext.SpanID() {
+		t.Errorf("Expected child parent %s, got %s", parentStub.SpanContext.SpanID(), childStub.Parent.SpanID())
+	}
+	if childStub.SpanContext.TraceID() != parentStub.SpanContext.TraceID() {
+		t.Errorf("Expected child to share the trace of its parent")
+	}
+
+	if attrs := parentStub.Attributes; len(attrs) != 1 || attrs[0] != String("phase", "scan") {
+		t.Errorf("Expected phase attribute 'scan', got %v", attrs)
+	}
+	if attrs := childStub.Attributes; len(attrs) != 1 || attrs[0] != Int("files", 3) {
+		t.Errorf("Expected files attribute 3, got %v", attrs)
+	}
+	if service, _ := parentStub.Resource.Set().Value("service.name"); service.AsString() != DefaultServiceName {
+		t.Errorf("Expected service.name %q, got %q", DefaultServiceName, service.AsString())
+	}
+}
+
+func TestStart_WithoutTracer(t *testing.T) {
+	ctx := context.Background()
+
+	spanCtx, span := Start(ctx, "noop")
+	if span != nil {
+		t.Errorf("Expected nil span without a tracer, got %v", span)
+	}
+	if spanCtx != ctx {
+		t.Error("Expected context to be returned unchanged")
+	}
+
+	// A nil span accepts all calls
+	span.SetAttributes(String("key", "value"))
+	span.RecordError(errors.New("ignored"))
+	span.End()
+}
+
+func TestSpan_RecordError(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tracer := NewTracer(exporter, "")
+
+	_, span := tracer.Start(context.Background(), "failing")
+	span.RecordError(errors.New("boom"))
+	span.End()
+	span.End()
+
+	if err := tracer.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	failing := spanByName(t, exporter, "failing")
+	if failing.Status.Code != codes.Error || failing.Status.Description != "boom" {
+		t.Errorf("Expected error status 'boom', got %+v", failing.Status)
+	}
+	if len(failing.Events) != 1 {
+		t.Errorf("Expected the error to be recorded as an event, got %d events", len(failing.Events))
+	}
+}
@@ -0,0 +1,85 @@
+// Package usage combines the token usage and estimated cost of the providers used in a run.
+package usage
+
+import (
+	"github.com/kuderr/deepwiki/pkg/embedding"
+	"github.com/kuderr/deepwiki/pkg/llm"
+)
+
+// LLMUsage is the usage of the LLM provider
+type LLMUsage struct {
+	Provider         string  `json:"provider"`
+	Model            string  `json:"model"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	CacheWriteTokens int     `json:"cache_write_tokens,omitempty"` // Prompt tokens written to the cache
+	CacheReadTokens  int     `json:"cache_read_tokens,omitempty"`  // Prompt tokens read from the cache
+	Throttled        int     `json:"throttled,omitempty"`          // Requests rejected by the rate limit
+	Cost             float64 `json:"cost"`                         // Estimated, in USD
+}
+
+// EmbeddingUsage is the usage of the embedding provider
+type EmbeddingUsage struct {
+	Provider  string  `json:"provider"`
+	Model     string  `json:"model"`
+	Requests  int     `json:"requests"`
+	Tokens    int     `json:"tokens"`
+	Cost      float64 `json:"cost"`       // Estimated, in USD
+	CostKnown bool    `json:"cost_known"` // False when a model's price is unknown
+}
+
+// Report is the combined usage of a run
+type Report struct {
+	LLM       LLMUsage       `json:"llm"`
+	Embedding EmbeddingUsage `json:"embedding"`
+}
+
+// Aggregate collects the usage recorded by the LLM and embedding providers. Either may be nil.
+func Aggregate(llmProvider llm.Provider, embeddingProvider *embedding.UsageTrackingProvider) Report {
+	var report Report
+
+	if llmProvider != nil {
+		stats := llmProvider.GetUsageStats()
+		report.LLM = LLMUsage{
+			Provider:         string(llmProvider.GetProviderType()),
+			Model:            llmProvider.GetModel(),
+			PromptTokens:     stats.PromptTokens,
+			CompletionTokens: stats.CompletionTokens,
+			CacheWriteTokens: stats.CacheWriteTokens,
+			CacheReadTokens:  stats.CacheReadTokens,
+			Throttled:        stats.RateLimits.Throttled,
+			Cost:             stats.EstimatedCost,
+		}
+	}
+
+	report.Embedding.CostKnown = true
+	if embeddingProvider != nil {
+		stats := embeddingProvider.GetUsageStats()
+		report.Embedding = EmbeddingUsage{
+			Provider:  string(embeddingProvider.GetProviderType()),
+			Model:     embeddingProvider.GetModel(),
+			Requests:  stats.Requests,
+			Tokens:    stats.TotalTokens,
+			Cost:      stats.EstimatedCost,
+			CostKnown: stats.CostKnown,
+		}
+	}
+
+	return report
+}
+
+// LLMTokens returns the prompt and completion tokens of the LLM provider
+func (r Report) LLMTokens() int {
+	return r.LLM.PromptTokens + r.LLM.CompletionTokens
+}
+
+// TotalTokens returns the tokens used across all providers
+func (r Report) TotalTokens() int {
+	return r.LLMTokens() + r.Embedding.Tokens
+}
+
+// TotalCost returns the estimated cost in USD across all providers. When the price of an
+// embedding model is unknown, the total covers only the known part.
+func (r Report) TotalCost() float64 {
+	return r.LLM.Cost + r.Embedding.Cost
+}
@@ -0,0 +1,161 @@
+package usage
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/kuderr/deepwiki/pkg/embedding"
+	"github.com/kuderr/deepwiki/pkg/llm"
+)
+
+// mockLLMProvider reports fixed usage for every completion, priced at $1 and $2 per million
+// prompt and completion tokens
+type mockLLMProvider struct {
+	stats llm.TokenCount
+}
+
+func (p *mockLLMProvider) ChatCompletion(
+	ctx context.Context,
+	messages []llm.Message,
+	opts ...llm.ChatCompletionOptions,
+) (*llm.ChatCompletionResponse, error) {
+	usage := llm.Usage{PromptTokens: 1000, CompletionTokens: 250, TotalTokens: 1250}
+	p.stats.PromptTokens += usage.PromptTokens
+	p.stats.CompletionTokens += usage.CompletionTokens
+	p.stats.TotalTokens += usage.TotalTokens
+	p.stats.EstimatedCost += p.EstimateCost(usage.PromptTokens, usage.CompletionTokens)
+	return &llm.ChatCompletionResponse{Model: p.GetModel(), Usage: usage}, nil
+}
+
+func (p *mockLLMProvider) ChatCompletionStream(
+	ctx context.Context,
+	messages []llm.Message,
+	handler llm.StreamHandler,
+	opts ...llm.ChatCompletionOptions,
+) error {
+	return nil
+}
+
+func (p *mockLLMProvider) CountTokens(text string) (int, error) { return len(text) / 4, nil }
+func (p *mockLLMProvider) EstimateCost(promptTokens, completionTokens int) float64 {
+	return float64(promptTokens)*1/1000000 + float64(completionTokens)*2/1000000
+}
+func (p *mockLLMProvider) GetUsageStats() llm.TokenCount     { return p.stats }
+func (p *mockLLMProvider) ResetUsageStats()                  { p.stats = llm.TokenCount{} }
+func (p *mockLLMProvider) GetProviderType() llm.ProviderType { return llm.ProviderOpenAI }
+func (p *mockLLMProvider) GetModel() string                  { return "mock-llm" }
+
+// mockEmbeddingProvider reports usage in its responses unless it is an Ollama provider
+type mockEmbeddingProvider struct {
+	providerType embedding.ProviderType
+	model        string
+}
+
+func (p *mockEmbeddingProvider) CreateEmbeddings(
+	ctx context.Context,
+	texts []string,
+	opts ...embedding.EmbeddingOptions,
+) (*embedding.EmbeddingResponse, error) {
+	response := &embedding.EmbeddingResponse{Model: p.model}
+	if p.providerType != embedding.ProviderOllama {
+		response.Usage = embedding.Usage{PromptTokens: 100 * len(texts), TotalTokens: 100 * len(texts)}
+	}
+	return response, nil
+}
+
+func (p *mockEmbeddingProvider) GetProviderType() embedding.ProviderType { return p.providerType }
+func (p *mockEmbeddingProvider) GetModel() string                        { return p.model }
+func (p *mockEmbeddingProvider) GetDimensions() int                      { return 4 }
+func (p *mockEmbeddingProvider) GetMaxTokens() int                       { return 8192 }
+func (p *mockEmbeddingProvider) EstimateTokens(text string) int          { return len(text) }
+func (p *mockEmbeddingProvider) SplitTextForEmbedding(text string, maxTokens int) []string {
+	return []string{text}
+}
+
+func newTrackedEmbeddings(t *testing.T, provider embedding.Provider) *embedding.UsageTrackingProvider {
+	t.Helper()
+
+	tracked, err := embedding.NewUsageTrackingProvider(provider)
+	if err != nil {
+		t.Fatalf("NewUsageTrackingProvider failed: %v", err)
+	}
+	return tracked
+}
+
+func TestAggregate_SumsProviderUsage(t *testing.T) {
+	ctx := context.Background()
+	llmProvider := &mockLLMProvider{}
+	for range 3 {
+		if _, err := llmProvider.ChatCompletion(ctx, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	embeddingProvider := newTrackedEmbeddings(t, &mockEmbeddingProvider{
+		providerType: embedding.ProviderOpenAI,
+		model:        "text-embedding-3-small",
+	})
+	for _, batch := range [][]string{{"a", "b"}, {"c", "d", "e"}} {
+		if _, err := embeddingProvider.CreateEmbeddings(ctx, batch); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report := Aggregate(llmProvider, embeddingProvider)
+
+	if report.LLM.PromptTokens != 3000 || report.LLM.CompletionTokens != 750 {
+		t.Errorf("Expected 3000 prompt and 750 completion tokens, got %+v", report.LLM)
+	}
+	if report.Embedding.Tokens != 500 || report.Embedding.Requests != 2 {
+		t.Errorf("Expected 500 embedding tokens in 2 requests, got %+v", report.Embedding)
+	}
+	if report.TotalTokens() != 3000+750+500 {
+		t.Errorf("Expected 4250 total tokens, got %d", report.TotalTokens())
+	}
+
+	llmCost := llmProvider.GetUsageStats().EstimatedCost
+	embeddingCost, _ := embedding.EstimateCost(embedding.ProviderOpenAI, "text-embedding-3-small", 500)
+	if !almostEqual(report.LLM.Cost, llmCost) || !almostEqual(report.Embedding.Cost, embeddingCost) {
+		t.Errorf("Expected costs %f and %f, got %+v", llmCost, embeddingCost, report)
+	}
+	if !report.Embedding.CostKnown || !almostEqual(report.TotalCost(), llmCost+embeddingCost) {
+		t.Errorf("Expected total cost %f, got %f", llmCost+embeddingCost, report.TotalCost())
+	}
+	if report.LLM.Model != "mock-llm" || report.Embedding.Provider != "openai" {
+		t.Errorf("Expected provider details in the report, got %+v", report)
+	}
+}
+
+func TestAggregate_EstimatesMissingUsage(t *testing.T) {
+	ollama := newTrackedEmbeddings(t, &mockEmbeddingProvider{
+		providerType: embedding.ProviderOllama,
+		model:        "nomic-embed-text",
+	})
+	if _, err := ollama.CreateEmbeddings(context.Background(), []string{"four", "sixsix"}); err != nil {
+		t.Fatal(err)
+	}
+
+	report := Aggregate(nil, ollama)
+	if report.Embedding.Tokens != 10 {
+		t.Errorf("Expected 10 estimated tokens, got %d", report.Embedding.Tokens)
+	}
+	if !report.Embedding.CostKnown || report.TotalCost() != 0 {
+		t.Errorf("Expected local embeddings to be free, got %+v", report.Embedding)
+	}
+
+	unpriced := newTrackedEmbeddings(t, &mockEmbeddingProvider{
+		providerType: embedding.ProviderOpenAI,
+		model:        "custom-model",
+	})
+	if _, err := unpriced.CreateEmbeddings(context.Background(), []string{"a"}); err != nil {
+		t.Fatal(err)
+	}
+	if report := Aggregate(nil, unpriced); report.Embedding.CostKnown {
+		t.Errorf("Expected the cost of an unknown model to be unknown, got %+v", report.Embedding)
+	}
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-12
+}
@@ -0,0 +1,184 @@
+// Package watch reports file changes below a directory tree once they settle.
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/kuderr/deepwiki/internal/logging"
+)
+
+// DefaultDebounce is how long the tree must be quiet before changes are reported
+const DefaultDebounce = 500 * time.Millisecond
+
+// Options configures a Watcher
+type Options struct {
+	// Debounce is the quiet period after the last change before a batch is reported
+	// (0 = DefaultDebounce)
+	Debounce time.Duration
+
+	// Ignore reports whether an absolute path is skipped; ignored directories are not watched
+	Ignore func(path string, isDir bool) bool
+}
+
+// Watcher watches a directory tree and reports batches of changed files
+type Watcher struct {
+	root     string
+	debounce time.Duration
+	ignore   func(path string, isDir bool) bool
+	notify   *fsnotify.Watcher
+	logger   *logging.Logger
+}
+
+// New creates a watcher for every directory below root that is not ignored
+func New(root string, options Options) (*Watcher, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve watch root: %w", err)
+	}
+
+	notify, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file watcher: %w", err)
+	}
+
+	w := &Watcher{
+		root:     absRoot,
+		debounce: options.Debounce,
+		ignore:   options.Ignore,
+		notify:   notify,
+		logger:   logging.GetGlobalLogger().WithComponent("watch"),
+	}
+	if w.debounce <= 0 {
+		w.debounce = DefaultDebounce
+	}
+	if w.ignore == nil {
+		w.ignore = func(string, bool) bool { return false }
+	}
+
+	if err := w.addTree(absRoot, nil); err != nil {
+		notify.Close()
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Run reports changed files, relative to the root and sorted, to onChange once no further change
+// has arrived for the debounce period. It blocks until ctx is done. onChange runs on the watching
+// goroutine, so changes made while it runs are reported in the next batch.
+func (w *Watcher) Run(ctx context.Context, onChange func(changed []string)) error {
+	defer w.notify.Close()
+
+	pending := make(map[string]bool)
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-w.notify.Events:
+			if !ok {
+				return nil
+			}
+			if !w.record(event, pending) {
+				continue
+			}
+			timer.Reset(w.debounce)
+
+		case err, ok := <-w.notify.Errors:
+			if !ok {
+				return nil
+			}
+			w.logger.LogError(ctx, "file watcher error", err)
+
+		case <-timer.C:
+			if len(pending) == 0 {
+				continue
+			}
+			changed := make([]string, 0, len(pending))
+			for path := range pending {
+				changed = append(changed, path)
+			}
+			slices.Sort(changed)
+			clear(pending)
+
+			w.logger.InfoContext(ctx, "detected changes", slog.Int("files", len(changed)))
+			onChange(changed)
+		}
+	}
+}
+
+// record adds the file changed by event to pending and starts watching new directories. It
+// returns false for events that are ignored.
+func (w *Watcher) record(event fsnotify.Event, pending map[string]bool) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+
+	info, err := os.Stat(event.Name)
+	isDir := err == nil && info.IsDir()
+	if w.ignore(event.Name, isDir) {
+		return false
+	}
+
+	if isDir {
+		// Directories are not reported themselves, but the files already created in them are
+		if !event.Has(fsnotify.Create) {
+			return false
+		}
+		before := len(pending)
+		if err := w.addTree(event.Name, pending); err != nil {
+			w.logger.LogError(context.Background(), "failed to watch new directory", err,
+				slog.String("path", event.Name))
+		}
+		return len(pending) > before
+	}
+
+	w.addPending(event.Name, pending)
+	return true
+}
+
+// addPending records a changed file by its slash-separated path relative to the root
+func (w *Watcher) addPending(path string, pending map[string]bool) {
+	if relPath, err := filepath.Rel(w.root, path); err == nil {
+		pending[filepath.ToSlash(relPath)] = true
+	}
+}
+
+// addTree watches dir and every directory below it that is not ignored. Files found on the way
+// are added to pending unless it is nil.
+func (w *Watcher) addTree(dir string, pending map[string]bool) error {
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// The directory may have been removed since the event
+			if path != dir && os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !entry.IsDir() {
+			if pending != nil && !w.ignore(path, false) {
+				w.addPending(path, pending)
+			}
+			return nil
+		}
+		if path != w.root && w.ignore(path, true) {
+			return filepath.SkipDir
+		}
+		if err := w.notify.Add(path); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,85 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatcher_ReportsChangesAfterDebounce(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(root, "vendor"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	debounce := 100 * time.Millisecond
+	watcher, err := New(root, Options{
+		Debounce: debounce,
+		Ignore: func(path string, isDir bool) bool {
+			return strings.Contains(filepath.ToSlash(path), "/vendor")
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	batches := make(chan []string, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- watcher.Run(ctx, func(changed []string) { batches <- changed })
+	}()
+
+	// Changes in ignored directories are never reported
+	if err := os.WriteFile(filepath.Join(root, "vendor", "lib.go"), []byte("package lib\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	touched := time.Now()
+	mainGo := []byte("package main\n\nfunc main() {}\n")
+	if err := os.WriteFile(filepath.Join(root, "main.go"), mainGo, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case changed := <-batches:
+		if elapsed := time.Since(touched); elapsed < debounce {
+			t.Errorf("changes reported after %v, before the %v debounce", elapsed, debounce)
+		}
+		if !slices.Equal(changed, []string{"main.go"}) {
+			t.Errorf("changed = %v, want [main.go]", changed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no changes reported")
+	}
+
+	// A new directory is watched and the files in it are reported
+	if err := os.MkdirAll(filepath.Join(root, "pkg", "util"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "pkg", "util", "util.go"), []byte("package util\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case changed := <-batches:
+		if !slices.Equal(changed, []string{"pkg/util/util.go"}) {
+			t.Errorf("changed = %v, want [pkg/util/util.go]", changed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no changes reported for the new directory")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run() error = %v", err)
+	}
+}